@@ -33,21 +33,73 @@ type ClientOption func(*runtime.ClientOperation)
 
 // ClientService is the interface for Client methods
 type ClientService interface {
+	DeleteCoordinatorMac(params *DeleteCoordinatorMacParams, opts ...ClientOption) (*DeleteCoordinatorMacOK, error)
+
 	DeleteIpamIP(params *DeleteIpamIPParams, opts ...ClientOption) (*DeleteIpamIPOK, error)
 
 	DeleteIpamIps(params *DeleteIpamIpsParams, opts ...ClientOption) (*DeleteIpamIpsOK, error)
 
 	GetCoordinatorConfig(params *GetCoordinatorConfigParams, opts ...ClientOption) (*GetCoordinatorConfigOK, error)
 
+	GetCoordinatorMac(params *GetCoordinatorMacParams, opts ...ClientOption) (*GetCoordinatorMacOK, error)
+
 	GetWorkloadendpoint(params *GetWorkloadendpointParams, opts ...ClientOption) (*GetWorkloadendpointOK, error)
 
+	PostCoordinatorMac(params *PostCoordinatorMacParams, opts ...ClientOption) (*PostCoordinatorMacOK, error)
+
+	PostIpamDryRun(params *PostIpamDryRunParams, opts ...ClientOption) (*PostIpamDryRunOK, error)
+
 	PostIpamIP(params *PostIpamIPParams, opts ...ClientOption) (*PostIpamIPOK, error)
 
+	PostIpamIPConflict(params *PostIpamIPConflictParams, opts ...ClientOption) (*PostIpamIPConflictOK, error)
+
 	PostIpamIps(params *PostIpamIpsParams, opts ...ClientOption) (*PostIpamIpsOK, error)
 
 	SetTransport(transport runtime.ClientTransport)
 }
 
+/*
+	DeleteCoordinatorMac releases the MAC address of the pod
+
+	Send a request to daemonset to release the MAC address assigned to
+
+the interface of the pod
+*/
+func (a *Client) DeleteCoordinatorMac(params *DeleteCoordinatorMacParams, opts ...ClientOption) (*DeleteCoordinatorMacOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewDeleteCoordinatorMacParams()
+	}
+	op := &runtime.ClientOperation{
+		ID:                 "DeleteCoordinatorMac",
+		Method:             "DELETE",
+		PathPattern:        "/coordinator/mac",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http"},
+		Params:             params,
+		Reader:             &DeleteCoordinatorMacReader{formats: a.formats},
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	}
+	for _, opt := range opts {
+		opt(op)
+	}
+
+	result, err := a.transport.Submit(op)
+	if err != nil {
+		return nil, err
+	}
+	success, ok := result.(*DeleteCoordinatorMacOK)
+	if ok {
+		return success, nil
+	}
+	// unexpected success response
+	// safeguard: normally, absent a default response, unknown success responses return an error above: so this is a codegen issue
+	msg := fmt.Sprintf("unexpected success response for DeleteCoordinatorMac: API contract not enforced by server. Client expected to get an error, but got: %T", result)
+	panic(msg)
+}
+
 /*
 DeleteIpamIP deletes ip from spiderpool daemon
 
@@ -168,6 +220,48 @@ func (a *Client) GetCoordinatorConfig(params *GetCoordinatorConfigParams, opts .
 	panic(msg)
 }
 
+/*
+	GetCoordinatorMac lists the MAC addresses assigned to the pods
+
+	List the MAC addresses assigned to the pods on the node by the
+
+coordinator podMACPrefix, for debugging
+*/
+func (a *Client) GetCoordinatorMac(params *GetCoordinatorMacParams, opts ...ClientOption) (*GetCoordinatorMacOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewGetCoordinatorMacParams()
+	}
+	op := &runtime.ClientOperation{
+		ID:                 "GetCoordinatorMac",
+		Method:             "GET",
+		PathPattern:        "/coordinator/mac",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http"},
+		Params:             params,
+		Reader:             &GetCoordinatorMacReader{formats: a.formats},
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	}
+	for _, opt := range opts {
+		opt(op)
+	}
+
+	result, err := a.transport.Submit(op)
+	if err != nil {
+		return nil, err
+	}
+	success, ok := result.(*GetCoordinatorMacOK)
+	if ok {
+		return success, nil
+	}
+	// unexpected success response
+	// safeguard: normally, absent a default response, unknown success responses return an error above: so this is a codegen issue
+	msg := fmt.Sprintf("unexpected success response for GetCoordinatorMac: API contract not enforced by server. Client expected to get an error, but got: %T", result)
+	panic(msg)
+}
+
 /*
 GetWorkloadendpoint gets workloadendpoint status
 
@@ -208,6 +302,91 @@ func (a *Client) GetWorkloadendpoint(params *GetWorkloadendpointParams, opts ...
 	panic(msg)
 }
 
+/*
+	PostCoordinatorMac assigns a MAC address to the pod
+
+	Send a request to daemonset to assign a MAC address with the
+
+podMACPrefix to the interface of the pod, which collides with none
+of the MAC addresses assigned to the other pods on the node
+*/
+func (a *Client) PostCoordinatorMac(params *PostCoordinatorMacParams, opts ...ClientOption) (*PostCoordinatorMacOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewPostCoordinatorMacParams()
+	}
+	op := &runtime.ClientOperation{
+		ID:                 "PostCoordinatorMac",
+		Method:             "POST",
+		PathPattern:        "/coordinator/mac",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http"},
+		Params:             params,
+		Reader:             &PostCoordinatorMacReader{formats: a.formats},
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	}
+	for _, opt := range opts {
+		opt(op)
+	}
+
+	result, err := a.transport.Submit(op)
+	if err != nil {
+		return nil, err
+	}
+	success, ok := result.(*PostCoordinatorMacOK)
+	if ok {
+		return success, nil
+	}
+	// unexpected success response
+	// safeguard: normally, absent a default response, unknown success responses return an error above: so this is a codegen issue
+	msg := fmt.Sprintf("unexpected success response for PostCoordinatorMac: API contract not enforced by server. Client expected to get an error, but got: %T", result)
+	panic(msg)
+}
+
+/*
+	PostIpamDryRun dries run ip allocation
+
+	Run the IPPool selection and capacity check of the ip allocation for
+
+some replicas of a pod, without allocating any ip
+*/
+func (a *Client) PostIpamDryRun(params *PostIpamDryRunParams, opts ...ClientOption) (*PostIpamDryRunOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewPostIpamDryRunParams()
+	}
+	op := &runtime.ClientOperation{
+		ID:                 "PostIpamDryRun",
+		Method:             "POST",
+		PathPattern:        "/ipam/dry-run",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http"},
+		Params:             params,
+		Reader:             &PostIpamDryRunReader{formats: a.formats},
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	}
+	for _, opt := range opts {
+		opt(op)
+	}
+
+	result, err := a.transport.Submit(op)
+	if err != nil {
+		return nil, err
+	}
+	success, ok := result.(*PostIpamDryRunOK)
+	if ok {
+		return success, nil
+	}
+	// unexpected success response
+	// safeguard: normally, absent a default response, unknown success responses return an error above: so this is a codegen issue
+	msg := fmt.Sprintf("unexpected success response for PostIpamDryRun: API contract not enforced by server. Client expected to get an error, but got: %T", result)
+	panic(msg)
+}
+
 /*
 PostIpamIP gets ip from spiderpool daemon
 
@@ -248,6 +427,48 @@ func (a *Client) PostIpamIP(params *PostIpamIPParams, opts ...ClientOption) (*Po
 	panic(msg)
 }
 
+/*
+	PostIpamIPConflict detects ip conflict on the node
+
+	Send a request to daemonset to detect whether the ip still answers
+
+ARP or NDP on the node, before the ip is released by force
+*/
+func (a *Client) PostIpamIPConflict(params *PostIpamIPConflictParams, opts ...ClientOption) (*PostIpamIPConflictOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewPostIpamIPConflictParams()
+	}
+	op := &runtime.ClientOperation{
+		ID:                 "PostIpamIPConflict",
+		Method:             "POST",
+		PathPattern:        "/ipam/ip/conflict",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http"},
+		Params:             params,
+		Reader:             &PostIpamIPConflictReader{formats: a.formats},
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	}
+	for _, opt := range opts {
+		opt(op)
+	}
+
+	result, err := a.transport.Submit(op)
+	if err != nil {
+		return nil, err
+	}
+	success, ok := result.(*PostIpamIPConflictOK)
+	if ok {
+		return success, nil
+	}
+	// unexpected success response
+	// safeguard: normally, absent a default response, unknown success responses return an error above: so this is a codegen issue
+	msg := fmt.Sprintf("unexpected success response for PostIpamIPConflict: API contract not enforced by server. Client expected to get an error, but got: %T", result)
+	panic(msg)
+}
+
 /*
 PostIpamIps assigns multiple ip as a batch
 
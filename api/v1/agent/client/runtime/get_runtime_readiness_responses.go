@@ -10,9 +10,12 @@ package runtime
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/strfmt"
+
+	"github.com/spidernet-io/spiderpool/api/v1/agent/models"
 )
 
 // GetRuntimeReadinessReader is a Reader for the GetRuntimeReadiness structure.
@@ -29,8 +32,8 @@ func (o *GetRuntimeReadinessReader) ReadResponse(response runtime.ClientResponse
 			return nil, err
 		}
 		return result, nil
-	case 500:
-		result := NewGetRuntimeReadinessInternalServerError()
+	case 503:
+		result := NewGetRuntimeReadinessServiceUnavailable()
 		if err := result.readResponse(response, consumer, o.formats); err != nil {
 			return nil, err
 		}
@@ -91,53 +94,65 @@ func (o *GetRuntimeReadinessOK) readResponse(response runtime.ClientResponse, co
 	return nil
 }
 
-// NewGetRuntimeReadinessInternalServerError creates a GetRuntimeReadinessInternalServerError with default headers values
-func NewGetRuntimeReadinessInternalServerError() *GetRuntimeReadinessInternalServerError {
-	return &GetRuntimeReadinessInternalServerError{}
+// NewGetRuntimeReadinessServiceUnavailable creates a GetRuntimeReadinessServiceUnavailable with default headers values
+func NewGetRuntimeReadinessServiceUnavailable() *GetRuntimeReadinessServiceUnavailable {
+	return &GetRuntimeReadinessServiceUnavailable{}
 }
 
 /*
-GetRuntimeReadinessInternalServerError describes a response with status code 500, with default header values.
+GetRuntimeReadinessServiceUnavailable describes a response with status code 503, with default header values.
 
 Failed
 */
-type GetRuntimeReadinessInternalServerError struct {
+type GetRuntimeReadinessServiceUnavailable struct {
+	Payload *models.ReadinessStatus
 }
 
-// IsSuccess returns true when this get runtime readiness internal server error response has a 2xx status code
-func (o *GetRuntimeReadinessInternalServerError) IsSuccess() bool {
+// IsSuccess returns true when this get runtime readiness service unavailable response has a 2xx status code
+func (o *GetRuntimeReadinessServiceUnavailable) IsSuccess() bool {
 	return false
 }
 
-// IsRedirect returns true when this get runtime readiness internal server error response has a 3xx status code
-func (o *GetRuntimeReadinessInternalServerError) IsRedirect() bool {
+// IsRedirect returns true when this get runtime readiness service unavailable response has a 3xx status code
+func (o *GetRuntimeReadinessServiceUnavailable) IsRedirect() bool {
 	return false
 }
 
-// IsClientError returns true when this get runtime readiness internal server error response has a 4xx status code
-func (o *GetRuntimeReadinessInternalServerError) IsClientError() bool {
+// IsClientError returns true when this get runtime readiness service unavailable response has a 4xx status code
+func (o *GetRuntimeReadinessServiceUnavailable) IsClientError() bool {
 	return false
 }
 
-// IsServerError returns true when this get runtime readiness internal server error response has a 5xx status code
-func (o *GetRuntimeReadinessInternalServerError) IsServerError() bool {
+// IsServerError returns true when this get runtime readiness service unavailable response has a 5xx status code
+func (o *GetRuntimeReadinessServiceUnavailable) IsServerError() bool {
 	return true
 }
 
-// IsCode returns true when this get runtime readiness internal server error response a status code equal to that given
-func (o *GetRuntimeReadinessInternalServerError) IsCode(code int) bool {
-	return code == 500
+// IsCode returns true when this get runtime readiness service unavailable response a status code equal to that given
+func (o *GetRuntimeReadinessServiceUnavailable) IsCode(code int) bool {
+	return code == 503
+}
+
+func (o *GetRuntimeReadinessServiceUnavailable) Error() string {
+	return fmt.Sprintf("[GET /runtime/readiness][%d] getRuntimeReadinessServiceUnavailable  %+v", 503, o.Payload)
 }
 
-func (o *GetRuntimeReadinessInternalServerError) Error() string {
-	return fmt.Sprintf("[GET /runtime/readiness][%d] getRuntimeReadinessInternalServerError ", 500)
+func (o *GetRuntimeReadinessServiceUnavailable) String() string {
+	return fmt.Sprintf("[GET /runtime/readiness][%d] getRuntimeReadinessServiceUnavailable  %+v", 503, o.Payload)
 }
 
-func (o *GetRuntimeReadinessInternalServerError) String() string {
-	return fmt.Sprintf("[GET /runtime/readiness][%d] getRuntimeReadinessInternalServerError ", 500)
+func (o *GetRuntimeReadinessServiceUnavailable) GetPayload() *models.ReadinessStatus {
+	return o.Payload
 }
 
-func (o *GetRuntimeReadinessInternalServerError) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+func (o *GetRuntimeReadinessServiceUnavailable) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	o.Payload = new(models.ReadinessStatus)
+
+	// response payload
+	if err := consumer.Consume(response.Body(), o.Payload); err != nil && err != io.EOF {
+		return err
+	}
 
 	return nil
 }
@@ -10,6 +10,7 @@ package models
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
@@ -22,15 +23,33 @@ import (
 // swagger:model CoordinatorConfig
 type CoordinatorConfig struct {
 
+	// default route mode
+	DefaultRouteMode string `json:"defaultRouteMode,omitempty"`
+
+	// default route weight
+	DefaultRouteWeight int64 `json:"defaultRouteWeight,omitempty"`
+
 	// detect gateway
 	DetectGateway bool `json:"detectGateway,omitempty"`
 
+	// detect gateway mode
+	DetectGatewayMode string `json:"detectGatewayMode,omitempty"`
+
 	// detect IP conflict
 	DetectIPConflict bool `json:"detectIPConflict,omitempty"`
 
+	// enable proxy n d p
+	EnableProxyNDP bool `json:"enableProxyNDP,omitempty"`
+
+	// enable reply via veth
+	EnableReplyViaVeth bool `json:"enableReplyViaVeth,omitempty"`
+
 	// hijack c ID r
 	HijackCIDR []string `json:"hijackCIDR"`
 
+	// hijack route m t u
+	HijackRouteMTU int64 `json:"hijackRouteMTU,omitempty"`
+
 	// host r p filter
 	HostRPFilter int64 `json:"hostRPFilter,omitempty"`
 
@@ -54,6 +73,12 @@ type CoordinatorConfig struct {
 	// pod n i cs
 	PodNICs []string `json:"podNICs"`
 
+	// pod routes
+	PodRoutes []*Route `json:"podRoutes"`
+
+	// route table mode
+	RouteTableMode string `json:"routeTableMode,omitempty"`
+
 	// service c ID r
 	// Required: true
 	ServiceCIDR []string `json:"serviceCIDR"`
@@ -75,6 +100,10 @@ func (m *CoordinatorConfig) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validatePodRoutes(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateServiceCIDR(formats); err != nil {
 		res = append(res, err)
 	}
@@ -107,6 +136,32 @@ func (m *CoordinatorConfig) validateOverlayPodCIDR(formats strfmt.Registry) erro
 	return nil
 }
 
+func (m *CoordinatorConfig) validatePodRoutes(formats strfmt.Registry) error {
+	if swag.IsZero(m.PodRoutes) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.PodRoutes); i++ {
+		if swag.IsZero(m.PodRoutes[i]) { // not required
+			continue
+		}
+
+		if m.PodRoutes[i] != nil {
+			if err := m.PodRoutes[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("podRoutes" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("podRoutes" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
 func (m *CoordinatorConfig) validateServiceCIDR(formats strfmt.Registry) error {
 
 	if err := validate.Required("serviceCIDR", "body", m.ServiceCIDR); err != nil {
@@ -125,8 +180,37 @@ func (m *CoordinatorConfig) validateTunePodRoutes(formats strfmt.Registry) error
 	return nil
 }
 
-// ContextValidate validates this coordinator config based on context it is used
+// ContextValidate validate this coordinator config based on the context it is used
 func (m *CoordinatorConfig) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidatePodRoutes(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *CoordinatorConfig) contextValidatePodRoutes(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.PodRoutes); i++ {
+
+		if m.PodRoutes[i] != nil {
+			if err := m.PodRoutes[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("podRoutes" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("podRoutes" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
 	return nil
 }
 
@@ -20,6 +20,9 @@ import (
 // swagger:model GetCoordinatorArgs
 type GetCoordinatorArgs struct {
 
+	// The CNI command which gets the config, ADD or DEL
+	CniCommand string `json:"cniCommand,omitempty"`
+
 	// pod name
 	PodName string `json:"podName,omitempty"`
 
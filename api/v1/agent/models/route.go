@@ -33,6 +33,9 @@ type Route struct {
 	// if name
 	// Required: true
 	IfName *string `json:"ifName"`
+
+	// table
+	Table string `json:"table,omitempty"`
 }
 
 // Validate validates this route
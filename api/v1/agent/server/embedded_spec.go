@@ -69,6 +69,125 @@ func init() {
         }
       }
     },
+    "/coordinator/mac": {
+      "get": {
+        "description": "List the MAC addresses assigned to the pods on the node by the\ncoordinator podMACPrefix, for debugging\n",
+        "tags": [
+          "daemonset"
+        ],
+        "summary": "List the MAC addresses assigned to the pods",
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/PodMacList"
+            }
+          },
+          "500": {
+            "description": "Failed to list the MAC addresses",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
+          }
+        }
+      },
+      "post": {
+        "description": "Send a request to daemonset to assign a MAC address with the\npodMACPrefix to the interface of the pod, which collides with none\nof the MAC addresses assigned to the other pods on the node\n",
+        "tags": [
+          "daemonset"
+        ],
+        "summary": "Assign a MAC address to the pod",
+        "parameters": [
+          {
+            "name": "pod-mac-args",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "$ref": "#/definitions/PodMacArgs"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/PodMac"
+            }
+          },
+          "500": {
+            "description": "MAC address assignment failure",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
+          }
+        }
+      },
+      "delete": {
+        "description": "Send a request to daemonset to release the MAC address assigned to\nthe interface of the pod\n",
+        "tags": [
+          "daemonset"
+        ],
+        "summary": "Release the MAC address of the pod",
+        "parameters": [
+          {
+            "name": "pod-mac-args",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "$ref": "#/definitions/PodMacArgs"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Success"
+          },
+          "500": {
+            "description": "MAC address release failure",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
+          }
+        }
+      }
+    },
+    "/ipam/dry-run": {
+      "post": {
+        "description": "Run the IPPool selection and capacity check of the ip allocation for\nsome replicas of a pod, without allocating any ip\n",
+        "tags": [
+          "daemonset"
+        ],
+        "summary": "Dry-run ip allocation",
+        "parameters": [
+          {
+            "name": "ipam-dry-run-args",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "$ref": "#/definitions/IpamDryRunArgs"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/IpamDryRunResponse"
+            }
+          },
+          "500": {
+            "description": "Dry-run failure",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
+          }
+        }
+      }
+    },
     "/ipam/healthy": {
       "get": {
         "description": "Check spiderpool daemonset health to make sure whether it's ready\nfor CNI plugin usage\n",
@@ -149,6 +268,37 @@ func init() {
         }
       }
     },
+    "/ipam/ip/conflict": {
+      "post": {
+        "description": "Send a request to daemonset to detect whether the ip still answers\nARP or NDP on the node, before the ip is released by force\n",
+        "tags": [
+          "daemonset"
+        ],
+        "summary": "Detect ip conflict on the node",
+        "parameters": [
+          {
+            "name": "ipam-ip-conflict-args",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "$ref": "#/definitions/IpamIPConflictArgs"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Success, no one answers for the ip"
+          },
+          "500": {
+            "description": "The ip may be still in use, or failed to detect it",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
+          }
+        }
+      }
+    },
     "/ipam/ips": {
       "post": {
         "description": "Assign multiple ip for a pod, case for spiderflat compent\n",
@@ -217,8 +367,11 @@ func init() {
           "200": {
             "description": "Success"
           },
-          "500": {
-            "description": "Failed"
+          "503": {
+            "description": "Failed",
+            "schema": {
+              "$ref": "#/definitions/ReadinessStatus"
+            }
           }
         }
       }
@@ -269,18 +422,36 @@ func init() {
         "tunePodRoutes"
       ],
       "properties": {
+        "defaultRouteMode": {
+          "type": "string"
+        },
+        "defaultRouteWeight": {
+          "type": "integer"
+        },
         "detectGateway": {
           "type": "boolean"
         },
+        "detectGatewayMode": {
+          "type": "string"
+        },
         "detectIPConflict": {
           "type": "boolean"
         },
+        "enableProxyNDP": {
+          "type": "boolean"
+        },
+        "enableReplyViaVeth": {
+          "type": "boolean"
+        },
         "hijackCIDR": {
           "type": "array",
           "items": {
             "type": "string"
           }
         },
+        "hijackRouteMTU": {
+          "type": "integer"
+        },
         "hostRPFilter": {
           "type": "integer"
         },
@@ -308,6 +479,15 @@ func init() {
             "type": "string"
           }
         },
+        "podRoutes": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/Route"
+          }
+        },
+        "routeTableMode": {
+          "type": "string"
+        },
         "serviceCIDR": {
           "type": "array",
           "items": {
@@ -346,6 +526,72 @@ func init() {
         }
       }
     },
+    "DryRunCandidate": {
+      "description": "IPPools of an IP version in the dry-run result",
+      "type": "object",
+      "required": [
+        "version",
+        "allocatable"
+      ],
+      "properties": {
+        "allocatable": {
+          "type": "integer"
+        },
+        "pools": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/DryRunPool"
+          }
+        },
+        "version": {
+          "type": "integer",
+          "enum": [
+            4,
+            6
+          ]
+        }
+      }
+    },
+    "DryRunInterface": {
+      "description": "IPPool candidates of a NIC in the dry-run result",
+      "type": "object",
+      "required": [
+        "nic"
+      ],
+      "properties": {
+        "candidates": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/DryRunCandidate"
+          }
+        },
+        "nic": {
+          "type": "string"
+        }
+      }
+    },
+    "DryRunPool": {
+      "description": "IPPool in the dry-run result, filteredReason is set if it is filtered out",
+      "type": "object",
+      "required": [
+        "name",
+        "allocatable"
+      ],
+      "properties": {
+        "allocatable": {
+          "type": "integer"
+        },
+        "availableIPCount": {
+          "type": "integer"
+        },
+        "filteredReason": {
+          "type": "string"
+        },
+        "name": {
+          "type": "string"
+        }
+      }
+    },
     "Error": {
       "description": "API error",
       "type": "string"
@@ -354,6 +600,10 @@ func init() {
       "description": "Get Coordinator Args",
       "type": "object",
       "properties": {
+        "cniCommand": {
+          "description": "The CNI command which gets the config, ADD or DEL",
+          "type": "string"
+        },
         "podName": {
           "type": "string"
         },
@@ -498,6 +748,178 @@ func init() {
         }
       }
     },
+    "IpamDryRunArgs": {
+      "description": "IPAM dry-run request args",
+      "type": "object",
+      "required": [
+        "ifName",
+        "podNamespace",
+        "nodeName",
+        "replicas"
+      ],
+      "properties": {
+        "defaultIPv4IPPool": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "defaultIPv6IPPool": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "ifName": {
+          "type": "string"
+        },
+        "nodeName": {
+          "type": "string"
+        },
+        "podAnnotations": {
+          "type": "object",
+          "additionalProperties": {
+            "type": "string"
+          }
+        },
+        "podLabels": {
+          "type": "object",
+          "additionalProperties": {
+            "type": "string"
+          }
+        },
+        "podNamespace": {
+          "type": "string"
+        },
+        "replicas": {
+          "type": "integer",
+          "minimum": 1
+        }
+      }
+    },
+    "IpamDryRunResponse": {
+      "description": "IPAM dry-run result, contains the IPPool candidates of each NIC",
+      "type": "object",
+      "required": [
+        "replicas",
+        "allocatable",
+        "nics"
+      ],
+      "properties": {
+        "allocatable": {
+          "type": "integer"
+        },
+        "dualStack": {
+          "type": "boolean"
+        },
+        "dualStackSatisfied": {
+          "type": "boolean"
+        },
+        "nics": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/DryRunInterface"
+          }
+        },
+        "replicas": {
+          "type": "integer"
+        }
+      }
+    },
+    "IpamIPConflictArgs": {
+      "description": "The ip to detect the conflict of",
+      "type": "object",
+      "required": [
+        "ip"
+      ],
+      "properties": {
+        "ip": {
+          "type": "string"
+        }
+      }
+    },
+    "PodMac": {
+      "description": "The MAC address assigned to the interface of the pod",
+      "type": "object",
+      "required": [
+        "mac"
+      ],
+      "properties": {
+        "assignedTime": {
+          "type": "string"
+        },
+        "ifName": {
+          "type": "string"
+        },
+        "mac": {
+          "type": "string"
+        },
+        "podName": {
+          "type": "string"
+        },
+        "podNamespace": {
+          "type": "string"
+        },
+        "podUID": {
+          "type": "string"
+        },
+        "salt": {
+          "type": "integer"
+        }
+      }
+    },
+    "PodMacArgs": {
+      "description": "The interface of the pod to assign or release the MAC address",
+      "type": "object",
+      "required": [
+        "podNamespace",
+        "podName",
+        "podUID",
+        "ifName"
+      ],
+      "properties": {
+        "ifName": {
+          "type": "string"
+        },
+        "macPrefix": {
+          "type": "string"
+        },
+        "podName": {
+          "type": "string"
+        },
+        "podNamespace": {
+          "type": "string"
+        },
+        "podUID": {
+          "type": "string"
+        }
+      }
+    },
+    "PodMacList": {
+      "description": "The MAC addresses assigned to the pods on the node",
+      "type": "object",
+      "properties": {
+        "items": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/PodMac"
+          }
+        }
+      }
+    },
+    "ReadinessStatus": {
+      "description": "Readiness status of spiderpool-agent",
+      "type": "object",
+      "properties": {
+        "failedComponents": {
+          "description": "The failed components and their errors",
+          "type": "object",
+          "additionalProperties": {
+            "type": "string"
+          }
+        }
+      }
+    },
     "Route": {
       "description": "IPAM CNI types Route",
       "type": "object",
@@ -515,43 +937,165 @@ func init() {
         },
         "ifName": {
           "type": "string"
+        },
+        "table": {
+          "type": "string"
+        }
+      }
+    }
+  },
+  "x-schemes": [
+    "unix"
+  ]
+}`))
+	FlatSwaggerJSON = json.RawMessage([]byte(`{
+  "consumes": [
+    "application/json"
+  ],
+  "produces": [
+    "application/json"
+  ],
+  "swagger": "2.0",
+  "info": {
+    "description": "Spiderpool Agent",
+    "title": "Spiderpool-Agent API",
+    "version": "v1"
+  },
+  "basePath": "/v1",
+  "paths": {
+    "/coordinator/config": {
+      "get": {
+        "description": "Send a request to daemonset to get coordinator config\n",
+        "tags": [
+          "daemonset"
+        ],
+        "summary": "Get coordinator config",
+        "parameters": [
+          {
+            "name": "get-coordinator-config",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "$ref": "#/definitions/GetCoordinatorArgs"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/CoordinatorConfig"
+            }
+          },
+          "500": {
+            "description": "Config not initialized successfully or others",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
+          }
+        }
+      }
+    },
+    "/coordinator/mac": {
+      "get": {
+        "description": "List the MAC addresses assigned to the pods on the node by the\ncoordinator podMACPrefix, for debugging\n",
+        "tags": [
+          "daemonset"
+        ],
+        "summary": "List the MAC addresses assigned to the pods",
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/PodMacList"
+            }
+          },
+          "500": {
+            "description": "Failed to list the MAC addresses",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
+          }
+        }
+      },
+      "post": {
+        "description": "Send a request to daemonset to assign a MAC address with the\npodMACPrefix to the interface of the pod, which collides with none\nof the MAC addresses assigned to the other pods on the node\n",
+        "tags": [
+          "daemonset"
+        ],
+        "summary": "Assign a MAC address to the pod",
+        "parameters": [
+          {
+            "name": "pod-mac-args",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "$ref": "#/definitions/PodMacArgs"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/PodMac"
+            }
+          },
+          "500": {
+            "description": "MAC address assignment failure",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
+          }
+        }
+      },
+      "delete": {
+        "description": "Send a request to daemonset to release the MAC address assigned to\nthe interface of the pod\n",
+        "tags": [
+          "daemonset"
+        ],
+        "summary": "Release the MAC address of the pod",
+        "parameters": [
+          {
+            "name": "pod-mac-args",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "$ref": "#/definitions/PodMacArgs"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Success"
+          },
+          "500": {
+            "description": "MAC address release failure",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
+          }
         }
       }
-    }
-  },
-  "x-schemes": [
-    "unix"
-  ]
-}`))
-	FlatSwaggerJSON = json.RawMessage([]byte(`{
-  "consumes": [
-    "application/json"
-  ],
-  "produces": [
-    "application/json"
-  ],
-  "swagger": "2.0",
-  "info": {
-    "description": "Spiderpool Agent",
-    "title": "Spiderpool-Agent API",
-    "version": "v1"
-  },
-  "basePath": "/v1",
-  "paths": {
-    "/coordinator/config": {
-      "get": {
-        "description": "Send a request to daemonset to get coordinator config\n",
+    },
+    "/ipam/dry-run": {
+      "post": {
+        "description": "Run the IPPool selection and capacity check of the ip allocation for\nsome replicas of a pod, without allocating any ip\n",
         "tags": [
           "daemonset"
         ],
-        "summary": "Get coordinator config",
+        "summary": "Dry-run ip allocation",
         "parameters": [
           {
-            "name": "get-coordinator-config",
+            "name": "ipam-dry-run-args",
             "in": "body",
             "required": true,
             "schema": {
-              "$ref": "#/definitions/GetCoordinatorArgs"
+              "$ref": "#/definitions/IpamDryRunArgs"
             }
           }
         ],
@@ -559,11 +1103,11 @@ func init() {
           "200": {
             "description": "Success",
             "schema": {
-              "$ref": "#/definitions/CoordinatorConfig"
+              "$ref": "#/definitions/IpamDryRunResponse"
             }
           },
           "500": {
-            "description": "Config not initialized successfully or others",
+            "description": "Dry-run failure",
             "schema": {
               "$ref": "#/definitions/Error"
             },
@@ -652,6 +1196,37 @@ func init() {
         }
       }
     },
+    "/ipam/ip/conflict": {
+      "post": {
+        "description": "Send a request to daemonset to detect whether the ip still answers\nARP or NDP on the node, before the ip is released by force\n",
+        "tags": [
+          "daemonset"
+        ],
+        "summary": "Detect ip conflict on the node",
+        "parameters": [
+          {
+            "name": "ipam-ip-conflict-args",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "$ref": "#/definitions/IpamIPConflictArgs"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Success, no one answers for the ip"
+          },
+          "500": {
+            "description": "The ip may be still in use, or failed to detect it",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
+          }
+        }
+      }
+    },
     "/ipam/ips": {
       "post": {
         "description": "Assign multiple ip for a pod, case for spiderflat compent\n",
@@ -720,8 +1295,11 @@ func init() {
           "200": {
             "description": "Success"
           },
-          "500": {
-            "description": "Failed"
+          "503": {
+            "description": "Failed",
+            "schema": {
+              "$ref": "#/definitions/ReadinessStatus"
+            }
           }
         }
       }
@@ -772,18 +1350,36 @@ func init() {
         "tunePodRoutes"
       ],
       "properties": {
+        "defaultRouteMode": {
+          "type": "string"
+        },
+        "defaultRouteWeight": {
+          "type": "integer"
+        },
         "detectGateway": {
           "type": "boolean"
         },
+        "detectGatewayMode": {
+          "type": "string"
+        },
         "detectIPConflict": {
           "type": "boolean"
         },
+        "enableProxyNDP": {
+          "type": "boolean"
+        },
+        "enableReplyViaVeth": {
+          "type": "boolean"
+        },
         "hijackCIDR": {
           "type": "array",
           "items": {
             "type": "string"
           }
         },
+        "hijackRouteMTU": {
+          "type": "integer"
+        },
         "hostRPFilter": {
           "type": "integer"
         },
@@ -811,6 +1407,15 @@ func init() {
             "type": "string"
           }
         },
+        "podRoutes": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/Route"
+          }
+        },
+        "routeTableMode": {
+          "type": "string"
+        },
         "serviceCIDR": {
           "type": "array",
           "items": {
@@ -849,6 +1454,72 @@ func init() {
         }
       }
     },
+    "DryRunCandidate": {
+      "description": "IPPools of an IP version in the dry-run result",
+      "type": "object",
+      "required": [
+        "version",
+        "allocatable"
+      ],
+      "properties": {
+        "allocatable": {
+          "type": "integer"
+        },
+        "pools": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/DryRunPool"
+          }
+        },
+        "version": {
+          "type": "integer",
+          "enum": [
+            4,
+            6
+          ]
+        }
+      }
+    },
+    "DryRunInterface": {
+      "description": "IPPool candidates of a NIC in the dry-run result",
+      "type": "object",
+      "required": [
+        "nic"
+      ],
+      "properties": {
+        "candidates": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/DryRunCandidate"
+          }
+        },
+        "nic": {
+          "type": "string"
+        }
+      }
+    },
+    "DryRunPool": {
+      "description": "IPPool in the dry-run result, filteredReason is set if it is filtered out",
+      "type": "object",
+      "required": [
+        "name",
+        "allocatable"
+      ],
+      "properties": {
+        "allocatable": {
+          "type": "integer"
+        },
+        "availableIPCount": {
+          "type": "integer"
+        },
+        "filteredReason": {
+          "type": "string"
+        },
+        "name": {
+          "type": "string"
+        }
+      }
+    },
     "Error": {
       "description": "API error",
       "type": "string"
@@ -857,6 +1528,10 @@ func init() {
       "description": "Get Coordinator Args",
       "type": "object",
       "properties": {
+        "cniCommand": {
+          "description": "The CNI command which gets the config, ADD or DEL",
+          "type": "string"
+        },
         "podName": {
           "type": "string"
         },
@@ -1001,6 +1676,178 @@ func init() {
         }
       }
     },
+    "IpamDryRunArgs": {
+      "description": "IPAM dry-run request args",
+      "type": "object",
+      "required": [
+        "ifName",
+        "podNamespace",
+        "nodeName",
+        "replicas"
+      ],
+      "properties": {
+        "defaultIPv4IPPool": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "defaultIPv6IPPool": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "ifName": {
+          "type": "string"
+        },
+        "nodeName": {
+          "type": "string"
+        },
+        "podAnnotations": {
+          "type": "object",
+          "additionalProperties": {
+            "type": "string"
+          }
+        },
+        "podLabels": {
+          "type": "object",
+          "additionalProperties": {
+            "type": "string"
+          }
+        },
+        "podNamespace": {
+          "type": "string"
+        },
+        "replicas": {
+          "type": "integer",
+          "minimum": 1
+        }
+      }
+    },
+    "IpamDryRunResponse": {
+      "description": "IPAM dry-run result, contains the IPPool candidates of each NIC",
+      "type": "object",
+      "required": [
+        "replicas",
+        "allocatable",
+        "nics"
+      ],
+      "properties": {
+        "allocatable": {
+          "type": "integer"
+        },
+        "dualStack": {
+          "type": "boolean"
+        },
+        "dualStackSatisfied": {
+          "type": "boolean"
+        },
+        "nics": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/DryRunInterface"
+          }
+        },
+        "replicas": {
+          "type": "integer"
+        }
+      }
+    },
+    "IpamIPConflictArgs": {
+      "description": "The ip to detect the conflict of",
+      "type": "object",
+      "required": [
+        "ip"
+      ],
+      "properties": {
+        "ip": {
+          "type": "string"
+        }
+      }
+    },
+    "PodMac": {
+      "description": "The MAC address assigned to the interface of the pod",
+      "type": "object",
+      "required": [
+        "mac"
+      ],
+      "properties": {
+        "assignedTime": {
+          "type": "string"
+        },
+        "ifName": {
+          "type": "string"
+        },
+        "mac": {
+          "type": "string"
+        },
+        "podName": {
+          "type": "string"
+        },
+        "podNamespace": {
+          "type": "string"
+        },
+        "podUID": {
+          "type": "string"
+        },
+        "salt": {
+          "type": "integer"
+        }
+      }
+    },
+    "PodMacArgs": {
+      "description": "The interface of the pod to assign or release the MAC address",
+      "type": "object",
+      "required": [
+        "podNamespace",
+        "podName",
+        "podUID",
+        "ifName"
+      ],
+      "properties": {
+        "ifName": {
+          "type": "string"
+        },
+        "macPrefix": {
+          "type": "string"
+        },
+        "podName": {
+          "type": "string"
+        },
+        "podNamespace": {
+          "type": "string"
+        },
+        "podUID": {
+          "type": "string"
+        }
+      }
+    },
+    "PodMacList": {
+      "description": "The MAC addresses assigned to the pods on the node",
+      "type": "object",
+      "properties": {
+        "items": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/PodMac"
+          }
+        }
+      }
+    },
+    "ReadinessStatus": {
+      "description": "Readiness status of spiderpool-agent",
+      "type": "object",
+      "properties": {
+        "failedComponents": {
+          "description": "The failed components and their errors",
+          "type": "object",
+          "additionalProperties": {
+            "type": "string"
+          }
+        }
+      }
+    },
     "Route": {
       "description": "IPAM CNI types Route",
       "type": "object",
@@ -1018,6 +1865,9 @@ func init() {
         },
         "ifName": {
           "type": "string"
+        },
+        "table": {
+          "type": "string"
         }
       }
     }
@@ -12,6 +12,8 @@ import (
 	"net/http"
 
 	"github.com/go-openapi/runtime"
+
+	"github.com/spidernet-io/spiderpool/api/v1/agent/models"
 )
 
 // GetRuntimeReadinessOKCode is the HTTP code returned for type GetRuntimeReadinessOK
@@ -39,27 +41,47 @@ func (o *GetRuntimeReadinessOK) WriteResponse(rw http.ResponseWriter, producer r
 	rw.WriteHeader(200)
 }
 
-// GetRuntimeReadinessInternalServerErrorCode is the HTTP code returned for type GetRuntimeReadinessInternalServerError
-const GetRuntimeReadinessInternalServerErrorCode int = 500
+// GetRuntimeReadinessServiceUnavailableCode is the HTTP code returned for type GetRuntimeReadinessServiceUnavailable
+const GetRuntimeReadinessServiceUnavailableCode int = 503
 
 /*
-GetRuntimeReadinessInternalServerError Failed
+GetRuntimeReadinessServiceUnavailable Failed
 
-swagger:response getRuntimeReadinessInternalServerError
+swagger:response getRuntimeReadinessServiceUnavailable
 */
-type GetRuntimeReadinessInternalServerError struct {
+type GetRuntimeReadinessServiceUnavailable struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.ReadinessStatus `json:"body,omitempty"`
 }
 
-// NewGetRuntimeReadinessInternalServerError creates GetRuntimeReadinessInternalServerError with default headers values
-func NewGetRuntimeReadinessInternalServerError() *GetRuntimeReadinessInternalServerError {
+// NewGetRuntimeReadinessServiceUnavailable creates GetRuntimeReadinessServiceUnavailable with default headers values
+func NewGetRuntimeReadinessServiceUnavailable() *GetRuntimeReadinessServiceUnavailable {
 
-	return &GetRuntimeReadinessInternalServerError{}
+	return &GetRuntimeReadinessServiceUnavailable{}
 }
 
-// WriteResponse to the client
-func (o *GetRuntimeReadinessInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+// WithPayload adds the payload to the get runtime readiness service unavailable response
+func (o *GetRuntimeReadinessServiceUnavailable) WithPayload(payload *models.ReadinessStatus) *GetRuntimeReadinessServiceUnavailable {
+	o.Payload = payload
+	return o
+}
 
-	rw.Header().Del(runtime.HeaderContentType) //Remove Content-Type on empty responses
+// SetPayload sets the payload to the get runtime readiness service unavailable response
+func (o *GetRuntimeReadinessServiceUnavailable) SetPayload(payload *models.ReadinessStatus) {
+	o.Payload = payload
+}
 
-	rw.WriteHeader(500)
+// WriteResponse to the client
+func (o *GetRuntimeReadinessServiceUnavailable) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(503)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
 }
@@ -49,6 +49,9 @@ func NewSpiderpoolAgentAPIAPI(spec *loads.Document) *SpiderpoolAgentAPIAPI {
 
 		JSONProducer: runtime.JSONProducer(),
 
+		DaemonsetDeleteCoordinatorMacHandler: daemonset.DeleteCoordinatorMacHandlerFunc(func(params daemonset.DeleteCoordinatorMacParams) middleware.Responder {
+			return middleware.NotImplemented("operation daemonset.DeleteCoordinatorMac has not yet been implemented")
+		}),
 		DaemonsetDeleteIpamIPHandler: daemonset.DeleteIpamIPHandlerFunc(func(params daemonset.DeleteIpamIPParams) middleware.Responder {
 			return middleware.NotImplemented("operation daemonset.DeleteIpamIP has not yet been implemented")
 		}),
@@ -58,6 +61,9 @@ func NewSpiderpoolAgentAPIAPI(spec *loads.Document) *SpiderpoolAgentAPIAPI {
 		DaemonsetGetCoordinatorConfigHandler: daemonset.GetCoordinatorConfigHandlerFunc(func(params daemonset.GetCoordinatorConfigParams) middleware.Responder {
 			return middleware.NotImplemented("operation daemonset.GetCoordinatorConfig has not yet been implemented")
 		}),
+		DaemonsetGetCoordinatorMacHandler: daemonset.GetCoordinatorMacHandlerFunc(func(params daemonset.GetCoordinatorMacParams) middleware.Responder {
+			return middleware.NotImplemented("operation daemonset.GetCoordinatorMac has not yet been implemented")
+		}),
 		ConnectivityGetIpamHealthyHandler: connectivity.GetIpamHealthyHandlerFunc(func(params connectivity.GetIpamHealthyParams) middleware.Responder {
 			return middleware.NotImplemented("operation connectivity.GetIpamHealthy has not yet been implemented")
 		}),
@@ -73,9 +79,18 @@ func NewSpiderpoolAgentAPIAPI(spec *loads.Document) *SpiderpoolAgentAPIAPI {
 		DaemonsetGetWorkloadendpointHandler: daemonset.GetWorkloadendpointHandlerFunc(func(params daemonset.GetWorkloadendpointParams) middleware.Responder {
 			return middleware.NotImplemented("operation daemonset.GetWorkloadendpoint has not yet been implemented")
 		}),
+		DaemonsetPostCoordinatorMacHandler: daemonset.PostCoordinatorMacHandlerFunc(func(params daemonset.PostCoordinatorMacParams) middleware.Responder {
+			return middleware.NotImplemented("operation daemonset.PostCoordinatorMac has not yet been implemented")
+		}),
+		DaemonsetPostIpamDryRunHandler: daemonset.PostIpamDryRunHandlerFunc(func(params daemonset.PostIpamDryRunParams) middleware.Responder {
+			return middleware.NotImplemented("operation daemonset.PostIpamDryRun has not yet been implemented")
+		}),
 		DaemonsetPostIpamIPHandler: daemonset.PostIpamIPHandlerFunc(func(params daemonset.PostIpamIPParams) middleware.Responder {
 			return middleware.NotImplemented("operation daemonset.PostIpamIP has not yet been implemented")
 		}),
+		DaemonsetPostIpamIPConflictHandler: daemonset.PostIpamIPConflictHandlerFunc(func(params daemonset.PostIpamIPConflictParams) middleware.Responder {
+			return middleware.NotImplemented("operation daemonset.PostIpamIPConflict has not yet been implemented")
+		}),
 		DaemonsetPostIpamIpsHandler: daemonset.PostIpamIpsHandlerFunc(func(params daemonset.PostIpamIpsParams) middleware.Responder {
 			return middleware.NotImplemented("operation daemonset.PostIpamIps has not yet been implemented")
 		}),
@@ -115,12 +130,16 @@ type SpiderpoolAgentAPIAPI struct {
 	//   - application/json
 	JSONProducer runtime.Producer
 
+	// DaemonsetDeleteCoordinatorMacHandler sets the operation handler for the delete coordinator mac operation
+	DaemonsetDeleteCoordinatorMacHandler daemonset.DeleteCoordinatorMacHandler
 	// DaemonsetDeleteIpamIPHandler sets the operation handler for the delete ipam IP operation
 	DaemonsetDeleteIpamIPHandler daemonset.DeleteIpamIPHandler
 	// DaemonsetDeleteIpamIpsHandler sets the operation handler for the delete ipam ips operation
 	DaemonsetDeleteIpamIpsHandler daemonset.DeleteIpamIpsHandler
 	// DaemonsetGetCoordinatorConfigHandler sets the operation handler for the get coordinator config operation
 	DaemonsetGetCoordinatorConfigHandler daemonset.GetCoordinatorConfigHandler
+	// DaemonsetGetCoordinatorMacHandler sets the operation handler for the get coordinator mac operation
+	DaemonsetGetCoordinatorMacHandler daemonset.GetCoordinatorMacHandler
 	// ConnectivityGetIpamHealthyHandler sets the operation handler for the get ipam healthy operation
 	ConnectivityGetIpamHealthyHandler connectivity.GetIpamHealthyHandler
 	// RuntimeGetRuntimeLivenessHandler sets the operation handler for the get runtime liveness operation
@@ -131,8 +150,14 @@ type SpiderpoolAgentAPIAPI struct {
 	RuntimeGetRuntimeStartupHandler runtimeops.GetRuntimeStartupHandler
 	// DaemonsetGetWorkloadendpointHandler sets the operation handler for the get workloadendpoint operation
 	DaemonsetGetWorkloadendpointHandler daemonset.GetWorkloadendpointHandler
+	// DaemonsetPostCoordinatorMacHandler sets the operation handler for the post coordinator mac operation
+	DaemonsetPostCoordinatorMacHandler daemonset.PostCoordinatorMacHandler
+	// DaemonsetPostIpamDryRunHandler sets the operation handler for the post ipam dry run operation
+	DaemonsetPostIpamDryRunHandler daemonset.PostIpamDryRunHandler
 	// DaemonsetPostIpamIPHandler sets the operation handler for the post ipam IP operation
 	DaemonsetPostIpamIPHandler daemonset.PostIpamIPHandler
+	// DaemonsetPostIpamIPConflictHandler sets the operation handler for the post ipam IP conflict operation
+	DaemonsetPostIpamIPConflictHandler daemonset.PostIpamIPConflictHandler
 	// DaemonsetPostIpamIpsHandler sets the operation handler for the post ipam ips operation
 	DaemonsetPostIpamIpsHandler daemonset.PostIpamIpsHandler
 
@@ -212,6 +237,9 @@ func (o *SpiderpoolAgentAPIAPI) Validate() error {
 		unregistered = append(unregistered, "JSONProducer")
 	}
 
+	if o.DaemonsetDeleteCoordinatorMacHandler == nil {
+		unregistered = append(unregistered, "daemonset.DeleteCoordinatorMacHandler")
+	}
 	if o.DaemonsetDeleteIpamIPHandler == nil {
 		unregistered = append(unregistered, "daemonset.DeleteIpamIPHandler")
 	}
@@ -221,6 +249,9 @@ func (o *SpiderpoolAgentAPIAPI) Validate() error {
 	if o.DaemonsetGetCoordinatorConfigHandler == nil {
 		unregistered = append(unregistered, "daemonset.GetCoordinatorConfigHandler")
 	}
+	if o.DaemonsetGetCoordinatorMacHandler == nil {
+		unregistered = append(unregistered, "daemonset.GetCoordinatorMacHandler")
+	}
 	if o.ConnectivityGetIpamHealthyHandler == nil {
 		unregistered = append(unregistered, "connectivity.GetIpamHealthyHandler")
 	}
@@ -236,9 +267,18 @@ func (o *SpiderpoolAgentAPIAPI) Validate() error {
 	if o.DaemonsetGetWorkloadendpointHandler == nil {
 		unregistered = append(unregistered, "daemonset.GetWorkloadendpointHandler")
 	}
+	if o.DaemonsetPostCoordinatorMacHandler == nil {
+		unregistered = append(unregistered, "daemonset.PostCoordinatorMacHandler")
+	}
+	if o.DaemonsetPostIpamDryRunHandler == nil {
+		unregistered = append(unregistered, "daemonset.PostIpamDryRunHandler")
+	}
 	if o.DaemonsetPostIpamIPHandler == nil {
 		unregistered = append(unregistered, "daemonset.PostIpamIPHandler")
 	}
+	if o.DaemonsetPostIpamIPConflictHandler == nil {
+		unregistered = append(unregistered, "daemonset.PostIpamIPConflictHandler")
+	}
 	if o.DaemonsetPostIpamIpsHandler == nil {
 		unregistered = append(unregistered, "daemonset.PostIpamIpsHandler")
 	}
@@ -330,6 +370,10 @@ func (o *SpiderpoolAgentAPIAPI) initHandlerCache() {
 		o.handlers = make(map[string]map[string]http.Handler)
 	}
 
+	if o.handlers["DELETE"] == nil {
+		o.handlers["DELETE"] = make(map[string]http.Handler)
+	}
+	o.handlers["DELETE"]["/coordinator/mac"] = daemonset.NewDeleteCoordinatorMac(o.context, o.DaemonsetDeleteCoordinatorMacHandler)
 	if o.handlers["DELETE"] == nil {
 		o.handlers["DELETE"] = make(map[string]http.Handler)
 	}
@@ -345,6 +389,10 @@ func (o *SpiderpoolAgentAPIAPI) initHandlerCache() {
 	if o.handlers["GET"] == nil {
 		o.handlers["GET"] = make(map[string]http.Handler)
 	}
+	o.handlers["GET"]["/coordinator/mac"] = daemonset.NewGetCoordinatorMac(o.context, o.DaemonsetGetCoordinatorMacHandler)
+	if o.handlers["GET"] == nil {
+		o.handlers["GET"] = make(map[string]http.Handler)
+	}
 	o.handlers["GET"]["/ipam/healthy"] = connectivity.NewGetIpamHealthy(o.context, o.ConnectivityGetIpamHealthyHandler)
 	if o.handlers["GET"] == nil {
 		o.handlers["GET"] = make(map[string]http.Handler)
@@ -365,10 +413,22 @@ func (o *SpiderpoolAgentAPIAPI) initHandlerCache() {
 	if o.handlers["POST"] == nil {
 		o.handlers["POST"] = make(map[string]http.Handler)
 	}
+	o.handlers["POST"]["/coordinator/mac"] = daemonset.NewPostCoordinatorMac(o.context, o.DaemonsetPostCoordinatorMacHandler)
+	if o.handlers["POST"] == nil {
+		o.handlers["POST"] = make(map[string]http.Handler)
+	}
+	o.handlers["POST"]["/ipam/dry-run"] = daemonset.NewPostIpamDryRun(o.context, o.DaemonsetPostIpamDryRunHandler)
+	if o.handlers["POST"] == nil {
+		o.handlers["POST"] = make(map[string]http.Handler)
+	}
 	o.handlers["POST"]["/ipam/ip"] = daemonset.NewPostIpamIP(o.context, o.DaemonsetPostIpamIPHandler)
 	if o.handlers["POST"] == nil {
 		o.handlers["POST"] = make(map[string]http.Handler)
 	}
+	o.handlers["POST"]["/ipam/ip/conflict"] = daemonset.NewPostIpamIPConflict(o.context, o.DaemonsetPostIpamIPConflictHandler)
+	if o.handlers["POST"] == nil {
+		o.handlers["POST"] = make(map[string]http.Handler)
+	}
 	o.handlers["POST"]["/ipam/ips"] = daemonset.NewPostIpamIps(o.context, o.DaemonsetPostIpamIpsHandler)
 }
 
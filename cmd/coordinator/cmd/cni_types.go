@@ -9,7 +9,6 @@ import (
 	"net"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
@@ -20,6 +19,7 @@ import (
 
 	"github.com/spidernet-io/spiderpool/api/v1/agent/models"
 	"github.com/spidernet-io/spiderpool/pkg/logutils"
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
 )
 
 var (
@@ -28,9 +28,25 @@ var (
 	defaultMarkBit          = 0 // ox1
 	// by default, k8s pod's first NIC is eth0
 	defaultOverlayVethName  = "eth0"
-	defaultPodRuleTable     = 100
 	defaultHostRulePriority = 1000
-	BinNamePlugin           = filepath.Base(os.Args[0])
+	// after the rule of the reply packets marked by iptables, see makeReplyPacketViaVeth,
+	// and before the rules of the rule tables, whose priorities are assigned by kernel
+	defaultSuppressRulePriority = 1001
+	// the rules of the invertHijackRule strategy, see tunePodRoutesByInvertRule
+	defaultInvertFromRulePriority   = 1002
+	defaultInvertHijackRulePriority = 1003
+	defaultDADTimeout               = 5 * time.Second
+	defaultRATimeout                = 5 * time.Second
+	BinNamePlugin                   = filepath.Base(os.Args[0])
+)
+
+// the phases of the command reported to spiderpool-agent
+const (
+	phaseNetnsEnter  = "netns_enter"
+	phaseDetection   = "conflict_detection"
+	phaseRuleInstall = "rule_install"
+	phaseRouteMove   = "route_move"
+	phaseCleanup     = "cleanup"
 )
 
 type Mode string
@@ -42,23 +58,76 @@ const (
 	ModeDisable  Mode = "disable"
 )
 
+type RouteTableMode string
+
+const (
+	// RouteTableModeMove moves the routes of the interface to the policy route table
+	RouteTableModeMove RouteTableMode = "move"
+	// RouteTableModeCopy copies the routes of the interface to the policy route table,
+	// so that the routes in main table still works
+	RouteTableModeCopy RouteTableMode = "copy"
+)
+
+type DefaultRouteMode string
+
+const (
+	// DefaultRouteModePrimaryBackup keeps the default route on podDefaultRouteNIC,
+	// the default routes of the other NICs are in their policy route tables
+	DefaultRouteModePrimaryBackup DefaultRouteMode = "primaryBackup"
+	// DefaultRouteModeLoadBalance balances the default route across the NICs
+	// by the weighted multipath route
+	DefaultRouteModeLoadBalance DefaultRouteMode = "loadBalance"
+)
+
+type GatewayDiscovery string
+
+const (
+	// GatewayDiscoveryStatic uses the gateway of the IP pool
+	GatewayDiscoveryStatic GatewayDiscovery = "static"
+	// GatewayDiscoveryRA learns the IPv6 gateway by the Router Advertisement
+	// if the IP pool has no IPv6 gateway
+	GatewayDiscoveryRA GatewayDiscovery = "ra"
+)
+
+type DetectGatewayMode string
+
+const (
+	// DetectGatewayModeARP resolves the gateway by ARP for IPv4 or the
+	// Neighbor Solicitation for IPv6, it works with the gateways dropping ICMP
+	DetectGatewayModeARP DetectGatewayMode = "arp"
+	// DetectGatewayModeICMP pings the gateway
+	DetectGatewayModeICMP DetectGatewayMode = "icmp"
+	// DetectGatewayModeBoth requires the gateway to answer both ARP/NS and ICMP
+	DetectGatewayModeBoth DetectGatewayMode = "both"
+)
+
 type Config struct {
 	types.NetConf
-	DetectGateway      *bool          `json:"detectGateway,omitempty"`
-	MacPrefix          string         `json:"podMACPrefix,omitempty"`
-	MultusNicPrefix    string         `json:"multusNicPrefix,omitempty"`
-	PodDefaultCniNic   string         `json:"podDefaultCniNic,omitempty"`
-	OverlayPodCIDR     []string       `json:"overlayPodCIDR,omitempty"`
-	ServiceCIDR        []string       `json:"serviceCIDR,omitempty"`
-	HijackCIDR         []string       `json:"hijackCIDR,omitempty"`
-	TunePodRoutes      *bool          `json:"tunePodRoutes,omitempty"`
-	PodDefaultRouteNIC string         `json:"podDefaultRouteNic,omitempty"`
-	Mode               Mode           `json:"mode,omitempty"`
-	HostRuleTable      *int64         `json:"hostRuleTable,omitempty"`
-	RPFilter           int32          `json:"hostRPFilter,omitempty" `
-	IPConflict         *bool          `json:"detectIPConflict,omitempty"`
-	DetectOptions      *DetectOptions `json:"detectOptions,omitempty"`
-	LogOptions         *LogOptions    `json:"logOptions,omitempty"`
+	DetectGateway      *bool             `json:"detectGateway,omitempty"`
+	DetectGatewayMode  DetectGatewayMode `json:"detectGatewayMode,omitempty"`
+	MacPrefix          string            `json:"podMACPrefix,omitempty"`
+	MultusNicPrefix    string            `json:"multusNicPrefix,omitempty"`
+	PodDefaultCniNic   string            `json:"podDefaultCniNic,omitempty"`
+	OverlayPodCIDR     []string          `json:"overlayPodCIDR,omitempty"`
+	ServiceCIDR        []string          `json:"serviceCIDR,omitempty"`
+	HijackCIDR         []string          `json:"hijackCIDR,omitempty"`
+	TunePodRoutes      *bool             `json:"tunePodRoutes,omitempty"`
+	PodDefaultRouteNIC string            `json:"podDefaultRouteNic,omitempty"`
+	RouteTableMode     RouteTableMode    `json:"routeTableMode,omitempty"`
+	EnableReplyViaVeth *bool             `json:"enableReplyViaVeth,omitempty"`
+	EnableProxyNDP     *bool             `json:"enableProxyNDP,omitempty"`
+	DefaultRouteMode   DefaultRouteMode  `json:"defaultRouteMode,omitempty"`
+	DefaultRouteWeight *int              `json:"defaultRouteWeight,omitempty"`
+	HijackRouteMTU     *int              `json:"hijackRouteMTU,omitempty"`
+	InvertHijackRule   *bool             `json:"invertHijackRule,omitempty"`
+	EnableIPv6         *bool             `json:"enableIPv6,omitempty"`
+	GatewayDiscovery   GatewayDiscovery  `json:"gatewayDiscovery,omitempty"`
+	Mode               Mode              `json:"mode,omitempty"`
+	HostRuleTable      *int64            `json:"hostRuleTable,omitempty"`
+	RPFilter           int32             `json:"hostRPFilter,omitempty" `
+	IPConflict         *bool             `json:"detectIPConflict,omitempty"`
+	DetectOptions      *DetectOptions    `json:"detectOptions,omitempty"`
+	LogOptions         *LogOptions       `json:"logOptions,omitempty"`
 }
 
 // DetectOptions enable ip conflicting check for pod's ip
@@ -160,6 +229,14 @@ func ParseConfig(stdin []byte, coordinatorConfig *models.CoordinatorConfig) (*Co
 		conf.DetectGateway = pointer.Bool(coordinatorConfig.DetectGateway)
 	}
 
+	if conf.DetectGatewayMode == "" {
+		conf.DetectGatewayMode = DetectGatewayMode(coordinatorConfig.DetectGatewayMode)
+	}
+
+	if err = validateDetectGatewayMode(&conf.DetectGatewayMode); err != nil {
+		return nil, err
+	}
+
 	if conf.TunePodRoutes == nil {
 		conf.TunePodRoutes = coordinatorConfig.TunePodRoutes
 	}
@@ -172,6 +249,67 @@ func ParseConfig(stdin []byte, coordinatorConfig *models.CoordinatorConfig) (*Co
 		conf.PodDefaultRouteNIC = coordinatorConfig.PodDefaultRouteNIC
 	}
 
+	if conf.RouteTableMode == "" {
+		conf.RouteTableMode = RouteTableMode(coordinatorConfig.RouteTableMode)
+	}
+
+	if err = validateRouteTableMode(&conf.RouteTableMode); err != nil {
+		return nil, err
+	}
+
+	if conf.EnableReplyViaVeth == nil {
+		conf.EnableReplyViaVeth = pointer.Bool(coordinatorConfig.EnableReplyViaVeth)
+	}
+
+	if conf.EnableProxyNDP == nil {
+		conf.EnableProxyNDP = pointer.Bool(coordinatorConfig.EnableProxyNDP)
+	}
+
+	if conf.InvertHijackRule == nil {
+		conf.InvertHijackRule = pointer.Bool(false)
+	}
+
+	if conf.EnableIPv6 == nil {
+		conf.EnableIPv6 = pointer.Bool(true)
+	}
+
+	if err = validateGatewayDiscovery(&conf.GatewayDiscovery); err != nil {
+		return nil, err
+	}
+
+	if conf.DefaultRouteMode == "" {
+		conf.DefaultRouteMode = DefaultRouteMode(coordinatorConfig.DefaultRouteMode)
+	}
+
+	if err = validateDefaultRouteMode(&conf.DefaultRouteMode); err != nil {
+		return nil, err
+	}
+
+	if conf.DefaultRouteWeight == nil && coordinatorConfig.DefaultRouteWeight > 0 {
+		conf.DefaultRouteWeight = pointer.Int(int(coordinatorConfig.DefaultRouteWeight))
+	}
+
+	if conf.DefaultRouteWeight == nil {
+		conf.DefaultRouteWeight = pointer.Int(1)
+	}
+
+	if *conf.DefaultRouteWeight < 1 || *conf.DefaultRouteWeight > 256 {
+		return nil, fmt.Errorf("invalid defaultRouteWeight %d, it must be in range [1, 256]", *conf.DefaultRouteWeight)
+	}
+
+	if conf.HijackRouteMTU == nil && coordinatorConfig.HijackRouteMTU > 0 {
+		conf.HijackRouteMTU = pointer.Int(int(coordinatorConfig.HijackRouteMTU))
+	}
+
+	// 0 means the mtu of the hijack routes is unset
+	if conf.HijackRouteMTU == nil {
+		conf.HijackRouteMTU = pointer.Int(0)
+	}
+
+	if *conf.HijackRouteMTU < 0 || *conf.HijackRouteMTU > 65535 {
+		return nil, fmt.Errorf("invalid hijackRouteMTU %d, it must be in range [0, 65535]", *conf.HijackRouteMTU)
+	}
+
 	return &conf, nil
 }
 
@@ -179,16 +317,8 @@ func validateHwPrefix(prefix string) error {
 	if prefix == "" {
 		return nil
 	}
-	// prefix format like: 00:00、0a:1b
-	matchRegexp, err := regexp.Compile("^" + "(" + "[a-fA-F0-9][a-fA-F,0,2-9][:-][a-fA-F0-9]{2}" + ")" + "$")
-	if err != nil {
-		return err
-	}
-	if !matchRegexp.MatchString(prefix) {
-		return fmt.Errorf("mac_prefix format should be match regex: [a-fA-F0-9][a-fA-F,0,2-9][:][a-fA-F0-9]{2}, like '0a:1b'")
-	}
-
-	return nil
+	// prefix format like: 0a:1b, it must be unicast and locally administered
+	return networking.ValidateMACPrefix(prefix)
 }
 
 func ValidateRoutes(conf *Config, coordinatorConfig *models.CoordinatorConfig) error {
@@ -237,6 +367,51 @@ func validateRoutes(routes []string) error {
 	return nil
 }
 
+func validateDetectGatewayMode(mode *DetectGatewayMode) error {
+	switch *mode {
+	case "":
+		*mode = DetectGatewayModeARP
+	case DetectGatewayModeARP, DetectGatewayModeICMP, DetectGatewayModeBoth:
+	default:
+		return fmt.Errorf("invalid detectGatewayMode %v, available options: [%v,%v,%v]", *mode,
+			DetectGatewayModeARP, DetectGatewayModeICMP, DetectGatewayModeBoth)
+	}
+	return nil
+}
+
+func validateRouteTableMode(mode *RouteTableMode) error {
+	switch *mode {
+	case "":
+		*mode = RouteTableModeMove
+	case RouteTableModeMove, RouteTableModeCopy:
+	default:
+		return fmt.Errorf("invalid routeTableMode %v, available options: [%v,%v]", *mode, RouteTableModeMove, RouteTableModeCopy)
+	}
+	return nil
+}
+
+func validateDefaultRouteMode(mode *DefaultRouteMode) error {
+	switch *mode {
+	case "":
+		*mode = DefaultRouteModePrimaryBackup
+	case DefaultRouteModePrimaryBackup, DefaultRouteModeLoadBalance:
+	default:
+		return fmt.Errorf("invalid defaultRouteMode %v, available options: [%v,%v]", *mode, DefaultRouteModePrimaryBackup, DefaultRouteModeLoadBalance)
+	}
+	return nil
+}
+
+func validateGatewayDiscovery(discovery *GatewayDiscovery) error {
+	switch *discovery {
+	case "":
+		*discovery = GatewayDiscoveryStatic
+	case GatewayDiscoveryStatic, GatewayDiscoveryRA:
+	default:
+		return fmt.Errorf("invalid gatewayDiscovery %v, available options: [%v,%v]", *discovery, GatewayDiscoveryStatic, GatewayDiscoveryRA)
+	}
+	return nil
+}
+
 func validateRPFilterConfig(rpfilter int32) error {
 	found := false
 	// NOTE: -1 means disable
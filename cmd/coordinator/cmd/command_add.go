@@ -5,6 +5,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/containernetworking/cni/pkg/skel"
 	"github.com/containernetworking/cni/pkg/types"
@@ -13,6 +14,9 @@ import (
 	"github.com/vishvananda/netlink"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/sys/unix"
+	"k8s.io/utils/pointer"
+	"net"
 	"time"
 
 	"github.com/spidernet-io/spiderpool/api/v1/agent/client/daemonset"
@@ -20,7 +24,7 @@ import (
 	plugincmd "github.com/spidernet-io/spiderpool/cmd/spiderpool/cmd"
 	"github.com/spidernet-io/spiderpool/pkg/constant"
 	"github.com/spidernet-io/spiderpool/pkg/logutils"
-	"github.com/spidernet-io/spiderpool/pkg/networking/gwconnection"
+	"github.com/spidernet-io/spiderpool/pkg/metric/cnireport"
 	"github.com/spidernet-io/spiderpool/pkg/networking/ipchecking"
 	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
 	"github.com/spidernet-io/spiderpool/pkg/networking/sysctl"
@@ -29,11 +33,17 @@ import (
 
 func CmdAdd(args *skel.CmdArgs) (err error) {
 	startTime := time.Now()
+	report := cnireport.NewReport(cnireport.CmdAdd, args.ContainerID)
+	defer func() {
+		report.Finish(err)
+		_ = cnireport.Write(constant.DefaultCoordinatorReportDir, report)
+	}()
 
 	k8sArgs := plugincmd.K8sArgs{}
 	if err = types.LoadArgs(args.Args, &k8sArgs); nil != err {
 		return fmt.Errorf("failed to load CNI ENV args: %w", err)
 	}
+	report.SetPod(string(k8sArgs.K8S_POD_NAMESPACE), string(k8sArgs.K8S_POD_NAME))
 
 	client, err := openapi.NewAgentOpenAPIUnixClient(constant.DefaultIPAMUnixSocketPath)
 	if err != nil {
@@ -42,6 +52,7 @@ func CmdAdd(args *skel.CmdArgs) (err error) {
 
 	resp, err := client.Daemonset.GetCoordinatorConfig(daemonset.NewGetCoordinatorConfigParams().WithGetCoordinatorConfig(
 		&models.GetCoordinatorArgs{
+			CniCommand:   constant.CNICommandAdd,
 			PodName:      string(k8sArgs.K8S_POD_NAME),
 			PodNamespace: string(k8sArgs.K8S_POD_NAMESPACE),
 		},
@@ -95,207 +106,330 @@ func CmdAdd(args *skel.CmdArgs) (err error) {
 	c := &coordinator{
 		HijackCIDR:       conf.OverlayPodCIDR,
 		hostRuleTable:    int(*conf.HostRuleTable),
+		hijackRouteMTU:   *conf.HijackRouteMTU,
 		ipFamily:         ipFamily,
 		currentInterface: args.IfName,
 		tuneMode:         conf.Mode,
+		routeTableMode:   conf.RouteTableMode,
 		podNics:          coordinatorConfig.PodNICs,
+		podRoutes:        coordinatorConfig.PodRoutes,
+		podLinks:         networking.NewLinkCache(),
+		report:           report,
+	}
+	if !*conf.EnableIPv6 {
+		// the node may disable IPv6 by design, skip the IPv6 routes and rules
+		// with a warning if IPv6 is unsupported
+		allowMissingIPv6 := networking.AllowMissingIPv6(logger)
+		c.routeOpts = append(c.routeOpts, allowMissingIPv6)
+		c.ruleOpts = append(c.ruleOpts, allowMissingIPv6)
 	}
 	c.HijackCIDR = append(c.HijackCIDR, conf.ServiceCIDR...)
 	c.HijackCIDR = append(c.HijackCIDR, conf.HijackCIDR...)
+	if *conf.InvertHijackRule {
+		c.invertHijackDst, err = getInvertHijackDst(conf.HijackCIDR, ipFamily)
+		if err != nil {
+			logger.Error("invalid hijackCIDR for invertHijackRule", zap.Error(err))
+			return err
+		}
+	}
 
+	endPhase := report.StartPhase(phaseNetnsEnter)
 	c.netns, err = ns.GetNS(args.Netns)
+	endPhase()
 	if err != nil {
 		logger.Error(err.Error())
 		return fmt.Errorf("failed to GetNS %q: %v", args.Netns, err)
 	}
 	defer c.netns.Close()
 
-	// check if it's first time invoke
-	err = c.coordinatorModeAndFirstInvoke(logger, conf.PodDefaultCniNic)
-	if err != nil {
-		logger.Error(err.Error())
-		return err
-	}
-
-	// get basic info
-	switch c.tuneMode {
-	case ModeUnderlay:
-		c.podVethName = defaultUnderlayVethName
-		c.hostVethName = getHostVethName(args.ContainerID)
-		if c.firstInvoke {
-			err = c.setupVeth(args.ContainerID)
+	// multus invokes us once per network of the pod, serialize the invocations
+	// for the same pod so that the tuning of the routes and rules doesn't interleave
+	err = networking.WithNetnsLock(c.netns, func() error {
+		if ipFamily != netlink.FAMILY_V4 {
+			// ensure ipv6 is enable before any ipv6 operation
+			err = sysctl.EnableIPv6(c.netns, []string{args.IfName})
+			if errors.Is(err, sysctl.ErrIPv6NotCompiled) && !*conf.EnableIPv6 {
+				logger.Warn("IPv6 is not supported by the kernel of the node, skip enabling ipv6 in pod", zap.Error(err))
+				err = nil
+			}
 			if err != nil {
-				logger.Error("failed to create veth-pair device", zap.Error(err))
-				return err
+				logger.Error("failed to enable ipv6 in pod", zap.Error(err))
+				if errors.Is(err, sysctl.ErrIPv6NotCompiled) {
+					return fmt.Errorf("pod is assigned with IPv6 addresses, but IPv6 is not supported by the kernel of the node, please enable IPv6 on the node or only assign IPv4 addresses to the pod: %w", err)
+				}
+				return fmt.Errorf("failed to enable ipv6 in pod: %w", err)
 			}
-			logger.Debug("Setup veth-pair device successfully", zap.String("hostVethPairName", getHostVethName(args.ContainerID)),
-				zap.String("hostVethMac", c.hostVethHwAddress.String()), zap.String("podVethMac", c.podVethHwAddress.String()))
 		}
-	case ModeOverlay:
-		c.podVethName = defaultOverlayVethName
-		c.hostVethName, err = networking.GetHostVethName(c.netns, defaultOverlayVethName)
+
+		// check if it's first time invoke
+		err = c.coordinatorModeAndFirstInvoke(logger, conf.PodDefaultCniNic)
 		if err != nil {
-			logger.Error("failed to GetHostVethName", zap.Error(err))
+			logger.Error(err.Error())
 			return err
 		}
-	case ModeDisable:
-		logger.Info("TuneMode is disable, nothing to do")
-		return types.PrintResult(conf.PrevResult, conf.CNIVersion)
-	default:
-		logger.Error("Unknown tuneMode", zap.String("invalid tuneMode", string(conf.Mode)))
-		return fmt.Errorf("unknown tuneMode: %s", conf.Mode)
-	}
 
-	logger.Sugar().Infof("Get coordinator config: %+v", c)
+		// get basic info
+		switch c.tuneMode {
+		case ModeUnderlay:
+			c.podVethName = defaultUnderlayVethName
+			c.hostVethName = getHostVethName(args.ContainerID)
+			if c.firstInvoke {
+				err = c.setupVeth(args.ContainerID)
+				c.podLinks.Invalidate()
+				if err != nil {
+					logger.Error("failed to create veth-pair device", zap.Error(err))
+					return err
+				}
+				logger.Debug("Setup veth-pair device successfully", zap.String("hostVethPairName", getHostVethName(args.ContainerID)),
+					zap.String("hostVethMac", c.hostVethHwAddress.String()), zap.String("podVethMac", c.podVethHwAddress.String()))
+			}
+		case ModeOverlay:
+			c.podVethName = defaultOverlayVethName
+			c.hostVethName, err = networking.GetHostVethName(c.netns, defaultOverlayVethName)
+			if err != nil {
+				logger.Error("failed to GetHostVethName", zap.Error(err))
+				return err
+			}
+		case ModeDisable:
+			logger.Info("TuneMode is disable, nothing to do")
+			return nil
+		default:
+			logger.Error("Unknown tuneMode", zap.String("invalid tuneMode", string(conf.Mode)))
+			return fmt.Errorf("unknown tuneMode: %s", conf.Mode)
+		}
 
-	errg, ctx := errgroup.WithContext(context.Background())
-	defer ctx.Done()
+		logger.Sugar().Infof("Get coordinator config: %+v", c)
+
+		endPhase = report.StartPhase(phaseDetection)
+		errg, ctx := errgroup.WithContext(context.Background())
+		defer ctx.Done()
+
+		//  we do detect gateway connection firstly
+		if conf.DetectGateway != nil && *conf.DetectGateway {
+			logger.Debug("Try to detect gateway")
+
+			var gws []string
+			err = c.netns.Do(func(netNS ns.NetNS) error {
+				gws, err = c.podLinks.GetDefaultGatewayByName(c.currentInterface, c.ipFamily, unix.RT_TABLE_MAIN)
+				if err != nil {
+					logger.Error("failed to GetDefaultGatewayByName", zap.Error(err))
+					return fmt.Errorf("failed to GetDefaultGatewayByName: %v", err)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
 
-	//  we do detect gateway connection firstly
-	if conf.DetectGateway != nil && *conf.DetectGateway {
-		logger.Debug("Try to detect gateway")
+			logger.Debug("Get GetDefaultGatewayByName", zap.Strings("Gws", gws), zap.String("mode", string(conf.DetectGatewayMode)))
+
+			for _, gw := range gws {
+				probes, err := c.gatewayProbes(conf.DetectGatewayMode, conf.DetectOptions, gw, logger)
+				if err != nil {
+					return err
+				}
+				gw := gw
+				errg.Go(func() error {
+					return detectGateway(gw, probes)
+				})
+			}
+		} else {
+			logger.Debug("disable detect gateway")
+		}
 
-		var gws []string
-		err = c.netns.Do(func(netNS ns.NetNS) error {
-			gws, err = networking.GetDefaultGatewayByName(c.currentInterface, c.ipFamily)
+		if conf.IPConflict != nil && *conf.IPConflict {
+			logger.Debug("Try to detect ip conflict")
+			ipc, err := ipchecking.NewIPChecker(conf.DetectOptions.Retry, conf.DetectOptions.Interval, conf.DetectOptions.TimeOut, c.netns, logger)
 			if err != nil {
-				logger.Error("failed to GetDefaultGatewayByName", zap.Error(err))
-				return fmt.Errorf("failed to GetDefaultGatewayByName: %v", err)
+				return fmt.Errorf("failed to run NewIPChecker: %w", err)
 			}
-			return nil
-		})
+			ipc.DoIPConflictChecking(prevResult.IPs, c.currentInterface, errg)
+		} else {
+			logger.Debug("disable detect ip conflict")
+		}
+
+		err = errg.Wait()
+		endPhase()
 		if err != nil {
-			return err
+			logger.Error("failed to ip checking", zap.Error(err))
+			return fmt.Errorf("failed to ip checking: %w", err)
 		}
 
-		logger.Debug("Get GetDefaultGatewayByName", zap.Strings("Gws", gws))
+		// overwrite mac address
+		if len(conf.MacPrefix) != 0 {
+			// the agent derives the MAC from the pod UID, and resolves the collision with the other pods on the node
+			macResp, err := client.Daemonset.PostCoordinatorMac(daemonset.NewPostCoordinatorMacParams().WithPodMacArgs(
+				&models.PodMacArgs{
+					PodNamespace: pointer.String(string(k8sArgs.K8S_POD_NAMESPACE)),
+					PodName:      pointer.String(string(k8sArgs.K8S_POD_NAME)),
+					PodUID:       pointer.String(string(k8sArgs.K8S_POD_UID)),
+					IfName:       pointer.String(args.IfName),
+					MacPrefix:    conf.MacPrefix,
+				},
+			))
+			if err != nil {
+				return fmt.Errorf("failed to assign hardware address for interface %s with hardware_prefix(%s): %v", args.IfName, conf.MacPrefix, err)
+			}
 
-		for _, gw := range gws {
-			p, err := gwconnection.NewPinger(conf.DetectOptions.Retry, conf.DetectOptions.Interval, conf.DetectOptions.TimeOut, gw, logger)
+			hwAddr, err := net.ParseMAC(*macResp.Payload.Mac)
 			if err != nil {
-				return fmt.Errorf("failed to run NewPinger: %v", err)
+				return fmt.Errorf("invalid hardware address %s assigned by spiderpool-agent: %v", *macResp.Payload.Mac, err)
 			}
-			errg.Go(p.DetectGateway)
+			err = networking.SetHwAddress(c.netns, args.IfName, hwAddr)
+			c.podLinks.Invalidate()
+			if err != nil {
+				logger.Error("failed to override hardware address", zap.String("hardware address", hwAddr.String()), zap.Error(err))
+				return fmt.Errorf("failed to update hardware address for interface %s: %v", args.IfName, err)
+			}
+			logger.Info("Override hardware address successfully", zap.String("interface", args.IfName),
+				zap.String("hardware address", hwAddr.String()), zap.Int64("salt", macResp.Payload.Salt))
 		}
-	} else {
-		logger.Debug("disable detect gateway")
-	}
 
-	if conf.IPConflict != nil && *conf.IPConflict {
-		logger.Debug("Try to detect ip conflict")
-		ipc, err := ipchecking.NewIPChecker(conf.DetectOptions.Retry, conf.DetectOptions.Interval, conf.DetectOptions.TimeOut, c.netns, logger)
+		// =================================
+
+		// get all ip of pod
+		var allPodIp []netlink.Addr
+		err = c.netns.Do(func(netNS ns.NetNS) error {
+			allPodIp, err = networking.GetAllIPAddress(ipFamily, []string{`^lo$`})
+			if err != nil {
+				logger.Error("failed to GetAllIPAddress in pod", zap.Error(err))
+				return fmt.Errorf("failed to GetAllIPAddress in pod: %v", err)
+			}
+			return nil
+		})
 		if err != nil {
-			return fmt.Errorf("failed to run NewIPChecker: %w", err)
+			logger.Error("failed to all ip of pod", zap.Error(err))
+			return err
 		}
-		ipc.DoIPConflictChecking(prevResult.IPs, c.currentInterface, errg)
-	} else {
-		logger.Debug("disable detect ip conflict")
-	}
+		logger.Debug(fmt.Sprintf("all pod ip: %+v", allPodIp))
 
-	if err = errg.Wait(); err != nil {
-		logger.Error("failed to ip checking", zap.Error(err))
-		return fmt.Errorf("failed to ip checking: %w", err)
-	}
-
-	// overwrite mac address
-	if len(conf.MacPrefix) != 0 {
-		hwAddr, err := networking.OverwriteHwAddress(logger, c.netns, conf.MacPrefix, args.IfName)
+		// get ip addresses of the node
+		c.hostIPRouteForPod, err = GetAllHostIPRouteForPod(c, ipFamily, allPodIp)
 		if err != nil {
-			return fmt.Errorf("failed to update hardware address for interface %s, maybe hardware_prefix(%s) is invalid: %v", args.IfName, conf.MacPrefix, err)
+			logger.Error("failed to get IPAddressOnNode", zap.Error(err))
+			return fmt.Errorf("failed to get IPAddressOnNode: %v", err)
 		}
-		logger.Info("Override hardware address successfully", zap.String("interface", args.IfName), zap.String("hardware address", hwAddr))
-	}
+		logger.Debug(fmt.Sprintf("host IP for route to Pod: %+v", c.hostIPRouteForPod))
 
-	// =================================
+		// =================================
 
-	// get all ip of pod
-	var allPodIp []netlink.Addr
-	err = c.netns.Do(func(netNS ns.NetNS) error {
-		allPodIp, err = networking.GetAllIPAddress(ipFamily, []string{`^lo$`})
+		// get ips of this interface(preInterfaceName) from, including ipv4 and ipv6
+		c.currentAddress, err = networking.IPAddressByName(c.netns, args.IfName, ipFamily)
 		if err != nil {
-			logger.Error("failed to GetAllIPAddress in pod", zap.Error(err))
-			return fmt.Errorf("failed to GetAllIPAddress in pod: %v", err)
+			logger.Error(err.Error())
+			return fmt.Errorf("failed to IPAddressByName for pod %s : %v", args.IfName, err)
 		}
-		return nil
-	})
-	if err != nil {
-		logger.Error("failed to all ip of pod", zap.Error(err))
-		return err
-	}
-	logger.Debug(fmt.Sprintf("all pod ip: %+v", allPodIp))
 
-	// get ip addresses of the node
-	c.hostIPRouteForPod, err = GetAllHostIPRouteForPod(c, ipFamily, allPodIp)
-	if err != nil {
-		logger.Error("failed to get IPAddressOnNode", zap.Error(err))
-		return fmt.Errorf("failed to get IPAddressOnNode: %v", err)
-	}
-	logger.Debug(fmt.Sprintf("host IP for route to Pod: %+v", c.hostIPRouteForPod))
+		logger.Debug("Get currentAddress", zap.Any("currentAddress", c.currentAddress))
 
-	// =================================
+		// routes using the IPv6 address as source fail if the address is still tentative
+		for _, addr := range c.currentAddress {
+			if addr.IP.To4() != nil {
+				continue
+			}
+			if err = networking.WaitForDADComplete(c.netns, args.IfName, addr.IP, defaultDADTimeout); err != nil {
+				logger.Error("failed to WaitForDADComplete", zap.String("address", addr.IP.String()), zap.Error(err))
+				if errors.Is(err, networking.ErrDADFailed) {
+					// fail the ADD so that the runtime invokes DEL and the IP is released by IPAM
+					return fmt.Errorf("failed to allocate IP %v: it is already used by others on the network: %w", addr.IP, err)
+				}
+				return err
+			}
+		}
 
-	// get ips of this interface(preInterfaceName) from, including ipv4 and ipv6
-	c.currentAddress, err = networking.IPAddressByName(c.netns, args.IfName, ipFamily)
-	if err != nil {
-		logger.Error(err.Error())
-		return fmt.Errorf("failed to IPAddressByName for pod %s : %v", args.IfName, err)
-	}
+		if conf.GatewayDiscovery == GatewayDiscoveryRA && ipFamily != netlink.FAMILY_V4 && !hasIPv6Gateway(prevResult) {
+			if err = c.setupIPv6GatewayByRA(logger); err != nil {
+				logger.Error("failed to setupIPv6GatewayByRA", zap.Error(err))
+				return err
+			}
+		}
 
-	logger.Debug("Get currentAddress", zap.Any("currentAddress", c.currentAddress))
+		if conf.RPFilter != -1 {
+			if err = sysctl.SysctlRPFilter(c.netns, conf.RPFilter); err != nil {
+				logger.Error(err.Error())
+				return err
+			}
+		}
 
-	if ipFamily != netlink.FAMILY_V4 {
-		// ensure ipv6 is enable
-		if err := sysctl.EnableIpv6Sysctl(c.netns); err != nil {
-			logger.Error(err.Error())
+		if err = c.setupNeighborhood(logger); err != nil {
+			logger.Error("failed to setupNeighborhood", zap.Error(err))
 			return err
 		}
-	}
 
-	if conf.RPFilter != -1 {
-		if err = sysctl.SysctlRPFilter(c.netns, conf.RPFilter); err != nil {
+		if c.tuneMode == ModeUnderlay && *conf.EnableProxyNDP {
+			if err = c.setupProxyNeighbor(logger); err != nil {
+				logger.Error("failed to setupProxyNeighbor", zap.Error(err))
+				return err
+			}
+		}
+
+		c.currentRuleTable = c.mustGetRuleNumber(c.currentInterface)
+		if c.currentRuleTable < 0 {
+			logger.Error("failed to get the rule table of the current interface")
+			return fmt.Errorf("failed to get the rule table of interface %s", c.currentInterface)
+		}
+		logger.Debug("Get currentRuleTable", zap.Int("ruleTable", c.currentRuleTable))
+
+		if c.currentRuleTable != unix.RT_TABLE_MAIN {
+			err = c.netns.Do(func(_ ns.NetNS) error {
+				return networking.EnsureRuleTableAvailable(c.currentRuleTable, c.ipFamily)
+			})
+			if err != nil {
+				logger.Error("failed to EnsureRuleTableAvailable", zap.Error(err))
+				return err
+			}
+		}
+
+		endPhase = report.StartPhase(phaseRuleInstall)
+		if err = c.setupHostRoutes(logger); err != nil {
 			logger.Error(err.Error())
 			return err
 		}
-	}
 
-	if err = c.setupNeighborhood(logger); err != nil {
-		logger.Error("failed to setupNeighborhood", zap.Error(err))
-		return err
-	}
+		if err = c.setupHijackRoutes(logger, c.currentRuleTable); err != nil {
+			logger.Error("failed to setupHijackRoutes", zap.Error(err))
+			return err
+		}
 
-	c.currentRuleTable = c.mustGetRuleNumber(c.podNics)
-	if c.currentRuleTable < 0 {
-		logger.Error("coordinator must be working with spiderpool: no spiderendpoint records found", zap.Strings("spiderNics", c.podNics))
-		return fmt.Errorf("coordinator must be working with spiderpool: no spiderendpoint records found")
-	}
-	logger.Debug("Get currentRuleTable", zap.Int("ruleTable", c.currentRuleTable))
+		if c.tuneMode == ModeUnderlay && *conf.EnableReplyViaVeth {
+			if err = c.makeReplyPacketViaVeth(logger); err != nil {
+				logger.Error("failed to makeReplyPacketViaVeth", zap.Error(err))
+				return fmt.Errorf("failed to makeReplyPacketViaVeth: %v", err)
+			} else {
+				logger.Sugar().Infof("Successfully to ensure reply packet is forward by veth0")
+			}
+		}
 
-	if err = c.setupHostRoutes(logger); err != nil {
-		logger.Error(err.Error())
-		return err
-	}
+		endPhase()
 
-	if err = c.setupHijackRoutes(logger, c.currentRuleTable); err != nil {
-		logger.Error("failed to setupHijackRoutes", zap.Error(err))
-		return err
-	}
+		if conf.TunePodRoutes != nil && *conf.TunePodRoutes && (!c.firstInvoke || c.tuneMode == ModeOverlay) {
+			logger.Debug("Try to tune pod routes")
+			endPhase = report.StartPhase(phaseRouteMove)
+			err = c.tunePodRoutes(logger, conf.PodDefaultRouteNIC)
+			endPhase()
+			if err != nil {
+				logger.Error("failed to tunePodRoutes", zap.Error(err))
+				return fmt.Errorf("failed to tunePodRoutes: %v", err)
+			}
+			logger.Debug("Success to tune pod routes")
+		}
 
-	if c.tuneMode == ModeUnderlay {
-		if err = c.makeReplyPacketViaVeth(logger); err != nil {
-			logger.Error("failed to makeReplyPacketViaVeth", zap.Error(err))
-			return fmt.Errorf("failed to makeReplyPacketViaVeth: %v", err)
-		} else {
-			logger.Sugar().Infof("Successfully to ensure reply packet is forward by veth0")
+		if err = c.setupTableRoutes(logger); err != nil {
+			logger.Error("failed to setupTableRoutes", zap.Error(err))
+			return fmt.Errorf("failed to setupTableRoutes: %v", err)
 		}
-	}
 
-	if conf.TunePodRoutes != nil && *conf.TunePodRoutes && (!c.firstInvoke || c.tuneMode == ModeOverlay) {
-		logger.Debug("Try to tune pod routes")
-		if err = c.tunePodRoutes(logger, conf.PodDefaultRouteNIC); err != nil {
-			logger.Error("failed to tunePodRoutes", zap.Error(err))
-			return fmt.Errorf("failed to tunePodRoutes: %v", err)
+		// the overlay NIC, whose routes are in main table, is not a member of the load-balanced default route
+		if conf.DefaultRouteMode == DefaultRouteModeLoadBalance && c.currentRuleTable != unix.RT_TABLE_MAIN {
+			if err = c.loadBalanceDefaultRoute(logger, *conf.DefaultRouteWeight); err != nil {
+				logger.Error("failed to loadBalanceDefaultRoute", zap.Error(err))
+				return fmt.Errorf("failed to loadBalanceDefaultRoute: %v", err)
+			}
 		}
-		logger.Debug("Success to tune pod routes")
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	logger.Sugar().Infof("coordinator end, time cost: %v", time.Since(startTime))
@@ -12,17 +12,25 @@ import (
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/vishvananda/netlink"
 	"go.uber.org/zap"
+	"k8s.io/utils/pointer"
 
 	"github.com/spidernet-io/spiderpool/api/v1/agent/client/daemonset"
 	"github.com/spidernet-io/spiderpool/api/v1/agent/models"
 	plugincmd "github.com/spidernet-io/spiderpool/cmd/spiderpool/cmd"
 	"github.com/spidernet-io/spiderpool/pkg/constant"
 	"github.com/spidernet-io/spiderpool/pkg/logutils"
+	"github.com/spidernet-io/spiderpool/pkg/metric/cnireport"
 	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
 	"github.com/spidernet-io/spiderpool/pkg/openapi"
 )
 
 func CmdDel(args *skel.CmdArgs) (err error) {
+	report := cnireport.NewReport(cnireport.CmdDel, args.ContainerID)
+	defer func() {
+		report.Finish(err)
+		_ = cnireport.Write(constant.DefaultCoordinatorReportDir, report)
+	}()
+
 	k8sArgs := plugincmd.K8sArgs{}
 	if err = types.LoadArgs(args.Args, &k8sArgs); nil != err {
 		return fmt.Errorf("failed to load CNI ENV args: %w", err)
@@ -35,6 +43,7 @@ func CmdDel(args *skel.CmdArgs) (err error) {
 
 	resp, err := client.Daemonset.GetCoordinatorConfig(daemonset.NewGetCoordinatorConfigParams().WithGetCoordinatorConfig(
 		&models.GetCoordinatorArgs{
+			CniCommand:   constant.CNICommandDel,
 			PodName:      string(k8sArgs.K8S_POD_NAME),
 			PodNamespace: string(k8sArgs.K8S_POD_NAMESPACE),
 		},
@@ -69,11 +78,28 @@ func CmdDel(args *skel.CmdArgs) (err error) {
 
 	logger.Info(fmt.Sprintf("start to implement DELETE command in %v mode", conf.Mode))
 
+	if len(conf.MacPrefix) != 0 {
+		_, err = client.Daemonset.DeleteCoordinatorMac(daemonset.NewDeleteCoordinatorMacParams().WithPodMacArgs(
+			&models.PodMacArgs{
+				PodNamespace: pointer.String(string(k8sArgs.K8S_POD_NAMESPACE)),
+				PodName:      pointer.String(string(k8sArgs.K8S_POD_NAME)),
+				PodUID:       pointer.String(string(k8sArgs.K8S_POD_UID)),
+				IfName:       pointer.String(args.IfName),
+			},
+		))
+		if err != nil {
+			// ignore err, the MAC is taken over once the pod is gone
+			logger.Sugar().Warnf("failed to release hardware address of interface %s: %v", args.IfName, err)
+		}
+	}
+
 	c := &coordinator{
 		hostRuleTable: int(*conf.HostRuleTable),
 	}
 
+	endPhase := report.StartPhase(phaseNetnsEnter)
 	c.netns, err = ns.GetNS(args.Netns)
+	endPhase()
 	if err != nil {
 		if _, ok := err.(ns.NSPathNotExistErr); ok {
 			logger.Sugar().Debug("Pod's netns already gone. Nothing to do.")
@@ -84,6 +110,22 @@ func CmdDel(args *skel.CmdArgs) (err error) {
 	}
 	defer c.netns.Close()
 
+	endPhase = report.StartPhase(phaseCleanup)
+	defer endPhase()
+
+	err = c.netns.Do(func(netNS ns.NetNS) error {
+		c.currentAddress, err = networking.GetAddersByName(args.IfName, netlink.FAMILY_ALL)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+
+	if err != nil {
+		// ignore err
+		logger.Sugar().Warn("failed to GetAddersByName, ignore error", zap.Error(err))
+	}
+
 	hostVeth := getHostVethName(args.ContainerID)
 	vethLink, err := netlink.LinkByName(hostVeth)
 	if err != nil {
@@ -94,6 +136,14 @@ func CmdDel(args *skel.CmdArgs) (err error) {
 			return fmt.Errorf("failed to get host veth device %s: %v", hostVeth, err)
 		}
 	} else {
+		if *conf.EnableProxyNDP {
+			c.hostVethName = hostVeth
+			if err = c.cleanupProxyNeighbor(logger); err != nil {
+				logger.Error("failed to cleanupProxyNeighbor", zap.Error(err))
+				return fmt.Errorf("failed to cleanupProxyNeighbor: %v", err)
+			}
+		}
+
 		if err = netlink.LinkDel(vethLink); err != nil {
 			logger.Sugar().Warn("failed to del hostVeth", zap.Error(err))
 			return fmt.Errorf("failed to del hostVeth %s: %w", hostVeth, err)
@@ -101,17 +151,26 @@ func CmdDel(args *skel.CmdArgs) (err error) {
 		logger.Sugar().Debug("success to del hostVeth", zap.String("HostVeth", hostVeth))
 	}
 
-	err = c.netns.Do(func(netNS ns.NetNS) error {
-		c.currentAddress, err = networking.GetAddersByName(args.IfName, netlink.FAMILY_ALL)
+	// the rules are installed in underlay mode along with veth0, which is set up
+	// by the first interface of the pod
+	if *conf.EnableReplyViaVeth && conf.Mode != ModeOverlay && args.IfName == conf.PodDefaultCniNic {
+		err = c.netns.Do(func(netNS ns.NetNS) error {
+			return c.cleanupReplyPacketViaVeth(logger)
+		})
 		if err != nil {
-			return err
+			logger.Error("failed to cleanupReplyPacketViaVeth", zap.Error(err))
+			return fmt.Errorf("failed to cleanupReplyPacketViaVeth: %v", err)
 		}
-		return nil
-	})
+	}
 
-	if err != nil {
-		// ignore err
-		logger.Sugar().Warn("failed to GetAddersByName, ignore error", zap.Error(err))
+	if conf.DefaultRouteMode == DefaultRouteModeLoadBalance {
+		err = c.netns.Do(func(netNS ns.NetNS) error {
+			return cleanupLoadBalanceDefaultRoute()
+		})
+		if err != nil {
+			logger.Error("failed to cleanupLoadBalanceDefaultRoute", zap.Error(err))
+			return fmt.Errorf("failed to cleanupLoadBalanceDefaultRoute: %v", err)
+		}
 	}
 
 	for idx := range c.currentAddress {
@@ -4,10 +4,14 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"os"
+	"strconv"
+	"strings"
 
+	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/plugins/pkg/ip"
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/vishvananda/netlink"
@@ -16,19 +20,38 @@ import (
 	utiliptables "k8s.io/kubernetes/pkg/util/iptables"
 	"k8s.io/utils/exec"
 
+	"github.com/spidernet-io/spiderpool/api/v1/agent/models"
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/metric/cnireport"
+	"github.com/spidernet-io/spiderpool/pkg/networking/gwconnection"
 	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+	"github.com/spidernet-io/spiderpool/pkg/networking/sysctl"
 )
 
 type coordinator struct {
 	firstInvoke                                 bool
 	ipFamily, currentRuleTable, hostRuleTable   int
+	hijackRouteMTU                              int
 	tuneMode                                    Mode
+	routeTableMode                              RouteTableMode
 	hostVethName, podVethName, currentInterface string
 	HijackCIDR, podNics                         []string
 	netns                                       ns.NetNS
 	hostVethHwAddress, podVethHwAddress         net.HardwareAddr
 	currentAddress                              []netlink.Addr
 	hostIPRouteForPod                           []net.IP
+	podRoutes                                   []*models.Route
+	// invertHijackDst is the hijack CIDR of every family matched by the
+	// inverted rules, nil if the invertHijackRule strategy is disabled
+	invertHijackDst map[int]*net.IPNet
+	// podLinks caches the links of the pod's netns during the ADD
+	podLinks *networking.LinkCache
+	// routeOpts and ruleOpts carry AllowMissingIPv6 if enableIPv6 is false
+	routeOpts []networking.RouteOption
+	ruleOpts  []networking.RuleOption
+	// report records the best-effort steps failed without failing the ADD,
+	// which are emitted as the events of the pod by spiderpool-agent
+	report *cnireport.Report
 }
 
 func (c *coordinator) autoModeToSpecificMode(mode Mode, podFirstInterface string) error {
@@ -113,21 +136,21 @@ func (c *coordinator) coordinatorModeAndFirstInvoke(logger *zap.Logger, podFirst
 	return fmt.Errorf("unknown tuneMode: %s", c.tuneMode)
 }
 
-// getRuleNumber return the number of rule table corresponding to the previous interface from the given interface.
+// mustGetRuleNumber return the number of rule table of the given interface,
+// which is derived from the interface name by networking.GetRuleNumber.
 // for example:
-// input: net1, output: 100(eth0)
-// input: net2, output: 101(net1)
-func (c *coordinator) mustGetRuleNumber(spiderNics []string) int {
-	if len(spiderNics) == 0 {
-		return -1
+// input: eth0, output: 100
+// input: net1, output: 101
+func (c *coordinator) mustGetRuleNumber(nic string) int {
+	if nic == defaultOverlayVethName {
+		return unix.RT_TABLE_MAIN
 	}
 
-	if c.currentInterface == defaultOverlayVethName {
-		return unix.RT_TABLE_MAIN
-	} else if spiderNics[0] == c.currentInterface {
-		return defaultPodRuleTable
+	ruleTable, err := networking.GetRuleNumber(nic)
+	if err != nil {
+		return -1
 	}
-	return defaultPodRuleTable + len(spiderNics) - 1
+	return ruleTable
 }
 
 // setupVeth sets up a pair of virtual ethernet devices. move one to the host and other
@@ -174,6 +197,7 @@ func (c *coordinator) setupNeighborhood(logger *zap.Logger) error {
 	nList, err := netlink.NeighList(0, c.ipFamily)
 	if err != nil {
 		logger.Warn("failed to get NeighList, ignore clean dirty neigh table")
+		c.report.AddWarning("neigh list", err)
 	}
 
 	hostVethlink, err := netlink.LinkByName(c.hostVethName)
@@ -188,6 +212,7 @@ func (c *coordinator) setupNeighborhood(logger *zap.Logger) error {
 				if err = netlink.NeighDel(&nList[idx]); err != nil && !os.IsNotExist(err) {
 					logger.Warn("failed to clean dirty neigh table, it may cause the pod can't communicate with the node, please clean it up manually",
 						zap.String("dirty neigh table", nList[idx].String()))
+					c.report.AddWarning("dirty neigh "+nList[idx].String(), err)
 				} else {
 					logger.Debug("successfully cleaned up the dirty neigh table", zap.String("dirty neigh table", nList[idx].String()))
 				}
@@ -230,6 +255,51 @@ func (c *coordinator) setupNeighborhood(logger *zap.Logger) error {
 	return err
 }
 
+// setupProxyNeighbor makes the host answer the ARP and the neighbor solicitation
+// for the pod's addresses on the host veth.
+// equivalent to: `sysctl -w net.ipv4.conf.<hostVeth>.proxy_arp=1` and `ip -6 neigh add proxy <podIP> dev <hostVeth>`
+func (c *coordinator) setupProxyNeighbor(logger *zap.Logger) error {
+	proxyARPEnabled, proxyNDPEnabled := false, false
+	for _, ipAddr := range c.currentAddress {
+		if ipAddr.IP.To4() != nil {
+			if !proxyARPEnabled {
+				if err := sysctl.SetProxyARP(c.hostVethName, true); err != nil {
+					return err
+				}
+				proxyARPEnabled = true
+			}
+			continue
+		}
+
+		if !proxyNDPEnabled {
+			if err := sysctl.SetProxyNDP(c.hostVethName, true); err != nil {
+				return err
+			}
+			proxyNDPEnabled = true
+		}
+		if err := networking.AddProxyNDP(c.hostVethName, ipAddr.IP); err != nil {
+			return err
+		}
+		logger.Debug("Add proxy neigh successfully", zap.String("HostVeth", c.hostVethName), zap.String("IP", ipAddr.IP.String()))
+	}
+	return nil
+}
+
+// cleanupProxyNeighbor removes the proxy neighbor entries of the pod's addresses
+// which are added by setupProxyNeighbor
+func (c *coordinator) cleanupProxyNeighbor(logger *zap.Logger) error {
+	for _, ipAddr := range c.currentAddress {
+		if ipAddr.IP.To4() != nil {
+			continue
+		}
+		if err := networking.DelProxyNDP(c.hostVethName, ipAddr.IP); err != nil {
+			return err
+		}
+		logger.Debug("Del proxy neigh successfully", zap.String("HostVeth", c.hostVethName), zap.String("IP", ipAddr.IP.String()))
+	}
+	return nil
+}
+
 // setupRoutes setup hijack subnet routes for pod and host
 // equivalent to: `ip route add $route table $ruleTable`
 func (c *coordinator) setupHijackRoutes(logger *zap.Logger, ruleTable int) error {
@@ -243,7 +313,7 @@ func (c *coordinator) setupHijackRoutes(logger *zap.Logger, ruleTable int) error
 
 	err = c.netns.Do(func(_ ns.NetNS) error {
 		// make sure that veth0/eth0 forwards traffic within the cluster
-		// eq: ip route add <cluster/service cidr> dev veth0/eth0
+		// eq: ip route add <cluster/service cidr> dev veth0/eth0 [mtu <hijackRouteMTU>]
 		for _, hijack := range c.HijackCIDR {
 			nip, ipNet, err := net.ParseCIDR(hijack)
 			if err != nil {
@@ -253,21 +323,25 @@ func (c *coordinator) setupHijackRoutes(logger *zap.Logger, ruleTable int) error
 
 			if nip.To4() != nil && v4Gw == nil {
 				logger.Warn("ignore adding hijack routing table(ipv4), due to ipv4 gateway is nil", zap.String("IPv4 Hijack cidr", hijack))
+				c.report.AddWarning("hijack route "+hijack, errors.New("no ipv4 gateway"))
 				continue
 			}
 
 			if nip.To4() == nil && v6Gw == nil {
 				logger.Warn("ignore adding hijack routing table(ipv6), due to ipv6 gateway is nil", zap.String("IPv6 Hijack cidr", hijack))
+				c.report.AddWarning("hijack route "+hijack, errors.New("no ipv6 gateway"))
 				continue
 			}
 
-			if err := networking.AddRoute(logger, ruleTable, c.ipFamily, netlink.SCOPE_UNIVERSE, c.podVethName, ipNet, v4Gw, v6Gw); err != nil {
+			if err := c.podLinks.AddRoute(logger, ruleTable, c.ipFamily, netlink.SCOPE_UNIVERSE, c.podVethName, ipNet, v4Gw, v6Gw,
+				networking.WithMTU(c.hijackRouteMTU)); err != nil {
 				logger.Error("failed to AddRoute for hijackCIDR", zap.String("Dst", ipNet.String()), zap.Error(err))
 				return fmt.Errorf("failed to AddRoute for hijackCIDR: %v", err)
 			}
 
 			if c.tuneMode == ModeOverlay && c.firstInvoke {
-				if err := networking.AddRoute(logger, unix.RT_TABLE_MAIN, c.ipFamily, netlink.SCOPE_UNIVERSE, c.podVethName, ipNet, v4Gw, v6Gw); err != nil {
+				if err := c.podLinks.AddRoute(logger, unix.RT_TABLE_MAIN, c.ipFamily, netlink.SCOPE_UNIVERSE, c.podVethName, ipNet, v4Gw, v6Gw,
+					networking.WithMTU(c.hijackRouteMTU)); err != nil {
 					logger.Error("failed to AddRoute for hijackCIDR", zap.String("Dst", ipNet.String()), zap.Error(err))
 					return fmt.Errorf("failed to AddRoute for hijackCIDR: %v", err)
 				}
@@ -291,13 +365,13 @@ func (c *coordinator) setupHostRoutes(logger *zap.Logger) error {
 		// eq: "ip r add <ipAddressOnNode> dev veth0/eth0 table <ruleTable>"
 		for _, hostAddress := range c.hostIPRouteForPod {
 			ipNet := networking.ConvertMaxMaskIPNet(hostAddress)
-			if err = networking.AddRoute(logger, c.currentRuleTable, c.ipFamily, netlink.SCOPE_LINK, c.podVethName, ipNet, nil, nil); err != nil {
+			if err = c.podLinks.AddRoute(logger, c.currentRuleTable, c.ipFamily, netlink.SCOPE_LINK, c.podVethName, ipNet, nil, nil); err != nil {
 				logger.Error("failed to AddRoute for ipAddressOnNode", zap.Error(err))
 				return fmt.Errorf("failed to AddRouteTable for ipAddressOnNode: %v", err)
 			}
 
 			if c.tuneMode == ModeOverlay && c.firstInvoke {
-				if err = networking.AddRoute(logger, unix.RT_TABLE_MAIN, c.ipFamily, netlink.SCOPE_LINK, c.podVethName, ipNet, nil, nil); err != nil {
+				if err = c.podLinks.AddRoute(logger, unix.RT_TABLE_MAIN, c.ipFamily, netlink.SCOPE_LINK, c.podVethName, ipNet, nil, nil); err != nil {
 					logger.Error("failed to AddRoute for ipAddressOnNode", zap.Error(err))
 					return fmt.Errorf("failed to AddRouteTable for ipAddressOnNode: %v", err)
 				}
@@ -342,6 +416,7 @@ func (c *coordinator) setupHostRoutes(logger *zap.Logger) error {
 		filterRoutes, err := netlink.RouteListFiltered(c.ipFamily, filterRoute, netlink.RT_FILTER_TABLE)
 		if err != nil {
 			logger.Warn("failed to fetch route list filter by RT_FILTER_DST, ignore clean dirty route table")
+			c.report.AddWarning(fmt.Sprintf("route list of table %d", c.hostRuleTable), err)
 		}
 
 		for idx := range filterRoutes {
@@ -349,6 +424,7 @@ func (c *coordinator) setupHostRoutes(logger *zap.Logger) error {
 				if err = netlink.RouteDel(&filterRoutes[idx]); err != nil && !os.IsNotExist(err) {
 					logger.Warn("failed to clean dirty route table, it may cause the pod can't communicate with the node, please clean it up manually",
 						zap.String("dirty route table", filterRoutes[idx].String()))
+					c.report.AddWarning("dirty route "+filterRoutes[idx].String(), err)
 				} else {
 					logger.Debug("successfully cleaned up the dirty route table", zap.String("dirty route table", filterRoutes[idx].String()))
 				}
@@ -357,7 +433,7 @@ func (c *coordinator) setupHostRoutes(logger *zap.Logger) error {
 
 		// set routes for host
 		// equivalent: ip add  <chainedIPs> dev <hostVethName> table  on host
-		if err = networking.AddRoute(logger, c.hostRuleTable, c.ipFamily, netlink.SCOPE_LINK, c.hostVethName, ipNet, nil, nil); err != nil {
+		if err = networking.AddRoute(logger, c.hostRuleTable, c.ipFamily, netlink.SCOPE_LINK, c.hostVethName, ipNet, nil, nil, c.routeOpts...); err != nil {
 			logger.Error("failed to AddRouteTable for preInterfaceIPAddress", zap.Error(err))
 			return fmt.Errorf("failed to AddRouteTable for preInterfaceIPAddress: %v", err)
 		}
@@ -394,6 +470,7 @@ func (c *coordinator) tunePodRoutes(logger *zap.Logger, configDefaultRouteNIC st
 	if podDefaultRouteNIC == "" {
 		// TODO(cyclinder): should we be return?
 		logger.Warn("podDefaultRouteNIC no found in pod, ignore tuneRoutes")
+		c.report.AddWarning("default route of pod", errors.New("no interface with the default route found, the routes are not tuned"))
 		return nil
 	}
 	logger.Sugar().Infof("podDefaultRouteNIC: %v", podDefaultRouteNIC)
@@ -401,7 +478,7 @@ func (c *coordinator) tunePodRoutes(logger *zap.Logger, configDefaultRouteNIC st
 	// make sure that traffic sent from current interface to lookup table <ruleTable>
 	// eq: ip rule add from <currentInterfaceIPAddress> lookup <ruleTable>
 	err = c.netns.Do(func(_ ns.NetNS) error {
-		defaultInterfaceAddress, err := networking.GetAddersByName(podDefaultRouteNIC, c.ipFamily)
+		defaultInterfaceAddress, err := c.podLinks.GetAddersByName(podDefaultRouteNIC, c.ipFamily)
 		if err != nil {
 			logger.Error("failed to GetAdders for podDefaultRouteNIC", zap.Error(err))
 			return fmt.Errorf("failed to GetDefaultRouteInterface for podDefaultRouteNIC: %v", err)
@@ -410,7 +487,7 @@ func (c *coordinator) tunePodRoutes(logger *zap.Logger, configDefaultRouteNIC st
 		logger.Sugar().Infof("defaultInterfaceAddress: %v", defaultInterfaceAddress)
 
 		// get all routes of current interface
-		currentInterfaceRoutes, err := networking.GetRoutesByName(c.currentInterface, c.ipFamily)
+		currentInterfaceRoutes, err := c.podLinks.GetRoutesByName(c.currentInterface, c.ipFamily)
 		if err != nil {
 			logger.Error("failed to GetRoutesByName", zap.Error(err))
 			return fmt.Errorf("failed to GetRoutesByName: %v", err)
@@ -419,7 +496,7 @@ func (c *coordinator) tunePodRoutes(logger *zap.Logger, configDefaultRouteNIC st
 		logger.Sugar().Infof("currentInterfaceRoutes: %v", currentInterfaceRoutes)
 
 		// get all routes of default route interface
-		defaultInterfaceRoutes, err := networking.GetRoutesByName(podDefaultRouteNIC, c.ipFamily)
+		defaultInterfaceRoutes, err := c.podLinks.GetRoutesByName(podDefaultRouteNIC, c.ipFamily)
 		if err != nil {
 			logger.Error("failed to GetRoutesByName", zap.Error(err))
 			return fmt.Errorf("failed to GetRoutesByName: %v", err)
@@ -427,10 +504,25 @@ func (c *coordinator) tunePodRoutes(logger *zap.Logger, configDefaultRouteNIC st
 
 		logger.Sugar().Infof("defaultInterfaceRoutes: %v", defaultInterfaceRoutes)
 
-		if configDefaultRouteNIC == c.currentInterface {
+		if configDefaultRouteNIC == c.currentInterface && c.invertHijackDst != nil {
+			if err = c.tunePodRoutesByInvertRule(logger, defaultInterfaceAddress); err != nil {
+				return err
+			}
+		} else if configDefaultRouteNIC == c.currentInterface {
+			// the routes of podDefaultRouteNIC are moved out of main, so they
+			// go to the rule table of podDefaultRouteNIC
+			ruleTable := c.mustGetRuleNumber(podDefaultRouteNIC)
+			if ruleTable < 0 {
+				return fmt.Errorf("failed to get the rule table of interface %s", podDefaultRouteNIC)
+			}
+			if err = networking.EnsureRuleTableAvailable(ruleTable, c.ipFamily); err != nil {
+				logger.Error("failed to EnsureRuleTableAvailable", zap.Int("ruleTable", ruleTable), zap.Error(err))
+				return err
+			}
+
 			for idx, route := range defaultInterfaceRoutes {
 				if route.Dst != nil {
-					if err := networking.AddToRuleTable(defaultInterfaceRoutes[idx].Dst, c.currentRuleTable); err != nil {
+					if err := networking.AddToRuleTable(defaultInterfaceRoutes[idx].Dst, ruleTable); err != nil {
 						logger.Error("failed to AddToRuleTable", zap.Error(err))
 						return fmt.Errorf("failed to AddToRuleTable: %v", err)
 					}
@@ -439,7 +531,7 @@ func (c *coordinator) tunePodRoutes(logger *zap.Logger, configDefaultRouteNIC st
 
 			for idx := range defaultInterfaceAddress {
 				ipNet := networking.ConvertMaxMaskIPNet(defaultInterfaceAddress[idx].IP)
-				err = networking.AddFromRuleTable(ipNet, c.currentRuleTable)
+				err = networking.AddFromRuleTable(ipNet, ruleTable)
 				if err != nil {
 					logger.Error("failed to AddFromRuleTable", zap.Error(err))
 					return err
@@ -447,7 +539,7 @@ func (c *coordinator) tunePodRoutes(logger *zap.Logger, configDefaultRouteNIC st
 			}
 
 			// move all routes of the specified interface to a new route table
-			if err = networking.MoveRouteTable(logger, podDefaultRouteNIC, unix.RT_TABLE_MAIN, c.currentRuleTable, c.ipFamily); err != nil {
+			if err = c.migrateRouteTable(logger, podDefaultRouteNIC, unix.RT_TABLE_MAIN, ruleTable); err != nil {
 				return err
 			}
 
@@ -471,7 +563,11 @@ func (c *coordinator) tunePodRoutes(logger *zap.Logger, configDefaultRouteNIC st
 			}
 
 			// move all routes of the specified interface from src rule table to dst route table
-			if err = networking.MoveRouteTable(logger, c.currentInterface, unix.RT_TABLE_MAIN, c.currentRuleTable, c.ipFamily); err != nil {
+			if err = c.migrateRouteTable(logger, c.currentInterface, unix.RT_TABLE_MAIN, c.currentRuleTable); err != nil {
+				return err
+			}
+
+			if err = c.setNoPrefixRoute(logger, c.currentInterface); err != nil {
 				return err
 			}
 		} else {
@@ -500,23 +596,28 @@ func (c *coordinator) tunePodRoutes(logger *zap.Logger, configDefaultRouteNIC st
 			}
 
 			// move current interface's routes to new rule table
-			if err = networking.MoveRouteTable(logger, c.currentInterface, unix.RT_TABLE_MAIN, c.currentRuleTable, c.ipFamily); err != nil {
+			if err = c.migrateRouteTable(logger, c.currentInterface, unix.RT_TABLE_MAIN, c.currentRuleTable); err != nil {
 				return err
 			}
 
-			routes, err := networking.GetRoutesByName(configDefaultRouteNIC, c.ipFamily)
+			if err = c.setNoPrefixRoute(logger, c.currentInterface); err != nil {
+				return err
+			}
+
+			routes, err := c.podLinks.GetRoutesByName(configDefaultRouteNIC, c.ipFamily)
 			if err != nil {
 				return fmt.Errorf("failed to GetRoutesByName for configDefaultRouteNIC: %v", err)
 			}
 
-			address, err := networking.GetAddersByName(configDefaultRouteNIC, c.ipFamily)
+			address, err := c.podLinks.GetAddersByName(configDefaultRouteNIC, c.ipFamily)
 			if err != nil {
 				return fmt.Errorf("failed to GetAddrs for configDefaultRouteNIC: %v", err)
 			}
 
-			ruleTable := c.mustGetRuleNumber(c.podNics)
+			// the routes of configDefaultRouteNIC are in its own rule table
+			ruleTable := c.mustGetRuleNumber(configDefaultRouteNIC)
 			if ruleTable < 0 {
-				return fmt.Errorf("coordinator must be working with spiderpool: no spiderendpoint records found")
+				return fmt.Errorf("failed to get the rule table of interface %s", configDefaultRouteNIC)
 			}
 
 			// 1. cleanup ip rule to cidr for configDefaultRouteNIC interface
@@ -538,11 +639,24 @@ func (c *coordinator) tunePodRoutes(logger *zap.Logger, configDefaultRouteNIC st
 			}
 
 			// 3. move configDefaultRouteNIC interface's routes to main table
-			if err = networking.MoveRouteTable(logger, configDefaultRouteNIC, ruleTable, unix.RT_TABLE_MAIN, c.ipFamily); err != nil {
+			if err = c.migrateRouteTable(logger, configDefaultRouteNIC, ruleTable, unix.RT_TABLE_MAIN); err != nil {
 				return err
 			}
 		}
 
+		// make the connected routes in main take precedence over the rules of the
+		// rule tables, while the default route is still policy-routed
+		// eq: ip rule add lookup main suppress_prefixlength 0 pref 1001
+		for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+			if c.ipFamily != family && c.ipFamily != netlink.FAMILY_ALL {
+				continue
+			}
+			if err = networking.AddSuppressPrefixRule(unix.RT_TABLE_MAIN, family, defaultSuppressRulePriority, 0, c.ruleOpts...); err != nil && !os.IsExist(err) {
+				logger.Error("failed to AddSuppressPrefixRule", zap.Int("family", family), zap.Error(err))
+				return fmt.Errorf("failed to AddSuppressPrefixRule: %v", err)
+			}
+		}
+
 		// for idx, _ := range c.hostIPRouteForPod {
 		//	ipNet := networking.ConvertMaxMaskIPNet(c.hostIPRouteForPod[idx])
 		//	if err = networking.DelToRuleTable(ipNet, c.hostRuleTable); err != nil {
@@ -563,6 +677,338 @@ func (c *coordinator) tunePodRoutes(logger *zap.Logger, configDefaultRouteNIC st
 	return nil
 }
 
+// tunePodRoutesByInvertRule makes the current interface the default route NIC
+// without enumerating the routes of podDefaultRouteNIC, which are kept in main.
+// The traffic not destined to the hijack CIDR lookups the table of the current
+// interface, except for the traffic from the addresses of podDefaultRouteNIC.
+// equivalent to:
+//
+//	ip rule add from <podDefaultRouteNIC address> lookup main pref 1002
+//	ip rule add not to <hijack CIDR> lookup <ruleTable> pref 1003
+//	ip rule add from <current interface address> lookup <ruleTable>
+//	ip route del <routes> dev <current interface> && ip route add <routes> dev <current interface> table <ruleTable>
+func (c *coordinator) tunePodRoutesByInvertRule(logger *zap.Logger, defaultInterfaceAddress []netlink.Addr) error {
+	for idx := range defaultInterfaceAddress {
+		ipNet := networking.ConvertMaxMaskIPNet(defaultInterfaceAddress[idx].IP)
+		if err := networking.AddFromRuleTableOrdered(ipNet, unix.RT_TABLE_MAIN, defaultInvertFromRulePriority); err != nil && !os.IsExist(err) {
+			logger.Error("failed to AddFromRuleTableOrdered", zap.String("src", ipNet.String()), zap.Error(err))
+			return fmt.Errorf("failed to AddFromRuleTableOrdered: %v", err)
+		}
+	}
+
+	for family, dst := range c.invertHijackDst {
+		if err := networking.AddNotToRuleTable(dst, c.currentRuleTable, family, defaultInvertHijackRulePriority); err != nil && !os.IsExist(err) {
+			logger.Error("failed to AddNotToRuleTable", zap.String("dst", dst.String()), zap.Error(err))
+			return fmt.Errorf("failed to AddNotToRuleTable: %v", err)
+		}
+	}
+
+	for idx := range c.currentAddress {
+		ipNet := networking.ConvertMaxMaskIPNet(c.currentAddress[idx].IP)
+		if err := networking.AddFromRuleTable(ipNet, c.currentRuleTable); err != nil {
+			logger.Error("failed to AddFromRuleTable", zap.Error(err))
+			return err
+		}
+	}
+
+	if err := c.migrateRouteTable(logger, c.currentInterface, unix.RT_TABLE_MAIN, c.currentRuleTable); err != nil {
+		return err
+	}
+	return c.setNoPrefixRoute(logger, c.currentInterface)
+}
+
+// getInvertHijackDst returns the hijack CIDR of every family of the pod for
+// the invertHijackRule strategy. A family must have exactly one CIDR, because
+// the inverted rules of multiple CIDRs would match all the destinations.
+func getInvertHijackDst(hijackCIDR []string, ipFamily int) (map[int]*net.IPNet, error) {
+	dsts := make(map[int]*net.IPNet)
+	for _, cidr := range hijackCIDR {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, err
+		}
+		family := netlink.FAMILY_V6
+		if ipNet.IP.To4() != nil {
+			family = netlink.FAMILY_V4
+		}
+		if ipFamily != family && ipFamily != netlink.FAMILY_ALL {
+			continue
+		}
+		if _, ok := dsts[family]; ok {
+			return nil, fmt.Errorf("invertHijackRule requires a single hijackCIDR covering the cluster per ip family, got %v", hijackCIDR)
+		}
+		dsts[family] = ipNet
+	}
+
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		if ipFamily != family && ipFamily != netlink.FAMILY_ALL {
+			continue
+		}
+		if _, ok := dsts[family]; !ok {
+			return nil, fmt.Errorf("invertHijackRule requires a hijackCIDR of ip family %d", family)
+		}
+	}
+	return dsts, nil
+}
+
+// setupTableRoutes moves the routes of the current interface, whose route table
+// is specified in the IPPool, to the table.
+// equivalent to: `ip route del <dst> dev <iface> && ip route add <dst> via <gw> dev <iface> table <table>`
+func (c *coordinator) setupTableRoutes(logger *zap.Logger) error {
+	for _, route := range c.podRoutes {
+		if route.IfName == nil || *route.IfName != c.currentInterface || route.Dst == nil || route.Gw == nil {
+			continue
+		}
+
+		table, err := c.resolveRouteTable(route.Table)
+		if err != nil {
+			return fmt.Errorf("invalid table of route %s: %v", *route.Dst, err)
+		}
+
+		_, dst, err := net.ParseCIDR(*route.Dst)
+		if err != nil {
+			return fmt.Errorf("invalid dst of route: %v", err)
+		}
+		gw := net.ParseIP(*route.Gw)
+
+		err = c.netns.Do(func(_ ns.NetNS) error {
+			if err := c.podLinks.AddRoute(logger, table, c.ipFamily, netlink.SCOPE_UNIVERSE, c.currentInterface, dst, gw, gw); err != nil {
+				return err
+			}
+
+			// the route is installed in main table by the main CNI, and may be moved to the table of the interface
+			for _, srcTable := range []int{unix.RT_TABLE_MAIN, c.currentRuleTable} {
+				if srcTable == table {
+					continue
+				}
+				if err := c.podLinks.DelRoute(srcTable, c.currentInterface, dst); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Error("failed to move the route to its table", zap.String("dst", *route.Dst), zap.Int("table", table), zap.Error(err))
+			return err
+		}
+		logger.Debug("move the route to its table", zap.String("dst", *route.Dst), zap.Int("table", table))
+	}
+
+	return nil
+}
+
+// resolveRouteTable returns the table number of the route table of the IPPool route,
+// the "interface" table is illegal for the NIC whose routes are in main table.
+func (c *coordinator) resolveRouteTable(table string) (int, error) {
+	switch table {
+	case constant.RouteTableMain:
+		return unix.RT_TABLE_MAIN, nil
+	case constant.RouteTableInterface:
+		if c.currentRuleTable == unix.RT_TABLE_MAIN {
+			return 0, fmt.Errorf("the %q table is only available for the non-default NIC, but %s is the default NIC", table, c.currentInterface)
+		}
+		return c.currentRuleTable, nil
+	}
+
+	num, err := strconv.Atoi(table)
+	if err != nil || num < constant.RouteTableMin || num > constant.RouteTableMax {
+		return 0, fmt.Errorf("table %q must be %q, %q or a number from %d to %d", table,
+			constant.RouteTableMain, constant.RouteTableInterface, constant.RouteTableMin, constant.RouteTableMax)
+	}
+	return num, nil
+}
+
+// loadBalanceDefaultRoute merges the default gateway of the current interface
+// into the weighted multipath default route in main table, so that the default
+// traffic is balanced across the pod's NICs managed by spiderpool. A family
+// falls back to single path if only one NIC has the gateway of the family.
+// equivalent to: `ip route replace default nexthop via <gw1> dev net1 weight <w1> nexthop via <gw2> dev net2 weight <w2>`
+func (c *coordinator) loadBalanceDefaultRoute(logger *zap.Logger, weight int) error {
+	return c.netns.Do(func(_ ns.NetNS) error {
+		linkIndex, _, err := c.podLinks.ResolveLinkStable(c.currentInterface)
+		if err != nil {
+			return err
+		}
+
+		for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+			if c.ipFamily != family && c.ipFamily != netlink.FAMILY_ALL {
+				continue
+			}
+
+			gw, err := c.getCurrentDefaultGateway(family)
+			if err != nil {
+				return err
+			}
+			if gw == nil {
+				logger.Warn("no default gateway found on the interface, the default route of the family falls back to single path",
+					zap.String("interface", c.currentInterface), zap.Int("ipFamily", family))
+				c.report.AddWarning(fmt.Sprintf("load-balanced default route of family %d", family),
+					fmt.Errorf("no default gateway on %s, fall back to single path", c.currentInterface))
+				continue
+			}
+
+			dst := defaultRouteDst(family)
+			existing, err := networking.GetMultipathNexthops(unix.RT_TABLE_MAIN, dst)
+			if err != nil {
+				return fmt.Errorf("failed to get the default route: %v", err)
+			}
+
+			nexthops := make([]networking.Nexthop, 0, len(existing)+1)
+			for _, nh := range existing {
+				if nh.LinkIndex != linkIndex && nh.Gw != nil && c.isPodNIC(nh.LinkIndex) {
+					nexthops = append(nexthops, nh)
+				}
+			}
+			nexthops = append(nexthops, networking.Nexthop{LinkIndex: linkIndex, Gw: gw, Weight: weight})
+			if len(nexthops) == 1 {
+				logger.Warn("only the interface has the default gateway, the default route of the family falls back to single path",
+					zap.String("interface", c.currentInterface), zap.Int("ipFamily", family))
+			}
+
+			if err = networking.AddMultipathRoute(logger, unix.RT_TABLE_MAIN, dst, nexthops); err != nil {
+				return err
+			}
+			logger.Debug("Set load-balanced default route successfully", zap.Int("ipFamily", family), zap.Any("nexthops", nexthops))
+		}
+		return nil
+	})
+}
+
+// getCurrentDefaultGateway returns the default gateway of the current interface,
+// which may have been moved to the policy route table of the interface
+func (c *coordinator) getCurrentDefaultGateway(family int) (net.IP, error) {
+	for _, table := range []int{c.currentRuleTable, unix.RT_TABLE_MAIN} {
+		gws, err := c.podLinks.GetDefaultGatewayByName(c.currentInterface, family, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to GetDefaultGatewayByName: %v", err)
+		}
+		if len(gws) > 0 {
+			return net.ParseIP(gws[0]), nil
+		}
+	}
+	return nil, nil
+}
+
+// isPodNIC returns true if the link is one of the pod's NICs managed by spiderpool
+func (c *coordinator) isPodNIC(linkIndex int) bool {
+	link, err := netlink.LinkByIndex(linkIndex)
+	if err != nil {
+		return false
+	}
+	for _, nic := range c.podNics {
+		if nic == link.Attrs().Name {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanupLoadBalanceDefaultRoute removes the multipath default routes set up
+// by loadBalanceDefaultRoute, even if some member interface is already gone.
+// it must be called in pod's netns.
+func cleanupLoadBalanceDefaultRoute() error {
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		if err := networking.DelMultipathRoute(unix.RT_TABLE_MAIN, defaultRouteDst(family)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func defaultRouteDst(family int) *net.IPNet {
+	if family == netlink.FAMILY_V4 {
+		return &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}
+	}
+	return &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}
+}
+
+// hasIPv6Gateway returns true if any IPv6 address of the result has the gateway,
+// which comes from the IP pool.
+func hasIPv6Gateway(result *current.Result) bool {
+	for _, ipc := range result.IPs {
+		if ipc.Address.IP.To4() == nil && ipc.Gateway != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// setupIPv6GatewayByRA learns the IPv6 gateway of the current interface by the
+// Router Advertisement, and installs the default route via its link-local address
+// in main table. The default route installed by the kernel for accept_ra is adopted.
+// equivalent to: `ip -6 route add default via <fe80::gw> dev <currentInterface>`
+func (c *coordinator) setupIPv6GatewayByRA(logger *zap.Logger) error {
+	var gws []string
+	err := c.netns.Do(func(_ ns.NetNS) error {
+		var err error
+		gws, err = c.podLinks.GetDefaultGatewayByName(c.currentInterface, netlink.FAMILY_V6, unix.RT_TABLE_MAIN)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to GetDefaultGatewayByName: %v", err)
+	}
+	if len(gws) > 0 {
+		logger.Info("the IPv6 default route of the interface already exists, adopt it",
+			zap.String("interface", c.currentInterface), zap.Strings("gateways", gws))
+		return nil
+	}
+
+	gw, err := gwconnection.DiscoverIPv6Gateway(c.netns, c.currentInterface, defaultRATimeout)
+	if err != nil {
+		return err
+	}
+	logger.Info("Discover the IPv6 gateway by router advertisement", zap.String("interface", c.currentInterface), zap.String("gateway", gw.String()))
+
+	// the kernel may install the same default route for accept_ra meanwhile,
+	// which is not an error for AddRoute
+	return c.netns.Do(func(_ ns.NetNS) error {
+		return c.podLinks.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V6, netlink.SCOPE_UNIVERSE,
+			c.currentInterface, defaultRouteDst(netlink.FAMILY_V6), nil, gw)
+	})
+}
+
+// migrateRouteTable move or copy all routes of the iface from srcRuleTable to dstRuleTable,
+// it depends on the routeTableMode.
+func (c *coordinator) migrateRouteTable(logger *zap.Logger, iface string, srcRuleTable, dstRuleTable int) error {
+	if c.routeTableMode == RouteTableModeCopy {
+		return c.podLinks.CopyRouteTable(logger, iface, srcRuleTable, dstRuleTable, c.ipFamily, c.routeOpts...)
+	}
+	return c.podLinks.MoveRouteTable(logger, iface, srcRuleTable, dstRuleTable, c.ipFamily, nil, false, c.routeOpts...)
+}
+
+// setNoPrefixRoute re-applies the IPv6 addresses of the secondary interface with
+// noprefixroute after its routes are moved, so that the kernel doesn't create the
+// prefix route in main table again and the only routes of the interface are the
+// ones we program. NOTE: the kernel can't update the flags of an existing IPv4 address.
+func (c *coordinator) setNoPrefixRoute(logger *zap.Logger, iface string) error {
+	if c.routeTableMode != RouteTableModeMove || c.ipFamily == netlink.FAMILY_V4 {
+		return nil
+	}
+
+	addrs, err := c.podLinks.GetAddersByName(iface, netlink.FAMILY_V6)
+	if err != nil {
+		return fmt.Errorf("failed to GetAddersByName for %s: %v", iface, err)
+	}
+
+	for _, addr := range addrs {
+		if addr.Flags&unix.IFA_F_NOPREFIXROUTE != 0 {
+			continue
+		}
+
+		err = networking.AddAddress(nil, iface, addr.IPNet, networking.AddrOptions{
+			NoPrefixRoute: true,
+			NoDAD:         addr.Flags&unix.IFA_F_NODAD != 0,
+			PreferedLft:   addr.PreferedLft,
+			ValidLft:      addr.ValidLft,
+		})
+		if err != nil {
+			logger.Error("failed to set noprefixroute for address", zap.String("address", addr.IPNet.String()), zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
 // makeReplyPacketViaVeth make sure that tcp replay packet is forward by veth0
 // NOTE: underlay mode only.
 func (c *coordinator) makeReplyPacketViaVeth(logger *zap.Logger) error {
@@ -595,11 +1041,11 @@ func (c *coordinator) makeReplyPacketViaVeth(logger *zap.Logger) error {
 		}
 
 		for _, family := range ipFamily {
-			if err := networking.AddRuleTableWithMark(markInt, c.hostRuleTable, family); err != nil && !os.IsExist(err) {
+			if err := networking.AddRuleTableWithMark(markInt, c.hostRuleTable, family, networking.DefaultMarkRulePriority, c.ruleOpts...); err != nil && !os.IsExist(err) {
 				return fmt.Errorf("failed to add rule table with mark: %v", err)
 			}
 
-			if err = networking.AddRoute(logger, c.hostRuleTable, family, netlink.SCOPE_UNIVERSE, c.podVethName, nil, v4Gw, v6Gw); err != nil {
+			if err = c.podLinks.AddRoute(logger, c.hostRuleTable, family, netlink.SCOPE_UNIVERSE, c.podVethName, nil, v4Gw, v6Gw, c.routeOpts...); err != nil {
 				return err
 			}
 		}
@@ -627,44 +1073,112 @@ func getMarkString(mark int) string {
 	return fmt.Sprintf("%#08x", mark)
 }
 
+type iptablesRule struct {
+	action string
+	table  utiliptables.Table
+	chain  utiliptables.Chain
+	args   []string
+}
+
+// replyViaVethIPtablesRules returns the mangle rules which mark the new connections
+// coming from veth0, so that the reply packets could be forwarded by veth0.
+func replyViaVethIPtablesRules() []iptablesRule {
+	markStr := getMarkString(getMarkInt(defaultMarkBit))
+	return []iptablesRule{
+		{
+			action: "set-xmark",
+			table:  utiliptables.TableMangle,
+			chain:  utiliptables.ChainPrerouting,
+			args: []string{
+				"-i", defaultUnderlayVethName,
+				"-m", "conntrack",
+				"--ctstate", "NEW",
+				"-j", "MARK",
+				"--set-xmark", markStr,
+			},
+		},
+		{
+			action: "save-mark",
+			table:  utiliptables.TableMangle,
+			chain:  utiliptables.ChainPrerouting,
+			args: []string{
+				"-m", "mark",
+				"--mark", markStr,
+				"-j", "CONNMARK",
+				"--save-mark",
+			},
+		},
+		{
+			action: "restore-mark",
+			table:  utiliptables.TableMangle,
+			chain:  utiliptables.ChainOutput,
+			args: []string{
+				"-j", "CONNMARK",
+				"--restore-mark",
+			},
+		},
+	}
+}
+
 func (c *coordinator) ensureIPtablesRule(iptablesInterfaces []utiliptables.Interface) error {
-	markStr := getMarkString(getMarkInt(0))
 	for _, ipt := range iptablesInterfaces {
 		if ipt == nil {
 			continue
 		}
-		_, err := ipt.EnsureRule(utiliptables.Append, utiliptables.TableMangle, utiliptables.ChainPrerouting, []string{
-			"-i", defaultUnderlayVethName,
-			"-m", "conntrack",
-			"--ctstate", "NEW",
-			"-j", "MARK",
-			"--set-xmark", markStr,
-		}...)
-		if err != nil {
-			return fmt.Errorf("iptables ensureRule err: failed to set-xmark: %v", err)
+		for _, rule := range replyViaVethIPtablesRules() {
+			if _, err := ipt.EnsureRule(utiliptables.Append, rule.table, rule.chain, rule.args...); err != nil {
+				return fmt.Errorf("iptables ensureRule err: failed to %s: %v", rule.action, err)
+			}
 		}
+	}
+	return nil
+}
 
-		_, err = ipt.EnsureRule(utiliptables.Append, utiliptables.TableMangle, utiliptables.ChainPrerouting, []string{
-			"-m", "mark",
-			"--mark", markStr,
-			"-j", "CONNMARK",
-			"--save-mark",
-		}...)
-		if err != nil {
-			return fmt.Errorf("iptables ensureRule err: failed to save-mark: %v", err)
+// cleanupReplyPacketViaVeth removes the iptables rules and the fwmark rules
+// which are set up by makeReplyPacketViaVeth, it must be called in pod's netns.
+// The IPv6 ones are skipped if IPv6 is unsupported in the pod's netns.
+func (c *coordinator) cleanupReplyPacketViaVeth(logger *zap.Logger) error {
+	protocols := []utiliptables.Protocol{utiliptables.ProtocolIPv4}
+	families := []int{netlink.FAMILY_V4}
+	ipv6Supported, err := networking.IPv6Supported()
+	if err != nil {
+		logger.Warn("failed to check whether IPv6 is supported, clean up the IPv6 rules anyway", zap.Error(err))
+		ipv6Supported = true
+	}
+	if ipv6Supported {
+		protocols = append(protocols, utiliptables.ProtocolIPv6)
+		families = append(families, netlink.FAMILY_V6)
+	}
+
+	execer := exec.New()
+	markInt := getMarkInt(defaultMarkBit)
+	for _, protocol := range protocols {
+		ipt := utiliptables.New(execer, protocol)
+		for _, rule := range replyViaVethIPtablesRules() {
+			if err := ipt.DeleteRule(rule.table, rule.chain, rule.args...); err != nil {
+				logger.Warn("failed to delete iptables rule", zap.String("rule", rule.action), zap.Error(err))
+			}
 		}
+	}
 
-		_, err = ipt.EnsureRule(utiliptables.Append, utiliptables.TableMangle, utiliptables.ChainOutput, []string{
-			"-j", "CONNMARK",
-			"--restore-mark",
-		}...)
-		if err != nil {
-			return fmt.Errorf("iptables ensureRule err: failed to restore-mark: %v", err)
+	for _, family := range families {
+		if err := networking.DelRuleTableWithMark(markInt, c.hostRuleTable, family, networking.DefaultMarkRulePriority); err != nil && !isGoneOrUnsupported(err) {
+			return fmt.Errorf("failed to delete rule table with mark: %v", err)
+		}
+
+		if err := networking.FlushRouteTable(c.hostRuleTable, family); err != nil && !isGoneOrUnsupported(err) {
+			return fmt.Errorf("failed to flush routes of table %d: %v", c.hostRuleTable, err)
 		}
 	}
 	return nil
 }
 
+// isGoneOrUnsupported returns true if the rule or the route to delete is gone
+// already, or the address family is unsupported by the kernel
+func isGoneOrUnsupported(err error) bool {
+	return os.IsNotExist(err) || errors.Is(err, unix.ENOENT) || errors.Is(err, unix.EAFNOSUPPORT)
+}
+
 func GetAllHostIPRouteForPod(c *coordinator, ipFamily int, allPodIp []netlink.Addr) (finalNodeIpList []net.IP, e error) {
 
 	finalNodeIpList = []net.IP{}
@@ -726,3 +1240,50 @@ OUTER2:
 
 	return finalNodeIpList, nil
 }
+
+// gatewayProbe is a probe of the reachability of the gateway, named after the
+// packets it sends, such as arp, ndp and icmp
+type gatewayProbe struct {
+	name   string
+	detect func() error
+}
+
+// gatewayProbes returns the probes of the gateway in the order they run: the
+// link-layer resolution goes first, and icmp only in the mode icmp or both.
+func (c *coordinator) gatewayProbes(mode DetectGatewayMode, opts *DetectOptions, gw string, logger *zap.Logger) ([]gatewayProbe, error) {
+	var probes []gatewayProbe
+	if mode == DetectGatewayModeARP || mode == DetectGatewayModeBoth {
+		p, err := gwconnection.NewNeighborProber(opts.Retry, opts.Interval, opts.TimeOut, gw, c.netns, c.currentInterface, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run NewNeighborProber: %v", err)
+		}
+		name := "arp"
+		if !strings.Contains(gw, ".") {
+			name = "ndp"
+		}
+		probes = append(probes, gatewayProbe{name: name, detect: p.DetectGateway})
+	}
+	if mode == DetectGatewayModeICMP || mode == DetectGatewayModeBoth {
+		p, err := gwconnection.NewPinger(opts.Retry, opts.Interval, opts.TimeOut, gw, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run NewPinger: %v", err)
+		}
+		probes = append(probes, gatewayProbe{name: "icmp", detect: p.DetectGateway})
+	}
+	return probes, nil
+}
+
+// detectGateway runs the probes one by one, the gateway is reachable only if
+// it answers all of them. The error tells the probes attempted, which ends up
+// in the event of the pod.
+func detectGateway(gw string, probes []gatewayProbe) error {
+	attempted := make([]string, 0, len(probes))
+	for _, probe := range probes {
+		attempted = append(attempted, probe.name)
+		if err := probe.detect(); err != nil {
+			return fmt.Errorf("gateway %s is unreachable by %s (probes attempted: %s): %w",
+				gw, probe.name, strings.Join(attempted, ","), err)
+		}
+	}
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 	"runtime"
 
 	"github.com/spidernet-io/spiderpool/cmd/coordinator/cmd"
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/metric/cnireport"
 
 	"github.com/containernetworking/cni/pkg/skel"
 	cniSpecVersion "github.com/containernetworking/cni/pkg/version"
@@ -24,5 +26,8 @@ func main() {
 }
 
 func cmdCheck(args *skel.CmdArgs) error {
+	report := cnireport.NewReport(cnireport.CmdCheck, args.ContainerID)
+	report.Finish(nil)
+	_ = cnireport.Write(constant.DefaultCoordinatorReportDir, report)
 	return nil
 }
@@ -79,29 +79,8 @@ func CmdAdd(args *skel.CmdArgs) error {
 			return err
 		}
 
-		vlanLink, err := netlink.LinkByName(vlanName)
-		if err == nil {
-			if vlanLink.Attrs().Flags != net.FlagUp {
-				if err = netlink.LinkSetUp(vlanLink); err != nil {
-					return fmt.Errorf("failed to set %s up: %v", vlanLink.Attrs().Name, err)
-				}
-			}
-			return nil
-		}
-
-		if _, ok := err.(netlink.LinkNotFoundError); !ok {
-			return fmt.Errorf("failed to LinkByName %s: %v", vlanName, err)
-		}
-
-		// create vlan interface
-		if err = networking.LinkAdd(&netlink.Vlan{
-			LinkAttrs: netlink.LinkAttrs{
-				Name:        vlanName,
-				ParentIndex: bond.Index,
-			},
-			VlanId: conf.VlanID,
-		}); err != nil {
-			return fmt.Errorf("failed to create vlan interface %s: %w", vlanName, err)
+		if _, err = networking.EnsureVlanInterface(conf.Bond.Name, conf.VlanID); err != nil {
+			return err
 		}
 
 		return types.PrintResult(result, conf.CNIVersion)
@@ -155,42 +134,8 @@ func createBondDevice(conf *Ifacer) (*netlink.Bond, error) {
 }
 
 func createVlanDevice(conf *Ifacer) error {
-	var err error
-	var vlanLink netlink.Link
-	vlanIfName := getVlanIfaceName(conf.Interfaces[0], conf.VlanID)
-	vlanLink, err = netlink.LinkByName(vlanIfName)
-	if err == nil {
-		if vlanLink.Attrs().Flags != net.FlagUp {
-			if err = netlink.LinkSetUp(vlanLink); err != nil {
-				return fmt.Errorf("failed to set %s up: %v", vlanLink.Attrs().Name, err)
-			}
-		}
-		return nil
-	}
-
-	if _, ok := err.(netlink.LinkNotFoundError); !ok {
-		return fmt.Errorf("failed to LinkByName %s: %v", vlanIfName, err)
-	}
-
-	// we create vlanif if it only not present
-	var parentLink netlink.Link
-	parentLink, err = netlink.LinkByName(conf.Interfaces[0])
-	if err != nil {
-		return fmt.Errorf("failed to LinkByName %s: %w", conf.Interfaces[0], err)
-	}
-
-	if parentLink.Attrs().Flags != net.FlagUp {
-		if err = netlink.LinkSetUp(parentLink); err != nil {
-			return fmt.Errorf("failed to set %s up: %v", parentLink.Attrs().Name, err)
-		}
-	}
-
-	// we only create if vlanIf not present
-	return networking.LinkAdd(&netlink.Vlan{
-		LinkAttrs: netlink.LinkAttrs{
-			Name:        vlanIfName,
-			ParentIndex: parentLink.Attrs().Index,
-		},
-		VlanId: conf.VlanID,
-	})
+	// the existing interface is reused only if it's the vlan on the master,
+	// rather than an unrelated one with the same name
+	_, err := networking.EnsureVlanInterface(conf.Interfaces[0], conf.VlanID)
+	return err
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/spf13/pflag"
 	"go.uber.org/atomic"
 	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/spidernet-io/spiderpool/api/v1/agent/client"
@@ -23,6 +24,8 @@ import (
 	"github.com/spidernet-io/spiderpool/pkg/ippoolmanager"
 	"github.com/spidernet-io/spiderpool/pkg/logutils"
 	"github.com/spidernet-io/spiderpool/pkg/namespacemanager"
+	"github.com/spidernet-io/spiderpool/pkg/networking/macregistry"
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
 	"github.com/spidernet-io/spiderpool/pkg/nodemanager"
 	"github.com/spidernet-io/spiderpool/pkg/podmanager"
 	"github.com/spidernet-io/spiderpool/pkg/reservedipmanager"
@@ -58,10 +61,23 @@ var envInfo = []envConf{
 	{"SPIDERPOOL_PYROSCOPE_PUSH_SERVER_ADDRESS", "", false, &agentContext.Cfg.PyroscopeAddress, nil, nil},
 
 	{"SPIDERPOOL_IPPOOL_MAX_ALLOCATED_IPS", "5000", true, nil, nil, &agentContext.Cfg.IPPoolMaxAllocatedIPs},
+	{"SPIDERPOOL_IP_REUSE_COOLDOWN", "0", false, nil, nil, &agentContext.Cfg.IPReuseCooldown},
+	{"SPIDERPOOL_WORKLOADENDPOINT_MAX_HISTORY_RECORDS", "5", false, nil, nil, &agentContext.Cfg.EndpointMaxHistoryRecords},
 	{"SPIDERPOOL_WAIT_SUBNET_POOL_TIME_IN_SECOND", "2", false, nil, nil, &agentContext.Cfg.WaitSubnetPoolTime},
 	{"SPIDERPOOL_WAIT_SUBNET_POOL_MAX_RETRIES", "25", false, nil, nil, &agentContext.Cfg.WaitSubnetPoolMaxRetries},
 
 	{"MULTUS_CLUSTER_NETWORK", "", false, &agentContext.Cfg.MultusClusterNetwork, nil, nil},
+
+	{"SPIDERPOOL_NODE_NAME", "", false, &agentContext.Cfg.NodeName, nil, nil},
+	{"SPIDERPOOL_ENABLED_ROUTE_REPAIR", "false", false, nil, &agentContext.Cfg.EnableRouteRepair, nil},
+	{"SPIDERPOOL_SELF_CHECK_ALLOW_DEGRADED", "false", false, nil, &agentContext.Cfg.SelfCheckAllowDegraded, nil},
+	{"SPIDERPOOL_ENABLED_STALE_RULE_CLEANUP", "false", false, nil, &agentContext.Cfg.EnableStaleRuleCleanup, nil},
+	{"SPIDERPOOL_STALE_RULE_PRIORITY_RANGE", "", false, &agentContext.Cfg.StaleRulePriorityRange, nil, nil},
+	{"SPIDERPOOL_STALE_RULE_TABLES", "", false, &agentContext.Cfg.StaleRuleTables, nil, nil},
+	{"SPIDERPOOL_PROMISC_RECONCILE_INTERVAL", "60", false, nil, nil, &agentContext.Cfg.PromiscReconcileInterval},
+	{"SPIDERPOOL_CNI_CONFIG_DIR", "/etc/cni/net.d", false, &agentContext.Cfg.CniConfigDir, nil, nil},
+	{"SPIDERPOOL_POD_NAMESPACE", "", false, &agentContext.Cfg.AgentPodNamespace, nil, nil},
+	{"SPIDERPOOL_CONTROLLER_NAME", "spiderpool-controller", false, &agentContext.Cfg.ControllerName, nil, nil},
 }
 
 type Config struct {
@@ -71,7 +87,8 @@ type Config struct {
 	GoMaxProcs    int
 
 	// flags
-	ConfigPath string
+	ConfigPath      string
+	ReadinessStrict bool
 
 	// env
 	LogLevel               string
@@ -83,12 +100,30 @@ type Config struct {
 	GopsListenPort   string
 	PyroscopeAddress string
 
-	IPPoolMaxAllocatedIPs    int
-	WaitSubnetPoolTime       int
-	WaitSubnetPoolMaxRetries int
+	IPPoolMaxAllocatedIPs     int
+	IPReuseCooldown           int
+	WaitSubnetPoolTime        int
+	WaitSubnetPoolMaxRetries  int
+	EndpointMaxHistoryRecords int
 
 	MultusClusterNetwork string
 
+	NodeName               string
+	EnableRouteRepair      bool
+	SelfCheckAllowDegraded bool
+	EnableStaleRuleCleanup bool
+	StaleRulePriorityRange string
+	StaleRuleTables        string
+
+	PromiscReconcileInterval int
+	CniConfigDir             string
+
+	// AgentPodNamespace and ControllerName locate the spiderpool-controller
+	// pods, which are the only callers allowed to request the IP conflict
+	// detection
+	AgentPodNamespace string
+	ControllerName    string
+
 	// configmap
 	IpamUnixSocketPath                string   `yaml:"ipamUnixSocketPath"`
 	EnableIPv4                        bool     `yaml:"enableIPv4"`
@@ -121,6 +156,7 @@ type AgentContext struct {
 	PodManager        podmanager.PodManager
 	StsManager        statefulsetmanager.StatefulSetManager
 	SubnetManager     subnetmanager.SubnetManager
+	MACRegistry       macregistry.MACRegistry
 
 	// handler
 	HttpServer        *server.Server
@@ -129,14 +165,18 @@ type AgentContext struct {
 
 	// client
 	unixClient *client.SpiderpoolAgentAPI
+	// apiServerClient only requests the health endpoints of API Server
+	apiServerClient rest.Interface
 
 	// probe
-	IsStartupProbe atomic.Bool
+	IsStartupProbe      atomic.Bool
+	SelfCheckConditions []networking.SelfCheckCondition
 }
 
 // BindAgentDaemonFlags bind agent cli daemon flags
 func (ac *AgentContext) BindAgentDaemonFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&ac.Cfg.ConfigPath, "config-path", "/tmp/spiderpool/config-map/conf.yml", "spiderpool-agent configmap file")
+	flags.BoolVar(&ac.Cfg.ReadinessStrict, "readiness-strict", false, "fail the readiness if the CNI config is missing or API Server is unreachable, besides the self-check and the IPAM server")
 }
 
 // ParseConfiguration set the env to AgentConfiguration
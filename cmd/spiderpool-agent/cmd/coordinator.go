@@ -12,6 +12,7 @@ import (
 	"github.com/spidernet-io/spiderpool/pkg/constant"
 	"github.com/spidernet-io/spiderpool/pkg/coordinatormanager"
 	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
+	"github.com/spidernet-io/spiderpool/pkg/utils/convert"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
@@ -27,6 +28,10 @@ func (g *_unixGetCoordinatorConfig) Handle(params daemonset.GetCoordinatorConfig
 	podClient := agentContext.PodManager
 	epClient := agentContext.EndpointManager
 
+	// coordinator deletes the routes and rules of the pod on CNI DEL, which
+	// must not be repaired by the route watcher
+	cniDelPods.record(params.GetCoordinatorConfig.PodNamespace, params.GetCoordinatorConfig.PodName, params.GetCoordinatorConfig.CniCommand)
+
 	var coordList spiderpoolv2beta1.SpiderCoordinatorList
 	if err := crdClient.List(ctx, &coordList); err != nil {
 		return daemonset.NewGetCoordinatorConfigFailure().WithPayload(models.Error(err.Error()))
@@ -43,6 +48,7 @@ func (g *_unixGetCoordinatorConfig) Handle(params daemonset.GetCoordinatorConfig
 
 	var err error
 	var spNics []string
+	var podRoutes []*models.Route
 	var se *spiderpoolv2beta1.SpiderEndpoint
 	// get spiderendpoint
 	se, err = epClient.GetEndpointByName(ctx, params.GetCoordinatorConfig.PodNamespace, params.GetCoordinatorConfig.PodName, constant.UseCache)
@@ -53,6 +59,13 @@ func (g *_unixGetCoordinatorConfig) Handle(params daemonset.GetCoordinatorConfig
 	if se != nil {
 		for _, spip := range se.Status.Current.IPs {
 			spNics = append(spNics, spip.NIC)
+
+			// only the routes with the table need to be programmed by coordinator
+			for _, route := range convert.ConvertSpecRoutesToOAIRoutes(spip.NIC, spip.Routes) {
+				if route.Table != "" {
+					podRoutes = append(podRoutes, route)
+				}
+			}
 		}
 	}
 
@@ -72,6 +85,41 @@ func (g *_unixGetCoordinatorConfig) Handle(params daemonset.GetCoordinatorConfig
 		nic = *coord.Spec.PodDefaultRouteNIC
 	}
 
+	var routeTableMode string
+	if coord.Spec.RouteTableMode != nil {
+		routeTableMode = *coord.Spec.RouteTableMode
+	}
+
+	enableReplyViaVeth := true
+	if coord.Spec.EnableReplyViaVeth != nil {
+		enableReplyViaVeth = *coord.Spec.EnableReplyViaVeth
+	}
+
+	var enableProxyNDP bool
+	if coord.Spec.EnableProxyNDP != nil {
+		enableProxyNDP = *coord.Spec.EnableProxyNDP
+	}
+
+	var defaultRouteMode string
+	if coord.Spec.DefaultRouteMode != nil {
+		defaultRouteMode = *coord.Spec.DefaultRouteMode
+	}
+
+	var defaultRouteWeight int64
+	if coord.Spec.DefaultRouteWeight != nil {
+		defaultRouteWeight = int64(*coord.Spec.DefaultRouteWeight)
+	}
+
+	var hijackRouteMTU int64
+	if coord.Spec.HijackRouteMTU != nil {
+		hijackRouteMTU = int64(*coord.Spec.HijackRouteMTU)
+	}
+
+	var detectGatewayMode string
+	if coord.Spec.DetectGatewayMode != nil {
+		detectGatewayMode = *coord.Spec.DetectGatewayMode
+	}
+
 	defaultRouteNic, ok := pod.Annotations[constant.AnnoDefaultRouteInterface]
 	if ok {
 		nic = defaultRouteNic
@@ -85,11 +133,19 @@ func (g *_unixGetCoordinatorConfig) Handle(params daemonset.GetCoordinatorConfig
 		PodMACPrefix:       prefix,
 		TunePodRoutes:      coord.Spec.TunePodRoutes,
 		PodDefaultRouteNIC: nic,
+		RouteTableMode:     routeTableMode,
+		EnableReplyViaVeth: enableReplyViaVeth,
+		EnableProxyNDP:     enableProxyNDP,
+		DefaultRouteMode:   defaultRouteMode,
+		DefaultRouteWeight: defaultRouteWeight,
+		HijackRouteMTU:     hijackRouteMTU,
 		HostRuleTable:      int64(*coord.Spec.HostRuleTable),
 		HostRPFilter:       int64(*coord.Spec.HostRPFilter),
 		DetectGateway:      *coord.Spec.DetectGateway,
+		DetectGatewayMode:  detectGatewayMode,
 		DetectIPConflict:   *coord.Spec.DetectIPConflict,
 		PodNICs:            spNics,
+		PodRoutes:          podRoutes,
 	}
 
 	if config.OverlayPodCIDR == nil {
@@ -0,0 +1,185 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/spidernet-io/spiderpool/api/v1/agent/models"
+	"github.com/spidernet-io/spiderpool/api/v1/agent/server/restapi/daemonset"
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/logutils"
+	"github.com/spidernet-io/spiderpool/pkg/networking/macregistry"
+)
+
+// initMACRegistry creates the registry of the MAC addresses assigned to the
+// pods on this node, and restores the ones recorded in the SpiderEndpoints.
+func initMACRegistry(ctx context.Context) error {
+	registry, err := macregistry.NewMACRegistry(macregistry.MACRegistryConfig{}, isMACOwnerAlive)
+	if err != nil {
+		return err
+	}
+	agentContext.MACRegistry = registry
+
+	log := logutils.FromContext(ctx)
+	if agentContext.Cfg.NodeName == "" {
+		log.Warn("node name is unknown, skip restoring the MAC addresses of the pods on the node")
+		return nil
+	}
+
+	endpointList, err := agentContext.EndpointManager.ListEndpoints(ctx, constant.UseCache)
+	if err != nil {
+		return fmt.Errorf("failed to list SpiderEndpoints: %w", err)
+	}
+
+	for _, endpoint := range endpointList.Items {
+		if endpoint.Status.Current.Node != agentContext.Cfg.NodeName {
+			continue
+		}
+
+		for _, detail := range endpoint.Status.Current.IPs {
+			if detail.MAC == nil {
+				continue
+			}
+			mac, err := net.ParseMAC(*detail.MAC)
+			if err != nil {
+				log.Sugar().Warnf("invalid MAC %s of SpiderEndpoint %s/%s: %v", *detail.MAC, endpoint.Namespace, endpoint.Name, err)
+				continue
+			}
+
+			owner := macregistry.Owner{
+				PodNamespace: endpoint.Namespace,
+				PodName:      endpoint.Name,
+				PodUID:       endpoint.Status.Current.UID,
+				NIC:          detail.NIC,
+			}
+			if !registry.Restore(owner, mac) {
+				log.Sugar().Warnf("MAC %s of SpiderEndpoint %s/%s is recorded by another SpiderEndpoint on the node", mac, endpoint.Namespace, endpoint.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// isMACOwnerAlive reports whether the pod owning a MAC address still runs on
+// this node, the API Server is requested directly for the up-to-date answer.
+func isMACOwnerAlive(ctx context.Context, owner macregistry.Owner) (bool, error) {
+	pod, err := agentContext.PodManager.GetPodByName(ctx, owner.PodNamespace, owner.PodName, constant.IgnoreCache)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return string(pod.UID) == owner.PodUID && pod.Spec.NodeName == agentContext.Cfg.NodeName, nil
+}
+
+var (
+	unixPostCoordinatorMac   = &_unixPostCoordinatorMac{}
+	unixDeleteCoordinatorMac = &_unixDeleteCoordinatorMac{}
+	httpGetCoordinatorMac    = &_httpGetCoordinatorMac{}
+)
+
+type _unixPostCoordinatorMac struct{}
+
+// Handle handles POST requests for /coordinator/mac.
+func (g *_unixPostCoordinatorMac) Handle(params daemonset.PostCoordinatorMacParams) middleware.Responder {
+	if err := params.PodMacArgs.Validate(strfmt.Default); err != nil {
+		return daemonset.NewPostCoordinatorMacFailure().WithPayload(models.Error(err.Error()))
+	}
+
+	owner := podMacOwner(params.PodMacArgs)
+	logger := logutils.Logger.Named("MAC").With(
+		zap.String("PodNamespace", owner.PodNamespace),
+		zap.String("PodName", owner.PodName),
+		zap.String("PodUID", owner.PodUID),
+		zap.String("IfName", owner.NIC),
+	)
+	ctx := logutils.IntoContext(params.HTTPRequest.Context(), logger)
+
+	entry, err := agentContext.MACRegistry.Assign(ctx, owner, params.PodMacArgs.MacPrefix)
+	if err != nil {
+		logger.Error(err.Error())
+		return daemonset.NewPostCoordinatorMacFailure().WithPayload(models.Error(err.Error()))
+	}
+	logger.Sugar().Infof("assigned MAC %s with salt %d", entry.MAC, entry.Salt)
+
+	// the pod may get its IPs from another IPAM, then there is no SpiderEndpoint
+	endpoint, err := agentContext.EndpointManager.GetEndpointByName(ctx, owner.PodNamespace, owner.PodName, constant.IgnoreCache)
+	switch {
+	case apierrors.IsNotFound(err):
+		logger.Debug("no SpiderEndpoint to record the MAC")
+	case err != nil:
+		logger.Sugar().Warnf("failed to get SpiderEndpoint to record the MAC: %v", err)
+	case endpoint.Status.Current.UID != owner.PodUID:
+		logger.Debug("the SpiderEndpoint belongs to another pod, skip recording the MAC")
+	default:
+		if err := agentContext.EndpointManager.RecordPodMAC(ctx, endpoint, owner.NIC, entry.MAC.String()); err != nil {
+			logger.Sugar().Warnf("failed to record the MAC: %v", err)
+		}
+	}
+
+	return daemonset.NewPostCoordinatorMacOK().WithPayload(convertMACEntry(entry))
+}
+
+type _unixDeleteCoordinatorMac struct{}
+
+// Handle handles DELETE requests for /coordinator/mac.
+func (g *_unixDeleteCoordinatorMac) Handle(params daemonset.DeleteCoordinatorMacParams) middleware.Responder {
+	if err := params.PodMacArgs.Validate(strfmt.Default); err != nil {
+		return daemonset.NewDeleteCoordinatorMacFailure().WithPayload(models.Error(err.Error()))
+	}
+
+	agentContext.MACRegistry.Release(podMacOwner(params.PodMacArgs))
+	return daemonset.NewDeleteCoordinatorMacOK()
+}
+
+type _httpGetCoordinatorMac struct{}
+
+// Handle handles GET requests for /coordinator/mac.
+func (g *_httpGetCoordinatorMac) Handle(params daemonset.GetCoordinatorMacParams) middleware.Responder {
+	if agentContext.MACRegistry == nil {
+		return daemonset.NewGetCoordinatorMacFailure().WithPayload(models.Error("MAC registry is not ready"))
+	}
+
+	entries := agentContext.MACRegistry.List()
+	list := &models.PodMacList{Items: make([]*models.PodMac, 0, len(entries))}
+	for _, entry := range entries {
+		list.Items = append(list.Items, convertMACEntry(entry))
+	}
+
+	return daemonset.NewGetCoordinatorMacOK().WithPayload(list)
+}
+
+func podMacOwner(args *models.PodMacArgs) macregistry.Owner {
+	return macregistry.Owner{
+		PodNamespace: *args.PodNamespace,
+		PodName:      *args.PodName,
+		PodUID:       *args.PodUID,
+		NIC:          *args.IfName,
+	}
+}
+
+func convertMACEntry(entry macregistry.Entry) *models.PodMac {
+	mac := entry.MAC.String()
+	return &models.PodMac{
+		Mac:          &mac,
+		PodNamespace: entry.PodNamespace,
+		PodName:      entry.PodName,
+		PodUID:       entry.PodUID,
+		IfName:       entry.NIC,
+		Salt:         int64(entry.Salt),
+		AssignedTime: entry.AssignedTime.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/event"
+	"github.com/spidernet-io/spiderpool/pkg/metric"
+	"github.com/spidernet-io/spiderpool/pkg/metric/cnireport"
+)
+
+const (
+	coordinatorReportCollectInterval = 10 * time.Second
+	// a pod gets at most one CoordinatorRouteWarning event every interval
+	coordinatorWarningEventInterval = 5 * time.Minute
+)
+
+// startCoordinatorReportCollector collects the reports of the coordinator
+// plugin, which are recorded as metrics if the metrics are enabled, and whose
+// warnings are emitted as the events of the pods.
+func startCoordinatorReportCollector(ctx context.Context) {
+	// the coordinator plugin only writes the reports if the directory exists
+	if err := os.MkdirAll(constant.DefaultCoordinatorReportDir, 0o755); nil != err {
+		logger.Sugar().Errorf("failed to create coordinator report directory, the coordinator metrics and warning events are disabled: %v", err)
+		return
+	}
+
+	emitter := event.NewCoordinatorWarningEmitter(event.EventRecorder, coordinatorWarningEventInterval,
+		func(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+			return agentContext.PodManager.GetPodByName(ctx, namespace, name, constant.UseCache)
+		})
+	go metric.CollectCoordinatorReports(ctx, constant.DefaultCoordinatorReportDir, coordinatorReportCollectInterval,
+		func(ctx context.Context, r *cnireport.Report) {
+			emitter.Emit(ctx, r)
+		})
+}
@@ -22,6 +22,8 @@ import (
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/event"
 	"github.com/spidernet-io/spiderpool/pkg/ipam"
 	"github.com/spidernet-io/spiderpool/pkg/ippoolmanager"
 	"github.com/spidernet-io/spiderpool/pkg/logutils"
@@ -111,6 +113,16 @@ func DaemonMain() {
 		}
 	}
 
+	logger.Info("Begin to run spiderpool-agent self-check")
+	runSelfCheck()
+
+	if agentContext.Cfg.EnableStaleRuleCleanup {
+		logger.Info("Begin to clean up the stale rules")
+		if err := cleanupStaleRules(); err != nil {
+			logger.Error(err.Error())
+		}
+	}
+
 	agentContext.InnerCtx, agentContext.InnerCancel = context.WithCancel(context.Background())
 	if err := waitAPIServerReady(agentContext.InnerCtx); err != nil {
 		logger.Fatal(err.Error())
@@ -125,6 +137,7 @@ func DaemonMain() {
 		logger.Fatal(err.Error())
 	}
 	agentContext.CRDManager = mgr
+	event.EventRecorder = mgr.GetEventRecorderFor(constant.SpiderpoolAgent)
 
 	// init managers...
 	initAgentServiceManagers(agentContext.InnerCtx)
@@ -176,6 +189,26 @@ func DaemonMain() {
 		logger.Fatal("failed to wait for syncing controller-runtime cache")
 	}
 
+	logger.Info("Begin to initialize MAC registry")
+	if err := initMACRegistry(agentContext.InnerCtx); err != nil {
+		logger.Fatal(err.Error())
+	}
+
+	if agentContext.Cfg.EnableRouteRepair {
+		logger.Info("Begin to start route watcher")
+		if err := startRouteWatcher(agentContext.InnerCtx); err != nil {
+			logger.Fatal(err.Error())
+		}
+	}
+
+	if agentContext.Cfg.PromiscReconcileInterval > 0 {
+		logger.Info("Begin to start promisc reconciler")
+		startPromiscReconciler(agentContext.InnerCtx, time.Duration(agentContext.Cfg.PromiscReconcileInterval)*time.Second)
+	}
+
+	logger.Info("Begin to start coordinator report collector")
+	startCoordinatorReportCollector(agentContext.InnerCtx)
+
 	logger.Info("Begin to initialize spiderpool-agent OpenAPI HTTP server")
 	srv, err := newAgentOpenAPIHttpServer()
 	if nil != err {
@@ -258,7 +291,9 @@ func WatchSignal(sigCh chan os.Signal) {
 	}
 }
 
-func waitAPIServerReady(ctx context.Context) error {
+// newAPIServerHealthClient creates the client requesting the health endpoints
+// of API Server, each request times out in 2 seconds.
+func newAPIServerHealthClient() (rest.Interface, error) {
 	config := ctrl.GetConfigOrDie()
 	config.APIPath = ""
 	config.GroupVersion = nil
@@ -267,16 +302,20 @@ func waitAPIServerReady(ctx context.Context) error {
 	// to detect whether the API Server's readiness probe is ready, so there
 	// is no need to add any decoder.
 	config.NegotiatedSerializer = apiruntime.NewSimpleNegotiatedSerializer(apiruntime.SerializerInfo{})
-
-	// Request API Server every 2 seconds until API Server is ready or all 15
-	// retries have timed out. (total cost: 2 * 15 = 30s)
 	config.Timeout = 2 * time.Second
 
-	client, err := rest.UnversionedRESTClientFor(config)
+	return rest.UnversionedRESTClientFor(config)
+}
+
+func waitAPIServerReady(ctx context.Context) error {
+	client, err := newAPIServerHealthClient()
 	if err != nil {
 		return err
 	}
+	agentContext.apiServerClient = client
 
+	// Request API Server every 2 seconds until API Server is ready or all 15
+	// retries have timed out. (total cost: 2 * 15 = 30s)
 	for i := 0; i < 15; i++ {
 		select {
 		case <-ctx.Done():
@@ -342,6 +381,9 @@ func initAgentServiceManagers(ctx context.Context) {
 
 	logger.Debug("Begin to initialize Endpoint manager")
 	endpointManager, err := workloadendpointmanager.NewWorkloadEndpointManager(
+		workloadendpointmanager.EndpointManagerConfig{
+			MaxHistoryRecords: &agentContext.Cfg.EndpointMaxHistoryRecords,
+		},
 		agentContext.CRDManager.GetClient(),
 		agentContext.CRDManager.GetAPIReader(),
 	)
@@ -364,6 +406,7 @@ func initAgentServiceManagers(ctx context.Context) {
 	ipPoolManager, err := ippoolmanager.NewIPPoolManager(
 		ippoolmanager.IPPoolManagerConfig{
 			MaxAllocatedIPs: &agentContext.Cfg.IPPoolMaxAllocatedIPs,
+			IPReuseCooldown: time.Duration(agentContext.Cfg.IPReuseCooldown) * time.Second,
 		},
 		agentContext.CRDManager.GetClient(),
 		agentContext.CRDManager.GetAPIReader(),
@@ -33,6 +33,12 @@ func newAgentOpenAPIHttpServer() (*agentOpenAPIServer.Server, error) {
 	api.RuntimeGetRuntimeReadinessHandler = httpGetAgentReadiness
 	api.RuntimeGetRuntimeLivenessHandler = httpGetAgentLiveness
 
+	// coordinator API
+	api.DaemonsetGetCoordinatorMacHandler = httpGetCoordinatorMac
+
+	// IPAM API, for the IP GC of spiderpool-controller
+	api.DaemonsetPostIpamIPConflictHandler = httpPostIpamIPConflict
+
 	// new agent OpenAPI server with api
 	srv := agentOpenAPIServer.NewServer(api)
 
@@ -0,0 +1,129 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/spidernet-io/spiderpool/api/v1/agent/models"
+	"github.com/spidernet-io/spiderpool/api/v1/agent/server/restapi/daemonset"
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/networking/ipchecking"
+)
+
+// the label of the spiderpool components set by the chart
+const labelComponent = "app.kubernetes.io/component"
+
+// the options of the IP conflict detection, same as the default detect
+// options of coordinator
+const (
+	ipConflictDetectRetries  = 3
+	ipConflictDetectInterval = "1s"
+	ipConflictDetectTimeout  = "1s"
+)
+
+var httpPostIpamIPConflict = &_httpPostIpamIPConflict{}
+
+type _httpPostIpamIPConflict struct{}
+
+// Handle handles POST requests for /ipam/ip/conflict. The detection sends ARP
+// or NDP probes on the interfaces of the node, so only the spiderpool-controller
+// is allowed to request it.
+func (g *_httpPostIpamIPConflict) Handle(params daemonset.PostIpamIPConflictParams) middleware.Responder {
+	if err := params.IpamIPConflictArgs.Validate(strfmt.Default); err != nil {
+		return daemonset.NewPostIpamIPConflictFailure().WithPayload(models.Error(err.Error()))
+	}
+
+	if err := checkControllerCaller(params.HTTPRequest.Context(), params.HTTPRequest.RemoteAddr); err != nil {
+		logger.Warn("refuse the IP conflict detection", zap.String("RemoteAddr", params.HTTPRequest.RemoteAddr), zap.Error(err))
+		return daemonset.NewPostIpamIPConflictFailure().WithPayload(models.Error(err.Error()))
+	}
+
+	ip := *params.IpamIPConflictArgs.IP
+	if err := detectIPConflict(ip); err != nil {
+		logger.Warn("IP may be still in use", zap.String("IP", ip), zap.Error(err))
+		return daemonset.NewPostIpamIPConflictFailure().WithPayload(models.Error(err.Error()))
+	}
+
+	return daemonset.NewPostIpamIPConflictOK()
+}
+
+// checkControllerCaller checks that the remote address is the IP of one of the
+// spiderpool-controller pods, which run in the namespace of spiderpool-agent.
+func checkControllerCaller(ctx context.Context, remoteAddr string) error {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return fmt.Errorf("invalid remote address '%s': %w", remoteAddr, err)
+	}
+	callerIP := net.ParseIP(host)
+	if callerIP == nil {
+		return fmt.Errorf("invalid remote address '%s'", remoteAddr)
+	}
+
+	if agentContext.Cfg.AgentPodNamespace == "" {
+		return fmt.Errorf("the namespace of spiderpool-controller is unknown")
+	}
+	podList, err := agentContext.PodManager.ListPods(ctx, constant.UseCache,
+		client.InNamespace(agentContext.Cfg.AgentPodNamespace),
+		client.MatchingLabels{labelComponent: agentContext.Cfg.ControllerName})
+	if err != nil {
+		return fmt.Errorf("failed to list the spiderpool-controller pods: %w", err)
+	}
+
+	for _, pod := range podList.Items {
+		for _, podIP := range pod.Status.PodIPs {
+			if callerIP.Equal(net.ParseIP(podIP.IP)) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("caller %s is not spiderpool-controller", callerIP)
+}
+
+// detectIPConflict checks whether the ip still answers ARP or NDP on the
+// interface of the node which the ip is on-link of. It fails if the ip is
+// routed through a gateway, because it can't be detected from the node then.
+func detectIPConflict(ipStr string) error {
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return fmt.Errorf("invalid IP '%s'", ipStr)
+	}
+
+	routes, err := netlink.RouteGet(ip)
+	if err != nil {
+		return fmt.Errorf("failed to get the route to %s: %w", ipStr, err)
+	}
+	if len(routes) == 0 {
+		return fmt.Errorf("no route to %s", ipStr)
+	}
+	if routes[0].Gw != nil {
+		return fmt.Errorf("%s is not on-link of the node, it's routed through %s", ipStr, routes[0].Gw)
+	}
+
+	link, err := netlink.LinkByIndex(routes[0].LinkIndex)
+	if err != nil {
+		return fmt.Errorf("failed to get the link of the route to %s: %w", ipStr, err)
+	}
+
+	hostNS, err := ns.GetCurrentNS()
+	if err != nil {
+		return fmt.Errorf("failed to get the netns of the node: %w", err)
+	}
+	defer hostNS.Close()
+
+	ipc, err := ipchecking.NewIPChecker(ipConflictDetectRetries, ipConflictDetectInterval, ipConflictDetectTimeout, hostNS, logger)
+	if err != nil {
+		return err
+	}
+	return ipc.DetectIPConflict(ip, link.Attrs().Name)
+}
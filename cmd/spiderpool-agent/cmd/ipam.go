@@ -20,10 +20,11 @@ import (
 
 // Singleton.
 var (
-	unixPostAgentIpamIp    = &_unixPostAgentIpamIp{}
-	unixDeleteAgentIpamIp  = &_unixDeleteAgentIpamIp{}
-	unixPostAgentIpamIps   = &_unixPostAgentIpamIps{}
-	unixDeleteAgentIpamIps = &_unixDeleteAgentIpamIps{}
+	unixPostAgentIpamIp     = &_unixPostAgentIpamIp{}
+	unixDeleteAgentIpamIp   = &_unixDeleteAgentIpamIp{}
+	unixPostAgentIpamIps    = &_unixPostAgentIpamIps{}
+	unixDeleteAgentIpamIps  = &_unixDeleteAgentIpamIps{}
+	unixPostAgentIpamDryRun = &_unixPostAgentIpamDryRun{}
 )
 
 type _unixPostAgentIpamIp struct{}
@@ -125,6 +126,32 @@ func (g *_unixDeleteAgentIpamIps) Handle(params daemonset.DeleteIpamIpsParams) m
 	return daemonset.NewDeleteIpamIpsOK()
 }
 
+type _unixPostAgentIpamDryRun struct{}
+
+// Handle handles POST requests for /ipam/dry-run.
+func (g *_unixPostAgentIpamDryRun) Handle(params daemonset.PostIpamDryRunParams) middleware.Responder {
+	if err := params.IpamDryRunArgs.Validate(strfmt.Default); err != nil {
+		return daemonset.NewPostIpamDryRunFailure().WithPayload(models.Error(err.Error()))
+	}
+
+	logger := logutils.Logger.Named("IPAM").With(
+		zap.String("Operation", "DryRun"),
+		zap.String("IfName", *params.IpamDryRunArgs.IfName),
+		zap.String("PodNamespace", *params.IpamDryRunArgs.PodNamespace),
+		zap.String("NodeName", *params.IpamDryRunArgs.NodeName),
+		zap.Int64("Replicas", *params.IpamDryRunArgs.Replicas),
+	)
+	ctx := logutils.IntoContext(params.HTTPRequest.Context(), logger)
+
+	resp, err := agentContext.IPAM.DryRun(ctx, params.IpamDryRunArgs)
+	if err != nil {
+		logger.Error(err.Error())
+		return daemonset.NewPostIpamDryRunFailure().WithPayload(models.Error(err.Error()))
+	}
+
+	return daemonset.NewPostIpamDryRunOK().WithPayload(resp)
+}
+
 func gatherIPAMAllocationErrMetric(ctx context.Context, err error) {
 	internal := true
 	if errors.Is(err, constant.ErrWrongInput) {
@@ -0,0 +1,86 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/spidernet-io/spiderpool/pkg/event"
+	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
+	"github.com/spidernet-io/spiderpool/pkg/multuscniconfig"
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+// startPromiscReconciler keeps the master interface of the macvlan networks with
+// ensurePromisc in the promiscuous mode, since other daemons or the operator may
+// turn it off, which breaks the macvlan interfaces with MAC addresses not known
+// by the NIC. It's re-asserted every interval until ctx is done.
+func startPromiscReconciler(ctx context.Context, interval time.Duration) {
+	promiscLogger := logger.Named("promisc-reconciler")
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := reconcilePromisc(ctx); err != nil {
+				promiscLogger.Error(err.Error())
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func reconcilePromisc(ctx context.Context) error {
+	var multusConfigList spiderpoolv2beta1.SpiderMultusConfigList
+	if err := agentContext.CRDManager.GetClient().List(ctx, &multusConfigList); err != nil {
+		return fmt.Errorf("failed to list SpiderMultusConfigs: %w", err)
+	}
+
+	var errs []error
+	for i := range multusConfigList.Items {
+		multusConfig := &multusConfigList.Items[i]
+		macvlanConfig := multusConfig.Spec.MacvlanConfig
+		if multusConfig.Spec.CniType != multuscniconfig.MacVlanType || macvlanConfig == nil ||
+			macvlanConfig.EnsurePromisc == nil || !*macvlanConfig.EnsurePromisc {
+			continue
+		}
+
+		master := multuscniconfig.MacvlanMasterName(macvlanConfig)
+		promisc, err := networking.GetPromiscuousMode(master)
+		if err != nil {
+			// the master may be only on some of the nodes
+			var notFound netlink.LinkNotFoundError
+			if errors.As(err, &notFound) {
+				continue
+			}
+			errs = append(errs, err)
+			continue
+		}
+		if promisc {
+			continue
+		}
+
+		if err := networking.SetPromiscuousMode(master, true); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		logger.Sugar().Warnf("Re-enable the promiscuous mode of %s for SpiderMultusConfig %s/%s", master, multusConfig.Namespace, multusConfig.Name)
+		event.EventRecorder.Eventf(multusConfig, corev1.EventTypeWarning, "PromiscReEnabled",
+			"Re-enable the promiscuous mode of master %s on node %s", master, agentContext.Cfg.NodeName)
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,181 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
+	"github.com/spidernet-io/spiderpool/pkg/lock"
+	"github.com/spidernet-io/spiderpool/pkg/logutils"
+	"github.com/spidernet-io/spiderpool/pkg/networking/routewatcher"
+)
+
+const (
+	// defaultHostRuleTable is the hostRuleTable of coordinator by default
+	defaultHostRuleTable = 500
+	// defaultHostRulePriority is the priority of `ip rule add from all lookup <hostRuleTable>`
+	// installed by coordinator
+	defaultHostRulePriority = 1000
+)
+
+// cniDelPods records the pods in CNI DEL, which is told by coordinator when it
+// gets its config. Their routes and rules are being deleted by coordinator, so
+// the route watcher must not repair them.
+var cniDelPods = &podSet{pods: map[string]struct{}{}}
+
+type podSet struct {
+	lock lock.RWMutex
+	pods map[string]struct{}
+}
+
+// record marks the pod in CNI DEL, until the pod is set up again by CNI ADD
+func (s *podSet) record(namespace, name, cniCommand string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch cniCommand {
+	case constant.CNICommandDel:
+		s.pods[namespace+"/"+name] = struct{}{}
+	case constant.CNICommandAdd:
+		delete(s.pods, namespace+"/"+name)
+	}
+}
+
+func (s *podSet) has(namespace, name string) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	_, ok := s.pods[namespace+"/"+name]
+	return ok
+}
+
+// retain forgets the pods whose SpiderEndpoints are gone
+func (s *podSet) retain(existing map[string]struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for key := range s.pods {
+		if _, ok := existing[key]; !ok {
+			delete(s.pods, key)
+		}
+	}
+}
+
+// startRouteWatcher watches the routes and rules installed by coordinator for the
+// pods on this node, and repairs them if they are deleted by other daemons.
+func startRouteWatcher(ctx context.Context) error {
+	if agentContext.Cfg.NodeName == "" {
+		return fmt.Errorf("node name %w", constant.ErrMissingRequiredParam)
+	}
+
+	tables, err := listHostRuleTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := routewatcher.NewRouteWatcher(routewatcher.RouteWatcherConfig{
+		Tables:       tables,
+		RulePriority: defaultHostRulePriority,
+	}, desiredEndpointIPs)
+	if err != nil {
+		return err
+	}
+
+	logutils.FromContext(ctx).Sugar().Infof("Starting route watcher for tables %v", tables)
+	return watcher.Start(ctx)
+}
+
+// listHostRuleTables returns the hostRuleTables of all the SpiderCoordinators and
+// SpiderMultusConfigs, along with the default one of coordinator.
+func listHostRuleTables(ctx context.Context) ([]int, error) {
+	crdClient := agentContext.CRDManager.GetClient()
+
+	var coordList spiderpoolv2beta1.SpiderCoordinatorList
+	if err := crdClient.List(ctx, &coordList); err != nil {
+		return nil, fmt.Errorf("failed to list SpiderCoordinators: %w", err)
+	}
+	var multusConfigList spiderpoolv2beta1.SpiderMultusConfigList
+	if err := crdClient.List(ctx, &multusConfigList); err != nil {
+		return nil, fmt.Errorf("failed to list SpiderMultusConfigs: %w", err)
+	}
+
+	tables := []int{defaultHostRuleTable}
+	addTable := func(table *int) {
+		if table == nil {
+			return
+		}
+		for _, t := range tables {
+			if t == *table {
+				return
+			}
+		}
+		tables = append(tables, *table)
+	}
+	for _, coord := range coordList.Items {
+		addTable(coord.Spec.HostRuleTable)
+	}
+	for _, multusConfig := range multusConfigList.Items {
+		if multusConfig.Spec.CoordinatorConfig != nil {
+			addTable(multusConfig.Spec.CoordinatorConfig.HostRuleTable)
+		}
+	}
+
+	return tables, nil
+}
+
+// desiredEndpointIPs returns the IPs allocated to the SpiderEndpoints on this node,
+// leaving out the ones of the pods which are terminating or in CNI DEL.
+func desiredEndpointIPs(ctx context.Context) ([]net.IP, error) {
+	endpointList, err := agentContext.EndpointManager.ListEndpoints(ctx, constant.UseCache)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	existing := map[string]struct{}{}
+	for _, endpoint := range endpointList.Items {
+		if endpoint.Status.Current.Node != agentContext.Cfg.NodeName {
+			continue
+		}
+		existing[endpoint.Namespace+"/"+endpoint.Name] = struct{}{}
+
+		if endpoint.DeletionTimestamp != nil || cniDelPods.has(endpoint.Namespace, endpoint.Name) {
+			continue
+		}
+
+		pod, err := agentContext.PodManager.GetPodByName(ctx, endpoint.Namespace, endpoint.Name, constant.UseCache)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		// the allocation of a previous pod with the same name
+		if pod.DeletionTimestamp != nil || string(pod.UID) != endpoint.Status.Current.UID {
+			continue
+		}
+
+		for _, detail := range endpoint.Status.Current.IPs {
+			for _, address := range []*string{detail.IPv4, detail.IPv6} {
+				if address == nil {
+					continue
+				}
+				ip, _, err := net.ParseCIDR(*address)
+				if err != nil {
+					continue
+				}
+				ips = append(ips, ip)
+			}
+		}
+	}
+	cniDelPods.retain(existing)
+
+	return ips, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+// cleanupStaleRules sweeps the rules left on the node in the priority band owned
+// by spiderpool, such as by the earlier versions, which no current code knows how
+// to identify. It's disabled by default, and the band must be declared explicitly.
+func cleanupStaleRules() error {
+	minPrio, maxPrio, err := parsePriorityRange(agentContext.Cfg.StaleRulePriorityRange)
+	if err != nil {
+		return err
+	}
+
+	tables, err := parseRuleTables(agentContext.Cfg.StaleRuleTables)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := networking.DelRulesByPriorityRange(logger.Named("stale-rule-cleanup"), netlink.FAMILY_ALL, minPrio, maxPrio, tables)
+	if err != nil {
+		return fmt.Errorf("failed to clean up the stale rules after deleting %d rules: %w", deleted, err)
+	}
+	logger.Sugar().Infof("Clean up %d stale rules in priority range [%d, %d] of tables %v", deleted, minPrio, maxPrio, tables)
+	return nil
+}
+
+// parsePriorityRange parses the priority range like "999-1005"
+func parsePriorityRange(priorityRange string) (minPrio, maxPrio int, err error) {
+	low, high, found := strings.Cut(priorityRange, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid priority range %q, it must be like 999-1005", priorityRange)
+	}
+
+	minPrio, err = strconv.Atoi(strings.TrimSpace(low))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid priority range %q: %v", priorityRange, err)
+	}
+	maxPrio, err = strconv.Atoi(strings.TrimSpace(high))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid priority range %q: %v", priorityRange, err)
+	}
+	if minPrio < 0 || minPrio > maxPrio {
+		return 0, 0, fmt.Errorf("invalid priority range %q", priorityRange)
+	}
+	return minPrio, maxPrio, nil
+}
+
+// parseRuleTables parses the comma separated tables, empty for all the tables
+func parseRuleTables(tables string) ([]int, error) {
+	var result []int
+	for _, table := range strings.Split(tables, ",") {
+		table = strings.TrimSpace(table)
+		if table == "" {
+			continue
+		}
+		num, err := strconv.Atoi(table)
+		if err != nil || num <= 0 {
+			return nil, fmt.Errorf("invalid rule table %q", table)
+		}
+		result = append(result, num)
+	}
+	return result, nil
+}
@@ -4,12 +4,27 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/containernetworking/cni/libcni"
 	"github.com/go-openapi/runtime/middleware"
 
 	"github.com/spidernet-io/spiderpool/api/v1/agent/client/connectivity"
+	"github.com/spidernet-io/spiderpool/api/v1/agent/models"
 	"github.com/spidernet-io/spiderpool/api/v1/agent/server/restapi/runtime"
 )
 
+// the components reported by the readiness probe
+const (
+	readinessComponentSelfCheck  = "netlinkSelfCheck"
+	readinessComponentIPAMServer = "ipamServer"
+	readinessComponentCNIConfig  = "cniConfig"
+	readinessComponentAPIServer  = "apiServer"
+)
+
 // Singleton
 var (
 	httpGetAgentStartup   = &_httpGetAgentStartup{agentContext}
@@ -37,15 +52,70 @@ type _httpGetAgentReadiness struct {
 
 // Handle handles GET requests for k8s readiness probe.
 func (g *_httpGetAgentReadiness) Handle(params runtime.GetRuntimeReadinessParams) middleware.Responder {
-	_, err := g.unixClient.Connectivity.GetIpamHealthy(connectivity.NewGetIpamHealthyParams())
-	if nil != err {
-		logger.Sugar().Errorf("failed to check spiderpool-agent readiness probe, error: %v", err)
-		return runtime.NewGetRuntimeReadinessInternalServerError()
+	failed := g.failedReadinessComponents(params.HTTPRequest.Context())
+	if len(failed) != 0 {
+		logger.Sugar().Errorf("failed to check spiderpool-agent readiness probe, components are not ready: %v", failed)
+		return runtime.NewGetRuntimeReadinessServiceUnavailable().WithPayload(&models.ReadinessStatus{FailedComponents: failed})
 	}
 
 	return runtime.NewGetRuntimeReadinessOK()
 }
 
+// failedReadinessComponents returns the errors of the components failing the
+// readiness. The CNI config and API Server are only checked in the strict mode,
+// so that the agent upgraded keeps ready on the nodes where they were not
+// checked before.
+func (g *_httpGetAgentReadiness) failedReadinessComponents(ctx context.Context) map[string]string {
+	failed := map[string]string{}
+	if conditions := g.failedSelfCheckConditions(); len(conditions) != 0 {
+		failed[readinessComponentSelfCheck] = fmt.Sprintf("%v", conditions)
+	}
+
+	if _, err := g.unixClient.Connectivity.GetIpamHealthy(connectivity.NewGetIpamHealthyParams()); nil != err {
+		failed[readinessComponentIPAMServer] = err.Error()
+	}
+
+	if !g.Cfg.ReadinessStrict {
+		return failed
+	}
+
+	if err := checkCNIConfig(g.Cfg.CniConfigDir); err != nil {
+		failed[readinessComponentCNIConfig] = err.Error()
+	}
+
+	if g.apiServerClient == nil {
+		failed[readinessComponentAPIServer] = "API Server client is not initialized"
+	} else if err := g.apiServerClient.Get().AbsPath("/readyz").Do(ctx).Error(); err != nil {
+		failed[readinessComponentAPIServer] = err.Error()
+	}
+
+	return failed
+}
+
+// checkCNIConfig checks that the CNI config picked by the container runtime,
+// which is the first one in the dir, is present and loadable.
+func checkCNIConfig(dir string) error {
+	files, err := libcni.ConfFiles(dir, []string{".conf", ".conflist"})
+	if err != nil {
+		return fmt.Errorf("failed to load CNI config files in %s: %w", dir, err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no CNI config file found in %s", dir)
+	}
+	sort.Strings(files)
+
+	path := files[0]
+	if strings.HasSuffix(path, ".conflist") {
+		_, err = libcni.ConfListFromFile(path)
+	} else {
+		_, err = libcni.ConfFromFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid CNI config file %s: %w", path, err)
+	}
+	return nil
+}
+
 type _httpGetAgentLiveness struct {
 	*AgentContext
 }
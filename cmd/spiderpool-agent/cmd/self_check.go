@@ -0,0 +1,47 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+// selfCheckRuleTable is the scratch table of the self-check, which is away
+// from the rule tables used by spiderpool and coordinator
+const selfCheckRuleTable = 65000
+
+// runSelfCheck verifies the netlink capabilities and the kernel features used
+// by spiderpool on the node, so that a hardened host fails the agent's readiness
+// instead of the first pod creation.
+func runSelfCheck() {
+	conditions := networking.SelfCheck(selfCheckRuleTable, agentContext.Cfg.EnableIPv6)
+	for _, condition := range conditions {
+		if condition.Ready {
+			logger.Sugar().Infof("Self-check %s", condition)
+			continue
+		}
+		if agentContext.Cfg.SelfCheckAllowDegraded {
+			logger.Sugar().Warnf("Self-check %s, running degraded", condition)
+		} else {
+			logger.Sugar().Errorf("Self-check %s", condition)
+		}
+	}
+	agentContext.SelfCheckConditions = conditions
+}
+
+// failedSelfCheckConditions returns the failed self-check conditions which
+// fail the agent's readiness, they are ignored in the degraded mode.
+func (ac *AgentContext) failedSelfCheckConditions() []networking.SelfCheckCondition {
+	if ac.Cfg.SelfCheckAllowDegraded {
+		return nil
+	}
+
+	var failed []networking.SelfCheckCondition
+	for _, condition := range ac.SelfCheckConditions {
+		if !condition.Ready {
+			failed = append(failed, condition)
+		}
+	}
+	return failed
+}
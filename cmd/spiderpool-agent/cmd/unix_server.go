@@ -33,7 +33,10 @@ func newAgentOpenAPIUnixServer() (*agentOpenAPIServer.Server, error) {
 	api.DaemonsetDeleteIpamIPHandler = unixDeleteAgentIpamIp
 	api.DaemonsetPostIpamIpsHandler = unixPostAgentIpamIps
 	api.DaemonsetDeleteIpamIpsHandler = unixDeleteAgentIpamIps
+	api.DaemonsetPostIpamDryRunHandler = unixPostAgentIpamDryRun
 	api.DaemonsetGetCoordinatorConfigHandler = unixGetCoordinatorConfig
+	api.DaemonsetPostCoordinatorMacHandler = unixPostCoordinatorMac
+	api.DaemonsetDeleteCoordinatorMacHandler = unixDeleteCoordinatorMac
 
 	// new agent OpenAPI server with api
 	srv := agentOpenAPIServer.NewServer(api)
@@ -64,6 +64,10 @@ var envInfo = []envConf{
 
 	{"SPIDERPOOL_GC_IP_ENABLED", "true", true, nil, &gcIPConfig.EnableGCIP, nil},
 	{"SPIDERPOOL_GC_TERMINATING_POD_IP_ENABLED", "true", true, nil, &gcIPConfig.EnableGCForTerminatingPod, nil},
+	{"SPIDERPOOL_GC_DELETED_NODE_IP_ENABLED", "false", false, nil, &gcIPConfig.EnableGCForDeletedNode, nil},
+	{"SPIDERPOOL_GC_NODE_UNREACHABLE_DURATION", "0", false, nil, nil, &gcIPConfig.GCNodeUnreachableDuration},
+	{"SPIDERPOOL_GC_FORCED_RELEASE_TERMINATING_POD_DEADLINE", "0", false, nil, nil, &gcIPConfig.ForcedReleaseTerminatingPodDeadline},
+	{"SPIDERPOOL_AGENT_HTTP_PORT", "5710", false, nil, nil, &gcIPConfig.AgentHttpPort},
 	{"SPIDERPOOL_GC_IP_WORKER_NUM", "3", true, nil, nil, &gcIPConfig.ReleaseIPWorkerNum},
 	{"SPIDERPOOL_GC_CHANNEL_BUFFER", "5000", true, nil, nil, &gcIPConfig.GCIPChannelBuffer},
 	{"SPIDERPOOL_GC_MAX_PODENTRY_DB_CAP", "100000", true, nil, nil, &gcIPConfig.MaxPodEntryDatabaseCap},
@@ -81,6 +85,8 @@ var envInfo = []envConf{
 	{"SPIDERPOOL_LEADER_RETRY_GAP", "1", true, nil, nil, &controllerContext.Cfg.LeaseRetryGap},
 
 	{"SPIDERPOOL_IPPOOL_MAX_ALLOCATED_IPS", "5000", false, nil, nil, &controllerContext.Cfg.IPPoolMaxAllocatedIPs},
+	{"SPIDERPOOL_IP_REUSE_COOLDOWN", "0", false, nil, nil, &controllerContext.Cfg.IPReuseCooldown},
+	{"SPIDERPOOL_WORKLOADENDPOINT_MAX_HISTORY_RECORDS", "5", false, nil, nil, &controllerContext.Cfg.EndpointMaxHistoryRecords},
 
 	{"SPIDERPOOL_SUBNET_INFORMER_RESYNC_PERIOD", "300", false, nil, nil, &controllerContext.Cfg.SubnetInformerResyncPeriod},
 	{"SPIDERPOOL_SUBNET_INFORMER_WORKERS", "5", true, nil, nil, &controllerContext.Cfg.SubnetInformerWorkers},
@@ -96,6 +102,7 @@ var envInfo = []envConf{
 	{"SPIDERPOOL_IPPOOL_INFORMER_RESYNC_PERIOD", "300", false, nil, nil, &controllerContext.Cfg.IPPoolInformerResyncPeriod},
 	{"SPIDERPOOL_IPPOOL_INFORMER_WORKERS", "3", true, nil, nil, &controllerContext.Cfg.IPPoolInformerWorkers},
 	{"SPIDERPOOL_AUTO_IPPOOL_HANDLER_MAX_WORKQUEUE_LENGTH", "10000", true, nil, nil, &controllerContext.Cfg.IPPoolInformerMaxWorkQueueLength},
+	{"SPIDERPOOL_AUTO_IPPOOL_RETENTION_PERIOD", "0", false, nil, nil, &controllerContext.Cfg.AutoIPPoolRetentionPeriod},
 	{"SPIDERPOOL_WORKQUEUE_MAX_RETRIES", "500", true, nil, nil, &controllerContext.Cfg.WorkQueueMaxRetries},
 	{"SPIDERPOOL_WORKQUEUE_RETRY_DELAY_DURATION", "5", true, nil, nil, &controllerContext.Cfg.WorkQueueRequeueDelayDuration},
 }
@@ -131,7 +138,9 @@ type Config struct {
 	LeaseRetryPeriod       int
 	LeaseRetryGap          int
 
-	IPPoolMaxAllocatedIPs int
+	IPPoolMaxAllocatedIPs     int
+	IPReuseCooldown           int
+	EndpointMaxHistoryRecords int
 
 	SubnetInformerResyncPeriod       int
 	SubnetInformerWorkers            int
@@ -141,6 +150,7 @@ type Config struct {
 	IPPoolInformerResyncPeriod       int
 	IPPoolInformerWorkers            int
 	IPPoolInformerMaxWorkQueueLength int
+	AutoIPPoolRetentionPeriod        int
 	WorkQueueMaxRetries              int
 	WorkQueueRequeueDelayDuration    int
 
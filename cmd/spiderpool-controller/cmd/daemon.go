@@ -263,6 +263,9 @@ func initControllerServiceManagers(ctx context.Context) {
 
 	logger.Debug("Begin to initialize Endpoint manager")
 	endpointManager, err := workloadendpointmanager.NewWorkloadEndpointManager(
+		workloadendpointmanager.EndpointManagerConfig{
+			MaxHistoryRecords: &controllerContext.Cfg.EndpointMaxHistoryRecords,
+		},
 		controllerContext.CRDManager.GetClient(),
 		controllerContext.CRDManager.GetAPIReader(),
 	)
@@ -293,6 +296,7 @@ func initControllerServiceManagers(ctx context.Context) {
 	ipPoolManager, err := ippoolmanager.NewIPPoolManager(
 		ippoolmanager.IPPoolManagerConfig{
 			MaxAllocatedIPs: &controllerContext.Cfg.IPPoolMaxAllocatedIPs,
+			IPReuseCooldown: time.Duration(controllerContext.Cfg.IPReuseCooldown) * time.Second,
 		},
 		controllerContext.CRDManager.GetClient(),
 		controllerContext.CRDManager.GetAPIReader(),
@@ -349,7 +353,9 @@ func initControllerServiceManagers(ctx context.Context) {
 
 	if controllerContext.Cfg.EnableMultusConfig {
 		logger.Debug("Begin to set up MultusConfig webhook")
-		if err := (&multuscniconfig.MultusConfigWebhook{}).SetupWebhookWithManager(controllerContext.CRDManager); nil != err {
+		if err := (&multuscniconfig.MultusConfigWebhook{
+			Client: controllerContext.CRDManager.GetClient(),
+		}).SetupWebhookWithManager(controllerContext.CRDManager); nil != err {
 			logger.Fatal(err.Error())
 		}
 	}
@@ -466,6 +472,7 @@ func setupInformers() {
 			WorkQueueRequeueDelayDuration: time.Duration(controllerContext.Cfg.WorkQueueRequeueDelayDuration) * time.Second,
 			WorkQueueMaxRetries:           controllerContext.Cfg.WorkQueueMaxRetries,
 			ResyncPeriod:                  time.Duration(controllerContext.Cfg.IPPoolInformerResyncPeriod) * time.Second,
+			AutoIPPoolRetentionPeriod:     time.Duration(controllerContext.Cfg.AutoIPPoolRetentionPeriod) * time.Second,
 		},
 		controllerContext.CRDManager.GetClient(),
 		controllerContext.DynamicClient,
@@ -0,0 +1,134 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/spidernet-io/spiderpool/api/v1/agent/client/daemonset"
+	"github.com/spidernet-io/spiderpool/api/v1/agent/models"
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/openapi"
+)
+
+// ipamCmd represents the base command.
+var ipamCmd = &cobra.Command{
+	Use:   "ipam",
+	Short: "spiderpoolctl ipam cli",
+	Long:  `spiderpoolctl ipam cli to interact with the IPAM of spiderpool-agent`,
+}
+
+// ipamDryRunCmd represents the dry-run command.
+var ipamDryRunCmd = &cobra.Command{
+	Use:   "dry-run",
+	Short: "dry-run the ip allocation",
+	Long:  `select the IPPools and count how many replicas of a pod could get ips from them, without allocating any ip. It talks to spiderpool-agent through the unix socket, so it runs on the node, such as in the spiderpool-agent pod`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+		socketPath, _ := flags.GetString("socket")
+		namespace, _ := flags.GetString("namespace")
+		node, _ := flags.GetString("node")
+		nic, _ := flags.GetString("interface")
+		replicas, _ := flags.GetInt64("replicas")
+		v4Pools, _ := flags.GetStringSlice("default-ipv4-ippool")
+		v6Pools, _ := flags.GetStringSlice("default-ipv6-ippool")
+
+		annotations, err := parseStringMapFlag(cmd, "annotations")
+		if err != nil {
+			return err
+		}
+		labels, err := parseStringMapFlag(cmd, "labels")
+		if err != nil {
+			return err
+		}
+
+		dryRunArgs := &models.IpamDryRunArgs{
+			IfName:            &nic,
+			PodNamespace:      &namespace,
+			NodeName:          &node,
+			PodAnnotations:    annotations,
+			PodLabels:         labels,
+			Replicas:          &replicas,
+			DefaultIPV4IPPool: v4Pools,
+			DefaultIPV6IPPool: v6Pools,
+		}
+		if err := dryRunArgs.Validate(strfmt.Default); err != nil {
+			return err
+		}
+
+		client, err := openapi.NewAgentOpenAPIUnixClient(socketPath)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Daemonset.PostIpamDryRun(daemonset.NewPostIpamDryRunParams().WithIpamDryRunArgs(dryRunArgs))
+		if err != nil {
+			return fmt.Errorf("failed to dry-run the ip allocation: %w", err)
+		}
+
+		data, err := json.MarshalIndent(resp.GetPayload(), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+
+		return nil
+	},
+}
+
+// parseStringMapFlag parses the flag in JSON or YAML, the value is read from
+// the file if it is prefixed with '@'.
+func parseStringMapFlag(cmd *cobra.Command, name string) (map[string]string, error) {
+	value, _ := cmd.Flags().GetString(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	data := []byte(value)
+	if strings.HasPrefix(value, "@") {
+		var err error
+		data, err = os.ReadFile(strings.TrimPrefix(value, "@"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read --%s: %w", name, err)
+		}
+	}
+
+	var m map[string]string
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse --%s: %w", name, err)
+	}
+
+	return m, nil
+}
+
+func init() {
+	// dry-run flags
+	ipamDryRunCmd.PersistentFlags().String("socket", constant.DefaultIPAMUnixSocketPath, "[optional] unix socket path of spiderpool-agent")
+	ipamDryRunCmd.PersistentFlags().String("namespace", "", "[required] pod namespace")
+	ipamDryRunCmd.PersistentFlags().String("node", "", "[required] the node name who the pod locates")
+	ipamDryRunCmd.PersistentFlags().String("interface", constant.ClusterDefaultInterfaceName, "[optional] pod interface")
+	ipamDryRunCmd.PersistentFlags().String("annotations", "", "[optional] pod annotations in JSON or YAML, or '@file' to read them from the file")
+	ipamDryRunCmd.PersistentFlags().String("labels", "", "[optional] pod labels in JSON or YAML, or '@file' to read them from the file")
+	ipamDryRunCmd.PersistentFlags().Int64("replicas", 1, "[optional] the number of pod replicas")
+	ipamDryRunCmd.PersistentFlags().StringSlice("default-ipv4-ippool", nil, "[optional] default IPv4 IPPools of the CNI network configuration")
+	ipamDryRunCmd.PersistentFlags().StringSlice("default-ipv6-ippool", nil, "[optional] default IPv6 IPPools of the CNI network configuration")
+
+	err := ipamDryRunCmd.MarkPersistentFlagRequired("namespace")
+	if nil != err {
+		logger.Error(err.Error())
+	}
+	err = ipamDryRunCmd.MarkPersistentFlagRequired("node")
+	if nil != err {
+		logger.Error(err.Error())
+	}
+
+	rootCmd.AddCommand(ipamCmd)
+	ipamCmd.AddCommand(ipamDryRunCmd)
+}
@@ -0,0 +1,286 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/spidernet-io/spiderpool/pkg/ippoolmanager"
+	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
+	"github.com/spidernet-io/spiderpool/pkg/podmanager"
+	"github.com/spidernet-io/spiderpool/pkg/reservedipmanager"
+	"github.com/spidernet-io/spiderpool/pkg/types"
+	"github.com/spidernet-io/spiderpool/pkg/workloadendpointmanager"
+)
+
+const (
+	outputWide = "wide"
+	outputJSON = "json"
+)
+
+// poolCmd represents the base command.
+var poolCmd = &cobra.Command{
+	Use:   "pool",
+	Short: "spiderpoolctl pool cli",
+	Long:  `spiderpoolctl pool cli to interact with SpiderIPPools`,
+}
+
+// poolStatusCmd represents the status command.
+var poolStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show the statistics and health of ippools",
+	Long:  `show the ip statistics of every SpiderIPPool, and the anomalies found by cross-referencing them with SpiderEndpoints`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+		output, _ := flags.GetString("output")
+		fix, _ := flags.GetBool("fix")
+		yes, _ := flags.GetBool("yes")
+		if output != "" && output != outputWide && output != outputJSON {
+			return fmt.Errorf("unsupported output format %q", output)
+		}
+
+		c, err := newK8sClient()
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		var poolList spiderpoolv2beta1.SpiderIPPoolList
+		if err := c.List(ctx, &poolList); err != nil {
+			return fmt.Errorf("failed to list SpiderIPPools: %w", err)
+		}
+		var endpointList spiderpoolv2beta1.SpiderEndpointList
+		if err := c.List(ctx, &endpointList); err != nil {
+			return fmt.Errorf("failed to list SpiderEndpoints: %w", err)
+		}
+
+		reports, err := ippoolmanager.AuditIPPools(poolList.Items, endpointList.Items)
+		if err != nil {
+			return err
+		}
+		sort.Slice(reports, func(i, j int) bool {
+			return reports[i].Name < reports[j].Name
+		})
+
+		out := cmd.OutOrStdout()
+		if err := printPoolReports(out, reports, output); err != nil {
+			return err
+		}
+		if !fix {
+			return nil
+		}
+
+		orphans := map[string][]ippoolmanager.IPPoolAnomaly{}
+		var count int
+		for _, report := range reports {
+			for _, anomaly := range report.Anomalies {
+				if anomaly.Type == ippoolmanager.AnomalyOrphanIP {
+					orphans[report.Name] = append(orphans[report.Name], anomaly)
+					count++
+				}
+			}
+		}
+		if count == 0 {
+			fmt.Fprintln(out, "no orphan ip to release")
+			return nil
+		}
+
+		if !yes {
+			fmt.Fprintf(out, "release %d orphan ips? [y/N]: ", count)
+			answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				fmt.Fprintln(out, "aborted")
+				return nil
+			}
+		}
+
+		return releaseOrphanIPs(cmd, c, orphans)
+	},
+}
+
+// releaseOrphanIPs releases the orphan IPs through the IPPool manager. As
+// the audit may be out of date, every orphan IP is checked again just before
+// it's released.
+func releaseOrphanIPs(cmd *cobra.Command, c client.Client, orphans map[string][]ippoolmanager.IPPoolAnomaly) error {
+	rIPManager, err := reservedipmanager.NewReservedIPManager(c, c)
+	if err != nil {
+		return err
+	}
+	ipPoolManager, err := ippoolmanager.NewIPPoolManager(ippoolmanager.IPPoolManagerConfig{}, c, c, rIPManager)
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	out := cmd.OutOrStdout()
+	var failed int
+	for pool, anomalies := range orphans {
+		var ipAndUIDs []types.IPAndUID
+		for _, anomaly := range anomalies {
+			orphan, err := isOrphanIP(ctx, c, anomaly)
+			if err != nil {
+				logger.Sugar().Errorf("failed to check orphan ip %s of IPPool %s: %v", anomaly.IP, pool, err)
+				failed++
+				continue
+			}
+			if !orphan {
+				fmt.Fprintf(out, "skip ip %s of IPPool %s, it's in use by pod %s now\n", anomaly.IP, pool, anomaly.Pod)
+				continue
+			}
+			ipAndUIDs = append(ipAndUIDs, types.IPAndUID{IP: anomaly.IP, UID: anomaly.PodUID})
+		}
+		if len(ipAndUIDs) == 0 {
+			continue
+		}
+
+		if err := ipPoolManager.ReleaseIP(ctx, pool, ipAndUIDs); err != nil {
+			logger.Sugar().Errorf("failed to release orphan ips of IPPool %s: %v", pool, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "released %d orphan ips of IPPool %s\n", len(ipAndUIDs), pool)
+	}
+	if failed != 0 {
+		return fmt.Errorf("failed to release the orphan ips, %d errors occurred", failed)
+	}
+
+	return nil
+}
+
+// isOrphanIP checks the orphan IP with the up-to-date pod and SpiderEndpoint,
+// the client isn't backed by a cache. The IP isn't an orphan any more if the
+// pod of the same UID is alive, or the SpiderEndpoint of the same pod UID
+// holds it.
+func isOrphanIP(ctx context.Context, c client.Client, anomaly ippoolmanager.IPPoolAnomaly) (bool, error) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(anomaly.Pod)
+	if err != nil {
+		return false, err
+	}
+
+	var pod corev1.Pod
+	err = c.Get(ctx, apitypes.NamespacedName{Namespace: namespace, Name: name}, &pod)
+	if client.IgnoreNotFound(err) != nil {
+		return false, err
+	}
+	if err == nil && string(pod.UID) == anomaly.PodUID && podmanager.IsPodAlive(&pod) {
+		return false, nil
+	}
+
+	var endpoint spiderpoolv2beta1.SpiderEndpoint
+	err = c.Get(ctx, apitypes.NamespacedName{Namespace: namespace, Name: name}, &endpoint)
+	if client.IgnoreNotFound(err) != nil {
+		return false, err
+	}
+	if err == nil && endpoint.Status.Current.UID == anomaly.PodUID && workloadendpointmanager.HasEndpointIP(&endpoint, anomaly.IP) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func printPoolReports(out io.Writer, reports []ippoolmanager.IPPoolReport, output string) error {
+	if output == outputJSON {
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(data))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	header := "NAME\tVERSION\tTOTAL\tUSED\tFREE\tUSED%\tSUBNET\tDISABLED\tHEALTH"
+	if output == outputWide {
+		header = "NAME\tVERSION\tCIDR\tGATEWAY\tTOTAL\tUSED\tFREE\tUSED%\tSUBNET\tDISABLED\tHEALTH"
+	}
+	fmt.Fprintln(w, header)
+	for _, r := range reports {
+		ownerSubnet := r.OwnerSubnet
+		if ownerSubnet == "" {
+			ownerSubnet = "<none>"
+		}
+		if output == outputWide {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%d\t%d\t%d\t%.1f%%\t%s\t%t\t%s\n", r.Name, r.IPVersion, r.Subnet, r.Gateway,
+				r.TotalIPCount, r.AllocatedIPCount, r.FreeIPCount, r.UsedPercentage, ownerSubnet, r.Disabled, formatAnomalies(r.Anomalies, true))
+		} else {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.1f%%\t%s\t%t\t%s\n", r.Name, r.IPVersion,
+				r.TotalIPCount, r.AllocatedIPCount, r.FreeIPCount, r.UsedPercentage, ownerSubnet, r.Disabled, formatAnomalies(r.Anomalies, false))
+		}
+	}
+
+	return w.Flush()
+}
+
+// formatAnomalies renders the anomalies as the count of every type, e.g.
+// 'OrphanIP(2)', or every anomaly with the IP and the Pod in detail.
+func formatAnomalies(anomalies []ippoolmanager.IPPoolAnomaly, detail bool) string {
+	if len(anomalies) == 0 {
+		return "Healthy"
+	}
+
+	var items []string
+	if detail {
+		for _, a := range anomalies {
+			item := a.Type + ":" + a.IP
+			if a.Pod != "" {
+				item += "(" + a.Pod + ")"
+			}
+			items = append(items, item)
+		}
+		return strings.Join(items, ",")
+	}
+
+	counts := map[string]int{}
+	var anomalyTypes []string
+	for _, a := range anomalies {
+		if counts[a.Type] == 0 {
+			anomalyTypes = append(anomalyTypes, a.Type)
+		}
+		counts[a.Type]++
+	}
+	for _, t := range anomalyTypes {
+		items = append(items, fmt.Sprintf("%s(%d)", t, counts[t]))
+	}
+	return strings.Join(items, ",")
+}
+
+func newK8sClient() (client.Client, error) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(spiderpoolv2beta1.AddToScheme(scheme))
+
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the kubeconfig: %w", err)
+	}
+
+	return client.New(config, client.Options{Scheme: scheme})
+}
+
+func init() {
+	// status flags
+	poolStatusCmd.PersistentFlags().StringP("output", "o", "", "[optional] output format, 'wide' or 'json'")
+	poolStatusCmd.PersistentFlags().Bool("fix", false, "[optional] release the orphan ips as the GC does, after confirmation")
+	poolStatusCmd.PersistentFlags().BoolP("yes", "y", false, "[optional] release the orphan ips without confirmation")
+
+	rootCmd.AddCommand(poolCmd)
+	poolCmd.AddCommand(poolStatusCmd)
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/spidernet-io/spiderpool/pkg/applicationcontroller/applicationinformers"
 	"github.com/spidernet-io/spiderpool/pkg/constant"
 	"github.com/spidernet-io/spiderpool/pkg/election"
+	"github.com/spidernet-io/spiderpool/pkg/ippoolmanager"
 	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
 	"github.com/spidernet-io/spiderpool/pkg/logutils"
 	"github.com/spidernet-io/spiderpool/pkg/subnetmanager"
@@ -769,9 +770,11 @@ func (sac *SubnetAppController) applyAutoIPPool(ctx context.Context, podSubnetCo
 		} else {
 			for i := range tmpPoolList.Items {
 				// We need to ignore the previous same NamespacedName application corresponding auto-created IPPool.
-				// Because this auto-created IPPool will be deleted by the system with 'ippool-reclaim'
+				// Because this auto-created IPPool will be deleted by the system with 'ippool-reclaim',
+				// unless it's still retained as an orphan.
 				labels := tmpPoolList.Items[i].GetLabels()
-				if labels[constant.LabelIPPoolReclaimIPPool] == constant.True && labels[constant.LabelIPPoolOwnerApplicationUID] != string(podController.UID) {
+				if labels[constant.LabelIPPoolReclaimIPPool] == constant.True && labels[constant.LabelIPPoolOwnerApplicationUID] != string(podController.UID) &&
+					!ippoolmanager.IsOrphanedAutoIPPool(&tmpPoolList.Items[i]) {
 					log.Sugar().Debugf("found the previous same app auto-created IPPool %s", tmpPoolList.Items[i].Name)
 					continue
 				}
@@ -166,7 +166,11 @@ func GenSubnetFreeIPs(subnet *spiderpoolv2beta1.SpiderSubnet) ([]net.IP, error)
 		return nil, err
 	}
 
-	totalIPs, err := spiderpoolip.AssembleTotalIPs(*subnet.Spec.IPVersion, subnet.Spec.IPs, subnet.Spec.ExcludeIPs)
+	var gateways []string
+	if subnet.Spec.Gateway != nil {
+		gateways = append(gateways, *subnet.Spec.Gateway)
+	}
+	totalIPs, err := spiderpoolip.AssembleAllocatableIPs(*subnet.Spec.IPVersion, subnet.Spec.Subnet, gateways, subnet.Spec.IPs, subnet.Spec.ExcludeIPs)
 	if err != nil {
 		return nil, err
 	}
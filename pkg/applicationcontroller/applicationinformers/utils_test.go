@@ -167,6 +167,8 @@ var _ = Describe("Utils", func() {
 			subnet = spiderpoolv2beta1.SpiderSubnet{
 				Spec: spiderpoolv2beta1.SubnetSpec{
 					IPVersion: pointer.Int64(4),
+					Subnet:    "10.0.0.0/16",
+					Gateway:   pointer.String("10.0.0.1"),
 					IPs: []string{
 						"10.0.0.10-10.0.0.100",
 						"10.0.1.10-10.0.1.101",
@@ -219,6 +221,13 @@ var _ = Describe("Utils", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(freeIPs).Should(HaveLen(91))
 		})
+
+		It("excludes the gateway of the Subnet from the free IPs", func() {
+			subnet.Spec.Gateway = pointer.String("10.0.1.10")
+			freeIPs, err := GenSubnetFreeIPs(&subnet)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(freeIPs).Should(HaveLen(90))
+		})
 	})
 
 	Context("GetSubnetAnnoConfig", Label("unitest", "GetSubnetAnnoConfig"), func() {
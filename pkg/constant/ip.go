@@ -19,4 +19,13 @@ const (
 	InvalidGateway   = "invalid routing gateway"
 )
 
+const (
+	RouteTableMain      = "main"
+	RouteTableInterface = "interface"
+	// RouteTableMin and RouteTableMax bound the explicit route table numbers,
+	// which exclude the tables reserved by the kernel
+	RouteTableMin = 1
+	RouteTableMax = 252
+)
+
 var InvalidIPRanges = []string{InvalidIPRange}
@@ -20,6 +20,13 @@ const (
 
 	// For ipam plugin and spiderpool-agent use
 	DefaultIPAMUnixSocketPath = "/var/run/spidernet/spiderpool.sock"
+	// For coordinator plugin to report its latency to spiderpool-agent, it
+	// shares the host directory of the unix socket
+	DefaultCoordinatorReportDir = "/var/run/spidernet/coordinator-reports"
+
+	// The CNI commands of coordinator getting its config from spiderpool-agent
+	CNICommandAdd = "ADD"
+	CNICommandDel = "DEL"
 )
 
 const (
@@ -62,6 +69,11 @@ const (
 	AnnoSpiderSubnets             = AnnotationPre + "/subnets"
 	AnnoSpiderSubnetPoolIPNumber  = AnnotationPre + "/ippool-ip-number"
 	AnnoSpiderSubnetReclaimIPPool = AnnotationPre + "/ippool-reclaim"
+	AnnoIPPoolOrphanedSince       = AnnotationPre + "/orphaned-since"
+
+	// forced release of the IPs of the pod terminating beyond the deadline
+	AnnoForcedReleaseTime = AnnotationPre + "/forced-release-time"
+	AnnoForcedReleaseIPs  = AnnotationPre + "/forced-release-ips"
 
 	LabelIPPoolReclaimIPPool             = AnnoSpiderSubnetReclaimIPPool
 	LabelIPPoolOwnerSpiderSubnet         = AnnotationPre + "/owner-spider-subnet"
@@ -90,6 +102,7 @@ const (
 	MultusConfAnnoPre          = "multus.spidernet.io"
 	AnnoNetAttachConfName      = MultusConfAnnoPre + "/cr-name"
 	AnnoMultusConfigCNIVersion = MultusConfAnnoPre + "/cni-version"
+	AnnoMultusConfigSharedVlan = MultusConfAnnoPre + "/shared-vlan"
 
 	// Coordinator
 	AnnoDefaultRouteInterface = AnnotationPre + "/default-route-nic"
@@ -120,6 +133,11 @@ const (
 	IgnoreCache = false
 )
 
+// the reasons of the released IP allocations recorded in the history of SpiderEndpoint
+const (
+	ReleaseReasonPodDeleted = "PodDeleted"
+)
+
 const (
 	SpiderControllerElectorLockName = SpiderpoolController + "-" + resourcelock.LeasesResourceLock
 	QualifiedK8sObjNameFmt          = "[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*"
@@ -27,6 +27,15 @@ func mutateCoordinator(ctx context.Context, coord *spiderpoolv2beta1.SpiderCoord
 	if coord.Spec.TunePodRoutes == nil {
 		coord.Spec.TunePodRoutes = pointer.Bool(true)
 	}
+	if coord.Spec.RouteTableMode == nil {
+		coord.Spec.RouteTableMode = pointer.String("move")
+	}
+	if coord.Spec.EnableReplyViaVeth == nil {
+		coord.Spec.EnableReplyViaVeth = pointer.Bool(true)
+	}
+	if coord.Spec.DefaultRouteMode == nil {
+		coord.Spec.DefaultRouteMode = pointer.String("primaryBackup")
+	}
 	if coord.Spec.HostRuleTable == nil {
 		coord.Spec.HostRuleTable = pointer.Int(500)
 	}
@@ -39,6 +48,9 @@ func mutateCoordinator(ctx context.Context, coord *spiderpoolv2beta1.SpiderCoord
 	if coord.Spec.DetectGateway == nil {
 		coord.Spec.DetectGateway = pointer.Bool(false)
 	}
+	if coord.Spec.DetectGatewayMode == nil {
+		coord.Spec.DetectGatewayMode = pointer.String("arp")
+	}
 
 	if coord.DeletionTimestamp != nil {
 		logger.Info("Terminating Coordinator, noting to mutate")
@@ -4,22 +4,20 @@
 package coordinatormanager
 
 import (
-	"fmt"
-	"strconv"
-	"strings"
-
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/strings/slices"
 
 	"github.com/spidernet-io/spiderpool/pkg/ip"
 	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
 )
 
 var (
-	podCIDRTypeField  *field.Path = field.NewPath("spec").Child("podCIDRType")
-	extraCIDRField    *field.Path = field.NewPath("spec").Child("extraCIDR")
-	podMACPrefixField *field.Path = field.NewPath("spec").Child("podMACPrefix")
-	hostRPFilterField *field.Path = field.NewPath("spec").Child("hostRPFilter")
+	podCIDRTypeField   *field.Path = field.NewPath("spec").Child("podCIDRType")
+	extraCIDRField     *field.Path = field.NewPath("spec").Child("extraCIDR")
+	podMACPrefixField  *field.Path = field.NewPath("spec").Child("podMACPrefix")
+	hostRPFilterField  *field.Path = field.NewPath("spec").Child("hostRPFilter")
+	hostRuleTableField *field.Path = field.NewPath("spec").Child("hostRuleTable")
 )
 
 func validateCreateCoordinator(coord *spiderpoolv2beta1.SpiderCoordinator) field.ErrorList {
@@ -83,6 +81,12 @@ func ValidateCoordinatorSpec(spec *spiderpoolv2beta1.CoordinatorSpec, requireOpt
 		}
 	}
 
+	if spec.HostRuleTable != nil {
+		if err := networking.ValidateRuleTable(*spec.HostRuleTable); err != nil {
+			return field.Invalid(hostRuleTableField, *spec.HostRuleTable, err.Error())
+		}
+	}
+
 	return nil
 }
 
@@ -126,28 +130,8 @@ func validateCoordinatorPodMACPrefix(prefix *string) *field.Error {
 		return nil
 	}
 
-	errInvalid := field.Invalid(podMACPrefixField, *prefix, "not a valid MAC prefix")
-	parts := strings.Split(*prefix, ":")
-	if len(parts) != 2 {
-		return errInvalid
-	}
-
-	if len(parts[0]) != 2 || len(parts[1]) != 2 {
-		return errInvalid
-	}
-
-	fb, err := strconv.ParseInt(parts[0], 16, 0)
-	if err != nil {
-		return errInvalid
-	}
-	_, err = strconv.ParseInt(parts[1], 16, 0)
-	if err != nil {
-		return errInvalid
-	}
-
-	bb := fmt.Sprintf("%08b", fb)
-	if string(bb[7]) != "0" {
-		return field.Invalid(podMACPrefixField, *prefix, "not a unicast MAC")
+	if err := networking.ValidateMACPrefix(*prefix); err != nil {
+		return field.Invalid(podMACPrefixField, *prefix, err.Error())
 	}
 
 	return nil
@@ -0,0 +1,92 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package event
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/spidernet-io/spiderpool/pkg/lock"
+	"github.com/spidernet-io/spiderpool/pkg/metric/cnireport"
+)
+
+// CoordinatorRouteWarningReason is the reason of the pod events emitted for
+// the route tuning of the coordinator failed without failing the pod
+const CoordinatorRouteWarningReason = "CoordinatorRouteWarning"
+
+// CoordinatorWarningEmitter emits the warnings in the coordinator reports as
+// the Warning events of the pods, at most one event per pod every interval.
+type CoordinatorWarningEmitter struct {
+	recorder record.EventRecorder
+	// getPod returns the pod to emit the event for, the pod is referenced
+	// by the namespace and the name if it's nil or fails
+	getPod   func(ctx context.Context, namespace, name string) (*corev1.Pod, error)
+	interval time.Duration
+
+	lock lock.Mutex
+	// lastEmit is the time of the last event emitted for each pod
+	lastEmit map[string]time.Time
+}
+
+// NewCoordinatorWarningEmitter creates a CoordinatorWarningEmitter, the getPod
+// may be nil.
+func NewCoordinatorWarningEmitter(recorder record.EventRecorder, interval time.Duration,
+	getPod func(ctx context.Context, namespace, name string) (*corev1.Pod, error)) *CoordinatorWarningEmitter {
+	return &CoordinatorWarningEmitter{
+		recorder: recorder,
+		getPod:   getPod,
+		interval: interval,
+		lastEmit: map[string]time.Time{},
+	}
+}
+
+// Emit emits the warnings of the report as an event of its pod, it returns
+// false if the report has no warnings or the pod is rate limited.
+func (e *CoordinatorWarningEmitter) Emit(ctx context.Context, r *cnireport.Report) bool {
+	if len(r.Warnings) == 0 || r.PodName == "" {
+		return false
+	}
+
+	key := r.PodNamespace + "/" + r.PodName
+	now := time.Now()
+	e.lock.Lock()
+	for k, t := range e.lastEmit {
+		if now.Sub(t) >= e.interval {
+			delete(e.lastEmit, k)
+		}
+	}
+	if _, ok := e.lastEmit[key]; ok {
+		e.lock.Unlock()
+		return false
+	}
+	e.lastEmit[key] = now
+	e.lock.Unlock()
+
+	warnings := make([]string, 0, len(r.Warnings))
+	for _, w := range r.Warnings {
+		warnings = append(warnings, fmt.Sprintf("%s: %s", w.Object, w.Error))
+	}
+
+	var obj runtime.Object = &corev1.ObjectReference{
+		Kind:       "Pod",
+		APIVersion: "v1",
+		Namespace:  r.PodNamespace,
+		Name:       r.PodName,
+	}
+	if e.getPod != nil {
+		if pod, err := e.getPod(ctx, r.PodNamespace, r.PodName); err == nil {
+			obj = pod
+		}
+	}
+	e.recorder.Eventf(obj, corev1.EventTypeWarning, CoordinatorRouteWarningReason,
+		"coordinator failed to tune the routes of container %s, the pod may not communicate as expected: %s",
+		r.ContainerID, strings.Join(warnings, "; "))
+	return true
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package gcmanager
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/spidernet-io/spiderpool/api/v1/agent/client/daemonset"
+	"github.com/spidernet-io/spiderpool/api/v1/agent/models"
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/event"
+	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
+	"github.com/spidernet-io/spiderpool/pkg/logutils"
+	"github.com/spidernet-io/spiderpool/pkg/metric"
+	"github.com/spidernet-io/spiderpool/pkg/openapi"
+	"github.com/spidernet-io/spiderpool/pkg/types"
+	"github.com/spidernet-io/spiderpool/pkg/workloadendpointmanager"
+)
+
+// the timeout of requesting spiderpool-agent to detect the IP conflict before
+// the forced release
+const forcedReleaseDetectTimeout = 30 * time.Second
+
+// forceReleaseTerminatingPodsIPs releases the IPs of the pods which have been
+// terminating for ForcedReleaseTerminatingPodDeadline on a Ready node, the
+// kubelet should have cleaned them up but didn't. The pods on the NotReady
+// nodes are left to the unreachable node IP GC.
+func (s *SpiderGC) forceReleaseTerminatingPodsIPs(ctx context.Context) {
+	if s.gcConfig.ForcedReleaseTerminatingPodDeadline <= 0 || s.nodeLister == nil || !s.leader.IsElected() {
+		return
+	}
+
+	endpointList, err := s.wepMgr.ListEndpoints(ctx, constant.UseCache)
+	if err != nil {
+		logger.Sugar().Errorf("failed to list SpiderEndpoints: %v", err)
+		return
+	}
+
+	deadline := time.Duration(s.gcConfig.ForcedReleaseTerminatingPodDeadline) * time.Second
+	for i := range endpointList.Items {
+		endpoint := &endpointList.Items[i]
+		if _, ok := endpoint.Annotations[constant.AnnoForcedReleaseTime]; ok {
+			continue
+		}
+
+		pod, err := s.podMgr.GetPodByName(ctx, endpoint.Namespace, endpoint.Name, constant.UseCache)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				logger.Sugar().Errorf("failed to get pod '%s/%s': %v", endpoint.Namespace, endpoint.Name, err)
+			}
+			continue
+		}
+		if pod.DeletionTimestamp == nil || time.Since(pod.DeletionTimestamp.Time) <= deadline ||
+			string(pod.UID) != endpoint.Status.Current.UID {
+			continue
+		}
+
+		wrappedLog := logger.With(
+			zap.String("podNS", pod.Namespace),
+			zap.String("podName", pod.Name),
+			zap.String("podUID", string(pod.UID)),
+			zap.String("node", pod.Spec.NodeName),
+			zap.String("gc-reason", "pod is terminating beyond the deadline"),
+		)
+
+		ready, err := s.isNodeReady(pod.Spec.NodeName)
+		if err != nil {
+			wrappedLog.Sugar().Errorf("failed to check the node is Ready or not: %v", err)
+			continue
+		}
+		if !ready {
+			wrappedLog.Debug("the node is not Ready, leave the IPs to the unreachable node IP GC")
+			continue
+		}
+
+		if s.gcConfig.EnableStatefulSet && endpoint.Status.OwnerControllerType == constant.KindStatefulSet {
+			isValidStsPod, err := s.stsMgr.IsValidStatefulSetPod(ctx, pod.Namespace, pod.Name, constant.KindStatefulSet)
+			if err != nil {
+				wrappedLog.Sugar().Errorf("failed to check StatefulSet pod should be cleaned or not, error: %v", err)
+				continue
+			}
+			if isValidStsPod {
+				wrappedLog.Debug("keep the IPs reserved for the StatefulSet pod")
+				continue
+			}
+		}
+
+		s.forceReleaseEndpointIPs(logutils.IntoContext(ctx, wrappedLog), endpoint, pod)
+	}
+}
+
+// forceReleaseEndpointIPs releases the IPs of the SpiderEndpoint after making
+// sure that none of them is still live on the wire of the node of the pod,
+// then annotates the SpiderEndpoint with the forced release and removes its
+// finalizer.
+func (s *SpiderGC) forceReleaseEndpointIPs(ctx context.Context, endpoint *spiderpoolv2beta1.SpiderEndpoint, pod *corev1.Pod) {
+	log := logutils.FromContext(ctx)
+
+	endpointIPs := workloadendpointmanager.ListEndpointIPs(endpoint)
+	if len(endpointIPs) == 0 {
+		return
+	}
+
+	deletionTime := pod.DeletionTimestamp.Time
+	for _, ip := range endpointIPs {
+		if err := s.detectIPConflict(ctx, pod, ip.IP); err != nil {
+			log.Sugar().Warnf("keep the IPs, IP '%s' may be still in use: %v", ip.IP, err)
+			event.EventRecorder.Eventf(endpoint, corev1.EventTypeWarning, "IPForcedReleaseSkipped",
+				"Pod has been terminating since %s, but IP %s may be still in use: %v", deletionTime.UTC().Format(time.RFC3339), ip.IP, err)
+			return
+		}
+	}
+
+	released := make([]string, 0, len(endpointIPs))
+	for _, ip := range endpointIPs {
+		err := s.ippoolMgr.ReleaseIP(ctx, ip.Pool, []types.IPAndUID{{IP: ip.IP, UID: endpoint.Status.Current.UID}})
+		if err != nil {
+			metric.IPGCFailureCounts.Add(ctx, 1)
+			log.Sugar().Errorf("failed to release IP '%s' of IPPool '%s': %v", ip.IP, ip.Pool, err)
+			continue
+		}
+
+		metric.IPGCTotalCounts.Add(ctx, 1)
+		released = append(released, ip.IP)
+		log.Sugar().Infof("force releasing IP '%s' of IPPool '%s' successfully", ip.IP, ip.Pool)
+		event.EventRecorder.Eventf(endpoint, corev1.EventTypeWarning, "IPForcedReleased",
+			"Released IP %s of IPPool %s, because the pod has been terminating since %s", ip.IP, ip.Pool, deletionTime.UTC().Format(time.RFC3339))
+	}
+
+	// keep the SpiderEndpoint for the next try if some IPs are not released
+	if len(released) != len(endpointIPs) {
+		return
+	}
+
+	if err := s.wepMgr.AnnotateForcedRelease(ctx, endpoint, released, metav1.Now()); err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	// the StatefulSet pod to come reuses the IPs of the SpiderEndpoint, so delete it
+	if endpoint.Status.OwnerControllerType == constant.KindStatefulSet && endpoint.DeletionTimestamp == nil {
+		if err := s.wepMgr.DeleteEndpoint(ctx, endpoint); err != nil {
+			log.Sugar().Errorf("failed to delete SpiderEndpoint: %v", err)
+			return
+		}
+	}
+
+	if err := s.wepMgr.RemoveFinalizer(ctx, endpoint); err != nil {
+		log.Error(err.Error())
+		return
+	}
+	log.Info("force releasing the IPs of SpiderEndpoint successfully")
+}
+
+// isNodeReady checks whether the Ready condition of the node is True
+func (s *SpiderGC) isNodeReady(nodeName string) (bool, error) {
+	node, err := s.nodeLister.Get(nodeName)
+	if err != nil {
+		return false, err
+	}
+
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue, nil
+		}
+	}
+	return false, nil
+}
+
+// detectIPConflict requests the spiderpool-agent on the node of the pod to
+// check whether the ip still answers ARP or NDP there, the node may be not the
+// one spiderpool-controller runs on.
+func (s *SpiderGC) detectIPConflict(ctx context.Context, pod *corev1.Pod, ip string) error {
+	if pod.Status.HostIP == "" {
+		return fmt.Errorf("the host IP of node '%s' is unknown", pod.Spec.NodeName)
+	}
+
+	host := net.JoinHostPort(pod.Status.HostIP, strconv.Itoa(s.gcConfig.AgentHttpPort))
+	agentAPI, err := openapi.NewAgentOpenAPIHttpClient(host, forcedReleaseDetectTimeout)
+	if err != nil {
+		return err
+	}
+
+	params := daemonset.NewPostIpamIPConflictParams().
+		WithContext(ctx).
+		WithIpamIPConflictArgs(&models.IpamIPConflictArgs{IP: &ip})
+	if _, err := agentAPI.Daemonset.PostIpamIPConflict(params); err != nil {
+		return fmt.Errorf("spiderpool-agent on node '%s' failed to check it: %w", pod.Spec.NodeName, err)
+	}
+
+	return nil
+}
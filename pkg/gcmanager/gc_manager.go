@@ -11,6 +11,7 @@ import (
 	"go.uber.org/zap"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
 
 	"github.com/spidernet-io/spiderpool/pkg/election"
 	"github.com/spidernet-io/spiderpool/pkg/ippoolmanager"
@@ -24,6 +25,7 @@ import (
 type GarbageCollectionConfig struct {
 	EnableGCIP                bool
 	EnableGCForTerminatingPod bool
+	EnableGCForDeletedNode    bool
 	EnableStatefulSet         bool
 
 	ReleaseIPWorkerNum     int
@@ -36,6 +38,13 @@ type GarbageCollectionConfig struct {
 	GCSignalTimeoutDuration   int
 	GCSignalGapDuration       int
 	AdditionalGraceDelay      int
+	GCNodeUnreachableDuration int
+	// ForcedReleaseTerminatingPodDeadline is the seconds after the deletion
+	// timestamp of the terminating pod to force releasing its IPs, 0 disables it
+	ForcedReleaseTerminatingPodDeadline int
+	// AgentHttpPort is the http port of spiderpool-agent, which is requested
+	// to detect the IP conflict on the node of the pod before the forced release
+	AgentHttpPort int
 
 	LeaderRetryElectGap time.Duration
 }
@@ -69,6 +78,7 @@ type SpiderGC struct {
 	leader    election.SpiderLeaseElector
 
 	informerFactory informers.SharedInformerFactory
+	nodeLister      corelisters.NodeLister
 	gcLimiter       limiter.Limiter
 }
 
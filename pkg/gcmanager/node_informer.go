@@ -0,0 +1,188 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package gcmanager
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelapi "go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/event"
+	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
+	"github.com/spidernet-io/spiderpool/pkg/logutils"
+	"github.com/spidernet-io/spiderpool/pkg/metric"
+	"github.com/spidernet-io/spiderpool/pkg/podmanager"
+	"github.com/spidernet-io/spiderpool/pkg/types"
+	"github.com/spidernet-io/spiderpool/pkg/workloadendpointmanager"
+)
+
+const (
+	nodeReclaimReasonDeleted     = "node_deleted"
+	nodeReclaimReasonUnreachable = "node_unreachable"
+)
+
+// onNodeDel represents Node informer Delete Event, the pods on the deleted
+// node will never run CNI DEL, so their IPs are reclaimed here
+func (s *SpiderGC) onNodeDel(obj interface{}) {
+	// backup controller could be elected as master
+	if !s.leader.IsElected() {
+		return
+	}
+
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			logger.Sugar().Errorf("onNodeDel: unexpected object %+v", obj)
+			return
+		}
+		node, ok = tombstone.Obj.(*corev1.Node)
+		if !ok {
+			logger.Sugar().Errorf("onNodeDel: unexpected tombstone object %+v", tombstone.Obj)
+			return
+		}
+	}
+
+	logger.Sugar().Infof("onNodeDel: node '%s' is deleted, try to reclaim the IPs of its pods", node.Name)
+	go s.reclaimNodeIPs(context.TODO(), node.Name, nodeReclaimReasonDeleted)
+}
+
+// reclaimUnreachableNodesIPs reclaims the IPs of the pods on the nodes which
+// have been unreachable for GCNodeUnreachableDuration
+func (s *SpiderGC) reclaimUnreachableNodesIPs(ctx context.Context) {
+	if s.gcConfig.GCNodeUnreachableDuration <= 0 || s.nodeLister == nil || !s.leader.IsElected() {
+		return
+	}
+
+	nodes, err := s.nodeLister.List(labels.Everything())
+	if err != nil {
+		logger.Sugar().Errorf("failed to list nodes: %v", err)
+		return
+	}
+
+	unreachableDuration := time.Duration(s.gcConfig.GCNodeUnreachableDuration) * time.Second
+	for _, node := range nodes {
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionUnknown &&
+				time.Since(condition.LastTransitionTime.Time) > unreachableDuration {
+				logger.Sugar().Warnf("node '%s' has been unreachable since %v, try to reclaim the IPs of its pods", node.Name, condition.LastTransitionTime)
+				s.reclaimNodeIPs(ctx, node.Name, nodeReclaimReasonUnreachable)
+			}
+		}
+	}
+}
+
+// reclaimNodeIPs releases the IPs of the SpiderEndpoints on the node and
+// deletes the SpiderEndpoints. The IPs of the StatefulSet pods which will be
+// rescheduled are kept reserved for them, and the IPs of the pods which may
+// still be running are never released.
+func (s *SpiderGC) reclaimNodeIPs(ctx context.Context, nodeName, reason string) {
+	nodeLogger := logger.With(
+		zap.String("node", nodeName),
+		zap.String("gc-reason", reason),
+	)
+
+	endpointList, err := s.wepMgr.ListEndpoints(ctx, constant.UseCache)
+	if err != nil {
+		nodeLogger.Sugar().Errorf("failed to list SpiderEndpoints: %v", err)
+		return
+	}
+
+	reasonAttr := attribute.String("reason", reason)
+	for i := range endpointList.Items {
+		endpoint := &endpointList.Items[i]
+		if endpoint.Status.Current.Node != nodeName {
+			continue
+		}
+
+		wrappedLog := nodeLogger.With(
+			zap.String("podNS", endpoint.Namespace),
+			zap.String("podName", endpoint.Name),
+			zap.String("podUID", endpoint.Status.Current.UID),
+		)
+
+		if s.gcConfig.EnableStatefulSet && endpoint.Status.OwnerControllerType == constant.KindStatefulSet {
+			isValidStsPod, err := s.stsMgr.IsValidStatefulSetPod(ctx, endpoint.Namespace, endpoint.Name, constant.KindStatefulSet)
+			if err != nil {
+				wrappedLog.Sugar().Errorf("failed to check StatefulSet pod should be cleaned or not, error: %v", err)
+				continue
+			}
+			if isValidStsPod {
+				wrappedLog.Info("keep the IPs reserved for the StatefulSet pod")
+				event.EventRecorder.Eventf(endpoint, corev1.EventTypeNormal, "IPReservedForOwner",
+					"Node %s is gone, the IPs are reserved for the rescheduled pod", nodeName)
+				continue
+			}
+		}
+
+		gone, err := s.isEndpointPodGone(ctx, endpoint)
+		if err != nil {
+			wrappedLog.Sugar().Errorf("failed to check whether the pod is gone: %v", err)
+			continue
+		}
+		if !gone {
+			wrappedLog.Info("the pod is still alive, keep its IPs")
+			continue
+		}
+
+		released := true
+		for _, ip := range workloadendpointmanager.ListEndpointIPs(endpoint) {
+			err := s.ippoolMgr.ReleaseIP(ctx, ip.Pool, []types.IPAndUID{{IP: ip.IP, UID: endpoint.Status.Current.UID}})
+			if err != nil {
+				metric.IPGCFailureCounts.Add(ctx, 1)
+				wrappedLog.Sugar().Errorf("failed to release IP '%s' of IPPool '%s': %v", ip.IP, ip.Pool, err)
+				released = false
+				continue
+			}
+
+			metric.IPGCTotalCounts.Add(ctx, 1)
+			metric.IPGCNodeReclaimCounts.Add(ctx, 1, otelapi.WithAttributes(reasonAttr))
+			wrappedLog.Sugar().Infof("release IP '%s' of IPPool '%s' successfully", ip.IP, ip.Pool)
+			event.EventRecorder.Eventf(endpoint, corev1.EventTypeWarning, "IPReclaimed",
+				"Released IP %s of IPPool %s, because of %s %s", ip.IP, ip.Pool, strings.ReplaceAll(reason, "_", " "), nodeName)
+		}
+
+		// keep the SpiderEndpoint for the next try if some IPs are not released
+		if !released {
+			continue
+		}
+
+		if err := s.wepMgr.DeleteEndpoint(ctx, endpoint); err != nil {
+			wrappedLog.Sugar().Errorf("failed to delete SpiderEndpoint: %v", err)
+			continue
+		}
+		if err := s.wepMgr.RemoveFinalizer(logutils.IntoContext(ctx, wrappedLog), endpoint); err != nil {
+			wrappedLog.Error(err.Error())
+			continue
+		}
+		wrappedLog.Info("delete SpiderEndpoint successfully")
+	}
+}
+
+// isEndpointPodGone checks the pod of the SpiderEndpoint from the API Server
+// rather than the cache, it's gone if it's deleted, recreated with another UID
+// or terminated.
+func (s *SpiderGC) isEndpointPodGone(ctx context.Context, endpoint *spiderpoolv2beta1.SpiderEndpoint) (bool, error) {
+	pod, err := s.podMgr.GetPodByName(ctx, endpoint.Namespace, endpoint.Name, constant.IgnoreCache)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	if string(pod.UID) != endpoint.Status.Current.UID {
+		return true, nil
+	}
+	return !podmanager.IsPodAlive(pod), nil
+}
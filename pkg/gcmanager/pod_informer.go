@@ -59,6 +59,25 @@ func (s *SpiderGC) startPodInformer(ctx context.Context) {
 			innerCancel()
 			continue
 		}
+
+		// the node informer is needed by the deleted and the unreachable node IP GC,
+		// and the forced release of the terminating pod IPs
+		if s.gcConfig.EnableGCForDeletedNode || s.gcConfig.GCNodeUnreachableDuration > 0 ||
+			s.gcConfig.ForcedReleaseTerminatingPodDeadline > 0 {
+			nodeInformer := informerFactory.Core().V1().Nodes()
+			if s.gcConfig.EnableGCForDeletedNode {
+				_, err = nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+					DeleteFunc: s.onNodeDel,
+				})
+				if nil != err {
+					logger.Error(err.Error())
+					innerCancel()
+					continue
+				}
+			}
+			s.nodeLister = nodeInformer.Lister()
+		}
+
 		s.informerFactory = informerFactory
 		informerFactory.Start(innerCtx.Done())
 
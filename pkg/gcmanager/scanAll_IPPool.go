@@ -6,7 +6,6 @@ package gcmanager
 import (
 	"context"
 	"fmt"
-	"strings"
 	"sync"
 	"time"
 
@@ -20,6 +19,7 @@ import (
 	"github.com/spidernet-io/spiderpool/pkg/metric"
 	"github.com/spidernet-io/spiderpool/pkg/types"
 	"github.com/spidernet-io/spiderpool/pkg/utils/convert"
+	"github.com/spidernet-io/spiderpool/pkg/workloadendpointmanager"
 )
 
 // monitorGCSignal will monitor signal from CLI, DefaultGCInterval
@@ -75,6 +75,9 @@ func (s *SpiderGC) monitorGCSignal(ctx context.Context) {
 
 // executeScanAll scans the whole pod and whole IPPoolList
 func (s *SpiderGC) executeScanAll(ctx context.Context) {
+	s.reclaimUnreachableNodesIPs(ctx)
+	s.forceReleaseTerminatingPodsIPs(ctx)
+
 	poolList, err := s.ippoolMgr.ListIPPools(ctx, constant.UseCache)
 	if nil != err {
 		if apierrors.IsNotFound(err) {
@@ -210,19 +213,7 @@ func (s *SpiderGC) executeScanAll(ctx context.Context) {
 							// case: The pod in IPPool's ip-allocationDetail is also exist in k8s,
 							// and the IPPool IP corresponding allocation pod UID is same with Endpoint pod UID, but the IPPool IP isn't belong to the Endpoint IPs
 							wrappedLog := scanAllLogger.With(zap.String("gc-reason", "same pod UID but IPPoolAllocation IP is different with Endpoint IP"))
-							isBadIP := true
-							for _, endpointIP := range endpoint.Status.Current.IPs {
-								if *pool.Spec.IPVersion == constant.IPv4 {
-									if endpointIP.IPv4 != nil && strings.Split(*endpointIP.IPv4, "/")[0] == poolIP {
-										isBadIP = false
-									}
-								} else {
-									if endpointIP.IPv6 != nil && strings.Split(*endpointIP.IPv6, "/")[0] == poolIP {
-										isBadIP = false
-									}
-								}
-							}
-							if isBadIP {
+							if !workloadendpointmanager.HasEndpointIP(endpoint, poolIP) {
 								// release IP but no need to clean up SpiderEndpoint object
 								err = s.ippoolMgr.ReleaseIP(ctx, pool.Name, []types.IPAndUID{{
 									IP:  poolIP,
@@ -6,6 +6,8 @@ package ip
 import (
 	"fmt"
 	"net"
+	"net/netip"
+	"sort"
 
 	"github.com/spidernet-io/spiderpool/pkg/constant"
 	"github.com/spidernet-io/spiderpool/pkg/types"
@@ -102,3 +104,80 @@ func IsIPv6CIDR(subnet string) bool {
 
 	return ip.To4() == nil
 }
+
+// AggregatePrefixes merges the prefixes into the fewest prefixes covering
+// exactly the same IP addresses, like the routes to several Pod IPs via the
+// same gateway. The prefixes contained in another one are dropped, and two
+// adjacent prefixes halving their supernet, like "172.18.40.0/25" and
+// "172.18.40.128/25", are merged into it, repeatedly. The result is sorted,
+// with IPv4 prefixes first.
+func AggregatePrefixes(prefixes []*net.IPNet) []*net.IPNet {
+	nPrefixes := make([]netip.Prefix, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		if prefix == nil {
+			continue
+		}
+		addr, ok := netip.AddrFromSlice(prefix.IP)
+		if !ok {
+			continue
+		}
+		addr = addr.Unmap()
+		ones, bits := prefix.Mask.Size()
+		if bits == 0 || bits != addr.BitLen() {
+			continue
+		}
+		nPrefixes = append(nPrefixes, netip.PrefixFrom(addr, ones).Masked())
+	}
+
+	sort.Slice(nPrefixes, func(i, j int) bool {
+		if c := nPrefixes[i].Addr().Compare(nPrefixes[j].Addr()); c != 0 {
+			return c < 0
+		}
+		return nPrefixes[i].Bits() < nPrefixes[j].Bits()
+	})
+
+	// sorted, a prefix can only be contained in the last one kept, and the
+	// supernet of two merged prefixes can only be merged with the last one
+	// kept before them
+	var aggregated []netip.Prefix
+	for _, p := range nPrefixes {
+		if n := len(aggregated); n > 0 && aggregated[n-1].Overlaps(p) {
+			continue
+		}
+		aggregated = append(aggregated, p)
+
+		for n := len(aggregated); n > 1; n = len(aggregated) {
+			supernet, ok := mergeSiblingPrefixes(aggregated[n-2], aggregated[n-1])
+			if !ok {
+				break
+			}
+			aggregated = append(aggregated[:n-2], supernet)
+		}
+	}
+
+	result := make([]*net.IPNet, 0, len(aggregated))
+	for _, p := range aggregated {
+		result = append(result, &net.IPNet{
+			IP:   net.IP(p.Addr().AsSlice()),
+			Mask: net.CIDRMask(p.Bits(), p.Addr().BitLen()),
+		})
+	}
+
+	return result
+}
+
+// mergeSiblingPrefixes returns the supernet of p1 and p2 if they are the two
+// halves of it.
+func mergeSiblingPrefixes(p1, p2 netip.Prefix) (netip.Prefix, bool) {
+	if p1.Bits() != p2.Bits() || p1.Bits() == 0 || p1.Addr().BitLen() != p2.Addr().BitLen() || p1 == p2 {
+		return netip.Prefix{}, false
+	}
+
+	supernet1, _ := p1.Addr().Prefix(p1.Bits() - 1)
+	supernet2, _ := p2.Addr().Prefix(p2.Bits() - 1)
+	if supernet1 != supernet2 {
+		return netip.Prefix{}, false
+	}
+
+	return supernet1, true
+}
@@ -183,4 +183,87 @@ var _ = Describe("CIDR", Label("cidr_test"), func() {
 			Expect(spiderpoolip.IsIPv6CIDR("abcd:1234::/120")).To(BeTrue())
 		})
 	})
+
+	Describe("Test AggregatePrefixes", func() {
+		parse := func(cidrs ...string) []*net.IPNet {
+			prefixes := make([]*net.IPNet, 0, len(cidrs))
+			for _, cidr := range cidrs {
+				_, ipNet, err := net.ParseCIDR(cidr)
+				Expect(err).NotTo(HaveOccurred())
+				prefixes = append(prefixes, ipNet)
+			}
+			return prefixes
+		}
+
+		toStrings := func(prefixes []*net.IPNet) []string {
+			cidrs := make([]string, 0, len(prefixes))
+			for _, prefix := range prefixes {
+				cidrs = append(cidrs, prefix.String())
+			}
+			return cidrs
+		}
+
+		It("inputs no prefixes", func() {
+			Expect(spiderpoolip.AggregatePrefixes(nil)).To(BeEmpty())
+		})
+
+		It("merges two adjacent /25 into /24", func() {
+			aggregated := spiderpoolip.AggregatePrefixes(parse("172.18.40.128/25", "172.18.40.0/25"))
+			Expect(aggregated).To(Equal([]*net.IPNet{
+				{
+					IP:   net.IPv4(172, 18, 40, 0).To4(),
+					Mask: net.CIDRMask(24, 32),
+				},
+			}))
+		})
+
+		It("merges the /32 of the Pod IPs repeatedly", func() {
+			aggregated := spiderpoolip.AggregatePrefixes(parse(
+				"172.18.40.3/32",
+				"172.18.40.1/32",
+				"172.18.40.0/32",
+				"172.18.40.2/32",
+				"172.18.40.4/32",
+			))
+			Expect(toStrings(aggregated)).To(Equal([]string{"172.18.40.0/30", "172.18.40.4/32"}))
+		})
+
+		It("drops the prefixes contained in another one", func() {
+			aggregated := spiderpoolip.AggregatePrefixes(parse(
+				"172.18.40.10/32",
+				"172.18.40.0/24",
+				"172.18.40.0/24",
+				"172.18.40.128/26",
+			))
+			Expect(toStrings(aggregated)).To(Equal([]string{"172.18.40.0/24"}))
+		})
+
+		It("keeps the prefixes not aggregatable", func() {
+			cidrs := []string{
+				// adjacent, but not the two halves of a supernet
+				"172.18.40.128/25",
+				"172.18.41.0/25",
+				// siblings of different lengths
+				"172.18.42.0/25",
+				"172.18.42.128/26",
+				// not adjacent
+				"172.18.43.1/32",
+				"172.18.43.3/32",
+			}
+			aggregated := spiderpoolip.AggregatePrefixes(parse(cidrs...))
+			Expect(toStrings(aggregated)).To(Equal(cidrs))
+		})
+
+		It("aggregates IPv4 and IPv6 prefixes separately", func() {
+			aggregated := spiderpoolip.AggregatePrefixes(parse(
+				"abcd:1234::80/121",
+				"172.18.40.1/32",
+				"abcd:1234::/121",
+				"172.18.40.0/32",
+				"0.0.0.0/1",
+				"128.0.0.0/1",
+			))
+			Expect(toStrings(aggregated)).To(Equal([]string{"0.0.0.0/0", "abcd:1234::/120"}))
+		})
+	})
 })
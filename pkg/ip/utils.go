@@ -7,6 +7,7 @@ import (
 	"net"
 	"strings"
 
+	"github.com/spidernet-io/spiderpool/pkg/constant"
 	"github.com/spidernet-io/spiderpool/pkg/types"
 )
 
@@ -24,6 +25,61 @@ func AssembleTotalIPs(ipVersion types.IPVersion, ipRanges, excludedIPRanges []st
 	return totalIPs, nil
 }
 
+// ImplicitExcludedIPs returns the IP addresses of the subnet which are never
+// allocated regardless of the excluded IP ranges: the gateways, the all-zeros
+// host address and, for IPv4, the broadcast address. The subnets without such
+// addresses, /31 and /32 of IPv4 and /127 and /128 of IPv6, only exclude the
+// gateways.
+func ImplicitExcludedIPs(ipVersion types.IPVersion, subnet string, gateways []string) ([]net.IP, error) {
+	ipNet, err := ParseCIDR(ipVersion, subnet)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	for _, gateway := range gateways {
+		if gateway == "" {
+			continue
+		}
+		gw, err := ParseIP(ipVersion, gateway, false)
+		if err != nil {
+			return nil, err
+		}
+		ips = append(ips, gw.IP)
+	}
+
+	ones, bits := ipNet.Mask.Size()
+	if bits-ones < 2 {
+		return ips, nil
+	}
+
+	ips = append(ips, ipNet.IP.To16())
+	if ipVersion == constant.IPv4 {
+		broadcast := make(net.IP, len(ipNet.IP))
+		for i := range ipNet.IP {
+			broadcast[i] = ipNet.IP[i] | ^ipNet.Mask[i]
+		}
+		ips = append(ips, broadcast.To16())
+	}
+
+	return ips, nil
+}
+
+// AssembleAllocatableIPs assembles the IP addresses to allocate, which are
+// the total IP addresses without the implicit excluded ones.
+func AssembleAllocatableIPs(ipVersion types.IPVersion, subnet string, gateways, ipRanges, excludedIPRanges []string) ([]net.IP, error) {
+	totalIPs, err := AssembleTotalIPs(ipVersion, ipRanges, excludedIPRanges)
+	if err != nil {
+		return nil, err
+	}
+	implicitIPs, err := ImplicitExcludedIPs(ipVersion, subnet, gateways)
+	if err != nil {
+		return nil, err
+	}
+
+	return IPsDiffSet(totalIPs, implicitIPs, false), nil
+}
+
 func CIDRToLabelValue(ipVersion types.IPVersion, subnet string) (string, error) {
 	if err := IsCIDR(ipVersion, subnet); err != nil {
 		return "", err
@@ -66,6 +66,77 @@ var _ = Describe("IP utils", Label("ip_utils_test"), func() {
 		})
 	})
 
+	Describe("Test ImplicitExcludedIPs", func() {
+		It("inputs invalid subnet", func() {
+			ips, err := spiderpoolip.ImplicitExcludedIPs(constant.IPv4, constant.InvalidCIDR, nil)
+			Expect(err).To(MatchError(spiderpoolip.ErrInvalidCIDRFormat))
+			Expect(ips).To(BeEmpty())
+		})
+
+		It("inputs invalid gateway", func() {
+			ips, err := spiderpoolip.ImplicitExcludedIPs(constant.IPv4, "172.18.40.0/24", []string{"abcd:1234::1"})
+			Expect(err).To(HaveOccurred())
+			Expect(ips).To(BeEmpty())
+		})
+
+		It("excludes the gateway, the network and the broadcast addresses of IPv4", func() {
+			ips, err := spiderpoolip.ImplicitExcludedIPs(constant.IPv4, "172.18.40.0/24", []string{"172.18.40.1"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ips).To(ConsistOf(
+				net.ParseIP("172.18.40.1"),
+				net.ParseIP("172.18.40.0"),
+				net.ParseIP("172.18.40.255"),
+			))
+		})
+
+		It("excludes the gateway and the all-zeros address of IPv6", func() {
+			ips, err := spiderpoolip.ImplicitExcludedIPs(constant.IPv6, "abcd:1234::/120", []string{"abcd:1234::1"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ips).To(ConsistOf(
+				net.ParseIP("abcd:1234::1"),
+				net.ParseIP("abcd:1234::"),
+			))
+		})
+
+		It("excludes all the gateways", func() {
+			ips, err := spiderpoolip.ImplicitExcludedIPs(constant.IPv4, "172.18.40.0/24", []string{"172.18.40.1", "172.18.40.254"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ips).To(ConsistOf(
+				net.ParseIP("172.18.40.1"),
+				net.ParseIP("172.18.40.254"),
+				net.ParseIP("172.18.40.0"),
+				net.ParseIP("172.18.40.255"),
+			))
+		})
+
+		It("only excludes the gateway of the point-to-point subnets", func() {
+			ips, err := spiderpoolip.ImplicitExcludedIPs(constant.IPv4, "172.18.40.0/31", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ips).To(BeEmpty())
+
+			ips, err = spiderpoolip.ImplicitExcludedIPs(constant.IPv6, "abcd:1234::/127", []string{"abcd:1234::1"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ips).To(Equal([]net.IP{net.ParseIP("abcd:1234::1")}))
+		})
+	})
+
+	Describe("Test AssembleAllocatableIPs", func() {
+		It("inputs invalid subnet", func() {
+			ips, err := spiderpoolip.AssembleAllocatableIPs(constant.IPv4, constant.InvalidCIDR, nil, []string{"172.18.40.1-172.18.40.2"}, nil)
+			Expect(err).To(MatchError(spiderpoolip.ErrInvalidCIDRFormat))
+			Expect(ips).To(BeEmpty())
+		})
+
+		It("excludes the implicit excluded IP addresses regardless of the excluded IP ranges", func() {
+			ips, err := spiderpoolip.AssembleAllocatableIPs(constant.IPv4, "172.18.40.0/30", []string{"172.18.40.1"},
+				[]string{"172.18.40.0-172.18.40.3", "172.18.40.10"},
+				[]string{"172.18.40.10"},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ips).To(Equal([]net.IP{net.ParseIP("172.18.40.2")}))
+		})
+	})
+
 	Describe("Test CIDRToLabelValue", func() {
 		When("Verifying", func() {
 			It("inputs invalid IP version", func() {
@@ -21,6 +21,7 @@ import (
 
 	"github.com/spidernet-io/spiderpool/api/v1/agent/models"
 	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/event"
 	"github.com/spidernet-io/spiderpool/pkg/ippoolmanager"
 	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
 	"github.com/spidernet-io/spiderpool/pkg/logutils"
@@ -280,6 +281,11 @@ func (i *ipam) genToBeAllocatedSet(ctx context.Context, addArgs *models.IpamAddA
 
 	// sort IPPool candidates
 	sortPoolCandidates(preliminary)
+	for _, t := range preliminary {
+		for _, c := range t.PoolCandidates {
+			logger.Sugar().Debugf("Sorted IPv%d IPPool candidates of NIC %s: %s", c.IPVersion, t.NIC, describePoolOrder(c))
+		}
+	}
 
 	return preliminary, nil
 }
@@ -353,6 +359,8 @@ func (i *ipam) allocateIPFromCandidate(ctx context.Context, c *PoolCandidate, ni
 				logger.Sugar().Infof("Reuse allocated IPv%d IP %s for NIC %s from IPPool %s", c.IPVersion, *oldRes.IP.Address, nic, ipPool.Name)
 				oldRes.Routes = convert.ConvertSpecRoutesToOAIRoutes(nic, ipPool.Spec.Routes)
 				oldRes.CleanGateway = cleanGateway
+				// keep the gateway the IP was allocated with, as the
+				// StatefulSet pods keep the recorded gateway
 				return oldRes, nil
 			}
 		}
@@ -369,6 +377,15 @@ func (i *ipam) allocateIPFromCandidate(ctx context.Context, c *PoolCandidate, ni
 		}
 
 		logger.Sugar().Infof("Allocate IPv%d IP %s to NIC %s from IPPool %s", c.IPVersion, *ip.Address, nic, pool)
+		if len(c.PToIPPool[pool].Spec.GatewayOverrides) != 0 {
+			gateway, err := i.gatewayOfPodNode(ctx, c.PToIPPool[pool], pod)
+			if err != nil {
+				return nil, err
+			}
+			logger.Sugar().Infof("Use gateway %s of Node %s for IPv%d IP %s", gateway, pod.Spec.NodeName, c.IPVersion, *ip.Address)
+			ip.Gateway = gateway
+		}
+
 		result = &types.AllocationResult{
 			IP:           ip,
 			Routes:       convert.ConvertSpecRoutesToOAIRoutes(nic, c.PToIPPool[pool].Spec.Routes),
@@ -379,12 +396,32 @@ func (i *ipam) allocateIPFromCandidate(ctx context.Context, c *PoolCandidate, ni
 	}
 
 	if len(errs) == len(c.Pools) {
+		event.EventRecorder.Eventf(pod, corev1.EventTypeWarning, "IPAllocationFailed",
+			"Failed to allocate IPv%d IP address to NIC %s from IPPools in order %s: %v", c.IPVersion, nic, describePoolOrder(c), utilerrors.NewAggregate(errs))
 		return nil, fmt.Errorf("failed to allocate any IPv%d IP address to NIC %s from IPPools %v: %w", c.IPVersion, nic, c.Pools, utilerrors.NewAggregate(errs))
 	}
 
 	return result, nil
 }
 
+// gatewayOfPodNode returns the gateway of the IPPool for the Node of the pod,
+// considering the gateway overrides of the IPPool.
+func (i *ipam) gatewayOfPodNode(ctx context.Context, ipPool *spiderpoolv2beta1.SpiderIPPool, pod *corev1.Pod) (string, error) {
+	if len(ipPool.Spec.GatewayOverrides) == 0 {
+		if ipPool.Spec.Gateway == nil {
+			return "", nil
+		}
+		return *ipPool.Spec.Gateway, nil
+	}
+
+	node, err := i.nodeManager.GetNodeByName(ctx, pod.Spec.NodeName, constant.UseCache)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Node %s for the gateway overrides of IPPool %s: %w", pod.Spec.NodeName, ipPool.Name, err)
+	}
+
+	return ippoolmanager.GatewayOfNode(ipPool, node)
+}
+
 func (i *ipam) precheckPoolCandidates(ctx context.Context, t *ToBeAllocated) error {
 	logger := logutils.FromContext(ctx)
 
@@ -418,26 +455,18 @@ func (i *ipam) precheckPoolCandidates(ctx context.Context, t *ToBeAllocated) err
 }
 
 func (i *ipam) filterPoolCandidates(ctx context.Context, t *ToBeAllocated, pod *corev1.Pod, podTopController types.PodTopController) error {
-	logger := logutils.FromContext(ctx)
-
 	for _, c := range t.PoolCandidates {
 		cp := make([]string, len(c.Pools))
 		copy(cp, c.Pools)
 
-		var errs []error
-		for j := 0; j < len(c.Pools); j++ {
-			pool := c.Pools[j]
-			if err := i.selectByPod(ctx, c.IPVersion, c.PToIPPool[pool], pod, podTopController, t.NIC); err != nil {
-				logger.Sugar().Warnf("IPPool %s is filtered by Pod: %v", pool, err)
-				errs = append(errs, err)
-
-				delete(c.PToIPPool, pool)
-				c.Pools = append((c.Pools)[:j], (c.Pools)[j+1:]...)
-				j--
-			}
-		}
-
+		filtered := i.filterPoolCandidate(ctx, c, t.NIC, pod, podTopController)
 		if len(c.Pools) == 0 {
+			var errs []error
+			for _, pool := range cp {
+				if err, ok := filtered[pool]; ok {
+					errs = append(errs, err)
+				}
+			}
 			return fmt.Errorf("%w, all IPv%d IPPools %v of %s filtered out: %v", constant.ErrNoAvailablePool, c.IPVersion, cp, t.NIC, utilerrors.NewAggregate(errs))
 		}
 	}
@@ -445,6 +474,27 @@ func (i *ipam) filterPoolCandidates(ctx context.Context, t *ToBeAllocated, pod *
 	return nil
 }
 
+// filterPoolCandidate removes the IPPools unmatched with the Pod from the
+// candidate, and returns the reasons by the names of the removed IPPools.
+func (i *ipam) filterPoolCandidate(ctx context.Context, c *PoolCandidate, nic string, pod *corev1.Pod, podTopController types.PodTopController) map[string]error {
+	logger := logutils.FromContext(ctx)
+
+	filtered := map[string]error{}
+	for j := 0; j < len(c.Pools); j++ {
+		pool := c.Pools[j]
+		if err := i.selectByPod(ctx, c.IPVersion, c.PToIPPool[pool], pod, podTopController, nic); err != nil {
+			logger.Sugar().Warnf("IPPool %s is filtered by Pod: %v", pool, err)
+			filtered[pool] = err
+
+			delete(c.PToIPPool, pool)
+			c.Pools = append((c.Pools)[:j], (c.Pools)[j+1:]...)
+			j--
+		}
+	}
+
+	return filtered
+}
+
 func (i *ipam) selectByPod(ctx context.Context, version types.IPVersion, ipPool *spiderpoolv2beta1.SpiderIPPool, pod *corev1.Pod, podTopController types.PodTopController, nic string) error {
 	if ipPool.DeletionTimestamp != nil {
 		return fmt.Errorf("terminating IPPool %s", ipPool.Name)
@@ -612,20 +662,29 @@ func (i *ipam) verifyPoolCandidates(tt ToBeAllocateds) error {
 	return nil
 }
 
-// sortPoolCandidates would sort IPPool candidates sequence depends on the IPPool multiple affinities.
+// sortPoolCandidates would sort IPPool candidates sequence depends on the IPPool
+// priority and multiple affinities, see ippoolmanager.ByPoolPriority. The ties of
+// the cluster default IPPools are sorted by free IP count and name, and the ties
+// of the other IPPools keep the order they are listed in.
 func sortPoolCandidates(preliminary ToBeAllocateds) {
 	for _, toBeAllocate := range preliminary {
 		for _, poolCandidate := range (*toBeAllocate).PoolCandidates {
 			// new IPPool candidate names
 			poolNameList := []string{}
 
-			// collect all IPPool resource from PoolCandidate.PToIPPool
+			// collect all IPPool resource from PoolCandidate.PToIPPool in the listed order
 			pools := []*spiderpoolv2beta1.SpiderIPPool{}
-			for _, tmpPool := range poolCandidate.PToIPPool {
-				pools = append(pools, tmpPool.DeepCopy())
+			for _, pool := range poolCandidate.Pools {
+				if tmpPool, ok := poolCandidate.PToIPPool[pool]; ok {
+					pools = append(pools, tmpPool.DeepCopy())
+				}
 			}
 			// make it order with ippoolmanager.ByPoolPriority interface rules
-			sort.Sort(ippoolmanager.ByPoolPriority(pools))
+			if poolCandidate.ClusterDefault {
+				sort.Sort(ippoolmanager.ByPoolPriorityAndFreeIPs(pools))
+			} else {
+				sort.Stable(ippoolmanager.ByPoolPriority(pools))
+			}
 			for _, tmpPool := range pools {
 				poolNameList = append(poolNameList, tmpPool.Name)
 			}
@@ -635,3 +694,19 @@ func sortPoolCandidates(preliminary ToBeAllocateds) {
 		}
 	}
 }
+
+// describePoolOrder renders the IPPools of the candidate in order, with the
+// properties they are sorted by.
+func describePoolOrder(c *PoolCandidate) string {
+	descs := make([]string, 0, len(c.Pools))
+	for _, pool := range c.Pools {
+		ipPool, ok := c.PToIPPool[pool]
+		if !ok {
+			descs = append(descs, pool)
+			continue
+		}
+		descs = append(descs, ippoolmanager.DescribePoolPriority(ipPool))
+	}
+
+	return "[" + strings.Join(descs, ", ") + "]"
+}
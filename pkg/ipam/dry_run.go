@@ -0,0 +1,214 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ipam
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/spidernet-io/spiderpool/api/v1/agent/models"
+	"github.com/spidernet-io/spiderpool/pkg/applicationcontroller/applicationinformers"
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/logutils"
+	"github.com/spidernet-io/spiderpool/pkg/types"
+)
+
+const dryRunPodName = "spiderpool-dry-run"
+
+// DryRun selects the IPPool candidates for the replicas of a Pod in the same
+// way as the IP allocation, and counts how many of the replicas could get IP
+// addresses from them. Nothing is created or updated. The allocatable of an
+// IPPool is the number of IP addresses it provides to the replicas, while the
+// allocatable of a candidate is the number of IP addresses its IPPools could
+// provide on their own. The IPPools auto-created from SpiderSubnet are not
+// supported, because they are created along with the application.
+func (i *ipam) DryRun(ctx context.Context, dryRunArgs *models.IpamDryRunArgs) (*models.IpamDryRunResponse, error) {
+	logger := logutils.FromContext(ctx)
+
+	if i.config.EnableSpiderSubnet {
+		subnetAnnoConfig, err := applicationinformers.GetSubnetAnnoConfig(dryRunArgs.PodAnnotations, logger)
+		if err != nil {
+			return nil, err
+		}
+		if !applicationinformers.IsDefaultIPPoolMode(subnetAnnoConfig) {
+			return nil, fmt.Errorf("%w, dry-run does not support the IPPools auto-created from SpiderSubnet", constant.ErrWrongInput)
+		}
+	}
+
+	pod := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       constant.KindPod,
+			APIVersion: corev1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        dryRunPodName,
+			Namespace:   *dryRunArgs.PodNamespace,
+			Annotations: dryRunArgs.PodAnnotations,
+			Labels:      dryRunArgs.PodLabels,
+		},
+		Spec: corev1.PodSpec{
+			NodeName: *dryRunArgs.NodeName,
+		},
+	}
+	podTopController := types.PodTopController{
+		AppNamespacedName: types.AppNamespacedName{
+			APIVersion: pod.APIVersion,
+			Kind:       pod.Kind,
+			Namespace:  pod.Namespace,
+			Name:       pod.Name,
+		},
+		APP: pod,
+	}
+	addArgs := &models.IpamAddArgs{
+		IfName:            dryRunArgs.IfName,
+		PodNamespace:      &pod.Namespace,
+		PodName:           &pod.Name,
+		DefaultIPV4IPPool: dryRunArgs.DefaultIPV4IPPool,
+		DefaultIPV6IPPool: dryRunArgs.DefaultIPV6IPPool,
+	}
+
+	preliminary, err := i.getPoolCandidates(ctx, addArgs, pod, podTopController)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.config.checkIPVersionEnable(ctx, preliminary); err != nil {
+		return nil, err
+	}
+	for _, t := range preliminary {
+		if err := i.precheckPoolCandidates(ctx, t); err != nil {
+			return nil, err
+		}
+	}
+
+	originalPools := map[*PoolCandidate][]string{}
+	filtered := map[*PoolCandidate]map[string]error{}
+	for _, t := range preliminary {
+		for _, c := range t.PoolCandidates {
+			originalPools[c] = append([]string{}, c.Pools...)
+			filtered[c] = i.filterPoolCandidate(ctx, c, t.NIC, pod, podTopController)
+		}
+	}
+	if err := i.verifyPoolCandidates(preliminary); err != nil {
+		return nil, err
+	}
+	sortPoolCandidates(preliminary)
+
+	available := map[string]int{}
+	for _, c := range preliminary.Candidates() {
+		for _, pool := range c.Pools {
+			if _, ok := available[pool]; ok {
+				continue
+			}
+			count, err := i.ipPoolManager.AvailableIPCount(ctx, c.PToIPPool[pool])
+			if err != nil {
+				return nil, fmt.Errorf("failed to count the available IP addresses of IPPool %s: %w", pool, err)
+			}
+			available[pool] = count
+		}
+	}
+
+	replicas := int(*dryRunArgs.Replicas)
+	allocatable, allocations := simulateAllocation(preliminary.Candidates(), available, replicas)
+	logger.Sugar().Infof("%d of %d replicas could be allocated from IPPool candidates: %s", allocatable, replicas, preliminary)
+
+	resp := &models.IpamDryRunResponse{
+		Replicas:    dryRunArgs.Replicas,
+		Allocatable: pointer.Int64(int64(allocatable)),
+		DualStack:   i.config.EnableIPv4 && i.config.EnableIPv6,
+		Nics:        []*models.DryRunInterface{},
+	}
+	resp.DualStackSatisfied = resp.DualStack && allocatable == replicas
+	for _, t := range preliminary {
+		nic := &models.DryRunInterface{Nic: pointer.String(t.NIC)}
+		versions := map[types.IPVersion]bool{}
+		for _, c := range t.PoolCandidates {
+			if len(c.Pools) != 0 {
+				versions[c.IPVersion] = true
+			}
+
+			candidate := &models.DryRunCandidate{Version: pointer.Int64(c.IPVersion)}
+			var total int
+			for _, pool := range c.Pools {
+				total += available[pool]
+				candidate.Pools = append(candidate.Pools, &models.DryRunPool{
+					Name:             pointer.String(pool),
+					AvailableIPCount: int64(available[pool]),
+					Allocatable:      pointer.Int64(int64(allocations[c][pool])),
+				})
+			}
+			if total > replicas {
+				total = replicas
+			}
+			candidate.Allocatable = pointer.Int64(int64(total))
+
+			for _, pool := range originalPools[c] {
+				if err, ok := filtered[c][pool]; ok {
+					candidate.Pools = append(candidate.Pools, &models.DryRunPool{
+						Name:           pointer.String(pool),
+						Allocatable:    pointer.Int64(0),
+						FilteredReason: err.Error(),
+					})
+				}
+			}
+			nic.Candidates = append(nic.Candidates, candidate)
+		}
+		if !versions[constant.IPv4] || !versions[constant.IPv6] {
+			resp.DualStackSatisfied = false
+		}
+		resp.Nics = append(resp.Nics, nic)
+	}
+
+	return resp, nil
+}
+
+// simulateAllocation allocates an IP address from every candidate to each of
+// the replicas in turn, taking the IPPools of a candidate in order just like
+// the IP allocation does, until any candidate runs out of IP addresses. It
+// returns the number of replicas allocated and the IP addresses taken from
+// each IPPool by each candidate.
+func simulateAllocation(candidates []*PoolCandidate, available map[string]int, replicas int) (int, map[*PoolCandidate]map[string]int) {
+	remaining := map[string]int{}
+	for pool, count := range available {
+		remaining[pool] = count
+	}
+	allocations := map[*PoolCandidate]map[string]int{}
+	for _, c := range candidates {
+		allocations[c] = map[string]int{}
+	}
+
+	var allocated int
+	for ; allocated < replicas; allocated++ {
+		var picks []string
+		for _, c := range candidates {
+			pick := ""
+			for _, pool := range c.Pools {
+				if remaining[pool] > 0 {
+					remaining[pool]--
+					pick = pool
+					break
+				}
+			}
+			if pick == "" {
+				break
+			}
+			picks = append(picks, pick)
+		}
+
+		if len(picks) != len(candidates) {
+			for _, pool := range picks {
+				remaining[pool]++
+			}
+			break
+		}
+		for j, c := range candidates {
+			allocations[c][picks[j]]++
+		}
+	}
+
+	return allocated, allocations
+}
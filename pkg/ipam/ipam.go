@@ -24,6 +24,7 @@ import (
 type IPAM interface {
 	Allocate(ctx context.Context, addArgs *models.IpamAddArgs) (*models.IpamAddResponse, error)
 	Release(ctx context.Context, delArgs *models.IpamDelArgs) error
+	DryRun(ctx context.Context, dryRunArgs *models.IpamDryRunArgs) (*models.IpamDryRunResponse, error)
 	Start(ctx context.Context) error
 }
 
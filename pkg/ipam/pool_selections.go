@@ -20,6 +20,7 @@ import (
 	"github.com/spidernet-io/spiderpool/api/v1/agent/models"
 	"github.com/spidernet-io/spiderpool/pkg/applicationcontroller/applicationinformers"
 	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/ippoolmanager"
 	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
 	"github.com/spidernet-io/spiderpool/pkg/logutils"
 	"github.com/spidernet-io/spiderpool/pkg/namespacemanager"
@@ -284,7 +285,8 @@ func (i *ipam) applyThirdControllerAutoPool(ctx context.Context, subnetName stri
 		} else {
 			for k := range poolList.Items {
 				labels := poolList.Items[k].GetLabels()
-				if labels[constant.LabelIPPoolReclaimIPPool] == constant.True && labels[constant.LabelIPPoolOwnerApplicationUID] != string(podController.UID) {
+				if labels[constant.LabelIPPoolReclaimIPPool] == constant.True && labels[constant.LabelIPPoolOwnerApplicationUID] != string(podController.UID) &&
+					!ippoolmanager.IsOrphanedAutoIPPool(&poolList.Items[k]) {
 					log.Sugar().Debugf("found the previous same app auto-created IPPool %s", poolList.Items[k].Name)
 					continue
 				}
@@ -499,16 +501,18 @@ func (i *ipam) getClusterDefaultPools(ctx context.Context, nic string, cleanGate
 
 	if len(v4Pools) != 0 {
 		t.PoolCandidates = append(t.PoolCandidates, &PoolCandidate{
-			IPVersion: constant.IPv4,
-			Pools:     v4Pools,
-			PToIPPool: v4PToIPPool,
+			IPVersion:      constant.IPv4,
+			Pools:          v4Pools,
+			PToIPPool:      v4PToIPPool,
+			ClusterDefault: true,
 		})
 	}
 	if len(v6Pools) != 0 {
 		t.PoolCandidates = append(t.PoolCandidates, &PoolCandidate{
-			IPVersion: constant.IPv6,
-			Pools:     v6Pools,
-			PToIPPool: v6PToIPPool,
+			IPVersion:      constant.IPv6,
+			Pools:          v6Pools,
+			PToIPPool:      v6PToIPPool,
+			ClusterDefault: true,
 		})
 	}
 
@@ -53,6 +53,9 @@ type PoolCandidate struct {
 	IPVersion types.IPVersion
 	Pools     []string
 	PToIPPool PoolNameToIPPool
+	// ClusterDefault is true if the IPPools are the cluster default ones,
+	// which are not listed in order by the user.
+	ClusterDefault bool
 }
 
 func (c *PoolCandidate) String() string {
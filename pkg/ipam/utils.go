@@ -18,6 +18,7 @@ import (
 	subnetmanagercontrollers "github.com/spidernet-io/spiderpool/pkg/applicationcontroller/applicationinformers"
 	"github.com/spidernet-io/spiderpool/pkg/constant"
 	spiderpoolip "github.com/spidernet-io/spiderpool/pkg/ip"
+	"github.com/spidernet-io/spiderpool/pkg/ippoolmanager"
 	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
 	"github.com/spidernet-io/spiderpool/pkg/logutils"
 	"github.com/spidernet-io/spiderpool/pkg/types"
@@ -37,9 +38,9 @@ func getCustomRoutes(pod *corev1.Pod) ([]*models.Route, error) {
 		return nil, fmt.Errorf("%w: %v", errPrefix, err)
 	}
 
-	for _, route := range annoPodRoutes {
+	for idx, route := range annoPodRoutes {
 		if err := spiderpoolip.IsRouteWithoutIPVersion(route.Dst, route.Gw); err != nil {
-			return nil, fmt.Errorf("%w: %v", errPrefix, err)
+			return nil, fmt.Errorf("%w: routes[%d]: %v", errPrefix, idx, err)
 		}
 	}
 
@@ -51,32 +52,63 @@ func groupCustomRoutes(ctx context.Context, customRoutes []*models.Route, result
 		return nil
 	}
 
-	for _, res := range results {
-		_, ipNet, err := net.ParseCIDR(*res.IP.Address)
+	var ungrouped []*models.Route
+	for idx, route := range customRoutes {
+		res, err := matchCustomRoute(route, results)
 		if err != nil {
-			return err
+			return fmt.Errorf("%w, invalid Pod annotation '%s': routes[%d]: %v", constant.ErrWrongInput, constant.AnnoPodRoutes, idx, err)
 		}
-
-		for i := 0; i < len(customRoutes); i++ {
-			route := customRoutes[i]
-			if ipNet.Contains(net.ParseIP(*route.Gw)) {
-				route.IfName = res.IP.Nic
-				res.Routes = append(res.Routes, route)
-
-				customRoutes = append((customRoutes)[:i], (customRoutes)[i+1:]...)
-				i--
-			}
+		if res == nil {
+			ungrouped = append(ungrouped, route)
+			continue
 		}
+
+		route.IfName = res.IP.Nic
+		res.Routes = append(res.Routes, route)
 	}
 
-	if len(customRoutes) != 0 {
+	if len(ungrouped) != 0 {
 		logger := logutils.FromContext(ctx)
-		logger.Sugar().Warnf("Invalid custom routes: %+v", customRoutes)
+		logger.Sugar().Warnf("Invalid custom routes: %+v", ungrouped)
 	}
 
 	return nil
 }
 
+// matchCustomRoute returns the IP allocation result whose subnet contains the
+// gateway of the custom route. If the interface of the route is specified,
+// only the results of the interface are matched, and it is an error if none
+// of them contains the gateway. nil is returned if the route doesn't belong to
+// the interfaces allocated this time.
+func matchCustomRoute(route *models.Route, results []*types.AllocationResult) (*types.AllocationResult, error) {
+	gw := net.ParseIP(*route.Gw)
+	iface := ""
+	if route.IfName != nil {
+		iface = *route.IfName
+	}
+
+	attached := false
+	for _, res := range results {
+		if iface != "" && *res.IP.Nic != iface {
+			continue
+		}
+		attached = true
+
+		_, ipNet, err := net.ParseCIDR(*res.IP.Address)
+		if err != nil {
+			return nil, err
+		}
+		if ipNet.Contains(gw) {
+			return res, nil
+		}
+	}
+
+	if iface != "" && attached {
+		return nil, fmt.Errorf("gateway %s is not in the subnets of interface %s", *route.Gw, iface)
+	}
+	return nil, nil
+}
+
 // getAutoPoolIPNumber calculates the auto-created IPPool IP number with the given params pod and pod top controller.
 // If it's an orphan pod, it will return 1.
 func getAutoPoolIPNumber(pod *corev1.Pod, podController types.PodTopController) (int, error) {
@@ -150,7 +182,7 @@ func getAutoPoolIPNumber(pod *corev1.Pod, podController types.PodTopController)
 
 // isPoolIPsDesired checks the auto-created IPPool's IPs whether matches its AutoDesiredIPCount
 func isPoolIPsDesired(pool *spiderpoolv2beta1.SpiderIPPool, desiredIPCount int) bool {
-	totalIPs, err := spiderpoolip.AssembleTotalIPs(*pool.Spec.IPVersion, pool.Spec.IPs, pool.Spec.ExcludeIPs)
+	totalIPs, err := spiderpoolip.AssembleAllocatableIPs(*pool.Spec.IPVersion, pool.Spec.Subnet, ippoolmanager.PoolGateways(pool), pool.Spec.IPs, pool.Spec.ExcludeIPs)
 	if nil != err {
 		return false
 	}
@@ -3,12 +3,18 @@
 
 package ippoolmanager
 
+import "time"
+
 const (
 	defaultMaxAllocatedIPs = 5000
 )
 
 type IPPoolManagerConfig struct {
 	MaxAllocatedIPs *int
+	// IPReuseCooldown is the quarantine window of the released IP addresses,
+	// which are not reused within the window unless the IPPool has no other
+	// free IP address. 0 means the released IP addresses are reused at once.
+	IPReuseCooldown time.Duration
 }
 
 func setDefaultsForIPPoolManagerConfig(config IPPoolManagerConfig) IPPoolManagerConfig {
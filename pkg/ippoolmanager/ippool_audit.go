@@ -0,0 +1,171 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ippoolmanager
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	spiderpoolip "github.com/spidernet-io/spiderpool/pkg/ip"
+	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
+	"github.com/spidernet-io/spiderpool/pkg/utils/convert"
+	"github.com/spidernet-io/spiderpool/pkg/workloadendpointmanager"
+)
+
+// the types of the anomalies found by AuditIPPools
+const (
+	// AnomalyOrphanIP is an allocated IP address without the SpiderEndpoint
+	// of the same Pod UID holding it.
+	AnomalyOrphanIP = "OrphanIP"
+	// AnomalyUnallocatedIP is an IP address held by the SpiderEndpoint but
+	// not allocated to the Pod in the IPPool.
+	AnomalyUnallocatedIP = "UnallocatedIP"
+	// AnomalyGatewayOutOfSubnet is the gateway not in the subnet of the IPPool.
+	AnomalyGatewayOutOfSubnet = "GatewayOutOfSubnet"
+)
+
+// IPPoolAnomaly is an anomaly of the IPPool, IP, Pod and PodUID are set for
+// the anomalies of an IP address.
+type IPPoolAnomaly struct {
+	Type   string `json:"type"`
+	IP     string `json:"ip,omitempty"`
+	Pod    string `json:"pod,omitempty"`
+	PodUID string `json:"podUID,omitempty"`
+}
+
+// IPPoolReport is the statistics and the anomalies of an IPPool.
+type IPPoolReport struct {
+	Name             string          `json:"name"`
+	IPVersion        int64           `json:"ipVersion"`
+	Subnet           string          `json:"subnet"`
+	Gateway          string          `json:"gateway,omitempty"`
+	OwnerSubnet      string          `json:"ownerSubnet,omitempty"`
+	Disabled         bool            `json:"disabled"`
+	TotalIPCount     int64           `json:"totalIPCount"`
+	AllocatedIPCount int64           `json:"allocatedIPCount"`
+	FreeIPCount      int64           `json:"freeIPCount"`
+	UsedPercentage   float64         `json:"usedPercentage"`
+	Anomalies        []IPPoolAnomaly `json:"anomalies,omitempty"`
+}
+
+// Healthy reports whether no anomaly is found in the IPPool.
+func (r *IPPoolReport) Healthy() bool {
+	return len(r.Anomalies) == 0
+}
+
+// AuditIPPools counts the IP addresses of the IPPools, and cross-references
+// their IP allocation records with the SpiderEndpoints. An allocated IP
+// address is an orphan if no SpiderEndpoint of the same Pod UID holds it, and
+// an IP address of the SpiderEndpoints is unallocated if the IPPool doesn't
+// record it for the Pod. The IP addresses being allocated may be reported as
+// orphans, as the SpiderEndpoint is updated after the IPPool.
+func AuditIPPools(pools []spiderpoolv2beta1.SpiderIPPool, endpoints []spiderpoolv2beta1.SpiderEndpoint) ([]IPPoolReport, error) {
+	endpointMap := map[string]*spiderpoolv2beta1.SpiderEndpoint{}
+	for i := range endpoints {
+		endpointMap[endpoints[i].Namespace+"/"+endpoints[i].Name] = &endpoints[i]
+	}
+
+	reports := make([]IPPoolReport, 0, len(pools))
+	poolRecords := map[string]spiderpoolv2beta1.PoolIPAllocations{}
+	reportIndex := map[string]int{}
+	for i := range pools {
+		pool := &pools[i]
+		report, records, err := auditIPPool(pool, endpointMap)
+		if err != nil {
+			return nil, fmt.Errorf("failed to audit IPPool %s: %w", pool.Name, err)
+		}
+		poolRecords[pool.Name] = records
+		reportIndex[pool.Name] = len(reports)
+		reports = append(reports, *report)
+	}
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		for _, endpointIP := range workloadendpointmanager.ListEndpointIPs(endpoint) {
+			records, ok := poolRecords[endpointIP.Pool]
+			if !ok {
+				continue
+			}
+			if record, ok := records[endpointIP.IP]; ok && record.PodUID == endpoint.Status.Current.UID {
+				continue
+			}
+
+			report := &reports[reportIndex[endpointIP.Pool]]
+			report.Anomalies = append(report.Anomalies, IPPoolAnomaly{
+				Type:   AnomalyUnallocatedIP,
+				IP:     endpointIP.IP,
+				Pod:    endpoint.Namespace + "/" + endpoint.Name,
+				PodUID: endpoint.Status.Current.UID,
+			})
+		}
+	}
+
+	return reports, nil
+}
+
+func auditIPPool(pool *spiderpoolv2beta1.SpiderIPPool, endpoints map[string]*spiderpoolv2beta1.SpiderEndpoint) (*IPPoolReport, spiderpoolv2beta1.PoolIPAllocations, error) {
+	if pool.Spec.IPVersion == nil {
+		return nil, nil, fmt.Errorf("%w, the IP version is not set", constant.ErrWrongInput)
+	}
+
+	report := &IPPoolReport{
+		Name:        pool.Name,
+		IPVersion:   *pool.Spec.IPVersion,
+		Subnet:      pool.Spec.Subnet,
+		OwnerSubnet: pool.Labels[constant.LabelIPPoolOwnerSpiderSubnet],
+		Disabled:    pool.Spec.Disable != nil && *pool.Spec.Disable,
+	}
+
+	totalIPs, err := spiderpoolip.AssembleAllocatableIPs(*pool.Spec.IPVersion, pool.Spec.Subnet, PoolGateways(pool), pool.Spec.IPs, pool.Spec.ExcludeIPs)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, err := convert.UnmarshalIPPoolAllocatedIPs(pool.Status.AllocatedIPs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report.TotalIPCount = int64(len(totalIPs))
+	report.AllocatedIPCount = int64(len(records))
+	if report.TotalIPCount > report.AllocatedIPCount {
+		report.FreeIPCount = report.TotalIPCount - report.AllocatedIPCount
+	}
+	if report.TotalIPCount != 0 {
+		report.UsedPercentage = float64(report.AllocatedIPCount) * 100 / float64(report.TotalIPCount)
+	}
+
+	if pool.Spec.Gateway != nil {
+		report.Gateway = *pool.Spec.Gateway
+		contains, err := spiderpoolip.ContainsIP(*pool.Spec.IPVersion, pool.Spec.Subnet, *pool.Spec.Gateway)
+		if err != nil || !contains {
+			report.Anomalies = append(report.Anomalies, IPPoolAnomaly{
+				Type: AnomalyGatewayOutOfSubnet,
+				IP:   *pool.Spec.Gateway,
+			})
+		}
+	}
+
+	ips := make([]string, 0, len(records))
+	for ip := range records {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	for _, ip := range ips {
+		record := records[ip]
+		endpoint, ok := endpoints[record.NamespacedName]
+		if ok && endpoint.Status.Current.UID == record.PodUID && workloadendpointmanager.HasEndpointIP(endpoint, ip) {
+			continue
+		}
+
+		report.Anomalies = append(report.Anomalies, IPPoolAnomaly{
+			Type:   AnomalyOrphanIP,
+			IP:     ip,
+			Pod:    record.NamespacedName,
+			PodUID: record.PodUID,
+		})
+	}
+
+	return report, records, nil
+}
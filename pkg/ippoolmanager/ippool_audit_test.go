@@ -0,0 +1,104 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ippoolmanager_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/ippoolmanager"
+	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
+	"github.com/spidernet-io/spiderpool/pkg/utils/convert"
+)
+
+var _ = Describe("AuditIPPools", Label("ippool_audit_test"), func() {
+	var poolT spiderpoolv2beta1.SpiderIPPool
+	var endpointT spiderpoolv2beta1.SpiderEndpoint
+
+	BeforeEach(func() {
+		poolT = spiderpoolv2beta1.SpiderIPPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "pool",
+				Labels: map[string]string{constant.LabelIPPoolOwnerSpiderSubnet: "subnet"},
+			},
+			Spec: spiderpoolv2beta1.IPPoolSpec{
+				IPVersion: pointer.Int64(constant.IPv4),
+				Subnet:    "172.18.40.0/24",
+				IPs:       []string{"172.18.40.10-172.18.40.13"},
+				Gateway:   pointer.String("172.18.40.1"),
+				Disable:   pointer.Bool(false),
+			},
+		}
+
+		endpointT = spiderpoolv2beta1.SpiderEndpoint{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pod",
+				Namespace: "default",
+			},
+			Status: spiderpoolv2beta1.WorkloadEndpointStatus{
+				Current: spiderpoolv2beta1.PodIPAllocation{
+					UID: "uid",
+					IPs: []spiderpoolv2beta1.IPAllocationDetail{{
+						NIC:      "eth0",
+						IPv4:     pointer.String("172.18.40.10/24"),
+						IPv4Pool: pointer.String("pool"),
+					}},
+				},
+			},
+		}
+	})
+
+	setAllocatedIPs := func(records spiderpoolv2beta1.PoolIPAllocations) {
+		data, err := convert.MarshalIPPoolAllocatedIPs(records)
+		Expect(err).NotTo(HaveOccurred())
+		poolT.Status.AllocatedIPs = data
+	}
+
+	It("counts the IP addresses of the healthy IPPool", func() {
+		setAllocatedIPs(spiderpoolv2beta1.PoolIPAllocations{
+			"172.18.40.10": {NIC: "eth0", NamespacedName: "default/pod", PodUID: "uid"},
+		})
+
+		reports, err := ippoolmanager.AuditIPPools([]spiderpoolv2beta1.SpiderIPPool{poolT}, []spiderpoolv2beta1.SpiderEndpoint{endpointT})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reports).To(HaveLen(1))
+		Expect(reports[0].Healthy()).To(BeTrue())
+		Expect(reports[0].OwnerSubnet).To(Equal("subnet"))
+		Expect(reports[0].TotalIPCount).To(BeEquivalentTo(4))
+		Expect(reports[0].AllocatedIPCount).To(BeEquivalentTo(1))
+		Expect(reports[0].FreeIPCount).To(BeEquivalentTo(3))
+		Expect(reports[0].UsedPercentage).To(BeNumerically("==", 25))
+	})
+
+	It("reports the orphan IP addresses, the unallocated IP addresses and the gateway out of subnet", func() {
+		poolT.Spec.Gateway = pointer.String("172.18.41.1")
+		setAllocatedIPs(spiderpoolv2beta1.PoolIPAllocations{
+			// the Pod is recreated with another UID
+			"172.18.40.10": {NIC: "eth0", NamespacedName: "default/pod", PodUID: "old-uid"},
+			// no Endpoint
+			"172.18.40.11": {NIC: "eth0", NamespacedName: "default/gone", PodUID: "gone-uid"},
+		})
+
+		reports, err := ippoolmanager.AuditIPPools([]spiderpoolv2beta1.SpiderIPPool{poolT}, []spiderpoolv2beta1.SpiderEndpoint{endpointT})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reports).To(HaveLen(1))
+		Expect(reports[0].Healthy()).To(BeFalse())
+		Expect(reports[0].Anomalies).To(ConsistOf(
+			ippoolmanager.IPPoolAnomaly{Type: ippoolmanager.AnomalyGatewayOutOfSubnet, IP: "172.18.41.1"},
+			ippoolmanager.IPPoolAnomaly{Type: ippoolmanager.AnomalyOrphanIP, IP: "172.18.40.10", Pod: "default/pod", PodUID: "old-uid"},
+			ippoolmanager.IPPoolAnomaly{Type: ippoolmanager.AnomalyOrphanIP, IP: "172.18.40.11", Pod: "default/gone", PodUID: "gone-uid"},
+			ippoolmanager.IPPoolAnomaly{Type: ippoolmanager.AnomalyUnallocatedIP, IP: "172.18.40.10", Pod: "default/pod", PodUID: "uid"},
+		))
+	})
+
+	It("fails to audit the IPPool with the invalid IP allocation records", func() {
+		poolT.Status.AllocatedIPs = pointer.String("invalid")
+
+		_, err := ippoolmanager.AuditIPPools([]spiderpoolv2beta1.SpiderIPPool{poolT}, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
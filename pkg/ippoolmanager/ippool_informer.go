@@ -7,12 +7,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	otelapi "go.opentelemetry.io/otel/metric"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
@@ -25,15 +30,18 @@ import (
 	"github.com/spidernet-io/spiderpool/pkg/applicationcontroller/applicationinformers"
 	"github.com/spidernet-io/spiderpool/pkg/constant"
 	"github.com/spidernet-io/spiderpool/pkg/election"
+	"github.com/spidernet-io/spiderpool/pkg/event"
 	spiderpoolip "github.com/spidernet-io/spiderpool/pkg/ip"
 	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
 	crdclientset "github.com/spidernet-io/spiderpool/pkg/k8s/client/clientset/versioned"
 	"github.com/spidernet-io/spiderpool/pkg/k8s/client/informers/externalversions"
 	informers "github.com/spidernet-io/spiderpool/pkg/k8s/client/informers/externalversions/spiderpool.spidernet.io/v2beta1"
 	listers "github.com/spidernet-io/spiderpool/pkg/k8s/client/listers/spiderpool.spidernet.io/v2beta1"
+	"github.com/spidernet-io/spiderpool/pkg/lock"
 	"github.com/spidernet-io/spiderpool/pkg/logutils"
 	"github.com/spidernet-io/spiderpool/pkg/metric"
 	"github.com/spidernet-io/spiderpool/pkg/types"
+	"github.com/spidernet-io/spiderpool/pkg/utils/convert"
 )
 
 var informerLogger *zap.Logger
@@ -45,6 +53,11 @@ type IPPoolController struct {
 	poolLister    listers.SpiderIPPoolLister
 	poolSynced    cache.InformerSynced
 	poolWorkqueue workqueue.RateLimitingInterface
+
+	// subnetExpansions records the previous subnet of the IPPools whose
+	// subnet is expanded, the event is emitted by the worker of the leader
+	subnetExpansionsLock lock.Mutex
+	subnetExpansions     map[string]string
 }
 
 type IPPoolControllerConfig struct {
@@ -55,6 +68,9 @@ type IPPoolControllerConfig struct {
 	LeaderRetryElectGap           time.Duration
 	WorkQueueRequeueDelayDuration time.Duration
 	ResyncPeriod                  time.Duration
+	// AutoIPPoolRetentionPeriod is how long an orphaned auto-created IPPool
+	// is kept before it's deleted
+	AutoIPPoolRetentionPeriod time.Duration
 }
 
 func NewIPPoolController(poolControllerConfig IPPoolControllerConfig, client client.Client, dynamicClient dynamic.Interface) *IPPoolController {
@@ -100,6 +116,8 @@ func (ic *IPPoolController) SetupInformer(ctx context.Context, client crdclients
 					if !controllerLeader.IsElected() {
 						informerLogger.Warn("Leader lost, stop IPPool informer")
 						innerCancel()
+						// only the leader exports the capacity of the IPPools
+						metric.ResetIPPoolCapacity()
 						return
 					}
 					time.Sleep(ic.LeaderRetryElectGap)
@@ -107,6 +125,8 @@ func (ic *IPPoolController) SetupInformer(ctx context.Context, client crdclients
 			}()
 
 			informerLogger.Info("create SpiderIPPool informer")
+			// the IPPools deleted while not being the leader are never seen
+			metric.ResetIPPoolCapacity()
 			factory := externalversions.NewSharedInformerFactory(client, ic.ResyncPeriod)
 			err := ic.addEventHandlers(factory.Spiderpool().V2beta1().SpiderIPPools())
 			if nil != err {
@@ -118,6 +138,8 @@ func (ic *IPPoolController) SetupInformer(ctx context.Context, client crdclients
 			if err := ic.Run(innerCtx.Done()); nil != err {
 				informerLogger.Sugar().Errorf("failed to run ippool controller, error: %v", err)
 			}
+			// drop the capacity recorded by the in-flight workers after leader lost
+			metric.ResetIPPoolCapacity()
 			informerLogger.Error("SpiderIPPool informer broken")
 		}
 	}()
@@ -133,12 +155,22 @@ func (ic *IPPoolController) addEventHandlers(poolInformer informers.SpiderIPPool
 
 	// for all IPPool processing
 	_, err := poolInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: ic.enqueueIPPool,
+		AddFunc: func(obj interface{}) {
+			recordIPPoolCapacity(obj.(*spiderpoolv2beta1.SpiderIPPool))
+			ic.enqueueIPPool(obj)
+		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
-			ic.enqueueIPPool(newObj)
+			recordIPPoolCapacity(newObj.(*spiderpoolv2beta1.SpiderIPPool))
+			ic.enqueueIPPoolOnUpdate(oldObj, newObj)
 		},
 		DeleteFunc: func(obj interface{}) {
-
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pool, ok := obj.(*spiderpoolv2beta1.SpiderIPPool); ok {
+				metric.DeleteIPPoolCapacity(pool.Name)
+				ic.popSubnetExpansion(pool.Name)
+			}
 		},
 	})
 	if nil != err {
@@ -148,6 +180,24 @@ func (ic *IPPoolController) addEventHandlers(poolInformer informers.SpiderIPPool
 	return nil
 }
 
+// recordIPPoolCapacity records the capacity metrics of the IPPool in the
+// informer cache, once its status is calculated.
+func recordIPPoolCapacity(pool *spiderpoolv2beta1.SpiderIPPool) {
+	if pool.Status.TotalIPCount == nil {
+		return
+	}
+
+	family := constant.LabelValueIPVersionV4
+	if pool.Spec.IPVersion != nil && *pool.Spec.IPVersion == constant.IPv6 {
+		family = constant.LabelValueIPVersionV6
+	}
+	var allocated int64
+	if pool.Status.AllocatedIPCount != nil {
+		allocated = *pool.Status.AllocatedIPCount
+	}
+	metric.RecordIPPoolCapacity(pool.Name, family, pool.Labels[constant.LabelIPPoolOwnerSpiderSubnet], *pool.Status.TotalIPCount, allocated)
+}
+
 // enqueueIPPool will check the given pool and enqueue them into different workqueue
 func (ic *IPPoolController) enqueueIPPool(obj interface{}) {
 	pool := obj.(*spiderpoolv2beta1.SpiderIPPool)
@@ -161,6 +211,43 @@ func (ic *IPPoolController) enqueueIPPool(obj interface{}) {
 	informerLogger.Sugar().Debugf("added '%s' to IPPool workqueue", pool.Name)
 }
 
+func (ic *IPPoolController) enqueueIPPoolOnUpdate(oldObj, newObj interface{}) {
+	oldPool := oldObj.(*spiderpoolv2beta1.SpiderIPPool)
+	newPool := newObj.(*spiderpoolv2beta1.SpiderIPPool)
+
+	// the webhook only allows the subnet to be expanded
+	if newPool.Spec.Subnet != oldPool.Spec.Subnet {
+		ic.recordSubnetExpansion(newPool.Name, oldPool.Spec.Subnet)
+	}
+
+	ic.enqueueIPPool(newObj)
+}
+
+// recordSubnetExpansion records the subnet before the first of the successive
+// expansions of the IPPool, until the worker emits the event.
+func (ic *IPPoolController) recordSubnetExpansion(poolName, oldSubnet string) {
+	ic.subnetExpansionsLock.Lock()
+	defer ic.subnetExpansionsLock.Unlock()
+
+	if ic.subnetExpansions == nil {
+		ic.subnetExpansions = map[string]string{}
+	}
+	if _, ok := ic.subnetExpansions[poolName]; !ok {
+		ic.subnetExpansions[poolName] = oldSubnet
+	}
+}
+
+// popSubnetExpansion returns and forgets the subnet of the IPPool before the
+// expansion, if any.
+func (ic *IPPoolController) popSubnetExpansion(poolName string) (string, bool) {
+	ic.subnetExpansionsLock.Lock()
+	defer ic.subnetExpansionsLock.Unlock()
+
+	oldSubnet, ok := ic.subnetExpansions[poolName]
+	delete(ic.subnetExpansions, poolName)
+	return oldSubnet, ok
+}
+
 // Run will set up the event handlers for IPPool, as well
 // as syncing informer caches and starting workers. It will block until stopCh
 // is closed, at which point it will shutdown the workqueue and wait for
@@ -316,16 +403,39 @@ func (ic *IPPoolController) syncHandler(ctx context.Context, pool *spiderpoolv2b
 		informerLogger.Sugar().Infof("initial SpiderIPPool '%s' status AllocatedIPCount to 0", pool.Name)
 	}
 
-	totalIPs, err := spiderpoolip.AssembleTotalIPs(*pool.Spec.IPVersion, pool.Spec.IPs, pool.Spec.ExcludeIPs)
+	totalIPs, err := spiderpoolip.AssembleAllocatableIPs(*pool.Spec.IPVersion, pool.Spec.Subnet, PoolGateways(pool), pool.Spec.IPs, pool.Spec.ExcludeIPs)
 	if nil != err {
 		return fmt.Errorf("%w: failed to calculate SpiderIPPool '%s' total IP count, error: %v", constant.ErrWrongInput, pool.Name, err)
 	}
 
 	if pool.Status.TotalIPCount == nil || *pool.Status.TotalIPCount != int64(len(totalIPs)) {
+		if pool.Status.TotalIPCount != nil && *pool.Status.TotalIPCount < int64(len(totalIPs)) {
+			event.EventRecorder.Eventf(
+				pool,
+				corev1.EventTypeNormal,
+				"IPPoolExpanded",
+				"Total IP count expanded from %d to %d", *pool.Status.TotalIPCount, len(totalIPs),
+			)
+		}
 		needUpdate = true
 		pool.Status.TotalIPCount = pointer.Int64(int64(len(totalIPs)))
 	}
 
+	if oldSubnet, ok := ic.popSubnetExpansion(pool.Name); ok && oldSubnet != pool.Spec.Subnet {
+		event.EventRecorder.Eventf(
+			pool,
+			corev1.EventTypeNormal,
+			"SubnetExpanded",
+			"Subnet expanded from %s to %s", oldSubnet, pool.Spec.Subnet,
+		)
+	}
+
+	updated, err := setImplicitExcludedIPCondition(pool)
+	if nil != err {
+		return fmt.Errorf("failed to check SpiderIPPool '%s' allocated implicit excluded IPs, error: %v", pool.Name, err)
+	}
+	needUpdate = needUpdate || updated
+
 	if needUpdate {
 		err = ic.client.Status().Update(ctx, pool)
 		if nil != err {
@@ -337,6 +447,60 @@ func (ic *IPPoolController) syncHandler(ctx context.Context, pool *spiderpoolv2b
 	return nil
 }
 
+// setImplicitExcludedIPCondition sets the ImplicitExcludedIPAllocated condition
+// if the gateway, the all-zeros or the broadcast IP address of the subnet was
+// allocated before they were always excluded, such IP addresses are reported
+// rather than taken back from the pods. It reports whether the condition is
+// changed.
+func setImplicitExcludedIPCondition(pool *spiderpoolv2beta1.SpiderIPPool) (bool, error) {
+	records, err := convert.UnmarshalIPPoolAllocatedIPs(pool.Status.AllocatedIPs)
+	if err != nil {
+		return false, err
+	}
+	implicitIPs, err := spiderpoolip.ImplicitExcludedIPs(*pool.Spec.IPVersion, pool.Spec.Subnet, PoolGateways(pool))
+	if err != nil {
+		return false, err
+	}
+
+	var allocated []string
+	for _, ip := range implicitIPs {
+		if record, ok := records[ip.String()]; ok {
+			allocated = append(allocated, fmt.Sprintf("%s (%s)", ip, record.NamespacedName))
+		}
+	}
+	sort.Strings(allocated)
+
+	condition := metav1.Condition{
+		Type:               ConditionImplicitExcludedIPAllocated,
+		ObservedGeneration: pool.Generation,
+	}
+	if len(allocated) != 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ReasonImplicitExcludedIPInUse
+		condition.Message = fmt.Sprintf("the gateway, the all-zeros or the broadcast IP addresses of subnet %s are allocated: %s",
+			pool.Spec.Subnet, strings.Join(allocated, ", "))
+	} else {
+		if apimeta.FindStatusCondition(pool.Status.Conditions, ConditionImplicitExcludedIPAllocated) == nil {
+			return false, nil
+		}
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonNoImplicitExcludedIPInUse
+	}
+
+	old := apimeta.FindStatusCondition(pool.Status.Conditions, ConditionImplicitExcludedIPAllocated)
+	if old != nil && old.Status == condition.Status && old.Reason == condition.Reason &&
+		old.Message == condition.Message && old.ObservedGeneration == condition.ObservedGeneration {
+		return false, nil
+	}
+
+	if condition.Status == metav1.ConditionTrue && (old == nil || old.Status != metav1.ConditionTrue) {
+		informerLogger.Sugar().Warnf("SpiderIPPool '%s': %s", pool.Name, condition.Message)
+		event.EventRecorder.Event(pool, corev1.EventTypeWarning, ReasonImplicitExcludedIPInUse, condition.Message)
+	}
+	apimeta.SetStatusCondition(&pool.Status.Conditions, condition)
+	return true, nil
+}
+
 // removeFinalizer removes SpiderIPPool finalizer
 func (ic *IPPoolController) removeFinalizer(ctx context.Context, pool *spiderpoolv2beta1.SpiderIPPool) error {
 	if !controllerutil.ContainsFinalizer(pool, constant.SpiderFinalizer) {
@@ -352,7 +516,11 @@ func (ic *IPPoolController) removeFinalizer(ctx context.Context, pool *spiderpoo
 	return nil
 }
 
-// cleanAutoIPPoolLegacy checks whether the given IPPool should be deleted or not
+// cleanAutoIPPoolLegacy checks whether the given IPPool should be deleted or not.
+// The auto-created IPPool whose application is gone is marked as orphaned, and
+// it is deleted once it has been orphaned for AutoIPPoolRetentionPeriod, so
+// that a quickly recreated application could still find it. The orphaned
+// IPPool with allocated IP addresses is never deleted.
 func (ic *IPPoolController) cleanAutoIPPoolLegacy(ctx context.Context, pool *spiderpoolv2beta1.SpiderIPPool) error {
 	if pool.DeletionTimestamp != nil {
 		return nil
@@ -366,48 +534,110 @@ func (ic *IPPoolController) cleanAutoIPPoolLegacy(ctx context.Context, pool *spi
 		return nil
 	}
 
-	if pool.Status.AllocatedIPs == nil {
-		// unpack the IPPool corresponding application type,namespace and name
-		appGVStr := poolLabels[constant.LabelIPPoolOwnerApplicationGV]
-		appAPIVersion, isMatch := applicationinformers.ParseApplicationGVStr(appGVStr)
-		if !isMatch {
-			return fmt.Errorf("%w: invalid IPPool label '%s' value '%s'", constant.ErrWrongInput, constant.LabelIPPoolOwnerApplicationGV, appGVStr)
+	// unpack the IPPool corresponding application type,namespace and name
+	appGVStr := poolLabels[constant.LabelIPPoolOwnerApplicationGV]
+	appAPIVersion, isMatch := applicationinformers.ParseApplicationGVStr(appGVStr)
+	if !isMatch {
+		return fmt.Errorf("%w: invalid IPPool label '%s' value '%s'", constant.ErrWrongInput, constant.LabelIPPoolOwnerApplicationGV, appGVStr)
+	}
+
+	appNamespacedName := types.AppNamespacedName{
+		APIVersion: appAPIVersion,
+		Kind:       poolLabels[constant.LabelIPPoolOwnerApplicationKind],
+		Namespace:  poolLabels[constant.LabelIPPoolOwnerApplicationNamespace],
+		Name:       poolLabels[constant.LabelIPPoolOwnerApplicationName],
+	}
+
+	orphaned := false
+	// check the IPPool's corresponding application whether is existed or not
+	informerLogger.Sugar().Debugf("try to get auto-created IPPool '%s' corresponding application '%v'", pool.Name, appNamespacedName)
+	isAppExist, appUID, err := applicationinformers.IsAppExist(ctx, ic.client, ic.dynamicClient, appNamespacedName)
+	if nil != err {
+		return fmt.Errorf("failed to get auto-created IPPool '%s' corresponding application '%v': %w", pool.Name, appNamespacedName, err)
+	}
+
+	// mismatch application UID
+	if !isAppExist {
+		informerLogger.Sugar().Warnf("auto-created IPPool '%s' corresponding application '%v' no longer exist", pool.Name, appNamespacedName)
+		orphaned = true
+	} else {
+		if string(appUID) != poolLabels[constant.LabelIPPoolOwnerApplicationUID] {
+			informerLogger.Sugar().Warnf("auto-created IPPool '%s' mismatches application '%v' UID '%s'", pool.Name, appNamespacedName, appUID)
+			orphaned = true
 		}
+	}
 
-		appNamespacedName := types.AppNamespacedName{
-			APIVersion: appAPIVersion,
-			Kind:       poolLabels[constant.LabelIPPoolOwnerApplicationKind],
-			Namespace:  poolLabels[constant.LabelIPPoolOwnerApplicationNamespace],
-			Name:       poolLabels[constant.LabelIPPoolOwnerApplicationName],
+	orphanedSince, isMarked := pool.Annotations[constant.AnnoIPPoolOrphanedSince]
+	if !orphaned {
+		if isMarked {
+			delete(pool.Annotations, constant.AnnoIPPoolOrphanedSince)
+			if err := ic.client.Update(ctx, pool); err != nil {
+				return fmt.Errorf("failed to unmark auto-created IPPool %s as orphaned: %w", pool.Name, err)
+			}
 		}
+		return nil
+	}
 
-		enableDelete := false
-		// check the IPPool's corresponding application whether is existed or not
-		informerLogger.Sugar().Debugf("try to get auto-created IPPool '%s' corresponding application '%v'", pool.Name, appNamespacedName)
-		isAppExist, appUID, err := applicationinformers.IsAppExist(ctx, ic.client, ic.dynamicClient, appNamespacedName)
-		if nil != err {
-			return fmt.Errorf("failed to get auto-created IPPool '%s' corresponding application '%v': %w", pool.Name, appNamespacedName, err)
+	inUse := pool.Status.AllocatedIPs != nil
+	if !inUse && !isMarked && ic.AutoIPPoolRetentionPeriod <= 0 {
+		return ic.deleteAutoIPPoolLegacy(ctx, pool)
+	}
+
+	since := time.Now()
+	if isMarked {
+		since, err = time.Parse(time.RFC3339, orphanedSince)
+		if err != nil {
+			informerLogger.Sugar().Warnf("invalid annotation %s: %s of auto-created IPPool '%s', mark it again", constant.AnnoIPPoolOrphanedSince, orphanedSince, pool.Name)
+			since = time.Now()
+			isMarked = false
 		}
+	}
 
-		// mismatch application UID
-		if !isAppExist {
-			informerLogger.Sugar().Warnf("auto-created IPPool '%s' corresponding application '%v' no longer exist, try to delete IPPool", pool.Name, appNamespacedName)
-			enableDelete = true
-		} else {
-			if string(appUID) != poolLabels[constant.LabelIPPoolOwnerApplicationUID] {
-				informerLogger.Sugar().Warnf("auto-created IPPool '%s' mismatches application '%v' UID '%s', try to delete IPPool", pool.Name, appNamespacedName, appUID)
-				enableDelete = true
-			}
+	if !isMarked {
+		if pool.Annotations == nil {
+			pool.Annotations = make(map[string]string)
+		}
+		pool.Annotations[constant.AnnoIPPoolOrphanedSince] = since.UTC().Format(time.RFC3339)
+		if err := ic.client.Update(ctx, pool); err != nil {
+			return fmt.Errorf("failed to mark auto-created IPPool %s as orphaned: %w", pool.Name, err)
 		}
 
-		if enableDelete {
-			err := ic.client.Delete(ctx, pool)
-			if client.IgnoreNotFound(err) != nil {
-				return fmt.Errorf("failed to delete legacy auto-created IPPool %s: %w", pool.Name, err)
-			}
-			return nil
+		if inUse {
+			event.EventRecorder.Eventf(
+				pool,
+				corev1.EventTypeWarning,
+				"OrphanedIPPoolInUse",
+				"The application %v is gone but the IPPool still has allocated IP addresses, it won't be deleted", appNamespacedName,
+			)
+		} else {
+			event.EventRecorder.Eventf(
+				pool,
+				corev1.EventTypeNormal,
+				"OrphanedIPPool",
+				"The application %v is gone, the IPPool will be deleted after %v", appNamespacedName, ic.AutoIPPoolRetentionPeriod,
+			)
 		}
 	}
 
+	if inUse {
+		return nil
+	}
+
+	if remaining := ic.AutoIPPoolRetentionPeriod - time.Since(since); remaining > 0 {
+		ic.poolWorkqueue.AddAfter(pool.Name, remaining)
+		return nil
+	}
+
+	return ic.deleteAutoIPPoolLegacy(ctx, pool)
+}
+
+func (ic *IPPoolController) deleteAutoIPPoolLegacy(ctx context.Context, pool *spiderpoolv2beta1.SpiderIPPool) error {
+	informerLogger.Sugar().Warnf("try to delete orphaned auto-created IPPool '%s'", pool.Name)
+	// the IPPool may have been adopted by the recreated application meanwhile
+	err := ic.client.Delete(ctx, pool, client.Preconditions{ResourceVersion: &pool.ResourceVersion})
+	if client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete legacy auto-created IPPool %s: %w", pool.Name, err)
+	}
+
 	return nil
 }
@@ -13,16 +13,21 @@ import (
 
 	"github.com/agiledragon/gomonkey/v2"
 	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/spidernet-io/spiderpool/pkg/applicationcontroller/applicationinformers"
 	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/event"
 	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
 	spiderpoolfake "github.com/spidernet-io/spiderpool/pkg/k8s/client/clientset/versioned/fake"
 	"github.com/spidernet-io/spiderpool/pkg/k8s/client/informers/externalversions"
@@ -199,6 +204,204 @@ var _ = Describe("IPPool-informer", Label("unitest"), Ordered, func() {
 
 	})
 
+	Describe("clean orphaned auto-created IPPool", func() {
+		var control *IPPoolController
+		var fakeClient client.Client
+		var autoPool *spiderpoolv2beta1.SpiderIPPool
+
+		BeforeEach(func() {
+			scheme = runtime.NewScheme()
+			err := spiderpoolv2beta1.AddToScheme(scheme)
+			Expect(err).NotTo(HaveOccurred())
+			err = appsv1.AddToScheme(scheme)
+			Expect(err).NotTo(HaveOccurred())
+
+			autoPool = pool.DeepCopy()
+			autoPool.Name = "auto4-deploy-abc"
+			autoPool.SetLabels(map[string]string{
+				constant.LabelIPPoolOwnerApplicationGV:        applicationinformers.ApplicationLabelGV(appsv1.SchemeGroupVersion.String()),
+				constant.LabelIPPoolOwnerApplicationKind:      constant.KindDeployment,
+				constant.LabelIPPoolOwnerApplicationNamespace: "test-ns",
+				constant.LabelIPPoolOwnerApplicationName:      "test-name",
+				constant.LabelIPPoolOwnerApplicationUID:       "old-uid",
+				constant.LabelIPPoolReclaimIPPool:             constant.True,
+			})
+		})
+
+		newOrphanController := func(retention time.Duration) {
+			fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(autoPool).Build()
+			err := fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(autoPool), autoPool)
+			Expect(err).NotTo(HaveOccurred())
+
+			config := poolControllerConfig
+			config.AutoIPPoolRetentionPeriod = retention
+			control = NewIPPoolController(config, fakeClient, dynamicfake.NewSimpleDynamicClient(scheme))
+			fakeClientSet := spiderpoolfake.NewSimpleClientset()
+			factory := externalversions.NewSharedInformerFactory(fakeClientSet, 0)
+			err = control.addEventHandlers(factory.Spiderpool().V2beta1().SpiderIPPools())
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		It("deletes the orphaned IPPool immediately without retention", func() {
+			newOrphanController(0)
+
+			err := control.cleanAutoIPPoolLegacy(context.TODO(), autoPool)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(autoPool), &spiderpoolv2beta1.SpiderIPPool{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("marks the orphaned IPPool and retains it for the retention period", func() {
+			newOrphanController(time.Hour)
+
+			err := control.cleanAutoIPPoolLegacy(context.TODO(), autoPool)
+			Expect(err).NotTo(HaveOccurred())
+
+			var retained spiderpoolv2beta1.SpiderIPPool
+			err = fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(autoPool), &retained)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(retained.Annotations).To(HaveKey(constant.AnnoIPPoolOrphanedSince))
+			Expect(IsOrphanedAutoIPPool(&retained)).To(BeTrue())
+		})
+
+		It("deletes the orphaned IPPool after the retention period", func() {
+			autoPool.SetAnnotations(map[string]string{
+				constant.AnnoIPPoolOrphanedSince: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+			})
+			newOrphanController(time.Hour)
+
+			err := control.cleanAutoIPPoolLegacy(context.TODO(), autoPool)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(autoPool), &spiderpoolv2beta1.SpiderIPPool{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("never deletes the orphaned IPPool with allocated IP addresses", func() {
+			autoPool.Status.AllocatedIPs = pointer.String(`{"10.1.0.1":{"namespacedName":"test-ns/test-pod","podUid":"abc"}}`)
+			newOrphanController(0)
+
+			err := control.cleanAutoIPPoolLegacy(context.TODO(), autoPool)
+			Expect(err).NotTo(HaveOccurred())
+
+			var retained spiderpoolv2beta1.SpiderIPPool
+			err = fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(autoPool), &retained)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(retained.Annotations).To(HaveKey(constant.AnnoIPPoolOrphanedSince))
+		})
+	})
+
+	Describe("set the ImplicitExcludedIPAllocated condition", func() {
+		var recorder *record.FakeRecorder
+
+		BeforeEach(func() {
+			recorder = record.NewFakeRecorder(10)
+			origin := event.EventRecorder
+			event.EventRecorder = recorder
+			DeferCleanup(func() {
+				event.EventRecorder = origin
+			})
+
+			pool.Spec.Subnet = "10.1.0.0/24"
+			pool.Spec.IPs = []string{"10.1.0.0-10.1.0.10", "10.1.0.255"}
+			pool.Spec.Gateway = pointer.String("10.1.0.1")
+		})
+
+		It("leaves the IPPool without the implicit excluded IPs allocated alone", func() {
+			pool.Status.AllocatedIPs = pointer.String(`{"10.1.0.2":{"pod":"test-ns/test-pod","podUid":"abc"}}`)
+
+			updated, err := setImplicitExcludedIPCondition(pool)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeFalse())
+			Expect(pool.Status.Conditions).To(BeEmpty())
+			Expect(recorder.Events).To(BeEmpty())
+		})
+
+		It("reports the implicit excluded IPs allocated", func() {
+			pool.Status.AllocatedIPs = pointer.String(`{"10.1.0.1":{"pod":"test-ns/pod-a","podUid":"a"},"10.1.0.255":{"pod":"test-ns/pod-b","podUid":"b"}}`)
+
+			updated, err := setImplicitExcludedIPCondition(pool)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeTrue())
+
+			condition := apimeta.FindStatusCondition(pool.Status.Conditions, ConditionImplicitExcludedIPAllocated)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(ReasonImplicitExcludedIPInUse))
+			Expect(condition.Message).To(ContainSubstring("10.1.0.1 (test-ns/pod-a)"))
+			Expect(condition.Message).To(ContainSubstring("10.1.0.255 (test-ns/pod-b)"))
+			Expect(recorder.Events).To(HaveLen(1))
+
+			// nothing changes on the next sync
+			updated, err = setImplicitExcludedIPCondition(pool)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeFalse())
+			Expect(recorder.Events).To(HaveLen(1))
+		})
+
+		It("clears the condition once the implicit excluded IPs are released", func() {
+			pool.Status.AllocatedIPs = pointer.String(`{"10.1.0.0":{"pod":"test-ns/test-pod","podUid":"abc"}}`)
+			updated, err := setImplicitExcludedIPCondition(pool)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeTrue())
+
+			pool.Status.AllocatedIPs = nil
+			updated, err = setImplicitExcludedIPCondition(pool)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeTrue())
+
+			condition := apimeta.FindStatusCondition(pool.Status.Conditions, ConditionImplicitExcludedIPAllocated)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(ReasonNoImplicitExcludedIPInUse))
+		})
+	})
+
+	Describe("emit the SubnetExpanded event", func() {
+		var recorder *record.FakeRecorder
+		var control *IPPoolController
+
+		BeforeEach(func() {
+			recorder = record.NewFakeRecorder(10)
+			origin := event.EventRecorder
+			event.EventRecorder = recorder
+			DeferCleanup(func() {
+				event.EventRecorder = origin
+			})
+
+			scheme = runtime.NewScheme()
+			err := spiderpoolv2beta1.AddToScheme(scheme)
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pool).WithStatusSubresource(pool).Build()
+			err = fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(pool), pool)
+			Expect(err).NotTo(HaveOccurred())
+
+			control = newController().IPPoolController
+			control.client = fakeClient
+			factory := externalversions.NewSharedInformerFactory(spiderpoolfake.NewSimpleClientset(), 0)
+			err = control.addEventHandlers(factory.Spiderpool().V2beta1().SpiderIPPools())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("emits the event once by the worker rather than the informer", func() {
+			newPool := pool.DeepCopy()
+			newPool.Spec.Subnet = "10.0.0.0/8"
+
+			control.enqueueIPPoolOnUpdate(pool, newPool)
+			Expect(recorder.Events).To(BeEmpty())
+
+			err := control.syncHandler(context.TODO(), newPool.DeepCopy())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.Events).To(HaveLen(1))
+			Expect(<-recorder.Events).To(ContainSubstring("Subnet expanded from 10.1.0.0/16 to 10.0.0.0/8"))
+
+			err = control.syncHandler(context.TODO(), newPool.DeepCopy())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.Events).To(BeEmpty())
+		})
+	})
 })
 
 var scheme *runtime.Scheme
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"time"
 
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
@@ -18,6 +19,7 @@ import (
 
 	"github.com/spidernet-io/spiderpool/api/v1/agent/models"
 	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/event"
 	spiderpoolip "github.com/spidernet-io/spiderpool/pkg/ip"
 	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
 	"github.com/spidernet-io/spiderpool/pkg/logutils"
@@ -34,6 +36,7 @@ type IPPoolManager interface {
 	AllocateIP(ctx context.Context, poolName, nic string, pod *corev1.Pod) (*models.IPConfig, error)
 	ReleaseIP(ctx context.Context, poolName string, ipAndUIDs []types.IPAndUID) error
 	UpdateAllocatedIPs(ctx context.Context, poolName string, ipAndCIDs []types.IPAndUID) error
+	AvailableIPCount(ctx context.Context, ipPool *spiderpoolv2beta1.SpiderIPPool) (int, error)
 }
 
 type ipPoolManager struct {
@@ -108,7 +111,7 @@ func (im *ipPoolManager) AllocateIP(ctx context.Context, poolName, nic string, p
 		}
 
 		logger.Debug("Generate a random IP address")
-		allocatedIP, err := im.genRandomIP(ctx, nic, ipPool, pod)
+		allocatedIP, earlyRelease, err := im.genRandomIP(ctx, nic, ipPool, pod)
 		if err != nil {
 			return err
 		}
@@ -125,6 +128,15 @@ func (im *ipPoolManager) AllocateIP(ctx context.Context, poolName, nic string, p
 		}
 		ipConfig = convert.GenIPConfigResult(allocatedIP, nic, ipPool)
 
+		if earlyRelease != nil {
+			releasedFor := time.Since(time.Unix(earlyRelease.ReleaseTime, 0)).Truncate(time.Second)
+			logger.Sugar().Warnf("Reuse IP %s released %s ago within the quarantine window %s, since IPPool has no other free IP",
+				allocatedIP, releasedFor, im.config.IPReuseCooldown)
+			event.EventRecorder.Eventf(pod, corev1.EventTypeWarning, "IPReusedEarly",
+				"IP %s of IPPool %s released by %s %s ago is reused within the quarantine window %s, since the IPPool has no other free IP",
+				allocatedIP, poolName, earlyRelease.NamespacedName, releasedFor, im.config.IPReuseCooldown)
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -138,40 +150,40 @@ func (im *ipPoolManager) AllocateIP(ctx context.Context, poolName, nic string, p
 	return ipConfig, nil
 }
 
-func (im *ipPoolManager) genRandomIP(ctx context.Context, nic string, ipPool *spiderpoolv2beta1.SpiderIPPool, pod *corev1.Pod) (net.IP, error) {
-	reservedIPs, err := im.rIPManager.AssembleReservedIPs(ctx, *ipPool.Spec.IPVersion)
+// genRandomIP picks a free IP address of the IPPool for the Pod and records the
+// allocation in the IPPool. With the quarantine window, the release record is
+// returned if the IP address is reused within the window.
+func (im *ipPoolManager) genRandomIP(ctx context.Context, nic string, ipPool *spiderpoolv2beta1.SpiderIPPool, pod *corev1.Pod) (net.IP, *spiderpoolv2beta1.PoolIPRelease, error) {
+	availableIPs, allocatedRecords, err := im.availableIPs(ctx, ipPool)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-
-	allocatedRecords, err := convert.UnmarshalIPPoolAllocatedIPs(ipPool.Status.AllocatedIPs)
-	if err != nil {
-		return nil, err
-	}
-
-	var used []string
-	for ip := range allocatedRecords {
-		used = append(used, ip)
-	}
-	usedIPs, err := spiderpoolip.ParseIPRanges(*ipPool.Spec.IPVersion, used)
-	if err != nil {
-		return nil, err
+	if len(availableIPs) == 0 {
+		return nil, nil, constant.ErrIPUsedOut
 	}
 
-	totalIPs, err := spiderpoolip.AssembleTotalIPs(*ipPool.Spec.IPVersion, ipPool.Spec.IPs, ipPool.Spec.ExcludeIPs)
+	key, err := cache.MetaNamespaceKeyFunc(pod)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	availableIPs := spiderpoolip.IPsDiffSet(totalIPs, append(reservedIPs, usedIPs...), false)
-	if len(availableIPs) == 0 {
-		return nil, constant.ErrIPUsedOut
-	}
 	resIP := availableIPs[0]
+	var earlyRelease *spiderpoolv2beta1.PoolIPRelease
+	if im.config.IPReuseCooldown > 0 || ipPool.Status.ReleasedIPs != nil {
+		releasedRecords, err := convert.UnmarshalIPPoolReleasedIPs(ipPool.Status.ReleasedIPs)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	key, err := cache.MetaNamespaceKeyFunc(pod)
-	if err != nil {
-		return nil, err
+		now := time.Now()
+		pruneReleasedIPs(releasedRecords, now, im.config.IPReuseCooldown)
+		resIP, earlyRelease = pickReleasedIP(availableIPs, releasedRecords, key)
+		delete(releasedRecords, resIP.String())
+
+		ipPool.Status.ReleasedIPs, err = convert.MarshalIPPoolReleasedIPs(releasedRecords)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	if allocatedRecords == nil {
@@ -185,7 +197,7 @@ func (im *ipPoolManager) genRandomIP(ctx context.Context, nic string, ipPool *sp
 
 	data, err := convert.MarshalIPPoolAllocatedIPs(allocatedRecords)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	ipPool.Status.AllocatedIPs = data
 
@@ -195,10 +207,106 @@ func (im *ipPoolManager) genRandomIP(ctx context.Context, nic string, ipPool *sp
 
 	*ipPool.Status.AllocatedIPCount++
 	if *ipPool.Status.AllocatedIPCount > int64(*im.config.MaxAllocatedIPs) {
-		return nil, fmt.Errorf("%w, threshold of IP records(<=%d) for IPPool %s exceeded", constant.ErrIPUsedOut, im.config.MaxAllocatedIPs, ipPool.Name)
+		return nil, nil, fmt.Errorf("%w, threshold of IP records(<=%d) for IPPool %s exceeded", constant.ErrIPUsedOut, im.config.MaxAllocatedIPs, ipPool.Name)
+	}
+
+	return resIP, earlyRelease, nil
+}
+
+// pruneReleasedIPs removes the release records out of the quarantine window,
+// all of them if the window is 0.
+func pruneReleasedIPs(releasedRecords spiderpoolv2beta1.PoolIPReleases, now time.Time, cooldown time.Duration) {
+	for ip, record := range releasedRecords {
+		if now.Sub(time.Unix(record.ReleaseTime, 0)) >= cooldown {
+			delete(releasedRecords, ip)
+		}
+	}
+}
+
+// pickReleasedIP picks the IP address for the Pod key from availableIPs, whose
+// release records are all within the quarantine window. The IP address released
+// by the same Pod is re-claimed first, regardless of the window, then the first
+// IP address out of quarantine. If all of them are in quarantine, the one
+// released the earliest is reused, along with its release record.
+func pickReleasedIP(availableIPs []net.IP, releasedRecords spiderpoolv2beta1.PoolIPReleases, key string) (net.IP, *spiderpoolv2beta1.PoolIPRelease) {
+	var free, oldest net.IP
+	var oldestRecord spiderpoolv2beta1.PoolIPRelease
+	for _, ip := range availableIPs {
+		record, ok := releasedRecords[ip.String()]
+		if !ok {
+			if free == nil {
+				free = ip
+			}
+			continue
+		}
+		if record.NamespacedName == key {
+			return ip, nil
+		}
+		if oldest == nil || record.ReleaseTime < oldestRecord.ReleaseTime {
+			oldest, oldestRecord = ip, record
+		}
 	}
 
-	return resIP, nil
+	if free != nil {
+		return free, nil
+	}
+	return oldest, &oldestRecord
+}
+
+// availableIPs returns the IP addresses of the IPPool which are neither
+// reserved nor allocated, along with the allocation records of the IPPool.
+// The gateway, the all-zeros and the broadcast IP addresses of the subnet are
+// never available.
+func (im *ipPoolManager) availableIPs(ctx context.Context, ipPool *spiderpoolv2beta1.SpiderIPPool) ([]net.IP, spiderpoolv2beta1.PoolIPAllocations, error) {
+	reservedIPs, err := im.rIPManager.AssembleReservedIPs(ctx, *ipPool.Spec.IPVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allocatedRecords, err := convert.UnmarshalIPPoolAllocatedIPs(ipPool.Status.AllocatedIPs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var used []string
+	for ip := range allocatedRecords {
+		used = append(used, ip)
+	}
+	usedIPs, err := spiderpoolip.ParseIPRanges(*ipPool.Spec.IPVersion, used)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalIPs, err := spiderpoolip.AssembleAllocatableIPs(*ipPool.Spec.IPVersion, ipPool.Spec.Subnet, PoolGateways(ipPool), ipPool.Spec.IPs, ipPool.Spec.ExcludeIPs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return spiderpoolip.IPsDiffSet(totalIPs, append(reservedIPs, usedIPs...), false), allocatedRecords, nil
+}
+
+// AvailableIPCount returns how many IP addresses could still be allocated
+// from the IPPool, considering the reserved IP addresses and the threshold
+// of IP records, which is the same as the IP allocation.
+func (im *ipPoolManager) AvailableIPCount(ctx context.Context, ipPool *spiderpoolv2beta1.SpiderIPPool) (int, error) {
+	availableIPs, _, err := im.availableIPs(ctx, ipPool)
+	if err != nil {
+		return 0, err
+	}
+
+	count := len(availableIPs)
+	var allocatedIPCount int
+	if ipPool.Status.AllocatedIPCount != nil {
+		allocatedIPCount = int(*ipPool.Status.AllocatedIPCount)
+	}
+	if remaining := *im.config.MaxAllocatedIPs - allocatedIPCount; remaining < count {
+		count = remaining
+	}
+	if count < 0 {
+		count = 0
+	}
+
+	return count, nil
 }
 
 func (im *ipPoolManager) ReleaseIP(ctx context.Context, poolName string, ipAndUIDs []types.IPAndUID) error {
@@ -226,6 +334,18 @@ func (im *ipPoolManager) ReleaseIP(ctx context.Context, poolName string, ipAndUI
 			ipPool.Status.AllocatedIPCount = new(int64)
 		}
 
+		var releasedRecords spiderpoolv2beta1.PoolIPReleases
+		if im.config.IPReuseCooldown > 0 {
+			releasedRecords, err = convert.UnmarshalIPPoolReleasedIPs(ipPool.Status.ReleasedIPs)
+			if err != nil {
+				return err
+			}
+			if releasedRecords == nil {
+				releasedRecords = spiderpoolv2beta1.PoolIPReleases{}
+			}
+		}
+
+		now := time.Now()
 		release := false
 		for _, iu := range ipAndUIDs {
 			if record, ok := allocatedRecords[iu.IP]; ok {
@@ -233,6 +353,13 @@ func (im *ipPoolManager) ReleaseIP(ctx context.Context, poolName string, ipAndUI
 					delete(allocatedRecords, iu.IP)
 					*ipPool.Status.AllocatedIPCount--
 					release = true
+
+					if releasedRecords != nil {
+						releasedRecords[iu.IP] = spiderpoolv2beta1.PoolIPRelease{
+							NamespacedName: record.NamespacedName,
+							ReleaseTime:    now.Unix(),
+						}
+					}
 				}
 			}
 		}
@@ -247,6 +374,14 @@ func (im *ipPoolManager) ReleaseIP(ctx context.Context, poolName string, ipAndUI
 		}
 		ipPool.Status.AllocatedIPs = data
 
+		if releasedRecords != nil {
+			pruneReleasedIPs(releasedRecords, now, im.config.IPReuseCooldown)
+			ipPool.Status.ReleasedIPs, err = convert.MarshalIPPoolReleasedIPs(releasedRecords)
+			if err != nil {
+				return err
+			}
+		}
+
 		resourceVersion := ipPool.ResourceVersion
 		logger.With(zap.String("IPPool-ResourceVersion", resourceVersion)).
 			Sugar().Debugf("Try to clean the IP allocation records of IPPool with IP addresses %+v", ipAndUIDs)
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net"
 	"sync/atomic"
+	"time"
 
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/golang/mock/gomock"
@@ -19,10 +20,12 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/event"
 	"github.com/spidernet-io/spiderpool/pkg/ippoolmanager"
 	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
 	spiderpooltypes "github.com/spidernet-io/spiderpool/pkg/types"
@@ -274,6 +277,7 @@ var _ = Describe("IPPoolManager", Label("ippool_manager_test"), func() {
 					Times(1)
 
 				ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+				ipPoolT.Spec.Subnet = "172.18.40.0/24"
 				ipPoolT.Spec.IPs = append(ipPoolT.Spec.IPs, "172.18.40.40")
 
 				err := fakeClient.Create(ctx, ipPoolT)
@@ -296,6 +300,7 @@ var _ = Describe("IPPoolManager", Label("ippool_manager_test"), func() {
 				defer patches.Reset()
 
 				ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+				ipPoolT.Spec.Subnet = "172.18.40.0/24"
 				ipPoolT.Spec.IPs = append(ipPoolT.Spec.IPs, "172.18.40.40")
 
 				err := fakeClient.Create(ctx, ipPoolT)
@@ -318,6 +323,7 @@ var _ = Describe("IPPoolManager", Label("ippool_manager_test"), func() {
 				defer patches.Reset()
 
 				ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+				ipPoolT.Spec.Subnet = "172.18.40.0/24"
 				ipPoolT.Spec.IPs = append(ipPoolT.Spec.IPs, "172.18.40.40")
 
 				err := fakeClient.Create(ctx, ipPoolT)
@@ -364,6 +370,28 @@ var _ = Describe("IPPoolManager", Label("ippool_manager_test"), func() {
 				Expect(res.Gateway).To(Equal(gateway))
 				Expect(res.Vlan).To(Equal(vlan))
 			})
+
+			It("never allocates the gateway, all-zeros or broadcast IP address", func() {
+				mockRIPManager.EXPECT().
+					AssembleReservedIPs(gomock.Eq(ctx), gomock.Eq(constant.IPv4)).
+					Return(nil, nil).
+					Times(1)
+
+				ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+				ipPoolT.Spec.Subnet = "172.18.40.0/24"
+				ipPoolT.Spec.IPs = []string{"172.18.40.0-172.18.40.1", "172.18.40.255"}
+				ipPoolT.Spec.Gateway = pointer.String("172.18.40.1")
+				ipPoolT.Spec.Vlan = pointer.Int64(0)
+
+				err := fakeClient.Create(ctx, ipPoolT)
+				Expect(err).NotTo(HaveOccurred())
+				err = tracker.Add(ipPoolT)
+				Expect(err).NotTo(HaveOccurred())
+
+				res, err := ipPoolManager.AllocateIP(ctx, ipPoolName, nic, podT)
+				Expect(err).To(MatchError(constant.ErrIPUsedOut))
+				Expect(res).To(BeNil())
+			})
 		})
 
 		Describe("ReleaseIP", func() {
@@ -548,5 +576,188 @@ var _ = Describe("IPPoolManager", Label("ippool_manager_test"), func() {
 				Expect(newRecords[ip].PodUID).To(Equal(newUID))
 			})
 		})
+
+		Describe("AvailableIPCount", func() {
+			BeforeEach(func() {
+				ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+				ipPoolT.Spec.Subnet = "172.18.40.0/24"
+				ipPoolT.Spec.IPs = []string{"172.18.40.10-172.18.40.20"}
+				ipPoolT.Spec.ExcludeIPs = []string{"172.18.40.20"}
+			})
+
+			It("failed to assemble the reserved IP addresses due to some unknown errors", func() {
+				mockRIPManager.EXPECT().
+					AssembleReservedIPs(gomock.Eq(ctx), gomock.Eq(constant.IPv4)).
+					Return(nil, constant.ErrUnknown).
+					Times(1)
+
+				count, err := ipPoolManager.AvailableIPCount(ctx, ipPoolT)
+				Expect(err).To(MatchError(constant.ErrUnknown))
+				Expect(count).To(BeZero())
+			})
+
+			It("counts the IP addresses neither reserved nor allocated", func() {
+				mockRIPManager.EXPECT().
+					AssembleReservedIPs(gomock.Eq(ctx), gomock.Eq(constant.IPv4)).
+					Return([]net.IP{net.ParseIP("172.18.40.10")}, nil).
+					Times(1)
+
+				data, err := convert.MarshalIPPoolAllocatedIPs(spiderpoolv2beta1.PoolIPAllocations{
+					"172.18.40.11": spiderpoolv2beta1.PoolIPAllocation{
+						NamespacedName: "default/pod",
+						PodUID:         string(uuid.NewUUID()),
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				ipPoolT.Status.AllocatedIPs = data
+				ipPoolT.Status.AllocatedIPCount = pointer.Int64(1)
+
+				count, err := ipPoolManager.AvailableIPCount(ctx, ipPoolT)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(8))
+			})
+		})
+
+		Describe("IPReuseCooldown", func() {
+			var manager ippoolmanager.IPPoolManager
+			var podT *corev1.Pod
+			var recorder *record.FakeRecorder
+
+			BeforeEach(func() {
+				var err error
+				manager, err = ippoolmanager.NewIPPoolManager(
+					ippoolmanager.IPPoolManagerConfig{IPReuseCooldown: time.Hour},
+					fakeClient,
+					fakeAPIReader,
+					mockRIPManager,
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				podT = &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod",
+						Namespace: "default",
+						UID:       uuid.NewUUID(),
+					},
+				}
+
+				recorder = record.NewFakeRecorder(10)
+				origin := event.EventRecorder
+				event.EventRecorder = recorder
+				DeferCleanup(func() {
+					event.EventRecorder = origin
+				})
+
+				ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+				ipPoolT.Spec.Subnet = "172.18.40.0/24"
+				ipPoolT.Spec.IPs = []string{"172.18.40.40-172.18.40.41"}
+				ipPoolT.Spec.Vlan = pointer.Int64(0)
+			})
+
+			createIPPool := func(released spiderpoolv2beta1.PoolIPReleases) {
+				data, err := convert.MarshalIPPoolReleasedIPs(released)
+				Expect(err).NotTo(HaveOccurred())
+				ipPoolT.Status.ReleasedIPs = data
+
+				err = fakeClient.Create(ctx, ipPoolT)
+				Expect(err).NotTo(HaveOccurred())
+				err = tracker.Add(ipPoolT)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			releasedIPs := func() spiderpoolv2beta1.PoolIPReleases {
+				var ipPool spiderpoolv2beta1.SpiderIPPool
+				err := fakeClient.Get(ctx, types.NamespacedName{Name: ipPoolT.Name}, &ipPool)
+				Expect(err).NotTo(HaveOccurred())
+
+				records, err := convert.UnmarshalIPPoolReleasedIPs(ipPool.Status.ReleasedIPs)
+				Expect(err).NotTo(HaveOccurred())
+				return records
+			}
+
+			expectReserved := func() {
+				mockRIPManager.EXPECT().
+					AssembleReservedIPs(gomock.Eq(ctx), gomock.Eq(constant.IPv4)).
+					Return(nil, nil).
+					Times(1)
+			}
+
+			It("records the release time of the released IP address", func() {
+				uid := string(uuid.NewUUID())
+				data, err := convert.MarshalIPPoolAllocatedIPs(spiderpoolv2beta1.PoolIPAllocations{
+					"172.18.40.40": spiderpoolv2beta1.PoolIPAllocation{
+						NIC:            "eth0",
+						NamespacedName: "default/other",
+						PodUID:         uid,
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				ipPoolT.Status.AllocatedIPs = data
+				ipPoolT.Status.AllocatedIPCount = pointer.Int64(1)
+				createIPPool(nil)
+
+				err = manager.ReleaseIP(ctx, ipPoolName, []spiderpooltypes.IPAndUID{{IP: "172.18.40.40", UID: uid}})
+				Expect(err).NotTo(HaveOccurred())
+
+				records := releasedIPs()
+				Expect(records).To(HaveKey("172.18.40.40"))
+				Expect(records["172.18.40.40"].NamespacedName).To(Equal("default/other"))
+				Expect(records["172.18.40.40"].ReleaseTime).To(BeNumerically("~", time.Now().Unix(), 5))
+			})
+
+			It("skips the IP address in quarantine", func() {
+				expectReserved()
+				createIPPool(spiderpoolv2beta1.PoolIPReleases{
+					"172.18.40.40": {NamespacedName: "default/other", ReleaseTime: time.Now().Unix()},
+				})
+
+				res, err := manager.AllocateIP(ctx, ipPoolName, "eth0", podT)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*res.Address).To(Equal("172.18.40.41/24"))
+				Expect(releasedIPs()).To(HaveKey("172.18.40.40"))
+				Expect(recorder.Events).To(BeEmpty())
+			})
+
+			It("reuses the earliest released IP address if the IPPool would be exhausted", func() {
+				expectReserved()
+				createIPPool(spiderpoolv2beta1.PoolIPReleases{
+					"172.18.40.40": {NamespacedName: "default/other", ReleaseTime: time.Now().Unix()},
+					"172.18.40.41": {NamespacedName: "default/other", ReleaseTime: time.Now().Add(-time.Minute).Unix()},
+				})
+
+				res, err := manager.AllocateIP(ctx, ipPoolName, "eth0", podT)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*res.Address).To(Equal("172.18.40.41/24"))
+				Expect(releasedIPs()).To(HaveLen(1))
+				Expect(recorder.Events).To(Receive(ContainSubstring("IPReusedEarly")))
+			})
+
+			It("re-claims the IP address released by the same Pod regardless of the quarantine", func() {
+				expectReserved()
+				createIPPool(spiderpoolv2beta1.PoolIPReleases{
+					"172.18.40.41": {NamespacedName: "default/pod", ReleaseTime: time.Now().Unix()},
+				})
+
+				res, err := manager.AllocateIP(ctx, ipPoolName, "eth0", podT)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*res.Address).To(Equal("172.18.40.41/24"))
+				Expect(releasedIPs()).To(BeEmpty())
+				Expect(recorder.Events).To(BeEmpty())
+			})
+
+			It("prunes the release records out of the quarantine window", func() {
+				expectReserved()
+				ipPoolT.Spec.IPs = []string{"172.18.40.40"}
+				createIPPool(spiderpoolv2beta1.PoolIPReleases{
+					"172.18.40.40": {NamespacedName: "default/other", ReleaseTime: time.Now().Add(-2 * time.Hour).Unix()},
+				})
+
+				res, err := manager.AllocateIP(ctx, ipPoolName, "eth0", podT)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*res.Address).To(Equal("172.18.40.40/24"))
+				Expect(releasedIPs()).To(BeEmpty())
+				Expect(recorder.Events).To(BeEmpty())
+			})
+		})
 	})
 })
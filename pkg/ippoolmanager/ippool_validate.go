@@ -6,8 +6,11 @@ package ippoolmanager
 import (
 	"context"
 	"fmt"
+	"net"
 	"strconv"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/strings/slices"
@@ -26,6 +29,7 @@ var (
 	ipsField         *field.Path = field.NewPath("spec").Child("ips")
 	excludeIPsField  *field.Path = field.NewPath("spec").Child("excludeIPs")
 	gatewayField     *field.Path = field.NewPath("spec").Child("gateway")
+	gwOverridesField *field.Path = field.NewPath("spec").Child("gatewayOverrides")
 	routesField      *field.Path = field.NewPath("spec").Child("routes")
 	podAffinityField *field.Path = field.NewPath("spec").Child("podAffinity")
 )
@@ -65,6 +69,12 @@ func (iw *IPPoolWebhook) validateUpdateIPPool(ctx context.Context, oldIPPool, ne
 		return field.ErrorList{err}
 	}
 
+	if newIPPool.Spec.Subnet != oldIPPool.Spec.Subnet {
+		if err := iw.validateIPPoolCIDROverlap(ctx, newIPPool); err != nil {
+			return field.ErrorList{err}
+		}
+	}
+
 	if err := iw.validateIPPoolSpec(ctx, newIPPool); err != nil {
 		return field.ErrorList{err}
 	}
@@ -96,9 +106,47 @@ func validateIPPoolShouldNotBeChanged(oldIPPool, newIPPool *spiderpoolv2beta1.Sp
 	}
 
 	if newIPPool.Spec.Subnet != oldIPPool.Spec.Subnet {
+		return validateIPPoolSubnetExpansion(oldIPPool, newIPPool)
+	}
+
+	return nil
+}
+
+// validateIPPoolSubnetExpansion only allows 'spec.subnet' to be expanded to
+// a CIDR containing the old one, so that all the existing IP addresses,
+// gateway and routes of the IPPool stay inside the new subnet.
+func validateIPPoolSubnetExpansion(oldIPPool, newIPPool *spiderpoolv2beta1.SpiderIPPool) *field.Error {
+	// The 'spec.subnet' of the IPPool controlled by a Subnet is always the
+	// same as the Subnet's.
+	if owner := metav1.GetControllerOf(newIPPool); owner != nil {
 		return field.Forbidden(
 			subnetField,
-			"is not changeable",
+			fmt.Sprintf("is not changeable for the IPPool controlled by Subnet %s", owner.Name),
+		)
+	}
+
+	_, oldCIDR, err := net.ParseCIDR(oldIPPool.Spec.Subnet)
+	if err != nil {
+		return field.InternalError(subnetField, fmt.Errorf("failed to parse the old 'spec.subnet' %s: %v", oldIPPool.Spec.Subnet, err))
+	}
+	if err := spiderpoolip.IsCIDR(*newIPPool.Spec.IPVersion, newIPPool.Spec.Subnet); err != nil {
+		return field.Invalid(
+			subnetField,
+			newIPPool.Spec.Subnet,
+			err.Error(),
+		)
+	}
+	_, newCIDR, err := net.ParseCIDR(newIPPool.Spec.Subnet)
+	if err != nil {
+		return field.Invalid(subnetField, newIPPool.Spec.Subnet, err.Error())
+	}
+
+	oldOnes, _ := oldCIDR.Mask.Size()
+	newOnes, _ := newCIDR.Mask.Size()
+	if newOnes > oldOnes || !newCIDR.Contains(oldCIDR.IP) {
+		return field.Forbidden(
+			subnetField,
+			fmt.Sprintf("is only allowed to be expanded to a CIDR containing %s", oldIPPool.Spec.Subnet),
 		)
 	}
 
@@ -112,8 +160,36 @@ func (iw *IPPoolWebhook) validateIPPoolSpec(ctx context.Context, ipPool *spiderp
 	if err := validateIPPoolGateway(ipPool); err != nil {
 		return err
 	}
+	if err := iw.validateIPPoolGatewayOverrides(ctx, ipPool); err != nil {
+		return err
+	}
+
+	if err := validateIPPoolRoutes(*ipPool.Spec.IPVersion, ipPool.Spec.Subnet, ipPool.Spec.Routes); err != nil {
+		return err
+	}
 
-	return validateIPPoolRoutes(*ipPool.Spec.IPVersion, ipPool.Spec.Subnet, ipPool.Spec.Routes)
+	return validateIPPoolInterfaceRouteTable(ipPool)
+}
+
+// validateIPPoolInterfaceRouteTable refuses the "interface" table in the
+// cluster default IPPool, which serves the pod's default NIC, whose routes
+// stay in main table rather than the table of the NIC.
+func validateIPPoolInterfaceRouteTable(ipPool *spiderpoolv2beta1.SpiderIPPool) *field.Error {
+	if ipPool.Spec.Default == nil || !*ipPool.Spec.Default {
+		return nil
+	}
+
+	for i, r := range ipPool.Spec.Routes {
+		if r.Table == constant.RouteTableInterface {
+			return field.Invalid(
+				routesField.Index(i).Child("table"),
+				r.Table,
+				fmt.Sprintf("the '%s' table is only available for the non-default NIC, it's not allowed in the default IPPool", constant.RouteTableInterface),
+			)
+		}
+	}
+
+	return nil
 }
 
 func validateIPPoolIPInUse(ipPool *spiderpoolv2beta1.SpiderIPPool) *field.Error {
@@ -196,12 +272,29 @@ func (iw *IPPoolWebhook) validateIPPoolCIDR(ctx context.Context, ipPool *spiderp
 	}
 
 	for _, pool := range ipPoolList.Items {
-		if *pool.Spec.IPVersion == *ipPool.Spec.IPVersion {
-			if pool.Name == ipPool.Name {
-				return field.InternalError(subnetField, fmt.Errorf("IPPool %s already exists", ipPool.Name))
-			}
+		if *pool.Spec.IPVersion == *ipPool.Spec.IPVersion && pool.Name == ipPool.Name {
+			return field.InternalError(subnetField, fmt.Errorf("IPPool %s already exists", ipPool.Name))
+		}
+	}
+
+	return validateIPPoolCIDRNotOverlap(ipPool, ipPoolList.Items)
+}
+
+// validateIPPoolCIDROverlap checks the expanded 'spec.subnet' of an existing
+// IPPool against the other IPPools.
+func (iw *IPPoolWebhook) validateIPPoolCIDROverlap(ctx context.Context, ipPool *spiderpoolv2beta1.SpiderIPPool) *field.Error {
+	var ipPoolList spiderpoolv2beta1.SpiderIPPoolList
+	if err := iw.APIReader.List(ctx, &ipPoolList); err != nil {
+		return field.InternalError(subnetField, fmt.Errorf("failed to list IPPools: %v", err))
+	}
+
+	return validateIPPoolCIDRNotOverlap(ipPool, ipPoolList.Items)
+}
 
-			if pool.Spec.Subnet == ipPool.Spec.Subnet {
+func validateIPPoolCIDRNotOverlap(ipPool *spiderpoolv2beta1.SpiderIPPool, pools []spiderpoolv2beta1.SpiderIPPool) *field.Error {
+	for _, pool := range pools {
+		if *pool.Spec.IPVersion == *ipPool.Spec.IPVersion {
+			if pool.Name == ipPool.Name || pool.Spec.Subnet == ipPool.Spec.Subnet {
 				continue
 			}
 
@@ -224,7 +317,7 @@ func (iw *IPPoolWebhook) validateIPPoolCIDR(ctx context.Context, ipPool *spiderp
 }
 
 func (iw *IPPoolWebhook) validateIPPoolAvailableIPs(ctx context.Context, ipPool *spiderpoolv2beta1.SpiderIPPool) *field.Error {
-	if err := iw.validateIPPoolIPs(*ipPool.Spec.IPVersion, ipPool.Spec.Subnet, ipPool.Spec.IPs); err != nil {
+	if err := iw.validateIPPoolIPs(*ipPool.Spec.IPVersion, ipPool.Spec.Subnet, PoolGateways(ipPool), ipPool.Spec.IPs); err != nil {
 		return err
 	}
 	if err := validateIPPoolExcludeIPs(*ipPool.Spec.IPVersion, ipPool.Spec.Subnet, ipPool.Spec.ExcludeIPs); err != nil {
@@ -275,13 +368,38 @@ func (iw *IPPoolWebhook) validateIPPoolAvailableIPs(ctx context.Context, ipPool
 	return nil
 }
 
-func (iw *IPPoolWebhook) validateIPPoolIPs(version types.IPVersion, subnet string, ips []string) *field.Error {
+func (iw *IPPoolWebhook) validateIPPoolIPs(version types.IPVersion, subnet string, gateways []string, ips []string) *field.Error {
 	for i, r := range ips {
 		if err := ValidateContainsIPRange(ipsField.Index(i), version, subnet, r); err != nil {
 			return err
 		}
 	}
 
+	// the gateways are validated later, just leave out the invalid ones
+	implicitIPs, err := spiderpoolip.ImplicitExcludedIPs(version, subnet, nil)
+	if err != nil {
+		return field.InternalError(ipsField, fmt.Errorf("failed to get the implicit excluded IP addresses of subnet %s: %v", subnet, err))
+	}
+	for _, gateway := range gateways {
+		if gw, err := spiderpoolip.ParseIP(version, gateway, false); err == nil {
+			implicitIPs = append(implicitIPs, gw.IP)
+		}
+	}
+
+	for i, r := range ips {
+		rangeIPs, err := spiderpoolip.ParseIPRange(version, r)
+		if err != nil {
+			return field.Invalid(ipsField.Index(i), r, err.Error())
+		}
+		if len(spiderpoolip.IPsDiffSet(rangeIPs, implicitIPs, false)) == 0 {
+			return field.Invalid(
+				ipsField.Index(i),
+				r,
+				"consists solely of the gateway, the all-zeros or the broadcast IP addresses of 'spec.subnet', which are never allocated",
+			)
+		}
+	}
+
 	return nil
 }
 
@@ -325,6 +443,72 @@ func validateIPPoolGateway(ipPool *spiderpoolv2beta1.SpiderIPPool) *field.Error
 	return nil
 }
 
+// validateIPPoolGatewayOverrides checks the gateway of each override pertains
+// to the subnet, each override selects the nodes either by names or by a node
+// selector, and that no node is matched by multiple overrides.
+func (iw *IPPoolWebhook) validateIPPoolGatewayOverrides(ctx context.Context, ipPool *spiderpoolv2beta1.SpiderIPPool) *field.Error {
+	if len(ipPool.Spec.GatewayOverrides) == 0 {
+		return nil
+	}
+
+	nodeNames := map[string]int{}
+	for i, override := range ipPool.Spec.GatewayOverrides {
+		if err := ValidateContainsIP(gwOverridesField.Index(i).Child("gateway"), *ipPool.Spec.IPVersion, ipPool.Spec.Subnet, override.Gateway); err != nil {
+			return err
+		}
+
+		if len(override.NodeName) == 0 && override.NodeSelector == nil {
+			return field.Required(
+				gwOverridesField.Index(i),
+				"either 'nodeName' or 'nodeSelector' must be specified",
+			)
+		}
+		if len(override.NodeName) != 0 && override.NodeSelector != nil {
+			return field.Forbidden(
+				gwOverridesField.Index(i),
+				"'nodeName' and 'nodeSelector' are mutually exclusive",
+			)
+		}
+
+		for _, name := range override.NodeName {
+			if j, ok := nodeNames[name]; ok {
+				return field.Invalid(
+					gwOverridesField.Index(i).Child("nodeName"),
+					name,
+					fmt.Sprintf("Node is already matched by %s", gwOverridesField.Index(j)),
+				)
+			}
+			nodeNames[name] = i
+		}
+
+		if override.NodeSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(override.NodeSelector); err != nil {
+				return field.Invalid(
+					gwOverridesField.Index(i).Child("nodeSelector"),
+					override.NodeSelector,
+					err.Error(),
+				)
+			}
+		}
+	}
+
+	var nodeList corev1.NodeList
+	if err := iw.Client.List(ctx, &nodeList); err != nil {
+		return field.InternalError(gwOverridesField, fmt.Errorf("failed to list Nodes: %v", err))
+	}
+	for i := range nodeList.Items {
+		if _, err := GatewayOfNode(ipPool, &nodeList.Items[i]); err != nil {
+			return field.Invalid(
+				gwOverridesField,
+				ipPool.Spec.GatewayOverrides,
+				err.Error(),
+			)
+		}
+	}
+
+	return nil
+}
+
 func validateIPPoolRoutes(version types.IPVersion, subnet string, routes []spiderpoolv2beta1.Route) *field.Error {
 	if len(routes) == 0 {
 		return nil
@@ -361,6 +545,10 @@ func validateIPPoolRoutes(version types.IPVersion, subnet string, routes []spide
 		if err := ValidateContainsIP(routesField.Index(i).Child("gw"), version, subnet, r.Gw); err != nil {
 			return err
 		}
+
+		if err := ValidateRouteTable(routesField.Index(i).Child("table"), r.Table); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -408,6 +596,27 @@ func ValidateContainsIP(fieldPath *field.Path, version types.IPVersion, subnet s
 	return nil
 }
 
+// ValidateRouteTable checks the route table is empty, "main", "interface" or
+// a table number which is not reserved by the kernel. Whether the "interface"
+// table is legal depends on the NIC the pool is used for, it's refused for the
+// default IPPool here, and checked by coordinator when the route is programmed.
+func ValidateRouteTable(fieldPath *field.Path, table string) *field.Error {
+	if table == "" || table == constant.RouteTableMain || table == constant.RouteTableInterface {
+		return nil
+	}
+
+	num, err := strconv.Atoi(table)
+	if err != nil || num < constant.RouteTableMin || num > constant.RouteTableMax {
+		return field.Invalid(
+			fieldPath,
+			table,
+			fmt.Sprintf("must be '%s', '%s' or a number from %d to %d", constant.RouteTableMain, constant.RouteTableInterface, constant.RouteTableMin, constant.RouteTableMax),
+		)
+	}
+
+	return nil
+}
+
 func validateIPPoolPodAffinity(fieldPath *field.Path, ipPool *spiderpoolv2beta1.SpiderIPPool) field.ErrorList {
 	if ipPool.Spec.PodAffinity == nil {
 		return nil
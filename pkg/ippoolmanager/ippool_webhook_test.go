@@ -12,6 +12,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -796,6 +797,52 @@ var _ = Describe("IPPoolWebhook", Label("ippool_webhook_test"), func() {
 					Expect(warns).To(BeNil())
 				})
 
+				It("inputs 'spec.ips' that consist solely of the all-zeros and broadcast addresses", func() {
+					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+					ipPoolT.Spec.Subnet = "172.18.40.0/24"
+					ipPoolT.Spec.IPs = append(ipPoolT.Spec.IPs,
+						[]string{
+							"172.18.40.10",
+							"172.18.40.255",
+						}...,
+					)
+
+					warns, err := ipPoolWebhook.ValidateCreate(ctx, ipPoolT)
+					Expect(apierrors.IsInvalid(err)).To(BeTrue())
+					Expect(err.Error()).To(ContainSubstring("spec.ips[1]"))
+					Expect(warns).To(BeNil())
+				})
+
+				It("inputs 'spec.ips' that consist solely of the gateway and the all-zeros address", func() {
+					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+					ipPoolT.Spec.Subnet = "172.18.40.0/24"
+					ipPoolT.Spec.IPs = append(ipPoolT.Spec.IPs, "172.18.40.0-172.18.40.1")
+					ipPoolT.Spec.Gateway = pointer.String("172.18.40.1")
+
+					warns, err := ipPoolWebhook.ValidateCreate(ctx, ipPoolT)
+					Expect(apierrors.IsInvalid(err)).To(BeTrue())
+					Expect(err.Error()).To(ContainSubstring("spec.ips[0]"))
+					Expect(warns).To(BeNil())
+				})
+
+				It("inputs 'spec.ips' that consist solely of the gateway of an override", func() {
+					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+					ipPoolT.Spec.Subnet = "172.18.40.0/24"
+					ipPoolT.Spec.IPs = append(ipPoolT.Spec.IPs, "172.18.40.2")
+					ipPoolT.Spec.Gateway = pointer.String("172.18.40.1")
+					ipPoolT.Spec.GatewayOverrides = []spiderpoolv2beta1.GatewayOverride{
+						{
+							NodeName: []string{"node1"},
+							Gateway:  "172.18.40.2",
+						},
+					}
+
+					warns, err := ipPoolWebhook.ValidateCreate(ctx, ipPoolT)
+					Expect(apierrors.IsInvalid(err)).To(BeTrue())
+					Expect(err.Error()).To(ContainSubstring("spec.ips[0]"))
+					Expect(warns).To(BeNil())
+				})
+
 				It("is a empty IPPool", func() {
 					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
 					ipPoolT.Spec.Subnet = "172.18.40.0/24"
@@ -1054,6 +1101,145 @@ var _ = Describe("IPPoolWebhook", Label("ippool_webhook_test"), func() {
 					Expect(warns).To(BeNil())
 				})
 
+				It("inputs gateway override that do not pertains to 'spec.subnet'", func() {
+					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+					ipPoolT.Spec.Subnet = "172.18.40.0/24"
+					ipPoolT.Spec.IPs = append(ipPoolT.Spec.IPs, "172.18.40.2-172.18.40.3")
+					ipPoolT.Spec.GatewayOverrides = []spiderpoolv2beta1.GatewayOverride{
+						{
+							NodeName: []string{"node1"},
+							Gateway:  "172.18.41.1",
+						},
+					}
+
+					warns, err := ipPoolWebhook.ValidateCreate(ctx, ipPoolT)
+					Expect(apierrors.IsInvalid(err)).To(BeTrue())
+					Expect(warns).To(BeNil())
+				})
+
+				It("inputs gateway override with both 'nodeName' and 'nodeSelector'", func() {
+					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+					ipPoolT.Spec.Subnet = "172.18.40.0/24"
+					ipPoolT.Spec.IPs = append(ipPoolT.Spec.IPs, "172.18.40.2-172.18.40.3")
+					ipPoolT.Spec.GatewayOverrides = []spiderpoolv2beta1.GatewayOverride{
+						{
+							NodeName: []string{"node1"},
+							NodeSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{"zone": "z1"},
+							},
+							Gateway: "172.18.40.1",
+						},
+					}
+
+					warns, err := ipPoolWebhook.ValidateCreate(ctx, ipPoolT)
+					Expect(apierrors.IsInvalid(err)).To(BeTrue())
+					Expect(warns).To(BeNil())
+				})
+
+				It("inputs gateway overrides matching the same Node name", func() {
+					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+					ipPoolT.Spec.Subnet = "172.18.40.0/24"
+					ipPoolT.Spec.IPs = append(ipPoolT.Spec.IPs, "172.18.40.2-172.18.40.3")
+					ipPoolT.Spec.GatewayOverrides = []spiderpoolv2beta1.GatewayOverride{
+						{
+							NodeName: []string{"node1"},
+							Gateway:  "172.18.40.1",
+						},
+						{
+							NodeName: []string{"node2", "node1"},
+							Gateway:  "172.18.40.254",
+						},
+					}
+
+					warns, err := ipPoolWebhook.ValidateCreate(ctx, ipPoolT)
+					Expect(apierrors.IsInvalid(err)).To(BeTrue())
+					Expect(warns).To(BeNil())
+				})
+
+				It("inputs gateway overrides whose selectors match the same Node", func() {
+					node := &corev1.Node{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:   "node1",
+							Labels: map[string]string{"rack": "a", "zone": "z1"},
+						},
+					}
+					Expect(fakeClient.Create(ctx, node)).To(Succeed())
+					DeferCleanup(func() {
+						Expect(fakeClient.Delete(ctx, node)).To(Succeed())
+					})
+
+					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+					ipPoolT.Spec.Subnet = "172.18.40.0/24"
+					ipPoolT.Spec.IPs = append(ipPoolT.Spec.IPs, "172.18.40.2-172.18.40.3")
+					ipPoolT.Spec.GatewayOverrides = []spiderpoolv2beta1.GatewayOverride{
+						{
+							NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"rack": "a"}},
+							Gateway:      "172.18.40.1",
+						},
+						{
+							NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "z1"}},
+							Gateway:      "172.18.40.254",
+						},
+					}
+
+					warns, err := ipPoolWebhook.ValidateCreate(ctx, ipPoolT)
+					Expect(apierrors.IsInvalid(err)).To(BeTrue())
+					Expect(warns).To(BeNil())
+
+					ipPoolT.Spec.GatewayOverrides[1].NodeSelector.MatchLabels["zone"] = "z2"
+					warns, err = ipPoolWebhook.ValidateCreate(ctx, ipPoolT)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(warns).To(BeNil())
+				})
+
+				It("inputs invalid route table", func() {
+					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+					ipPoolT.Spec.Subnet = "172.18.40.0/24"
+					ipPoolT.Spec.IPs = append(ipPoolT.Spec.IPs,
+						[]string{
+							"172.18.40.2-172.18.40.3",
+							"172.18.40.10",
+						}...,
+					)
+
+					for _, table := range []string{"local", "0", "253", "255"} {
+						ipPoolT.Spec.Routes = []spiderpoolv2beta1.Route{
+							{
+								Dst:   "192.168.40.0/24",
+								Gw:    "172.18.40.1",
+								Table: table,
+							},
+						}
+
+						warns, err := ipPoolWebhook.ValidateCreate(ctx, ipPoolT)
+						Expect(apierrors.IsInvalid(err)).To(BeTrue())
+						Expect(warns).To(BeNil())
+					}
+				})
+
+				It("inputs the interface route table in the default IPPool", func() {
+					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+					ipPoolT.Spec.Subnet = "172.18.40.0/24"
+					ipPoolT.Spec.IPs = append(ipPoolT.Spec.IPs, "172.18.40.2-172.18.40.3")
+					ipPoolT.Spec.Routes = []spiderpoolv2beta1.Route{
+						{
+							Dst:   "192.168.40.0/24",
+							Gw:    "172.18.40.1",
+							Table: constant.RouteTableInterface,
+						},
+					}
+					ipPoolT.Spec.Default = pointer.Bool(true)
+
+					warns, err := ipPoolWebhook.ValidateCreate(ctx, ipPoolT)
+					Expect(apierrors.IsInvalid(err)).To(BeTrue())
+					Expect(warns).To(BeNil())
+
+					ipPoolT.Spec.Default = pointer.Bool(false)
+					warns, err = ipPoolWebhook.ValidateCreate(ctx, ipPoolT)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(warns).To(BeNil())
+				})
+
 				It("inputs gateway that do not pertains to 'spec.subnet'", func() {
 					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
 					ipPoolT.Spec.Subnet = "172.18.40.0/24"
@@ -1320,8 +1506,9 @@ var _ = Describe("IPPoolWebhook", Label("ippool_webhook_test"), func() {
 				ipPoolT.Spec.Vlan = pointer.Int64(0)
 				ipPoolT.Spec.Routes = append(ipPoolT.Spec.Routes,
 					spiderpoolv2beta1.Route{
-						Dst: "192.168.40.0/24",
-						Gw:  "172.18.40.40",
+						Dst:   "192.168.40.0/24",
+						Gw:    "172.18.40.40",
+						Table: constant.RouteTableMain,
 					},
 				)
 				ipPoolT.Spec.Default = pointer.Bool(true)
@@ -1362,8 +1549,9 @@ var _ = Describe("IPPoolWebhook", Label("ippool_webhook_test"), func() {
 				ipPoolT.Spec.Vlan = pointer.Int64(0)
 				ipPoolT.Spec.Routes = append(ipPoolT.Spec.Routes,
 					spiderpoolv2beta1.Route{
-						Dst: "fd00:40::/120",
-						Gw:  "abcd:1234::28",
+						Dst:   "fd00:40::/120",
+						Gw:    "abcd:1234::28",
+						Table: "252",
 					},
 				)
 				ipPoolT.Spec.Default = pointer.Bool(true)
@@ -1459,6 +1647,67 @@ var _ = Describe("IPPoolWebhook", Label("ippool_webhook_test"), func() {
 					Expect(apierrors.IsInvalid(err)).To(BeTrue())
 					Expect(warns).To(BeNil())
 				})
+
+				It("expands 'spec.subnet' and appends IP range of the new subnet to 'spec.ips'", func() {
+					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+					ipPoolT.Spec.Subnet = "172.18.40.0/25"
+					ipPoolT.Spec.IPs = append(ipPoolT.Spec.IPs, "172.18.40.2-172.18.40.3")
+					ipPoolT.Spec.Gateway = pointer.String("172.18.40.1")
+
+					newIPPoolT := ipPoolT.DeepCopy()
+					newIPPoolT.Spec.Subnet = "172.18.40.0/24"
+					newIPPoolT.Spec.IPs = append(newIPPoolT.Spec.IPs, "172.18.40.200")
+
+					warns, err := ipPoolWebhook.ValidateUpdate(ctx, ipPoolT, newIPPoolT)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(warns).To(BeNil())
+				})
+
+				It("changes 'spec.subnet' to a CIDR not containing the old one", func() {
+					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+					ipPoolT.Spec.Subnet = "172.18.40.0/24"
+
+					newIPPoolT := ipPoolT.DeepCopy()
+					newIPPoolT.Spec.Subnet = "172.18.42.0/23"
+
+					warns, err := ipPoolWebhook.ValidateUpdate(ctx, ipPoolT, newIPPoolT)
+					Expect(apierrors.IsInvalid(err)).To(BeTrue())
+					Expect(warns).To(BeNil())
+				})
+
+				It("expands 'spec.subnet' to overlap with existing IPPool", func() {
+					existIPPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+					existIPPoolT.Spec.Subnet = "172.18.41.0/24"
+
+					err := tracker.Add(existIPPoolT)
+					Expect(err).NotTo(HaveOccurred())
+
+					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+					ipPoolT.Spec.Subnet = "172.18.40.0/24"
+
+					newIPPoolT := ipPoolT.DeepCopy()
+					newIPPoolT.Spec.Subnet = "172.18.40.0/23"
+
+					warns, err := ipPoolWebhook.ValidateUpdate(ctx, ipPoolT, newIPPoolT)
+					Expect(apierrors.IsInvalid(err)).To(BeTrue())
+					Expect(warns).To(BeNil())
+				})
+
+				It("expands 'spec.subnet' of the IPPool controlled by Subnet", func() {
+					subnetT.SetUID(uuid.NewUUID())
+					err := controllerutil.SetControllerReference(subnetT, ipPoolT, scheme)
+					Expect(err).NotTo(HaveOccurred())
+
+					ipPoolT.Spec.IPVersion = pointer.Int64(constant.IPv4)
+					ipPoolT.Spec.Subnet = "172.18.40.0/25"
+
+					newIPPoolT := ipPoolT.DeepCopy()
+					newIPPoolT.Spec.Subnet = "172.18.40.0/24"
+
+					warns, err := ipPoolWebhook.ValidateUpdate(ctx, ipPoolT, newIPPoolT)
+					Expect(apierrors.IsInvalid(err)).To(BeTrue())
+					Expect(warns).To(BeNil())
+				})
 			})
 
 			When("Validating 'spec.default'", func() {
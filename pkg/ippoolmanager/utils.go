@@ -4,17 +4,28 @@
 package ippoolmanager
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/strings/slices"
 
 	"github.com/spidernet-io/spiderpool/pkg/constant"
 	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
 	"github.com/spidernet-io/spiderpool/pkg/types"
 )
 
+const (
+	// ConditionImplicitExcludedIPAllocated is True if the gateway, the
+	// all-zeros or the broadcast IP address of the subnet is allocated
+	ConditionImplicitExcludedIPAllocated = "ImplicitExcludedIPAllocated"
+	ReasonImplicitExcludedIPInUse        = "ImplicitExcludedIPInUse"
+	ReasonNoImplicitExcludedIPInUse      = "NoImplicitExcludedIPInUse"
+)
+
 func IsAutoCreatedIPPool(pool *spiderpoolv2beta1.SpiderIPPool) bool {
 	// only the auto-created IPPool owns the annotation "ipam.spidernet.io/owner-application"
 	poolLabels := pool.GetLabels()
@@ -22,6 +33,76 @@ func IsAutoCreatedIPPool(pool *spiderpoolv2beta1.SpiderIPPool) bool {
 	return ok
 }
 
+// IsOrphanedAutoIPPool checks whether the auto-created IPPool is marked as
+// orphaned, such an IPPool could be adopted by the recreated application with
+// the same name before it's deleted.
+func IsOrphanedAutoIPPool(pool *spiderpoolv2beta1.SpiderIPPool) bool {
+	_, ok := pool.GetAnnotations()[constant.AnnoIPPoolOrphanedSince]
+	return ok && pool.DeletionTimestamp == nil
+}
+
+// PoolGateways returns all the gateways of the IPPool, 'spec.gateway' and the
+// gateways of 'spec.gatewayOverrides'.
+func PoolGateways(pool *spiderpoolv2beta1.SpiderIPPool) []string {
+	var gateways []string
+	if pool.Spec.Gateway != nil {
+		gateways = append(gateways, *pool.Spec.Gateway)
+	}
+	for _, override := range pool.Spec.GatewayOverrides {
+		gateways = append(gateways, override.Gateway)
+	}
+
+	return gateways
+}
+
+// GatewayOfNode returns the gateway of the IPPool for the pods on the node,
+// which is the gateway of the override matching the node, or 'spec.gateway'
+// if no override matches. It's an error if multiple overrides match the node.
+func GatewayOfNode(pool *spiderpoolv2beta1.SpiderIPPool, node *corev1.Node) (string, error) {
+	var gateway string
+	if pool.Spec.Gateway != nil {
+		gateway = *pool.Spec.Gateway
+	}
+
+	matched := -1
+	for i, override := range pool.Spec.GatewayOverrides {
+		ok, err := IsGatewayOverrideMatchNode(override, node)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			continue
+		}
+		if matched >= 0 {
+			return "", fmt.Errorf("both gateway overrides %d and %d of IPPool %s match Node %s", matched, i, pool.Name, node.Name)
+		}
+		matched = i
+	}
+
+	if matched >= 0 {
+		gateway = pool.Spec.GatewayOverrides[matched].Gateway
+	}
+	return gateway, nil
+}
+
+// IsGatewayOverrideMatchNode checks whether the node is selected by the node
+// names of the override, or by its node selector, the webhook makes sure that
+// only one of them is specified.
+func IsGatewayOverrideMatchNode(override spiderpoolv2beta1.GatewayOverride, node *corev1.Node) (bool, error) {
+	if len(override.NodeName) != 0 {
+		return slices.Contains(override.NodeName, node.Name), nil
+	}
+
+	if override.NodeSelector == nil {
+		return false, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(override.NodeSelector)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(node.Labels)), nil
+}
+
 func NewAutoPoolPodAffinity(podTopController types.PodTopController) *metav1.LabelSelector {
 	var group, version string
 
@@ -67,7 +148,57 @@ func IsMatchAutoPoolAffinity(podAffinity *metav1.LabelSelector, podTopController
 	return true
 }
 
-// ByPoolPriority implements sort.Interface
+// PoolPriority returns 'spec.priority' of the IPPool, which is 0 if unset.
+func PoolPriority(pool *spiderpoolv2beta1.SpiderIPPool) int32 {
+	if pool.Spec.Priority == nil {
+		return 0
+	}
+	return *pool.Spec.Priority
+}
+
+// FreeIPCount returns the count of the IP addresses not allocated yet in the
+// IPPool, based on its status.
+func FreeIPCount(pool *spiderpoolv2beta1.SpiderIPPool) int64 {
+	var total, allocated int64
+	if pool.Status.TotalIPCount != nil {
+		total = *pool.Status.TotalIPCount
+	}
+	if pool.Status.AllocatedIPCount != nil {
+		allocated = *pool.Status.AllocatedIPCount
+	}
+	if total < allocated {
+		return 0
+	}
+	return total - allocated
+}
+
+// DescribePoolPriority renders the properties the IPPool is sorted by, which
+// explains why it's selected in such order.
+func DescribePoolPriority(pool *spiderpoolv2beta1.SpiderIPPool) string {
+	var affinities []string
+	if pool.Spec.PodAffinity != nil {
+		affinities = append(affinities, "pod")
+	}
+	if len(pool.Spec.NodeName) != 0 || pool.Spec.NodeAffinity != nil {
+		affinities = append(affinities, "node")
+	}
+	if len(pool.Spec.NamespaceName) != 0 || pool.Spec.NamespaceAffinity != nil {
+		affinities = append(affinities, "namespace")
+	}
+	if len(pool.Spec.MultusName) != 0 {
+		affinities = append(affinities, "multus")
+	}
+
+	return fmt.Sprintf("%s(priority=%d, affinities=%v, free=%d)", pool.Name, PoolPriority(pool), affinities, FreeIPCount(pool))
+}
+
+// ByPoolPriority implements sort.Interface, the IPPools are sorted by:
+//  1. 'spec.priority', the higher one goes first.
+//  2. the affinities, the IPPool with the more specific affinities goes first.
+//
+// The IPPools listed in order by the user, e.g. in the Pod annotation
+// 'ipam.spidernet.io/ippools', are sorted with sort.Stable, so that the ties
+// keep the order they are listed in.
 var _ sort.Interface = &ByPoolPriority{}
 
 type ByPoolPriority []*spiderpoolv2beta1.SpiderIPPool
@@ -77,49 +208,92 @@ func (b ByPoolPriority) Len() int { return len(b) }
 func (b ByPoolPriority) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
 
 func (b ByPoolPriority) Less(i, j int) bool {
-	// Pod Affinity
-	if b[i].Spec.PodAffinity != nil && b[j].Spec.PodAffinity == nil {
+	// Priority
+	if pi, pj := PoolPriority(b[i]), PoolPriority(b[j]); pi != pj {
+		return pi > pj
+	}
+
+	less, _ := lessByAffinity(b[i], b[j])
+	return less
+}
+
+// ByPoolPriorityAndFreeIPs implements sort.Interface for the IPPools not
+// listed in order, e.g. the cluster default IPPools. The IPPools are sorted as
+// ByPoolPriority, and then the ties are sorted by:
+//  1. the count of the free IP addresses, the larger one goes first.
+//  2. the name in lexical order.
+//
+// So the order is stable for the same IPPools.
+var _ sort.Interface = &ByPoolPriorityAndFreeIPs{}
+
+type ByPoolPriorityAndFreeIPs []*spiderpoolv2beta1.SpiderIPPool
+
+func (b ByPoolPriorityAndFreeIPs) Len() int { return len(b) }
+
+func (b ByPoolPriorityAndFreeIPs) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+
+func (b ByPoolPriorityAndFreeIPs) Less(i, j int) bool {
+	if ByPoolPriority(b).Less(i, j) {
 		return true
 	}
-	if b[i].Spec.PodAffinity == nil && b[j].Spec.PodAffinity != nil {
+	if ByPoolPriority(b).Less(j, i) {
 		return false
 	}
 
+	// Free IP count
+	if fi, fj := FreeIPCount(b[i]), FreeIPCount(b[j]); fi != fj {
+		return fi > fj
+	}
+
+	return b[i].Name < b[j].Name
+}
+
+// lessByAffinity compares the affinities of the IPPools, ok is false if they
+// are equally specific.
+func lessByAffinity(a, b *spiderpoolv2beta1.SpiderIPPool) (less, ok bool) {
+	// Pod Affinity
+	if a.Spec.PodAffinity != nil && b.Spec.PodAffinity == nil {
+		return true, true
+	}
+	if a.Spec.PodAffinity == nil && b.Spec.PodAffinity != nil {
+		return false, true
+	}
+
 	// Node Affinity
-	if len(b[i].Spec.NodeName) != 0 && len(b[j].Spec.NodeName) == 0 {
-		return true
+	if len(a.Spec.NodeName) != 0 && len(b.Spec.NodeName) == 0 {
+		return true, true
 	}
-	if len(b[i].Spec.NodeName) == 0 && len(b[j].Spec.NodeName) != 0 {
-		return false
+	if len(a.Spec.NodeName) == 0 && len(b.Spec.NodeName) != 0 {
+		return false, true
 	}
-	if b[i].Spec.NodeAffinity != nil && b[j].Spec.NodeAffinity == nil {
-		return true
+	if a.Spec.NodeAffinity != nil && b.Spec.NodeAffinity == nil {
+		return true, true
 	}
-	if b[i].Spec.NodeAffinity == nil && b[j].Spec.NodeAffinity != nil {
-		return false
+	if a.Spec.NodeAffinity == nil && b.Spec.NodeAffinity != nil {
+		return false, true
 	}
 
 	// Namespace Affinity
-	if len(b[i].Spec.NamespaceName) != 0 && len(b[j].Spec.NamespaceName) == 0 {
-		return true
+	if len(a.Spec.NamespaceName) != 0 && len(b.Spec.NamespaceName) == 0 {
+		return true, true
 	}
-	if len(b[i].Spec.NamespaceName) == 0 && len(b[j].Spec.NamespaceName) != 0 {
-		return false
+	if len(a.Spec.NamespaceName) == 0 && len(b.Spec.NamespaceName) != 0 {
+		return false, true
 	}
-	if b[i].Spec.NamespaceAffinity != nil && b[j].Spec.NamespaceAffinity == nil {
-		return true
+	if a.Spec.NamespaceAffinity != nil && b.Spec.NamespaceAffinity == nil {
+		return true, true
 	}
-	if b[i].Spec.NamespaceAffinity == nil && b[j].Spec.NamespaceAffinity != nil {
-		return false
+	if a.Spec.NamespaceAffinity == nil && b.Spec.NamespaceAffinity != nil {
+		return false, true
 	}
 
 	// Multus Name
-	if len(b[i].Spec.MultusName) != 0 && len(b[j].Spec.MultusName) == 0 {
-		return true
+	if len(a.Spec.MultusName) != 0 && len(b.Spec.MultusName) == 0 {
+		return true, true
 	}
-	if len(b[i].Spec.MultusName) == 0 && len(b[j].Spec.MultusName) != 0 {
-		return false
+	if len(a.Spec.MultusName) == 0 && len(b.Spec.MultusName) != 0 {
+		return false, true
 	}
 
-	return false
+	return false, false
 }
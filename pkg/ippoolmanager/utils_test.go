@@ -9,8 +9,10 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types2 "k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 
 	"github.com/spidernet-io/spiderpool/pkg/constant"
 	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
@@ -289,5 +291,157 @@ var _ = Describe("IPPoolManager-utils", Label("ippool_manager_utils"), func() {
 			sort.Sort(byPoolPriority)
 			Expect(byPoolPriority).Should(Equal(ByPoolPriority{pool2, pool1}))
 		})
+
+		Context("IPPool with priority", func() {
+			newPool := func(name string, priority int32, total, allocated int64) *spiderpoolv2beta1.SpiderIPPool {
+				pool := poolTemplate.DeepCopy()
+				pool.SetName(name)
+				pool.Spec.Priority = pointer.Int32(priority)
+				pool.Status.TotalIPCount = pointer.Int64(total)
+				pool.Status.AllocatedIPCount = pointer.Int64(allocated)
+				return pool
+			}
+
+			It("prefers the higher priority over the affinities and the free IP count", func() {
+				pool1 := newPool("pool1", 10, 2, 1)
+				pool2 := newPool("pool2", 0, 100, 0)
+				pool2.Spec.PodAffinity = &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"PodAffinityKey": "PodAffinityValue1",
+					},
+				}
+				pool3 := newPool("pool3", -1, 100, 0)
+
+				byPoolPriority := ByPoolPriority{pool3, pool2, pool1}
+				sort.Sort(byPoolPriority)
+				Expect(byPoolPriority).To(Equal(ByPoolPriority{pool1, pool2, pool3}))
+			})
+
+			It("treats the unset priority as 0", func() {
+				pool1 := newPool("pool1", 1, 10, 0)
+				pool2 := newPool("pool2", 0, 10, 0)
+				pool2.Spec.Priority = nil
+				pool3 := newPool("pool3", -1, 10, 0)
+
+				byPoolPriority := ByPoolPriority{pool3, pool2, pool1}
+				sort.Sort(byPoolPriority)
+				Expect(byPoolPriority).To(Equal(ByPoolPriority{pool1, pool2, pool3}))
+			})
+
+			It("breaks the ties by the free IP count and then the name", func() {
+				pool1 := newPool("pool-a", 5, 10, 8)
+				pool2 := newPool("pool-b", 5, 10, 2)
+				pool3 := newPool("pool-c", 5, 10, 2)
+				pool4 := newPool("pool-d", 5, 10, 8)
+
+				for _, order := range []ByPoolPriorityAndFreeIPs{
+					{pool1, pool2, pool3, pool4},
+					{pool4, pool3, pool2, pool1},
+					{pool3, pool1, pool4, pool2},
+				} {
+					sort.Sort(order)
+					Expect(order).To(Equal(ByPoolPriorityAndFreeIPs{pool2, pool3, pool1, pool4}))
+				}
+			})
+
+			It("keeps the listed order of the ties", func() {
+				pool1 := newPool("pool-a", 5, 10, 8)
+				pool2 := newPool("pool-b", 5, 10, 2)
+				pool3 := newPool("pool-c", 10, 10, 10)
+
+				byPoolPriority := ByPoolPriority{pool1, pool2, pool3}
+				sort.Stable(byPoolPriority)
+				Expect(byPoolPriority).To(Equal(ByPoolPriority{pool3, pool1, pool2}))
+
+				byPoolPriority = ByPoolPriority{pool2, pool1, pool3}
+				sort.Stable(byPoolPriority)
+				Expect(byPoolPriority).To(Equal(ByPoolPriority{pool3, pool2, pool1}))
+			})
+
+			It("keeps the exhausted preferred IPPool ahead, so that the allocation falls back to the next one", func() {
+				preferred := newPool("pool-a", 10, 10, 10)
+				fallback := newPool("pool-b", 0, 10, 0)
+				Expect(FreeIPCount(preferred)).To(BeZero())
+
+				byPoolPriority := ByPoolPriority{fallback, preferred}
+				sort.Sort(byPoolPriority)
+				Expect(byPoolPriority).To(Equal(ByPoolPriority{preferred, fallback}))
+			})
+
+			It("moves the exhausted IPPool behind the ones with the same priority", func() {
+				exhausted := newPool("pool-a", 10, 10, 10)
+				available := newPool("pool-b", 10, 10, 9)
+
+				byPoolPriority := ByPoolPriorityAndFreeIPs{exhausted, available}
+				sort.Sort(byPoolPriority)
+				Expect(byPoolPriority).To(Equal(ByPoolPriorityAndFreeIPs{available, exhausted}))
+			})
+
+			It("describes the properties the IPPool is sorted by", func() {
+				pool := newPool("pool1", 10, 10, 4)
+				pool.Spec.NodeName = []string{"master"}
+				Expect(DescribePoolPriority(pool)).To(Equal("pool1(priority=10, affinities=[node], free=6)"))
+			})
+		})
+	})
+
+	Context("GatewayOfNode", Labels{"unitest", "GatewayOfNode"}, func() {
+		var pool *spiderpoolv2beta1.SpiderIPPool
+		var node *corev1.Node
+
+		BeforeEach(func() {
+			pool = &spiderpoolv2beta1.SpiderIPPool{}
+			pool.SetName("pool")
+			pool.Spec.Gateway = pointer.String("172.18.40.1")
+			pool.Spec.GatewayOverrides = []spiderpoolv2beta1.GatewayOverride{
+				{
+					NodeName: []string{"node1"},
+					Gateway:  "172.18.40.2",
+				},
+				{
+					NodeSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"rack": "b"},
+					},
+					Gateway: "172.18.40.3",
+				},
+			}
+
+			node = &corev1.Node{}
+			node.SetName("node2")
+			node.SetLabels(map[string]string{"rack": "a"})
+		})
+
+		It("falls back to 'spec.gateway' if no override matches", func() {
+			gateway, err := GatewayOfNode(pool, node)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gateway).To(Equal("172.18.40.1"))
+		})
+
+		It("uses the gateway of the matched override", func() {
+			node.SetName("node1")
+			gateway, err := GatewayOfNode(pool, node)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gateway).To(Equal("172.18.40.2"))
+
+			node.SetName("node2")
+			node.SetLabels(map[string]string{"rack": "b"})
+			gateway, err = GatewayOfNode(pool, node)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gateway).To(Equal("172.18.40.3"))
+		})
+
+		It("refuses the ambiguous overrides", func() {
+			node.SetName("node1")
+			node.SetLabels(map[string]string{"rack": "b"})
+			_, err := GatewayOfNode(pool, node)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns all the gateways of the IPPool", func() {
+			Expect(PoolGateways(pool)).To(Equal([]string{"172.18.40.1", "172.18.40.2", "172.18.40.3"}))
+
+			pool.Spec.Gateway = nil
+			Expect(PoolGateways(pool)).To(Equal([]string{"172.18.40.2", "172.18.40.3"}))
+		})
 	})
 })
@@ -32,6 +32,45 @@ type CoordinatorSpec struct {
 	// +kubebuilder:validation:Optional
 	PodDefaultRouteNIC *string `json:"podDefaultRouteNIC,omitempty"`
 
+	// RouteTableMode decides whether the routes of the interface are moved
+	// or copied to the policy route table
+	// +kubebuilder:validation:Enum=move;copy
+	// +kubebuilder:validation:Optional
+	RouteTableMode *string `json:"routeTableMode,omitempty"`
+
+	// EnableReplyViaVeth makes the reply packets of the traffic from the node,
+	// such as hostPort and NodePort, forwarded through veth0. underlay mode only
+	// +kubebuilder:validation:Optional
+	EnableReplyViaVeth *bool `json:"enableReplyViaVeth,omitempty"`
+
+	// EnableProxyNDP enables proxy_arp on the host side of the veth and adds
+	// the proxy neighbor entries of the pod's IPv6 addresses, so that the node
+	// answers the ARP and the neighbor solicitation for the pod. underlay mode only
+	// +kubebuilder:validation:Optional
+	EnableProxyNDP *bool `json:"enableProxyNDP,omitempty"`
+
+	// DefaultRouteMode decides how the default route is set up while the pod has
+	// multiple NICs. primaryBackup: the default route is on podDefaultRouteNIC;
+	// loadBalance: the default route is balanced across the NICs by defaultRouteWeight
+	// +kubebuilder:validation:Enum=primaryBackup;loadBalance
+	// +kubebuilder:validation:Optional
+	DefaultRouteMode *string `json:"defaultRouteMode,omitempty"`
+
+	// DefaultRouteWeight is the weight of the NIC in the default route while
+	// defaultRouteMode is loadBalance
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=256
+	// +kubebuilder:validation:Optional
+	DefaultRouteWeight *int `json:"defaultRouteWeight,omitempty"`
+
+	// HijackRouteMTU is the MTU of the routes to the hijackCIDR, serviceCIDR
+	// and overlayPodCIDR via the veth, such as for the overlay network with
+	// vxlan, 0 means unset
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:validation:Optional
+	HijackRouteMTU *int `json:"hijackRouteMTU,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	HostRuleTable *int `json:"hostRuleTable,omitempty"`
 
@@ -43,6 +82,13 @@ type CoordinatorSpec struct {
 
 	// +kubebuilder:validation:Optional
 	DetectGateway *bool `json:"detectGateway,omitempty"`
+
+	// DetectGatewayMode decides how detectGateway probes the gateway. arp:
+	// resolve the gateway by ARP for IPv4 or the neighbor solicitation for IPv6;
+	// icmp: ping the gateway; both: the gateway must answer both of them
+	// +kubebuilder:validation:Enum=arp;icmp;both
+	// +kubebuilder:validation:Optional
+	DetectGatewayMode *string `json:"detectGatewayMode,omitempty"`
 }
 
 // CoordinationStatus defines the observed state of SpiderCoordinator.
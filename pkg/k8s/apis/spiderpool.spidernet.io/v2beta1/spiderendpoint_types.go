@@ -12,6 +12,11 @@ type WorkloadEndpointStatus struct {
 	// +kubebuilder:validation:Required
 	Current PodIPAllocation `json:"current"`
 
+	// History is the previous IP allocations of the pod, the latest first.
+	// +kubebuilder:validation:MaxItems=20
+	// +kubebuilder:validation:Optional
+	History []PodIPAllocationRecord `json:"history,omitempty"`
+
 	// +kubebuilder:validation:Required
 	OwnerControllerType string `json:"ownerControllerType"`
 
@@ -28,6 +33,31 @@ type PodIPAllocation struct {
 
 	// +kubebuilder:validation:Required
 	IPs []IPAllocationDetail `json:"ips"`
+
+	// +kubebuilder:validation:Optional
+	AllocatedTime *metav1.Time `json:"allocatedTime,omitempty"`
+}
+
+// PodIPAllocationRecord is a released IP allocation of the pod, the routes of
+// the allocation are not recorded.
+type PodIPAllocationRecord struct {
+	// +kubebuilder:validation:Required
+	UID string `json:"uid"`
+
+	// +kubebuilder:validation:Required
+	Node string `json:"node"`
+
+	// +kubebuilder:validation:Required
+	IPs []IPAllocationDetail `json:"ips"`
+
+	// +kubebuilder:validation:Optional
+	AllocatedTime *metav1.Time `json:"allocatedTime,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	ReleasedTime *metav1.Time `json:"releasedTime,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	ReleaseReason string `json:"releaseReason,omitempty"`
 }
 
 type IPAllocationDetail struct {
@@ -63,6 +93,10 @@ type IPAllocationDetail struct {
 
 	// +kubebuilder:validation:Optional
 	Routes []Route `json:"routes,omitempty"`
+
+	// MAC is the MAC address assigned to the interface by the podMACPrefix
+	// +kubebuilder:validation:Optional
+	MAC *string `json:"mac,omitempty"`
 }
 
 // +kubebuilder:resource:categories={spiderpool},path="spiderendpoints",scope="Namespaced",shortName={se},singular="spiderendpoint"
@@ -25,6 +25,11 @@ type IPPoolSpec struct {
 	// +kubebuilder:validation:Optional
 	Gateway *string `json:"gateway,omitempty"`
 
+	// GatewayOverrides replace 'spec.gateway' for the pods on the selected
+	// nodes, at most one override is allowed to match a node.
+	// +kubebuilder:validation:Optional
+	GatewayOverrides []GatewayOverride `json:"gatewayOverrides,omitempty"`
+
 	// +kubebuilder:default=0
 	// +kubebuilder:validation:Maximum=4094
 	// +kubebuilder:validation:Minimum=0
@@ -59,6 +64,25 @@ type IPPoolSpec struct {
 	// +kubebuilder:default=false
 	// +kubebuilder:validation:Optional
 	Disable *bool `json:"disable,omitempty"`
+
+	// Priority orders the IPPool among the candidates of a NIC, the IPPool
+	// with the higher priority is tried first.
+	// +kubebuilder:default=0
+	// +kubebuilder:validation:Optional
+	Priority *int32 `json:"priority,omitempty"`
+}
+
+// GatewayOverride is the gateway of the pods on the nodes selected by the
+// node names or the node selector.
+type GatewayOverride struct {
+	// +kubebuilder:validation:Optional
+	NodeName []string `json:"nodeName,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+
+	// +kubebuilder:validation:Required
+	Gateway string `json:"gateway"`
 }
 
 type Route struct {
@@ -67,6 +91,12 @@ type Route struct {
 
 	// +kubebuilder:validation:Required
 	Gw string `json:"gw"`
+
+	// Table is the route table of the route in the pod, it is "main",
+	// "interface" (the policy routing table of the NIC created by coordinator)
+	// or a table number from 1 to 252. The route is left as it is by default.
+	// +kubebuilder:validation:Optional
+	Table string `json:"table,omitempty"`
 }
 
 // IPPoolStatus defines the observed state of SpiderIPPool.
@@ -81,6 +111,19 @@ type IPPoolStatus struct {
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Optional
 	AllocatedIPCount *int64 `json:"allocatedIPCount,omitempty"`
+
+	// ReleasedIPs are the IP addresses released within the quarantine window,
+	// which are not reused until the window passes
+	// +kubebuilder:validation:Optional
+	ReleasedIPs *string `json:"releasedIPs,omitempty"`
+
+	// Conditions reports the problems found by the controller, such as the
+	// ImplicitExcludedIPAllocated for the gateway, the all-zeros or the
+	// broadcast IP address allocated before they were excluded.
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // PoolIPAllocations is a map of IP allocation details indexed by IP address.
@@ -92,6 +135,16 @@ type PoolIPAllocation struct {
 	PodUID         string `json:"podUid"`
 }
 
+// PoolIPReleases is a map of IP release details indexed by IP address.
+type PoolIPReleases map[string]PoolIPRelease
+
+type PoolIPRelease struct {
+	// NamespacedName is the pod the IP address was allocated to
+	NamespacedName string `json:"pod"`
+	// ReleaseTime is the unix time in seconds when the IP address was released
+	ReleaseTime int64 `json:"releaseTime"`
+}
+
 // +kubebuilder:resource:categories={spiderpool},path="spiderippools",scope="Cluster",shortName={sp},singular="spiderippool"
 // +kubebuilder:printcolumn:JSONPath=".spec.ipVersion",description="ipVersion",name="VERSION",type=string
 // +kubebuilder:printcolumn:JSONPath=".spec.subnet",description="subnet",name="SUBNET",type=string
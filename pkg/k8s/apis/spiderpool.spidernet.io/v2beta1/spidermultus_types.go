@@ -7,14 +7,17 @@ import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 // +kubebuilder:resource:categories={spiderpool},path="spidermultusconfigs",scope="Namespaced",shortName={smc},singular="spidermultusconfig"
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 // +genclient
-// +genclient:noStatus
 type SpiderMultusConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	// Spec is the specification of the MultusCNIConfig
 	Spec MultusCNIConfigSpec `json:"spec,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	Status MultusCNIConfigStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -51,6 +54,13 @@ type MultusCNIConfigSpec struct {
 	// +kubebuilder:validation:Optional
 	DisableIPAM *bool `json:"disableIPAM"`
 
+	// EnableVlanAutoCreate makes the ifacer plugin create the missing VLAN
+	// sub-interface of the macvlan or ipvlan master at the pod setup, and the
+	// bond of the multiple masters. Otherwise, they must exist on the nodes.
+	// +kubebuilder:default=true
+	// +kubebuilder:validation:Optional
+	EnableVlanAutoCreate *bool `json:"enableVlanAutoCreate,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	CoordinatorConfig *CoordinatorSpec `json:"coordinator,omitempty"`
 
@@ -59,6 +69,16 @@ type MultusCNIConfigSpec struct {
 	CustomCNIConfig *string `json:"customCNI,omitempty"`
 }
 
+// MultusCNIConfigStatus defines the observed state of SpiderMultusConfig.
+type MultusCNIConfigStatus struct {
+	// Conditions reports the problems found by the controller, such as the
+	// VlanConflict with another SpiderMultusConfig sharing the master.
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
 type SpiderMacvlanCniConfig struct {
 	// +kubebuilder:validation:Required
 	Master []string `json:"master"`
@@ -73,6 +93,12 @@ type SpiderMacvlanCniConfig struct {
 
 	// +kubebuilder:validation:Optional
 	SpiderpoolConfigPools *SpiderpoolPools `json:"ippools,omitempty"`
+
+	// EnsurePromisc makes the spiderpool-agent keep the master interface in the
+	// promiscuous mode on each node
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	EnsurePromisc *bool `json:"ensurePromisc,omitempty"`
 }
 
 type SpiderIPvlanCniConfig struct {
@@ -36,6 +36,7 @@ func (in *IPPoolSpec) String() string {
 		`Subnet:` + fmt.Sprintf("%v", in.Subnet) + `,`,
 		`IPs:` + fmt.Sprintf("%v", in.IPs) + `,`,
 		`Disable:` + stringutil.ValueToStringGenerated(in.Disable) + `,`,
+		`Priority:` + stringutil.ValueToStringGenerated(in.Priority) + `,`,
 		`ExcludeIPs:` + fmt.Sprintf("%v", in.ExcludeIPs) + `,`,
 		`Gateway:` + stringutil.ValueToStringGenerated(in.Gateway) + `,`,
 		`Vlan:` + stringutil.ValueToStringGenerated(in.Vlan) + `,`,
@@ -58,6 +59,7 @@ func (in *IPPoolStatus) String() string {
 		`AllocatedIPs:` + stringutil.ValueToStringGenerated(in.AllocatedIPs) + `,`,
 		`TotalIPCount:` + stringutil.ValueToStringGenerated(in.TotalIPCount) + `,`,
 		`AllocatedIPCount:` + stringutil.ValueToStringGenerated(in.AllocatedIPCount) + `,`,
+		`ReleasedIPs:` + stringutil.ValueToStringGenerated(in.ReleasedIPs) + `,`,
 		`}`,
 	}, "")
 	return s
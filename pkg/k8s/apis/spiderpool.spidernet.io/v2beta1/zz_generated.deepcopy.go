@@ -66,6 +66,36 @@ func (in *CoordinatorSpec) DeepCopyInto(out *CoordinatorSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.RouteTableMode != nil {
+		in, out := &in.RouteTableMode, &out.RouteTableMode
+		*out = new(string)
+		**out = **in
+	}
+	if in.EnableReplyViaVeth != nil {
+		in, out := &in.EnableReplyViaVeth, &out.EnableReplyViaVeth
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnableProxyNDP != nil {
+		in, out := &in.EnableProxyNDP, &out.EnableProxyNDP
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DefaultRouteMode != nil {
+		in, out := &in.DefaultRouteMode, &out.DefaultRouteMode
+		*out = new(string)
+		**out = **in
+	}
+	if in.DefaultRouteWeight != nil {
+		in, out := &in.DefaultRouteWeight, &out.DefaultRouteWeight
+		*out = new(int)
+		**out = **in
+	}
+	if in.HijackRouteMTU != nil {
+		in, out := &in.HijackRouteMTU, &out.HijackRouteMTU
+		*out = new(int)
+		**out = **in
+	}
 	if in.HostRuleTable != nil {
 		in, out := &in.HostRuleTable, &out.HostRuleTable
 		*out = new(int)
@@ -86,6 +116,11 @@ func (in *CoordinatorSpec) DeepCopyInto(out *CoordinatorSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.DetectGatewayMode != nil {
+		in, out := &in.DetectGatewayMode, &out.DetectGatewayMode
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CoordinatorSpec.
@@ -123,6 +158,31 @@ func (in *CoordinatorStatus) DeepCopy() *CoordinatorStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayOverride) DeepCopyInto(out *GatewayOverride) {
+	*out = *in
+	if in.NodeName != nil {
+		in, out := &in.NodeName, &out.NodeName
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayOverride.
+func (in *GatewayOverride) DeepCopy() *GatewayOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IPAllocationDetail) DeepCopyInto(out *IPAllocationDetail) {
 	*out = *in
@@ -171,6 +231,11 @@ func (in *IPAllocationDetail) DeepCopyInto(out *IPAllocationDetail) {
 		*out = make([]Route, len(*in))
 		copy(*out, *in)
 	}
+	if in.MAC != nil {
+		in, out := &in.MAC, &out.MAC
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPAllocationDetail.
@@ -206,6 +271,13 @@ func (in *IPPoolSpec) DeepCopyInto(out *IPPoolSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.GatewayOverrides != nil {
+		in, out := &in.GatewayOverrides, &out.GatewayOverrides
+		*out = make([]GatewayOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Vlan != nil {
 		in, out := &in.Vlan, &out.Vlan
 		*out = new(int64)
@@ -256,6 +328,11 @@ func (in *IPPoolSpec) DeepCopyInto(out *IPPoolSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPPoolSpec.
@@ -286,6 +363,18 @@ func (in *IPPoolStatus) DeepCopyInto(out *IPPoolStatus) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.ReleasedIPs != nil {
+		in, out := &in.ReleasedIPs, &out.ReleasedIPs
+		*out = new(string)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPPoolStatus.
@@ -331,6 +420,11 @@ func (in *MultusCNIConfigSpec) DeepCopyInto(out *MultusCNIConfigSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.EnableVlanAutoCreate != nil {
+		in, out := &in.EnableVlanAutoCreate, &out.EnableVlanAutoCreate
+		*out = new(bool)
+		**out = **in
+	}
 	if in.CoordinatorConfig != nil {
 		in, out := &in.CoordinatorConfig, &out.CoordinatorConfig
 		*out = new(CoordinatorSpec)
@@ -353,6 +447,28 @@ func (in *MultusCNIConfigSpec) DeepCopy() *MultusCNIConfigSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultusCNIConfigStatus) DeepCopyInto(out *MultusCNIConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultusCNIConfigStatus.
+func (in *MultusCNIConfigStatus) DeepCopy() *MultusCNIConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MultusCNIConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodIPAllocation) DeepCopyInto(out *PodIPAllocation) {
 	*out = *in
@@ -363,6 +479,10 @@ func (in *PodIPAllocation) DeepCopyInto(out *PodIPAllocation) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AllocatedTime != nil {
+		in, out := &in.AllocatedTime, &out.AllocatedTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodIPAllocation.
@@ -375,6 +495,36 @@ func (in *PodIPAllocation) DeepCopy() *PodIPAllocation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodIPAllocationRecord) DeepCopyInto(out *PodIPAllocationRecord) {
+	*out = *in
+	if in.IPs != nil {
+		in, out := &in.IPs, &out.IPs
+		*out = make([]IPAllocationDetail, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AllocatedTime != nil {
+		in, out := &in.AllocatedTime, &out.AllocatedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ReleasedTime != nil {
+		in, out := &in.ReleasedTime, &out.ReleasedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodIPAllocationRecord.
+func (in *PodIPAllocationRecord) DeepCopy() *PodIPAllocationRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(PodIPAllocationRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PoolIPAllocation) DeepCopyInto(out *PoolIPAllocation) {
 	*out = *in
@@ -411,6 +561,42 @@ func (in PoolIPAllocations) DeepCopy() PoolIPAllocations {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolIPRelease) DeepCopyInto(out *PoolIPRelease) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolIPRelease.
+func (in *PoolIPRelease) DeepCopy() *PoolIPRelease {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolIPRelease)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in PoolIPReleases) DeepCopyInto(out *PoolIPReleases) {
+	{
+		in := &in
+		*out = make(PoolIPReleases, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolIPReleases.
+func (in PoolIPReleases) DeepCopy() PoolIPReleases {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolIPReleases)
+	in.DeepCopyInto(out)
+	return *out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PoolIPPreAllocation) DeepCopyInto(out *PoolIPPreAllocation) {
 	*out = *in
@@ -731,6 +917,11 @@ func (in *SpiderMacvlanCniConfig) DeepCopyInto(out *SpiderMacvlanCniConfig) {
 		*out = new(SpiderpoolPools)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.EnsurePromisc != nil {
+		in, out := &in.EnsurePromisc, &out.EnsurePromisc
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpiderMacvlanCniConfig.
@@ -749,6 +940,7 @@ func (in *SpiderMultusConfig) DeepCopyInto(out *SpiderMultusConfig) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpiderMultusConfig.
@@ -1113,6 +1305,13 @@ func (in *Trunk) DeepCopy() *Trunk {
 func (in *WorkloadEndpointStatus) DeepCopyInto(out *WorkloadEndpointStatus) {
 	*out = *in
 	in.Current.DeepCopyInto(&out.Current)
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]PodIPAllocationRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadEndpointStatus.
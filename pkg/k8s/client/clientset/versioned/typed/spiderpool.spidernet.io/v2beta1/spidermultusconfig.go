@@ -27,6 +27,7 @@ type SpiderMultusConfigsGetter interface {
 type SpiderMultusConfigInterface interface {
 	Create(ctx context.Context, spiderMultusConfig *v2beta1.SpiderMultusConfig, opts v1.CreateOptions) (*v2beta1.SpiderMultusConfig, error)
 	Update(ctx context.Context, spiderMultusConfig *v2beta1.SpiderMultusConfig, opts v1.UpdateOptions) (*v2beta1.SpiderMultusConfig, error)
+	UpdateStatus(ctx context.Context, spiderMultusConfig *v2beta1.SpiderMultusConfig, opts v1.UpdateOptions) (*v2beta1.SpiderMultusConfig, error)
 	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
 	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
 	Get(ctx context.Context, name string, opts v1.GetOptions) (*v2beta1.SpiderMultusConfig, error)
@@ -122,6 +123,22 @@ func (c *spiderMultusConfigs) Update(ctx context.Context, spiderMultusConfig *v2
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *spiderMultusConfigs) UpdateStatus(ctx context.Context, spiderMultusConfig *v2beta1.SpiderMultusConfig, opts v1.UpdateOptions) (result *v2beta1.SpiderMultusConfig, err error) {
+	result = &v2beta1.SpiderMultusConfig{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("spidermultusconfigs").
+		Name(spiderMultusConfig.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(spiderMultusConfig).
+		Do(ctx).
+		Into(result)
+	return
+}
+
 // Delete takes name of the spiderMultusConfig and deletes it. Returns an error if one occurs.
 func (c *spiderMultusConfigs) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	return c.client.Delete().
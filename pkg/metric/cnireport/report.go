@@ -0,0 +1,196 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cnireport passes the latency and the warnings of the CNI plugin
+// invocations to the spiderpool-agent. The plugin process is too short-lived to
+// serve metrics, so it writes a report file to a node-local directory, which is
+// scraped by the agent and exported from the agent's metrics endpoint, and the
+// warnings are emitted as the events of the pod.
+package cnireport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	CmdAdd   = "add"
+	CmdDel   = "del"
+	CmdCheck = "check"
+
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+const (
+	reportSuffix = ".json"
+	tmpPrefix    = "."
+	// maxPendingReports bounds the directory if the agent stops scraping it
+	maxPendingReports = 1000
+	// the partial reports left by the crashed invocations are removed after staleTmpAge
+	staleTmpAge = time.Minute
+)
+
+// Phase is a timed step of the CNI invocation
+type Phase struct {
+	Name     string        `json:"name"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Warning is a best-effort step of the CNI invocation which failed without
+// failing the invocation, such as a route or a rule not tuned
+type Warning struct {
+	// Object is the route, rule or neighbor the step works on
+	Object string `json:"object"`
+	Error  string `json:"error"`
+}
+
+// Report is the latency of a CNI invocation
+type Report struct {
+	Cmd          string        `json:"cmd"`
+	Result       string        `json:"result"`
+	ContainerID  string        `json:"containerID"`
+	PodNamespace string        `json:"podNamespace,omitempty"`
+	PodName      string        `json:"podName,omitempty"`
+	Start        time.Time     `json:"start"`
+	Duration     time.Duration `json:"duration"`
+	Phases       []Phase       `json:"phases,omitempty"`
+	Warnings     []Warning     `json:"warnings,omitempty"`
+}
+
+// NewReport starts timing a CNI invocation
+func NewReport(cmd, containerID string) *Report {
+	return &Report{
+		Cmd:         cmd,
+		ContainerID: containerID,
+		Start:       time.Now(),
+	}
+}
+
+// StartPhase starts timing a phase, the phase is recorded when the returned
+// function is called
+func (r *Report) StartPhase(name string) func() {
+	start := time.Now()
+	return func() {
+		r.Phases = append(r.Phases, Phase{Name: name, Start: start, Duration: time.Since(start)})
+	}
+}
+
+// SetPod sets the pod of the invocation, the warnings of the report are
+// emitted as the events of the pod by the agent
+func (r *Report) SetPod(namespace, name string) {
+	r.PodNamespace = namespace
+	r.PodName = name
+}
+
+// AddWarning records a best-effort step failed on the object, it's a no-op
+// on a nil report
+func (r *Report) AddWarning(object string, err error) {
+	if r == nil {
+		return
+	}
+	r.Warnings = append(r.Warnings, Warning{Object: object, Error: err.Error()})
+}
+
+// Finish stops timing the invocation with its result
+func (r *Report) Finish(err error) {
+	r.Duration = time.Since(r.Start)
+	r.Result = ResultSuccess
+	if err != nil {
+		r.Result = ResultFailure
+	}
+}
+
+// Write writes the report to the dir. Nothing is written if the dir doesn't
+// exist, which means the agent doesn't collect the reports, or if too many
+// reports are pending.
+func Write(dir string, r *Report) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read report dir %s: %w", dir, err)
+	}
+	if len(entries) >= maxPendingReports {
+		return nil
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	// write to a hidden file and rename it, so that the agent never reads a
+	// partial report
+	f, err := os.CreateTemp(dir, tmpPrefix+r.Cmd+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	tmpName := f.Name()
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	name := filepath.Join(dir, strings.TrimPrefix(filepath.Base(tmpName), tmpPrefix)+reportSuffix)
+	if err = os.Rename(tmpName, name); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to commit report: %w", err)
+	}
+	return nil
+}
+
+// Collect passes the reports in the dir to the handler and removes them,
+// the malformed and the stale partial reports are removed too.
+func Collect(dir string, handler func(*Report)) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read report dir %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(name, tmpPrefix) {
+			if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > staleTmpAge {
+				_ = os.Remove(filepath.Join(dir, name))
+			}
+			continue
+		}
+		if !strings.HasSuffix(name, reportSuffix) {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("failed to read report %s: %w", path, err)
+		}
+		if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove report %s: %w", path, err)
+		}
+
+		var r Report
+		if err = json.Unmarshal(data, &r); err != nil {
+			continue
+		}
+		handler(&r)
+	}
+	return nil
+}
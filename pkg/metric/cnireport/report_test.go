@@ -0,0 +1,183 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package cnireport_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/event"
+	"github.com/spidernet-io/spiderpool/pkg/metric"
+	"github.com/spidernet-io/spiderpool/pkg/metric/cnireport"
+)
+
+var _ = Describe("Report", Label("report"), func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	It("passes the reports from the plugin to the collector", func() {
+		report := cnireport.NewReport(cnireport.CmdAdd, "container1")
+		endPhase := report.StartPhase("netns_enter")
+		time.Sleep(10 * time.Millisecond)
+		endPhase()
+		endPhase = report.StartPhase("route_move")
+		endPhase()
+		report.Finish(nil)
+		Expect(cnireport.Write(dir, report)).To(Succeed())
+
+		failed := cnireport.NewReport(cnireport.CmdDel, "container2")
+		failed.Finish(errors.New("failed"))
+		Expect(cnireport.Write(dir, failed)).To(Succeed())
+
+		var collected []*cnireport.Report
+		Expect(cnireport.Collect(dir, func(r *cnireport.Report) {
+			collected = append(collected, r)
+		})).To(Succeed())
+		Expect(collected).To(HaveLen(2))
+
+		for _, r := range collected {
+			switch r.Cmd {
+			case cnireport.CmdAdd:
+				Expect(r.Result).To(Equal(cnireport.ResultSuccess))
+				Expect(r.ContainerID).To(Equal("container1"))
+				Expect(r.Duration).To(BeNumerically(">=", 10*time.Millisecond))
+				Expect(r.Phases).To(HaveLen(2))
+				Expect(r.Phases[0].Name).To(Equal("netns_enter"))
+				Expect(r.Phases[0].Duration).To(BeNumerically(">=", 10*time.Millisecond))
+				Expect(r.Phases[1].Name).To(Equal("route_move"))
+			case cnireport.CmdDel:
+				Expect(r.Result).To(Equal(cnireport.ResultFailure))
+			default:
+				Fail("unexpected report " + r.Cmd)
+			}
+		}
+
+		// the collected reports are removed
+		entries, err := os.ReadDir(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("writes nothing if the directory is not created by the agent", func() {
+		missing := filepath.Join(dir, "missing")
+		report := cnireport.NewReport(cnireport.CmdCheck, "container1")
+		report.Finish(nil)
+		Expect(cnireport.Write(missing, report)).To(Succeed())
+
+		_, err := os.Stat(missing)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("removes the malformed reports and skips the partial ones", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, ".add-partial"), []byte("{"), 0o600)).To(Succeed())
+
+		called := false
+		Expect(cnireport.Collect(dir, func(*cnireport.Report) { called = true })).To(Succeed())
+		Expect(called).To(BeFalse())
+
+		_, err := os.Stat(filepath.Join(dir, "broken.json"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+		_, err = os.Stat(filepath.Join(dir, ".add-partial"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("the agent records the collected reports", func() {
+		handler, err := metric.InitMetric(context.TODO(), constant.SpiderpoolAgent, true, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(metric.InitSpiderpoolAgentMetrics(context.TODO())).To(Succeed())
+
+		report := cnireport.NewReport(cnireport.CmdAdd, "container1")
+		report.StartPhase("rule_install")()
+		report.Finish(nil)
+		Expect(cnireport.Write(dir, report)).To(Succeed())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go metric.CollectCoordinatorReports(ctx, dir, 10*time.Millisecond, nil)
+		DeferCleanup(cancel)
+
+		// the reports are removed before being recorded
+		Eventually(func() string {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			return recorder.Body.String()
+		}).Should(And(
+			MatchRegexp(`spiderpool_coordinator_cmd_duration_seconds_count\{cmd="add",.*result="success"\} 1`),
+			MatchRegexp(`spiderpool_coordinator_phase_duration_seconds_count\{cmd="add",.*phase="rule_install"\} 1`),
+		))
+
+		entries, err := os.ReadDir(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("the agent emits the warnings as the events of the pod", func() {
+		report := cnireport.NewReport(cnireport.CmdAdd, "container1")
+		report.SetPod("default", "pod1")
+		report.AddWarning("route 10.6.0.1/32 table 100", errors.New("no such process"))
+		report.Finish(nil)
+		Expect(cnireport.Write(dir, report)).To(Succeed())
+
+		// a report without warnings emits nothing
+		clean := cnireport.NewReport(cnireport.CmdAdd, "container2")
+		clean.SetPod("default", "pod2")
+		clean.Finish(nil)
+		Expect(cnireport.Write(dir, clean)).To(Succeed())
+
+		var collected []*cnireport.Report
+		Expect(cnireport.Collect(dir, func(r *cnireport.Report) {
+			collected = append(collected, r)
+		})).To(Succeed())
+		Expect(collected).To(HaveLen(2))
+
+		recorder := record.NewFakeRecorder(10)
+		emitter := event.NewCoordinatorWarningEmitter(recorder, time.Hour, nil)
+		for _, r := range collected {
+			emitter.Emit(context.TODO(), r)
+		}
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(And(
+			HavePrefix("Warning "+event.CoordinatorRouteWarningReason),
+			ContainSubstring("route 10.6.0.1/32 table 100: no such process"),
+		))
+
+		// the events of the pod are rate limited
+		Expect(emitter.Emit(context.TODO(), report)).To(BeFalse())
+		Expect(recorder.Events).To(BeEmpty())
+
+		// once the interval elapsed, the pod gets the event again
+		emitter = event.NewCoordinatorWarningEmitter(recorder, 0, nil)
+		Expect(emitter.Emit(context.TODO(), report)).To(BeTrue())
+		Expect(emitter.Emit(context.TODO(), report)).To(BeTrue())
+		Expect(recorder.Events).To(HaveLen(2))
+	})
+
+	It("keeps the warnings of the report", func() {
+		var report *cnireport.Report
+		// a nil report ignores the warnings
+		report.AddWarning("neigh 10.6.0.1", errors.New("failed"))
+
+		report = cnireport.NewReport(cnireport.CmdAdd, "container1")
+		report.SetPod("default", "pod1")
+		report.AddWarning("neigh 10.6.0.1", errors.New("failed"))
+		report.Finish(nil)
+		Expect(report.Result).To(Equal(cnireport.ResultSuccess))
+		Expect(report.PodNamespace).To(Equal("default"))
+		Expect(report.PodName).To(Equal("pod1"))
+		Expect(report.Warnings).To(Equal([]cnireport.Warning{{Object: "neigh 10.6.0.1", Error: "failed"}}))
+	})
+})
@@ -0,0 +1,79 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package metric
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	api "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/spidernet-io/spiderpool/pkg/logutils"
+	"github.com/spidernet-io/spiderpool/pkg/metric/cnireport"
+)
+
+const coordinatorTracerName = "spiderpool-coordinator"
+
+// RecordCoordinatorReport serves for the reports of the coordinator plugin
+// collected by spiderpool agent. The report is also recorded as trace spans,
+// which are dropped unless a tracer provider is registered.
+func RecordCoordinatorReport(ctx context.Context, r *cnireport.Report) {
+	if !globalEnableMetric {
+		return
+	}
+
+	cmdAttr := attribute.String("cmd", r.Cmd)
+	coordinatorCmdDurationSecondsHistogram.Record(ctx, r.Duration.Seconds(),
+		api.WithAttributes(cmdAttr, attribute.String("result", r.Result)))
+	for _, phase := range r.Phases {
+		coordinatorPhaseDurationSecondsHistogram.Record(ctx, phase.Duration.Seconds(),
+			api.WithAttributes(cmdAttr, attribute.String("phase", phase.Name)))
+	}
+
+	tracer := otel.Tracer(coordinatorTracerName)
+	spanCtx, span := tracer.Start(ctx, "coordinator "+r.Cmd,
+		trace.WithTimestamp(r.Start),
+		trace.WithAttributes(cmdAttr, attribute.String("containerID", r.ContainerID)))
+	for _, phase := range r.Phases {
+		_, phaseSpan := tracer.Start(spanCtx, phase.Name, trace.WithTimestamp(phase.Start))
+		phaseSpan.End(trace.WithTimestamp(phase.Start.Add(phase.Duration)))
+	}
+	if r.Result != cnireport.ResultSuccess {
+		span.SetStatus(codes.Error, r.Result)
+	}
+	span.End(trace.WithTimestamp(r.Start.Add(r.Duration)))
+}
+
+// CollectCoordinatorReports periodically records the reports written by the
+// coordinator plugin to the dir until the ctx is done, the reports are passed
+// to the handle as well if it's not nil.
+func CollectCoordinatorReports(ctx context.Context, dir string, interval time.Duration, handle func(ctx context.Context, r *cnireport.Report)) {
+	logger := logutils.FromContext(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := cnireport.Collect(dir, func(r *cnireport.Report) {
+			RecordCoordinatorReport(ctx, r)
+			if handle != nil {
+				handle(ctx, r)
+			}
+		})
+		if err != nil {
+			logger.Warn("failed to collect coordinator reports", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
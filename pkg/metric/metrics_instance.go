@@ -57,9 +57,19 @@ const (
 	ipam_release_latest_limit_duration_seconds  = metricPrefix + "ipam_release_latest_limit_duration_seconds"
 	ipam_release_limit_duration_seconds         = metricPrefix + "ipam_release_limit_duration_seconds"
 
+	// spiderpool agent route repair metrics name
+	route_repair_counts              = metricPrefix + "route_repair_counts"
+	route_repair_failure_counts      = metricPrefix + "route_repair_failure_counts"
+	route_repair_rate_limited_counts = metricPrefix + "route_repair_rate_limited_counts"
+
+	// spiderpool agent coordinator metrics name, reported by the coordinator plugin
+	coordinator_cmd_duration_seconds   = metricPrefix + "coordinator_cmd_duration_seconds"
+	coordinator_phase_duration_seconds = metricPrefix + "coordinator_phase_duration_seconds"
+
 	// spiderpool controller IP GC metrics name
-	ip_gc_counts         = metricPrefix + "ip_gc_counts"
-	ip_gc_failure_counts = metricPrefix + "ip_gc_failure_counts"
+	ip_gc_counts              = metricPrefix + "ip_gc_counts"
+	ip_gc_failure_counts      = metricPrefix + "ip_gc_failure_counts"
+	ip_gc_node_reclaim_counts = metricPrefix + "ip_gc_node_reclaim_counts"
 
 	// spiderpool IPPool and Subnet metrics and these include some debug level metrics
 	total_ippool_counts                   = metricPrefix + "total_ippool_counts"
@@ -109,9 +119,19 @@ var (
 	ipamReleaseLatestLimitDurationSeconds    = new(asyncFloat64Gauge)
 	ipamReleaseLimitDurationSecondsHistogram api.Float64Histogram
 
+	// route repair metrics in spiderpool-agent
+	RouteRepairCounts            api.Int64Counter
+	RouteRepairFailureCounts     api.Int64Counter
+	RouteRepairRateLimitedCounts api.Int64Counter
+
+	// coordinator metrics in spiderpool-agent
+	coordinatorCmdDurationSecondsHistogram   api.Float64Histogram
+	coordinatorPhaseDurationSecondsHistogram api.Float64Histogram
+
 	// IP GC metrics in spiderpool-controller
-	IPGCTotalCounts   api.Int64Counter
-	IPGCFailureCounts api.Int64Counter
+	IPGCTotalCounts       api.Int64Counter
+	IPGCFailureCounts     api.Int64Counter
+	IPGCNodeReclaimCounts api.Int64Counter
 
 	// IPPool&Subnet metrics in spiderpool-controller
 	TotalIPPoolCounts       = new(asyncInt64Gauge)
@@ -236,6 +256,56 @@ func InitSpiderpoolAgentMetrics(ctx context.Context) error {
 	}
 	AutoPoolWaitedForAvailableCounts = autoPoolWaitedForAvailableCounts
 
+	err = initSpiderpoolAgentRouteRepairMetrics(ctx)
+	if nil != err {
+		return err
+	}
+
+	err = initSpiderpoolAgentCoordinatorMetrics(ctx)
+	if nil != err {
+		return err
+	}
+
+	return nil
+}
+
+// initSpiderpoolAgentRouteRepairMetrics will init spiderpool-agent route repair metrics
+func initSpiderpoolAgentRouteRepairMetrics(ctx context.Context) error {
+	routeRepairCounts, err := newMetricInt64Counter(route_repair_counts, "spiderpool agent repaired routes and rules counts", false)
+	if nil != err {
+		return fmt.Errorf("failed to new spiderpool agent metric '%s', error: %v", route_repair_counts, err)
+	}
+	RouteRepairCounts = routeRepairCounts
+
+	routeRepairFailureCounts, err := newMetricInt64Counter(route_repair_failure_counts, "spiderpool agent route and rule repair failure counts", false)
+	if nil != err {
+		return fmt.Errorf("failed to new spiderpool agent metric '%s', error: %v", route_repair_failure_counts, err)
+	}
+	RouteRepairFailureCounts = routeRepairFailureCounts
+
+	routeRepairRateLimitedCounts, err := newMetricInt64Counter(route_repair_rate_limited_counts, "spiderpool agent rate limited route and rule repair counts", false)
+	if nil != err {
+		return fmt.Errorf("failed to new spiderpool agent metric '%s', error: %v", route_repair_rate_limited_counts, err)
+	}
+	RouteRepairRateLimitedCounts = routeRepairRateLimitedCounts
+
+	return nil
+}
+
+// initSpiderpoolAgentCoordinatorMetrics will init spiderpool-agent coordinator metrics
+func initSpiderpoolAgentCoordinatorMetrics(ctx context.Context) error {
+	cmdHistogram, err := newMetricFloat64Histogram(coordinator_cmd_duration_seconds, "histogram of coordinator plugin command duration", false)
+	if nil != err {
+		return fmt.Errorf("failed to new spiderpool agent metric '%s', error: %v", coordinator_cmd_duration_seconds, err)
+	}
+	coordinatorCmdDurationSecondsHistogram = cmdHistogram
+
+	phaseHistogram, err := newMetricFloat64Histogram(coordinator_phase_duration_seconds, "histogram of coordinator plugin command phase duration", false)
+	if nil != err {
+		return fmt.Errorf("failed to new spiderpool agent metric '%s', error: %v", coordinator_phase_duration_seconds, err)
+	}
+	coordinatorPhaseDurationSecondsHistogram = phaseHistogram
+
 	return nil
 }
 
@@ -251,6 +321,11 @@ func InitSpiderpoolControllerMetrics(ctx context.Context) error {
 		return err
 	}
 
+	err = initSpiderpoolControllerIPPoolCapacityMetrics(ctx)
+	if nil != err {
+		return err
+	}
+
 	return nil
 }
 
@@ -492,6 +567,12 @@ func initSpiderpoolControllerGCMetrics(ctx context.Context) error {
 	IPGCFailureCounts = ipGCFailureCounts
 	ipGCFailureCounts.Add(ctx, 0)
 
+	ipGCNodeReclaimCounts, err := newMetricInt64Counter(ip_gc_node_reclaim_counts, "spiderpool controller ip gc counts of the pods on the deleted or unreachable nodes", false)
+	if nil != err {
+		return fmt.Errorf("failed to new spiderpool controller metric '%s', error: %v", ip_gc_node_reclaim_counts, err)
+	}
+	IPGCNodeReclaimCounts = ipGCNodeReclaimCounts
+
 	releaseUpdateIPPoolConflictCounts, err := newMetricInt64Counter(ipam_release_update_ippool_conflict_counts, "spiderpool controller gc release update IPPool conflict counts", false)
 	if nil != err {
 		return fmt.Errorf("failed to new spiderpool agent metric '%s', error: %v", ipam_release_update_ippool_conflict_counts, err)
@@ -0,0 +1,134 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package metric
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+
+	"github.com/spidernet-io/spiderpool/pkg/lock"
+)
+
+const (
+	// spiderpool controller IPPool capacity metrics name
+	ippool_total_ips     = metricPrefix + "ippool_total_ips"
+	ippool_allocated_ips = metricPrefix + "ippool_allocated_ips"
+	ippool_free_ips      = metricPrefix + "ippool_free_ips"
+	subnet_total_ips     = metricPrefix + "subnet_total_ips"
+	subnet_allocated_ips = metricPrefix + "subnet_allocated_ips"
+	subnet_free_ips      = metricPrefix + "subnet_free_ips"
+)
+
+// ipPoolCapacity is the IPs of an IPPool, the subnet is the owner SpiderSubnet
+// and may be empty.
+type ipPoolCapacity struct {
+	family    string
+	subnet    string
+	total     int64
+	allocated int64
+}
+
+// ipPoolCapacities holds the IPs of each IPPool recorded from the IPPool
+// informer, so that the scrape never lists the IPPools.
+var ipPoolCapacities = struct {
+	lock.RWMutex
+	pools map[string]ipPoolCapacity
+}{pools: map[string]ipPoolCapacity{}}
+
+// RecordIPPoolCapacity records the total and allocated IPs of the IPPool, the
+// family is IPv4 or IPv6, and the subnet is the owner SpiderSubnet or empty.
+func RecordIPPoolCapacity(pool, family, subnet string, total, allocated int64) {
+	ipPoolCapacities.Lock()
+	ipPoolCapacities.pools[pool] = ipPoolCapacity{
+		family:    family,
+		subnet:    subnet,
+		total:     total,
+		allocated: allocated,
+	}
+	ipPoolCapacities.Unlock()
+}
+
+// DeleteIPPoolCapacity removes the series of the deleted IPPool.
+func DeleteIPPoolCapacity(pool string) {
+	ipPoolCapacities.Lock()
+	delete(ipPoolCapacities.pools, pool)
+	ipPoolCapacities.Unlock()
+}
+
+// ResetIPPoolCapacity removes the series of all IPPools, it's used before the
+// IPPool informer lists them again.
+func ResetIPPoolCapacity() {
+	ipPoolCapacities.Lock()
+	ipPoolCapacities.pools = map[string]ipPoolCapacity{}
+	ipPoolCapacities.Unlock()
+}
+
+// initSpiderpoolControllerIPPoolCapacityMetrics registers the gauges of each
+// IPPool and the rollup of the IPPools owned by each SpiderSubnet.
+func initSpiderpoolControllerIPPoolCapacityMetrics(ctx context.Context) error {
+	gauges := map[string]string{
+		ippool_total_ips:     "spiderpool single SpiderIPPool total IPs",
+		ippool_allocated_ips: "spiderpool single SpiderIPPool allocated IPs",
+		ippool_free_ips:      "spiderpool single SpiderIPPool free IPs",
+		subnet_total_ips:     "spiderpool total IPs of the SpiderIPPools owned by a single SpiderSubnet",
+		subnet_allocated_ips: "spiderpool allocated IPs of the SpiderIPPools owned by a single SpiderSubnet",
+		subnet_free_ips:      "spiderpool free IPs of the SpiderIPPools owned by a single SpiderSubnet",
+	}
+	instruments := map[string]api.Int64ObservableGauge{}
+	observables := make([]api.Observable, 0, len(gauges))
+	for name, description := range gauges {
+		gauge, err := newMetricInt64Gauge(name, description, false)
+		if nil != err {
+			return fmt.Errorf("failed to new spiderpool controller metric '%s', error: %v", name, err)
+		}
+		instruments[name] = gauge
+		observables = append(observables, gauge)
+	}
+
+	_, err := meter.RegisterCallback(func(_ context.Context, observer api.Observer) error {
+		ipPoolCapacities.RLock()
+		defer ipPoolCapacities.RUnlock()
+
+		type subnetKey struct{ subnet, family string }
+		subnets := map[subnetKey]ipPoolCapacity{}
+		for pool, c := range ipPoolCapacities.pools {
+			attrs := api.WithAttributes(
+				attribute.String("pool", pool),
+				attribute.String("family", c.family),
+				attribute.String("subnet", c.subnet),
+			)
+			observer.ObserveInt64(instruments[ippool_total_ips], c.total, attrs)
+			observer.ObserveInt64(instruments[ippool_allocated_ips], c.allocated, attrs)
+			observer.ObserveInt64(instruments[ippool_free_ips], c.total-c.allocated, attrs)
+
+			if c.subnet == "" {
+				continue
+			}
+			key := subnetKey{subnet: c.subnet, family: c.family}
+			rollup := subnets[key]
+			rollup.total += c.total
+			rollup.allocated += c.allocated
+			subnets[key] = rollup
+		}
+
+		for key, c := range subnets {
+			attrs := api.WithAttributes(
+				attribute.String("subnet", key.subnet),
+				attribute.String("family", key.family),
+			)
+			observer.ObserveInt64(instruments[subnet_total_ips], c.total, attrs)
+			observer.ObserveInt64(instruments[subnet_allocated_ips], c.allocated, attrs)
+			observer.ObserveInt64(instruments[subnet_free_ips], c.total-c.allocated, attrs)
+		}
+		return nil
+	}, observables...)
+	if nil != err {
+		return fmt.Errorf("failed to register callback for spiderpool IPPool capacity metrics, error: %v", err)
+	}
+
+	return nil
+}
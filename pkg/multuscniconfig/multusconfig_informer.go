@@ -9,12 +9,15 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	"go.uber.org/zap"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	ktypes "k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -161,6 +164,10 @@ func (mcc *MultusConfigController) Run(stopCh <-chan struct{}) error {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
+	// the webhook refuses the new collisions, but the ones created before it
+	// are only reported
+	mcc.reportVlanConflicts(context.TODO())
+
 	for i := 0; i < mcc.ControllerWorkers; i++ {
 		informerLogger.Sugar().Debugf("Starting MultusConfig processing worker %d", i)
 		go wait.Until(mcc.runWorker, 1*time.Second, stopCh)
@@ -171,6 +178,48 @@ func (mcc *MultusConfigController) Run(stopCh <-chan struct{}) error {
 	return nil
 }
 
+// reportVlanConflicts sets the VlanConflict condition on the
+// SpiderMultusConfigs using the same VLAN interface as another one, and
+// clears the condition of the ones no longer colliding.
+func (mcc *MultusConfigController) reportVlanConflicts(ctx context.Context) {
+	multusConfigs, err := mcc.multusConfigLister.List(labels.Everything())
+	if nil != err {
+		informerLogger.Sugar().Errorf("failed to list MultusConfigs to check VLAN conflicts: %v", err)
+		return
+	}
+
+	for _, multusConfig := range multusConfigs {
+		conflicts := findVlanConflicts(multusConfig, multusConfigs)
+		condition := metav1.Condition{
+			Type:               ConditionVlanConflict,
+			ObservedGeneration: multusConfig.Generation,
+		}
+		if len(conflicts) != 0 {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = ReasonVlanIDCollision
+			condition.Message = fmt.Sprintf("the VLAN interface %s is also used by SpiderMultusConfig %s",
+				vlanInterfaceName(multusConfig), strings.Join(conflicts, ", "))
+			informerLogger.Sugar().Warnf("MultusConfig %s/%s: %s", multusConfig.Namespace, multusConfig.Name, condition.Message)
+		} else {
+			if apimeta.FindStatusCondition(multusConfig.Status.Conditions, ConditionVlanConflict) == nil {
+				continue
+			}
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = ReasonNoVlanCollision
+		}
+
+		newMultusConfig := multusConfig.DeepCopy()
+		apimeta.SetStatusCondition(&newMultusConfig.Status.Conditions, condition)
+		if reflect.DeepEqual(newMultusConfig.Status, multusConfig.Status) {
+			continue
+		}
+		if err := mcc.client.Status().Patch(ctx, newMultusConfig, client.MergeFrom(multusConfig)); nil != err {
+			informerLogger.Sugar().Errorf("failed to update the %s condition of MultusConfig %s/%s: %v",
+				ConditionVlanConflict, multusConfig.Namespace, multusConfig.Name, err)
+		}
+	}
+}
+
 func (mcc *MultusConfigController) runWorker() {
 	for mcc.processNextWorkItem() {
 	}
@@ -360,6 +409,9 @@ func generateNetAttachDef(netAttachName string, multusConf *spiderpoolv2beta1.Sp
 		disableIPAM = true
 	}
 
+	// the VLAN sub-interface is created by ifacer unless it's disabled explicitly
+	enableVlanAutoCreate := multusConfSpec.EnableVlanAutoCreate == nil || *multusConfSpec.EnableVlanAutoCreate
+
 	// we'll use the default CNI version 0.3.1 if the annotation doesn't have it.
 	// the annotation custom CNI version is already validated by webhook.
 	cniVersion := cmd.CniVersion031
@@ -374,7 +426,7 @@ func generateNetAttachDef(netAttachName string, multusConf *spiderpoolv2beta1.Sp
 		macvlanCNIConf := generateMacvlanCNIConf(disableIPAM, *multusConfSpec)
 		// head insertion
 		plugins = append([]interface{}{macvlanCNIConf}, plugins...)
-		if multusConfSpec.MacvlanConfig.VlanID != nil && *multusConfSpec.MacvlanConfig.VlanID != 0 {
+		if enableVlanAutoCreate && multusConfSpec.MacvlanConfig.VlanID != nil && *multusConfSpec.MacvlanConfig.VlanID != 0 {
 			// we need to set Subvlan as first at the CNI plugin chain
 			subVlanCNIConf := generateIfacer(multusConfSpec.MacvlanConfig.Master,
 				*multusConfSpec.MacvlanConfig.VlanID,
@@ -390,7 +442,7 @@ func generateNetAttachDef(netAttachName string, multusConf *spiderpoolv2beta1.Sp
 		ipvlanCNIConf := generateIPvlanCNIConf(disableIPAM, *multusConfSpec)
 		// head insertion
 		plugins = append([]interface{}{ipvlanCNIConf}, plugins...)
-		if multusConfSpec.IPVlanConfig.VlanID != nil && *multusConfSpec.IPVlanConfig.VlanID != 0 {
+		if enableVlanAutoCreate && multusConfSpec.IPVlanConfig.VlanID != nil && *multusConfSpec.IPVlanConfig.VlanID != 0 {
 			// we need to set Subvlan as first at the CNI plugin chain
 			subVlanCNIConf := generateIfacer(multusConfSpec.IPVlanConfig.Master,
 				*multusConfSpec.IPVlanConfig.VlanID,
@@ -452,27 +504,33 @@ func generateNetAttachDef(netAttachName string, multusConf *spiderpoolv2beta1.Sp
 	return netAttachDef, nil
 }
 
-func generateMacvlanCNIConf(disableIPAM bool, multusConfSpec spiderpoolv2beta1.MultusCNIConfigSpec) interface{} {
+// MacvlanMasterName returns the name of the master interface of the generated
+// macvlan CNI configuration, the bond is the master if there are multiple
+// interfaces, and the vlan interface is the master if the vlanID is set.
+func MacvlanMasterName(macvlanConfig *spiderpoolv2beta1.SpiderMacvlanCniConfig) string {
 	var masterName string
 
 	// choose interface basement name
-	if len(multusConfSpec.MacvlanConfig.Master) == 1 {
-		masterName = multusConfSpec.MacvlanConfig.Master[0]
-	} else {
-		masterName = multusConfSpec.MacvlanConfig.Bond.Name
+	if len(macvlanConfig.Master) == 1 {
+		masterName = macvlanConfig.Master[0]
+	} else if macvlanConfig.Bond != nil {
+		masterName = macvlanConfig.Bond.Name
 	}
 
 	// set vlanID for interface basement name
-	if multusConfSpec.MacvlanConfig.VlanID != nil {
-		if *multusConfSpec.MacvlanConfig.VlanID != 0 {
-			masterName = fmt.Sprintf("%s.%d", masterName, *multusConfSpec.MacvlanConfig.VlanID)
+	if macvlanConfig.VlanID != nil {
+		if *macvlanConfig.VlanID != 0 {
+			masterName = fmt.Sprintf("%s.%d", masterName, *macvlanConfig.VlanID)
 		}
 	}
+	return masterName
+}
 
+func generateMacvlanCNIConf(disableIPAM bool, multusConfSpec spiderpoolv2beta1.MultusCNIConfigSpec) interface{} {
 	// TODO(Icarus9913): customize the macvlan mode
 	netConf := MacvlanNetConf{
 		Type:   MacVlanType,
-		Master: masterName,
+		Master: MacvlanMasterName(multusConfSpec.MacvlanConfig),
 		Mode:   "bridge",
 	}
 
@@ -615,12 +673,33 @@ func generateCoordinatorCNIConf(coordinatorSpec *spiderpoolv2beta1.CoordinatorSp
 		if coordinatorSpec.PodDefaultRouteNIC != nil {
 			coordinatorNetConf.PodDefaultRouteNIC = *coordinatorSpec.PodDefaultRouteNIC
 		}
+		if coordinatorSpec.RouteTableMode != nil {
+			coordinatorNetConf.RouteTableMode = coordinatorcmd.RouteTableMode(*coordinatorSpec.RouteTableMode)
+		}
+		if coordinatorSpec.EnableReplyViaVeth != nil {
+			coordinatorNetConf.EnableReplyViaVeth = coordinatorSpec.EnableReplyViaVeth
+		}
+		if coordinatorSpec.EnableProxyNDP != nil {
+			coordinatorNetConf.EnableProxyNDP = coordinatorSpec.EnableProxyNDP
+		}
+		if coordinatorSpec.DefaultRouteMode != nil {
+			coordinatorNetConf.DefaultRouteMode = coordinatorcmd.DefaultRouteMode(*coordinatorSpec.DefaultRouteMode)
+		}
+		if coordinatorSpec.DefaultRouteWeight != nil {
+			coordinatorNetConf.DefaultRouteWeight = coordinatorSpec.DefaultRouteWeight
+		}
+		if coordinatorSpec.HijackRouteMTU != nil {
+			coordinatorNetConf.HijackRouteMTU = coordinatorSpec.HijackRouteMTU
+		}
 		if coordinatorSpec.DetectIPConflict != nil {
 			coordinatorNetConf.IPConflict = coordinatorSpec.DetectIPConflict
 		}
 		if coordinatorSpec.DetectGateway != nil {
 			coordinatorNetConf.DetectGateway = coordinatorSpec.DetectGateway
 		}
+		if coordinatorSpec.DetectGatewayMode != nil {
+			coordinatorNetConf.DetectGatewayMode = coordinatorcmd.DetectGatewayMode(*coordinatorSpec.DetectGatewayMode)
+		}
 	}
 
 	return coordinatorNetConf
@@ -4,12 +4,15 @@
 package multuscniconfig
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/strings/slices"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/spidernet-io/spiderpool/cmd/spiderpool/cmd"
 	"github.com/spidernet-io/spiderpool/pkg/constant"
@@ -178,13 +181,46 @@ func validateVlanCNIConfig(master []string, bond *spiderpoolv2beta1.BondConfig)
 	return nil
 }
 
+// validateVlanId checks the vlanId is in range [0,4094], 0 means untagged.
 func validateVlanId(vlanId int32) error {
 	if vlanId < 0 || vlanId > 4094 {
-		return fmt.Errorf("invalid vlanId %v, please make sure vlanId in range [0,4094]", vlanId)
+		return fmt.Errorf("invalid vlanId %v, please make sure vlanId in range [0,4094], 0 means untagged", vlanId)
 	}
 	return nil
 }
 
+// validateVlanConflict refuses the SpiderMultusConfig using the same VLAN
+// interface, the master and the vlanID, as another one, otherwise their
+// net-attach-defs fight over the same kernel interface. It's allowed with the
+// shared-vlan annotation.
+func validateVlanConflict(ctx context.Context, c client.Reader, multusConfig *spiderpoolv2beta1.SpiderMultusConfig) *field.Error {
+	vlanInterface := vlanInterfaceName(multusConfig)
+	if vlanInterface == "" || isSharedVlan(multusConfig) {
+		return nil
+	}
+
+	var multusConfigList spiderpoolv2beta1.SpiderMultusConfigList
+	if err := c.List(ctx, &multusConfigList); err != nil {
+		return field.InternalError(annotationField, fmt.Errorf("failed to list SpiderMultusConfigs: %w", err))
+	}
+	others := make([]*spiderpoolv2beta1.SpiderMultusConfig, 0, len(multusConfigList.Items))
+	for i := range multusConfigList.Items {
+		others = append(others, &multusConfigList.Items[i])
+	}
+
+	conflicts := findVlanConflicts(multusConfig, others)
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	vlanField := macvlanConfigField.Child("vlanID")
+	if multusConfig.Spec.CniType == IpVlanType {
+		vlanField = ipvlanConfigField.Child("vlanID")
+	}
+	return field.Forbidden(vlanField, fmt.Sprintf("the VLAN interface %s is already used by SpiderMultusConfig %s, add the annotation %s: \"true\" to share it",
+		vlanInterface, strings.Join(conflicts, ", "), constant.AnnoMultusConfigSharedVlan))
+}
+
 func validateAnnotation(multusConfig *spiderpoolv2beta1.SpiderMultusConfig) *field.Error {
 	// validate the custom net-attach-def resource name
 	customMultusName, ok := multusConfig.Annotations[constant.AnnoNetAttachConfName]
@@ -12,6 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
@@ -22,7 +23,9 @@ import (
 
 var logger *zap.Logger
 
-type MultusConfigWebhook struct{}
+type MultusConfigWebhook struct {
+	Client client.Client
+}
 
 func (mcw *MultusConfigWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	if logger == nil {
@@ -47,6 +50,9 @@ func (mcw *MultusConfigWebhook) ValidateCreate(ctx context.Context, obj runtime.
 	log.Sugar().Debugf("Request MultusConfig: %+v", *multusConfig)
 
 	err := validate(nil, multusConfig)
+	if nil == err {
+		err = validateVlanConflict(ctx, mcw.Client, multusConfig)
+	}
 	if nil != err {
 		return nil, apierrors.NewInvalid(
 			spiderpoolv2beta1.SchemeGroupVersion.WithKind(constant.KindSpiderMultusConfig).GroupKind(),
@@ -70,6 +76,9 @@ func (mcw *MultusConfigWebhook) ValidateUpdate(ctx context.Context, oldObj, newO
 	log.Sugar().Debugf("Request new MultusConfig: %+v", *newMultusConfig)
 
 	err := validate(oldMultusConfig, newMultusConfig)
+	if nil == err {
+		err = validateVlanConflict(ctx, mcw.Client, newMultusConfig)
+	}
 	if nil != err {
 		return nil, apierrors.NewInvalid(
 			spiderpoolv2beta1.SchemeGroupVersion.WithKind(constant.KindSpiderMultusConfig).GroupKind(),
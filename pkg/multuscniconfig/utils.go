@@ -24,12 +24,14 @@ import (
 	"fmt"
 	"net"
 	"regexp"
+	"sort"
 	"strings"
 
 	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 
 	coordinatorcmd "github.com/spidernet-io/spiderpool/cmd/coordinator/cmd"
 	spiderpoolcmd "github.com/spidernet-io/spiderpool/cmd/spiderpool/cmd"
+	"github.com/spidernet-io/spiderpool/pkg/constant"
 	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
 )
 
@@ -41,6 +43,14 @@ const (
 	CustomType  = "custom"
 )
 
+const (
+	// ConditionVlanConflict is True if the SpiderMultusConfig uses the same
+	// VLAN interface as another one
+	ConditionVlanConflict = "VlanConflict"
+	ReasonVlanIDCollision = "VlanIDCollision"
+	ReasonNoVlanCollision = "NoVlanCollision"
+)
+
 type MacvlanNetConf struct {
 	Type   string                    `json:"type"`
 	Master string                    `json:"master"`
@@ -78,15 +88,22 @@ type IfacerNetConf struct {
 }
 
 type CoordinatorConfig struct {
-	IPConflict         *bool               `json:"detectIPConflict,omitempty"`
-	DetectGateway      *bool               `json:"detectGateway,omitempty"`
-	MacPrefix          string              `json:"podMACPrefix,omitempty"`
-	Mode               coordinatorcmd.Mode `json:"mode,omitempty"`
-	Type               string              `json:"type"`
-	PodDefaultRouteNIC string              `json:"podDefaultRouteNic,omitempty"`
-	OverlayPodCIDR     []string            `json:"overlayPodCIDR,omitempty"`
-	ServiceCIDR        []string            `json:"serviceCIDR,omitempty"`
-	HijackCIDR         []string            `json:"hijackCIDR,omitempty"`
+	IPConflict         *bool                            `json:"detectIPConflict,omitempty"`
+	DetectGateway      *bool                            `json:"detectGateway,omitempty"`
+	DetectGatewayMode  coordinatorcmd.DetectGatewayMode `json:"detectGatewayMode,omitempty"`
+	MacPrefix          string                           `json:"podMACPrefix,omitempty"`
+	Mode               coordinatorcmd.Mode              `json:"mode,omitempty"`
+	Type               string                           `json:"type"`
+	PodDefaultRouteNIC string                           `json:"podDefaultRouteNic,omitempty"`
+	RouteTableMode     coordinatorcmd.RouteTableMode    `json:"routeTableMode,omitempty"`
+	EnableReplyViaVeth *bool                            `json:"enableReplyViaVeth,omitempty"`
+	EnableProxyNDP     *bool                            `json:"enableProxyNDP,omitempty"`
+	DefaultRouteMode   coordinatorcmd.DefaultRouteMode  `json:"defaultRouteMode,omitempty"`
+	DefaultRouteWeight *int                             `json:"defaultRouteWeight,omitempty"`
+	HijackRouteMTU     *int                             `json:"hijackRouteMTU,omitempty"`
+	OverlayPodCIDR     []string                         `json:"overlayPodCIDR,omitempty"`
+	ServiceCIDR        []string                         `json:"serviceCIDR,omitempty"`
+	HijackCIDR         []string                         `json:"hijackCIDR,omitempty"`
 }
 
 func ParsePodNetworkAnnotation(podNetworks, defaultNamespace string) ([]*netv1.NetworkSelectionElement, error) {
@@ -202,3 +219,63 @@ func ParsePodNetworkObjectName(podnetwork string) (string, string, string, error
 
 	return netNsName, networkName, netIfName, nil
 }
+
+// vlanInterfaceName returns the VLAN interface created on the master for the
+// macvlan or ipvlan SpiderMultusConfig, it's empty for the other CNI types or
+// the untagged ones, which don't own any VLAN interface.
+func vlanInterfaceName(multusConfig *spiderpoolv2beta1.SpiderMultusConfig) string {
+	var master []string
+	var vlanID *int32
+	var bond *spiderpoolv2beta1.BondConfig
+	switch {
+	case multusConfig.Spec.CniType == MacVlanType && multusConfig.Spec.MacvlanConfig != nil:
+		master, vlanID, bond = multusConfig.Spec.MacvlanConfig.Master, multusConfig.Spec.MacvlanConfig.VlanID, multusConfig.Spec.MacvlanConfig.Bond
+	case multusConfig.Spec.CniType == IpVlanType && multusConfig.Spec.IPVlanConfig != nil:
+		master, vlanID, bond = multusConfig.Spec.IPVlanConfig.Master, multusConfig.Spec.IPVlanConfig.VlanID, multusConfig.Spec.IPVlanConfig.Bond
+	default:
+		return ""
+	}
+
+	if vlanID == nil || *vlanID == 0 {
+		return ""
+	}
+	if len(master) == 1 {
+		return fmt.Sprintf("%s.%d", master[0], *vlanID)
+	}
+	if bond != nil {
+		return fmt.Sprintf("%s.%d", bond.Name, *vlanID)
+	}
+	return ""
+}
+
+// isSharedVlan returns true if the SpiderMultusConfig is annotated to share
+// its VLAN interface with the other ones.
+func isSharedVlan(multusConfig *spiderpoolv2beta1.SpiderMultusConfig) bool {
+	return multusConfig.Annotations[constant.AnnoMultusConfigSharedVlan] == "true"
+}
+
+// findVlanConflicts returns the "namespace/name" of the SpiderMultusConfigs
+// in others using the same VLAN interface as multusConfig, the collision is
+// tolerated if either of them is annotated with shared-vlan. The interfaces
+// are per node, so the SpiderMultusConfigs of all namespaces are checked.
+func findVlanConflicts(multusConfig *spiderpoolv2beta1.SpiderMultusConfig, others []*spiderpoolv2beta1.SpiderMultusConfig) []string {
+	vlanInterface := vlanInterfaceName(multusConfig)
+	if vlanInterface == "" || isSharedVlan(multusConfig) {
+		return nil
+	}
+
+	var conflicts []string
+	for _, other := range others {
+		if other.Namespace == multusConfig.Namespace && other.Name == multusConfig.Name {
+			continue
+		}
+		if other.DeletionTimestamp != nil || isSharedVlan(other) {
+			continue
+		}
+		if vlanInterfaceName(other) == vlanInterface {
+			conflicts = append(conflicts, other.Namespace+"/"+other.Name)
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts
+}
@@ -0,0 +1,187 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package gwconnection
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/mdlayher/arp"
+	"github.com/mdlayher/ndp"
+	"go.uber.org/zap"
+	"golang.org/x/net/ipv6"
+)
+
+// NeighborProber detects the gateway by the link-layer resolution, ARP for
+// IPv4 and the Neighbor Solicitation for IPv6, the gateway is reachable once
+// it answers. It works with the gateways dropping ICMP.
+type NeighborProber struct {
+	logger   *zap.Logger
+	netns    ns.NetNS
+	iface    string
+	gw       netip.Addr
+	count    int
+	interval time.Duration
+	timeout  time.Duration
+}
+
+func NewNeighborProber(count int, interval, timeout, gw string, netns ns.NetNS, iface string, logger *zap.Logger) (*NeighborProber, error) {
+	addr, err := netip.ParseAddr(gw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gateway %s: %w", gw, err)
+	}
+
+	intervalDuration, err := time.ParseDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutDuration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NeighborProber{
+		logger:   logger,
+		netns:    netns,
+		iface:    iface,
+		gw:       addr.Unmap(),
+		count:    count,
+		interval: intervalDuration,
+		timeout:  timeoutDuration,
+	}, nil
+}
+
+func (p *NeighborProber) DetectGateway() error {
+	var hwAddr net.HardwareAddr
+	err := p.netns.Do(func(_ ns.NetNS) error {
+		ifi, err := net.InterfaceByName(p.iface)
+		if err != nil {
+			return fmt.Errorf("failed to InterfaceByName %s: %w", p.iface, err)
+		}
+
+		if p.gw.Is4() {
+			hwAddr, err = p.resolveByARP(ifi)
+		} else {
+			hwAddr, err = p.resolveByNDP(ifi)
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("gateway %s is unreachable: %w", p.gw, err)
+	}
+
+	p.logger.Sugar().Debugf("gateway %s is reachable at %s", p.gw, hwAddr)
+	return nil
+}
+
+func (p *NeighborProber) resolveByARP(ifi *net.Interface) (net.HardwareAddr, error) {
+	client, err := arp.Dial(ifi)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init arp client: %w", err)
+	}
+	defer client.Close()
+
+	var lastErr error
+	for i := 0; i < p.count; i++ {
+		if i > 0 {
+			time.Sleep(p.interval)
+		}
+
+		if err := client.SetDeadline(time.Now().Add(p.timeout)); err != nil {
+			return nil, fmt.Errorf("failed to set deadline: %w", err)
+		}
+		hwAddr, err := client.Resolve(p.gw)
+		if err == nil {
+			return hwAddr, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("no arp reply received after %d attempts: %w", p.count, lastErr)
+}
+
+func (p *NeighborProber) resolveByNDP(ifi *net.Interface) (net.HardwareAddr, error) {
+	conn, _, err := ndp.Listen(ifi, ndp.LinkLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init ndp client: %w", err)
+	}
+	defer conn.Close()
+
+	var filter ipv6.ICMPFilter
+	filter.SetAll(true)
+	filter.Accept(ipv6.ICMPTypeNeighborAdvertisement)
+	if err = conn.SetICMPFilter(&filter); err != nil {
+		return nil, fmt.Errorf("failed to set the icmp filter: %w", err)
+	}
+
+	// always multicast the solicitation to the solicited-node multicast group
+	// of the gateway, as if its MAC address is unknown
+	snm, err := ndp.SolicitedNodeMulticast(p.gw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine solicited-node multicast address: %w", err)
+	}
+	solicitation := &ndp.NeighborSolicitation{
+		TargetAddress: p.gw,
+		Options: []ndp.Option{
+			&ndp.LinkLayerAddress{
+				Direction: ndp.Source,
+				Addr:      ifi.HardwareAddr,
+			},
+		},
+	}
+
+	for i := 0; i < p.count; i++ {
+		if i > 0 {
+			time.Sleep(p.interval)
+		}
+
+		if err := conn.WriteTo(solicitation, nil, snm); err != nil {
+			return nil, fmt.Errorf("failed to send neighbor solicitation: %w", err)
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(p.timeout)); err != nil {
+			return nil, fmt.Errorf("failed to set deadline: %w", err)
+		}
+
+		hwAddr, err := readNeighborAdvertisement(conn, p.gw)
+		if err != nil {
+			return nil, err
+		}
+		if hwAddr != nil {
+			return hwAddr, nil
+		}
+	}
+	return nil, fmt.Errorf("no neighbor advertisement received after %d attempts", p.count)
+}
+
+// readNeighborAdvertisement reads until the advertisement of target arrives,
+// it returns nil without error once the read deadline expires.
+func readNeighborAdvertisement(conn *ndp.Conn, target netip.Addr) (net.HardwareAddr, error) {
+	for {
+		msg, _, _, err := conn.ReadFrom()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to receive neighbor advertisement: %w", err)
+		}
+
+		na, ok := msg.(*ndp.NeighborAdvertisement)
+		if !ok || na.TargetAddress != target {
+			continue
+		}
+		for _, option := range na.Options {
+			if lla, ok := option.(*ndp.LinkLayerAddress); ok && lla.Direction == ndp.Target {
+				return lla.Addr, nil
+			}
+		}
+		// the solicited advertisement may omit the target link-layer address,
+		// it still proves the gateway is there
+		return net.HardwareAddr{}, nil
+	}
+}
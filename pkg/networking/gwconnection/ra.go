@@ -0,0 +1,102 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package gwconnection
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/mdlayher/ndp"
+	"golang.org/x/net/ipv6"
+)
+
+// the interval to send the Router Solicitation again, it's shorter than the
+// RTR_SOLICITATION_INTERVAL of RFC 4861, since the pod waits for it
+const routerSolicitationInterval = time.Second
+
+var allRouters = netip.MustParseAddr("ff02::2")
+
+// DiscoverIPv6Gateway sends the Router Solicitation on the interface in the
+// netns, and returns the link-local address of the first router advertising
+// itself as a default router, whose router lifetime is greater than 0. It's
+// an error if no such router advertises within the timeout.
+func DiscoverIPv6Gateway(netns ns.NetNS, iface string, timeout time.Duration) (net.IP, error) {
+	var gw net.IP
+	err := netns.Do(func(_ ns.NetNS) error {
+		ifi, err := net.InterfaceByName(iface)
+		if err != nil {
+			return fmt.Errorf("failed to InterfaceByName %s: %w", iface, err)
+		}
+
+		conn, _, err := ndp.Listen(ifi, ndp.LinkLocal)
+		if err != nil {
+			return fmt.Errorf("failed to init ndp client: %w", err)
+		}
+		defer conn.Close()
+
+		var filter ipv6.ICMPFilter
+		filter.SetAll(true)
+		filter.Accept(ipv6.ICMPTypeRouterAdvertisement)
+		if err = conn.SetICMPFilter(&filter); err != nil {
+			return fmt.Errorf("failed to set the icmp filter: %w", err)
+		}
+
+		gw, err = solicitRouter(conn, ifi, time.Now().Add(timeout))
+		if err != nil {
+			return fmt.Errorf("failed to discover the ipv6 gateway on %s: %w", iface, err)
+		}
+		return nil
+	})
+	return gw, err
+}
+
+// solicitRouter sends the Router Solicitation every routerSolicitationInterval
+// until a default router advertises or the deadline expires.
+func solicitRouter(conn *ndp.Conn, ifi *net.Interface, deadline time.Time) (net.IP, error) {
+	rs := &ndp.RouterSolicitation{}
+	if len(ifi.HardwareAddr) != 0 {
+		rs.Options = append(rs.Options, &ndp.LinkLayerAddress{
+			Direction: ndp.Source,
+			Addr:      ifi.HardwareAddr,
+		})
+	}
+
+	for time.Now().Before(deadline) {
+		if err := conn.WriteTo(rs, nil, allRouters); err != nil {
+			return nil, fmt.Errorf("failed to send router solicitation: %w", err)
+		}
+
+		readDeadline := time.Now().Add(routerSolicitationInterval)
+		if readDeadline.After(deadline) {
+			readDeadline = deadline
+		}
+		if err := conn.SetReadDeadline(readDeadline); err != nil {
+			return nil, fmt.Errorf("failed to set deadline: %w", err)
+		}
+
+		for {
+			msg, _, from, err := conn.ReadFrom()
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break
+				}
+				return nil, fmt.Errorf("failed to receive router advertisement: %w", err)
+			}
+
+			ra, ok := msg.(*ndp.RouterAdvertisement)
+			// the router lifetime 0 means the router is not a default router,
+			// and the advertisement must come from the link-local address
+			if !ok || ra.RouterLifetime <= 0 || !from.Is6() || !from.IsLinkLocalUnicast() {
+				continue
+			}
+			return net.IP(from.WithZone("").AsSlice()), nil
+		}
+	}
+	return nil, fmt.Errorf("no router advertisement with router lifetime > 0 received")
+}
@@ -63,15 +63,43 @@ func (ipc *IPChecker) DoIPConflictChecking(ipconfigs []*types100.IPConfig, iface
 		return
 	}
 
-	var err error
-	_ = ipc.netns.Do(func(netNS ns.NetNS) error {
+	ips := make([]net.IP, 0, len(ipconfigs))
+	for idx := range ipconfigs {
+		ips = append(ips, ipconfigs[idx].Address.IP)
+	}
+	if err := ipc.startIPConflictChecking(ips, iface, errg); err != nil {
+		ipc.logger.Error("failed to start ip conflict checking", zap.Error(err))
+	}
+}
+
+// DetectIPConflict checks whether the ip answers ARP (IPv4) or NDP (IPv6) on
+// the iface of the netns, it returns an error if the ip is in use or the
+// checking fails to run.
+func (ipc *IPChecker) DetectIPConflict(ip net.IP, iface string) error {
+	ipc.logger.Debug("DetectIPConflict", zap.String("ip", ip.String()), zap.String("interface", iface))
+
+	errg := new(errgroup.Group)
+	if err := ipc.startIPConflictChecking([]net.IP{ip}, iface, errg); err != nil {
+		return err
+	}
+	return errg.Wait()
+}
+
+// startIPConflictChecking starts checking the ips in the errg
+func (ipc *IPChecker) startIPConflictChecking(ips []net.IP, iface string, errg *errgroup.Group) error {
+	return ipc.netns.Do(func(netNS ns.NetNS) error {
+		var err error
 		ipc.ifi, err = net.InterfaceByName(iface)
 		if err != nil {
 			return fmt.Errorf("failed to InterfaceByName %s: %w", iface, err)
 		}
 
-		for idx := range ipconfigs {
-			target := netip.MustParseAddr(ipconfigs[idx].Address.IP.String())
+		for idx := range ips {
+			target, ok := netip.AddrFromSlice(ips[idx])
+			if !ok {
+				return fmt.Errorf("invalid ip %v", ips[idx])
+			}
+			target = target.Unmap()
 			if target.Is4() {
 				ipc.logger.Debug("IPCheckingByARP", zap.String("ipv4 address", target.String()))
 				ipc.ip4 = target
@@ -0,0 +1,265 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package macregistry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/lock"
+	"github.com/spidernet-io/spiderpool/pkg/logutils"
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+// DefaultMaxSalt is the max salt tried to derive a MAC address without
+// collision for an interface
+const DefaultMaxSalt = 16
+
+// ErrMACExhausted means that all the MAC addresses derived for the interface
+// are held by the other live pods on the node.
+var ErrMACExhausted = errors.New("no MAC address without collision")
+
+// Owner is the interface of the pod which a MAC address is assigned to
+type Owner struct {
+	PodNamespace string
+	PodName      string
+	PodUID       string
+	NIC          string
+}
+
+func (o Owner) key() string {
+	return o.PodUID + "/" + o.NIC
+}
+
+// Entry is a MAC address assigned to an interface of a pod on the node
+type Entry struct {
+	Owner
+	MAC net.HardwareAddr
+	// Salt is the salt which the MAC address is derived with, it's -1 if
+	// the MAC address is restored but not derived by DerivePodMAC.
+	Salt         int
+	AssignedTime time.Time
+}
+
+// AliveFunc reports whether the pod owning a MAC address still exists on the
+// node, the MAC address of a gone pod is taken over on collision.
+type AliveFunc func(ctx context.Context, owner Owner) (bool, error)
+
+// MACRegistry records the MAC addresses assigned to the pods on the node, so
+// that no two interfaces of the pods on the node are assigned the same MAC
+// address.
+type MACRegistry interface {
+	// Assign assigns a MAC address with the macPrefix to the interface of the
+	// pod, it is idempotent for the same interface.
+	Assign(ctx context.Context, owner Owner, macPrefix string) (Entry, error)
+	// Release releases the MAC address of the interface of the pod
+	Release(owner Owner)
+	// Restore records the MAC address assigned before, e.g. by the previous
+	// spiderpool-agent, it returns false if the MAC address is held by another
+	// interface.
+	Restore(owner Owner, mac net.HardwareAddr) bool
+	// List returns the assigned MAC addresses sorted by the MAC address
+	List() []Entry
+}
+
+type MACRegistryConfig struct {
+	// MaxSalt is the max salt tried to derive the MAC address
+	MaxSalt int
+}
+
+type macRegistry struct {
+	config MACRegistryConfig
+	alive  AliveFunc
+
+	lock lock.Mutex
+	// MAC address -> entry
+	entries map[string]*Entry
+	// owner key -> MAC address
+	owners map[string]string
+}
+
+// NewMACRegistry creates an empty MACRegistry
+func NewMACRegistry(config MACRegistryConfig, alive AliveFunc) (MACRegistry, error) {
+	if alive == nil {
+		return nil, fmt.Errorf("alive func %w", constant.ErrMissingRequiredParam)
+	}
+
+	if config.MaxSalt <= 0 {
+		config.MaxSalt = DefaultMaxSalt
+	}
+
+	return &macRegistry{
+		config:  config,
+		alive:   alive,
+		entries: map[string]*Entry{},
+		owners:  map[string]string{},
+	}, nil
+}
+
+func (r *macRegistry) Assign(ctx context.Context, owner Owner, macPrefix string) (Entry, error) {
+	log := logutils.FromContext(ctx)
+
+	prefix, err := parseMACPrefix(macPrefix)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// the CNI ADD of the interface may be retried
+	if mac, ok := r.owners[owner.key()]; ok {
+		entry := r.entries[mac]
+		if bytes.HasPrefix(entry.MAC, prefix) {
+			return *entry, nil
+		}
+		r.remove(owner)
+	}
+
+	for salt := 0; salt <= r.config.MaxSalt; salt++ {
+		mac, err := networking.DerivePodMAC(macPrefix, owner.PodUID, owner.NIC, salt)
+		if err != nil {
+			return Entry{}, err
+		}
+
+		for {
+			holder, ok := r.entries[mac.String()]
+			if !ok {
+				break
+			}
+			if holder.key() == owner.key() {
+				return *holder, nil
+			}
+
+			// the alive func may request the API Server, don't block the
+			// other interfaces meanwhile
+			holderOwner := holder.Owner
+			r.lock.Unlock()
+			alive, err := r.alive(ctx, holderOwner)
+			r.lock.Lock()
+			if err != nil {
+				return Entry{}, fmt.Errorf("failed to check the holder %s/%s of MAC %s: %w", holderOwner.PodNamespace, holderOwner.PodName, mac, err)
+			}
+
+			// the MAC may be released or taken over by others meanwhile, check
+			// the current holder again
+			current, ok := r.entries[mac.String()]
+			if !ok {
+				break
+			}
+			if current.key() != holderOwner.key() {
+				continue
+			}
+			if alive {
+				log.Sugar().Warnf("MAC %s derived with salt %d is held by interface %s of pod %s/%s, try the next salt",
+					mac, salt, holderOwner.NIC, holderOwner.PodNamespace, holderOwner.PodName)
+				break
+			}
+			log.Sugar().Infof("take over MAC %s from interface %s of the gone pod %s/%s",
+				mac, holderOwner.NIC, holderOwner.PodNamespace, holderOwner.PodName)
+			r.remove(holderOwner)
+		}
+		if _, ok := r.entries[mac.String()]; ok {
+			continue
+		}
+
+		// another request of the interface may be assigned meanwhile
+		r.remove(owner)
+		entry := &Entry{
+			Owner:        owner,
+			MAC:          mac,
+			Salt:         salt,
+			AssignedTime: time.Now(),
+		}
+		r.entries[mac.String()] = entry
+		r.owners[owner.key()] = mac.String()
+		return *entry, nil
+	}
+
+	return Entry{}, fmt.Errorf("%w for interface %s of pod %s/%s with prefix %s after %d salts",
+		ErrMACExhausted, owner.NIC, owner.PodNamespace, owner.PodName, macPrefix, r.config.MaxSalt+1)
+}
+
+func (r *macRegistry) Release(owner Owner) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.remove(owner)
+}
+
+func (r *macRegistry) Restore(owner Owner, mac net.HardwareAddr) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if holder, ok := r.entries[mac.String()]; ok {
+		return holder.key() == owner.key()
+	}
+	r.remove(owner)
+
+	salt := -1
+	if len(mac) == 6 {
+		prefix := fmt.Sprintf("%02x:%02x", mac[0], mac[1])
+		for i := 0; i <= r.config.MaxSalt; i++ {
+			derived, err := networking.DerivePodMAC(prefix, owner.PodUID, owner.NIC, i)
+			if err != nil {
+				break
+			}
+			if bytes.Equal(derived, mac) {
+				salt = i
+				break
+			}
+		}
+	}
+
+	r.entries[mac.String()] = &Entry{
+		Owner:        owner,
+		MAC:          mac,
+		Salt:         salt,
+		AssignedTime: time.Now(),
+	}
+	r.owners[owner.key()] = mac.String()
+	return true
+}
+
+func (r *macRegistry) List() []Entry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	list := make([]Entry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		list = append(list, *entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].MAC, list[j].MAC) < 0
+	})
+	return list
+}
+
+// remove must be called with the lock held
+func (r *macRegistry) remove(owner Owner) {
+	mac, ok := r.owners[owner.key()]
+	if !ok {
+		return
+	}
+	delete(r.owners, owner.key())
+	delete(r.entries, mac)
+}
+
+func parseMACPrefix(macPrefix string) (net.HardwareAddr, error) {
+	if err := networking.ValidateMACPrefix(macPrefix); err != nil {
+		return nil, err
+	}
+	// pad the prefix to a MAC address to parse it
+	mac, err := net.ParseMAC(macPrefix + ":00:00:00:00")
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAC prefix '%s': %w", macPrefix, err)
+	}
+	return mac[:2], nil
+}
@@ -0,0 +1,184 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package macregistry_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/networking/macregistry"
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+var _ = Describe("MACRegistry", Label("mac_registry"), func() {
+	var ctx context.Context
+	var alivePods map[string]bool
+	var aliveErr error
+	var onAlive func()
+	var registry macregistry.MACRegistry
+
+	podA := macregistry.Owner{PodNamespace: "default", PodName: "a", PodUID: "uid-a", NIC: "net1"}
+	podB := macregistry.Owner{PodNamespace: "default", PodName: "b", PodUID: "uid-b", NIC: "net1"}
+
+	derive := func(owner macregistry.Owner, salt int) net.HardwareAddr {
+		mac, err := networking.DerivePodMAC("0a:1b", owner.PodUID, owner.NIC, salt)
+		Expect(err).NotTo(HaveOccurred())
+		return mac
+	}
+
+	BeforeEach(func() {
+		ctx = context.TODO()
+		alivePods = map[string]bool{}
+		aliveErr = nil
+		onAlive = nil
+
+		var err error
+		registry, err = macregistry.NewMACRegistry(macregistry.MACRegistryConfig{MaxSalt: 2},
+			func(_ context.Context, owner macregistry.Owner) (bool, error) {
+				if onAlive != nil {
+					f := onAlive
+					onAlive = nil
+					f()
+				}
+				return alivePods[owner.PodUID], aliveErr
+			})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("fails without the alive func", func() {
+		_, err := macregistry.NewMACRegistry(macregistry.MACRegistryConfig{}, nil)
+		Expect(err).To(MatchError(constant.ErrMissingRequiredParam))
+	})
+
+	It("assigns the MAC derived from the pod UID idempotently", func() {
+		entry, err := registry.Assign(ctx, podA, "0a:1b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.MAC).To(Equal(derive(podA, 0)))
+		Expect(entry.Salt).To(Equal(0))
+		Expect(entry.Owner).To(Equal(podA))
+
+		again, err := registry.Assign(ctx, podA, "0a:1b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again).To(Equal(entry))
+		Expect(registry.List()).To(HaveLen(1))
+	})
+
+	It("replaces the MAC of the interface when the prefix changes", func() {
+		_, err := registry.Assign(ctx, podA, "0a:1b")
+		Expect(err).NotTo(HaveOccurred())
+
+		entry, err := registry.Assign(ctx, podA, "1a:1b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strings.HasPrefix(entry.MAC.String(), "1a:1b:")).To(BeTrue())
+		Expect(registry.List()).To(ConsistOf(entry))
+	})
+
+	It("rejects the invalid prefix", func() {
+		_, err := registry.Assign(ctx, podA, "0b:1b")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("re-derives the MAC with a salt on collision with a live pod", func() {
+		Expect(registry.Restore(podB, derive(podA, 0))).To(BeTrue())
+		alivePods[podB.PodUID] = true
+
+		entry, err := registry.Assign(ctx, podA, "0a:1b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.MAC).To(Equal(derive(podA, 1)))
+		Expect(entry.Salt).To(Equal(1))
+		Expect(registry.List()).To(HaveLen(2))
+	})
+
+	It("takes over the MAC of a gone pod", func() {
+		Expect(registry.Restore(podB, derive(podA, 0))).To(BeTrue())
+
+		entry, err := registry.Assign(ctx, podA, "0a:1b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.MAC).To(Equal(derive(podA, 0)))
+		Expect(registry.List()).To(ConsistOf(entry))
+	})
+
+	It("fails when all the derived MACs are held by live pods", func() {
+		for salt := 0; salt <= 2; salt++ {
+			holder := macregistry.Owner{PodNamespace: "default", PodName: "b", PodUID: fmt.Sprintf("uid-%d", salt), NIC: "net1"}
+			Expect(registry.Restore(holder, derive(podA, salt))).To(BeTrue())
+			alivePods[holder.PodUID] = true
+		}
+
+		_, err := registry.Assign(ctx, podA, "0a:1b")
+		Expect(err).To(MatchError(macregistry.ErrMACExhausted))
+	})
+
+	It("fails when the holder can't be checked", func() {
+		Expect(registry.Restore(podB, derive(podA, 0))).To(BeTrue())
+		aliveErr = fmt.Errorf("failed to get pod")
+
+		_, err := registry.Assign(ctx, podA, "0a:1b")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("takes the MAC released while checking the holder", func() {
+		Expect(registry.Restore(podB, derive(podA, 0))).To(BeTrue())
+		alivePods[podB.PodUID] = true
+		onAlive = func() {
+			registry.Release(podB)
+		}
+
+		entry, err := registry.Assign(ctx, podA, "0a:1b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.MAC).To(Equal(derive(podA, 0)))
+		Expect(registry.List()).To(ConsistOf(entry))
+	})
+
+	It("checks the new holder of the MAC taken over while checking the holder", func() {
+		podC := macregistry.Owner{PodNamespace: "default", PodName: "c", PodUID: "uid-c", NIC: "net1"}
+		Expect(registry.Restore(podB, derive(podA, 0))).To(BeTrue())
+		alivePods[podC.PodUID] = true
+		onAlive = func() {
+			registry.Release(podB)
+			Expect(registry.Restore(podC, derive(podA, 0))).To(BeTrue())
+		}
+
+		entry, err := registry.Assign(ctx, podA, "0a:1b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.MAC).To(Equal(derive(podA, 1)))
+		Expect(registry.List()).To(HaveLen(2))
+	})
+
+	It("releases the MAC of the interface", func() {
+		_, err := registry.Assign(ctx, podA, "0a:1b")
+		Expect(err).NotTo(HaveOccurred())
+		entry, err := registry.Assign(ctx, podB, "0a:1b")
+		Expect(err).NotTo(HaveOccurred())
+
+		registry.Release(podA)
+		// it's idempotent
+		registry.Release(podA)
+		Expect(registry.List()).To(ConsistOf(entry))
+	})
+
+	It("restores the MACs and recovers their salts", func() {
+		Expect(registry.Restore(podA, derive(podA, 2))).To(BeTrue())
+		Expect(registry.Restore(podA, derive(podA, 2))).To(BeTrue())
+		Expect(registry.Restore(podB, derive(podA, 2))).To(BeFalse())
+
+		foreign, err := net.ParseMAC("0a:1b:00:00:00:01")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registry.Restore(podB, foreign)).To(BeTrue())
+
+		list := registry.List()
+		Expect(list).To(HaveLen(2))
+		// sorted by the MAC address
+		Expect(list[0].Owner).To(Equal(podB))
+		Expect(list[0].Salt).To(Equal(-1))
+		Expect(list[1].Owner).To(Equal(podA))
+		Expect(list[1].Salt).To(Equal(2))
+	})
+})
@@ -4,14 +4,17 @@
 package networking
 
 import (
+	"errors"
 	"fmt"
 	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 	"net"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // GetIPFamilyByResult return IPFamily by parse CNI Result
@@ -114,13 +117,48 @@ func GetAllIPAddress(ipFamily int, excludeInterface []string) ([]netlink.Addr, e
 
 // GetAddersByName return all unicast ip address of interface, filter by ipFamily
 func GetAddersByName(iface string, ipfamily int) ([]netlink.Addr, error) {
-	link, err := netlink.LinkByName(iface)
+	return getAddersByName(nil, iface, ipfamily)
+}
+
+func getAddersByName(links *LinkCache, iface string, ipfamily int) ([]netlink.Addr, error) {
+	link, err := links.LinkByName(iface)
 	if err != nil {
 		return nil, err
 	}
 	return getAdders(link, ipfamily)
 }
 
+// GetLinkSubnets returns the connected subnets of the interface, which are
+// derived from its unicast addresses and filtered by ipFamily. The addresses
+// with full mask, such as /32 and /128, have no connected subnet.
+func GetLinkSubnets(iface string, ipFamily int) ([]*net.IPNet, error) {
+	addrs, err := GetAddersByName(iface, ipFamily)
+	if err != nil {
+		return nil, err
+	}
+
+	var subnets []*net.IPNet
+	for _, addr := range addrs {
+		ones, bits := addr.Mask.Size()
+		if ones == bits {
+			continue
+		}
+
+		subnet := &net.IPNet{IP: addr.IP.Mask(addr.Mask), Mask: addr.Mask}
+		duplicate := false
+		for _, s := range subnets {
+			if IPNetEqual(s, subnet) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			subnets = append(subnets, subnet)
+		}
+	}
+	return subnets, nil
+}
+
 // GetAddersByLink return all unicast ip address of interface, filter by interface,ipFamily
 func GetAddersByLink(link netlink.Link, ipfamily int) ([]netlink.Addr, error) {
 	return getAdders(link, ipfamily)
@@ -201,6 +239,198 @@ func linkAddAndSetUp(link netlink.Link) error {
 	return nil
 }
 
+// AddrOptions controls the flags and lifetimes of the address
+type AddrOptions struct {
+	// NoPrefixRoute prevents the kernel from creating the prefix route in main table
+	NoPrefixRoute bool
+	// NoDAD skips the duplicate address detection, IPv6 only
+	NoDAD bool
+	// PreferedLft and ValidLft are the lifetimes in seconds, 0 means forever
+	PreferedLft int
+	ValidLft    int
+}
+
+// AddAddress adds or replaces the address of the interface in the netns, the
+// current netns is used if netns is nil. Note that the kernel doesn't update the
+// flags of an existing IPv4 address, it must be deleted and added again.
+// Equivalent to: `ip addr replace <addr> dev <iface> [noprefixroute] [nodad]`
+func AddAddress(netns ns.NetNS, iface string, addr *net.IPNet, opts AddrOptions) error {
+	return doInNetNS(netns, func() error {
+		link, err := netlink.LinkByName(iface)
+		if err != nil {
+			return err
+		}
+
+		nlAddr := &netlink.Addr{
+			IPNet:       addr,
+			PreferedLft: opts.PreferedLft,
+			ValidLft:    opts.ValidLft,
+		}
+		if opts.NoPrefixRoute {
+			nlAddr.Flags |= unix.IFA_F_NOPREFIXROUTE
+		}
+		if opts.NoDAD && addr.IP.To4() == nil {
+			nlAddr.Flags |= unix.IFA_F_NODAD
+		}
+
+		if err = netlink.AddrReplace(link, nlAddr); err != nil {
+			return fmt.Errorf("failed to add address %v to %s: %w", addr, iface, err)
+		}
+		return nil
+	})
+}
+
+// DelAddress deletes the address of the interface in the netns, the current
+// netns is used if netns is nil.
+// Equivalent to: `ip addr del <addr> dev <iface>`
+func DelAddress(netns ns.NetNS, iface string, addr *net.IPNet) error {
+	return doInNetNS(netns, func() error {
+		link, err := netlink.LinkByName(iface)
+		if err != nil {
+			return err
+		}
+
+		if err = netlink.AddrDel(link, &netlink.Addr{IPNet: addr}); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete address %v from %s: %w", addr, iface, err)
+		}
+		return nil
+	})
+}
+
+// ErrDADFailed is returned when the duplicate address detection of the IPv6
+// address fails, which means the address is already used by others on the link
+var ErrDADFailed = errors.New("duplicate address detection failed")
+
+// WaitForDADComplete waits for the IPv6 address of the interface to leave the
+// tentative state, so that it can be used as the source of routes. It returns
+// ErrDADFailed if the address is detected as duplicated.
+func WaitForDADComplete(netns ns.NetNS, iface string, addr net.IP, timeout time.Duration) error {
+	if addr.To4() != nil {
+		return nil
+	}
+
+	return doInNetNS(netns, func() error {
+		link, err := netlink.LinkByName(iface)
+		if err != nil {
+			return err
+		}
+
+		deadline := time.Now().Add(timeout)
+		for {
+			addrs, err := netlink.AddrList(link, netlink.FAMILY_V6)
+			if err != nil {
+				return fmt.Errorf("failed to list addresses of %s: %w", iface, err)
+			}
+
+			var found *netlink.Addr
+			for idx := range addrs {
+				if addrs[idx].IP.Equal(addr) {
+					found = &addrs[idx]
+					break
+				}
+			}
+			if found == nil {
+				return fmt.Errorf("address %v not found on %s", addr, iface)
+			}
+
+			if found.Flags&unix.IFA_F_DADFAILED != 0 {
+				return fmt.Errorf("address %v on %s: %w", addr, iface, ErrDADFailed)
+			}
+			if found.Flags&unix.IFA_F_TENTATIVE == 0 {
+				return nil
+			}
+
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout waiting for duplicate address detection of %v on %s", addr, iface)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	})
+}
+
+// CompareInterfaceAddrs compares the addresses of the interface in the netns
+// with the expected ones, the current netns is used if netns is nil. The
+// link-local and tentative addresses of the interface are ignored, and the
+// addresses are compared by the IP and prefix length in the canonical form of
+// its family, so a v4-mapped address equals to its IPv4 form.
+func CompareInterfaceAddrs(netns ns.NetNS, iface string, expected []net.IPNet) (missing, extra []net.IPNet, err error) {
+	var actual []netlink.Addr
+	err = doInNetNS(netns, func() error {
+		actual, err = GetAddersByName(iface, netlink.FAMILY_ALL)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	actualIPNets := make(map[string]net.IPNet, len(actual))
+	for _, addr := range actual {
+		if addr.Flags&unix.IFA_F_TENTATIVE != 0 {
+			continue
+		}
+		ipNet := normalizeIPNet(*addr.IPNet)
+		actualIPNets[ipNet.String()] = ipNet
+	}
+
+	expectedIPNets := make(map[string]net.IPNet, len(expected))
+	for _, e := range expected {
+		ipNet := normalizeIPNet(e)
+		if _, ok := expectedIPNets[ipNet.String()]; ok {
+			continue
+		}
+		expectedIPNets[ipNet.String()] = ipNet
+		if _, ok := actualIPNets[ipNet.String()]; !ok {
+			missing = append(missing, ipNet)
+		}
+	}
+
+	for _, a := range actual {
+		if a.Flags&unix.IFA_F_TENTATIVE != 0 {
+			continue
+		}
+		ipNet := normalizeIPNet(*a.IPNet)
+		if _, ok := expectedIPNets[ipNet.String()]; !ok {
+			extra = append(extra, ipNet)
+		}
+	}
+	return missing, extra, nil
+}
+
+// normalizeIPNet returns the address in the canonical form of its family,
+// the IPv4 address is in 4 bytes with the 32 bits mask
+func normalizeIPNet(ipNet net.IPNet) net.IPNet {
+	ip := ipNet.IP
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	} else {
+		ip = ip.To16()
+	}
+
+	bits := len(ip) * 8
+	ones, maskBits := ipNet.Mask.Size()
+	switch {
+	case maskBits == 0:
+		// non-canonical mask, treat it as the host address
+		ones = bits
+	case maskBits > bits:
+		// the mask of v4-mapped address
+		ones -= maskBits - bits
+		if ones < 0 {
+			ones = 0
+		}
+	}
+	return net.IPNet{IP: ip, Mask: net.CIDRMask(ones, bits)}
+}
+
+func doInNetNS(netns ns.NetNS, f func() error) error {
+	if netns == nil {
+		return f()
+	}
+	return netns.Do(func(_ ns.NetNS) error {
+		return f()
+	})
+}
+
 // IPNetEqual returns true iff both IPNet are equal
 // Copyright Authors of vishvananda/netlink
 func IPNetEqual(ipn1 *net.IPNet, ipn2 *net.IPNet) bool {
@@ -0,0 +1,271 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+var _ = Describe("IP", Label("ip"), func() {
+	var testNetNS ns.NetNS
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+
+		err = testNetNS.Do(func(_ ns.NetNS) error {
+			if err := netlink.LinkAdd(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+				PeerName:  "peer12345",
+			}); err != nil {
+				return err
+			}
+			for _, name := range []string{"net1", "peer12345"} {
+				link, err := netlink.LinkByName(name)
+				if err != nil {
+					return err
+				}
+				if err = netlink.LinkSetUp(link); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("AddAddress", func() {
+		prefixRoutes := func(dst string) []netlink.Route {
+			_, ipNet, err := net.ParseCIDR(dst)
+			Expect(err).NotTo(HaveOccurred())
+
+			var routes []netlink.Route
+			err = testNetNS.Do(func(_ ns.NetNS) (err error) {
+				routes, err = netlink.RouteListFiltered(netlink.FAMILY_ALL, &netlink.Route{
+					Table: unix.RT_TABLE_MAIN,
+					Dst:   ipNet,
+				}, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST)
+				return err
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return routes
+		}
+
+		It("the kernel creates the prefix route without noprefixroute", func() {
+			ip, ipNet, err := net.ParseCIDR("10.6.0.2/16")
+			Expect(err).NotTo(HaveOccurred())
+			ipNet.IP = ip
+
+			Expect(networking.AddAddress(testNetNS, "net1", ipNet, networking.AddrOptions{})).To(Succeed())
+			Expect(prefixRoutes("10.6.0.0/16")).To(HaveLen(1))
+
+			Expect(networking.DelAddress(testNetNS, "net1", ipNet)).To(Succeed())
+			Expect(prefixRoutes("10.6.0.0/16")).To(BeEmpty())
+		})
+
+		It("no prefix route appears in main with noprefixroute", func() {
+			opts := networking.AddrOptions{NoPrefixRoute: true, NoDAD: true}
+			for _, address := range []string{"10.6.0.2/16", "fd00:10:6::2/64"} {
+				ip, ipNet, err := net.ParseCIDR(address)
+				Expect(err).NotTo(HaveOccurred())
+				ipNet.IP = ip
+				Expect(networking.AddAddress(testNetNS, "net1", ipNet, opts)).To(Succeed())
+			}
+
+			Expect(prefixRoutes("10.6.0.0/16")).To(BeEmpty())
+			Expect(prefixRoutes("fd00:10:6::/64")).To(BeEmpty())
+
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addrs, err := netlink.AddrList(link, netlink.FAMILY_V6)
+				Expect(err).NotTo(HaveOccurred())
+				for _, addr := range addrs {
+					if addr.IP.Equal(net.ParseIP("fd00:10:6::2")) {
+						Expect(addr.Flags & unix.IFA_F_NODAD).NotTo(BeZero())
+						Expect(addr.Flags & unix.IFA_F_TENTATIVE).To(BeZero())
+						return nil
+					}
+				}
+				Fail("address fd00:10:6::2 not found")
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("replacing the IPv6 address with noprefixroute removes the prefix route", func() {
+			ip, ipNet, err := net.ParseCIDR("fd00:10:6::2/64")
+			Expect(err).NotTo(HaveOccurred())
+			ipNet.IP = ip
+
+			Expect(networking.AddAddress(testNetNS, "net1", ipNet, networking.AddrOptions{NoDAD: true})).To(Succeed())
+			Expect(prefixRoutes("fd00:10:6::/64")).To(HaveLen(1))
+
+			Expect(networking.AddAddress(testNetNS, "net1", ipNet, networking.AddrOptions{NoPrefixRoute: true, NoDAD: true})).To(Succeed())
+			Expect(prefixRoutes("fd00:10:6::/64")).To(BeEmpty())
+		})
+	})
+
+	Context("WaitForDADComplete", func() {
+		parseAddr := func(cidr string) *net.IPNet {
+			ip, ipNet, err := net.ParseCIDR(cidr)
+			Expect(err).NotTo(HaveOccurred())
+			ipNet.IP = ip
+			return ipNet
+		}
+
+		It("returns nil once the tentative flag clears", func() {
+			addr := parseAddr("fd00:10:6::2/64")
+			Expect(networking.AddAddress(testNetNS, "net1", addr, networking.AddrOptions{})).To(Succeed())
+			Expect(networking.WaitForDADComplete(testNetNS, "net1", addr.IP, 10*time.Second)).To(Succeed())
+		})
+
+		It("returns ErrDADFailed if the address is duplicated on the link", func() {
+			addr := parseAddr("fd00:10:6::2/64")
+			Expect(networking.AddAddress(testNetNS, "peer12345", addr, networking.AddrOptions{NoDAD: true})).To(Succeed())
+			Expect(networking.AddAddress(testNetNS, "net1", addr, networking.AddrOptions{})).To(Succeed())
+
+			err := networking.WaitForDADComplete(testNetNS, "net1", addr.IP, 10*time.Second)
+			Expect(errors.Is(err, networking.ErrDADFailed)).To(BeTrue())
+		})
+
+		It("returns an error if the address is not found", func() {
+			err := networking.WaitForDADComplete(testNetNS, "net1", net.ParseIP("fd00:10:6::3"), time.Second)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("CompareInterfaceAddrs", func() {
+		parseAddr := func(cidr string) *net.IPNet {
+			ip, ipNet, err := net.ParseCIDR(cidr)
+			Expect(err).NotTo(HaveOccurred())
+			ipNet.IP = ip
+			return ipNet
+		}
+		toStrings := func(ipNets []net.IPNet) []string {
+			var s []string
+			for _, ipNet := range ipNets {
+				s = append(s, ipNet.String())
+			}
+			return s
+		}
+
+		BeforeEach(func() {
+			Expect(networking.AddAddress(testNetNS, "net1", parseAddr("10.6.0.2/16"), networking.AddrOptions{})).To(Succeed())
+			Expect(networking.AddAddress(testNetNS, "net1", parseAddr("fd00:10:6::2/64"), networking.AddrOptions{NoDAD: true})).To(Succeed())
+		})
+
+		It("reports nothing when the addresses are exactly the expected ones", func() {
+			missing, extra, err := networking.CompareInterfaceAddrs(testNetNS, "net1", []net.IPNet{
+				*parseAddr("10.6.0.2/16"),
+				*parseAddr("fd00:10:6::2/64"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missing).To(BeEmpty())
+			Expect(extra).To(BeEmpty())
+		})
+
+		It("treats the v4-mapped address as its IPv4 form", func() {
+			mapped := net.IPNet{
+				IP:   net.ParseIP("::ffff:10.6.0.2"),
+				Mask: net.CIDRMask(112, 128),
+			}
+			missing, extra, err := networking.CompareInterfaceAddrs(testNetNS, "net1", []net.IPNet{
+				mapped,
+				*parseAddr("fd00:10:6::2/64"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missing).To(BeEmpty())
+			Expect(extra).To(BeEmpty())
+		})
+
+		It("reports the same IP with a different prefix length as both missing and extra", func() {
+			missing, extra, err := networking.CompareInterfaceAddrs(testNetNS, "net1", []net.IPNet{
+				*parseAddr("10.6.0.2/24"),
+				*parseAddr("fd00:10:6::2/64"),
+				*parseAddr("10.7.0.2/16"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(toStrings(missing)).To(ConsistOf("10.6.0.2/24", "10.7.0.2/16"))
+			Expect(toStrings(extra)).To(ConsistOf("10.6.0.2/16"))
+		})
+
+		It("ignores the tentative addresses", func() {
+			Expect(networking.AddAddress(testNetNS, "peer12345", parseAddr("fd00:10:6::3/64"), networking.AddrOptions{NoDAD: true})).To(Succeed())
+			// the address stays tentative as the DAD fails
+			Expect(networking.AddAddress(testNetNS, "net1", parseAddr("fd00:10:6::3/64"), networking.AddrOptions{})).To(Succeed())
+
+			missing, extra, err := networking.CompareInterfaceAddrs(testNetNS, "net1", []net.IPNet{
+				*parseAddr("10.6.0.2/16"),
+				*parseAddr("fd00:10:6::2/64"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missing).To(BeEmpty())
+			Expect(extra).To(BeEmpty())
+		})
+	})
+
+	Context("GetLinkSubnets", func() {
+		It("returns the connected subnets of the addresses on the link", func() {
+			for _, cidr := range []string{"10.6.0.2/16", "10.6.0.3/16", "172.16.1.10/24", "192.168.0.1/32"} {
+				ip, ipNet, err := net.ParseCIDR(cidr)
+				Expect(err).NotTo(HaveOccurred())
+				ipNet.IP = ip
+				Expect(networking.AddAddress(testNetNS, "net1", ipNet, networking.AddrOptions{})).To(Succeed())
+			}
+			ip, ipNet, err := net.ParseCIDR("fd00:10:6::2/64")
+			Expect(err).NotTo(HaveOccurred())
+			ipNet.IP = ip
+			Expect(networking.AddAddress(testNetNS, "net1", ipNet, networking.AddrOptions{NoDAD: true})).To(Succeed())
+
+			toStrings := func(ipNets []*net.IPNet) []string {
+				var s []string
+				for _, ipNet := range ipNets {
+					s = append(s, ipNet.String())
+				}
+				return s
+			}
+
+			err = testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				subnets, err := networking.GetLinkSubnets("net1", netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(toStrings(subnets)).To(ConsistOf("10.6.0.0/16", "172.16.1.0/24"))
+
+				subnets, err = networking.GetLinkSubnets("net1", netlink.FAMILY_V6)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(toStrings(subnets)).To(ConsistOf("fd00:10:6::/64"))
+
+				subnets, err = networking.GetLinkSubnets("net1", netlink.FAMILY_ALL)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(subnets).To(HaveLen(3))
+
+				_, err = networking.GetLinkSubnets("not-exist", netlink.FAMILY_ALL)
+				Expect(err).To(HaveOccurred())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
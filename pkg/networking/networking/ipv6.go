@@ -0,0 +1,92 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/sysctl"
+)
+
+// IPv6Option is both a RouteOption and a RuleOption, see AllowMissingIPv6
+type IPv6Option struct {
+	logger *zap.Logger
+}
+
+// AllowMissingIPv6 makes AddRoute, MoveRouteTable and the rule helpers skip the
+// IPv6 portion with a warning instead of failing, if IPv6 is unsupported in the
+// current netns, such as on the nodes disabling IPv6 intentionally. Without it
+// they fail as the kernel does.
+func AllowMissingIPv6(logger *zap.Logger) IPv6Option {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return IPv6Option{logger: logger}
+}
+
+func (o IPv6Option) applyRoute(_ *netlink.Route) {}
+
+func (o IPv6Option) applyRule(_ *netlink.Rule) {}
+
+// IPv6Supported returns false if IPv6 is not compiled in the kernel, or it's
+// disabled in the current netns by net.ipv6.conf.all.disable_ipv6.
+func IPv6Supported() (bool, error) {
+	if _, err := os.Stat("/proc/sys/net/ipv6"); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	disabled, err := sysctl.GetDisableIPv6("all")
+	if err != nil {
+		return false, err
+	}
+	return disabled == 0, nil
+}
+
+// missingIPv6Option returns the IPv6Option in opts, nil if AllowMissingIPv6 is
+// not passed
+func missingIPv6Option[T any](opts []T) *IPv6Option {
+	for _, opt := range opts {
+		if o, ok := any(opt).(IPv6Option); ok {
+			return &o
+		}
+	}
+	return nil
+}
+
+// skipMissingIPv6 returns true if the operation of the family should be
+// skipped, since IPv6 is unsupported and AllowMissingIPv6 is passed
+func (o *IPv6Option) skipMissingIPv6(family int, operation string) (bool, error) {
+	if o == nil || family != netlink.FAMILY_V6 {
+		return false, nil
+	}
+
+	supported, err := IPv6Supported()
+	if err != nil {
+		return false, fmt.Errorf("failed to check whether ipv6 is supported: %w", err)
+	}
+	if supported {
+		return false, nil
+	}
+	o.logger.Warn("IPv6 is unsupported in the netns, skip it", zap.String("operation", operation))
+	return true, nil
+}
+
+// skipIPv6Error returns true if err is ignored, since the kernel doesn't
+// support IPv6 and AllowMissingIPv6 is passed
+func (o *IPv6Option) skipIPv6Error(family int, operation string, err error) bool {
+	if o == nil || family != netlink.FAMILY_V6 || !errors.Is(err, unix.EAFNOSUPPORT) {
+		return false
+	}
+	o.logger.Warn("IPv6 is unsupported by the kernel, skip it", zap.String("operation", operation), zap.Error(err))
+	return true
+}
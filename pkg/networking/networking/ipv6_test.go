@@ -0,0 +1,131 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"net"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+	"github.com/spidernet-io/spiderpool/pkg/networking/sysctl"
+)
+
+var _ = Describe("AllowMissingIPv6", Label("ipv6"), func() {
+	var testNetNS ns.NetNS
+	logger := zap.NewNop()
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+
+		// the netns of the node disabling IPv6
+		err = testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			Expect(sysctl.SetDisableIPv6("all", 1)).To(Succeed())
+			Expect(sysctl.SetDisableIPv6("default", 1)).To(Succeed())
+
+			Expect(netlink.LinkAdd(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+				PeerName:  "peer12345",
+			})).To(Succeed())
+			link, err := netlink.LinkByName("net1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.LinkSetUp(link)).To(Succeed())
+			peer, err := netlink.LinkByName("peer12345")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.LinkSetUp(peer)).To(Succeed())
+			addr, err := netlink.ParseAddr("10.6.0.2/24")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("reports IPv6 as unsupported", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			supported, err := networking.IPv6Supported()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(supported).To(BeFalse())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("skips the IPv6 routes only with the option", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			dst := mustParseCIDR("fd00::/64")
+			Expect(networking.AddRoute(logger, 100, netlink.FAMILY_V6, netlink.SCOPE_UNIVERSE, "net1", dst, nil, net.ParseIP("fd00::1"))).NotTo(Succeed())
+			Expect(networking.AddRoute(logger, 100, netlink.FAMILY_V6, netlink.SCOPE_UNIVERSE, "net1", dst, nil, net.ParseIP("fd00::1"),
+				networking.AllowMissingIPv6(logger))).To(Succeed())
+
+			// the IPv4 routes are still added
+			Expect(networking.AddRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", nil, net.ParseIP("10.6.0.1"), nil,
+				networking.AllowMissingIPv6(logger), networking.WithMTU(1400))).To(Succeed())
+			routes, err := netlink.RouteListFiltered(netlink.FAMILY_ALL, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes).To(HaveLen(1))
+			Expect(routes[0].Gw.String()).To(Equal("10.6.0.1"))
+			Expect(routes[0].MTU).To(Equal(1400))
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("moves only the IPv4 routes with the option", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			Expect(networking.MoveRouteTable(logger, "net1", unix.RT_TABLE_MAIN, 100, netlink.FAMILY_V6, nil, false,
+				networking.AllowMissingIPv6(logger))).To(Succeed())
+			Expect(networking.MoveRouteTable(logger, "net1", unix.RT_TABLE_MAIN, 100, netlink.FAMILY_ALL, nil, false,
+				networking.AllowMissingIPv6(logger))).To(Succeed())
+
+			routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes).To(HaveLen(1))
+			Expect(routes[0].Dst.String()).To(Equal("10.6.0.0/24"))
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("skips the IPv6 rules with the option", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			Expect(networking.AddFromRuleTable(mustParseCIDR("fd00::/64"), 100, networking.AllowMissingIPv6(logger))).To(Succeed())
+			Expect(networking.AddSuppressPrefixRule(unix.RT_TABLE_MAIN, netlink.FAMILY_V6, 1000, 0, networking.AllowMissingIPv6(logger))).To(Succeed())
+			rules, err := netlink.RuleListFiltered(netlink.FAMILY_V6, &netlink.Rule{Table: 100}, netlink.RT_FILTER_TABLE)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rules).To(BeEmpty())
+
+			Expect(networking.AddFromRuleTable(mustParseCIDR("10.6.0.0/24"), 100, networking.AllowMissingIPv6(logger))).To(Succeed())
+			exists, err := networking.RuleExists(&netlink.Rule{Src: mustParseCIDR("10.6.0.0/24"), Table: 100, Priority: -1, Mark: -1, Mask: -1, Goto: -1,
+				SuppressIfgroup: -1, SuppressPrefixlen: -1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeTrue())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
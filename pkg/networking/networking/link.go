@@ -0,0 +1,192 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// macvlanModes is the name of each macvlan mode, as the macvlan CNI configures
+// it. The kernel reports MACVLAN_MODE_DEFAULT only if the mode is unknown.
+var macvlanModes = map[netlink.MacvlanMode]string{
+	netlink.MACVLAN_MODE_PRIVATE:  "private",
+	netlink.MACVLAN_MODE_VEPA:     "vepa",
+	netlink.MACVLAN_MODE_BRIDGE:   "bridge",
+	netlink.MACVLAN_MODE_PASSTHRU: "passthru",
+	netlink.MACVLAN_MODE_SOURCE:   "source",
+}
+
+// SetPromiscuousMode turns the promiscuous mode of the interface on or off.
+// It's a no-op if the interface is already in the mode.
+// Equivalent to: `ip link set <iface> promisc on|off`
+func SetPromiscuousMode(iface string, enable bool) error {
+	link, err := netlink.LinkByName(iface)
+	if err != nil {
+		return fmt.Errorf("failed to get link %s: %w", iface, err)
+	}
+	if (link.Attrs().Promisc == 1) == enable {
+		return nil
+	}
+
+	if enable {
+		err = netlink.SetPromiscOn(link)
+	} else {
+		err = netlink.SetPromiscOff(link)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set promisc %v for link %s: %w", enable, iface, err)
+	}
+	return nil
+}
+
+// GetPromiscuousMode returns true if the interface is in the promiscuous mode
+func GetPromiscuousMode(iface string) (bool, error) {
+	link, err := netlink.LinkByName(iface)
+	if err != nil {
+		return false, fmt.Errorf("failed to get link %s: %w", iface, err)
+	}
+	return link.Attrs().Promisc == 1, nil
+}
+
+// GetMacvlanMode returns the mode of the macvlan interface, one of bridge,
+// private, vepa, passthru and source. It's an error if the interface is not
+// a macvlan one.
+func GetMacvlanMode(iface string) (string, error) {
+	link, err := netlink.LinkByName(iface)
+	if err != nil {
+		return "", fmt.Errorf("failed to get link %s: %w", iface, err)
+	}
+
+	macvlan, ok := link.(*netlink.Macvlan)
+	if !ok {
+		return "", fmt.Errorf("link %s is %s, not macvlan", iface, link.Type())
+	}
+
+	mode, ok := macvlanModes[macvlan.Mode]
+	if !ok {
+		return "", fmt.Errorf("unknown mode %d of macvlan %s", macvlan.Mode, iface)
+	}
+	return mode, nil
+}
+
+// ErrVlanConflict is returned by EnsureVlanInterface when an interface with
+// the name of the VLAN sub-interface exists, but it's not the VLAN on the
+// parent interface.
+var ErrVlanConflict = errors.New("conflicting vlan interface")
+
+// EnsureVlanInterface creates the VLAN sub-interface <parent>.<vlanID> on the
+// parent interface if it's missing, and sets both of them up. The existing
+// interface with the name is reused only if it's the VLAN with the same ID on
+// the parent, otherwise it fails with ErrVlanConflict.
+// Equivalent to: `ip link add link <parent> name <parent>.<vlanID> type vlan id <vlanID>`
+func EnsureVlanInterface(parent string, vlanID int) (*netlink.Vlan, error) {
+	if vlanID <= 0 || vlanID > 4094 {
+		return nil, fmt.Errorf("invalid vlan id %d, it must be in range [1,4094]", vlanID)
+	}
+	name := fmt.Sprintf("%s.%d", parent, vlanID)
+	if len(name) >= unix.IFNAMSIZ {
+		return nil, fmt.Errorf("the name of vlan interface %s is longer than %d", name, unix.IFNAMSIZ-1)
+	}
+
+	parentLink, err := netlink.LinkByName(parent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the parent interface %s of vlan %d: %w", parent, vlanID, err)
+	}
+	if parentLink.Attrs().Flags&net.FlagUp == 0 {
+		if err = netlink.LinkSetUp(parentLink); err != nil {
+			return nil, fmt.Errorf("failed to set %s up: %w", parent, err)
+		}
+	}
+
+	link, err := netlink.LinkByName(name)
+	if err == nil {
+		vlan, ok := link.(*netlink.Vlan)
+		if !ok {
+			return nil, fmt.Errorf("%w: interface %s is %s, not vlan", ErrVlanConflict, name, link.Type())
+		}
+		if vlan.VlanId != vlanID || vlan.ParentIndex != parentLink.Attrs().Index {
+			return nil, fmt.Errorf("%w: interface %s is vlan %d on the interface with index %d, not vlan %d on %s",
+				ErrVlanConflict, name, vlan.VlanId, vlan.ParentIndex, vlanID, parent)
+		}
+		if vlan.Flags&net.FlagUp == 0 {
+			if err = netlink.LinkSetUp(vlan); err != nil {
+				return nil, fmt.Errorf("failed to set %s up: %w", name, err)
+			}
+		}
+		return vlan, nil
+	}
+	if !errors.As(err, &netlink.LinkNotFoundError{}) {
+		return nil, fmt.Errorf("failed to get link %s: %w", name, err)
+	}
+
+	vlan := &netlink.Vlan{
+		LinkAttrs: netlink.LinkAttrs{
+			Name:        name,
+			ParentIndex: parentLink.Attrs().Index,
+		},
+		VlanId: vlanID,
+	}
+	if err = netlink.LinkAdd(vlan); err != nil {
+		if errors.Is(err, unix.EEXIST) {
+			// created by a concurrent pod setup meanwhile, check it again
+			return EnsureVlanInterface(parent, vlanID)
+		}
+		return nil, fmt.Errorf("failed to create vlan interface %s: %w", name, err)
+	}
+	if err = netlink.LinkSetUp(vlan); err != nil {
+		return nil, fmt.Errorf("failed to set %s up: %w", name, err)
+	}
+	return vlan, nil
+}
+
+// LinkStats is the carrier state and the counters of an interface, so that
+// the reconcilers choosing the default gateway among the NICs can skip the
+// broken ones.
+type LinkStats struct {
+	Name string
+	// Carrier is true if the interface is up and its lower layer is up,
+	// as IFF_LOWER_UP
+	Carrier   bool
+	OperState string
+	RxBytes   uint64
+	TxBytes   uint64
+	RxPackets uint64
+	TxPackets uint64
+	RxErrors  uint64
+	TxErrors  uint64
+	RxDropped uint64
+	TxDropped uint64
+}
+
+// GetLinkStats returns the carrier state and the counters of the interface.
+// Equivalent to: `ip -s link show <iface>`
+func GetLinkStats(iface string) (*LinkStats, error) {
+	link, err := netlink.LinkByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link %s: %w", iface, err)
+	}
+
+	attrs := link.Attrs()
+	stats := &LinkStats{
+		Name:      attrs.Name,
+		Carrier:   attrs.Flags&net.FlagUp != 0 && attrs.RawFlags&unix.IFF_LOWER_UP != 0,
+		OperState: attrs.OperState.String(),
+	}
+	if attrs.Statistics != nil {
+		stats.RxBytes = attrs.Statistics.RxBytes
+		stats.TxBytes = attrs.Statistics.TxBytes
+		stats.RxPackets = attrs.Statistics.RxPackets
+		stats.TxPackets = attrs.Statistics.TxPackets
+		stats.RxErrors = attrs.Statistics.RxErrors
+		stats.TxErrors = attrs.Statistics.TxErrors
+		stats.RxDropped = attrs.Statistics.RxDropped
+		stats.TxDropped = attrs.Statistics.TxDropped
+	}
+	return stats, nil
+}
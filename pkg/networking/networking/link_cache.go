@@ -0,0 +1,106 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"net"
+
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+)
+
+// linkByName looks up the link from the kernel, it is replaced in the unit
+// tests to count the lookups.
+var linkByName = netlink.LinkByName
+
+// LinkCache caches the links looked up by name within a single operation, such
+// as one CNI ADD, so that each interface is resolved from the kernel only once.
+// It must never be shared across operations or network namespaces, because the
+// index behind a name changes when the link is recreated. Invalidate it after
+// any call creating, deleting, renaming, moving or modifying links. A nil
+// LinkCache looks up the links from the kernel every time. It is not safe for
+// concurrent use.
+type LinkCache struct {
+	links map[string]netlink.Link
+}
+
+// NewLinkCache creates an empty LinkCache for a single operation.
+func NewLinkCache() *LinkCache {
+	return &LinkCache{links: map[string]netlink.Link{}}
+}
+
+// LinkByName is netlink.LinkByName with the link cached, the failed lookups
+// are not cached.
+func (c *LinkCache) LinkByName(name string) (netlink.Link, error) {
+	if c == nil {
+		return linkByName(name)
+	}
+
+	if link, ok := c.links[name]; ok {
+		return link, nil
+	}
+	link, err := linkByName(name)
+	if err != nil {
+		return nil, err
+	}
+	c.links[name] = link
+	return link, nil
+}
+
+// Invalidate drops all the cached links.
+func (c *LinkCache) Invalidate() {
+	if c == nil {
+		return
+	}
+	c.links = map[string]netlink.Link{}
+}
+
+// ResolveLinkStable is ResolveLinkStable with the link cached.
+func (c *LinkCache) ResolveLinkStable(iface string) (index int, name string, err error) {
+	return resolveLinkStable(c, iface)
+}
+
+// AddRoute is AddRoute with the link cached.
+func (c *LinkCache) AddRoute(logger *zap.Logger, ruleTable, ipFamily int, scope netlink.Scope, iface string, dst *net.IPNet, v4Gw, v6Gw net.IP, opts ...RouteOption) error {
+	return addRouteByName(logger, c, ruleTable, ipFamily, scope, iface, dst, v4Gw, v6Gw, opts...)
+}
+
+// DelRoute is DelRoute with the link cached.
+func (c *LinkCache) DelRoute(ruleTable int, iface string, dst *net.IPNet) error {
+	return delRoute(c, ruleTable, iface, dst)
+}
+
+// GetRoutesByName is GetRoutesByName with the link cached.
+func (c *LinkCache) GetRoutesByName(iface string, ipfamily int) ([]netlink.Route, error) {
+	return getRoutesByName(c, iface, ipfamily)
+}
+
+// GetDefaultGatewayByName is GetDefaultGatewayByName with the link cached.
+func (c *LinkCache) GetDefaultGatewayByName(iface string, ipfamily, ruleTable int) ([]string, error) {
+	return getDefaultGatewayByName(c, iface, ipfamily, ruleTable)
+}
+
+// GetAddersByName is GetAddersByName with the link cached.
+func (c *LinkCache) GetAddersByName(iface string, ipfamily int) ([]netlink.Addr, error) {
+	return getAddersByName(c, iface, ipfamily)
+}
+
+// MoveRouteTable is MoveRouteTable with the link cached.
+func (c *LinkCache) MoveRouteTable(logger *zap.Logger, iface string, srcRuleTable, dstRuleTable, ipfamily int, skip []*net.IPNet, keepBackup bool, opts ...RouteOption) error {
+	logger.Debug("Debug MoveRouteTable", zap.String("interface", iface),
+		zap.Int("srcRuleTable", srcRuleTable), zap.Int("dstRuleTable", dstRuleTable), zap.Bool("keepBackup", keepBackup))
+	return migrateRouteTable(logger, c, iface, srcRuleTable, dstRuleTable, ipfamily, true, keepBackup, skip, opts)
+}
+
+// MoveRouteTables is MoveRouteTables with the links cached.
+func (c *LinkCache) MoveRouteTables(logger *zap.Logger, moves []RouteMove, ipfamily int, opts ...RouteOption) error {
+	return migrateRouteTables(logger, c, moves, ipfamily, true, opts)
+}
+
+// CopyRouteTable is CopyRouteTable with the link cached.
+func (c *LinkCache) CopyRouteTable(logger *zap.Logger, iface string, srcRuleTable, dstRuleTable, ipfamily int, opts ...RouteOption) error {
+	logger.Debug("Debug CopyRouteTable", zap.String("interface", iface),
+		zap.Int("srcRuleTable", srcRuleTable), zap.Int("dstRuleTable", dstRuleTable))
+	return migrateRouteTable(logger, c, iface, srcRuleTable, dstRuleTable, ipfamily, false, false, nil, opts)
+}
@@ -0,0 +1,230 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"net"
+	"testing"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// countLinkLookups counts the links looked up from the kernel until the
+// returned func is called.
+func countLinkLookups() (lookups *int, restore func()) {
+	lookups = new(int)
+	origin := linkByName
+	linkByName = func(name string) (netlink.Link, error) {
+		*lookups++
+		return origin(name)
+	}
+	return lookups, func() { linkByName = origin }
+}
+
+var _ = Describe("LinkCache", Label("link_cache"), func() {
+	var testNetNS ns.NetNS
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+	})
+
+	addVeth := func(name string) int {
+		Expect(netlink.LinkAdd(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{Name: name},
+			PeerName:  "peer12345",
+		})).To(Succeed())
+		link, err := netlink.LinkByName(name)
+		Expect(err).NotTo(HaveOccurred())
+		return link.Attrs().Index
+	}
+
+	It("looks up each link only once", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			index := addVeth("net1")
+			lookups, restore := countLinkLookups()
+			defer restore()
+
+			links := NewLinkCache()
+			for i := 0; i < 3; i++ {
+				linkIndex, name, err := links.ResolveLinkStable("net1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(linkIndex).To(Equal(index))
+				Expect(name).To(Equal("net1"))
+			}
+			Expect(*lookups).To(Equal(1))
+
+			// the failed lookups are not cached
+			for i := 0; i < 2; i++ {
+				_, _, err := links.ResolveLinkStable("net2")
+				Expect(err).To(HaveOccurred())
+			}
+			Expect(*lookups).To(Equal(3))
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("looks up the links every time if it is nil", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			addVeth("net1")
+			lookups, restore := countLinkLookups()
+			defer restore()
+
+			var links *LinkCache
+			for i := 0; i < 3; i++ {
+				_, err := links.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+			}
+			links.Invalidate()
+			Expect(*lookups).To(Equal(3))
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("resolves the recreated link after Invalidate", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			oldIndex := addVeth("net1")
+			links := NewLinkCache()
+			linkIndex, _, err := links.ResolveLinkStable("net1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(linkIndex).To(Equal(oldIndex))
+
+			link, err := netlink.LinkByName("net1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.LinkDel(link)).To(Succeed())
+			newIndex := addVeth("net1")
+			Expect(newIndex).NotTo(Equal(oldIndex))
+
+			links.Invalidate()
+			linkIndex, _, err = links.ResolveLinkStable("net1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(linkIndex).To(Equal(newIndex))
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+// BenchmarkRouteSetup runs the route helpers called by coordinator for one
+// interface during a CNI ADD, and reports the links looked up from the kernel
+// per ADD with and without the LinkCache.
+func BenchmarkRouteSetup(b *testing.B) {
+	testNetNS, err := testutils.NewNS()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer func() {
+		_ = testNetNS.Close()
+		_ = testutils.UnmountNS(testNetNS)
+	}()
+
+	logger := zap.NewNop()
+	gw := net.ParseIP("10.6.0.1")
+	var dsts []*net.IPNet
+	for _, cidr := range []string{"172.16.0.0/16", "172.17.0.0/16", "172.18.0.0/16"} {
+		_, dst, _ := net.ParseCIDR(cidr)
+		dsts = append(dsts, dst)
+	}
+
+	setup := func(links *LinkCache) error {
+		if _, err := links.GetDefaultGatewayByName("net1", netlink.FAMILY_V4, unix.RT_TABLE_MAIN); err != nil {
+			return err
+		}
+		if _, err := links.GetAddersByName("net1", netlink.FAMILY_V4); err != nil {
+			return err
+		}
+		if _, err := links.GetRoutesByName("net1", netlink.FAMILY_V4); err != nil {
+			return err
+		}
+		for _, dst := range dsts {
+			if err := links.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, gw, nil); err != nil {
+				return err
+			}
+		}
+		if err := links.CopyRouteTable(logger, "net1", unix.RT_TABLE_MAIN, 100, netlink.FAMILY_V4); err != nil {
+			return err
+		}
+		_, err := links.GetDefaultGatewayByName("net1", netlink.FAMILY_V4, 100)
+		return err
+	}
+
+	err = testNetNS.Do(func(_ ns.NetNS) error {
+		if err := netlink.LinkAdd(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+			PeerName:  "peer12345",
+		}); err != nil {
+			return err
+		}
+		for _, name := range []string{"net1", "peer12345"} {
+			link, err := netlink.LinkByName(name)
+			if err != nil {
+				return err
+			}
+			if err := netlink.LinkSetUp(link); err != nil {
+				return err
+			}
+		}
+		link, err := netlink.LinkByName("net1")
+		if err != nil {
+			return err
+		}
+		addr, err := netlink.ParseAddr("10.6.0.2/16")
+		if err != nil {
+			return err
+		}
+		return netlink.AddrAdd(link, addr)
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, cached := range []bool{false, true} {
+		name := "uncached"
+		if cached {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			lookups, restore := countLinkLookups()
+			defer restore()
+
+			// the sub-benchmark runs in its own goroutine
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				for i := 0; i < b.N; i++ {
+					var links *LinkCache
+					if cached {
+						links = NewLinkCache()
+					}
+					if err := setup(links); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(float64(*lookups)/float64(b.N), "linklookups/op")
+		})
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"errors"
+	"net"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+var _ = Describe("Link", Label("link"), func() {
+	var testNetNS ns.NetNS
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+
+		err = testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			Expect(netlink.LinkAdd(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Name: "eth0"},
+				PeerName:  "peer12345",
+			})).To(Succeed())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("turns the promiscuous mode on and off", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			promisc, err := networking.GetPromiscuousMode("eth0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(promisc).To(BeFalse())
+
+			for _, enable := range []bool{true, true, false, false} {
+				Expect(networking.SetPromiscuousMode("eth0", enable)).To(Succeed())
+				promisc, err = networking.GetPromiscuousMode("eth0")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(promisc).To(Equal(enable))
+			}
+
+			Expect(networking.SetPromiscuousMode("eth1", true)).NotTo(Succeed())
+			_, err = networking.GetPromiscuousMode("eth1")
+			Expect(err).To(HaveOccurred())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("gets the mode of the macvlan interface", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			parent, err := netlink.LinkByName("eth0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.LinkAdd(&netlink.Macvlan{
+				LinkAttrs: netlink.LinkAttrs{Name: "net1", ParentIndex: parent.Attrs().Index},
+				Mode:      netlink.MACVLAN_MODE_BRIDGE,
+			})).To(Succeed())
+
+			mode, err := networking.GetMacvlanMode("net1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mode).To(Equal("bridge"))
+
+			_, err = networking.GetMacvlanMode("eth0")
+			Expect(err).To(HaveOccurred())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("EnsureVlanInterface", func() {
+		// skipVlanUnsupported skips the spec if the kernel can't create the
+		// vlan interface, such as without the module 8021q
+		skipVlanUnsupported := func() {
+			var supported bool
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				parent, err := netlink.LinkByName("eth0")
+				if err != nil {
+					return err
+				}
+				probe := &netlink.Vlan{
+					LinkAttrs: netlink.LinkAttrs{Name: "probe.4094", ParentIndex: parent.Attrs().Index},
+					VlanId:    4094,
+				}
+				if err = netlink.LinkAdd(probe); err != nil {
+					if errors.Is(err, unix.EOPNOTSUPP) {
+						return nil
+					}
+					return err
+				}
+				supported = true
+				return netlink.LinkDel(probe)
+			})
+			Expect(err).NotTo(HaveOccurred())
+			if !supported {
+				Skip("the vlan interface is not supported by the kernel")
+			}
+		}
+
+		It("creates the vlan interface on demand and reuses it", func() {
+			skipVlanUnsupported()
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				vlan, err := networking.EnsureVlanInterface("eth0", 100)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vlan.Name).To(Equal("eth0.100"))
+
+				link, err := netlink.LinkByName("eth0.100")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(link.Type()).To(Equal("vlan"))
+				Expect(link.(*netlink.Vlan).VlanId).To(Equal(100))
+				Expect(link.Attrs().Flags & net.FlagUp).NotTo(BeZero())
+				parent, err := netlink.LinkByName("eth0")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(parent.Attrs().Flags & net.FlagUp).NotTo(BeZero())
+
+				vlan, err = networking.EnsureVlanInterface("eth0", 100)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vlan.Index).To(Equal(link.Attrs().Index))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("refuses the interface with the same name but not vlan", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "eth0.300"},
+					PeerName:  "peer300",
+				})).To(Succeed())
+				_, err := networking.EnsureVlanInterface("eth0", 300)
+				Expect(err).To(MatchError(networking.ErrVlanConflict))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("refuses the vlan interface with the same name but the wrong vlan", func() {
+			skipVlanUnsupported()
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				parent, err := netlink.LinkByName("eth0")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.LinkAdd(&netlink.Vlan{
+					LinkAttrs: netlink.LinkAttrs{Name: "eth0.100", ParentIndex: parent.Attrs().Index},
+					VlanId:    200,
+				})).To(Succeed())
+				_, err = networking.EnsureVlanInterface("eth0", 100)
+				Expect(err).To(MatchError(networking.ErrVlanConflict))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("fails if the parent interface doesn't exist", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				_, err := networking.EnsureVlanInterface("bond0", 100)
+				Expect(err).To(MatchError(ContainSubstring("parent interface bond0")))
+				_, err = networking.EnsureVlanInterface("eth0", 4095)
+				Expect(err).To(HaveOccurred())
+				_, err = networking.EnsureVlanInterface("a-long-name", 100)
+				Expect(err).To(HaveOccurred())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	It("gets the carrier state and the counters of the interface", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			stats, err := networking.GetLinkStats("eth0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats.Name).To(Equal("eth0"))
+			Expect(stats.Carrier).To(BeFalse())
+
+			for _, name := range []string{"eth0", "peer12345"} {
+				link, err := netlink.LinkByName(name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.LinkSetUp(link)).To(Succeed())
+			}
+			addr, err := netlink.ParseAddr("10.6.0.2/24")
+			Expect(err).NotTo(HaveOccurred())
+			link, err := netlink.LinkByName("eth0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+			Expect(netlink.NeighAdd(&netlink.Neigh{
+				LinkIndex:    link.Attrs().Index,
+				State:        netlink.NUD_PERMANENT,
+				IP:           net.ParseIP("10.6.0.1"),
+				HardwareAddr: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x01},
+			})).To(Succeed())
+
+			// send a packet out of eth0
+			conn, err := net.Dial("udp4", "10.6.0.1:9")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = conn.Write([]byte("ping"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conn.Close()).To(Succeed())
+
+			stats, err = networking.GetLinkStats("eth0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats.Carrier).To(BeTrue())
+			Expect(stats.OperState).To(Equal("up"))
+			Expect(stats.TxPackets).To(BeNumerically(">", 0))
+			Expect(stats.TxBytes).To(BeNumerically(">", 0))
+
+			_, err = networking.GetLinkStats("eth1")
+			Expect(err).To(HaveOccurred())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -4,99 +4,89 @@
 package networking
 
 import (
-	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"math/big"
 	"net"
-	"net/netip"
 	"os"
-	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/vishvananda/netlink"
-	"go.uber.org/zap"
+
+	"github.com/spidernet-io/spiderpool/pkg/lock"
+	"github.com/spidernet-io/spiderpool/pkg/networking/sysctl"
 )
 
-// OverwriteHwAddress override the hardware address of the specified interface.
-func OverwriteHwAddress(logger *zap.Logger, netns ns.NetNS, macPrefix, iface string) (string, error) {
-	ips, err := IPAddressByName(netns, iface, netlink.FAMILY_ALL)
-	if err != nil {
-		logger.Error("failed to get IPAddressByName", zap.String("interface", iface), zap.Error(err))
-		return "", err
+// ValidateMACPrefix validates the podMACPrefix, the first two octets of the
+// MAC addresses of the pods, e.g. "0a:1b". The first octet must be a unicast
+// and locally administered one, so that the MAC addresses neither break the
+// traffic nor clash with the ones assigned by the vendors.
+func ValidateMACPrefix(prefix string) error {
+	octets := strings.Split(prefix, ":")
+	if len(octets) != 2 {
+		return fmt.Errorf("invalid MAC prefix '%s', it must be two octets like '0a:1b'", prefix)
 	}
 
-	// we only focus on first element
-	nAddr, err := netip.ParseAddr(ips[0].IP.String())
-	if err != nil {
-		logger.Error("failed to ParsePrefix", zap.Error(err))
-		return "", err
+	var first byte
+	for i, octet := range octets {
+		b, err := hex.DecodeString(octet)
+		if err != nil || len(b) != 1 {
+			return fmt.Errorf("invalid MAC prefix '%s', octet '%s' is not a hex byte", prefix, octet)
+		}
+		if i == 0 {
+			first = b[0]
+		}
 	}
 
-	suffix, err := inetAton(nAddr)
-	if err != nil {
-		logger.Error("failed to inetAton", zap.Error(err))
-		return "", err
+	if first&0x01 != 0 {
+		return fmt.Errorf("invalid MAC prefix '%s', it must be unicast, the lowest bit of the first octet must be 0", prefix)
 	}
-
-	// newmac = xx:xx + xx:xx:xx:xx
-	hwAddr := macPrefix + ":" + suffix
-	err = netns.Do(func(netNS ns.NetNS) error {
-		link, err := netlink.LinkByName(iface)
-		if err != nil {
-			logger.Error(err.Error())
-			return err
-		}
-		return netlink.LinkSetHardwareAddr(link, parseMac(hwAddr))
-	})
-
-	if err != nil {
-		logger.Error("failed to OverrideHwAddress", zap.String("hardware address", hwAddr), zap.Error(err))
-		return "", err
+	if first&0x02 == 0 {
+		return fmt.Errorf("invalid MAC prefix '%s', it must be locally administered, the second lowest bit of the first octet must be 1", prefix)
 	}
-	return hwAddr, nil
+	return nil
 }
 
-// parseMac parse hardware addr from given string
-func parseMac(s string) net.HardwareAddr {
-	hardwareAddr, err := net.ParseMAC(s)
-	if err != nil {
-		panic(err)
+// DerivePodMAC derives the MAC address of the iface of the pod from the
+// macPrefix and the pod UID, the last four octets are hashed from the pod UID,
+// the iface and the salt. Another salt derives another MAC address for the
+// same iface, which is used to resolve the collision on the node.
+func DerivePodMAC(macPrefix, podUID, iface string, salt int) (net.HardwareAddr, error) {
+	if err := ValidateMACPrefix(macPrefix); err != nil {
+		return nil, err
 	}
-	return hardwareAddr
-}
-
-// inetAton converts an IP Address (IPv4 or IPv6) netip.addr object to a hexadecimal representation.
-// for ipv4: convert a full IP address(length: 4 B) to hexadecimal representation.
-// for ipv6: convert
-func inetAton(ip netip.Addr) (string, error) {
-	if ip.AsSlice() == nil {
-		return "", fmt.Errorf("invalid ip address")
+	if podUID == "" {
+		return nil, fmt.Errorf("empty pod UID")
 	}
 
-	ipInt := big.NewInt(0)
-	// 32 bit -> 4 B
-	hexCode := make([]byte, hex.EncodedLen(ip.BitLen()))
-	ipInt.SetBytes(ip.AsSlice()[:])
-	hex.Encode(hexCode, ipInt.Bytes())
+	prefix, err := hex.DecodeString(strings.ReplaceAll(macPrefix, ":", ""))
+	if err != nil {
+		return nil, err
+	}
 
-	if ip.Is6() {
-		// for ipv6: 128 bit = 32 hex
-		// take the last 8 hex as the hardware address
-		return convertHex2Mac(hexCode[24:]), nil
+	seed := podUID + "/" + iface
+	if salt > 0 {
+		seed += "/" + strconv.Itoa(salt)
 	}
+	sum := sha256.Sum256([]byte(seed))
 
-	return convertHex2Mac(hexCode), nil
+	return append(net.HardwareAddr(prefix), sum[:4]...), nil
 }
 
-// convertHex2Mac convert hexcode to 4B hardware address
-// convert ip(hex) to "xx:xx:xx:xx"
-func convertHex2Mac(hexCode []byte) string {
-	regexSpilt, err := regexp.Compile(".{2}")
-	if err != nil {
-		panic(err)
-	}
-	return string(bytes.Join(regexSpilt.FindAll(hexCode, 4), []byte(":")))
+// SetHwAddress sets the hardware address of the iface in the netns
+func SetHwAddress(netns ns.NetNS, iface string, hwAddr net.HardwareAddr) error {
+	return netns.Do(func(_ ns.NetNS) error {
+		link, err := netlink.LinkByName(iface)
+		if err != nil {
+			return err
+		}
+		if err = netlink.LinkSetHardwareAddr(link, hwAddr); err != nil {
+			return fmt.Errorf("failed to set the hardware address %s of %s: %w", hwAddr, iface, err)
+		}
+		return nil
+	})
 }
 
 // GetHwAddressByName get hardware address of veth pair device
@@ -165,3 +155,179 @@ func AddStaticNeighborTable(linkIndex int, dstIP net.IP, hwAddress net.HardwareA
 
 	return nil
 }
+
+// FlushNeighbors deletes all the neighbor entries of the ipFamily on the iface,
+// the proxy entries are kept. The ipFamily may be netlink.FAMILY_ALL.
+// Equivalent to: `ip neigh flush dev <iface>`
+func FlushNeighbors(iface string, ipFamily int) error {
+	switch ipFamily {
+	case netlink.FAMILY_V4, netlink.FAMILY_V6, netlink.FAMILY_ALL:
+	default:
+		return fmt.Errorf("unknown ipFamily %v", ipFamily)
+	}
+
+	link, err := netlink.LinkByName(iface)
+	if err != nil {
+		return err
+	}
+
+	neighs, err := netlink.NeighList(link.Attrs().Index, ipFamily)
+	if err != nil {
+		return fmt.Errorf("failed to list neigh of %s: %w", iface, err)
+	}
+
+	for idx := range neighs {
+		if err = netlink.NeighDel(&neighs[idx]); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete neigh %v from %s: %w", neighs[idx].IP, iface, err)
+		}
+	}
+	return nil
+}
+
+// AddProxyNDP adds the proxy neighbor entry of the ip to the iface, so that the
+// kernel answers the neighbor solicitation for the ip received on the iface.
+// The proxy_ndp sysctl of the iface must be enabled for the entry to work.
+// Equivalent to: `ip -6 neigh add proxy <ip> dev <iface>`
+func AddProxyNDP(iface string, ip net.IP) error {
+	neigh, err := newProxyNeigh(iface, ip, netlink.FAMILY_V6)
+	if err != nil {
+		return err
+	}
+
+	if err = netlink.NeighAdd(neigh); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("failed to add proxy neigh %v to %s: %w", ip, iface, err)
+	}
+	return nil
+}
+
+// DelProxyNDP deletes the proxy neighbor entry of the ip from the iface
+// Equivalent to: `ip -6 neigh del proxy <ip> dev <iface>`
+func DelProxyNDP(iface string, ip net.IP) error {
+	neigh, err := newProxyNeigh(iface, ip, netlink.FAMILY_V6)
+	if err != nil {
+		return err
+	}
+
+	if err = netlink.NeighDel(neigh); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete proxy neigh %v from %s: %w", ip, iface, err)
+	}
+	return nil
+}
+
+// proxyNDPEnabled records the interfaces whose proxy_ndp is enabled by
+// AddProxyNeighbor, only these ones are disabled by DelProxyNeighbor.
+var proxyNDPEnabled = struct {
+	lock.Mutex
+	links map[int]struct{}
+}{links: map[int]struct{}{}}
+
+// AddProxyNeighbor adds the proxy neighbor entry of the ip to the iface, so
+// that the kernel answers the ARP request or the neighbor solicitation for the
+// ip received on the iface. The IPv4 entry works without proxy_arp, which is
+// left untouched since it would answer for every routable address, while the
+// proxy_ndp of the iface is enabled for the IPv6 entry if it's not yet.
+// Equivalent to: `ip neigh add proxy <ip> dev <iface>`
+func AddProxyNeighbor(iface string, ip net.IP, ipFamily int) error {
+	neigh, err := newProxyNeigh(iface, ip, ipFamily)
+	if err != nil {
+		return err
+	}
+
+	if ipFamily == netlink.FAMILY_V6 {
+		if err = enableProxyNDP(iface, neigh.LinkIndex); err != nil {
+			return err
+		}
+	}
+
+	if err = netlink.NeighAdd(neigh); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("failed to add proxy neigh %v to %s: %w", ip, iface, err)
+	}
+	return nil
+}
+
+// DelProxyNeighbor deletes the proxy neighbor entry of the ip from the iface.
+// When it's the last IPv6 proxy neighbor entry of the iface, proxy_ndp is
+// disabled if it was enabled by AddProxyNeighbor, the value set by others,
+// such as sysctl.SetProxyNDP, is kept.
+// Equivalent to: `ip neigh del proxy <ip> dev <iface>`
+func DelProxyNeighbor(iface string, ip net.IP, ipFamily int) error {
+	neigh, err := newProxyNeigh(iface, ip, ipFamily)
+	if err != nil {
+		return err
+	}
+
+	if err = netlink.NeighDel(neigh); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete proxy neigh %v from %s: %w", ip, iface, err)
+	}
+
+	if ipFamily != netlink.FAMILY_V6 {
+		return nil
+	}
+	neighs, err := netlink.NeighProxyList(neigh.LinkIndex, ipFamily)
+	if err != nil {
+		return fmt.Errorf("failed to list proxy neigh of %s: %w", iface, err)
+	}
+	if len(neighs) > 0 {
+		return nil
+	}
+	return restoreProxyNDP(iface, neigh.LinkIndex)
+}
+
+func enableProxyNDP(iface string, linkIndex int) error {
+	proxyNDPEnabled.Lock()
+	defer proxyNDPEnabled.Unlock()
+
+	enabled, err := sysctl.GetProxyNDP(iface)
+	if err != nil {
+		return err
+	}
+	if enabled {
+		return nil
+	}
+	if err = sysctl.SetProxyNDP(iface, true); err != nil {
+		return err
+	}
+	proxyNDPEnabled.links[linkIndex] = struct{}{}
+	return nil
+}
+
+func restoreProxyNDP(iface string, linkIndex int) error {
+	proxyNDPEnabled.Lock()
+	defer proxyNDPEnabled.Unlock()
+
+	if _, ok := proxyNDPEnabled.links[linkIndex]; !ok {
+		return nil
+	}
+	if err := sysctl.SetProxyNDP(iface, false); err != nil {
+		return err
+	}
+	delete(proxyNDPEnabled.links, linkIndex)
+	return nil
+}
+
+func newProxyNeigh(iface string, ip net.IP, ipFamily int) (*netlink.Neigh, error) {
+	switch ipFamily {
+	case netlink.FAMILY_V4:
+		if ip.To4() == nil {
+			return nil, fmt.Errorf("proxy arp requires an IPv4 address, got %v", ip)
+		}
+	case netlink.FAMILY_V6:
+		if ip.To4() != nil || ip.To16() == nil {
+			return nil, fmt.Errorf("proxy ndp requires an IPv6 address, got %v", ip)
+		}
+	default:
+		return nil, fmt.Errorf("unknown ipFamily %v", ipFamily)
+	}
+
+	link, err := netlink.LinkByName(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	return &netlink.Neigh{
+		LinkIndex: link.Attrs().Index,
+		Family:    ipFamily,
+		Flags:     netlink.NTF_PROXY,
+		IP:        ip,
+	}, nil
+}
@@ -0,0 +1,285 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"net"
+	"os"
+	"strings"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+	"github.com/spidernet-io/spiderpool/pkg/networking/sysctl"
+)
+
+var _ = Describe("Neigh", Label("neigh"), func() {
+	var testNetNS ns.NetNS
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+	})
+
+	Context("AddProxyNDP", func() {
+		It("adds and deletes exactly the proxy entry of the ip", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "veth12345"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+
+				proxyIPs := func() []string {
+					neighs, err := netlink.NeighProxyList(0, netlink.FAMILY_V6)
+					Expect(err).NotTo(HaveOccurred())
+					var ips []string
+					for _, neigh := range neighs {
+						ips = append(ips, neigh.IP.String())
+					}
+					return ips
+				}
+
+				Expect(networking.AddProxyNDP("veth12345", net.ParseIP("fd00:10:6::2"))).To(Succeed())
+				Expect(networking.AddProxyNDP("veth12345", net.ParseIP("fd00:10:6::3"))).To(Succeed())
+				// it is idempotent
+				Expect(networking.AddProxyNDP("veth12345", net.ParseIP("fd00:10:6::2"))).To(Succeed())
+				Expect(proxyIPs()).To(ConsistOf("fd00:10:6::2", "fd00:10:6::3"))
+
+				Expect(networking.DelProxyNDP("veth12345", net.ParseIP("fd00:10:6::2"))).To(Succeed())
+				Expect(proxyIPs()).To(ConsistOf("fd00:10:6::3"))
+				Expect(networking.DelProxyNDP("veth12345", net.ParseIP("fd00:10:6::2"))).To(Succeed())
+
+				Expect(networking.AddProxyNDP("veth12345", net.ParseIP("10.6.0.2"))).NotTo(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("AddProxyNeighbor", func() {
+		It("adds the proxy entries and only toggles the proxy_ndp it enabled", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "veth12345"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+
+				proxyIPs := func(family int) []string {
+					neighs, err := netlink.NeighProxyList(0, family)
+					Expect(err).NotTo(HaveOccurred())
+					var ips []string
+					for _, neigh := range neighs {
+						Expect(neigh.Flags & netlink.NTF_PROXY).NotTo(BeZero())
+						ips = append(ips, neigh.IP.String())
+					}
+					return ips
+				}
+				sysctlValue := func(path string) string {
+					data, err := os.ReadFile(path)
+					Expect(err).NotTo(HaveOccurred())
+					return strings.TrimSpace(string(data))
+				}
+				const (
+					proxyARP = "/proc/sys/net/ipv4/conf/veth12345/proxy_arp"
+					proxyNDP = "/proc/sys/net/ipv6/conf/veth12345/proxy_ndp"
+				)
+
+				Expect(networking.AddProxyNeighbor("veth12345", net.ParseIP("10.6.0.2"), netlink.FAMILY_V4)).To(Succeed())
+				Expect(networking.AddProxyNeighbor("veth12345", net.ParseIP("10.6.0.3"), netlink.FAMILY_V4)).To(Succeed())
+				Expect(networking.AddProxyNeighbor("veth12345", net.ParseIP("fd00:10:6::2"), netlink.FAMILY_V6)).To(Succeed())
+				Expect(networking.AddProxyNeighbor("veth12345", net.ParseIP("fd00:10:6::3"), netlink.FAMILY_V6)).To(Succeed())
+				Expect(proxyIPs(netlink.FAMILY_V4)).To(ConsistOf("10.6.0.2", "10.6.0.3"))
+				Expect(proxyIPs(netlink.FAMILY_V6)).To(ConsistOf("fd00:10:6::2", "fd00:10:6::3"))
+				// the IPv4 proxy entries work without proxy_arp
+				Expect(sysctlValue(proxyARP)).To(Equal("0"))
+				Expect(sysctlValue(proxyNDP)).To(Equal("1"))
+
+				Expect(networking.DelProxyNeighbor("veth12345", net.ParseIP("10.6.0.2"), netlink.FAMILY_V4)).To(Succeed())
+				Expect(networking.DelProxyNeighbor("veth12345", net.ParseIP("10.6.0.3"), netlink.FAMILY_V4)).To(Succeed())
+				Expect(proxyIPs(netlink.FAMILY_V4)).To(BeEmpty())
+				Expect(sysctlValue(proxyARP)).To(Equal("0"))
+
+				// the proxy_ndp is kept until the last IPv6 entry is deleted
+				Expect(networking.DelProxyNeighbor("veth12345", net.ParseIP("fd00:10:6::2"), netlink.FAMILY_V6)).To(Succeed())
+				Expect(sysctlValue(proxyNDP)).To(Equal("1"))
+				Expect(networking.DelProxyNeighbor("veth12345", net.ParseIP("fd00:10:6::3"), netlink.FAMILY_V6)).To(Succeed())
+				Expect(proxyIPs(netlink.FAMILY_V6)).To(BeEmpty())
+				Expect(sysctlValue(proxyNDP)).To(Equal("0"))
+
+				// the proxy_ndp enabled by others is never disabled
+				Expect(sysctl.SetProxyNDP("veth12345", true)).To(Succeed())
+				Expect(networking.AddProxyNeighbor("veth12345", net.ParseIP("fd00:10:6::2"), netlink.FAMILY_V6)).To(Succeed())
+				Expect(networking.DelProxyNeighbor("veth12345", net.ParseIP("fd00:10:6::2"), netlink.FAMILY_V6)).To(Succeed())
+				Expect(sysctlValue(proxyNDP)).To(Equal("1"))
+
+				Expect(networking.AddProxyNeighbor("veth12345", net.ParseIP("fd00:10:6::3"), netlink.FAMILY_V4)).NotTo(Succeed())
+				Expect(networking.AddProxyNeighbor("veth12345", net.ParseIP("10.6.0.4"), netlink.FAMILY_V6)).NotTo(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("FlushNeighbors", func() {
+		It("deletes the neighbor entries of the family on the iface", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "veth12345"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"veth12345", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+
+				link, err := netlink.LinkByName("veth12345")
+				Expect(err).NotTo(HaveOccurred())
+				peer, err := netlink.LinkByName("peer12345")
+				Expect(err).NotTo(HaveOccurred())
+
+				hwAddr, err := net.ParseMAC("aa:bb:cc:dd:ee:01")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networking.AddStaticNeighborTable(link.Attrs().Index, net.ParseIP("10.6.0.2"), hwAddr)).To(Succeed())
+				Expect(networking.AddStaticNeighborTable(link.Attrs().Index, net.ParseIP("10.6.0.3"), hwAddr)).To(Succeed())
+				Expect(networking.AddStaticNeighborTable(link.Attrs().Index, net.ParseIP("fd00:10:6::2"), hwAddr)).To(Succeed())
+				Expect(networking.AddStaticNeighborTable(peer.Attrs().Index, net.ParseIP("10.6.0.4"), hwAddr)).To(Succeed())
+				Expect(networking.AddProxyNeighbor("veth12345", net.ParseIP("10.6.0.5"), netlink.FAMILY_V4)).To(Succeed())
+
+				neighIPs := func(linkIndex, family int) []string {
+					neighs, err := netlink.NeighList(linkIndex, family)
+					Expect(err).NotTo(HaveOccurred())
+					var ips []string
+					for _, neigh := range neighs {
+						ips = append(ips, neigh.IP.String())
+					}
+					return ips
+				}
+				Expect(neighIPs(link.Attrs().Index, netlink.FAMILY_V4)).To(ConsistOf("10.6.0.2", "10.6.0.3"))
+
+				Expect(networking.FlushNeighbors("veth12345", netlink.FAMILY_V4)).To(Succeed())
+				Expect(neighIPs(link.Attrs().Index, netlink.FAMILY_V4)).To(BeEmpty())
+				Expect(neighIPs(link.Attrs().Index, netlink.FAMILY_V6)).To(ContainElement("fd00:10:6::2"))
+				// the neighbor entries of the other links and the proxy entries are kept
+				Expect(neighIPs(peer.Attrs().Index, netlink.FAMILY_V4)).To(ConsistOf("10.6.0.4"))
+				proxies, err := netlink.NeighProxyList(link.Attrs().Index, netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(proxies).To(HaveLen(1))
+
+				Expect(networking.FlushNeighbors("veth12345", netlink.FAMILY_ALL)).To(Succeed())
+				Expect(neighIPs(link.Attrs().Index, netlink.FAMILY_ALL)).To(BeEmpty())
+
+				Expect(networking.FlushNeighbors("veth12345", 100)).NotTo(Succeed())
+				Expect(networking.FlushNeighbors("none12345", netlink.FAMILY_V4)).NotTo(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("MAC", Label("mac"), func() {
+	Context("ValidateMACPrefix", func() {
+		It("accepts the unicast and locally administered prefix", func() {
+			Expect(networking.ValidateMACPrefix("0a:1b")).To(Succeed())
+			Expect(networking.ValidateMACPrefix("F2:00")).To(Succeed())
+		})
+
+		DescribeTable("rejects the invalid prefix",
+			func(prefix string) {
+				Expect(networking.ValidateMACPrefix(prefix)).NotTo(Succeed())
+			},
+			Entry("empty", ""),
+			Entry("one octet", "0a"),
+			Entry("three octets", "0a:1b:2c"),
+			Entry("not hex", "0a:zz"),
+			Entry("too long octet", "0a:1bc"),
+			Entry("multicast", "0b:1b"),
+			Entry("universally administered", "08:1b"),
+		)
+	})
+
+	Context("DerivePodMAC", func() {
+		It("derives the MAC from the prefix, the pod UID, the iface and the salt", func() {
+			hwAddr, err := networking.DerivePodMAC("0a:1b", "a1b2c3d4", "net1", 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hwAddr).To(HaveLen(6))
+			Expect(strings.HasPrefix(hwAddr.String(), "0a:1b:")).To(BeTrue())
+
+			again, err := networking.DerivePodMAC("0a:1b", "a1b2c3d4", "net1", 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(again).To(Equal(hwAddr))
+
+			for _, other := range []struct {
+				uid, iface string
+				salt       int
+			}{
+				{"e5f6a7b8", "net1", 0},
+				{"a1b2c3d4", "eth0", 0},
+				{"a1b2c3d4", "net1", 1},
+			} {
+				otherAddr, err := networking.DerivePodMAC("0a:1b", other.uid, other.iface, other.salt)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(otherAddr).NotTo(Equal(hwAddr))
+			}
+		})
+
+		It("fails with the invalid prefix or the empty pod UID", func() {
+			_, err := networking.DerivePodMAC("0b:1b", "a1b2c3d4", "net1", 0)
+			Expect(err).To(HaveOccurred())
+			_, err = networking.DerivePodMAC("0a:1b", "", "net1", 0)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("SetHwAddress", func() {
+		It("sets the hardware address of the iface in the netns", func() {
+			testNetNS, err := testutils.NewNS()
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				Expect(testNetNS.Close()).To(Succeed())
+				Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+			})
+
+			err = testNetNS.Do(func(_ ns.NetNS) error {
+				return netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "veth12345"},
+					PeerName:  "peer12345",
+				})
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			hwAddr, err := networking.DerivePodMAC("0a:1b", "a1b2c3d4", "veth12345", 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(networking.SetHwAddress(testNetNS, "veth12345", hwAddr)).To(Succeed())
+			Expect(networking.SetHwAddress(testNetNS, "none12345", hwAddr)).NotTo(Succeed())
+
+			err = testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				link, err := netlink.LinkByName("veth12345")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(link.Attrs().HardwareAddr).To(Equal(hwAddr))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,88 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/spiderpool/pkg/lock"
+)
+
+// netnsKey identifies a network namespace by the inode of its nsfs file,
+// which is the same for all the paths and fds referring to the netns
+type netnsKey struct {
+	dev uint64
+	ino uint64
+}
+
+type netnsLockEntry struct {
+	lock.Mutex
+	refs int
+}
+
+var (
+	netnsLocksMutex lock.Mutex
+	netnsLocks      = map[netnsKey]*netnsLockEntry{}
+)
+
+// WithNetnsLock runs fn while holding the lock of the netns, so that the
+// mutating operations on the same netns are serialized, both among the
+// goroutines of this process and among the processes, such as the CNI
+// invocations for the different interfaces of the same pod.
+// The lock is not reentrant, fn must not call WithNetnsLock for the same netns.
+// The read-only helpers don't need the lock.
+func WithNetnsLock(netns ns.NetNS, fn func() error) error {
+	var stat unix.Stat_t
+	if err := unix.Fstat(int(netns.Fd()), &stat); err != nil {
+		return fmt.Errorf("failed to stat netns %s: %w", netns.Path(), err)
+	}
+	key := netnsKey{dev: uint64(stat.Dev), ino: stat.Ino}
+
+	// the flock is held by the open file description, the goroutines sharing
+	// it are serialized by the in-process lock
+	entry := acquireNetnsLock(key)
+	defer releaseNetnsLock(key, entry)
+
+	f, err := os.Open(netns.Path())
+	if err != nil {
+		return fmt.Errorf("failed to open netns %s: %w", netns.Path(), err)
+	}
+	// closing the file releases the flock
+	defer f.Close()
+
+	if err = unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock netns %s: %w", netns.Path(), err)
+	}
+
+	return fn()
+}
+
+func acquireNetnsLock(key netnsKey) *netnsLockEntry {
+	netnsLocksMutex.Lock()
+	entry, ok := netnsLocks[key]
+	if !ok {
+		entry = &netnsLockEntry{}
+		netnsLocks[key] = entry
+	}
+	entry.refs++
+	netnsLocksMutex.Unlock()
+
+	entry.Lock()
+	return entry
+}
+
+func releaseNetnsLock(key netnsKey, entry *netnsLockEntry) {
+	entry.Unlock()
+
+	netnsLocksMutex.Lock()
+	entry.refs--
+	if entry.refs == 0 {
+		delete(netnsLocks, key)
+	}
+	netnsLocksMutex.Unlock()
+}
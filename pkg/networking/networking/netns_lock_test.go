@@ -0,0 +1,114 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+var _ = Describe("NetnsLock", Label("netns_lock"), func() {
+	var testNetNS ns.NetNS
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+	})
+
+	It("serializes the concurrent mutations of the same netns", func() {
+		const ruleTable = 100
+		const workers = 20
+
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			Expect(netlink.LinkAdd(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+				PeerName:  "net1-peer",
+			})).To(Succeed())
+			for _, name := range []string{"net1", "net1-peer"} {
+				link, err := netlink.LinkByName(name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.LinkSetUp(link)).To(Succeed())
+			}
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		var inside, maxInside int32
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// every worker opens its own handle of the netns, as the CNI invocations do
+				netns, err := ns.GetNS(testNetNS.Path())
+				if err != nil {
+					errs <- err
+					return
+				}
+				defer netns.Close()
+
+				errs <- networking.WithNetnsLock(netns, func() error {
+					n := atomic.AddInt32(&inside, 1)
+					defer atomic.AddInt32(&inside, -1)
+					for {
+						old := atomic.LoadInt32(&maxInside)
+						if n <= old || atomic.CompareAndSwapInt32(&maxInside, old, n) {
+							break
+						}
+					}
+
+					// a check-then-act sequence, which is corrupted if interleaved
+					return netns.Do(func(_ ns.NetNS) error {
+						routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: ruleTable}, netlink.RT_FILTER_TABLE)
+						if err != nil {
+							return err
+						}
+						_, dst, err := net.ParseCIDR(fmt.Sprintf("10.%d.0.0/16", len(routes)))
+						if err != nil {
+							return err
+						}
+						return networking.AddRoute(zap.NewNop(), ruleTable, netlink.FAMILY_V4, netlink.SCOPE_LINK, "net1", dst, nil, nil)
+					})
+				})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(maxInside).To(BeEquivalentTo(1))
+
+		err = testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: ruleTable}, netlink.RT_FILTER_TABLE)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes).To(HaveLen(workers))
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
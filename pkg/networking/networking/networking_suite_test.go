@@ -0,0 +1,24 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"net"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestNetworking(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Networking Suite", Label("networking", "unitest"))
+}
+
+// mustParseCIDR parses the cidr of the test cases, which must be valid
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	Expect(err).NotTo(HaveOccurred())
+	return ipNet
+}
@@ -4,25 +4,105 @@
 package networking
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/vishvananda/netlink"
 	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
+// DefaultMarkRulePriority is the priority of the mark rule to the hostRuleTable
+// added by coordinator
+const DefaultMarkRulePriority = 1000
+
 var (
-	defaultRulePriority = 1000
+	// backupRouteMetricBump is added to the metric of the source route kept as backup by MoveRouteTable
+	backupRouteMetricBump = 100
+	// maxRoutesPerTable is the cap of the routes in a table, 0 means unlimited
+	maxRoutesPerTable = 0
 )
 
+// ErrTableFull is returned when the route table already has maxRoutesPerTable routes
+var ErrTableFull = errors.New("route table is full")
+
+// ErrFamilyMismatch is returned when the ipFamily, the destination and the
+// gateway of the route are not of the same family. The family of the missing
+// destination or gateway is FAMILY_ALL.
+type ErrFamilyMismatch struct {
+	IPFamily  int
+	DstFamily int
+	GwFamily  int
+}
+
+func (e *ErrFamilyMismatch) Error() string {
+	return fmt.Sprintf("the family of the destination (%s) or the gateway (%s) doesn't match the ipFamily (%s)",
+		familyName(e.DstFamily), familyName(e.GwFamily), familyName(e.IPFamily))
+}
+
+func newFamilyMismatch(ipFamily int, dst *net.IPNet, gw net.IP) *ErrFamilyMismatch {
+	err := &ErrFamilyMismatch{IPFamily: ipFamily, DstFamily: netlink.FAMILY_ALL, GwFamily: netlink.FAMILY_ALL}
+	if dst != nil {
+		err.DstFamily = ipFamilyOf(dst.IP)
+	}
+	if gw != nil {
+		err.GwFamily = ipFamilyOf(gw)
+	}
+	return err
+}
+
+func familyName(family int) string {
+	switch family {
+	case netlink.FAMILY_V4:
+		return "ipv4"
+	case netlink.FAMILY_V6:
+		return "ipv6"
+	case netlink.FAMILY_ALL:
+		return "all"
+	}
+	return strconv.Itoa(family)
+}
+
+// SetMaxRoutesPerTable sets the cap of the routes in a table for AddRoute,
+// 0 means unlimited
+func SetMaxRoutesPerTable(maxRoutes int) {
+	maxRoutesPerTable = maxRoutes
+}
+
+// ResolveLinkStable resolves the interface name to its link index and current name.
+// The index of a link is kept when the link is renamed (e.g. a CNI renames the
+// temporary interface to net1), so the route helpers resolve the name only once
+// and then operate by the index. Callers that hold the index should not look up
+// the link by name again.
+func ResolveLinkStable(iface string) (index int, name string, err error) {
+	return resolveLinkStable(nil, iface)
+}
+
+func resolveLinkStable(links *LinkCache, iface string) (index int, name string, err error) {
+	link, err := links.LinkByName(iface)
+	if err != nil {
+		return -1, "", err
+	}
+	return link.Attrs().Index, link.Attrs().Name, nil
+}
+
 // GetRoutesByName return all routes is belonged to specify interface
 // filter by family also
 func GetRoutesByName(iface string, ipfamily int) (routes []netlink.Route, err error) {
+	return getRoutesByName(nil, iface, ipfamily)
+}
+
+func getRoutesByName(links *LinkCache, iface string, ipfamily int) (routes []netlink.Route, err error) {
 	var link netlink.Link
 	if iface != "" {
-		link, err = netlink.LinkByName(iface)
+		link, err = links.LinkByName(iface)
 		if err != nil {
 			return nil, err
 		}
@@ -31,27 +111,33 @@ func GetRoutesByName(iface string, ipfamily int) (routes []netlink.Route, err er
 	return netlink.RouteList(link, ipfamily)
 }
 
-func GetDefaultGatewayByName(iface string, ipfamily int) ([]string, error) {
-	routes, err := GetRoutesByName("", ipfamily)
+// GetDefaultGatewayByName returns the gateways of the default routes of
+// the interface in the ruleTable
+func GetDefaultGatewayByName(iface string, ipfamily, ruleTable int) ([]string, error) {
+	return getDefaultGatewayByName(nil, iface, ipfamily, ruleTable)
+}
+
+func getDefaultGatewayByName(links *LinkCache, iface string, ipfamily, ruleTable int) ([]string, error) {
+	routes, err := netlink.RouteListFiltered(ipfamily, &netlink.Route{Table: ruleTable}, netlink.RT_FILTER_TABLE)
 	if err != nil {
 		return nil, err
 	}
 
-	link, err := netlink.LinkByName(iface)
+	linkIndex, _, err := resolveLinkStable(links, iface)
 	if err != nil {
 		return nil, err
 	}
 
 	gws := make([]string, 0)
 	for _, route := range routes {
-		if route.LinkIndex == link.Attrs().Index {
-			if route.Dst == nil || route.Dst.IP.Equal(net.IPv4zero) {
+		if route.LinkIndex == linkIndex {
+			if isDefaultRoute(&route) {
 				gws = append(gws, route.Gw.String())
 			}
 		} else {
 			if len(route.MultiPath) > 0 {
 				for _, r := range route.MultiPath {
-					if r.LinkIndex == link.Attrs().Index {
+					if r.LinkIndex == linkIndex {
 						gws = append(gws, r.Gw.String())
 						break
 					}
@@ -62,173 +148,1764 @@ func GetDefaultGatewayByName(iface string, ipfamily int) ([]string, error) {
 	return gws, nil
 }
 
-func AddToRuleTable(dst *net.IPNet, ruleTable int) error {
+// RuleOption sets the optional attributes of the rule added by the rule
+// helpers, the rule is deleted by the Del* helper with the same options. The
+// helpers taking the explicit priority only honor AllowMissingIPv6.
+type RuleOption interface {
+	applyRule(rule *netlink.Rule)
+}
+
+type ruleOptionFunc func(rule *netlink.Rule)
+
+func (f ruleOptionFunc) applyRule(rule *netlink.Rule) { f(rule) }
+
+// WithRulePriority sets the priority of the rule, otherwise it is assigned
+// by the kernel.
+// Equivalent: `ip rule add <rule> pref <priority>`
+func WithRulePriority(priority int) RuleOption {
+	return ruleOptionFunc(func(rule *netlink.Rule) {
+		rule.Priority = priority
+	})
+}
+
+// AddToRuleTable equivalent to: `ip rule add to <cidr> lookup <ruletable>`
+func AddToRuleTable(dst *net.IPNet, ruleTable int, opts ...RuleOption) error {
+	return addRule(newToRule(dst, ruleTable, opts...), opts...)
+}
+
+// DelToRuleTable deletes the rule added by AddToRuleTable with the same options.
+// Equivalent to: `ip rule del to <cidr> lookup <ruletable>`
+func DelToRuleTable(dst *net.IPNet, ruleTable int, opts ...RuleOption) error {
+	return netlink.RuleDel(newToRule(dst, ruleTable, opts...))
+}
+
+// newToRule builds the rule for both adding and deleting, so that the rule
+// to delete matches all the fields of the added one.
+func newToRule(dst *net.IPNet, ruleTable int, opts ...RuleOption) *netlink.Rule {
 	rule := netlink.NewRule()
 	rule.Table = ruleTable
 	rule.Dst = dst
-	return netlink.RuleAdd(rule)
+	if dst != nil {
+		rule.Family = ipFamilyOf(dst.IP)
+	}
+	for _, opt := range opts {
+		opt.applyRule(rule)
+	}
+	return rule
+}
+
+// AddNotToRuleTable makes the traffic not destined to dst lookup the table, such
+// as the traffic out of the cluster CIDR, instead of enumerating the destinations.
+// Equivalent to: `ip rule add not to <dst> lookup <ruletable> pref <priority>`
+func AddNotToRuleTable(dst *net.IPNet, ruleTable, ipFamily, priority int, opts ...RuleOption) error {
+	rule, err := newNotToRule(dst, ruleTable, ipFamily, priority)
+	if err != nil {
+		return err
+	}
+	return addRule(rule, opts...)
+}
+
+// DelNotToRuleTable equivalent to: `ip rule del not to <dst> lookup <ruletable> pref <priority>`,
+// the rule `to <dst>` without the invert flag is left untouched.
+func DelNotToRuleTable(dst *net.IPNet, ruleTable, ipFamily, priority int) error {
+	rule, err := newNotToRule(dst, ruleTable, ipFamily, priority)
+	if err != nil {
+		return err
+	}
+
+	// the kernel ignores the invert flag while looking up the rule to delete,
+	// make sure the rule to delete is the inverted one
+	exists, err := RuleExists(rule)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("rule '%s' is not found: %w", formatRule(rule), os.ErrNotExist)
+	}
+	return netlink.RuleDel(rule)
 }
 
-func DelToRuleTable(dst *net.IPNet, ruleTable int) error {
+func newNotToRule(dst *net.IPNet, ruleTable, ipFamily, priority int) (*netlink.Rule, error) {
+	if dst == nil {
+		return nil, fmt.Errorf("the destination of the rule must be specified")
+	}
+	if ipFamilyOf(dst.IP) != ipFamily {
+		return nil, fmt.Errorf("destination %v doesn't match the ipFamily %v", dst, ipFamily)
+	}
+
 	rule := netlink.NewRule()
 	rule.Table = ruleTable
+	rule.Family = ipFamily
+	rule.Priority = priority
 	rule.Dst = dst
-	return netlink.RuleDel(rule)
+	rule.Invert = true
+	return rule, nil
+}
+
+// AddRuleTableWithMark adds the mark rule at the priority, such as
+// DefaultMarkRulePriority, so the concurrent callers don't share any state.
+// Equivalent to: `ip rule add fwmark <mark> lookup <ruletable> pref <priority>`
+func AddRuleTableWithMark(mark, ruleTable, ipFamily, priority int, opts ...RuleOption) error {
+	return addRule(newMarkRule(mark, ruleTable, ipFamily, priority), opts...)
 }
 
-func AddRuleTableWithMark(mark, ruleTable, ipFamily int) error {
+// DelRuleTableWithMark equivalent to: `ip rule del fwmark <mark> lookup <ruletable> pref <priority>`
+func DelRuleTableWithMark(mark, ruleTable, ipFamily, priority int) error {
+	return netlink.RuleDel(newMarkRule(mark, ruleTable, ipFamily, priority))
+}
+
+func newMarkRule(mark, ruleTable, ipFamily, priority int) *netlink.Rule {
 	rule := netlink.NewRule()
 	rule.Mark = mark
 	rule.Table = ruleTable
 	rule.Family = ipFamily
-	rule.Priority = defaultRulePriority
-	return netlink.RuleAdd(rule)
+	rule.Priority = priority
+	return rule
 }
 
-// AddFromRuleTable add route rule for calico/cilium cidr(ipv4 and ipv6)
-// Equivalent to: `ip rule add from <cidr> `
-func AddFromRuleTable(src *net.IPNet, ruleTable int) error {
-	rule := netlink.NewRule()
-	rule.Table = ruleTable
-	rule.Src = src
-	return netlink.RuleAdd(rule)
+// AddSuppressPrefixRule adds the rule looking up the table, but ignoring the
+// routes whose prefix length is not longer than suppressPrefixLen, e.g. the
+// default routes with 0, so that they fall through to the following rules.
+// Equivalent to: `ip rule add lookup <table> suppress_prefixlength <len> pref <priority>`
+func AddSuppressPrefixRule(table, family, priority, suppressPrefixLen int, opts ...RuleOption) error {
+	rule, err := newSuppressPrefixRule(table, family, priority, suppressPrefixLen)
+	if err != nil {
+		return err
+	}
+	return addRule(rule, opts...)
 }
 
-// DelFromRuleTable equivalent to: `ip rule del from <cidr> lookup <ruletable>`
-func DelFromRuleTable(src *net.IPNet, ruleTable int) error {
-	rule := netlink.NewRule()
-	rule.Table = ruleTable
-	rule.Src = src
+// DelSuppressPrefixRule equivalent to: `ip rule del lookup <table> suppress_prefixlength <len> pref <priority>`
+func DelSuppressPrefixRule(table, family, priority, suppressPrefixLen int) error {
+	rule, err := newSuppressPrefixRule(table, family, priority, suppressPrefixLen)
+	if err != nil {
+		return err
+	}
 	return netlink.RuleDel(rule)
 }
 
-// AddRoute add static route to specify rule table
-func AddRoute(logger *zap.Logger, ruleTable, ipFamily int, scope netlink.Scope, iface string, dst *net.IPNet, v4Gw, v6Gw net.IP) error {
-	link, err := netlink.LinkByName(iface)
+func newSuppressPrefixRule(table, family, priority, suppressPrefixLen int) (*netlink.Rule, error) {
+	if family != netlink.FAMILY_V4 && family != netlink.FAMILY_V6 {
+		return nil, fmt.Errorf("the family of the suppress prefix rule must be either ipv4 or ipv6")
+	}
+	maxPrefixLen := 32
+	if family == netlink.FAMILY_V6 {
+		maxPrefixLen = 128
+	}
+	if suppressPrefixLen < 0 || suppressPrefixLen > maxPrefixLen {
+		return nil, fmt.Errorf("invalid suppress prefix length %d", suppressPrefixLen)
+	}
+
+	// SuppressIfgroup is left unset by NewRule
+	rule := netlink.NewRule()
+	rule.Table = table
+	rule.Family = family
+	rule.Priority = priority
+	rule.SuppressPrefixlen = suppressPrefixLen
+	return rule, nil
+}
+
+// IifOrOif decides whether the policy rule matches the input or the output interface
+type IifOrOif string
+
+const (
+	Iif IifOrOif = "iif"
+	Oif IifOrOif = "oif"
+)
+
+// AddRuleForInterface add the policy rule matching on the interface name.
+// The interface must exist in current netns at call time, but the rule
+// references the interface by name, so it survives the link being recreated.
+// Equivalent to: `ip rule add iif|oif <iface> lookup <ruletable> pref <priority>`
+func AddRuleForInterface(iface string, direction IifOrOif, ruleTable, ipFamily, priority int, opts ...RuleOption) error {
+	if _, err := netlink.LinkByName(iface); err != nil {
+		return fmt.Errorf("failed to find interface %s: %w", iface, err)
+	}
+
+	rule, err := newInterfaceRule(iface, direction, ruleTable, ipFamily, priority)
 	if err != nil {
-		logger.Error(err.Error())
 		return err
 	}
+	return addRule(rule, opts...)
+}
 
-	route := &netlink.Route{
-		LinkIndex: link.Attrs().Index,
-		Scope:     scope,
-		Dst:       dst,
-		Table:     ruleTable,
+// DelRuleForInterface equivalent to: `ip rule del iif|oif <iface> lookup <ruletable> pref <priority>`,
+// the interface is not required to exist.
+func DelRuleForInterface(iface string, direction IifOrOif, ruleTable, ipFamily, priority int) error {
+	rule, err := newInterfaceRule(iface, direction, ruleTable, ipFamily, priority)
+	if err != nil {
+		return err
 	}
+	return netlink.RuleDel(rule)
+}
 
-	switch ipFamily {
-	case netlink.FAMILY_V4:
-		if v4Gw != nil {
-			route.Gw = v4Gw
-		}
-	case netlink.FAMILY_V6:
-		if v6Gw != nil {
-			route.Gw = v6Gw
-		}
-	case netlink.FAMILY_ALL:
-		if dst != nil && dst.IP.To4() != nil && v4Gw != nil {
-			route.Gw = v4Gw
-		}
+func newInterfaceRule(iface string, direction IifOrOif, ruleTable, ipFamily, priority int) (*netlink.Rule, error) {
+	rule := netlink.NewRule()
+	rule.Table = ruleTable
+	rule.Family = ipFamily
+	rule.Priority = priority
+	switch direction {
+	case Iif:
+		rule.IifName = iface
+	case Oif:
+		rule.OifName = iface
+	default:
+		return nil, fmt.Errorf("unknown rule direction %v, available options: [%v,%v]", direction, Iif, Oif)
+	}
+	return rule, nil
+}
+
+// FormatRules renders the rules like `ip rule show`, one rule per line, e.g.
+//
+//	1000:	from 10.6.0.0/16 lookup 100
+//	1000:	from all fwmark 0x1 lookup 500
+//	32766:	from all lookup main
+func FormatRules(rules []netlink.Rule) string {
+	var sb strings.Builder
+	for idx := range rules {
+		sb.WriteString(formatRule(&rules[idx]))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func formatRule(rule *netlink.Rule) string {
+	var sb strings.Builder
+	if rule.Priority >= 0 {
+		fmt.Fprintf(&sb, "%d:\t", rule.Priority)
+	}
+	if rule.Invert {
+		sb.WriteString("not ")
+	}
+
+	if rule.Src != nil {
+		fmt.Fprintf(&sb, "from %s", rule.Src)
+	} else {
+		sb.WriteString("from all")
+	}
+	if rule.Dst != nil {
+		fmt.Fprintf(&sb, " to %s", rule.Dst)
+	}
 
-		if dst != nil && dst.IP.To4() == nil && v6Gw != nil {
-			route.Gw = v6Gw
+	if rule.Mark > 0 || rule.Mask > 0 {
+		fmt.Fprintf(&sb, " fwmark %#x", uint32(rule.Mark))
+		if rule.Mask > 0 && uint32(rule.Mask) != 0xffffffff {
+			fmt.Fprintf(&sb, "/%#x", uint32(rule.Mask))
 		}
+	}
+	if rule.IifName != "" {
+		fmt.Fprintf(&sb, " iif %s", rule.IifName)
+	}
+	if rule.OifName != "" {
+		fmt.Fprintf(&sb, " oif %s", rule.OifName)
+	}
+
+	switch rule.Table {
+	case unix.RT_TABLE_DEFAULT:
+		sb.WriteString(" lookup default")
+	case unix.RT_TABLE_MAIN:
+		sb.WriteString(" lookup main")
+	case unix.RT_TABLE_LOCAL:
+		sb.WriteString(" lookup local")
 	default:
-		return fmt.Errorf("unknown ipFamily %v", ipFamily)
+		fmt.Fprintf(&sb, " lookup %d", rule.Table)
+	}
+	if rule.SuppressPrefixlen >= 0 {
+		fmt.Fprintf(&sb, " suppress_prefixlength %d", rule.SuppressPrefixlen)
 	}
+	return sb.String()
+}
 
-	if err = netlink.RouteAdd(route); err != nil && !os.IsExist(err) {
-		logger.Error("failed to RouteAdd", zap.String("route", route.String()), zap.Error(err))
-		return fmt.Errorf("failed to add route table(%v): %v", route.String(), err)
+// AddFromRuleTable add route rule for calico/cilium cidr(ipv4 and ipv6)
+// Equivalent to: `ip rule add from <cidr> `
+func AddFromRuleTable(src *net.IPNet, ruleTable int, opts ...RuleOption) error {
+	return addRule(newFromRule(src, ruleTable, opts...), opts...)
+}
+
+// newFromRule builds the rule for both adding and deleting, so that the rule
+// to delete matches all the fields of the added one.
+func newFromRule(src *net.IPNet, ruleTable int, opts ...RuleOption) *netlink.Rule {
+	rule := netlink.NewRule()
+	rule.Table = ruleTable
+	rule.Src = src
+	if src != nil {
+		rule.Family = ipFamilyOf(src.IP)
 	}
-	return nil
+	for _, opt := range opts {
+		opt.applyRule(rule)
+	}
+	return rule
 }
 
-// MoveRouteTable move all routes of the specified interface to a new route table
-// Equivalent: `ip route del <route>` and `ip r route add <route> <table>`
-func MoveRouteTable(logger *zap.Logger, iface string, srcRuleTable, dstRuleTable, ipfamily int) error {
-	logger.Debug("Debug MoveRouteTable", zap.String("interface", iface),
-		zap.Int("srcRuleTable", srcRuleTable), zap.Int("dstRuleTable", dstRuleTable))
-	link, err := netlink.LinkByName(iface)
+// AddFromRuleTableOrdered adds the from-rule whose priority is derived from the
+// prefix length of src, so that the more specific sources are always evaluated
+// first regardless of the order in which the rules are added, e.g. with the
+// basePriority 2000, `from 10.6.0.2/32` gets 2000 and `from 10.6.0.0/24` gets 2008.
+// Equivalent to: `ip rule add from <cidr> lookup <ruletable> pref <priority>`
+func AddFromRuleTableOrdered(src *net.IPNet, ruleTable, basePriority int, opts ...RuleOption) error {
+	rule, err := newOrderedFromRule(src, ruleTable, basePriority)
 	if err != nil {
-		logger.Error(err.Error())
 		return err
 	}
+	return addRule(rule, opts...)
+}
 
-	routes, err := netlink.RouteList(nil, ipfamily)
+// DelFromRuleTableOrdered deletes the from-rule added by AddFromRuleTableOrdered.
+func DelFromRuleTableOrdered(src *net.IPNet, ruleTable, basePriority int) error {
+	rule, err := newOrderedFromRule(src, ruleTable, basePriority)
 	if err != nil {
-		logger.Error(err.Error())
 		return err
 	}
+	return netlink.RuleDel(rule)
+}
 
-	for _, route := range routes {
-		// only handle route tables from table main
-		if route.Table != srcRuleTable {
-			continue
-		}
+func newOrderedFromRule(src *net.IPNet, ruleTable, basePriority int) (*netlink.Rule, error) {
+	if src == nil {
+		return nil, fmt.Errorf("the source of the rule must be specified")
+	}
+	if basePriority < 0 {
+		return nil, fmt.Errorf("invalid base priority %d", basePriority)
+	}
 
-		// ignore local link route
-		if route.Dst.String() == "fe80::/64" {
-			continue
+	ones, bits := src.Mask.Size()
+	if bits == 0 {
+		return nil, fmt.Errorf("the mask of the source %s is not canonical", src)
+	}
+	return newFromRule(src, ruleTable, WithRulePriority(basePriority+bits-ones)), nil
+}
+
+// SetupSourceRouting makes the traffic from src lookup the table, and installs the
+// default route via gw on the iface to the table. The rule is rolled back if the
+// route fails to be added.
+// Equivalent to: `ip rule add from <src> lookup <table>` and `ip route add default via <gw> dev <iface> table <table>`
+func SetupSourceRouting(logger *zap.Logger, src *net.IPNet, ruleTable, ipFamily int, iface string, gw net.IP) error {
+	ruleCreated := true
+	if err := AddFromRuleTable(src, ruleTable); err != nil {
+		if !os.IsExist(err) {
+			logger.Error("failed to AddFromRuleTable", zap.String("src", src.String()), zap.Error(err))
+			return fmt.Errorf("failed to add rule from %v to table %d: %w", src, ruleTable, err)
 		}
+		// the rule is not owned by us, don't roll it back
+		ruleCreated = false
+	}
 
-		if route.LinkIndex == link.Attrs().Index {
-			if err = netlink.RouteDel(&route); err != nil {
-				logger.Error("failed to RouteDel in main", zap.String("route", route.String()), zap.Error(err))
-				return fmt.Errorf("failed to RouteDel %s in main table: %+v", route.String(), err)
+	if err := AddRoute(logger, ruleTable, ipFamily, netlink.SCOPE_UNIVERSE, iface, nil, gw, gw); err != nil {
+		if ruleCreated {
+			if delErr := DelFromRuleTable(src, ruleTable); delErr != nil && !os.IsNotExist(delErr) {
+				logger.Error("failed to roll back the rule", zap.String("src", src.String()), zap.Error(delErr))
 			}
-			logger.Debug("Del the route from main successfully", zap.String("Route", route.String()))
+		}
+		return err
+	}
+	return nil
+}
+
+// VerifyRouteResolution checks the route from src to dst is resolved by the
+// kernel in the expectedTable, src must be an address of the current netns.
+// Equivalent to: `ip route get <dst> from <src>`
+func VerifyRouteResolution(src, dst net.IP, expectedTable int) error {
+	routes, err := netlink.RouteGetWithOptions(dst, &netlink.RouteGetOptions{SrcAddr: src})
+	if err != nil {
+		return fmt.Errorf("failed to get the route from %s to %s: %w", src, dst, err)
+	}
+	if len(routes) == 0 {
+		return fmt.Errorf("no route from %s to %s", src, dst)
+	}
+
+	// the table of the resolved route is only set when it is not main
+	table := routes[0].Table
+	if table == unix.RT_TABLE_UNSPEC {
+		table = unix.RT_TABLE_MAIN
+	}
+	if table != expectedTable {
+		return fmt.Errorf("the route from %s to %s is resolved in table %d, not %d", src, dst, table, expectedTable)
+	}
+	return nil
+}
+
+// DelFromRuleTable deletes the rule added by AddFromRuleTable with the same options.
+// Equivalent to: `ip rule del from <cidr> lookup <ruletable>`
+func DelFromRuleTable(src *net.IPNet, ruleTable int, opts ...RuleOption) error {
+	return netlink.RuleDel(newFromRule(src, ruleTable, opts...))
+}
+
+// DeleteRulesByPriorityRange deletes the rules whose priority is in [low, high],
+// such as the rules in the priority band reserved by spiderpool, the rules out
+// of the range are left untouched. It's DelRulesByPriorityRange of all the
+// tables without logging.
+func DeleteRulesByPriorityRange(low, high, ipFamily int) error {
+	_, err := DelRulesByPriorityRange(zap.NewNop(), ipFamily, low, high, nil)
+	return err
+}
+
+// the priorities of the default rules of the kernel, which lookup the local,
+// main and default tables
+var reservedRulePriorities = map[int]struct{}{0: {}, 32766: {}, 32767: {}}
+
+// DelRulesByPriorityRange deletes the rules whose priority is in [minPrio, maxPrio]
+// and returns the number of the deleted rules, such as to sweep the stale rules
+// left in the priority band owned by spiderpool. If onlyTables is not empty, only
+// the rules looking up these tables are deleted. The default rules of the kernel,
+// whose priorities are 0, 32766 and 32767, are never deleted even if the range
+// includes them.
+func DelRulesByPriorityRange(logger *zap.Logger, ipFamily, minPrio, maxPrio int, onlyTables []int) (deleted int, err error) {
+	if minPrio > maxPrio {
+		return 0, fmt.Errorf("invalid priority range [%d, %d]", minPrio, maxPrio)
+	}
+
+	families := []int{ipFamily}
+	if ipFamily == netlink.FAMILY_ALL {
+		families = []int{netlink.FAMILY_V4, netlink.FAMILY_V6}
+	}
+
+	tables := make(map[int]struct{}, len(onlyTables))
+	for _, table := range onlyTables {
+		tables[table] = struct{}{}
+	}
+
+	for _, family := range families {
+		rules, err := netlink.RuleList(family)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to list rules: %w", err)
+		}
 
-			route.Table = dstRuleTable
-			if err = netlink.RouteAdd(&route); err != nil && !os.IsExist(err) {
-				logger.Error("failed to RouteAdd in new table ", zap.String("route", route.String()), zap.Error(err))
-				return fmt.Errorf("failed to RouteAdd (%+v) to new table: %+v", route, err)
+		for idx := range rules {
+			rule := &rules[idx]
+			// the kernel doesn't report the priority 0
+			priority := rule.Priority
+			if priority < 0 {
+				priority = 0
 			}
-			logger.Debug("MoveRoute to new table successfully", zap.String("Route", route.String()))
-		} else {
-			// especially for ipv6 default route
-			if len(route.MultiPath) == 0 {
+			if priority < minPrio || priority > maxPrio {
 				continue
 			}
-
-			var generatedRoute, deletedRoute *netlink.Route
-			// get generated default Route for new table
-			for _, v := range route.MultiPath {
-				logger.Debug("Found IPv6 Default Route", zap.String("Route", route.String()),
-					zap.Int("v.LinkIndex", v.LinkIndex), zap.Int("link.Attrs().Index", link.Attrs().Index))
-				if v.LinkIndex == link.Attrs().Index {
-					generatedRoute = &netlink.Route{
-						LinkIndex: v.LinkIndex,
-						Gw:        v.Gw,
-						Table:     dstRuleTable,
-						MTU:       route.MTU,
-					}
-					deletedRoute = &netlink.Route{
-						LinkIndex: v.LinkIndex,
-						Gw:        v.Gw,
-						Table:     srcRuleTable,
-					}
-					break
-				}
+			if _, ok := reservedRulePriorities[priority]; ok {
+				logger.Warn("skip the default rule of the kernel in the priority range", zap.String("rule", formatRule(rule)))
+				continue
 			}
-			if generatedRoute == nil {
+			if _, ok := tables[rule.Table]; len(tables) != 0 && !ok {
 				continue
 			}
 
-			logger.Debug("deletedRoute", zap.String("deletedRoute", deletedRoute.String()))
-			if err := netlink.RouteDel(deletedRoute); err != nil {
-				logger.Error("failed to RouteDel for IPv6", zap.String("Route", route.String()), zap.Error(err))
-				return fmt.Errorf("failed to RouteDel %v for IPv6: %+v", route.String(), err)
+			// the listed rules don't carry the family, which is required to
+			// delete the rules without src and dst
+			rule.Family = family
+			if err = netlink.RuleDel(rule); err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return deleted, fmt.Errorf("failed to delete rule '%s': %w", formatRule(rule), err)
 			}
+			deleted++
+			logger.Info("delete the rule", zap.Int("ipFamily", family), zap.String("rule", formatRule(rule)))
+		}
+	}
+	return deleted, nil
+}
 
-			if err = netlink.RouteAdd(generatedRoute); err != nil && !os.IsExist(err) {
-				logger.Error("failed to RouteAdd for IPv6 to new table", zap.String("route", route.String()), zap.Error(err))
-				return fmt.Errorf("failed to RouteAdd for IPv6 (%+v) to new table: %+v", route.String(), err)
-			}
+// ResolvedRule is a policy rule joined with the links it matches on, such as
+// for the diagnostics
+type ResolvedRule struct {
+	netlink.Rule
+	// IifIndex and OifIndex are the indexes of the links named by IifName and
+	// OifName, 0 if the rule doesn't match on the interface or the link is gone
+	IifIndex int
+	OifIndex int
+}
+
+// ListRulesResolved lists the rules with the links of their iif and oif
+// resolved, the rules referencing the vanished links are kept unresolved.
+// Equivalent to: `ip rule show` and `ip link show`
+func ListRulesResolved(ipFamily int) ([]ResolvedRule, error) {
+	rules, err := netlink.RuleList(ipFamily)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules: %w", err)
+	}
+
+	links, err := netlink.LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	linkIndexes := make(map[string]int, len(links))
+	for _, link := range links {
+		linkIndexes[link.Attrs().Name] = link.Attrs().Index
+	}
+
+	resolved := make([]ResolvedRule, 0, len(rules))
+	for _, rule := range rules {
+		r := ResolvedRule{Rule: rule}
+		if rule.IifName != "" {
+			r.IifIndex = linkIndexes[rule.IifName]
+		}
+		if rule.OifName != "" {
+			r.OifIndex = linkIndexes[rule.OifName]
 		}
+		resolved = append(resolved, r)
 	}
-	return nil
+	return resolved, nil
+}
+
+// RouteOption sets the optional attributes of the route added by AddRoute
+type RouteOption interface {
+	applyRoute(route *netlink.Route)
+}
+
+type routeOptionFunc func(route *netlink.Route)
+
+func (f routeOptionFunc) applyRoute(route *netlink.Route) { f(route) }
+
+// WithRealm tags the route with the realm, which can be matched by the iptables
+// realm module for traffic accounting, 0 means no realm.
+// Equivalent: `ip route add <route> realm <realm>`
+func WithRealm(realm int) RouteOption {
+	return routeOptionFunc(func(route *netlink.Route) {
+		route.Realm = realm
+	})
+}
+
+// WithMTU sets the MTU of the route, such as for the overlay destinations
+// reached via the veth, 0 means unset.
+// Equivalent: `ip route add <route> mtu <mtu>`
+func WithMTU(mtu int) RouteOption {
+	return routeOptionFunc(func(route *netlink.Route) {
+		route.MTU = mtu
+	})
+}
+
+// RouteMetrics are the TCP metrics of the route for the performance tuning,
+// 0 means unset.
+type RouteMetrics struct {
+	// AdvMSS is the MSS advertised to the peers of the TCP connections
+	AdvMSS int
+	// InitCwnd is the initial congestion window of the TCP connections in packets
+	InitCwnd int
+	// RtoMin is the minimum TCP retransmission timeout, which is rounded up to
+	// milliseconds
+	RtoMin time.Duration
+}
+
+// WithMetrics sets the TCP metrics of the route.
+// Equivalent: `ip route add <route> advmss <advmss> initcwnd <initcwnd> rto_min <rtoMin>`
+func WithMetrics(metrics RouteMetrics) RouteOption {
+	return routeOptionFunc(func(route *netlink.Route) {
+		route.AdvMSS = metrics.AdvMSS
+		route.InitCwnd = metrics.InitCwnd
+		route.RtoMin = int((metrics.RtoMin + time.Millisecond - 1) / time.Millisecond)
+	})
+}
+
+// AddRoute add static route to specify rule table. The route without gateway is
+// in scope link, which works for the point-to-point interfaces, such as ppp and
+// wireguard, as well, since the kernel sends the packets to the peer directly.
+// The dst and the gateway must be of ipFamily. For FAMILY_ALL, the family of
+// dst picks the gateway from v4Gw and v6Gw, and the default route takes the
+// only gateway given. Otherwise it fails with ErrFamilyMismatch.
+func AddRoute(logger *zap.Logger, ruleTable, ipFamily int, scope netlink.Scope, iface string, dst *net.IPNet, v4Gw, v6Gw net.IP, opts ...RouteOption) error {
+	return addRouteByName(logger, nil, ruleTable, ipFamily, scope, iface, dst, v4Gw, v6Gw, opts...)
+}
+
+// AddRouteWithScopeName is AddRoute with the scope by its name, such as
+// "global", "link" and "host", see ParseScope.
+func AddRouteWithScopeName(logger *zap.Logger, ruleTable, ipFamily int, scopeName string, iface string, dst *net.IPNet, v4Gw, v6Gw net.IP, opts ...RouteOption) error {
+	scope, err := ParseScope(scopeName)
+	if err != nil {
+		return err
+	}
+	return AddRoute(logger, ruleTable, ipFamily, scope, iface, dst, v4Gw, v6Gw, opts...)
+}
+
+// routeScopes are the names of the scopes as `ip route` takes them,
+// "universe" is the alias of "global"
+var routeScopes = map[string]netlink.Scope{
+	"global":   netlink.SCOPE_UNIVERSE,
+	"universe": netlink.SCOPE_UNIVERSE,
+	"site":     netlink.SCOPE_SITE,
+	"link":     netlink.SCOPE_LINK,
+	"host":     netlink.SCOPE_HOST,
+	"nowhere":  netlink.SCOPE_NOWHERE,
+}
+
+// ParseScope returns the scope of the name, one of global, universe, site,
+// link, host and nowhere, the name is case-insensitive.
+func ParseScope(s string) (netlink.Scope, error) {
+	scope, ok := routeScopes[strings.ToLower(strings.TrimSpace(s))]
+	if !ok {
+		return 0, fmt.Errorf("unknown route scope %q, available options: [global,site,link,host,nowhere]", s)
+	}
+	return scope, nil
+}
+
+func addRouteByName(logger *zap.Logger, links *LinkCache, ruleTable, ipFamily int, scope netlink.Scope, iface string, dst *net.IPNet, v4Gw, v6Gw net.IP, opts ...RouteOption) error {
+	linkIndex, _, err := resolveLinkStable(links, iface)
+	if err != nil {
+		logger.Error(err.Error())
+		return err
+	}
+
+	return addRoute(logger, ruleTable, ipFamily, scope, linkIndex, dst, v4Gw, v6Gw, opts...)
+}
+
+// AddRouteByIndex add static route to specify rule table via the link index,
+// the callers holding the index of the link, which may still have a temporary
+// name or be renamed meanwhile, can skip the resolution of the name.
+func AddRouteByIndex(logger *zap.Logger, ruleTable, ipFamily int, scope netlink.Scope, linkIndex int, dst *net.IPNet, gw net.IP, opts ...RouteOption) error {
+	return addRoute(logger, ruleTable, ipFamily, scope, linkIndex, dst, gw, gw, opts...)
+}
+
+// specialRouteScopes are the scopes the kernel gives to the special routes of
+// the types, the same as `ip route` picks for them
+var specialRouteScopes = map[int]netlink.Scope{
+	unix.RTN_LOCAL:     netlink.SCOPE_HOST,
+	unix.RTN_BROADCAST: netlink.SCOPE_LINK,
+}
+
+// AddSpecialRoute adds the route of the type RTN_LOCAL or RTN_BROADCAST to the
+// rule table, such as for the custom tables of the advanced setups. The scope
+// follows the type, host for the local route and link for the broadcast one,
+// and the broadcast route is IPv4 only.
+// Equivalent: `ip route add local|broadcast <dst> dev <iface> table <ruleTable>`
+func AddSpecialRoute(logger *zap.Logger, ruleTable, routeType int, iface string, dst *net.IPNet, opts ...RouteOption) error {
+	scope, ok := specialRouteScopes[routeType]
+	if !ok {
+		return fmt.Errorf("unsupported route type %d, only local and broadcast are supported", routeType)
+	}
+	if dst == nil {
+		return fmt.Errorf("the destination of the special route must be specified")
+	}
+	ipFamily := ipFamilyOf(dst.IP)
+	if routeType == unix.RTN_BROADCAST && ipFamily != netlink.FAMILY_V4 {
+		return fmt.Errorf("broadcast route %s is IPv4 only", dst)
+	}
+
+	opts = append(opts, routeOptionFunc(func(route *netlink.Route) {
+		route.Type = routeType
+	}))
+	return addRouteByName(logger, nil, ruleTable, ipFamily, scope, iface, dst, nil, nil, opts...)
+}
+
+// ReplaceRoute is AddRoute, but updates the attributes of the existing route,
+// e.g. the MTU set by WithMTU, instead of leaving it untouched. It is not
+// limited by SetMaxRoutesPerTable.
+// Equivalent: `ip route replace <route>`
+func ReplaceRoute(logger *zap.Logger, ruleTable, ipFamily int, scope netlink.Scope, iface string, dst *net.IPNet, v4Gw, v6Gw net.IP, opts ...RouteOption) error {
+	linkIndex, _, err := ResolveLinkStable(iface)
+	if err != nil {
+		logger.Error(err.Error())
+		return err
+	}
+
+	route, err := newRoute(ruleTable, ipFamily, scope, linkIndex, dst, v4Gw, v6Gw, opts...)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.RouteReplace(route); err != nil {
+		logger.Error("failed to RouteReplace", zap.String("route", route.String()), zap.Error(err))
+		return fmt.Errorf("failed to replace route table(%v): %v", route.String(), err)
+	}
+	return nil
+}
+
+// addRoute add static route to specify rule table by the link index
+func addRoute(logger *zap.Logger, ruleTable, ipFamily int, scope netlink.Scope, linkIndex int, dst *net.IPNet, v4Gw, v6Gw net.IP, opts ...RouteOption) error {
+	route, err := newRoute(ruleTable, ipFamily, scope, linkIndex, dst, v4Gw, v6Gw, opts...)
+	if err != nil {
+		return err
+	}
+
+	ipv6 := missingIPv6Option(opts)
+	family := routeFamily(route)
+	if family == netlink.FAMILY_ALL {
+		family = ipFamily
+	}
+	if skip, err := ipv6.skipMissingIPv6(family, "add route "+route.String()); err != nil || skip {
+		return err
+	}
+
+	if maxRoutesPerTable > 0 {
+		routes, err := netlink.RouteListFiltered(netlink.FAMILY_ALL, &netlink.Route{Table: ruleTable}, netlink.RT_FILTER_TABLE)
+		if err != nil {
+			return fmt.Errorf("failed to list routes of table %d: %w", ruleTable, err)
+		}
+		for i := range routes {
+			// the existing route doesn't count against the limit
+			if routeMatches(route, &routes[i]) {
+				return nil
+			}
+		}
+		if len(routes) >= maxRoutesPerTable {
+			logger.Error("failed to RouteAdd", zap.String("route", route.String()), zap.Int("maxRoutes", maxRoutesPerTable))
+			return fmt.Errorf("failed to add route %v, table %d has %d routes: %w", route.String(), ruleTable, len(routes), ErrTableFull)
+		}
+	}
+
+	// the kernel refuses the existing route with EEXIST
+	if err := netlink.RouteAdd(route); err != nil && !os.IsExist(err) {
+		if ipv6.skipIPv6Error(family, "add route "+route.String(), err) {
+			return nil
+		}
+		logger.Error("failed to RouteAdd", zap.String("route", route.String()), zap.Error(err))
+		return fmt.Errorf("failed to add route table(%v): %v", route.String(), err)
+	}
+	return nil
+}
+
+func newRoute(ruleTable, ipFamily int, scope netlink.Scope, linkIndex int, dst *net.IPNet, v4Gw, v6Gw net.IP, opts ...RouteOption) (*netlink.Route, error) {
+	route := &netlink.Route{
+		LinkIndex: linkIndex,
+		Scope:     scope,
+		Dst:       dst,
+		Table:     ruleTable,
+		Protocol:  RouteProtocolSpiderpool,
+	}
+	for _, opt := range opts {
+		opt.applyRoute(route)
+	}
+	if route.Realm < 0 {
+		return nil, fmt.Errorf("invalid realm %d", route.Realm)
+	}
+	if route.MTU < 0 {
+		return nil, fmt.Errorf("invalid mtu %d", route.MTU)
+	}
+	if route.AdvMSS < 0 || route.InitCwnd < 0 || route.RtoMin < 0 {
+		return nil, fmt.Errorf("invalid metrics advmss %d, initcwnd %d, rto_min %dms", route.AdvMSS, route.InitCwnd, route.RtoMin)
+	}
+
+	switch ipFamily {
+	case netlink.FAMILY_V4, netlink.FAMILY_V6:
+		gw := v4Gw
+		if ipFamily == netlink.FAMILY_V6 {
+			gw = v6Gw
+		}
+		if (dst != nil && ipFamilyOf(dst.IP) != ipFamily) || (gw != nil && ipFamilyOf(gw) != ipFamily) {
+			return nil, newFamilyMismatch(ipFamily, dst, gw)
+		}
+		route.Gw = gw
+	case netlink.FAMILY_ALL:
+		// the family of dst picks the gateway, it's a mismatch if only the
+		// gateway of the other family is given
+		if dst != nil {
+			route.Gw = gatewayOfFamily(ipFamilyOf(dst.IP), v4Gw, v6Gw)
+			if route.Gw == nil && (v4Gw != nil || v6Gw != nil) {
+				gw := v4Gw
+				if gw == nil {
+					gw = v6Gw
+				}
+				return nil, newFamilyMismatch(ipFamily, dst, gw)
+			}
+			break
+		}
+
+		// the default route takes the family of its gateway
+		v4, v6 := gatewayOfFamily(netlink.FAMILY_V4, v4Gw, v6Gw), gatewayOfFamily(netlink.FAMILY_V6, v4Gw, v6Gw)
+		if v4 != nil && v6 != nil {
+			return nil, fmt.Errorf("the family of the default route is ambiguous with the gateways %v and %v", v4, v6)
+		}
+		route.Gw = v4
+		if v6 != nil {
+			route.Gw = v6
+		}
+	default:
+		return nil, fmt.Errorf("unknown ipFamily %v", ipFamily)
+	}
+
+	// the link-local gateway, such as fe80::1, is only reachable on a concrete interface
+	if route.Gw != nil && route.Gw.IsLinkLocalUnicast() && route.LinkIndex <= 0 {
+		return nil, fmt.Errorf("the link-local gateway %v requires the interface", route.Gw)
+	}
+
+	// the route without gateway, such as the one to the peer of the point-to-point
+	// interface, reaches the destination directly, as `ip route add <dst> dev <iface>`
+	if route.Gw == nil && route.Scope == netlink.SCOPE_UNIVERSE {
+		route.Scope = netlink.SCOPE_LINK
+	}
+	return route, nil
+}
+
+// gatewayOfFamily returns the one of v4Gw and v6Gw in the family, the callers
+// may pass the same gateway as both
+func gatewayOfFamily(family int, v4Gw, v6Gw net.IP) net.IP {
+	for _, gw := range []net.IP{v4Gw, v6Gw} {
+		if gw != nil && ipFamilyOf(gw) == family {
+			return gw
+		}
+	}
+	return nil
+}
+
+// DelRoute deletes the routes to dst via the interface in the ruleTable,
+// it's not an error if there is no such route.
+// Equivalent: `ip route del <dst> dev <iface> table <ruleTable>`
+func DelRoute(ruleTable int, iface string, dst *net.IPNet) error {
+	return delRoute(nil, ruleTable, iface, dst)
+}
+
+func delRoute(links *LinkCache, ruleTable int, iface string, dst *net.IPNet) error {
+	if dst == nil {
+		return fmt.Errorf("the destination of the route must be specified")
+	}
+
+	linkIndex, _, err := resolveLinkStable(links, iface)
+	if err != nil {
+		return err
+	}
+
+	routes, err := netlink.RouteListFiltered(ipFamilyOf(dst.IP), &netlink.Route{Table: ruleTable, Dst: dst, LinkIndex: linkIndex},
+		netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST|netlink.RT_FILTER_OIF)
+	if err != nil {
+		return fmt.Errorf("failed to list routes of table %d: %w", ruleTable, err)
+	}
+
+	for i := range routes {
+		if err = netlink.RouteDel(&routes[i]); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete route %v: %w", routes[i].String(), err)
+		}
+	}
+	return nil
+}
+
+// DelRoutes deletes the routes to dsts via the gateway on the interface in the
+// ruleTable with the best effort, such as to tear down the routes added one by
+// one. The nil dst is the default route of ipFamily, and the nil gw matches any
+// gateway. The routes already gone, or whose interface is gone, are ignored,
+// and the other failures are aggregated.
+// Equivalent: `ip route del <dst> via <gw> dev <iface> table <ruleTable>`
+func DelRoutes(logger *zap.Logger, ruleTable, ipFamily int, iface string, dsts []*net.IPNet, gw net.IP) error {
+	if ipFamily != netlink.FAMILY_V4 && ipFamily != netlink.FAMILY_V6 {
+		return fmt.Errorf("invalid ipFamily %d", ipFamily)
+	}
+	if gw != nil && ipFamilyOf(gw) != ipFamily {
+		return newFamilyMismatch(ipFamily, nil, gw)
+	}
+
+	linkIndex, _, err := ResolveLinkStable(iface)
+	if err != nil {
+		var notFound netlink.LinkNotFoundError
+		if errors.As(err, &notFound) {
+			logger.Debug("the interface is gone, so are its routes", zap.String("interface", iface))
+			return nil
+		}
+		return err
+	}
+
+	defaultDst := &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}
+	if ipFamily == netlink.FAMILY_V6 {
+		defaultDst = &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}
+	}
+
+	var errs []error
+	for _, dst := range dsts {
+		if dst == nil {
+			dst = defaultDst
+		}
+		if ipFamilyOf(dst.IP) != ipFamily {
+			errs = append(errs, fmt.Errorf("destination %v: %w", dst, newFamilyMismatch(ipFamily, dst, gw)))
+			continue
+		}
+
+		route := &netlink.Route{LinkIndex: linkIndex, Dst: dst, Gw: gw, Table: ruleTable}
+		if err := netlink.RouteDel(route); err != nil {
+			if os.IsNotExist(err) || errors.Is(err, unix.ESRCH) {
+				logger.Debug("the route is already gone", zap.String("route", route.String()))
+				continue
+			}
+			logger.Error("failed to RouteDel", zap.String("route", route.String()), zap.Error(err))
+			errs = append(errs, fmt.Errorf("failed to delete route %v: %w", route.String(), err))
+			continue
+		}
+		logger.Debug("Delete the route successfully", zap.String("route", route.String()))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// linkLocalCIDRs are the link-local ranges, the routes to them are skipped by
+// MoveRouteTable, but removed by DeleteLinkLocalRoutes
+var linkLocalCIDRs = []*net.IPNet{
+	{IP: net.IPv4(169, 254, 0, 0).To4(), Mask: net.CIDRMask(16, 32)},
+	{IP: net.ParseIP("fe80::"), Mask: net.CIDRMask(10, 128)},
+}
+
+// DeleteLinkLocalRoutes deletes the routes to the link-local ranges, such as
+// fe80::/64, on the interface of all tables except the local one, whose routes
+// belong to the addresses, such as when tearing down the interface. The
+// ipFamily may be FAMILY_ALL. The routes already gone are ignored, and the
+// other failures are aggregated.
+// Equivalent: `ip route del fe80::/64 dev <iface> table <table>`
+func DeleteLinkLocalRoutes(iface string, ipFamily int) error {
+	linkIndex, _, err := ResolveLinkStable(iface)
+	if err != nil {
+		return err
+	}
+
+	routes, err := netlink.RouteListFiltered(ipFamily, &netlink.Route{LinkIndex: linkIndex, Table: unix.RT_TABLE_UNSPEC},
+		netlink.RT_FILTER_OIF|netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return fmt.Errorf("failed to list routes of %s: %w", iface, err)
+	}
+
+	var errs []error
+	for idx := range routes {
+		route := &routes[idx]
+		if route.Table == unix.RT_TABLE_LOCAL || !isRouteDstInCIDRs(route, linkLocalCIDRs) {
+			continue
+		}
+		if err := netlink.RouteDel(route); err != nil && !os.IsNotExist(err) && !errors.Is(err, unix.ESRCH) {
+			errs = append(errs, fmt.Errorf("failed to delete route %v: %w", route.String(), err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// RemapRouteLinkIndex rewrites the routes on the link oldIndex in the table to
+// the link newIndex, including the hops of the multipath routes, such as when
+// the managed interface is recreated with a new index while the routes of the
+// custom table still reference the old one. The routes are replaced in place,
+// the failures are aggregated.
+// Equivalent to: `ip route replace <route> dev <newIface> table <table>`
+func RemapRouteLinkIndex(table, oldIndex, newIndex, ipFamily int) error {
+	if oldIndex <= 0 || newIndex <= 0 {
+		return fmt.Errorf("invalid link index %d or %d", oldIndex, newIndex)
+	}
+	if oldIndex == newIndex {
+		return nil
+	}
+	if _, err := netlink.LinkByIndex(newIndex); err != nil {
+		return fmt.Errorf("failed to get link of index %d: %w", newIndex, err)
+	}
+
+	routes, err := netlink.RouteListFiltered(ipFamily, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return fmt.Errorf("failed to list routes of table %d: %w", table, err)
+	}
+
+	var errs []error
+	for i := range routes {
+		route := &routes[i]
+		remapped := false
+		if route.LinkIndex == oldIndex {
+			route.LinkIndex = newIndex
+			remapped = true
+		}
+		for _, hop := range route.MultiPath {
+			if hop.LinkIndex == oldIndex {
+				hop.LinkIndex = newIndex
+				remapped = true
+			}
+		}
+		if !remapped {
+			continue
+		}
+
+		if err := netlink.RouteReplace(route); err != nil {
+			errs = append(errs, fmt.Errorf("failed to replace route %v: %w", route.String(), err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// Nexthop is a member of the multipath route, the traffic is balanced
+// across the members by their weights
+type Nexthop struct {
+	LinkIndex int
+	Gw        net.IP
+	// Weight ranges from 1 to 256, 0 is treated as 1
+	Weight int
+}
+
+// AddMultipathRoute adds or replaces the route to dst in the ruleTable with
+// a multipath route across the nexthops. dst must not be nil, use 0.0.0.0/0
+// or ::/0 for the default route.
+// Equivalent to: `ip route replace <dst> table <table> nexthop via <gw> dev <iface> weight <weight> ...`
+func AddMultipathRoute(logger *zap.Logger, ruleTable int, dst *net.IPNet, nexthops []Nexthop) error {
+	if dst == nil {
+		return fmt.Errorf("dst of the multipath route must be specified")
+	}
+	if len(nexthops) == 0 {
+		return fmt.Errorf("no nexthop for the multipath route to %v", dst)
+	}
+
+	route := &netlink.Route{
+		Dst:      dst,
+		Table:    ruleTable,
+		Protocol: RouteProtocolSpiderpool,
+	}
+	for _, nh := range nexthops {
+		weight := nh.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		if weight > 256 {
+			return fmt.Errorf("invalid weight %d of the nexthop %v, it must be in range [1, 256]", weight, nh.Gw)
+		}
+		route.MultiPath = append(route.MultiPath, &netlink.NexthopInfo{
+			LinkIndex: nh.LinkIndex,
+			Gw:        nh.Gw,
+			Hops:      weight - 1,
+		})
+	}
+
+	if err := netlink.RouteReplace(route); err != nil {
+		logger.Error("failed to RouteReplace", zap.String("route", route.String()), zap.Error(err))
+		return fmt.Errorf("failed to replace multipath route %v: %w", route.String(), err)
+	}
+	return nil
+}
+
+// AppendNexthop adds the nexthop via gw on the iface to the route to dst in the
+// main table, so that the single path route becomes a multipath one without
+// rebuilding. The IPv6 nexthop is appended by the kernel as
+// `ip -6 route append <dst> via <gw> dev <iface> metric <metric>`, but the
+// appended IPv4 route is kept apart and never used, so the IPv4 route is
+// replaced with the multipath route including the existing nexthops instead.
+// The metric, the protocol and the other attributes of the existing route are
+// kept, and it fails if there are routes to dst with different metrics. It's a
+// no-op if the nexthop already exists.
+func AppendNexthop(dst *net.IPNet, iface string, gw net.IP, ipFamily int) error {
+	if ipFamily != netlink.FAMILY_V4 && ipFamily != netlink.FAMILY_V6 {
+		return fmt.Errorf("invalid ipFamily %d", ipFamily)
+	}
+	if dst == nil || gw == nil {
+		return fmt.Errorf("the destination and the gateway must be specified")
+	}
+	if ipFamilyOf(dst.IP) != ipFamily || ipFamilyOf(gw) != ipFamily {
+		return newFamilyMismatch(ipFamily, dst, gw)
+	}
+
+	linkIndex, _, err := ResolveLinkStable(iface)
+	if err != nil {
+		return err
+	}
+
+	routes, err := netlink.RouteListFiltered(ipFamily, &netlink.Route{Table: unix.RT_TABLE_MAIN, Dst: dst}, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST)
+	if err != nil {
+		return fmt.Errorf("failed to list the routes to %v: %w", dst, err)
+	}
+
+	// the IPv6 multipath route may be dumped as a route per nexthop, which
+	// share the same metric
+	var nexthops []*netlink.NexthopInfo
+	for _, route := range routes {
+		if route.Priority != routes[0].Priority {
+			return fmt.Errorf("there are routes to %v with metric %d and %d, failed to decide which one to append the nexthop to",
+				dst, routes[0].Priority, route.Priority)
+		}
+		if len(route.MultiPath) == 0 {
+			nexthops = append(nexthops, &netlink.NexthopInfo{LinkIndex: route.LinkIndex, Gw: route.Gw, Flags: route.Flags})
+		} else {
+			nexthops = append(nexthops, route.MultiPath...)
+		}
+	}
+	// the dumped dead and linkdown flags are refused when adding the route
+	for _, nh := range nexthops {
+		nh.Flags &= unix.RTNH_F_ONLINK
+	}
+	for _, nh := range nexthops {
+		if nh.LinkIndex == linkIndex && nh.Gw.Equal(gw) {
+			return nil
+		}
+	}
+
+	if len(routes) == 0 {
+		route := &netlink.Route{
+			LinkIndex: linkIndex,
+			Dst:       dst,
+			Gw:        gw,
+			Protocol:  RouteProtocolSpiderpool,
+		}
+		if err := netlink.RouteAppend(route); err != nil {
+			return fmt.Errorf("failed to append route %v: %w", route.String(), err)
+		}
+		return nil
+	}
+
+	if ipFamily == netlink.FAMILY_V6 {
+		route := &netlink.Route{
+			LinkIndex: linkIndex,
+			Dst:       dst,
+			Gw:        gw,
+			Table:     routes[0].Table,
+			Priority:  routes[0].Priority,
+			Protocol:  routes[0].Protocol,
+		}
+		if err := netlink.RouteAppend(route); err != nil {
+			return fmt.Errorf("failed to append route %v: %w", route.String(), err)
+		}
+		return nil
+	}
+
+	// replace the existing route in place, only its nexthops are changed
+	route := routes[0]
+	route.LinkIndex = 0
+	route.Gw = nil
+	route.Flags = 0
+	route.MultiPath = append(nexthops, &netlink.NexthopInfo{LinkIndex: linkIndex, Gw: gw})
+	if err := netlink.RouteReplace(&route); err != nil {
+		return fmt.Errorf("failed to replace multipath route %v: %w", route.String(), err)
+	}
+	return nil
+}
+
+// GetMultipathNexthops returns the nexthops of the route to dst in the ruleTable,
+// the route with single path is returned as one nexthop. dst must not be nil.
+func GetMultipathNexthops(ruleTable int, dst *net.IPNet) ([]Nexthop, error) {
+	routes, err := netlink.RouteListFiltered(ipFamilyOf(dst.IP), &netlink.Route{Table: ruleTable, Dst: dst}, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST)
+	if err != nil {
+		return nil, err
+	}
+
+	var nexthops []Nexthop
+	for _, route := range routes {
+		if len(route.MultiPath) == 0 {
+			nexthops = append(nexthops, Nexthop{LinkIndex: route.LinkIndex, Gw: route.Gw, Weight: 1})
+			continue
+		}
+		for _, nh := range route.MultiPath {
+			nexthops = append(nexthops, Nexthop{LinkIndex: nh.LinkIndex, Gw: nh.Gw, Weight: nh.Hops + 1})
+		}
+	}
+	return nexthops, nil
+}
+
+// DelMultipathRoute deletes the routes to dst in the ruleTable added by
+// AddMultipathRoute. The routes are deleted without matching the nexthops, so
+// that it works even if some member interface is already gone, in which case
+// the kernel may have turned the IPv6 multipath route into a single path one.
+// dst must not be nil.
+func DelMultipathRoute(ruleTable int, dst *net.IPNet) error {
+	routes, err := netlink.RouteListFiltered(ipFamilyOf(dst.IP), &netlink.Route{Table: ruleTable, Dst: dst}, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST)
+	if err != nil {
+		return err
+	}
+
+	for _, route := range routes {
+		if route.Protocol != RouteProtocolSpiderpool {
+			continue
+		}
+		if err = netlink.RouteDel(&netlink.Route{Dst: route.Dst, Table: ruleTable, Priority: route.Priority, Protocol: RouteProtocolSpiderpool}); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete multipath route %v: %w", route.String(), err)
+		}
+	}
+	return nil
+}
+
+// GatewayInfo is a default gateway and the interface it is reachable on
+type GatewayInfo struct {
+	Gw        net.IP
+	LinkIndex int
+}
+
+// InstallECMPDefault adds or replaces the default route of the ipFamily in the
+// table with an equal-cost multipath route across the gateways.
+// Equivalent to: `ip route replace default table <table> nexthop via <gw1> dev <iface1> nexthop via <gw2> dev <iface2> ...`
+func InstallECMPDefault(logger *zap.Logger, table, ipFamily int, gws []GatewayInfo) error {
+	var dst *net.IPNet
+	switch ipFamily {
+	case netlink.FAMILY_V4:
+		dst = &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}
+	case netlink.FAMILY_V6:
+		dst = &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}
+	default:
+		return fmt.Errorf("invalid ipFamily %d, it must be ipv4 or ipv6", ipFamily)
+	}
+
+	nexthops := make([]Nexthop, 0, len(gws))
+	for _, gw := range gws {
+		if gw.Gw == nil || ipFamilyOf(gw.Gw) != ipFamily {
+			return fmt.Errorf("gateway %v doesn't match the ipFamily %v", gw.Gw, ipFamily)
+		}
+		nexthops = append(nexthops, Nexthop{LinkIndex: gw.LinkIndex, Gw: gw.Gw, Weight: 1})
+	}
+
+	return AddMultipathRoute(logger, table, dst, nexthops)
+}
+
+// SwapDefaultGateway replaces the gateway of the default route of the interface
+// in main table with newGw in one atomic operation, the other attributes of the
+// route such as metric and mtu are preserved.
+// Equivalent to: `ip route replace default via <newGw> dev <iface> ...`
+func SwapDefaultGateway(logger *zap.Logger, iface string, ipFamily int, newGw net.IP) error {
+	if (ipFamily == netlink.FAMILY_V4) != (newGw.To4() != nil) {
+		return fmt.Errorf("gateway %v doesn't match the ipFamily %v", newGw, ipFamily)
+	}
+
+	linkIndex, _, err := ResolveLinkStable(iface)
+	if err != nil {
+		logger.Error(err.Error())
+		return err
+	}
+
+	routes, err := netlink.RouteList(nil, ipFamily)
+	if err != nil {
+		logger.Error(err.Error())
+		return err
+	}
+
+	for idx := range routes {
+		route := routes[idx]
+		if route.LinkIndex != linkIndex || !isDefaultRoute(&route) {
+			continue
+		}
+
+		route.Gw = newGw
+		if err = netlink.RouteReplace(&route); err != nil {
+			logger.Error("failed to RouteReplace", zap.String("route", route.String()), zap.Error(err))
+			return fmt.Errorf("failed to replace default route (%v): %v", route.String(), err)
+		}
+		logger.Debug("Swap the gateway of default route successfully", zap.String("Route", route.String()))
+		return nil
+	}
+
+	return fmt.Errorf("no default route found for interface %s", iface)
+}
+
+func ipFamilyOf(ip net.IP) int {
+	if ip.To4() != nil {
+		return netlink.FAMILY_V4
+	}
+	return netlink.FAMILY_V6
+}
+
+func isDefaultRoute(route *netlink.Route) bool {
+	if route.Dst == nil {
+		return true
+	}
+	ones, _ := route.Dst.Mask.Size()
+	return ones == 0 && route.Dst.IP.IsUnspecified()
+}
+
+// MoveRouteTable move all routes of the specified interface to a new route table,
+// the routes whose destination is within any of the skip CIDRs are left in place.
+// If keepBackup is true, the routes are kept in the source table as the backup
+// with their metric increased by backupRouteMetricBump instead of being deleted.
+// Only AllowMissingIPv6 of opts is honored.
+// Equivalent: `ip route del <route>` and `ip r route add <route> <table>`
+func MoveRouteTable(logger *zap.Logger, iface string, srcRuleTable, dstRuleTable, ipfamily int, skip []*net.IPNet, keepBackup bool, opts ...RouteOption) error {
+	logger.Debug("Debug MoveRouteTable", zap.String("interface", iface),
+		zap.Int("srcRuleTable", srcRuleTable), zap.Int("dstRuleTable", dstRuleTable), zap.Bool("keepBackup", keepBackup))
+	return migrateRouteTable(logger, nil, iface, srcRuleTable, dstRuleTable, ipfamily, true, keepBackup, skip, opts)
+}
+
+// DrainRoute increases the metric of the routes to dst in the table by
+// backupRouteMetricBump, the route with the lower metric to dst, if any, takes
+// over the new connections, while the drained one is still present for the
+// in-flight ones. It's intended to be called before MoveRouteTable. The nil dst
+// is the default route, and the table 0 is main.
+// Equivalent: `ip route replace <route> metric <metric+bump>` and `ip route del <route>`
+func DrainRoute(dst *net.IPNet, table, ipFamily int) error {
+	if table == unix.RT_TABLE_UNSPEC {
+		table = unix.RT_TABLE_MAIN
+	}
+
+	routes, err := netlink.RouteListFiltered(ipFamily, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return fmt.Errorf("failed to list routes of table %d: %w", table, err)
+	}
+
+	drained := 0
+	for idx := range routes {
+		if !sameIPNet(routes[idx].Dst, dst) {
+			continue
+		}
+		if err = demoteRoute(zap.NewNop(), routes[idx]); err != nil {
+			return err
+		}
+		drained++
+	}
+
+	if drained == 0 {
+		return fmt.Errorf("no route to %v found in table %d", dst, table)
+	}
+	return nil
+}
+
+// CopyRouteTable copy all routes of the specified interface to a new route table,
+// the routes in the source route table are kept. Only AllowMissingIPv6 of opts
+// is honored.
+// Equivalent: `ip r route add <route> <table>`
+func CopyRouteTable(logger *zap.Logger, iface string, srcRuleTable, dstRuleTable, ipfamily int, opts ...RouteOption) error {
+	logger.Debug("Debug CopyRouteTable", zap.String("interface", iface),
+		zap.Int("srcRuleTable", srcRuleTable), zap.Int("dstRuleTable", dstRuleTable))
+	return migrateRouteTable(logger, nil, iface, srcRuleTable, dstRuleTable, ipfamily, false, false, nil, opts)
+}
+
+// RestoreRouteTable is the reverse of MoveRouteTable, it moves all routes of the
+// specified interface in the customTable back to the main table, and deletes the
+// from-rules and mark-rules looking up the customTable. The backups kept by
+// MoveRouteTable in the main table are replaced by the restored routes.
+// Equivalent: `ip route del <route> table <customTable>`, `ip route add <route>` and
+// `ip rule del from <cidr>/fwmark <mark> lookup <customTable>`
+func RestoreRouteTable(logger *zap.Logger, iface string, customTable, ipfamily int) error {
+	logger.Debug("Debug RestoreRouteTable", zap.String("interface", iface), zap.Int("customTable", customTable))
+
+	if customTable == unix.RT_TABLE_MAIN {
+		return fmt.Errorf("the custom table must not be the main table")
+	}
+
+	linkIndex, _, err := ResolveLinkStable(iface)
+	if err != nil {
+		logger.Error(err.Error())
+		return err
+	}
+
+	// RouteList only lists the routes of the main table
+	routes, err := netlink.RouteListFiltered(ipfamily, &netlink.Route{Table: customTable, LinkIndex: linkIndex},
+		netlink.RT_FILTER_TABLE|netlink.RT_FILTER_OIF)
+	if err != nil {
+		logger.Error(err.Error())
+		return err
+	}
+
+	for _, route := range routes {
+		restored := route
+		restored.Table = unix.RT_TABLE_MAIN
+		// add the route to main first so that the destination stays reachable
+		if err = netlink.RouteAdd(&restored); err != nil && !os.IsExist(err) {
+			logger.Error("failed to RouteAdd in main", zap.String("route", restored.String()), zap.Error(err))
+			return fmt.Errorf("failed to RouteAdd %s to main table: %w", restored.String(), err)
+		}
+
+		// the backup keeps its original protocol, so it is matched without the protocol
+		backup := netlink.Route{
+			LinkIndex: restored.LinkIndex,
+			Dst:       restored.Dst,
+			Scope:     restored.Scope,
+			Table:     unix.RT_TABLE_MAIN,
+			Priority:  restored.Priority + backupRouteMetricBump,
+		}
+		if err = netlink.RouteDel(&backup); err != nil && !os.IsNotExist(err) && !errors.Is(err, unix.ESRCH) {
+			logger.Error("failed to RouteDel the backup route", zap.String("route", backup.String()), zap.Error(err))
+			return fmt.Errorf("failed to RouteDel the backup route %s: %w", backup.String(), err)
+		}
+
+		if err = netlink.RouteDel(&route); err != nil && !os.IsNotExist(err) && !errors.Is(err, unix.ESRCH) {
+			logger.Error("failed to RouteDel in custom table", zap.String("route", route.String()), zap.Error(err))
+			return fmt.Errorf("failed to RouteDel %s in table %d: %w", route.String(), customTable, err)
+		}
+		logger.Debug("Restore the route to main successfully", zap.String("Route", restored.String()))
+	}
+
+	// the rules are listed by each family, because the listed rules don't
+	// carry the family, which is required to delete the rules without src
+	families := []int{ipfamily}
+	if ipfamily == netlink.FAMILY_ALL {
+		families = []int{netlink.FAMILY_V4, netlink.FAMILY_V6}
+	}
+	for _, family := range families {
+		rules, err := netlink.RuleListFiltered(family, &netlink.Rule{Table: customTable}, netlink.RT_FILTER_TABLE)
+		if err != nil {
+			if ipfamily == netlink.FAMILY_ALL && family == netlink.FAMILY_V6 && errors.Is(err, unix.EAFNOSUPPORT) {
+				continue
+			}
+			logger.Error(err.Error())
+			return fmt.Errorf("failed to list rules of table %d: %w", customTable, err)
+		}
+
+		for idx := range rules {
+			rule := &rules[idx]
+			if rule.Src == nil && rule.Mark == 0 {
+				continue
+			}
+			rule.Family = family
+			if err = netlink.RuleDel(rule); err != nil && !os.IsNotExist(err) {
+				logger.Error("failed to RuleDel", zap.String("rule", formatRule(rule)), zap.Error(err))
+				return fmt.Errorf("failed to delete rule '%s': %w", formatRule(rule), err)
+			}
+			logger.Debug("Delete the rule successfully", zap.String("rule", formatRule(rule)))
+		}
+	}
+	return nil
+}
+
+// routeList lists the routes of all the tables for migrateRouteTable, since
+// netlink.RouteList only dumps the main table. It is replaced in the unit
+// tests to inject the cloned routes, which the kernel doesn't dump.
+var routeList = func(family int) ([]netlink.Route, error) {
+	return netlink.RouteListFiltered(family, &netlink.Route{Table: unix.RT_TABLE_UNSPEC}, netlink.RT_FILTER_TABLE)
+}
+
+// RouteMove is a move of the routes of the interface from SrcRuleTable to
+// DstRuleTable for MoveRouteTables, see MoveRouteTable for the fields.
+type RouteMove struct {
+	Iface        string
+	SrcRuleTable int
+	DstRuleTable int
+	Skip         []*net.IPNet
+	KeepBackup   bool
+}
+
+// MoveRouteTables is MoveRouteTable for several interfaces, such as all the
+// secondary NICs of the pod, the routes are listed only once and dispatched to
+// the moves by their interfaces. A move must not move the routes to the source
+// table of another move, because the moved routes are not listed again. Only
+// AllowMissingIPv6 of opts is honored.
+func MoveRouteTables(logger *zap.Logger, moves []RouteMove, ipfamily int, opts ...RouteOption) error {
+	return migrateRouteTables(logger, nil, moves, ipfamily, true, opts)
+}
+
+// migrateRouteTable add all routes of the specified interface in srcRuleTable
+// to dstRuleTable, the routes are deleted from srcRuleTable if delSrcRoute is true,
+// or demoted to the backup with a higher metric if keepBackup is true as well.
+// The routes whose destination is within any of the skip CIDRs are ignored.
+func migrateRouteTable(logger *zap.Logger, links *LinkCache, iface string, srcRuleTable, dstRuleTable, ipfamily int, delSrcRoute, keepBackup bool, skip []*net.IPNet, opts []RouteOption) error {
+	moves := []RouteMove{{
+		Iface:        iface,
+		SrcRuleTable: srcRuleTable,
+		DstRuleTable: dstRuleTable,
+		Skip:         skip,
+		KeepBackup:   keepBackup,
+	}}
+	return migrateRouteTables(logger, links, moves, ipfamily, delSrcRoute, opts)
+}
+
+// migrateRouteTables is migrateRouteTable for the moves with a single listing
+// of the routes.
+func migrateRouteTables(logger *zap.Logger, links *LinkCache, moves []RouteMove, ipfamily int, delSrcRoute bool, opts []RouteOption) error {
+	if len(moves) == 0 {
+		return nil
+	}
+
+	if ipfamily == netlink.FAMILY_V6 || ipfamily == netlink.FAMILY_ALL {
+		ifaces := make([]string, 0, len(moves))
+		for _, move := range moves {
+			ifaces = append(ifaces, move.Iface)
+		}
+		operation := fmt.Sprintf("migrate the IPv6 routes of %s", strings.Join(ifaces, ","))
+		skipIPv6, err := missingIPv6Option(opts).skipMissingIPv6(netlink.FAMILY_V6, operation)
+		if err != nil {
+			return err
+		}
+		if skipIPv6 {
+			if ipfamily == netlink.FAMILY_V6 {
+				return nil
+			}
+			ipfamily = netlink.FAMILY_V4
+		}
+	}
+
+	linkIndexes := make([]int, 0, len(moves))
+	for _, move := range moves {
+		linkIndex, _, err := resolveLinkStable(links, move.Iface)
+		if err != nil {
+			logger.Error(err.Error())
+			return err
+		}
+		linkIndexes = append(linkIndexes, linkIndex)
+	}
+
+	routes, err := routeList(ipfamily)
+	if err != nil {
+		logger.Error(err.Error())
+		return err
+	}
+
+	for _, route := range routes {
+		// ignore local link route
+		if route.Dst.String() == "fe80::/64" {
+			continue
+		}
+
+		// the cloned routes are the cache of the kernel, which can't be added
+		if route.Flags&unix.RTM_F_CLONED != 0 {
+			logger.Debug("skip the cloned route", zap.String("Route", route.String()))
+			continue
+		}
+
+		for i := range moves {
+			// only handle the routes of the source table
+			if route.Table != moves[i].SrcRuleTable {
+				continue
+			}
+			if err = migrateRoute(logger, route, linkIndexes[i], &moves[i], delSrcRoute); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// migrateRoute migrates the route of the source table if it's via the link of
+// the move, a multipath route is migrated as the route via its hop on the link.
+func migrateRoute(logger *zap.Logger, route netlink.Route, linkIndex int, move *RouteMove, delSrcRoute bool) error {
+	if isRouteDstInCIDRs(&route, move.Skip) {
+		logger.Debug("skip the route", zap.String("Route", route.String()))
+		return nil
+	}
+
+	if route.LinkIndex == linkIndex {
+		if delSrcRoute && move.KeepBackup {
+			if err := demoteRoute(logger, route); err != nil {
+				return err
+			}
+		} else if delSrcRoute {
+			if err := netlink.RouteDel(&route); err != nil {
+				logger.Error("failed to RouteDel in main", zap.String("route", route.String()), zap.Error(err))
+				return fmt.Errorf("failed to RouteDel %s in main table: %+v", route.String(), err)
+			}
+			logger.Debug("Del the route from main successfully", zap.String("Route", route.String()))
+		}
+
+		route.Table = move.DstRuleTable
+		route.Protocol = RouteProtocolSpiderpool
+		if err := netlink.RouteAdd(&route); err != nil && !os.IsExist(err) {
+			logger.Error("failed to RouteAdd in new table ", zap.String("route", route.String()), zap.Error(err))
+			return fmt.Errorf("failed to RouteAdd (%+v) to new table: %+v", route, err)
+		}
+		logger.Debug("Add the route to new table successfully", zap.String("Route", route.String()))
+		return nil
+	}
+
+	// especially for ipv6 default route
+	if len(route.MultiPath) == 0 {
+		return nil
+	}
+
+	var generatedRoute, deletedRoute *netlink.Route
+	// get generated default Route for new table
+	for _, v := range route.MultiPath {
+		logger.Debug("Found IPv6 Default Route", zap.String("Route", route.String()),
+			zap.Int("v.LinkIndex", v.LinkIndex), zap.Int("linkIndex", linkIndex))
+		if v.LinkIndex == linkIndex {
+			generatedRoute = &netlink.Route{
+				LinkIndex: v.LinkIndex,
+				Gw:        v.Gw,
+				Table:     move.DstRuleTable,
+				MTU:       route.MTU,
+				Realm:     route.Realm,
+				Protocol:  RouteProtocolSpiderpool,
+			}
+			deletedRoute = &netlink.Route{
+				LinkIndex: v.LinkIndex,
+				Gw:        v.Gw,
+				Table:     move.SrcRuleTable,
+			}
+			break
+		}
+	}
+	if generatedRoute == nil {
+		return nil
+	}
+
+	if delSrcRoute && move.KeepBackup {
+		deletedRoute.Priority = route.Priority
+		if err := demoteRoute(logger, *deletedRoute); err != nil {
+			return err
+		}
+	} else if delSrcRoute {
+		logger.Debug("deletedRoute", zap.String("deletedRoute", deletedRoute.String()))
+		if err := netlink.RouteDel(deletedRoute); err != nil {
+			logger.Error("failed to RouteDel for IPv6", zap.String("Route", route.String()), zap.Error(err))
+			return fmt.Errorf("failed to RouteDel %v for IPv6: %+v", route.String(), err)
+		}
+	}
+
+	if err := netlink.RouteAdd(generatedRoute); err != nil && !os.IsExist(err) {
+		logger.Error("failed to RouteAdd for IPv6 to new table", zap.String("route", route.String()), zap.Error(err))
+		return fmt.Errorf("failed to RouteAdd for IPv6 (%+v) to new table: %+v", route.String(), err)
+	}
+	return nil
+}
+
+// demoteRoute replaces the route with a copy whose metric is increased by
+// backupRouteMetricBump, the copy is added before the route is deleted so that
+// the destination stays reachable.
+func demoteRoute(logger *zap.Logger, route netlink.Route) error {
+	backup := route
+	backup.Priority = route.Priority + backupRouteMetricBump
+	if err := netlink.RouteReplace(&backup); err != nil {
+		logger.Error("failed to RouteReplace the backup route", zap.String("route", backup.String()), zap.Error(err))
+		return fmt.Errorf("failed to RouteReplace the backup route %s: %w", backup.String(), err)
+	}
+
+	if err := netlink.RouteDel(&route); err != nil && !os.IsNotExist(err) {
+		logger.Error("failed to RouteDel the demoted route", zap.String("route", route.String()), zap.Error(err))
+		return fmt.Errorf("failed to RouteDel the demoted route %s: %w", route.String(), err)
+	}
+	logger.Debug("Keep the route as the backup successfully", zap.String("Route", backup.String()))
+	return nil
+}
+
+// isRouteDstInCIDRs returns true if the destination of the route is within any of the cidrs
+func isRouteDstInCIDRs(route *netlink.Route, cidrs []*net.IPNet) bool {
+	if route.Dst == nil {
+		return false
+	}
+	ones, bits := route.Dst.Mask.Size()
+	for _, cidr := range cidrs {
+		cidrOnes, cidrBits := cidr.Mask.Size()
+		if bits == cidrBits && ones >= cidrOnes && cidr.Contains(route.Dst.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// FlushRouteTable deletes all routes of the ruleTable
+// Equivalent to: `ip route flush table <ruleTable>`
+func FlushRouteTable(ruleTable, ipfamily int) error {
+	routes, err := netlink.RouteListFiltered(ipfamily, &netlink.Route{Table: ruleTable}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range routes {
+		if err = netlink.RouteDel(&routes[idx]); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to RouteDel %v: %w", routes[idx].String(), err)
+		}
+	}
+	return nil
+}
+
+// RouteKey returns the identity of the route regardless of the table it
+// belongs to, so that routes in different tables can be compared
+func RouteKey(route *netlink.Route) string {
+	// the default route may be listed with dst nil or 0.0.0.0/0
+	dst := "default"
+	if !isDefaultRoute(route) {
+		dst = route.Dst.String()
+	}
+	return fmt.Sprintf("%s/%d/%s/%s/%d/%d", dst, route.LinkIndex, route.Gw, route.Src, route.Scope, route.Priority)
+}
+
+// DiffRouteTables compares the routes of aTable and bTable by RouteKey, returns
+// the routes only in aTable and the routes only in bTable
+func DiffRouteTables(aTable, bTable, ipFamily int) (onlyA, onlyB []netlink.Route, err error) {
+	aRoutes, err := netlink.RouteListFiltered(ipFamily, &netlink.Route{Table: aTable}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list routes of table %d: %w", aTable, err)
+	}
+	bRoutes, err := netlink.RouteListFiltered(ipFamily, &netlink.Route{Table: bTable}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list routes of table %d: %w", bTable, err)
+	}
+
+	aKeys := make(map[string]struct{}, len(aRoutes))
+	for idx := range aRoutes {
+		aKeys[RouteKey(&aRoutes[idx])] = struct{}{}
+	}
+	bKeys := make(map[string]struct{}, len(bRoutes))
+	for idx := range bRoutes {
+		bKeys[RouteKey(&bRoutes[idx])] = struct{}{}
+	}
+
+	for idx := range aRoutes {
+		if _, ok := bKeys[RouteKey(&aRoutes[idx])]; !ok {
+			onlyA = append(onlyA, aRoutes[idx])
+		}
+	}
+	for idx := range bRoutes {
+		if _, ok := aKeys[RouteKey(&bRoutes[idx])]; !ok {
+			onlyB = append(onlyB, bRoutes[idx])
+		}
+	}
+	return onlyA, onlyB, nil
+}
+
+// DedupRoutes deletes the duplicate routes of the table which differ only by
+// metric, such as the default routes to the same gateway left by crash loops.
+// The routes are grouped by (Dst, Gw, LinkIndex) and the one with the lowest
+// metric of each group is kept. The multipath routes are left untouched.
+func DedupRoutes(table, ipFamily int) (removed int, err error) {
+	routes, err := netlink.RouteListFiltered(ipFamily, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list routes of table %d: %w", table, err)
+	}
+
+	best := make(map[string]int, len(routes))
+	for idx := range routes {
+		if len(routes[idx].MultiPath) > 0 {
+			continue
+		}
+		key := dedupRouteKey(&routes[idx])
+		if b, ok := best[key]; !ok || routes[idx].Priority < routes[b].Priority {
+			best[key] = idx
+		}
+	}
+
+	for idx := range routes {
+		if len(routes[idx].MultiPath) > 0 || best[dedupRouteKey(&routes[idx])] == idx {
+			continue
+		}
+		if err = netlink.RouteDel(&routes[idx]); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to delete duplicate route %s: %w", routes[idx].String(), err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func dedupRouteKey(route *netlink.Route) string {
+	dst := "default"
+	if !isDefaultRoute(route) {
+		dst = route.Dst.String()
+	}
+	return fmt.Sprintf("%s/%s/%d", dst, route.Gw, route.LinkIndex)
+}
+
+// RouteInfo is the JSON friendly form of a route, such as for the status
+// and debug reports
+type RouteInfo struct {
+	Dst      string             `json:"dst"`
+	Gw       string             `json:"gw,omitempty"`
+	Src      string             `json:"src,omitempty"`
+	Iface    string             `json:"iface,omitempty"`
+	Scope    string             `json:"scope"`
+	Protocol int                `json:"protocol"`
+	Metric   int                `json:"metric"`
+	Nexthops []RouteNexthopInfo `json:"nexthops,omitempty"`
+}
+
+// RouteNexthopInfo is a member of the multipath route in RouteInfo
+type RouteNexthopInfo struct {
+	Gw     string `json:"gw,omitempty"`
+	Iface  string `json:"iface,omitempty"`
+	Weight int    `json:"weight"`
+}
+
+// RouteInventory returns the routes of all the tables grouped by the table
+func RouteInventory(ipFamily int) (map[int][]RouteInfo, error) {
+	routes, err := netlink.RouteListFiltered(ipFamily, &netlink.Route{Table: unix.RT_TABLE_UNSPEC}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routes: %w", err)
+	}
+
+	links, err := netlink.LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	linkNames := make(map[int]string, len(links))
+	for _, link := range links {
+		linkNames[link.Attrs().Index] = link.Attrs().Name
+	}
+
+	inventory := make(map[int][]RouteInfo)
+	for idx := range routes {
+		route := &routes[idx]
+		info := RouteInfo{
+			Dst:      "default",
+			Iface:    linkNames[route.LinkIndex],
+			Scope:    route.Scope.String(),
+			Protocol: int(route.Protocol),
+			Metric:   route.Priority,
+		}
+		if !isDefaultRoute(route) {
+			info.Dst = route.Dst.String()
+		}
+		if route.Gw != nil {
+			info.Gw = route.Gw.String()
+		}
+		if route.Src != nil {
+			info.Src = route.Src.String()
+		}
+		for _, nh := range route.MultiPath {
+			nexthop := RouteNexthopInfo{Iface: linkNames[nh.LinkIndex], Weight: nh.Hops + 1}
+			if nh.Gw != nil {
+				nexthop.Gw = nh.Gw.String()
+			}
+			info.Nexthops = append(info.Nexthops, nexthop)
+		}
+		inventory[route.Table] = append(inventory[route.Table], info)
+	}
+	return inventory, nil
 }
 
 // GetDefaultRouteInterface returns the name of the NIC where the default route is located
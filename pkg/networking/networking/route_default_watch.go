@@ -0,0 +1,118 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+)
+
+// DefaultRouteEvent is emitted by WatchDefaultRouteChanges when the gateway of
+// the default route changes. The Old or the New is nil when there was or is no
+// default route.
+type DefaultRouteEvent struct {
+	IPFamily int
+	Old      net.IP
+	New      net.IP
+}
+
+// WatchDefaultRouteChanges watches the default route of the main table in the
+// current netns, and emits an event to ch whenever its gateway changes, so that
+// the default route flapping between the gateways can be noticed. If there are
+// several default routes, the one with the lowest metric is watched.
+// It returns once the watch is started, and the watch is stopped when the ctx
+// is done. The ch is never closed by it.
+func WatchDefaultRouteChanges(ctx context.Context, ipFamily int, ch chan<- DefaultRouteEvent) error {
+	if ipFamily != netlink.FAMILY_V4 && ipFamily != netlink.FAMILY_V6 {
+		return fmt.Errorf("unsupported ipFamily %d", ipFamily)
+	}
+
+	// the routes are listed in the netns where the watch is started, rather
+	// than the one of the thread running the watch
+	nsHandle, err := netns.Get()
+	if err != nil {
+		return fmt.Errorf("failed to get current netns: %w", err)
+	}
+	handle, err := netlink.NewHandleAt(nsHandle)
+	if err != nil {
+		nsHandle.Close()
+		return fmt.Errorf("failed to create netlink handle: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cleanup := func() {
+		cancel()
+		handle.Delete()
+		nsHandle.Close()
+	}
+
+	// subscribe before listing, so that no change is missed
+	updates, err := SubscribeRouteUpdates(ctx, RouteUpdateFilter{}, 16)
+	if err != nil {
+		cleanup()
+		return err
+	}
+	current, err := defaultRouteGateway(handle, ipFamily)
+	if err != nil {
+		cleanup()
+		return err
+	}
+
+	go func() {
+		defer cleanup()
+
+		for update := range updates {
+			if update.Family != ipFamily || update.Table != unix.RT_TABLE_MAIN || !isDefaultRoute(&update.Route) {
+				continue
+			}
+
+			gw, err := defaultRouteGateway(handle, ipFamily)
+			if err != nil || gw.Equal(current) {
+				continue
+			}
+
+			event := DefaultRouteEvent{IPFamily: ipFamily, Old: current, New: gw}
+			current = gw
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// defaultRouteGateway returns the gateway of the default route with the lowest
+// metric in the main table, or nil if there is no default route.
+func defaultRouteGateway(handle *netlink.Handle, ipFamily int) (net.IP, error) {
+	routes, err := handle.RouteListFiltered(ipFamily, &netlink.Route{Table: unix.RT_TABLE_MAIN}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routes: %w", err)
+	}
+
+	var best *netlink.Route
+	for idx := range routes {
+		route := &routes[idx]
+		if !isDefaultRoute(route) {
+			continue
+		}
+		if best == nil || route.Priority < best.Priority {
+			best = route
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+
+	if best.Gw == nil && len(best.MultiPath) > 0 {
+		return best.MultiPath[0].Gw, nil
+	}
+	return best.Gw, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+var _ = Describe("WatchDefaultRouteChanges", Label("route_default_watch"), func() {
+	var testNetNS ns.NetNS
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+
+		err = testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			Expect(netlink.LinkAdd(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+				PeerName:  "peer12345",
+			})).To(Succeed())
+			for _, name := range []string{"net1", "peer12345"} {
+				link, err := netlink.LinkByName(name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.LinkSetUp(link)).To(Succeed())
+			}
+			link, err := netlink.LinkByName("net1")
+			Expect(err).NotTo(HaveOccurred())
+			addr, err := netlink.ParseAddr("10.6.0.2/24")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+			Expect(networking.AddRoute(zap.NewNop(), unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1",
+				nil, net.ParseIP("10.6.0.1"), nil)).To(Succeed())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("emits the old and new gateways when the default gateway is swapped", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := make(chan networking.DefaultRouteEvent, 4)
+
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			Expect(networking.WatchDefaultRouteChanges(ctx, netlink.FAMILY_V4, events)).To(Succeed())
+
+			// the routes not default are ignored
+			_, dst, err := net.ParseCIDR("172.16.0.0/16")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(networking.AddRoute(zap.NewNop(), unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1",
+				dst, net.ParseIP("10.6.0.1"), nil)).To(Succeed())
+
+			return networking.SwapDefaultGateway(zap.NewNop(), "net1", netlink.FAMILY_V4, net.ParseIP("10.6.0.254"))
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		var event networking.DefaultRouteEvent
+		Eventually(events).Should(Receive(&event))
+		Expect(event.IPFamily).To(Equal(netlink.FAMILY_V4))
+		Expect(event.Old.String()).To(Equal("10.6.0.1"))
+		Expect(event.New.String()).To(Equal("10.6.0.254"))
+		Consistently(events, 200*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("emits a nil new gateway once the default route is deleted", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := make(chan networking.DefaultRouteEvent, 4)
+
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			Expect(networking.WatchDefaultRouteChanges(ctx, netlink.FAMILY_V4, events)).To(Succeed())
+			link, err := netlink.LinkByName("net1")
+			Expect(err).NotTo(HaveOccurred())
+			return netlink.RouteDel(&netlink.Route{
+				LinkIndex: link.Attrs().Index,
+				Gw:        net.ParseIP("10.6.0.1"),
+				Table:     unix.RT_TABLE_MAIN,
+			})
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		var event networking.DefaultRouteEvent
+		Eventually(events).Should(Receive(&event))
+		Expect(event.Old.String()).To(Equal("10.6.0.1"))
+		Expect(event.New).To(BeNil())
+	})
+
+	It("refuses the unsupported ipFamily", func() {
+		Expect(networking.WatchDefaultRouteChanges(context.Background(), netlink.FAMILY_ALL, nil)).NotTo(Succeed())
+	})
+})
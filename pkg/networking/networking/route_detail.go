@@ -0,0 +1,148 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// RouteKind classifies the routes listed by ListRoutesDetailed
+type RouteKind string
+
+const (
+	// RouteKindDefault is the route to 0.0.0.0/0 or ::/0 via a single hop
+	RouteKindDefault RouteKind = "default"
+	// RouteKindConnected is the route to the subnet reached directly by the link
+	RouteKindConnected RouteKind = "connected"
+	// RouteKindHost is the route to a single address, /32 or /128
+	RouteKindHost RouteKind = "host"
+	// RouteKindGateway is the route to the subnet via the gateway
+	RouteKindGateway RouteKind = "gateway"
+	// RouteKindMultipath is the route via more than one hop, including the
+	// load-balanced default route
+	RouteKindMultipath RouteKind = "multipath"
+	// RouteKindSpecial is the route not forwarding to a link, such as the
+	// local, broadcast, blackhole and unreachable ones
+	RouteKindSpecial RouteKind = "special"
+)
+
+// RouteDetail is a route decorated with the names of its links and its kind,
+// such as for the diagnostics
+type RouteDetail struct {
+	netlink.Route
+	// IfName is the name of the link of the route, empty for the multipath route
+	// or if the link is gone
+	IfName string
+	// GatewayIfName is the name of the link of each hop of the multipath route,
+	// in the order of route.MultiPath
+	GatewayIfName []string
+	Kind          RouteKind
+	// Owned is true if the route is added by spiderpool
+	Owned bool
+}
+
+// ListRoutesDetailed lists the routes of the table in the netns, 0 for all the
+// tables, sorted by the table, family and destination.
+// Equivalent to: `ip route show table <table>` and `ip link show`
+func ListRoutesDetailed(netns ns.NetNS, family, table int) ([]RouteDetail, error) {
+	var details []RouteDetail
+	err := netns.Do(func(_ ns.NetNS) error {
+		routes, err := netlink.RouteListFiltered(family, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+		if err != nil {
+			return fmt.Errorf("failed to list routes of table %d: %w", table, err)
+		}
+
+		links, err := netlink.LinkList()
+		if err != nil {
+			return fmt.Errorf("failed to list links: %w", err)
+		}
+		linkNames := make(map[int]string, len(links))
+		for _, link := range links {
+			linkNames[link.Attrs().Index] = link.Attrs().Name
+		}
+
+		details = make([]RouteDetail, 0, len(routes))
+		for _, route := range routes {
+			detail := RouteDetail{
+				Route:  route,
+				IfName: linkNames[route.LinkIndex],
+				Kind:   routeKind(&route),
+				Owned:  route.Protocol == RouteProtocolSpiderpool,
+			}
+			for _, hop := range route.MultiPath {
+				detail.GatewayIfName = append(detail.GatewayIfName, linkNames[hop.LinkIndex])
+			}
+			details = append(details, detail)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(details, func(i, j int) bool {
+		return lessRoute(&details[i].Route, &details[j].Route)
+	})
+	return details, nil
+}
+
+func routeKind(route *netlink.Route) RouteKind {
+	if route.Type != unix.RTN_UNICAST {
+		return RouteKindSpecial
+	}
+	if len(route.MultiPath) > 0 {
+		return RouteKindMultipath
+	}
+	if isDefaultRoute(route) {
+		return RouteKindDefault
+	}
+	if ones, bits := route.Dst.Mask.Size(); ones == bits {
+		return RouteKindHost
+	}
+	if route.Gw == nil {
+		return RouteKindConnected
+	}
+	return RouteKindGateway
+}
+
+// lessRoute orders the routes by the table, family and destination, the routes
+// to the same destination are ordered by the metric and link.
+func lessRoute(a, b *netlink.Route) bool {
+	if a.Table != b.Table {
+		return a.Table < b.Table
+	}
+	if a.Family != b.Family {
+		return a.Family < b.Family
+	}
+
+	aIP, aOnes := routeDst(a)
+	bIP, bOnes := routeDst(b)
+	if c := bytes.Compare(aIP, bIP); c != 0 {
+		return c < 0
+	}
+	if aOnes != bOnes {
+		return aOnes < bOnes
+	}
+
+	if a.Priority != b.Priority {
+		return a.Priority < b.Priority
+	}
+	return a.LinkIndex < b.LinkIndex
+}
+
+// routeDst returns the destination of the route in 16 bytes and its prefix
+// length, the default route is ::/0.
+func routeDst(route *netlink.Route) ([]byte, int) {
+	if route.Dst == nil {
+		return make([]byte, 16), 0
+	}
+	ones, _ := route.Dst.Mask.Size()
+	return route.Dst.IP.To16(), ones
+}
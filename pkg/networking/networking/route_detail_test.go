@@ -0,0 +1,112 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"net"
+	"sort"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+var _ = Describe("ListRoutesDetailed", Label("route_detail"), func() {
+	var testNetNS ns.NetNS
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+	})
+
+	It("resolves the links, classifies and sorts the routes", func() {
+		logger := zap.NewNop()
+		gw1, gw2 := net.ParseIP("10.6.0.1"), net.ParseIP("10.6.0.254")
+
+		var linkIndex int
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			Expect(netlink.LinkAdd(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+				PeerName:  "peer12345",
+			})).To(Succeed())
+			link, err := netlink.LinkByName("net1")
+			Expect(err).NotTo(HaveOccurred())
+			linkIndex = link.Attrs().Index
+			Expect(netlink.LinkSetUp(link)).To(Succeed())
+			addr, err := netlink.ParseAddr("10.6.0.2/24")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+			_, hijack, _ := net.ParseCIDR("172.16.0.0/16")
+			_, host, _ := net.ParseCIDR("10.7.0.1/32")
+			Expect(networking.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", hijack, gw1, nil)).To(Succeed())
+			Expect(networking.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", host, gw1, nil)).To(Succeed())
+			Expect(networking.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", nil, gw1, nil)).To(Succeed())
+
+			_, defaultDst, _ := net.ParseCIDR("0.0.0.0/0")
+			Expect(networking.AddMultipathRoute(logger, 100, defaultDst, []networking.Nexthop{
+				{LinkIndex: linkIndex, Gw: gw1},
+				{LinkIndex: linkIndex, Gw: gw2},
+			})).To(Succeed())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		details, err := networking.ListRoutesDetailed(testNetNS, netlink.FAMILY_V4, unix.RT_TABLE_MAIN)
+		Expect(err).NotTo(HaveOccurred())
+
+		type summary struct {
+			Dst    string
+			IfName string
+			Kind   networking.RouteKind
+			Owned  bool
+		}
+		var summaries []summary
+		for _, detail := range details {
+			Expect(detail.Table).To(Equal(unix.RT_TABLE_MAIN))
+			summaries = append(summaries, summary{detail.Dst.String(), detail.IfName, detail.Kind, detail.Owned})
+		}
+		Expect(summaries).To(Equal([]summary{
+			{"0.0.0.0/0", "net1", networking.RouteKindDefault, true},
+			{"10.6.0.0/24", "net1", networking.RouteKindConnected, false},
+			{"10.7.0.1/32", "net1", networking.RouteKindHost, true},
+			{"172.16.0.0/16", "net1", networking.RouteKindGateway, true},
+		}))
+
+		// all the tables
+		details, err = networking.ListRoutesDetailed(testNetNS, netlink.FAMILY_V4, 0)
+		Expect(err).NotTo(HaveOccurred())
+		var tables []int
+		var multipath *networking.RouteDetail
+		for i := range details {
+			tables = append(tables, details[i].Table)
+			switch details[i].Table {
+			case 100:
+				multipath = &details[i]
+			case unix.RT_TABLE_LOCAL:
+				Expect(details[i].Kind).To(Equal(networking.RouteKindSpecial))
+			}
+		}
+		Expect(sort.IntsAreSorted(tables)).To(BeTrue())
+		Expect(multipath).NotTo(BeNil())
+		Expect(multipath.Kind).To(Equal(networking.RouteKindMultipath))
+		Expect(multipath.IfName).To(BeEmpty())
+		Expect(multipath.GatewayIfName).To(Equal([]string{"net1", "net1"}))
+		Expect(multipath.Owned).To(BeTrue())
+	})
+})
@@ -0,0 +1,228 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// RouteExists returns true if the route is in its table, the handle is nil for
+// the current netns. The destination, gateway, table and family are always
+// compared, the nil destination is the default route and the table 0 is main.
+// The attributes populated by the kernel, such as the link, metric, protocol,
+// type and preferred source, are only compared if they are set in the route.
+func RouteExists(handle *netlink.Handle, route *netlink.Route) (bool, error) {
+	if route == nil {
+		return false, fmt.Errorf("route must be specified")
+	}
+	family := routeFamily(route)
+	if family == netlink.FAMILY_ALL {
+		return false, fmt.Errorf("failed to get the family of route %s", route)
+	}
+
+	table := route.Table
+	if table == unix.RT_TABLE_UNSPEC {
+		table = unix.RT_TABLE_MAIN
+	}
+
+	filter := &netlink.Route{Table: table}
+	var routes []netlink.Route
+	var err error
+	if handle == nil {
+		routes, err = netlink.RouteListFiltered(family, filter, netlink.RT_FILTER_TABLE)
+	} else {
+		routes, err = handle.RouteListFiltered(family, filter, netlink.RT_FILTER_TABLE)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to list routes of table %d: %w", table, err)
+	}
+
+	for i := range routes {
+		if routeMatches(route, &routes[i]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RuleExists returns true if the rule is in the current netns. The rule is
+// expected to be built by netlink.NewRule, the priority, table and family are
+// only compared if they are set, the source or destination of length 0 is all,
+// and the mark without mask matches on all the bits as the kernel does.
+func RuleExists(rule *netlink.Rule) (bool, error) {
+	if rule == nil {
+		return false, fmt.Errorf("rule must be specified")
+	}
+
+	family := ruleFamily(rule)
+	rules, err := netlink.RuleList(family)
+	if err != nil {
+		return false, fmt.Errorf("failed to list rules: %w", err)
+	}
+
+	for i := range rules {
+		if ruleMatches(rule, &rules[i]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// addRule adds the rule unless the same rule exists, which fails with EEXIST
+// as the kernel does. The IPv6 rule is skipped if IPv6 is unsupported and
+// AllowMissingIPv6 is in opts.
+func addRule(rule *netlink.Rule, opts ...RuleOption) error {
+	ipv6 := missingIPv6Option(opts)
+	family := ruleFamily(rule)
+	if skip, err := ipv6.skipMissingIPv6(family, "add rule "+formatRule(rule)); err != nil || skip {
+		return err
+	}
+
+	exists, err := RuleExists(rule)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return unix.EEXIST
+	}
+
+	err = netlink.RuleAdd(rule)
+	if err != nil && ipv6.skipIPv6Error(family, "add rule "+formatRule(rule), err) {
+		return nil
+	}
+	return err
+}
+
+func routeFamily(route *netlink.Route) int {
+	switch {
+	case route.Dst != nil && route.Dst.IP != nil:
+		return ipFamilyOf(route.Dst.IP)
+	case route.Gw != nil:
+		return ipFamilyOf(route.Gw)
+	case len(route.MultiPath) > 0 && route.MultiPath[0].Gw != nil:
+		return ipFamilyOf(route.MultiPath[0].Gw)
+	}
+	return route.Family
+}
+
+func ruleFamily(rule *netlink.Rule) int {
+	switch {
+	case rule.Family != netlink.FAMILY_ALL:
+		return rule.Family
+	case rule.Src != nil && rule.Src.IP != nil:
+		return ipFamilyOf(rule.Src.IP)
+	case rule.Dst != nil && rule.Dst.IP != nil:
+		return ipFamilyOf(rule.Dst.IP)
+	}
+	return netlink.FAMILY_ALL
+}
+
+// routeMatches returns true if got, which is listed from the kernel, is the
+// route described by want
+func routeMatches(want, got *netlink.Route) bool {
+	if !sameIPNet(want.Dst, got.Dst) || !want.Gw.Equal(got.Gw) || want.Tos != got.Tos {
+		return false
+	}
+	if want.LinkIndex > 0 && want.LinkIndex != got.LinkIndex {
+		return false
+	}
+	if want.Priority > 0 && want.Priority != got.Priority {
+		return false
+	}
+	if want.Protocol != 0 && want.Protocol != got.Protocol {
+		return false
+	}
+	if want.Type != 0 && want.Type != got.Type {
+		return false
+	}
+	if want.Src != nil && !want.Src.Equal(got.Src) {
+		return false
+	}
+
+	if len(want.MultiPath) != len(got.MultiPath) {
+		return false
+	}
+	for i, hop := range want.MultiPath {
+		if !hop.Gw.Equal(got.MultiPath[i].Gw) {
+			return false
+		}
+		if hop.LinkIndex > 0 && hop.LinkIndex != got.MultiPath[i].LinkIndex {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleMatches returns true if got, which is listed from the kernel, is the
+// rule described by want
+func ruleMatches(want, got *netlink.Rule) bool {
+	// the kernel doesn't report the priority 0
+	gotPriority := got.Priority
+	if gotPriority < 0 {
+		gotPriority = 0
+	}
+	if want.Priority >= 0 && want.Priority != gotPriority {
+		return false
+	}
+	if want.Table > 0 && want.Table != got.Table {
+		return false
+	}
+	if !sameIPNet(want.Src, got.Src) || !sameIPNet(want.Dst, got.Dst) {
+		return false
+	}
+
+	wantMark, wantMask := ruleMark(want)
+	gotMark, gotMask := ruleMark(got)
+	if wantMark != gotMark || wantMask != gotMask {
+		return false
+	}
+
+	return want.Invert == got.Invert &&
+		want.Tos == got.Tos &&
+		want.Goto == got.Goto &&
+		want.IifName == got.IifName &&
+		want.OifName == got.OifName &&
+		want.SuppressPrefixlen == got.SuppressPrefixlen &&
+		want.SuppressIfgroup == got.SuppressIfgroup
+}
+
+// ruleMark returns the mark and mask matched by the rule, the rule without mark
+// matches on 0/0, and the mark without mask matches on all the bits.
+func ruleMark(rule *netlink.Rule) (mark, mask uint32) {
+	if rule.Mark > 0 {
+		mark = uint32(rule.Mark)
+	}
+	switch {
+	case rule.Mask >= 0:
+		mask = uint32(rule.Mask)
+	case mark != 0:
+		mask = 0xffffffff
+	}
+	return mark, mask
+}
+
+// sameIPNet returns true if a and b are the same prefix, nil is the prefix of
+// length 0, which is the default route or all for the rules
+func sameIPNet(a, b *net.IPNet) bool {
+	aAll, bAll := isAllPrefix(a), isAllPrefix(b)
+	if aAll || bAll {
+		return aAll && bAll
+	}
+
+	na, nb := normalizeIPNet(*a), normalizeIPNet(*b)
+	return na.IP.Mask(na.Mask).Equal(nb.IP.Mask(nb.Mask)) && bytes.Equal(na.Mask, nb.Mask)
+}
+
+func isAllPrefix(n *net.IPNet) bool {
+	if n == nil || n.IP == nil {
+		return true
+	}
+	ones, _ := normalizeIPNet(*n).Mask.Size()
+	return ones == 0
+}
@@ -0,0 +1,223 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"net"
+	"os"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+var _ = Describe("RouteExists", Label("route_exists"), func() {
+	var testNetNS ns.NetNS
+	var linkIndex int
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+
+		err = testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			logger := zap.NewNop()
+			Expect(netlink.LinkAdd(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+				PeerName:  "peer12345",
+			})).To(Succeed())
+			link, err := netlink.LinkByName("net1")
+			Expect(err).NotTo(HaveOccurred())
+			linkIndex = link.Attrs().Index
+			Expect(netlink.LinkSetUp(link)).To(Succeed())
+			for _, cidr := range []string{"10.6.0.2/24", "fd00::2/64"} {
+				addr, err := netlink.ParseAddr(cidr)
+				Expect(err).NotTo(HaveOccurred())
+				addr.Flags = unix.IFA_F_NODAD
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+			}
+
+			Expect(networking.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1",
+				nil, net.ParseIP("10.6.0.1"), nil)).To(Succeed())
+			Expect(networking.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1",
+				mustParseCIDR("172.16.0.0/16"), net.ParseIP("10.6.0.1"), nil)).To(Succeed())
+			Expect(networking.AddRoute(logger, 100, netlink.FAMILY_V6, netlink.SCOPE_UNIVERSE, "net1",
+				nil, nil, net.ParseIP("fd00::1"))).To(Succeed())
+
+			Expect(networking.AddFromRuleTable(mustParseCIDR("10.6.0.2/32"), 100, networking.WithRulePriority(2000))).To(Succeed())
+			Expect(networking.AddRuleTableWithMark(0x1, 500, netlink.FAMILY_V4, networking.DefaultMarkRulePriority)).To(Succeed())
+			Expect(networking.AddNotToRuleTable(mustParseCIDR("fd00::/64"), 100, netlink.FAMILY_V6, 2001)).To(Succeed())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("compares the semantically relevant fields of the route",
+		func(route func() *netlink.Route, expected bool) {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				exists, err := networking.RouteExists(nil, route())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(exists).To(Equal(expected))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		},
+		Entry("nil Dst is 0.0.0.0/0", func() *netlink.Route {
+			return &netlink.Route{Gw: net.ParseIP("10.6.0.1")}
+		}, true),
+		Entry("0.0.0.0/0 is the default route", func() *netlink.Route {
+			return &netlink.Route{Dst: mustParseCIDR("0.0.0.0/0"), Gw: net.ParseIP("10.6.0.1"), Table: unix.RT_TABLE_MAIN}
+		}, true),
+		Entry("nil Dst is ::/0 for the IPv6 gateway", func() *netlink.Route {
+			return &netlink.Route{Gw: net.ParseIP("fd00::1"), Table: 100}
+		}, true),
+		Entry("::/0 is not in main table", func() *netlink.Route {
+			return &netlink.Route{Dst: mustParseCIDR("::/0"), Gw: net.ParseIP("fd00::1")}
+		}, false),
+		Entry("4-byte gateway equals the v4-mapped one", func() *netlink.Route {
+			return &netlink.Route{Dst: mustParseCIDR("172.16.0.0/16"), Gw: net.ParseIP("10.6.0.1").To4()}
+		}, true),
+		Entry("IPv4 destination with 16-byte mask", func() *netlink.Route {
+			return &netlink.Route{
+				Dst: &net.IPNet{IP: net.ParseIP("172.16.0.0"), Mask: net.CIDRMask(96+16, 128)},
+				Gw:  net.ParseIP("10.6.0.1"),
+			}
+		}, true),
+		Entry("different gateway", func() *netlink.Route {
+			return &netlink.Route{Dst: mustParseCIDR("172.16.0.0/16"), Gw: net.ParseIP("10.6.0.254")}
+		}, false),
+		Entry("different prefix length", func() *netlink.Route {
+			return &netlink.Route{Dst: mustParseCIDR("172.16.0.0/24"), Gw: net.ParseIP("10.6.0.1")}
+		}, false),
+		Entry("the specified link and protocol", func() *netlink.Route {
+			return &netlink.Route{Dst: mustParseCIDR("172.16.0.0/16"), Gw: net.ParseIP("10.6.0.1"),
+				LinkIndex: linkIndex, Protocol: networking.RouteProtocolSpiderpool}
+		}, true),
+		Entry("different link", func() *netlink.Route {
+			return &netlink.Route{Dst: mustParseCIDR("172.16.0.0/16"), Gw: net.ParseIP("10.6.0.1"), LinkIndex: 1}
+		}, false),
+		Entry("different protocol", func() *netlink.Route {
+			return &netlink.Route{Dst: mustParseCIDR("172.16.0.0/16"), Gw: net.ParseIP("10.6.0.1"), Protocol: unix.RTPROT_BOOT}
+		}, false),
+		Entry("connected route populated by the kernel", func() *netlink.Route {
+			return &netlink.Route{Dst: mustParseCIDR("10.6.0.0/24")}
+		}, true),
+	)
+
+	DescribeTable("compares the semantically relevant fields of the rule",
+		func(rule func() *netlink.Rule, expected bool) {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				exists, err := networking.RuleExists(rule())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(exists).To(Equal(expected))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		},
+		Entry("priority unset", func() *netlink.Rule {
+			rule := netlink.NewRule()
+			rule.Src = mustParseCIDR("10.6.0.2/32")
+			rule.Table = 100
+			return rule
+		}, true),
+		Entry("same priority", func() *netlink.Rule {
+			rule := netlink.NewRule()
+			rule.Src = mustParseCIDR("10.6.0.2/32")
+			rule.Table = 100
+			rule.Priority = 2000
+			return rule
+		}, true),
+		Entry("different priority", func() *netlink.Rule {
+			rule := netlink.NewRule()
+			rule.Src = mustParseCIDR("10.6.0.2/32")
+			rule.Table = 100
+			rule.Priority = 2001
+			return rule
+		}, false),
+		Entry("IPv4 source with 16-byte mask", func() *netlink.Rule {
+			rule := netlink.NewRule()
+			rule.Src = &net.IPNet{IP: net.ParseIP("10.6.0.2"), Mask: net.CIDRMask(128, 128)}
+			rule.Table = 100
+			return rule
+		}, true),
+		Entry("source 0.0.0.0/0 is all", func() *netlink.Rule {
+			rule := netlink.NewRule()
+			rule.Family = netlink.FAMILY_V4
+			rule.Src = mustParseCIDR("0.0.0.0/0")
+			rule.Table = unix.RT_TABLE_MAIN
+			return rule
+		}, true),
+		Entry("mark without mask matches all the bits", func() *netlink.Rule {
+			rule := netlink.NewRule()
+			rule.Family = netlink.FAMILY_V4
+			rule.Mark = 0x1
+			rule.Table = 500
+			return rule
+		}, true),
+		Entry("mark with the full mask", func() *netlink.Rule {
+			rule := netlink.NewRule()
+			rule.Family = netlink.FAMILY_V4
+			rule.Mark = 0x1
+			rule.Mask = 0xffffffff
+			rule.Table = 500
+			return rule
+		}, true),
+		Entry("mark with a partial mask", func() *netlink.Rule {
+			rule := netlink.NewRule()
+			rule.Family = netlink.FAMILY_V4
+			rule.Mark = 0x1
+			rule.Mask = 0xff
+			rule.Table = 500
+			return rule
+		}, false),
+		Entry("inverted rule", func() *netlink.Rule {
+			rule := netlink.NewRule()
+			rule.Dst = mustParseCIDR("fd00::/64")
+			rule.Table = 100
+			rule.Invert = true
+			return rule
+		}, true),
+		Entry("the invert flag is compared", func() *netlink.Rule {
+			rule := netlink.NewRule()
+			rule.Dst = mustParseCIDR("fd00::/64")
+			rule.Table = 100
+			return rule
+		}, false),
+		Entry("family is compared", func() *netlink.Rule {
+			rule := netlink.NewRule()
+			rule.Family = netlink.FAMILY_V6
+			rule.Mark = 0x1
+			rule.Table = 500
+			return rule
+		}, false),
+	)
+
+	It("adds the existing rule with EEXIST", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			err := networking.AddFromRuleTable(mustParseCIDR("10.6.0.2/32"), 100, networking.WithRulePriority(2000))
+			Expect(os.IsExist(err)).To(BeTrue())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
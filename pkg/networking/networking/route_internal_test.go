@@ -0,0 +1,244 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+var _ = Describe("Route-internal", Label("route_internal"), func() {
+	var testNetNS ns.NetNS
+	var logger *zap.Logger
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+		logger = zap.NewNop()
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+	})
+
+	Context("MoveRouteTable", func() {
+		It("skips the cloned routes", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addr, err := netlink.ParseAddr("10.6.0.2/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				_, dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				err = AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, net.ParseIP("10.6.0.1"), nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				// the kernel doesn't dump the cloned routes, inject one
+				_, cachedDst, err := net.ParseCIDR("172.17.0.1/32")
+				Expect(err).NotTo(HaveOccurred())
+				cloned := netlink.Route{
+					LinkIndex: link.Attrs().Index,
+					Dst:       cachedDst,
+					Gw:        net.ParseIP("10.6.0.1"),
+					Table:     unix.RT_TABLE_MAIN,
+					Flags:     unix.RTM_F_CLONED,
+				}
+				defer func(list func(int) ([]netlink.Route, error)) {
+					routeList = list
+				}(routeList)
+				routeList = func(family int) ([]netlink.Route, error) {
+					routes, err := netlink.RouteList(nil, family)
+					return append(routes, cloned), err
+				}
+
+				err = MoveRouteTable(logger, "net1", unix.RT_TABLE_MAIN, 100, netlink.FAMILY_V4, nil, false)
+				Expect(err).NotTo(HaveOccurred())
+
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				var dsts []string
+				for _, route := range routes {
+					dsts = append(dsts, route.Dst.String())
+				}
+				Expect(dsts).To(ContainElement("172.16.0.0/16"))
+				Expect(dsts).NotTo(ContainElement("172.17.0.1/32"))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("moves the routes out of a custom table", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.RouteAdd(&netlink.Route{LinkIndex: link.Attrs().Index, Dst: dst, Table: 100})).To(Succeed())
+
+				err = MoveRouteTables(logger, []RouteMove{{Iface: "net1", SrcRuleTable: 100, DstRuleTable: unix.RT_TABLE_MAIN}}, netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(BeEmpty())
+
+				routes, err = netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: unix.RT_TABLE_MAIN, Dst: dst}, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(HaveLen(1))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
+
+// BenchmarkMoveRouteTables moves the routes of the four secondary NICs out of
+// the main table holding 10k routes of another NIC, such as on the BGP-heavy
+// nodes, with a MoveRouteTable per NIC and with a single MoveRouteTables.
+func BenchmarkMoveRouteTables(b *testing.B) {
+	testNetNS, err := testutils.NewNS()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer func() {
+		_ = testNetNS.Close()
+		_ = testutils.UnmountNS(testNetNS)
+	}()
+
+	logger := zap.NewNop()
+	ifaces := []string{"net1", "net2", "net3", "net4"}
+	err = testNetNS.Do(func(_ ns.NetNS) error {
+		for i, name := range append([]string{"net0"}, ifaces...) {
+			peer := fmt.Sprintf("peer%d", i)
+			if err := netlink.LinkAdd(&netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: name}, PeerName: peer}); err != nil {
+				return err
+			}
+			for _, n := range []string{name, peer} {
+				link, err := netlink.LinkByName(n)
+				if err != nil {
+					return err
+				}
+				if err := netlink.LinkSetUp(link); err != nil {
+					return err
+				}
+			}
+			link, err := netlink.LinkByName(name)
+			if err != nil {
+				return err
+			}
+			addr, err := netlink.ParseAddr(fmt.Sprintf("10.%d.0.2/24", i+6))
+			if err != nil {
+				return err
+			}
+			if err := netlink.AddrAdd(link, addr); err != nil {
+				return err
+			}
+		}
+
+		// the routes learned by BGP
+		link, err := netlink.LinkByName("net0")
+		if err != nil {
+			return err
+		}
+		for i := 0; i < 10000; i++ {
+			if err := netlink.RouteAdd(&netlink.Route{
+				LinkIndex: link.Attrs().Index,
+				Dst:       &net.IPNet{IP: net.IPv4(172, 16+byte(i>>16), byte(i>>8), byte(i)), Mask: net.CIDRMask(32, 32)},
+				Gw:        net.ParseIP("10.6.0.1"),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	moves := make([]RouteMove, 0, len(ifaces))
+	restores := make([]RouteMove, 0, len(ifaces))
+	for i, name := range ifaces {
+		moves = append(moves, RouteMove{Iface: name, SrcRuleTable: unix.RT_TABLE_MAIN, DstRuleTable: 100 + i})
+		restores = append(restores, RouteMove{Iface: name, SrcRuleTable: 100 + i, DstRuleTable: unix.RT_TABLE_MAIN})
+	}
+
+	for _, batched := range []bool{false, true} {
+		name := "per-interface"
+		if batched {
+			name = "batched"
+		}
+		b.Run(name, func(b *testing.B) {
+			// the sub-benchmark runs in its own goroutine
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				for i := 0; i < b.N; i++ {
+					if batched {
+						if err := MoveRouteTables(logger, moves, netlink.FAMILY_V4); err != nil {
+							return err
+						}
+					} else {
+						for _, move := range moves {
+							if err := MoveRouteTable(logger, move.Iface, move.SrcRuleTable, move.DstRuleTable, netlink.FAMILY_V4, nil, false); err != nil {
+								return err
+							}
+						}
+					}
+
+					b.StopTimer()
+					if err := MoveRouteTables(logger, restores, netlink.FAMILY_V4); err != nil {
+						return err
+					}
+					// make sure that every iteration moves the routes again
+					left, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+					if err != nil {
+						return err
+					}
+					if len(left) != 0 {
+						return fmt.Errorf("failed to restore the routes of table 100: %v", left)
+					}
+					b.StartTimer()
+				}
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"net"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+)
+
+// NetnsRouter programs the routes and rules of a netns without the caller
+// entering it. Each method enters the netns on its own, unless it's called
+// within Do, which enters the netns once for all the calls in it.
+// A NetnsRouter must not be used by several goroutines at the same time.
+type NetnsRouter struct {
+	netns ns.NetNS
+	// entered is true while running in the netns within Do
+	entered bool
+}
+
+// InNetNS returns a NetnsRouter for the netns.
+func InNetNS(netns ns.NetNS) *NetnsRouter {
+	return &NetnsRouter{netns: netns}
+}
+
+// Do runs fn in the netns, the methods of the router called by fn run
+// directly without entering the netns again.
+func (r *NetnsRouter) Do(fn func() error) error {
+	if r.entered {
+		return fn()
+	}
+
+	return r.netns.Do(func(_ ns.NetNS) error {
+		r.entered = true
+		defer func() { r.entered = false }()
+		return fn()
+	})
+}
+
+// AddRoute is AddRoute in the netns.
+func (r *NetnsRouter) AddRoute(logger *zap.Logger, ruleTable, ipFamily int, scope netlink.Scope, iface string, dst *net.IPNet, v4Gw, v6Gw net.IP, opts ...RouteOption) error {
+	return r.Do(func() error {
+		return AddRoute(logger, ruleTable, ipFamily, scope, iface, dst, v4Gw, v6Gw, opts...)
+	})
+}
+
+// MoveRouteTable is MoveRouteTable in the netns.
+func (r *NetnsRouter) MoveRouteTable(logger *zap.Logger, iface string, srcRuleTable, dstRuleTable, ipfamily int, skip []*net.IPNet, keepBackup bool, opts ...RouteOption) error {
+	return r.Do(func() error {
+		return MoveRouteTable(logger, iface, srcRuleTable, dstRuleTable, ipfamily, skip, keepBackup, opts...)
+	})
+}
+
+// AddFromRuleTable is AddFromRuleTable in the netns.
+func (r *NetnsRouter) AddFromRuleTable(src *net.IPNet, ruleTable int, opts ...RuleOption) error {
+	return r.Do(func() error {
+		return AddFromRuleTable(src, ruleTable, opts...)
+	})
+}
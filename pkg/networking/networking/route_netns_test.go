@@ -0,0 +1,122 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"net"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+// countingNetNS counts the entries of Do
+type countingNetNS struct {
+	ns.NetNS
+	entries int
+}
+
+func (c *countingNetNS) Do(toRun func(ns.NetNS) error) error {
+	c.entries++
+	return c.NetNS.Do(toRun)
+}
+
+var _ = Describe("NetnsRouter", Label("route_netns"), func() {
+	var testNetNS ns.NetNS
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+
+		err = testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			Expect(netlink.LinkAdd(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+				PeerName:  "net1-peer",
+			})).To(Succeed())
+			for _, name := range []string{"net1", "net1-peer"} {
+				link, err := netlink.LinkByName(name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.LinkSetUp(link)).To(Succeed())
+			}
+			link, err := netlink.LinkByName("net1")
+			Expect(err).NotTo(HaveOccurred())
+			addr, err := netlink.ParseAddr("10.6.0.2/24")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("programs several routes with a single entry of the netns", func() {
+		logger := zap.NewNop()
+		counting := &countingNetNS{NetNS: testNetNS}
+		router := networking.InNetNS(counting)
+
+		err := router.Do(func() error {
+			for _, dst := range []string{"172.16.0.0/16", "172.17.0.0/16", "172.18.0.0/16"} {
+				if err := router.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1",
+					mustParseCIDR(dst), net.ParseIP("10.6.0.1"), nil); err != nil {
+					return err
+				}
+			}
+			if err := router.MoveRouteTable(logger, "net1", unix.RT_TABLE_MAIN, 100, netlink.FAMILY_V4, nil, false); err != nil {
+				return err
+			}
+			return router.AddFromRuleTable(mustParseCIDR("10.6.0.2/32"), 100)
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(counting.entries).To(Equal(1))
+
+		err = testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+			Expect(err).NotTo(HaveOccurred())
+			var dsts []string
+			for _, route := range routes {
+				if route.Dst != nil {
+					dsts = append(dsts, route.Dst.String())
+				}
+			}
+			Expect(dsts).To(ContainElements("172.16.0.0/16", "172.17.0.0/16", "172.18.0.0/16"))
+
+			rules, err := netlink.RuleListFiltered(netlink.FAMILY_V4, &netlink.Rule{Table: 100}, netlink.RT_FILTER_TABLE)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rules).To(HaveLen(1))
+			Expect(rules[0].Src.String()).To(Equal("10.6.0.2/32"))
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("enters the netns for each call outside Do", func() {
+		counting := &countingNetNS{NetNS: testNetNS}
+		router := networking.InNetNS(counting)
+
+		Expect(router.AddRoute(zap.NewNop(), unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1",
+			mustParseCIDR("172.16.0.0/16"), net.ParseIP("10.6.0.1"), nil)).To(Succeed())
+		Expect(router.AddFromRuleTable(mustParseCIDR("10.6.0.2/32"), 100)).To(Succeed())
+		Expect(counting.entries).To(Equal(2))
+
+		// the route of the host netns is untouched
+		routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Dst: mustParseCIDR("172.16.0.0/16")}, netlink.RT_FILTER_DST)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(routes).To(BeEmpty())
+	})
+})
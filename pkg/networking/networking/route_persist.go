@@ -0,0 +1,142 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// RouteSpec is the JSON friendly form of a route installed by the agent, so
+// that it can be persisted by PersistRoutes and re-installed after restart.
+type RouteSpec struct {
+	// Table is the route table, 0 is the main table
+	Table    int `json:"table"`
+	IPFamily int `json:"ipFamily"`
+	// Dst is a CIDR, or "default" for the default route
+	Dst   string `json:"dst"`
+	Gw    string `json:"gw,omitempty"`
+	Iface string `json:"iface"`
+	// Scope is the name of the scope, see ParseScope, it's global if empty
+	Scope string `json:"scope,omitempty"`
+	MTU   int    `json:"mtu,omitempty"`
+}
+
+// EnsureRoute installs the route of the spec if it's missing, the existing
+// route is left as is.
+func EnsureRoute(logger *zap.Logger, spec RouteSpec) error {
+	var dst *net.IPNet
+	if spec.Dst != "" && spec.Dst != "default" {
+		var err error
+		if _, dst, err = net.ParseCIDR(spec.Dst); err != nil {
+			return fmt.Errorf("invalid destination %q of route: %w", spec.Dst, err)
+		}
+	}
+
+	var gw net.IP
+	if spec.Gw != "" {
+		if gw = net.ParseIP(spec.Gw); gw == nil {
+			return fmt.Errorf("invalid gateway %q of route", spec.Gw)
+		}
+	}
+
+	scope := spec.Scope
+	if scope == "" {
+		scope = "global"
+	}
+
+	var opts []RouteOption
+	if spec.MTU > 0 {
+		opts = append(opts, WithMTU(spec.MTU))
+	}
+
+	return AddRouteWithScopeName(logger, spec.Table, spec.IPFamily, scope, spec.Iface, dst, gw, gw, opts...)
+}
+
+// PersistRoutes writes the specs to the file at path, which is replaced
+// atomically, so that LoadAndApplyRoutes never reads a partial file.
+func PersistRoutes(path string, specs []RouteSpec) error {
+	data, err := json.MarshalIndent(specs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create dir %s: %w", dir, err)
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create the routes file: %w", err)
+	}
+	tmpName := f.Name()
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write the routes file: %w", err)
+	}
+
+	if err = os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to commit the routes file: %w", err)
+	}
+	return nil
+}
+
+// LoadAndApplyRoutes re-installs the routes persisted by PersistRoutes with
+// EnsureRoute, it's a no-op if the file doesn't exist. It tries all the routes
+// and returns the aggregated errors.
+func LoadAndApplyRoutes(logger *zap.Logger, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			logger.Debug("No persisted routes", zap.String("path", path))
+			return nil
+		}
+		return fmt.Errorf("failed to read the routes file %s: %w", path, err)
+	}
+
+	var specs []RouteSpec
+	if err = json.Unmarshal(data, &specs); err != nil {
+		return fmt.Errorf("failed to parse the routes file %s: %w", path, err)
+	}
+
+	// the routes via the gateway require the route to the gateway, so the
+	// routes in the narrower scope, such as the connected ones, go first
+	sort.SliceStable(specs, func(i, j int) bool {
+		return specScope(specs[i]) > specScope(specs[j])
+	})
+
+	var errs []error
+	for _, spec := range specs {
+		if err := EnsureRoute(logger, spec); err != nil {
+			errs = append(errs, fmt.Errorf("failed to apply route %+v: %w", spec, err))
+		}
+	}
+	logger.Sugar().Infof("Applied %d of %d persisted routes from %s", len(specs)-len(errs), len(specs), path)
+	return utilerrors.NewAggregate(errs)
+}
+
+// specScope returns the scope of the spec, the unknown one is taken as global
+// and fails in EnsureRoute later.
+func specScope(spec RouteSpec) netlink.Scope {
+	scope, err := ParseScope(spec.Scope)
+	if err != nil {
+		return netlink.SCOPE_UNIVERSE
+	}
+	return scope
+}
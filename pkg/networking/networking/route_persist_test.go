@@ -0,0 +1,146 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+var _ = Describe("PersistRoutes", Label("route_persist"), func() {
+	var testNetNS ns.NetNS
+	var path string
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+
+		path = filepath.Join(GinkgoT().TempDir(), "routes", "routes.json")
+
+		err = testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			Expect(netlink.LinkAdd(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+				PeerName:  "peer12345",
+			})).To(Succeed())
+			link, err := netlink.LinkByName("net1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.LinkSetUp(link)).To(Succeed())
+			addr, err := netlink.ParseAddr("10.6.0.2/24")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("restores the persisted routes after the table is cleared", func() {
+		logger := zap.NewNop()
+		// the routes via the gateway are listed ahead of the connected route
+		// they depend on
+		specs := []networking.RouteSpec{
+			{Table: 100, IPFamily: netlink.FAMILY_V4, Dst: "default", Gw: "10.6.0.1", Iface: "net1"},
+			{Table: 100, IPFamily: netlink.FAMILY_V4, Dst: "172.16.0.0/16", Gw: "10.6.0.1", Iface: "net1", MTU: 1400},
+			{Table: 100, IPFamily: netlink.FAMILY_V4, Dst: "10.6.0.0/24", Iface: "net1", Scope: "link"},
+		}
+		Expect(networking.PersistRoutes(path, specs)).To(Succeed())
+
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			for _, spec := range specs {
+				Expect(networking.EnsureRoute(logger, spec)).To(Succeed())
+			}
+			routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes).To(HaveLen(3))
+
+			for i := range routes {
+				Expect(netlink.RouteDel(&routes[i])).To(Succeed())
+			}
+
+			Expect(networking.LoadAndApplyRoutes(logger, path)).To(Succeed())
+			routes, err = netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes).To(HaveLen(3))
+
+			restored := map[string]netlink.Route{}
+			for _, route := range routes {
+				dst := "default"
+				if route.Dst != nil && route.Dst.String() != "0.0.0.0/0" {
+					dst = route.Dst.String()
+				}
+				restored[dst] = route
+			}
+			Expect(restored).To(HaveKey("default"))
+			Expect(restored["default"].Gw.String()).To(Equal("10.6.0.1"))
+			Expect(restored).To(HaveKey("172.16.0.0/16"))
+			Expect(restored["172.16.0.0/16"].Gw.String()).To(Equal("10.6.0.1"))
+			Expect(restored["172.16.0.0/16"].MTU).To(Equal(1400))
+			Expect(restored).To(HaveKey("10.6.0.0/24"))
+			Expect(restored["10.6.0.0/24"].Scope).To(Equal(netlink.SCOPE_LINK))
+
+			// the routes present are kept
+			Expect(networking.LoadAndApplyRoutes(logger, path)).To(Succeed())
+			routes, err = netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes).To(HaveLen(3))
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("does nothing without the persisted routes", func() {
+		Expect(networking.LoadAndApplyRoutes(zap.NewNop(), path)).To(Succeed())
+	})
+
+	It("applies the valid routes and reports the invalid ones", func() {
+		specs := []networking.RouteSpec{
+			{Table: unix.RT_TABLE_MAIN, IPFamily: netlink.FAMILY_V4, Dst: "172.16.0.0/16", Gw: "10.6.0.1", Iface: "net1"},
+			{Table: unix.RT_TABLE_MAIN, IPFamily: netlink.FAMILY_V4, Dst: "172.17.0.0/16", Iface: "net1", Scope: "unknown"},
+		}
+		Expect(networking.PersistRoutes(path, specs)).To(Succeed())
+
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			Expect(networking.LoadAndApplyRoutes(zap.NewNop(), path)).To(MatchError(ContainSubstring("unknown route scope")))
+			routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: unix.RT_TABLE_MAIN}, netlink.RT_FILTER_TABLE)
+			Expect(err).NotTo(HaveOccurred())
+			var dsts []string
+			for _, route := range routes {
+				if route.Dst != nil {
+					dsts = append(dsts, route.Dst.String())
+				}
+			}
+			Expect(dsts).To(ContainElement("172.16.0.0/16"))
+			Expect(dsts).NotTo(ContainElement("172.17.0.0/16"))
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("refuses the malformed file", func() {
+		Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte("{"), 0o600)).To(Succeed())
+		Expect(networking.LoadAndApplyRoutes(zap.NewNop(), path)).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,74 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const settableNexthopFlags = unix.RTNH_F_ONLINK | unix.RTNH_F_PERVASIVE
+
+// RouteSnapshot is the routes of a table captured by SnapshotRouteTable
+type RouteSnapshot struct {
+	Table    int
+	IPFamily int
+	Routes   []netlink.Route
+}
+
+// SnapshotRouteTable captures the routes of the table with all their attributes,
+// such as before MoveRouteTable, so that they can be restored by
+// RestoreRouteSnapshot if the migration goes wrong.
+// Equivalent: `ip route save table <table>`
+func SnapshotRouteTable(table, ipFamily int) (*RouteSnapshot, error) {
+	routes, err := netlink.RouteListFiltered(ipFamily, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routes of table %d: %w", table, err)
+	}
+	return &RouteSnapshot{Table: table, IPFamily: ipFamily, Routes: routes}, nil
+}
+
+// RestoreRouteSnapshot re-installs the routes of the snapshot, the routes which
+// are still present are kept as is, and the routes added after the snapshot are
+// not removed. It tries all the routes and returns the aggregated errors.
+// Equivalent: `ip route restore`
+func RestoreRouteSnapshot(snap *RouteSnapshot) error {
+	if snap == nil {
+		return fmt.Errorf("route snapshot must be specified")
+	}
+
+	// the routes via the gateway require the route to the gateway, so the
+	// routes in the narrower scope, such as the connected ones, go first
+	routes := make([]netlink.Route, len(snap.Routes))
+	copy(routes, snap.Routes)
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Scope > routes[j].Scope
+	})
+
+	var errs []error
+	for i := range routes {
+		route := routes[i]
+		// the flags reporting the state of the nexthop, such as linkdown, are
+		// set by the kernel, and can't be added
+		route.Flags &= settableNexthopFlags
+		if len(route.MultiPath) > 0 {
+			hops := make([]*netlink.NexthopInfo, 0, len(route.MultiPath))
+			for _, hop := range route.MultiPath {
+				h := *hop
+				h.Flags &= settableNexthopFlags
+				hops = append(hops, &h)
+			}
+			route.MultiPath = hops
+		}
+		if err := netlink.RouteAdd(&route); err != nil && !os.IsExist(err) {
+			errs = append(errs, fmt.Errorf("failed to restore route %s: %w", route.String(), err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"net"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+var _ = Describe("RouteSnapshot", Label("route_snapshot"), func() {
+	var testNetNS ns.NetNS
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+	})
+
+	It("restores the routes deleted from the table", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			logger := zap.NewNop()
+			Expect(netlink.LinkAdd(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+				PeerName:  "peer12345",
+			})).To(Succeed())
+			link, err := netlink.LinkByName("net1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.LinkSetUp(link)).To(Succeed())
+			addr, err := netlink.ParseAddr("10.6.0.2/24")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+			gw := net.ParseIP("10.6.0.1")
+			_, dst, _ := net.ParseCIDR("172.16.0.0/16")
+			Expect(networking.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, gw, nil,
+				networking.WithRealm(10), networking.WithMTU(1400))).To(Succeed())
+			Expect(networking.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", nil, gw, nil)).To(Succeed())
+
+			listMain := func() []netlink.Route {
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: unix.RT_TABLE_MAIN}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				return routes
+			}
+			origin := listMain()
+			Expect(origin).To(HaveLen(3))
+
+			snap, err := networking.SnapshotRouteTable(unix.RT_TABLE_MAIN, netlink.FAMILY_V4)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snap.Routes).To(Equal(origin))
+
+			// the connected route is deleted at first
+			for i := len(origin) - 1; i >= 0; i-- {
+				Expect(netlink.RouteDel(&origin[i])).To(Succeed())
+			}
+			Expect(listMain()).To(BeEmpty())
+
+			Expect(networking.RestoreRouteSnapshot(snap)).To(Succeed())
+			Expect(listMain()).To(Equal(origin))
+
+			// the present routes are tolerated
+			Expect(networking.RestoreRouteSnapshot(snap)).To(Succeed())
+			Expect(listMain()).To(Equal(origin))
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("fails with the nil snapshot", func() {
+		Expect(networking.RestoreRouteSnapshot(nil)).NotTo(Succeed())
+	})
+})
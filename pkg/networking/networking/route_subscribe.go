@@ -0,0 +1,78 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+)
+
+// RouteUpdateFilter selects the route updates delivered by SubscribeRouteUpdates,
+// the zero value delivers all the updates
+type RouteUpdateFilter struct {
+	// Protocol only delivers the updates of the routes with the protocol, it
+	// is ignored if zero (RTPROT_UNSPEC)
+	Protocol netlink.RouteProtocol
+	// ExcludeOwned drops the updates of the routes added by spiderpool, so that
+	// the reconcilers are not triggered by their own changes
+	ExcludeOwned bool
+}
+
+func (f RouteUpdateFilter) match(route *netlink.Route) bool {
+	if f.Protocol != 0 && route.Protocol != f.Protocol {
+		return false
+	}
+	if f.ExcludeOwned && route.Protocol == RouteProtocolSpiderpool {
+		return false
+	}
+	return true
+}
+
+// SubscribeRouteUpdates subscribes the route updates of the current netns, and
+// delivers the ones matching the filter. The subscription is stopped when the
+// ctx is done, and the returned channel is closed after that or when the
+// subscription is broken.
+func SubscribeRouteUpdates(ctx context.Context, filter RouteUpdateFilter, bufferSize int) (<-chan netlink.RouteUpdate, error) {
+	raw := make(chan netlink.RouteUpdate, bufferSize)
+	done := make(chan struct{})
+	if err := netlink.RouteSubscribe(raw, done); err != nil {
+		close(done)
+		return nil, fmt.Errorf("failed to subscribe route updates: %w", err)
+	}
+
+	updates := make(chan netlink.RouteUpdate, bufferSize)
+	go func() {
+		defer close(updates)
+		defer func() {
+			close(done)
+			// the netlink receiver may be blocked on sending to raw
+			go func() {
+				for range raw {
+				}
+			}()
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case update, ok := <-raw:
+				if !ok {
+					return
+				}
+				if !filter.match(&update.Route) {
+					continue
+				}
+				select {
+				case updates <- update:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return updates, nil
+}
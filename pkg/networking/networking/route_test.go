@@ -0,0 +1,2341 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+	"github.com/spidernet-io/spiderpool/pkg/networking/sysctl"
+)
+
+var _ = Describe("Route", Label("route"), func() {
+	var testNetNS ns.NetNS
+	var logger *zap.Logger
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+		logger = zap.NewNop()
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+	})
+
+	Context("ResolveLinkStable", func() {
+		It("route operations target the renamed link", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				err := netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "tmp12345"},
+					PeerName:  "peer12345",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				peer, err := netlink.LinkByName("peer12345")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.LinkSetUp(peer)).To(Succeed())
+
+				index, name, err := networking.ResolveLinkStable("tmp12345")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(name).To(Equal("tmp12345"))
+
+				link, err := netlink.LinkByIndex(index)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.LinkSetName(link, "net1")).To(Succeed())
+				Expect(netlink.LinkSetUp(link)).To(Succeed())
+
+				_, _, err = networking.ResolveLinkStable("tmp12345")
+				Expect(err).To(HaveOccurred())
+
+				renamedIndex, renamedName, err := networking.ResolveLinkStable("net1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(renamedIndex).To(Equal(index))
+				Expect(renamedName).To(Equal("net1"))
+
+				_, dst, err := net.ParseCIDR("10.6.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				err = networking.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_LINK, "net1", dst, nil, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = networking.MoveRouteTable(logger, "net1", unix.RT_TABLE_MAIN, 100, netlink.FAMILY_V4, nil, false)
+				Expect(err).NotTo(HaveOccurred())
+
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(HaveLen(1))
+				Expect(routes[0].LinkIndex).To(Equal(index))
+				Expect(routes[0].Dst.String()).To(Equal(dst.String()))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("AddRuleForInterface", func() {
+		It("rules survive the link being recreated", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				err := networking.AddRuleForInterface("net1", networking.Iif, 101, netlink.FAMILY_V4, 2000)
+				Expect(err).To(HaveOccurred())
+
+				veth := &netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				}
+				Expect(netlink.LinkAdd(veth)).To(Succeed())
+
+				err = networking.AddRuleForInterface("net1", "unknown", 101, netlink.FAMILY_V4, 2000)
+				Expect(err).To(HaveOccurred())
+
+				Expect(networking.AddRuleForInterface("net1", networking.Iif, 101, netlink.FAMILY_V4, 2000)).To(Succeed())
+				Expect(networking.AddRuleForInterface("net1", networking.Oif, 101, netlink.FAMILY_V4, 2001)).To(Succeed())
+
+				Expect(netlink.LinkDel(veth)).To(Succeed())
+
+				rules, err := netlink.RuleListFiltered(netlink.FAMILY_V4, &netlink.Rule{Table: 101}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rules).To(HaveLen(2))
+				for _, rule := range rules {
+					switch rule.Priority {
+					case 2000:
+						Expect(rule.IifName).To(Equal("net1"))
+					case 2001:
+						Expect(rule.OifName).To(Equal("net1"))
+					default:
+						Fail("unexpected rule " + rule.String())
+					}
+				}
+
+				Expect(networking.DelRuleForInterface("net1", networking.Iif, 101, netlink.FAMILY_V4, 2000)).To(Succeed())
+				Expect(networking.DelRuleForInterface("net1", networking.Oif, 101, netlink.FAMILY_V4, 2001)).To(Succeed())
+
+				rules, err = netlink.RuleListFiltered(netlink.FAMILY_V4, &netlink.Rule{Table: 101}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rules).To(BeEmpty())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("SwapDefaultGateway", func() {
+		It("replaces the gateway without removing the default route", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addr, err := netlink.ParseAddr("10.6.0.2/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				Expect(netlink.RouteAdd(&netlink.Route{
+					LinkIndex: link.Attrs().Index,
+					Gw:        net.ParseIP("10.6.0.1"),
+					Priority:  100,
+					MTU:       1400,
+				})).To(Succeed())
+
+				updates := make(chan netlink.RouteUpdate, 16)
+				done := make(chan struct{})
+				defer close(done)
+				Expect(netlink.RouteSubscribe(updates, done)).To(Succeed())
+
+				err = networking.SwapDefaultGateway(logger, "net1", netlink.FAMILY_V4, net.ParseIP("10.6.0.254"))
+				Expect(err).NotTo(HaveOccurred())
+
+				// ignore the IPv6 link-local routes that show up asynchronously after the link is up
+				var defaultRouteUpdates []netlink.RouteUpdate
+				timeout := time.After(200 * time.Millisecond)
+			collect:
+				for {
+					select {
+					case u := <-updates:
+						if u.Route.Gw.To4() != nil && (u.Route.Dst == nil || u.Route.Dst.String() == "0.0.0.0/0") {
+							defaultRouteUpdates = append(defaultRouteUpdates, u)
+						}
+					case <-timeout:
+						break collect
+					}
+				}
+				Expect(defaultRouteUpdates).To(HaveLen(1))
+				Expect(defaultRouteUpdates[0].Type).To(Equal(uint16(unix.RTM_NEWROUTE)))
+
+				routes, err := netlink.RouteList(link, netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+				var defaultRoutes []netlink.Route
+				for _, route := range routes {
+					if route.Dst == nil || route.Dst.String() == "0.0.0.0/0" {
+						defaultRoutes = append(defaultRoutes, route)
+					}
+				}
+				Expect(defaultRoutes).To(HaveLen(1))
+				Expect(defaultRoutes[0].Gw.String()).To(Equal("10.6.0.254"))
+				Expect(defaultRoutes[0].Priority).To(Equal(100))
+				Expect(defaultRoutes[0].MTU).To(Equal(1400))
+
+				err = networking.SwapDefaultGateway(logger, "net1", netlink.FAMILY_V4, net.ParseIP("fd00::1"))
+				Expect(err).To(HaveOccurred())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("GetDefaultGatewayByName", func() {
+		It("finds the gateway of the default route in the specified table", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addr, err := netlink.ParseAddr("10.6.0.2/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				Expect(netlink.RouteAdd(&netlink.Route{
+					LinkIndex: link.Attrs().Index,
+					Gw:        net.ParseIP("10.6.0.1"),
+					Table:     100,
+				})).To(Succeed())
+
+				gws, err := networking.GetDefaultGatewayByName("net1", netlink.FAMILY_V4, unix.RT_TABLE_MAIN)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(gws).To(BeEmpty())
+
+				gws, err = networking.GetDefaultGatewayByName("net1", netlink.FAMILY_V4, 100)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(gws).To(Equal([]string{"10.6.0.1"}))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("AddRuleTableWithMark", func() {
+		It("adds the mark rules at different priorities concurrently", func() {
+			priorities := []int{1000, 1001, 1002, 1003, 1004, 1005, 1006, 1007}
+
+			var wg sync.WaitGroup
+			errs := make([]error, len(priorities))
+			for i, priority := range priorities {
+				wg.Add(1)
+				go func(i, priority int) {
+					defer wg.Done()
+					errs[i] = testNetNS.Do(func(_ ns.NetNS) error {
+						return networking.AddRuleTableWithMark(0x100+i, 100+i, netlink.FAMILY_V4, priority)
+					})
+				}(i, priority)
+			}
+			wg.Wait()
+			for _, err := range errs {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				rules, err := netlink.RuleList(netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+				markPriorities := make(map[int]int)
+				for _, rule := range rules {
+					if rule.Mark > 0 {
+						markPriorities[rule.Mark] = rule.Priority
+					}
+				}
+				Expect(markPriorities).To(HaveLen(len(priorities)))
+				for i, priority := range priorities {
+					Expect(markPriorities).To(HaveKeyWithValue(0x100+i, priority))
+				}
+
+				for i, priority := range priorities {
+					Expect(networking.DelRuleTableWithMark(0x100+i, 100+i, netlink.FAMILY_V4, priority)).To(Succeed())
+				}
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("DelFromRuleTable", func() {
+		It("deletes the rule added with the same options", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				_, v6Src, err := net.ParseCIDR("fd00:10:6::2/128")
+				Expect(err).NotTo(HaveOccurred())
+				_, v6Dst, err := net.ParseCIDR("fd00:10:7::/64")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(networking.AddFromRuleTable(v6Src, 101, networking.WithRulePriority(2000))).To(Succeed())
+				Expect(networking.AddToRuleTable(v6Dst, 101, networking.WithRulePriority(2001))).To(Succeed())
+				// the same rule with another priority
+				Expect(networking.AddFromRuleTable(v6Src, 101, networking.WithRulePriority(2002))).To(Succeed())
+
+				priorities := func() []int {
+					rules, err := netlink.RuleListFiltered(netlink.FAMILY_V6, &netlink.Rule{Table: 101}, netlink.RT_FILTER_TABLE)
+					Expect(err).NotTo(HaveOccurred())
+					var result []int
+					for _, rule := range rules {
+						result = append(result, rule.Priority)
+					}
+					return result
+				}
+				Expect(priorities()).To(ConsistOf(2000, 2001, 2002))
+
+				Expect(networking.DelFromRuleTable(v6Src, 101, networking.WithRulePriority(2002))).To(Succeed())
+				Expect(priorities()).To(ConsistOf(2000, 2001))
+				Expect(networking.DelToRuleTable(v6Dst, 101, networking.WithRulePriority(2001))).To(Succeed())
+				Expect(priorities()).To(ConsistOf(2000))
+				Expect(networking.DelToRuleTable(v6Dst, 101, networking.WithRulePriority(2001))).NotTo(Succeed())
+				Expect(networking.DelFromRuleTable(v6Src, 101, networking.WithRulePriority(2000))).To(Succeed())
+				Expect(priorities()).To(BeEmpty())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("SetupSourceRouting", func() {
+		It("adds the rule and the route together, and rolls back the rule on failure", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addr, err := netlink.ParseAddr("10.6.0.2/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				_, src, err := net.ParseCIDR("10.233.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+
+				countRules := func(table int) int {
+					rules, err := netlink.RuleList(netlink.FAMILY_V4)
+					Expect(err).NotTo(HaveOccurred())
+					count := 0
+					for _, rule := range rules {
+						if rule.Table == table && rule.Src != nil && rule.Src.String() == src.String() {
+							count++
+						}
+					}
+					return count
+				}
+				listRoutes := func(table int) []netlink.Route {
+					routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+					Expect(err).NotTo(HaveOccurred())
+					return routes
+				}
+
+				// the gateway is unreachable, so the route fails to be added
+				err = networking.SetupSourceRouting(logger, src, 100, netlink.FAMILY_V4, "net1", net.ParseIP("10.7.0.1"))
+				Expect(err).To(HaveOccurred())
+				Expect(countRules(100)).To(BeZero())
+				Expect(listRoutes(100)).To(BeEmpty())
+
+				err = networking.SetupSourceRouting(logger, src, 101, netlink.FAMILY_V4, "net1", net.ParseIP("10.6.0.1"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(countRules(101)).To(Equal(1))
+				routes := listRoutes(101)
+				Expect(routes).To(HaveLen(1))
+				Expect(routes[0].Gw.String()).To(Equal("10.6.0.1"))
+				Expect(routes[0].LinkIndex).To(Equal(link.Attrs().Index))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("AddFromRuleTableOrdered", func() {
+		It("evaluates the more specific source first regardless of the adding order", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				_, subnet, err := net.ParseCIDR("10.6.0.0/24")
+				Expect(err).NotTo(HaveOccurred())
+				_, host, err := net.ParseCIDR("10.6.0.2/32")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(networking.AddFromRuleTableOrdered(subnet, 100, 2000)).To(Succeed())
+				Expect(networking.AddFromRuleTableOrdered(host, 101, 2000)).To(Succeed())
+				Expect(networking.AddFromRuleTableOrdered(nil, 101, 2000)).NotTo(Succeed())
+
+				// the rules are listed in the order they are evaluated
+				rules, err := netlink.RuleList(netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+				var fromRules []netlink.Rule
+				for _, rule := range rules {
+					if rule.Src != nil {
+						fromRules = append(fromRules, rule)
+					}
+				}
+				Expect(fromRules).To(HaveLen(2))
+				Expect(fromRules[0].Src.String()).To(Equal("10.6.0.2/32"))
+				Expect(fromRules[0].Priority).To(Equal(2000))
+				Expect(fromRules[1].Src.String()).To(Equal("10.6.0.0/24"))
+				Expect(fromRules[1].Priority).To(Equal(2008))
+
+				Expect(networking.DelFromRuleTableOrdered(subnet, 100, 2000)).To(Succeed())
+				Expect(networking.DelFromRuleTableOrdered(host, 101, 2000)).To(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("VerifyRouteResolution", func() {
+		It("resolves the route in the custom table for the source matching the from-rule", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				for _, cidr := range []string{"10.6.0.2/16", "10.6.0.3/16"} {
+					addr, err := netlink.ParseAddr(cidr)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+				}
+
+				_, src, err := net.ParseCIDR("10.6.0.2/32")
+				Expect(err).NotTo(HaveOccurred())
+				err = networking.SetupSourceRouting(logger, src, 100, netlink.FAMILY_V4, "net1", net.ParseIP("10.6.0.1"))
+				Expect(err).NotTo(HaveOccurred())
+				err = networking.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", nil, net.ParseIP("10.6.0.1"), nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				dst := net.ParseIP("8.8.8.8")
+				Expect(networking.VerifyRouteResolution(net.ParseIP("10.6.0.2"), dst, 100)).To(Succeed())
+				Expect(networking.VerifyRouteResolution(net.ParseIP("10.6.0.2"), dst, unix.RT_TABLE_MAIN)).NotTo(Succeed())
+
+				// the other source doesn't match the rule, so it is resolved in main
+				Expect(networking.VerifyRouteResolution(net.ParseIP("10.6.0.3"), dst, unix.RT_TABLE_MAIN)).To(Succeed())
+				Expect(networking.VerifyRouteResolution(net.ParseIP("10.6.0.3"), dst, 100)).NotTo(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("DiffRouteTables", func() {
+		It("returns the routes only in either table", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addr, err := netlink.ParseAddr("10.6.0.2/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				addRoute := func(table int, dst string) {
+					var ipNet *net.IPNet
+					if dst != "" {
+						_, ipNet, err = net.ParseCIDR(dst)
+						Expect(err).NotTo(HaveOccurred())
+					}
+					err = networking.AddRoute(logger, table, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", ipNet, net.ParseIP("10.6.0.1"), nil)
+					Expect(err).NotTo(HaveOccurred())
+				}
+				// both
+				addRoute(100, "")
+				addRoute(101, "")
+				addRoute(100, "172.16.0.0/16")
+				addRoute(101, "172.16.0.0/16")
+				// only in 100
+				addRoute(100, "172.17.0.0/16")
+				// only in 101
+				addRoute(101, "172.18.0.0/16")
+				addRoute(101, "172.19.0.0/16")
+
+				onlyA, onlyB, err := networking.DiffRouteTables(100, 101, netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(onlyA).To(HaveLen(1))
+				Expect(onlyA[0].Dst.String()).To(Equal("172.17.0.0/16"))
+				Expect(onlyB).To(HaveLen(2))
+				Expect([]string{onlyB[0].Dst.String(), onlyB[1].Dst.String()}).To(ConsistOf("172.18.0.0/16", "172.19.0.0/16"))
+
+				onlyA, onlyB, err = networking.DiffRouteTables(100, 100, netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(onlyA).To(BeEmpty())
+				Expect(onlyB).To(BeEmpty())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("MoveRouteTable", func() {
+		It("leaves the routes matching the skip CIDRs in place", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addr, err := netlink.ParseAddr("10.6.0.2/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				for _, dst := range []string{"169.254.169.254/32", "172.16.0.0/16"} {
+					_, ipNet, err := net.ParseCIDR(dst)
+					Expect(err).NotTo(HaveOccurred())
+					err = networking.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", ipNet, net.ParseIP("10.6.0.1"), nil)
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				_, skip, err := net.ParseCIDR("169.254.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				err = networking.MoveRouteTable(logger, "net1", unix.RT_TABLE_MAIN, 100, netlink.FAMILY_V4, []*net.IPNet{skip}, false)
+				Expect(err).NotTo(HaveOccurred())
+
+				tableDsts := func(table int) []string {
+					routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: table, LinkIndex: link.Attrs().Index},
+						netlink.RT_FILTER_TABLE|netlink.RT_FILTER_OIF)
+					Expect(err).NotTo(HaveOccurred())
+					var dsts []string
+					for _, route := range routes {
+						dsts = append(dsts, route.Dst.String())
+					}
+					return dsts
+				}
+				Expect(tableDsts(unix.RT_TABLE_MAIN)).To(ConsistOf("169.254.169.254/32"))
+				Expect(tableDsts(100)).To(ConsistOf("10.6.0.0/16", "172.16.0.0/16"))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("keeps the source routes as the backup with a higher metric", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addr, err := netlink.ParseAddr("10.6.0.2/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				_, dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.RouteAdd(&netlink.Route{
+					LinkIndex: link.Attrs().Index,
+					Dst:       dst,
+					Gw:        net.ParseIP("10.6.0.1"),
+					Priority:  10,
+				})).To(Succeed())
+
+				err = networking.MoveRouteTable(logger, "net1", unix.RT_TABLE_MAIN, 100, netlink.FAMILY_V4, nil, true)
+				Expect(err).NotTo(HaveOccurred())
+
+				tableRoutes := func(table int) []netlink.Route {
+					routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: table, Dst: dst},
+						netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST)
+					Expect(err).NotTo(HaveOccurred())
+					return routes
+				}
+				Expect(tableRoutes(100)).To(HaveLen(1))
+				Expect(tableRoutes(100)[0].Priority).To(Equal(10))
+				Expect(tableRoutes(unix.RT_TABLE_MAIN)).To(HaveLen(1))
+				Expect(tableRoutes(unix.RT_TABLE_MAIN)[0].Priority).To(Equal(110))
+				Expect(tableRoutes(unix.RT_TABLE_MAIN)[0].Gw.String()).To(Equal("10.6.0.1"))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("MoveRouteTables", func() {
+		It("moves the routes of every interface to its own table", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				linkIndexes := map[string]int{}
+				for i, name := range []string{"net1", "net2", "net3"} {
+					Expect(netlink.LinkAdd(&netlink.Veth{
+						LinkAttrs: netlink.LinkAttrs{Name: name},
+						PeerName:  fmt.Sprintf("peer%d", i),
+					})).To(Succeed())
+					for _, n := range []string{name, fmt.Sprintf("peer%d", i)} {
+						link, err := netlink.LinkByName(n)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(netlink.LinkSetUp(link)).To(Succeed())
+					}
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					linkIndexes[name] = link.Attrs().Index
+					addr, err := netlink.ParseAddr(fmt.Sprintf("10.%d.0.2/24", i+6))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+					_, dst, err := net.ParseCIDR(fmt.Sprintf("172.%d.0.0/16", i+16))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(networking.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, name, dst,
+						net.ParseIP(fmt.Sprintf("10.%d.0.1", i+6)), nil)).To(Succeed())
+				}
+
+				_, skip, err := net.ParseCIDR("172.17.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networking.MoveRouteTables(logger, []networking.RouteMove{
+					{Iface: "net1", SrcRuleTable: unix.RT_TABLE_MAIN, DstRuleTable: 100},
+					{Iface: "net2", SrcRuleTable: unix.RT_TABLE_MAIN, DstRuleTable: 101, Skip: []*net.IPNet{skip}},
+				}, netlink.FAMILY_V4)).To(Succeed())
+
+				tableDsts := func(table int, iface string) []string {
+					routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: table, LinkIndex: linkIndexes[iface]},
+						netlink.RT_FILTER_TABLE|netlink.RT_FILTER_OIF)
+					Expect(err).NotTo(HaveOccurred())
+					var dsts []string
+					for _, route := range routes {
+						dsts = append(dsts, route.Dst.String())
+					}
+					return dsts
+				}
+				Expect(tableDsts(100, "net1")).To(ConsistOf("10.6.0.0/24", "172.16.0.0/16"))
+				Expect(tableDsts(unix.RT_TABLE_MAIN, "net1")).To(BeEmpty())
+				Expect(tableDsts(101, "net2")).To(ConsistOf("10.7.0.0/24"))
+				Expect(tableDsts(unix.RT_TABLE_MAIN, "net2")).To(ConsistOf("172.17.0.0/16"))
+				// the interface not moved
+				Expect(tableDsts(unix.RT_TABLE_MAIN, "net3")).To(ConsistOf("10.8.0.0/24", "172.18.0.0/16"))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("RestoreRouteTable", func() {
+		It("moves the routes back to main and deletes the rules of the custom table", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addr, err := netlink.ParseAddr("10.6.0.2/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				_, dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				err = networking.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, net.ParseIP("10.6.0.1"), nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				// the state left by attaching the NIC to table 100
+				err = networking.MoveRouteTable(logger, "net1", unix.RT_TABLE_MAIN, 100, netlink.FAMILY_V4, nil, true)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networking.AddFromRuleTable(addr.IPNet, 100)).To(Succeed())
+				Expect(networking.AddRuleTableWithMark(0x200, 100, netlink.FAMILY_V4, networking.DefaultMarkRulePriority)).To(Succeed())
+				// the rule of another table is left untouched
+				Expect(networking.AddFromRuleTable(addr.IPNet, 101)).To(Succeed())
+
+				err = networking.RestoreRouteTable(logger, "net1", 100, netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+
+				tableRoutes := func(table int) []netlink.Route {
+					routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: table, LinkIndex: link.Attrs().Index},
+						netlink.RT_FILTER_TABLE|netlink.RT_FILTER_OIF)
+					Expect(err).NotTo(HaveOccurred())
+					return routes
+				}
+				Expect(tableRoutes(100)).To(BeEmpty())
+				var dsts []string
+				for _, route := range tableRoutes(unix.RT_TABLE_MAIN) {
+					dsts = append(dsts, route.Dst.String())
+					// the backup is replaced by the restored route
+					Expect(route.Priority).To(BeNumerically("<", 100))
+				}
+				Expect(dsts).To(ConsistOf("10.6.0.0/16", "172.16.0.0/16"))
+
+				rules, err := netlink.RuleList(netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+				var tables []int
+				for _, rule := range rules {
+					if rule.Table == 100 || rule.Table == 101 {
+						tables = append(tables, rule.Table)
+					}
+				}
+				Expect(tables).To(ConsistOf(101))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("deletes the mark rules of both families for FAMILY_ALL", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+
+				for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+					Expect(networking.AddRuleTableWithMark(0x200, 100, family, networking.DefaultMarkRulePriority)).To(Succeed())
+				}
+
+				err := networking.RestoreRouteTable(logger, "net1", 100, netlink.FAMILY_ALL)
+				Expect(err).NotTo(HaveOccurred())
+
+				for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+					rules, err := netlink.RuleListFiltered(family, &netlink.Rule{Table: 100}, netlink.RT_FILTER_TABLE)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(rules).To(BeEmpty())
+				}
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("SetMaxRoutesPerTable", func() {
+		It("refuses adding routes once the table is full", func() {
+			networking.SetMaxRoutesPerTable(3)
+			DeferCleanup(networking.SetMaxRoutesPerTable, 0)
+
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+
+				for _, dst := range []string{"172.16.0.0/16", "172.17.0.0/16", "172.18.0.0/16"} {
+					_, ipNet, err := net.ParseCIDR(dst)
+					Expect(err).NotTo(HaveOccurred())
+					err = networking.AddRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_LINK, "net1", ipNet, nil, nil)
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				_, ipNet, err := net.ParseCIDR("172.19.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				err = networking.AddRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_LINK, "net1", ipNet, nil, nil)
+				Expect(errors.Is(err, networking.ErrTableFull)).To(BeTrue())
+
+				// the other tables are not affected
+				err = networking.AddRoute(logger, 101, netlink.FAMILY_V4, netlink.SCOPE_LINK, "net1", ipNet, nil, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(HaveLen(3))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("AddRoute", func() {
+		It("refuses the gateway mismatching the ipFamily", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+
+				_, v4Dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				err = networking.AddRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", v4Dst, net.ParseIP("fd00::1"), nil)
+				Expect(err).To(MatchError(ContainSubstring("doesn't match the ipFamily")))
+
+				_, v6Dst, err := net.ParseCIDR("fd01::/64")
+				Expect(err).NotTo(HaveOccurred())
+				err = networking.AddRoute(logger, 100, netlink.FAMILY_V6, netlink.SCOPE_UNIVERSE, "net1", v6Dst, nil, net.ParseIP("10.6.0.1"))
+				Expect(err).To(MatchError(ContainSubstring("doesn't match the ipFamily")))
+
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_ALL, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(BeEmpty())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		DescribeTable("adds the default route by the family of the gateway for FAMILY_ALL",
+			func(v4Gw, v6Gw net.IP, expectedGw net.IP) {
+				err := testNetNS.Do(func(_ ns.NetNS) error {
+					defer GinkgoRecover()
+
+					Expect(netlink.LinkAdd(&netlink.Veth{
+						LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+						PeerName:  "peer12345",
+					})).To(Succeed())
+					for _, name := range []string{"net1", "peer12345"} {
+						link, err := netlink.LinkByName(name)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(netlink.LinkSetUp(link)).To(Succeed())
+					}
+					link, err := netlink.LinkByName("net1")
+					Expect(err).NotTo(HaveOccurred())
+					addr, err := netlink.ParseAddr("fd00::2/64")
+					Expect(err).NotTo(HaveOccurred())
+					addr.Flags = unix.IFA_F_NODAD
+					Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+					err = networking.AddRoute(logger, 100, netlink.FAMILY_ALL, netlink.SCOPE_UNIVERSE, "net1", nil, v4Gw, v6Gw)
+					Expect(err).NotTo(HaveOccurred())
+
+					routes, err := netlink.RouteListFiltered(netlink.FAMILY_V6, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(routes).To(HaveLen(1))
+					Expect(routes[0].Dst.String()).To(Equal("::/0"))
+					Expect(routes[0].Gw.Equal(expectedGw)).To(BeTrue(), "gateway %v", routes[0].Gw)
+					Expect(routes[0].LinkIndex).To(Equal(link.Attrs().Index))
+					return nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+			},
+			Entry("link-local gateway", nil, net.ParseIP("fe80::1"), net.ParseIP("fe80::1")),
+			Entry("global gateway", nil, net.ParseIP("fd00::1"), net.ParseIP("fd00::1")),
+			Entry("the same gateway passed as both", net.ParseIP("fe80::1"), net.ParseIP("fe80::1"), net.ParseIP("fe80::1")),
+		)
+
+		DescribeTable("validates the families of the ipFamily, the destination and the gateway",
+			func(ipFamily int, dst string, v4Gw, v6Gw net.IP, expected *networking.ErrFamilyMismatch) {
+				err := testNetNS.Do(func(_ ns.NetNS) error {
+					defer GinkgoRecover()
+
+					Expect(netlink.LinkAdd(&netlink.Veth{
+						LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+						PeerName:  "peer12345",
+					})).To(Succeed())
+					for _, name := range []string{"net1", "peer12345"} {
+						link, err := netlink.LinkByName(name)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(netlink.LinkSetUp(link)).To(Succeed())
+					}
+					link, err := netlink.LinkByName("net1")
+					Expect(err).NotTo(HaveOccurred())
+					for _, cidr := range []string{"10.6.0.2/24", "fd00::2/64"} {
+						addr, err := netlink.ParseAddr(cidr)
+						Expect(err).NotTo(HaveOccurred())
+						addr.Flags = unix.IFA_F_NODAD
+						Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+					}
+
+					var dstNet *net.IPNet
+					if dst != "" {
+						_, dstNet, err = net.ParseCIDR(dst)
+						Expect(err).NotTo(HaveOccurred())
+					}
+					err = networking.AddRoute(logger, 100, ipFamily, netlink.SCOPE_UNIVERSE, "net1", dstNet, v4Gw, v6Gw)
+					if expected == nil {
+						Expect(err).NotTo(HaveOccurred())
+						return nil
+					}
+
+					var mismatch *networking.ErrFamilyMismatch
+					Expect(errors.As(err, &mismatch)).To(BeTrue(), "error %v", err)
+					Expect(mismatch).To(Equal(expected))
+
+					routes, err := netlink.RouteListFiltered(netlink.FAMILY_ALL, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(routes).To(BeEmpty())
+					return nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+			},
+			Entry("ipv4 with the ipv4 destination", netlink.FAMILY_V4, "172.16.0.0/16", net.ParseIP("10.6.0.1"), nil, nil),
+			Entry("ipv4 with the ipv6 destination", netlink.FAMILY_V4, "fd01::/64", net.ParseIP("10.6.0.1"), nil,
+				&networking.ErrFamilyMismatch{IPFamily: netlink.FAMILY_V4, DstFamily: netlink.FAMILY_V6, GwFamily: netlink.FAMILY_V4}),
+			Entry("ipv4 with the default route", netlink.FAMILY_V4, "", net.ParseIP("10.6.0.1"), nil, nil),
+			Entry("ipv6 with the ipv4 destination", netlink.FAMILY_V6, "172.16.0.0/16", nil, net.ParseIP("fd00::1"),
+				&networking.ErrFamilyMismatch{IPFamily: netlink.FAMILY_V6, DstFamily: netlink.FAMILY_V4, GwFamily: netlink.FAMILY_V6}),
+			Entry("ipv6 with the ipv6 destination", netlink.FAMILY_V6, "fd01::/64", nil, net.ParseIP("fd00::1"), nil),
+			Entry("ipv6 with the default route", netlink.FAMILY_V6, "", nil, net.ParseIP("fd00::1"), nil),
+			Entry("all with the ipv4 destination but only the ipv6 gateway", netlink.FAMILY_ALL, "172.16.0.0/16", nil, net.ParseIP("fd00::1"),
+				&networking.ErrFamilyMismatch{IPFamily: netlink.FAMILY_ALL, DstFamily: netlink.FAMILY_V4, GwFamily: netlink.FAMILY_V6}),
+			Entry("all with the ipv6 destination", netlink.FAMILY_ALL, "fd01::/64", nil, net.ParseIP("fd00::1"), nil),
+			Entry("all with the default route", netlink.FAMILY_ALL, "", nil, net.ParseIP("fd00::1"), nil),
+		)
+
+		It("refuses the ambiguous default route and the link-local gateway without interface", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+
+				err := networking.AddRoute(logger, 100, netlink.FAMILY_ALL, netlink.SCOPE_UNIVERSE, "net1", nil, net.ParseIP("10.6.0.1"), net.ParseIP("fe80::1"))
+				Expect(err).To(MatchError(ContainSubstring("ambiguous")))
+
+				err = networking.AddRouteByIndex(logger, 100, netlink.FAMILY_V6, netlink.SCOPE_UNIVERSE, 0, nil, net.ParseIP("fe80::1"))
+				Expect(err).To(MatchError(ContainSubstring("requires the interface")))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("ParseScope", func() {
+		DescribeTable("maps the name to the scope",
+			func(name string, expected netlink.Scope) {
+				scope, err := networking.ParseScope(name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(scope).To(Equal(expected))
+			},
+			Entry("global", "global", netlink.SCOPE_UNIVERSE),
+			Entry("universe", "universe", netlink.SCOPE_UNIVERSE),
+			Entry("site", "site", netlink.SCOPE_SITE),
+			Entry("link", "link", netlink.SCOPE_LINK),
+			Entry("host", "host", netlink.SCOPE_HOST),
+			Entry("nowhere", "nowhere", netlink.SCOPE_NOWHERE),
+			Entry("case-insensitive", "Link", netlink.SCOPE_LINK),
+		)
+
+		DescribeTable("rejects the unknown name",
+			func(name string) {
+				_, err := networking.ParseScope(name)
+				Expect(err).To(MatchError(ContainSubstring("unknown route scope")))
+			},
+			Entry("empty", ""),
+			Entry("numeric", "253"),
+			Entry("unknown", "local"),
+		)
+
+		It("adds the route with the scope by its name", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+
+				_, dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networking.AddRouteWithScopeName(logger, 100, netlink.FAMILY_V4, "link", "net1", dst, nil, nil)).To(Succeed())
+				Expect(networking.AddRouteWithScopeName(logger, 100, netlink.FAMILY_V4, "bogus", "net1", dst, nil, nil)).NotTo(Succeed())
+
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(HaveLen(1))
+				Expect(routes[0].Scope).To(Equal(netlink.SCOPE_LINK))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("WithRealm", func() {
+		It("tags the route with the realm, which is kept by MoveRouteTable", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addr, err := netlink.ParseAddr("10.6.0.2/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				_, dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				err = networking.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, net.ParseIP("10.6.0.1"), nil,
+					networking.WithRealm(10))
+				Expect(err).NotTo(HaveOccurred())
+
+				tableRoutes := func(table int) []netlink.Route {
+					routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: table, Dst: dst},
+						netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST)
+					Expect(err).NotTo(HaveOccurred())
+					return routes
+				}
+				Expect(tableRoutes(unix.RT_TABLE_MAIN)).To(HaveLen(1))
+				Expect(tableRoutes(unix.RT_TABLE_MAIN)[0].Realm).To(Equal(10))
+
+				err = networking.MoveRouteTable(logger, "net1", unix.RT_TABLE_MAIN, 100, netlink.FAMILY_V4, nil, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tableRoutes(100)).To(HaveLen(1))
+				Expect(tableRoutes(100)[0].Realm).To(Equal(10))
+
+				err = networking.AddRoute(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, net.ParseIP("10.6.0.1"), nil,
+					networking.WithRealm(-1))
+				Expect(err).To(HaveOccurred())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("WithMTU", func() {
+		It("sets the mtu of the route, which is updated by ReplaceRoute", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addr, err := netlink.ParseAddr("10.6.0.2/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				_, dst, err := net.ParseCIDR("10.96.0.0/12")
+				Expect(err).NotTo(HaveOccurred())
+				gw := net.ParseIP("10.6.0.1")
+				routes := func() []netlink.Route {
+					routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100, Dst: dst},
+						netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST)
+					Expect(err).NotTo(HaveOccurred())
+					return routes
+				}
+
+				err = networking.AddRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, gw, nil, networking.WithMTU(1410))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes()).To(HaveLen(1))
+				Expect(routes()[0].MTU).To(Equal(1410))
+
+				// AddRoute leaves the existing route untouched
+				err = networking.AddRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, gw, nil, networking.WithMTU(1350))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes()[0].MTU).To(Equal(1410))
+
+				err = networking.ReplaceRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, gw, nil, networking.WithMTU(1350))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes()).To(HaveLen(1))
+				Expect(routes()[0].MTU).To(Equal(1350))
+
+				err = networking.ReplaceRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, gw, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes()[0].MTU).To(BeZero())
+
+				err = networking.AddRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, gw, nil, networking.WithMTU(-1))
+				Expect(err).To(HaveOccurred())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("WithMetrics", func() {
+		It("sets the TCP metrics of the route", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addr, err := netlink.ParseAddr("10.6.0.2/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				_, dst, err := net.ParseCIDR("10.96.0.0/12")
+				Expect(err).NotTo(HaveOccurred())
+				gw := net.ParseIP("10.6.0.1")
+				err = networking.AddRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, gw, nil,
+					networking.WithMetrics(networking.RouteMetrics{AdvMSS: 1400, InitCwnd: 10, RtoMin: 50 * time.Millisecond}))
+				Expect(err).NotTo(HaveOccurred())
+
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100, Dst: dst},
+					netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(HaveLen(1))
+				Expect(routes[0].AdvMSS).To(Equal(1400))
+				Expect(routes[0].InitCwnd).To(Equal(10))
+				Expect(routes[0].RtoMin).To(Equal(50))
+
+				err = networking.AddRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, gw, nil,
+					networking.WithMetrics(networking.RouteMetrics{InitCwnd: -1}))
+				Expect(err).To(HaveOccurred())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("AddRoute on the point-to-point interface", func() {
+		It("installs the routes without gateway in scope link", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Tuntap{
+					LinkAttrs: netlink.LinkAttrs{Name: "tun0"},
+					Mode:      netlink.TUNTAP_MODE_TUN,
+				})).To(Succeed())
+				link, err := netlink.LinkByName("tun0")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(link.Attrs().Flags & net.FlagPointToPoint).NotTo(BeZero())
+				Expect(netlink.LinkSetUp(link)).To(Succeed())
+				addr, err := netlink.ParseAddr("10.7.0.1/32")
+				Expect(err).NotTo(HaveOccurred())
+				_, addr.Peer, err = net.ParseCIDR("10.7.0.2/32")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				_, peer, err := net.ParseCIDR("10.7.0.2/32")
+				Expect(err).NotTo(HaveOccurred())
+				_, remote, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				for _, dst := range []*net.IPNet{peer, remote} {
+					err = networking.AddRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "tun0", dst, nil, nil)
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(HaveLen(2))
+				for _, route := range routes {
+					Expect(route.Dst.String()).To(BeElementOf(peer.String(), remote.String()))
+					Expect(route.LinkIndex).To(Equal(link.Attrs().Index))
+					Expect(route.Gw).To(BeNil())
+					Expect(route.Scope).To(Equal(netlink.SCOPE_LINK))
+				}
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("AddRouteByIndex", func() {
+		It("adds the route via the link index after the link is renamed", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "tmp12345"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				link, err := netlink.LinkByName("tmp12345")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.LinkSetName(link, "net1")).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				Expect(netlink.AddrAdd(link, &netlink.Addr{IPNet: &net.IPNet{IP: net.ParseIP("10.6.0.2"), Mask: net.CIDRMask(24, 32)}})).To(Succeed())
+
+				_, dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				gw := net.ParseIP("10.6.0.1")
+				Expect(networking.AddRouteByIndex(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, link.Attrs().Index, dst, gw)).To(Succeed())
+				// it is idempotent
+				Expect(networking.AddRouteByIndex(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, link.Attrs().Index, dst, gw)).To(Succeed())
+
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(HaveLen(1))
+				Expect(routes[0].LinkIndex).To(Equal(link.Attrs().Index))
+				Expect(routes[0].Dst.String()).To(Equal("172.16.0.0/16"))
+				Expect(routes[0].Gw.Equal(gw)).To(BeTrue())
+
+				Expect(networking.AddRouteByIndex(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, link.Attrs().Index, dst, net.ParseIP("fd00::1"))).NotTo(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("DelRoute", func() {
+		It("only deletes the route via the interface in the table", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+
+				_, dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				for _, table := range []int{100, 101} {
+					Expect(networking.AddRoute(logger, table, netlink.FAMILY_V4, netlink.SCOPE_LINK, "net1", dst, nil, nil)).To(Succeed())
+				}
+
+				Expect(networking.DelRoute(100, "net1", dst)).To(Succeed())
+				// no such route
+				Expect(networking.DelRoute(100, "net1", dst)).To(Succeed())
+
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Dst: dst, Table: unix.RT_TABLE_UNSPEC}, netlink.RT_FILTER_DST|netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(HaveLen(1))
+				Expect(routes[0].Table).To(Equal(101))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("RemapRouteLinkIndex", func() {
+		It("rewrites the routes of the table to the new link", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				linkIndex := make(map[string]int)
+				for i, name := range []string{"net1", "net2"} {
+					Expect(netlink.LinkAdd(&netlink.Veth{
+						LinkAttrs: netlink.LinkAttrs{Name: name},
+						PeerName:  fmt.Sprintf("peer%d", i),
+					})).To(Succeed())
+					peer, err := netlink.LinkByName(fmt.Sprintf("peer%d", i))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(peer)).To(Succeed())
+
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+					linkIndex[name] = link.Attrs().Index
+					for _, cidr := range []string{fmt.Sprintf("10.6.0.%d/24", i+2), fmt.Sprintf("fd00::%d/64", i+2)} {
+						addr, err := netlink.ParseAddr(cidr)
+						Expect(err).NotTo(HaveOccurred())
+						addr.Flags = unix.IFA_F_NODAD
+						Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+					}
+				}
+
+				_, dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networking.AddRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, net.ParseIP("10.6.0.1"), nil)).To(Succeed())
+				Expect(networking.AddRoute(logger, 100, netlink.FAMILY_V6, netlink.SCOPE_UNIVERSE, "net1", nil, nil, net.ParseIP("fd00::1"))).To(Succeed())
+				// the routes of the other table are left untouched
+				Expect(networking.AddRoute(logger, 101, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", nil, net.ParseIP("10.6.0.1"), nil)).To(Succeed())
+
+				Expect(networking.RemapRouteLinkIndex(100, linkIndex["net1"], linkIndex["net2"], netlink.FAMILY_ALL)).To(Succeed())
+
+				tableRoutes := func(table int) []netlink.Route {
+					routes, err := netlink.RouteListFiltered(netlink.FAMILY_ALL, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+					Expect(err).NotTo(HaveOccurred())
+					return routes
+				}
+				routes := tableRoutes(100)
+				Expect(routes).To(HaveLen(2))
+				for _, route := range routes {
+					Expect(route.LinkIndex).To(Equal(linkIndex["net2"]), "route %v", route)
+				}
+				routes = tableRoutes(101)
+				Expect(routes).To(HaveLen(1))
+				Expect(routes[0].LinkIndex).To(Equal(linkIndex["net1"]))
+
+				Expect(networking.RemapRouteLinkIndex(100, linkIndex["net1"], 1000, netlink.FAMILY_ALL)).NotTo(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("DelRoutes", func() {
+		It("deletes the batch with the best effort", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				addr, err := netlink.ParseAddr("10.6.0.2/24")
+				Expect(err).NotTo(HaveOccurred())
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				gw := net.ParseIP("10.6.0.1")
+				var dsts []*net.IPNet
+				for _, cidr := range []string{"172.16.0.0/16", "172.17.0.0/16", "10.7.0.1/32"} {
+					_, dst, err := net.ParseCIDR(cidr)
+					Expect(err).NotTo(HaveOccurred())
+					dsts = append(dsts, dst)
+				}
+				// the default route
+				dsts = append(dsts, nil)
+				for _, dst := range dsts {
+					Expect(networking.AddRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1", dst, gw, nil)).To(Succeed())
+				}
+
+				// one of them is already gone
+				Expect(networking.DelRoute(100, "net1", dsts[1])).To(Succeed())
+				Expect(networking.DelRoutes(logger, 100, netlink.FAMILY_V4, "net1", dsts, gw)).To(Succeed())
+
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_ALL, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(BeEmpty())
+
+				// all of them are gone
+				Expect(networking.DelRoutes(logger, 100, netlink.FAMILY_V4, "net1", dsts, gw)).To(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("aggregates the errors of the mismatched destinations", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+
+				_, v6Dst, err := net.ParseCIDR("fd00::/64")
+				Expect(err).NotTo(HaveOccurred())
+				_, v4Dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				err = networking.DelRoutes(logger, 100, netlink.FAMILY_V4, "net1", []*net.IPNet{v6Dst, v4Dst}, nil)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fd00::/64"))
+
+				// the interface is gone
+				Expect(networking.DelRoutes(logger, 100, netlink.FAMILY_V4, "net2", []*net.IPNet{v4Dst}, nil)).To(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("DeleteRulesByPriorityRange", func() {
+		It("only deletes the rules whose priority is in the range", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				for _, r := range []struct {
+					src      string
+					priority int
+				}{
+					{"10.6.0.0/16", 500},
+					{"10.7.0.0/16", 1000},
+					{"10.8.0.0/16", 1500},
+					{"10.9.0.0/16", 2000},
+					{"fd00:6::/64", 1200},
+					{"fd00:7::/64", 3000},
+				} {
+					_, src, err := net.ParseCIDR(r.src)
+					Expect(err).NotTo(HaveOccurred())
+					rule := netlink.NewRule()
+					rule.Src = src
+					rule.Table = 100
+					rule.Priority = r.priority
+					Expect(netlink.RuleAdd(rule)).To(Succeed())
+				}
+				// a rule without src, which is deleted by the family
+				rule := netlink.NewRule()
+				rule.Family = netlink.FAMILY_V6
+				rule.Mark = 1
+				rule.Table = 100
+				rule.Priority = 1100
+				Expect(netlink.RuleAdd(rule)).To(Succeed())
+
+				Expect(networking.DeleteRulesByPriorityRange(1000, 1500, netlink.FAMILY_ALL)).To(Succeed())
+
+				priorities := func(family int) []int {
+					rules, err := netlink.RuleList(family)
+					Expect(err).NotTo(HaveOccurred())
+					var result []int
+					for _, rule := range rules {
+						if rule.Table == 100 {
+							result = append(result, rule.Priority)
+						}
+					}
+					return result
+				}
+				Expect(priorities(netlink.FAMILY_V4)).To(ConsistOf(500, 2000))
+				Expect(priorities(netlink.FAMILY_V6)).To(ConsistOf(3000))
+
+				// the system rules are untouched
+				rules, err := netlink.RuleList(netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rules).To(ContainElement(HaveField("Table", unix.RT_TABLE_MAIN)))
+
+				Expect(networking.DeleteRulesByPriorityRange(2, 1, netlink.FAMILY_V4)).NotTo(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("DelRulesByPriorityRange", func() {
+		It("never deletes the default rules of the kernel and only deletes the rules of the tables", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				for _, r := range []struct {
+					src      string
+					table    int
+					priority int
+				}{
+					{"10.6.0.0/16", 100, 999},
+					{"10.7.0.0/16", 101, 1003},
+					{"10.8.0.0/16", 500, 1005},
+					{"fd00:6::/64", 100, 1001},
+				} {
+					_, src, err := net.ParseCIDR(r.src)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(networking.AddFromRuleTable(src, r.table, networking.WithRulePriority(r.priority))).To(Succeed())
+				}
+
+				var logs bytes.Buffer
+				logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&logs), zap.InfoLevel))
+
+				deleted, err := networking.DelRulesByPriorityRange(logger, netlink.FAMILY_ALL, 999, 1005, []int{100, 101})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deleted).To(Equal(3))
+				Expect(strings.Count(logs.String(), "delete the rule")).To(Equal(3))
+
+				priorities := func(family int) []int {
+					rules, err := netlink.RuleList(family)
+					Expect(err).NotTo(HaveOccurred())
+					var result []int
+					for _, rule := range rules {
+						// the kernel doesn't report the priority 0
+						if rule.Priority < 0 {
+							rule.Priority = 0
+						}
+						result = append(result, rule.Priority)
+					}
+					return result
+				}
+				Expect(priorities(netlink.FAMILY_V4)).To(ConsistOf(0, 1005, 32766, 32767))
+				Expect(priorities(netlink.FAMILY_V6)).To(ConsistOf(0, 32766))
+
+				// the misconfigured range including the default rules
+				deleted, err = networking.DelRulesByPriorityRange(logger, netlink.FAMILY_ALL, 0, 65535, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deleted).To(Equal(1))
+				Expect(priorities(netlink.FAMILY_V4)).To(ConsistOf(0, 32766, 32767))
+				Expect(priorities(netlink.FAMILY_V6)).To(ConsistOf(0, 32766))
+
+				_, err = networking.DelRulesByPriorityRange(logger, netlink.FAMILY_V4, 2, 1, nil)
+				Expect(err).To(HaveOccurred())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("AddNotToRuleTable", func() {
+		It("only deletes the inverted rule", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				_, clusterCIDR, err := net.ParseCIDR("10.244.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+
+				// the rule without the invert flag, which is identical otherwise
+				rule := netlink.NewRule()
+				rule.Dst = clusterCIDR
+				rule.Table = 100
+				rule.Priority = 2001
+				Expect(netlink.RuleAdd(rule)).To(Succeed())
+				Expect(networking.AddNotToRuleTable(clusterCIDR, 100, netlink.FAMILY_V4, 2000)).To(Succeed())
+				Expect(networking.AddNotToRuleTable(clusterCIDR, 100, netlink.FAMILY_V6, 2000)).NotTo(Succeed())
+				Expect(networking.AddNotToRuleTable(nil, 100, netlink.FAMILY_V4, 2000)).NotTo(Succeed())
+
+				tableRules := func() []netlink.Rule {
+					rules, err := netlink.RuleListFiltered(netlink.FAMILY_V4, &netlink.Rule{Table: 100}, netlink.RT_FILTER_TABLE)
+					Expect(err).NotTo(HaveOccurred())
+					return rules
+				}
+				Expect(tableRules()).To(HaveLen(2))
+				Expect(networking.FormatRules(tableRules())).To(ContainSubstring("2000:\tnot from all to 10.244.0.0/16 lookup 100\n"))
+
+				// the kernel would delete the rule without the invert flag
+				err = networking.DelNotToRuleTable(clusterCIDR, 100, netlink.FAMILY_V4, 2001)
+				Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+				Expect(tableRules()).To(HaveLen(2))
+
+				Expect(networking.DelNotToRuleTable(clusterCIDR, 100, netlink.FAMILY_V4, 2000)).To(Succeed())
+				rules := tableRules()
+				Expect(rules).To(HaveLen(1))
+				Expect(rules[0].Invert).To(BeFalse())
+				Expect(rules[0].Priority).To(Equal(2001))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("AddSuppressPrefixRule", func() {
+		It("round-trips the suppress prefix length through the rule list", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(networking.AddSuppressPrefixRule(unix.RT_TABLE_MAIN, netlink.FAMILY_V4, 1001, 0)).To(Succeed())
+				Expect(networking.AddSuppressPrefixRule(unix.RT_TABLE_MAIN, netlink.FAMILY_V6, 1001, 0)).To(Succeed())
+				Expect(networking.AddSuppressPrefixRule(unix.RT_TABLE_MAIN, netlink.FAMILY_ALL, 1001, 0)).NotTo(Succeed())
+				Expect(networking.AddSuppressPrefixRule(unix.RT_TABLE_MAIN, netlink.FAMILY_V4, 1001, 33)).NotTo(Succeed())
+
+				for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+					rules, err := netlink.RuleList(family)
+					Expect(err).NotTo(HaveOccurred())
+					var found []netlink.Rule
+					for _, rule := range rules {
+						if rule.Priority == 1001 {
+							found = append(found, rule)
+						}
+					}
+					Expect(found).To(HaveLen(1))
+					Expect(found[0].Table).To(Equal(unix.RT_TABLE_MAIN))
+					Expect(found[0].SuppressPrefixlen).To(Equal(0))
+					Expect(found[0].SuppressIfgroup).To(Equal(-1))
+
+					Expect(networking.DelSuppressPrefixRule(unix.RT_TABLE_MAIN, family, 1001, 0)).To(Succeed())
+					rules, err = netlink.RuleList(family)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(rules).NotTo(ContainElement(HaveField("Priority", 1001)))
+				}
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("ListRulesResolved", func() {
+		It("resolves the links of the rules and keeps the rules of the vanished links", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(networking.AddRuleForInterface("net1", networking.Iif, 101, netlink.FAMILY_V4, 2000)).To(Succeed())
+				// the rule references the link by name, which outlives the link
+				rule := netlink.NewRule()
+				rule.OifName = "net2"
+				rule.Table = 102
+				rule.Priority = 2001
+				Expect(netlink.RuleAdd(rule)).To(Succeed())
+
+				rules, err := networking.ListRulesResolved(netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+				tables := map[int]networking.ResolvedRule{}
+				for _, r := range rules {
+					tables[r.Table] = r
+				}
+				Expect(tables).To(HaveKey(101))
+				Expect(tables[101].IifName).To(Equal("net1"))
+				Expect(tables[101].IifIndex).To(Equal(link.Attrs().Index))
+				Expect(tables[101].OifIndex).To(BeZero())
+				Expect(tables).To(HaveKey(102))
+				Expect(tables[102].OifName).To(Equal("net2"))
+				Expect(tables[102].OifIndex).To(BeZero())
+				Expect(tables).To(HaveKey(unix.RT_TABLE_MAIN))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("FormatRules", func() {
+		It("renders the rules like ip rule show", func() {
+			_, src, err := net.ParseCIDR("10.6.0.0/16")
+			Expect(err).NotTo(HaveOccurred())
+			_, dst, err := net.ParseCIDR("fd00:10:6::/64")
+			Expect(err).NotTo(HaveOccurred())
+
+			fromRule := netlink.NewRule()
+			fromRule.Priority = 1000
+			fromRule.Src = src
+			fromRule.Table = 100
+
+			toRule := netlink.NewRule()
+			toRule.Priority = 1000
+			toRule.Dst = dst
+			toRule.Table = 101
+
+			markRule := netlink.NewRule()
+			markRule.Priority = 1000
+			markRule.Mark = 1
+			markRule.Table = 500
+
+			maskRule := netlink.NewRule()
+			maskRule.Priority = 1001
+			maskRule.Mark = 0x100
+			maskRule.Mask = 0xff00
+			maskRule.Table = 500
+
+			iifRule := netlink.NewRule()
+			iifRule.Priority = 1002
+			iifRule.IifName = "veth0"
+			iifRule.Table = 500
+
+			oifRule := netlink.NewRule()
+			oifRule.Priority = 1003
+			oifRule.OifName = "net1"
+			oifRule.Invert = true
+			oifRule.Table = 101
+
+			suppressRule := netlink.NewRule()
+			suppressRule.Priority = 1004
+			suppressRule.Table = unix.RT_TABLE_MAIN
+			suppressRule.SuppressPrefixlen = 0
+
+			mainRule := netlink.NewRule()
+			mainRule.Priority = 32766
+			mainRule.Table = unix.RT_TABLE_MAIN
+
+			Expect(networking.FormatRules([]netlink.Rule{*fromRule, *toRule, *markRule, *maskRule, *iifRule, *oifRule, *suppressRule, *mainRule})).To(Equal(
+				"1000:\tfrom 10.6.0.0/16 lookup 100\n" +
+					"1000:\tfrom all to fd00:10:6::/64 lookup 101\n" +
+					"1000:\tfrom all fwmark 0x1 lookup 500\n" +
+					"1001:\tfrom all fwmark 0x100/0xff00 lookup 500\n" +
+					"1002:\tfrom all iif veth0 lookup 500\n" +
+					"1003:\tnot from all oif net1 lookup 101\n" +
+					"1004:\tfrom all lookup main suppress_prefixlength 0\n" +
+					"32766:\tfrom all lookup main\n"))
+
+			Expect(networking.FormatRules(nil)).To(BeEmpty())
+		})
+	})
+
+	Context("AddMultipathRoute", func() {
+		It("adds the weighted multipath default routes and deletes them after a member is gone", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				links := map[string]netlink.Link{}
+				for idx, name := range []string{"net1", "net2"} {
+					Expect(netlink.LinkAdd(&netlink.Veth{
+						LinkAttrs: netlink.LinkAttrs{Name: name},
+						PeerName:  name + "-peer",
+					})).To(Succeed())
+					for _, n := range []string{name, name + "-peer"} {
+						link, err := netlink.LinkByName(n)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(netlink.LinkSetUp(link)).To(Succeed())
+					}
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					links[name] = link
+
+					for _, cidr := range []string{fmt.Sprintf("10.%d.0.2/16", 6+idx), fmt.Sprintf("fd00:10:%d::2/64", 6+idx)} {
+						addr, err := netlink.ParseAddr(cidr)
+						Expect(err).NotTo(HaveOccurred())
+						addr.Flags = unix.IFA_F_NODAD
+						Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+					}
+				}
+
+				_, v4Default, err := net.ParseCIDR("0.0.0.0/0")
+				Expect(err).NotTo(HaveOccurred())
+				_, v6Default, err := net.ParseCIDR("::/0")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(networking.AddMultipathRoute(logger, unix.RT_TABLE_MAIN, v4Default, []networking.Nexthop{
+					{LinkIndex: links["net1"].Attrs().Index, Gw: net.ParseIP("10.6.0.1"), Weight: 1},
+					{LinkIndex: links["net2"].Attrs().Index, Gw: net.ParseIP("10.7.0.1"), Weight: 3},
+				})).To(Succeed())
+				Expect(networking.AddMultipathRoute(logger, unix.RT_TABLE_MAIN, v6Default, []networking.Nexthop{
+					{LinkIndex: links["net1"].Attrs().Index, Gw: net.ParseIP("fd00:10:6::1")},
+					{LinkIndex: links["net2"].Attrs().Index, Gw: net.ParseIP("fd00:10:7::1")},
+				})).To(Succeed())
+
+				nexthops, err := networking.GetMultipathNexthops(unix.RT_TABLE_MAIN, v4Default)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(nexthops).To(ConsistOf(
+					networking.Nexthop{LinkIndex: links["net1"].Attrs().Index, Gw: net.ParseIP("10.6.0.1").To4(), Weight: 1},
+					networking.Nexthop{LinkIndex: links["net2"].Attrs().Index, Gw: net.ParseIP("10.7.0.1").To4(), Weight: 3},
+				))
+				nexthops, err = networking.GetMultipathNexthops(unix.RT_TABLE_MAIN, v6Default)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(nexthops).To(HaveLen(2))
+
+				// replace the member
+				Expect(networking.AddMultipathRoute(logger, unix.RT_TABLE_MAIN, v4Default, []networking.Nexthop{
+					{LinkIndex: links["net1"].Attrs().Index, Gw: net.ParseIP("10.6.0.254"), Weight: 2},
+					{LinkIndex: links["net2"].Attrs().Index, Gw: net.ParseIP("10.7.0.1"), Weight: 3},
+				})).To(Succeed())
+				nexthops, err = networking.GetMultipathNexthops(unix.RT_TABLE_MAIN, v4Default)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(nexthops).To(HaveLen(2))
+				Expect(nexthops).To(ContainElement(networking.Nexthop{LinkIndex: links["net1"].Attrs().Index, Gw: net.ParseIP("10.6.0.254").To4(), Weight: 2}))
+
+				Expect(netlink.LinkDel(links["net2"])).To(Succeed())
+				Expect(networking.DelMultipathRoute(unix.RT_TABLE_MAIN, v4Default)).To(Succeed())
+				Expect(networking.DelMultipathRoute(unix.RT_TABLE_MAIN, v6Default)).To(Succeed())
+
+				for _, dst := range []*net.IPNet{v4Default, v6Default} {
+					nexthops, err = networking.GetMultipathNexthops(unix.RT_TABLE_MAIN, dst)
+					Expect(err).NotTo(HaveOccurred())
+					for _, nh := range nexthops {
+						Expect(nh.LinkIndex).NotTo(Equal(links["net1"].Attrs().Index))
+					}
+				}
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("AppendNexthop", func() {
+		It("turns the single path route into a multipath one", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				links := map[string]netlink.Link{}
+				for idx, name := range []string{"net1", "net2"} {
+					Expect(netlink.LinkAdd(&netlink.Veth{
+						LinkAttrs: netlink.LinkAttrs{Name: name},
+						PeerName:  name + "-peer",
+					})).To(Succeed())
+					for _, n := range []string{name, name + "-peer"} {
+						link, err := netlink.LinkByName(n)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(netlink.LinkSetUp(link)).To(Succeed())
+					}
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					links[name] = link
+
+					for _, cidr := range []string{fmt.Sprintf("10.%d.0.2/16", 6+idx), fmt.Sprintf("fd00:10:%d::2/64", 6+idx)} {
+						addr, err := netlink.ParseAddr(cidr)
+						Expect(err).NotTo(HaveOccurred())
+						addr.Flags = unix.IFA_F_NODAD
+						Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+					}
+				}
+
+				_, v4Dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				_, v6Dst, err := net.ParseCIDR("fd01::/64")
+				Expect(err).NotTo(HaveOccurred())
+
+				for _, tc := range []struct {
+					family   int
+					dst      *net.IPNet
+					gw1, gw2 net.IP
+				}{
+					{netlink.FAMILY_V4, v4Dst, net.ParseIP("10.6.0.1").To4(), net.ParseIP("10.7.0.1").To4()},
+					{netlink.FAMILY_V6, v6Dst, net.ParseIP("fd00:10:6::1"), net.ParseIP("fd00:10:7::1")},
+				} {
+					Expect(networking.AddRoute(logger, unix.RT_TABLE_MAIN, tc.family, netlink.SCOPE_UNIVERSE, "net1", tc.dst, tc.gw1, tc.gw1)).To(Succeed())
+					Expect(networking.AppendNexthop(tc.dst, "net2", tc.gw2, tc.family)).To(Succeed())
+					// appending the existing nexthop is a no-op
+					Expect(networking.AppendNexthop(tc.dst, "net2", tc.gw2, tc.family)).To(Succeed())
+
+					routes, err := netlink.RouteListFiltered(tc.family, &netlink.Route{Dst: tc.dst}, netlink.RT_FILTER_DST)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(routes).To(HaveLen(1))
+					Expect(routes[0].MultiPath).To(HaveLen(2))
+
+					nexthops, err := networking.GetMultipathNexthops(unix.RT_TABLE_MAIN, tc.dst)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(nexthops).To(ConsistOf(
+						networking.Nexthop{LinkIndex: links["net1"].Attrs().Index, Gw: tc.gw1, Weight: 1},
+						networking.Nexthop{LinkIndex: links["net2"].Attrs().Index, Gw: tc.gw2, Weight: 1},
+					))
+				}
+
+				Expect(networking.AppendNexthop(v4Dst, "net2", net.ParseIP("fd00:10:7::1"), netlink.FAMILY_V4)).NotTo(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("keeps the metric and the attributes of the existing route", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				links := map[string]netlink.Link{}
+				for idx, name := range []string{"net1", "net2"} {
+					Expect(netlink.LinkAdd(&netlink.Veth{
+						LinkAttrs: netlink.LinkAttrs{Name: name},
+						PeerName:  name + "-peer",
+					})).To(Succeed())
+					for _, n := range []string{name, name + "-peer"} {
+						link, err := netlink.LinkByName(n)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(netlink.LinkSetUp(link)).To(Succeed())
+					}
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					links[name] = link
+
+					for _, cidr := range []string{fmt.Sprintf("10.%d.0.2/16", 6+idx), fmt.Sprintf("fd00:10:%d::2/64", 6+idx)} {
+						addr, err := netlink.ParseAddr(cidr)
+						Expect(err).NotTo(HaveOccurred())
+						addr.Flags = unix.IFA_F_NODAD
+						Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+					}
+				}
+
+				_, v4Dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				_, v6Dst, err := net.ParseCIDR("fd01::/64")
+				Expect(err).NotTo(HaveOccurred())
+
+				for _, tc := range []struct {
+					family   int
+					dst      *net.IPNet
+					gw1, gw2 net.IP
+				}{
+					{netlink.FAMILY_V4, v4Dst, net.ParseIP("10.6.0.1").To4(), net.ParseIP("10.7.0.1").To4()},
+					{netlink.FAMILY_V6, v6Dst, net.ParseIP("fd00:10:6::1"), net.ParseIP("fd00:10:7::1")},
+				} {
+					Expect(netlink.RouteAdd(&netlink.Route{
+						LinkIndex: links["net1"].Attrs().Index,
+						Dst:       tc.dst,
+						Gw:        tc.gw1,
+						Priority:  100,
+						Protocol:  unix.RTPROT_STATIC,
+					})).To(Succeed())
+					Expect(networking.AppendNexthop(tc.dst, "net2", tc.gw2, tc.family)).To(Succeed())
+
+					routes, err := netlink.RouteListFiltered(tc.family, &netlink.Route{Dst: tc.dst}, netlink.RT_FILTER_DST)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(routes).To(HaveLen(1))
+					Expect(routes[0].Priority).To(Equal(100))
+					Expect(routes[0].Protocol).To(Equal(netlink.RouteProtocol(unix.RTPROT_STATIC)))
+					Expect(routes[0].MultiPath).To(HaveLen(2))
+
+					// the nexthops of the routes with different metrics can't be merged
+					Expect(netlink.RouteAdd(&netlink.Route{
+						LinkIndex: links["net1"].Attrs().Index,
+						Dst:       tc.dst,
+						Gw:        tc.gw1,
+						Priority:  200,
+					})).To(Succeed())
+					Expect(networking.AppendNexthop(tc.dst, "net1", tc.gw2, tc.family)).NotTo(Succeed())
+				}
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("InstallECMPDefault", func() {
+		It("installs the ECMP default route across the gateways of the interfaces", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				var gws []networking.GatewayInfo
+				for idx, name := range []string{"net1", "net2"} {
+					Expect(netlink.LinkAdd(&netlink.Veth{
+						LinkAttrs: netlink.LinkAttrs{Name: name},
+						PeerName:  name + "-peer",
+					})).To(Succeed())
+					for _, n := range []string{name, name + "-peer"} {
+						link, err := netlink.LinkByName(n)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(netlink.LinkSetUp(link)).To(Succeed())
+					}
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+
+					addr, err := netlink.ParseAddr(fmt.Sprintf("10.%d.0.2/16", 6+idx))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+					gws = append(gws, networking.GatewayInfo{Gw: net.ParseIP(fmt.Sprintf("10.%d.0.1", 6+idx)), LinkIndex: link.Attrs().Index})
+				}
+
+				Expect(networking.InstallECMPDefault(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V6, gws)).NotTo(Succeed())
+				Expect(networking.InstallECMPDefault(logger, unix.RT_TABLE_MAIN, netlink.FAMILY_V4, gws)).To(Succeed())
+
+				for _, name := range []string{"net1", "net2"} {
+					found, err := networking.GetDefaultGatewayByName(name, netlink.FAMILY_V4, unix.RT_TABLE_MAIN)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(found).To(HaveLen(1))
+				}
+
+				_, v4Default, err := net.ParseCIDR("0.0.0.0/0")
+				Expect(err).NotTo(HaveOccurred())
+				nexthops, err := networking.GetMultipathNexthops(unix.RT_TABLE_MAIN, v4Default)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(nexthops).To(ConsistOf(
+					networking.Nexthop{LinkIndex: gws[0].LinkIndex, Gw: gws[0].Gw.To4(), Weight: 1},
+					networking.Nexthop{LinkIndex: gws[1].LinkIndex, Gw: gws[1].Gw.To4(), Weight: 1},
+				))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("SubscribeRouteUpdates", func() {
+		It("only delivers the updates of the foreign routes when the owned ones are excluded", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "net1-peer",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "net1-peer"} {
+					// the IPv6 routes are installed by the kernel asynchronously
+					// once the link is up, which are foreign routes as well
+					Expect(sysctl.SetDisableIPv6(name, 1)).To(Succeed())
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+
+				ctx, cancel := context.WithCancel(context.Background())
+				updates, err := networking.SubscribeRouteUpdates(ctx, networking.RouteUpdateFilter{ExcludeOwned: true}, 16)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, owned, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networking.AddRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_LINK, "net1", owned, nil, nil)).To(Succeed())
+
+				_, foreign, err := net.ParseCIDR("172.17.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.RouteAdd(&netlink.Route{
+					LinkIndex: link.Attrs().Index,
+					Dst:       foreign,
+					Scope:     netlink.SCOPE_LINK,
+					Table:     100,
+				})).To(Succeed())
+
+				var update netlink.RouteUpdate
+				Eventually(updates).Should(Receive(&update))
+				Expect(update.Type).To(BeEquivalentTo(unix.RTM_NEWROUTE))
+				Expect(update.Dst.String()).To(Equal(foreign.String()))
+				Consistently(updates, 200*time.Millisecond).ShouldNot(Receive())
+
+				// the channel is closed once the ctx is done
+				cancel()
+				Eventually(updates).Should(BeClosed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("DedupRoutes", func() {
+		It("keeps only the route with the lowest metric of the duplicates", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "net1-peer",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "net1-peer"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addr, err := netlink.ParseAddr("10.6.0.2/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				_, v4Default, err := net.ParseCIDR("0.0.0.0/0")
+				Expect(err).NotTo(HaveOccurred())
+				_, other, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				for _, route := range []*netlink.Route{
+					{Dst: v4Default, Gw: net.ParseIP("10.6.0.1"), Priority: 300},
+					{Dst: v4Default, Gw: net.ParseIP("10.6.0.1"), Priority: 100},
+					{Dst: v4Default, Gw: net.ParseIP("10.6.0.1"), Priority: 200},
+					// a different gateway is not a duplicate
+					{Dst: v4Default, Gw: net.ParseIP("10.6.0.254"), Priority: 400},
+					{Dst: other, Gw: net.ParseIP("10.6.0.1"), Priority: 100},
+				} {
+					route.LinkIndex = link.Attrs().Index
+					route.Table = 100
+					Expect(netlink.RouteAdd(route)).To(Succeed())
+				}
+
+				removed, err := networking.DedupRoutes(100, netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(removed).To(Equal(2))
+
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(HaveLen(3))
+				for _, route := range routes {
+					if route.Dst.String() == v4Default.String() && route.Gw.Equal(net.ParseIP("10.6.0.1")) {
+						Expect(route.Priority).To(Equal(100))
+					}
+				}
+
+				removed, err = networking.DedupRoutes(100, netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(removed).To(BeZero())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("RouteInventory", func() {
+		It("groups the routes by the table and marshals them", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "net1-peer",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "net1-peer"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addr, err := netlink.ParseAddr("10.6.0.2/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				_, v4Default, err := net.ParseCIDR("0.0.0.0/0")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.RouteAdd(&netlink.Route{LinkIndex: link.Attrs().Index, Dst: v4Default, Gw: net.ParseIP("10.6.0.1"), Table: 100})).To(Succeed())
+				_, other, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networking.AddRoute(logger, 101, netlink.FAMILY_V4, netlink.SCOPE_LINK, "net1", other, nil, nil)).To(Succeed())
+
+				inventory, err := networking.RouteInventory(netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(inventory[100]).To(ConsistOf(networking.RouteInfo{
+					Dst:      "default",
+					Gw:       "10.6.0.1",
+					Iface:    "net1",
+					Scope:    "universe",
+					Protocol: unix.RTPROT_BOOT,
+				}))
+				Expect(inventory[101]).To(ConsistOf(networking.RouteInfo{
+					Dst:      "172.16.0.0/16",
+					Iface:    "net1",
+					Scope:    "link",
+					Protocol: int(networking.RouteProtocolSpiderpool),
+				}))
+				Expect(inventory[unix.RT_TABLE_MAIN]).To(ContainElement(HaveField("Dst", "10.6.0.0/16")))
+
+				data, err := json.Marshal(inventory)
+				Expect(err).NotTo(HaveOccurred())
+				var decoded map[int][]networking.RouteInfo
+				Expect(json.Unmarshal(data, &decoded)).To(Succeed())
+				Expect(decoded).To(Equal(inventory))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("DrainRoute", func() {
+		It("increases the metric of the route and keeps it present", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "net1-peer",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "net1-peer"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				addr, err := netlink.ParseAddr("10.6.0.2/24")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+				_, dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.RouteAdd(&netlink.Route{
+					LinkIndex: link.Attrs().Index,
+					Dst:       dst,
+					Gw:        net.ParseIP("10.6.0.1"),
+					Table:     100,
+					Priority:  10,
+				})).To(Succeed())
+				_, other, err := net.ParseCIDR("172.17.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.RouteAdd(&netlink.Route{
+					LinkIndex: link.Attrs().Index,
+					Dst:       other,
+					Gw:        net.ParseIP("10.6.0.1"),
+					Table:     100,
+					Priority:  10,
+				})).To(Succeed())
+
+				Expect(networking.DrainRoute(dst, 100, netlink.FAMILY_V4)).To(Succeed())
+
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(HaveLen(2))
+				metrics := map[string]int{}
+				for _, route := range routes {
+					metrics[route.Dst.String()] = route.Priority
+				}
+				Expect(metrics).To(HaveKeyWithValue("172.16.0.0/16", BeNumerically(">", 10)))
+				Expect(metrics).To(HaveKeyWithValue("172.17.0.0/16", 10))
+
+				// the drained route is still usable
+				exists, err := networking.RouteExists(nil, &netlink.Route{Dst: dst, Gw: net.ParseIP("10.6.0.1"), Table: 100})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(exists).To(BeTrue())
+
+				_, missing, err := net.ParseCIDR("172.18.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networking.DrainRoute(missing, 100, netlink.FAMILY_V4)).NotTo(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("DeleteLinkLocalRoutes", func() {
+		It("deletes the fe80::/64 route, which MoveRouteTable leaves in place", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "net1-peer",
+				})).To(Succeed())
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.LinkSetUp(link)).To(Succeed())
+
+				_, linkLocal, err := net.ParseCIDR("fe80::/64")
+				Expect(err).NotTo(HaveOccurred())
+				_, dst, err := net.ParseCIDR("fd00:10::/64")
+				Expect(err).NotTo(HaveOccurred())
+				for _, ipNet := range []*net.IPNet{linkLocal, dst} {
+					// the kernel adds fe80::/64 once the link-local address is ready,
+					// which may race with the test, so it's replaced
+					Expect(netlink.RouteReplace(&netlink.Route{
+						LinkIndex: link.Attrs().Index,
+						Dst:       ipNet,
+						Table:     unix.RT_TABLE_MAIN,
+					})).To(Succeed())
+				}
+
+				mainDsts := func() []string {
+					routes, err := netlink.RouteListFiltered(netlink.FAMILY_V6, &netlink.Route{Table: unix.RT_TABLE_MAIN, LinkIndex: link.Attrs().Index},
+						netlink.RT_FILTER_TABLE|netlink.RT_FILTER_OIF)
+					Expect(err).NotTo(HaveOccurred())
+					var dsts []string
+					for _, route := range routes {
+						dsts = append(dsts, route.Dst.String())
+					}
+					return dsts
+				}
+
+				err = networking.MoveRouteTable(logger, "net1", unix.RT_TABLE_MAIN, 100, netlink.FAMILY_V6, nil, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mainDsts()).To(ConsistOf("fe80::/64"))
+
+				Expect(networking.DeleteLinkLocalRoutes("net1", netlink.FAMILY_V6)).To(Succeed())
+				Expect(mainDsts()).To(BeEmpty())
+				// the other routes are untouched
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V6, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(HaveLen(1))
+				Expect(routes[0].Dst.String()).To(Equal("fd00:10::/64"))
+
+				// it's a no-op once the routes are gone
+				Expect(networking.DeleteLinkLocalRoutes("net1", netlink.FAMILY_V6)).To(Succeed())
+				Expect(networking.DeleteLinkLocalRoutes("net2", netlink.FAMILY_V6)).NotTo(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("AddSpecialRoute", func() {
+		It("adds the local and broadcast routes with the scope of the type", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "net1-peer",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "net1-peer"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+
+				_, local, err := net.ParseCIDR("10.6.0.5/32")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networking.AddSpecialRoute(logger, unix.RT_TABLE_MAIN, unix.RTN_LOCAL, "net1", local)).To(Succeed())
+				// it's a no-op if the route exists
+				Expect(networking.AddSpecialRoute(logger, unix.RT_TABLE_MAIN, unix.RTN_LOCAL, "net1", local)).To(Succeed())
+
+				// the local route is in the main table besides the local one
+				routes, err := networking.GetRoutesByName("net1", netlink.FAMILY_V4)
+				Expect(err).NotTo(HaveOccurred())
+				var found []netlink.Route
+				for _, route := range routes {
+					if route.Dst != nil && route.Dst.String() == "10.6.0.5/32" {
+						found = append(found, route)
+					}
+				}
+				Expect(found).To(HaveLen(1))
+				Expect(found[0].Type).To(Equal(unix.RTN_LOCAL))
+				Expect(found[0].Scope).To(Equal(netlink.SCOPE_HOST))
+
+				_, broadcast, err := net.ParseCIDR("10.6.0.255/32")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networking.AddSpecialRoute(logger, 100, unix.RTN_BROADCAST, "net1", broadcast)).To(Succeed())
+				routes, err = netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes).To(HaveLen(1))
+				Expect(routes[0].Dst.String()).To(Equal("10.6.0.255/32"))
+				Expect(routes[0].Type).To(Equal(unix.RTN_BROADCAST))
+				Expect(routes[0].Scope).To(Equal(netlink.SCOPE_LINK))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("refuses the unsupported routes", func() {
+			_, dst, err := net.ParseCIDR("10.6.0.5/32")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(networking.AddSpecialRoute(logger, 100, unix.RTN_UNICAST, "net1", dst)).NotTo(Succeed())
+			Expect(networking.AddSpecialRoute(logger, 100, unix.RTN_LOCAL, "net1", nil)).NotTo(Succeed())
+
+			_, dst6, err := net.ParseCIDR("fd00::5/128")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(networking.AddSpecialRoute(logger, 100, unix.RTN_BROADCAST, "net1", dst6)).NotTo(Succeed())
+		})
+	})
+})
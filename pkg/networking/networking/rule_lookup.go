@@ -0,0 +1,130 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// LookupTableForSource returns the table the policy routing looks up first for
+// the unmarked packets from src, such as for debugging the asymmetric routing.
+// The rules are scanned by priority, the ones matching on the selectors unknown
+// for src, such as the destination, iif, oif or port, are skipped, and the mark
+// rules only match the unmarked packets if their mark is 0. It returns false if
+// the first matching rule isn't to a custom table, such as the main table.
+// Equivalent to: `ip rule show`
+func LookupTableForSource(src net.IP, ipFamily int) (int, bool, error) {
+	if src == nil {
+		return 0, false, fmt.Errorf("source must be specified")
+	}
+	if ipFamily != netlink.FAMILY_V4 && ipFamily != netlink.FAMILY_V6 {
+		return 0, false, fmt.Errorf("invalid ipFamily %d", ipFamily)
+	}
+	if ipFamilyOf(src) != ipFamily {
+		return 0, false, fmt.Errorf("source %s is not of ipFamily %d", src, ipFamily)
+	}
+
+	rules, err := netlink.RuleList(ipFamily)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to list rules: %w", err)
+	}
+	// the kernel lists the rules by priority, sort them anyway
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Priority < rules[j].Priority
+	})
+
+	for i := range rules {
+		rule := &rules[i]
+		if !ruleMatchesSource(rule, src) {
+			continue
+		}
+		// the local table only resolves the local addresses
+		if rule.Table == unix.RT_TABLE_LOCAL {
+			continue
+		}
+		return rule.Table, !isReservedTable(rule.Table), nil
+	}
+	return 0, false, nil
+}
+
+// ruleMatchesSource returns true if the rule looks up a table for all the
+// unmarked packets from src
+func ruleMatchesSource(rule *netlink.Rule, src net.IP) bool {
+	// the rule isn't to a table, such as the goto, blackhole and unreachable
+	// ones, or it may fall through
+	if rule.Table <= 0 || rule.Goto > 0 || rule.SuppressPrefixlen >= 0 || rule.SuppressIfgroup >= 0 {
+		return false
+	}
+	if !isAllPrefix(rule.Dst) || rule.IifName != "" || rule.OifName != "" || rule.Tos != 0 ||
+		rule.Dport != nil || rule.Sport != nil || rule.IPProto > 0 || rule.UIDRange != nil {
+		return false
+	}
+
+	mark, _ := ruleMark(rule)
+	matched := mark == 0 && (isAllPrefix(rule.Src) || rule.Src.Contains(src))
+	if rule.Invert {
+		return !matched
+	}
+	return matched
+}
+
+func isReservedTable(table int) bool {
+	return table == unix.RT_TABLE_MAIN || table == unix.RT_TABLE_DEFAULT || table == unix.RT_TABLE_LOCAL
+}
+
+// ValidateRuleTableConsistency checks that the table of the rule has a route
+// toward the destination the rule expects, which is the destination of the
+// rule, or any destination if the rule doesn't match on it, such as the
+// from-rules, so the table needs a default route. Only the unicast routes
+// count, the blackhole, unreachable and prohibit ones drop the packets. It
+// returns false with the reason if the rule is inconsistent with the table.
+func ValidateRuleTableConsistency(rule netlink.Rule) (bool, string, error) {
+	if rule.Table <= 0 || rule.Goto > 0 {
+		return false, "", fmt.Errorf("rule %s isn't to a table", rule.String())
+	}
+	ipFamily := ruleFamily(&rule)
+	if ipFamily == netlink.FAMILY_ALL {
+		return false, "", fmt.Errorf("unknown ipFamily of rule %s", rule.String())
+	}
+
+	routes, err := netlink.RouteListFiltered(ipFamily, &netlink.Route{Table: rule.Table}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to list routes of table %d: %w", rule.Table, err)
+	}
+	if len(routes) == 0 {
+		return false, fmt.Sprintf("table %d has no route", rule.Table), nil
+	}
+
+	dst := rule.Dst
+	if isAllPrefix(dst) {
+		dst = nil
+	}
+	for i := range routes {
+		route := &routes[i]
+		if route.Type != unix.RTN_UNICAST {
+			continue
+		}
+		if isDefaultRoute(route) {
+			return true, "", nil
+		}
+		if dst == nil {
+			continue
+		}
+		routeOnes, _ := route.Dst.Mask.Size()
+		dstOnes, _ := dst.Mask.Size()
+		if routeOnes <= dstOnes && route.Dst.Contains(dst.IP) {
+			return true, "", nil
+		}
+	}
+
+	if dst == nil {
+		return false, fmt.Sprintf("table %d has no default route", rule.Table), nil
+	}
+	return false, fmt.Sprintf("table %d has no route to %s", rule.Table, dst.String()), nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"net"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+var _ = Describe("LookupTableForSource", Label("rule_lookup"), func() {
+	var testNetNS ns.NetNS
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+
+		err = testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			_, src, _ := net.ParseCIDR("10.6.0.0/24")
+			Expect(networking.AddFromRuleTable(src, 100, networking.WithRulePriority(2000))).To(Succeed())
+			// the mark rule doesn't match the unmarked packets
+			Expect(networking.AddRuleTableWithMark(0x1, 500, netlink.FAMILY_V4, networking.DefaultMarkRulePriority)).To(Succeed())
+
+			_, src6, _ := net.ParseCIDR("fd00::/64")
+			Expect(networking.AddFromRuleTable(src6, 101)).To(Succeed())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("resolves the source to the table",
+		func(src string, ipFamily, expectedTable int, expectedFound bool) {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				table, found, err := networking.LookupTableForSource(net.ParseIP(src), ipFamily)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(table).To(Equal(expectedTable))
+				Expect(found).To(Equal(expectedFound))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		},
+		Entry("IPv4 source of the from-rule", "10.6.0.2", netlink.FAMILY_V4, 100, true),
+		Entry("IPv4 source out of the from-rule", "10.7.0.2", netlink.FAMILY_V4, unix.RT_TABLE_MAIN, false),
+		Entry("IPv6 source of the from-rule", "fd00::2", netlink.FAMILY_V6, 101, true),
+		Entry("IPv6 source out of the from-rule", "fd01::2", netlink.FAMILY_V6, unix.RT_TABLE_MAIN, false),
+	)
+
+	It("fails with the mismatched ipFamily", func() {
+		_, _, err := networking.LookupTableForSource(net.ParseIP("10.6.0.2"), netlink.FAMILY_V6)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("ValidateRuleTableConsistency", Label("rule_lookup"), func() {
+	var testNetNS ns.NetNS
+
+	newRule := func(src, dst string, table int) netlink.Rule {
+		rule := netlink.NewRule()
+		rule.Family = netlink.FAMILY_V4
+		rule.Table = table
+		if src != "" {
+			rule.Src = mustParseCIDR(src)
+		}
+		if dst != "" {
+			rule.Dst = mustParseCIDR(dst)
+		}
+		return *rule
+	}
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+
+		err = testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			Expect(netlink.LinkAdd(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+				PeerName:  "net1-peer",
+			})).To(Succeed())
+			for _, name := range []string{"net1", "net1-peer"} {
+				link, err := netlink.LinkByName(name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.LinkSetUp(link)).To(Succeed())
+			}
+			link, err := netlink.LinkByName("net1")
+			Expect(err).NotTo(HaveOccurred())
+			addr, err := netlink.ParseAddr("10.6.0.2/24")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.AddrAdd(link, addr)).To(Succeed())
+
+			logger := zap.NewNop()
+			// table 100 has the default route, table 101 only the route to 172.16.0.0/16
+			Expect(networking.AddRoute(logger, 100, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1",
+				nil, net.ParseIP("10.6.0.1"), nil)).To(Succeed())
+			Expect(networking.AddRoute(logger, 101, netlink.FAMILY_V4, netlink.SCOPE_UNIVERSE, "net1",
+				mustParseCIDR("172.16.0.0/16"), net.ParseIP("10.6.0.1"), nil)).To(Succeed())
+			// the blackhole route drops the packets
+			Expect(netlink.RouteAdd(&netlink.Route{
+				Dst:   mustParseCIDR("0.0.0.0/0"),
+				Table: 103,
+				Type:  unix.RTN_BLACKHOLE,
+			})).To(Succeed())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("checks the table of the rule has the route",
+		func(rule func() netlink.Rule, expected bool, reason string) {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				consistent, gotReason, err := networking.ValidateRuleTableConsistency(rule())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(consistent).To(Equal(expected))
+				Expect(gotReason).To(Equal(reason))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		},
+		Entry("from-rule to the table with the default route", func() netlink.Rule {
+			return newRule("10.6.0.2/32", "", 100)
+		}, true, ""),
+		Entry("from-rule to the table without the default route", func() netlink.Rule {
+			return newRule("10.6.0.2/32", "", 101)
+		}, false, "table 101 has no default route"),
+		Entry("to-rule within the route of the table", func() netlink.Rule {
+			return newRule("", "172.16.1.0/24", 101)
+		}, true, ""),
+		Entry("to-rule out of the route of the table", func() netlink.Rule {
+			return newRule("", "172.17.0.0/16", 101)
+		}, false, "table 101 has no route to 172.17.0.0/16"),
+		Entry("to-rule wider than the route of the table", func() netlink.Rule {
+			return newRule("", "172.0.0.0/8", 101)
+		}, false, "table 101 has no route to 172.0.0.0/8"),
+		Entry("rule to the empty table", func() netlink.Rule {
+			return newRule("10.6.0.2/32", "", 102)
+		}, false, "table 102 has no route"),
+		Entry("rule to the table with the blackhole route", func() netlink.Rule {
+			return newRule("10.6.0.2/32", "", 103)
+		}, false, "table 103 has no default route"),
+	)
+
+	It("fails with the rule not to a table", func() {
+		rule := newRule("10.6.0.2/32", "", 0)
+		_, _, err := networking.ValidateRuleTableConsistency(rule)
+		Expect(err).To(HaveOccurred())
+	})
+})
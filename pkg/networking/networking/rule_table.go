@@ -0,0 +1,185 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/spidernet-io/spiderpool/pkg/lock"
+)
+
+// RouteProtocolSpiderpool tags the routes added by spiderpool, so that they can
+// be told apart from the routes managed by others
+const RouteProtocolSpiderpool netlink.RouteProtocol = 200
+
+const (
+	// the rule table of eth0 is podRuleTableBase, and the one of netN is podRuleTableBase + N
+	podRuleTableBase = 100
+	// keep the tables of netN below the reserved tables 253(default), 254(main) and 255(local)
+	podRuleTableMaxIndex = 149
+	// the tables of the other interfaces are hashed into [podRuleTableHashBase, podRuleTableHashBase + podRuleTableHashSize)
+	podRuleTableHashBase = 1000
+	podRuleTableHashSize = 1000
+	// the tables allocated by AllocateRuleTable are in [allocRuleTableBase, allocRuleTableBase + allocRuleTableSize),
+	// right above the hashed tables
+	allocRuleTableBase = podRuleTableHashBase + podRuleTableHashSize
+	allocRuleTableSize = 1000
+)
+
+var (
+	allocRuleTablesMutex lock.Mutex
+	// allocRuleTables are the tables allocated but not yet released, which may
+	// not be populated in the kernel yet
+	allocRuleTables = map[int]struct{}{}
+)
+
+// GetRuleNumber returns the rule table of the pod's interface, it is a pure
+// function of the interface name:
+//
+//	eth0              -> 100
+//	net1 ... net149   -> 101 ... 249
+//	any other name    -> 1000 ... 1999, hashed by the name
+func GetRuleNumber(ifaceName string) (int, error) {
+	if ifaceName == "" {
+		return -1, fmt.Errorf("interface name must be specified")
+	}
+
+	if ifaceName == "eth0" {
+		return podRuleTableBase, nil
+	}
+
+	if suffix, ok := strings.CutPrefix(ifaceName, "net"); ok {
+		// reject the names like net01 or net+1, which would collide with net1
+		index, err := strconv.Atoi(suffix)
+		if err == nil && index > 0 && index <= podRuleTableMaxIndex && strconv.Itoa(index) == suffix {
+			return podRuleTableBase + index, nil
+		}
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(ifaceName))
+	return podRuleTableHashBase + int(h.Sum32()%podRuleTableHashSize), nil
+}
+
+// ValidateRuleTable returns error if the table can't be used by the users, such
+// as the hostRuleTable, it must not be one of the reserved tables 0(unspec),
+// 253(default), 254(main) and 255(local), nor in the ranges of the tables of the
+// pod's interfaces derived by GetRuleNumber.
+func ValidateRuleTable(table int) error {
+	switch {
+	case table <= 0 || table > math.MaxUint32:
+		return fmt.Errorf("rule table %d is out of range [1, %d]", table, uint32(math.MaxUint32))
+	case table >= 253 && table <= 255:
+		return fmt.Errorf("rule table %d is reserved by the kernel", table)
+	case table >= podRuleTableBase && table <= podRuleTableBase+podRuleTableMaxIndex:
+		return fmt.Errorf("rule table %d collides with the tables [%d, %d] of the pod's interfaces",
+			table, podRuleTableBase, podRuleTableBase+podRuleTableMaxIndex)
+	case table >= podRuleTableHashBase && table < podRuleTableHashBase+podRuleTableHashSize:
+		return fmt.Errorf("rule table %d collides with the tables [%d, %d] of the pod's interfaces",
+			table, podRuleTableHashBase, podRuleTableHashBase+podRuleTableHashSize-1)
+	}
+	return nil
+}
+
+// EnsureRuleTableAvailable returns error if the rule table is already populated
+// by the routes not added by spiderpool, which are detected by the protocol tag
+func EnsureRuleTableAvailable(ruleTable, ipFamily int) error {
+	routes, err := netlink.RouteListFiltered(ipFamily, &netlink.Route{Table: ruleTable}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return fmt.Errorf("failed to list routes of table %d: %w", ruleTable, err)
+	}
+
+	for _, route := range routes {
+		if route.Protocol != RouteProtocolSpiderpool {
+			return fmt.Errorf("rule table %d collides with the route %s not managed by spiderpool", ruleTable, route.String())
+		}
+	}
+	return nil
+}
+
+// UsedRuleTables returns the tables referenced by the rules or populated by the
+// routes of the current netns, in ascending order.
+// Equivalent to: `ip rule show` and `ip route show table all`
+func UsedRuleTables() ([]int, error) {
+	used := map[int]struct{}{}
+
+	rules, err := netlink.RuleList(netlink.FAMILY_ALL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules: %w", err)
+	}
+	for _, rule := range rules {
+		if rule.Table > 0 {
+			used[rule.Table] = struct{}{}
+		}
+	}
+
+	routes, err := netlink.RouteListFiltered(netlink.FAMILY_ALL, &netlink.Route{Table: 0}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routes of all the tables: %w", err)
+	}
+	for _, route := range routes {
+		if route.Table > 0 {
+			used[route.Table] = struct{}{}
+		}
+	}
+
+	tables := make([]int, 0, len(used))
+	for table := range used {
+		tables = append(tables, table)
+	}
+	sort.Ints(tables)
+	return tables, nil
+}
+
+// AllocateRuleTable picks a free table, neither in UsedRuleTables of the
+// current netns, nor in reserved, nor allocated by the other callers, from the
+// range above the tables of the pod's interfaces. The table stays allocated
+// until the returned release is called, which is idempotent. It's safe for
+// concurrent use.
+func AllocateRuleTable(reserved []int) (int, func(), error) {
+	allocRuleTablesMutex.Lock()
+	defer allocRuleTablesMutex.Unlock()
+
+	used, err := UsedRuleTables()
+	if err != nil {
+		return -1, nil, err
+	}
+
+	taken := make(map[int]struct{}, len(used)+len(reserved)+len(allocRuleTables))
+	for _, table := range used {
+		taken[table] = struct{}{}
+	}
+	for _, table := range reserved {
+		taken[table] = struct{}{}
+	}
+	for table := range allocRuleTables {
+		taken[table] = struct{}{}
+	}
+
+	for table := allocRuleTableBase; table < allocRuleTableBase+allocRuleTableSize; table++ {
+		if _, ok := taken[table]; ok {
+			continue
+		}
+
+		allocRuleTables[table] = struct{}{}
+		var once sync.Once
+		release := func() {
+			once.Do(func() {
+				allocRuleTablesMutex.Lock()
+				delete(allocRuleTables, table)
+				allocRuleTablesMutex.Unlock()
+			})
+		}
+		return table, release, nil
+	}
+	return -1, nil, fmt.Errorf("no free rule table in [%d, %d]", allocRuleTableBase, allocRuleTableBase+allocRuleTableSize-1)
+}
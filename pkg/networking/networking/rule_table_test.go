@@ -0,0 +1,217 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"math"
+	"net"
+	"sync"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+var _ = Describe("RuleTable", Label("rule_table"), func() {
+	DescribeTable("GetRuleNumber derives the rule table from the interface name",
+		func(iface string, expected int) {
+			ruleTable, err := networking.GetRuleNumber(iface)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ruleTable).To(Equal(expected))
+
+			// it is a pure function of the name
+			again, err := networking.GetRuleNumber(iface)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(again).To(Equal(ruleTable))
+		},
+		Entry("eth0", "eth0", 100),
+		Entry("net1", "net1", 101),
+		Entry("net2", "net2", 102),
+		Entry("net3", "net3", 103),
+		Entry("net4", "net4", 104),
+		Entry("net5", "net5", 105),
+		Entry("net6", "net6", 106),
+		Entry("net7", "net7", 107),
+		Entry("net8", "net8", 108),
+		Entry("net9", "net9", 109),
+		Entry("net149", "net149", 249),
+		Entry("net150 is hashed to keep clear of the reserved tables", "net150", 1926),
+		Entry("net01 is hashed to not collide with net1", "net01", 1227),
+		Entry("net0", "net0", 1272),
+		Entry("ens1f0.100", "ens1f0.100", 1025),
+	)
+
+	DescribeTable("ValidateRuleTable rejects the reserved tables",
+		func(table int, valid bool) {
+			err := networking.ValidateRuleTable(table)
+			if valid {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(HaveOccurred())
+			}
+		},
+		Entry("the default hostRuleTable", 500, true),
+		Entry("below the tables of the pod's interfaces", 99, true),
+		Entry("between the tables of the pod's interfaces and the reserved ones", 250, true),
+		Entry("above the hashed tables", 2000, true),
+		Entry("0 is unspec", 0, false),
+		Entry("negative", -1, false),
+		Entry("default", 253, false),
+		Entry("main", 254, false),
+		Entry("local", 255, false),
+		Entry("the table of eth0", 100, false),
+		Entry("the table of net149", 249, false),
+		Entry("the hashed table", 1999, false),
+		Entry("beyond uint32", math.MaxUint32+1, false),
+	)
+
+	It("GetRuleNumber fails with empty interface name", func() {
+		_, err := networking.GetRuleNumber("")
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("EnsureRuleTableAvailable", func() {
+		var testNetNS ns.NetNS
+
+		BeforeEach(func() {
+			var err error
+			testNetNS, err = testutils.NewNS()
+			Expect(err).NotTo(HaveOccurred())
+
+			DeferCleanup(func() {
+				Expect(testNetNS.Close()).To(Succeed())
+				Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+			})
+		})
+
+		It("fails only if the table is populated by the routes not added by spiderpool", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+				for _, name := range []string{"net1", "peer12345"} {
+					link, err := netlink.LinkByName(name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(netlink.LinkSetUp(link)).To(Succeed())
+				}
+				link, err := netlink.LinkByName("net1")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(networking.EnsureRuleTableAvailable(101, netlink.FAMILY_V4)).To(Succeed())
+
+				_, dst, err := net.ParseCIDR("172.16.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				err = networking.AddRoute(zap.NewNop(), 101, netlink.FAMILY_V4, netlink.SCOPE_LINK, "net1", dst, nil, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networking.EnsureRuleTableAvailable(101, netlink.FAMILY_V4)).To(Succeed())
+
+				_, dst, err = net.ParseCIDR("172.17.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.RouteAdd(&netlink.Route{
+					LinkIndex: link.Attrs().Index,
+					Dst:       dst,
+					Scope:     netlink.SCOPE_LINK,
+					Table:     101,
+				})).To(Succeed())
+				Expect(networking.EnsureRuleTableAvailable(101, netlink.FAMILY_V4)).NotTo(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("AllocateRuleTable", func() {
+		var testNetNS ns.NetNS
+
+		BeforeEach(func() {
+			var err error
+			testNetNS, err = testutils.NewNS()
+			Expect(err).NotTo(HaveOccurred())
+
+			DeferCleanup(func() {
+				Expect(testNetNS.Close()).To(Succeed())
+				Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+			})
+
+			err = testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				rule := netlink.NewRule()
+				rule.Mark = 0x1
+				rule.Table = 2000
+				Expect(netlink.RuleAdd(rule)).To(Succeed())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("doesn't hand out the same table to the concurrent callers", func() {
+			const workers = 50
+			tables := make([]int, workers)
+			releases := make([]func(), workers)
+
+			var wg sync.WaitGroup
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					err := testNetNS.Do(func(_ ns.NetNS) error {
+						var err error
+						tables[i], releases[i], err = networking.AllocateRuleTable([]int{2001})
+						return err
+					})
+					Expect(err).NotTo(HaveOccurred())
+				}(i)
+			}
+			wg.Wait()
+
+			seen := map[int]struct{}{}
+			for _, table := range tables {
+				Expect(seen).NotTo(HaveKey(table))
+				seen[table] = struct{}{}
+				// the table used by the rule and the reserved one are skipped
+				Expect(table).To(And(BeNumerically(">", 2001), BeNumerically("<", 3000)))
+				Expect(networking.ValidateRuleTable(table)).To(Succeed())
+			}
+
+			// the released table is free again, release is idempotent
+			releases[0]()
+			releases[0]()
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				table, release, err := networking.AllocateRuleTable([]int{2001})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(table).To(Equal(tables[0]))
+				release()
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, release := range releases {
+				release()
+			}
+		})
+
+		It("lists the tables of the rules and the routes", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				tables, err := networking.UsedRuleTables()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tables).To(ContainElements(2000, 253, 254, 255))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
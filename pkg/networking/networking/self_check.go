@@ -0,0 +1,167 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// the types of the self-check conditions
+const (
+	SelfCheckPolicyRouting = "PolicyRouting"
+	SelfCheckFwmarkRule    = "FwmarkRule"
+	SelfCheckIPv6Routing   = "IPv6Routing"
+)
+
+const (
+	// selfCheckRulePriority is below the rule of the main table (32766), so the
+	// probe rules are looked up after the rules of spiderpool and others
+	selfCheckRulePriority = 32700
+	selfCheckMark         = 0x53504b
+)
+
+var (
+	// the probe addresses are taken from the benchmarking ranges (RFC 2544 and
+	// RFC 5180), which never show up in the real traffic
+	selfCheckIPv4Src = &net.IPNet{IP: net.ParseIP("198.18.0.1").To4(), Mask: net.CIDRMask(32, 32)}
+	selfCheckIPv6Src = &net.IPNet{IP: net.ParseIP("2001:2::1"), Mask: net.CIDRMask(128, 128)}
+)
+
+// SelfCheckCondition is the result of a self-check, Op names the netlink
+// operation failed if it is not ready
+type SelfCheckCondition struct {
+	Type  string
+	Ready bool
+	Op    string
+	Err   error
+}
+
+func (c SelfCheckCondition) String() string {
+	if c.Ready {
+		return fmt.Sprintf("%s: ready", c.Type)
+	}
+	return fmt.Sprintf("%s: failed to %s: %v", c.Type, c.Op, c.Err)
+}
+
+// SelfCheck verifies that policy routing, fwmark rules and, if enableIPv6,
+// IPv6 routing work in the current netns, by adding and removing probe rules
+// and blackhole routes in the scratchTable. It is used to find out the hosts
+// missing CAP_NET_ADMIN or the kernel features before any pod is created. The
+// probes are cleaned up even on partial failure, the error of the cleanup is
+// reported by the condition as well.
+func SelfCheck(scratchTable int, enableIPv6 bool) []SelfCheckCondition {
+	conditions := []SelfCheckCondition{
+		probeSourceRouting(SelfCheckPolicyRouting, scratchTable, netlink.FAMILY_V4, selfCheckIPv4Src),
+		probeFwmarkRule(scratchTable),
+	}
+	if enableIPv6 {
+		conditions = append(conditions, probeSourceRouting(SelfCheckIPv6Routing, scratchTable, netlink.FAMILY_V6, selfCheckIPv6Src))
+	}
+	return conditions
+}
+
+// probeSourceRouting adds the blackhole route of src to the table, and the
+// rule making src lookup the table
+func probeSourceRouting(conditionType string, table, ipFamily int, src *net.IPNet) (condition SelfCheckCondition) {
+	condition = SelfCheckCondition{Type: conditionType, Ready: true}
+	fail := func(op string, err error) {
+		if condition.Ready {
+			condition.Ready = false
+			condition.Op = op
+			condition.Err = err
+		}
+	}
+
+	route := &netlink.Route{
+		Dst:      src,
+		Table:    table,
+		Type:     unix.RTN_BLACKHOLE,
+		Protocol: RouteProtocolSpiderpool,
+	}
+	op := fmt.Sprintf("add route 'blackhole %s table %d'", src, table)
+	if err := netlink.RouteAdd(route); err != nil && !os.IsExist(err) {
+		fail(op, err)
+		return condition
+	}
+	defer func() {
+		op := fmt.Sprintf("delete route 'blackhole %s table %d'", src, table)
+		// match the probe left by the previous check regardless of the protocol
+		probe := &netlink.Route{Dst: src, Table: table, Type: unix.RTN_BLACKHOLE}
+		if err := netlink.RouteDel(probe); err != nil && !os.IsNotExist(err) && !errors.Is(err, unix.ESRCH) {
+			fail(op, err)
+		}
+	}()
+
+	op = fmt.Sprintf("list routes of table %d", table)
+	routes, err := netlink.RouteListFiltered(ipFamily, &netlink.Route{Table: table, Dst: src}, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST)
+	if err != nil {
+		fail(op, err)
+		return condition
+	}
+	if len(routes) == 0 {
+		fail(op, fmt.Errorf("the route to %s is not found in table %d", src, table))
+		return condition
+	}
+
+	rule := netlink.NewRule()
+	rule.Family = ipFamily
+	rule.Src = src
+	rule.Table = table
+	rule.Priority = selfCheckRulePriority
+	probeRule(rule, fail)
+	return condition
+}
+
+// probeFwmarkRule adds the rule making the marked traffic lookup the table
+func probeFwmarkRule(table int) (condition SelfCheckCondition) {
+	condition = SelfCheckCondition{Type: SelfCheckFwmarkRule, Ready: true}
+	fail := func(op string, err error) {
+		if condition.Ready {
+			condition.Ready = false
+			condition.Op = op
+			condition.Err = err
+		}
+	}
+
+	rule := netlink.NewRule()
+	rule.Family = netlink.FAMILY_V4
+	rule.Mark = selfCheckMark
+	rule.Table = table
+	rule.Priority = selfCheckRulePriority
+	probeRule(rule, fail)
+	return condition
+}
+
+// probeRule adds the rule, checks it is listed and deletes it, the first
+// failing operation is passed to fail
+func probeRule(rule *netlink.Rule, fail func(op string, err error)) {
+	if err := netlink.RuleAdd(rule); err != nil && !os.IsExist(err) {
+		fail(fmt.Sprintf("add rule '%s'", formatRule(rule)), err)
+		return
+	}
+	defer func() {
+		if err := netlink.RuleDel(rule); err != nil && !os.IsNotExist(err) {
+			fail(fmt.Sprintf("delete rule '%s'", formatRule(rule)), err)
+		}
+	}()
+
+	rules, err := netlink.RuleListFiltered(rule.Family, &netlink.Rule{Table: rule.Table}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		fail(fmt.Sprintf("list rules of table %d", rule.Table), err)
+		return
+	}
+	for idx := range rules {
+		if rules[idx].Priority == rule.Priority && rules[idx].Mark == rule.Mark &&
+			rules[idx].Src.String() == rule.Src.String() {
+			return
+		}
+	}
+	fail(fmt.Sprintf("list rules of table %d", rule.Table), fmt.Errorf("rule '%s' is not found", formatRule(rule)))
+}
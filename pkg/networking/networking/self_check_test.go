@@ -0,0 +1,98 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package networking_test
+
+import (
+	"net"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+var _ = Describe("SelfCheck", Label("self_check"), func() {
+	var testNetNS ns.NetNS
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+	})
+
+	const scratchTable = 65000
+
+	expectScratchTableClean := func() {
+		for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+			routes, err := netlink.RouteListFiltered(family, &netlink.Route{Table: scratchTable}, netlink.RT_FILTER_TABLE)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes).To(BeEmpty())
+
+			rules, err := netlink.RuleListFiltered(family, &netlink.Rule{Table: scratchTable}, netlink.RT_FILTER_TABLE)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rules).To(BeEmpty())
+		}
+	}
+
+	It("reports all the conditions ready and removes the probes", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			conditions := networking.SelfCheck(scratchTable, true)
+			var types []string
+			for _, condition := range conditions {
+				Expect(condition.Ready).To(BeTrue(), condition.String())
+				types = append(types, condition.Type)
+			}
+			Expect(types).To(ConsistOf(networking.SelfCheckPolicyRouting, networking.SelfCheckFwmarkRule, networking.SelfCheckIPv6Routing))
+
+			expectScratchTableClean()
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("skips IPv6 routing if IPv6 is disabled", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			conditions := networking.SelfCheck(scratchTable, false)
+			for _, condition := range conditions {
+				Expect(condition.Type).NotTo(Equal(networking.SelfCheckIPv6Routing))
+			}
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("removes the probes left by the previous check", func() {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			defer GinkgoRecover()
+
+			_, leftover, err := net.ParseCIDR("198.18.0.1/32")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.RouteAdd(&netlink.Route{
+				Dst:   leftover,
+				Table: scratchTable,
+				Type:  unix.RTN_BLACKHOLE,
+			})).To(Succeed())
+
+			for _, condition := range networking.SelfCheck(scratchTable, false) {
+				Expect(condition.Ready).To(BeTrue(), condition.String())
+			}
+			expectScratchTableClean()
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,528 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package routewatcher
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"github.com/vishvananda/netns"
+	"go.opentelemetry.io/otel/attribute"
+	otelapi "go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/lock"
+	"github.com/spidernet-io/spiderpool/pkg/logutils"
+	"github.com/spidernet-io/spiderpool/pkg/metric"
+	"github.com/spidernet-io/spiderpool/pkg/networking/networking"
+)
+
+const (
+	repairTypeRoute = "route"
+	repairTypeRule  = "rule"
+
+	// defaultRulePriority is the priority of the rules to the hostRuleTable
+	// installed by coordinator
+	defaultRulePriority = 1000
+)
+
+// RouteWatcher watches the routes and rules spiderpool installs on the node for
+// the pods, and re-installs them when they are deleted by other daemons, such
+// as dhclient or NetworkManager.
+type RouteWatcher interface {
+	Start(ctx context.Context) error
+}
+
+type RouteWatcherConfig struct {
+	// Tables are the route tables managed by spiderpool, such as the
+	// hostRuleTables of coordinator
+	Tables []int
+	// RulePriority is the priority of the rules to lookup the tables, which
+	// are installed by coordinator as `ip rule add from all lookup <table>`
+	RulePriority int
+	// ResyncPeriod is the interval of the full resync
+	ResyncPeriod time.Duration
+	// RepairQPS and RepairBurst limit the rate of the repair actions
+	RepairQPS   float32
+	RepairBurst int
+	// UpdateBufferSize is the size of the route update channel
+	UpdateBufferSize int
+}
+
+// DesiredFunc returns the IPs of the pods on the node whose routes and rules
+// are desired, as recorded in the SpiderEndpoints. The IPs of the pods which
+// are terminating or in CNI DEL must be left out.
+type DesiredFunc func(ctx context.Context) ([]net.IP, error)
+
+type routeWatcher struct {
+	config  RouteWatcherConfig
+	desired DesiredFunc
+	limiter flowcontrol.RateLimiter
+
+	// the watcher works in the netns where it is created
+	nsHandle netns.NsHandle
+	handle   *netlink.Handle
+
+	lock lock.Mutex
+	// hostLinks records the host veths of the routes to the pods, which are
+	// created by coordinator along with the routes, keyed by routeKey
+	hostLinks map[string]hostRoute
+}
+
+// hostRoute is the route to a pod's IP via its host veth
+type hostRoute struct {
+	dst       *net.IPNet
+	table     int
+	priority  int
+	linkIndex int
+}
+
+// NewRouteWatcher creates a RouteWatcher working in the current netns.
+func NewRouteWatcher(config RouteWatcherConfig, desired DesiredFunc) (RouteWatcher, error) {
+	if len(config.Tables) == 0 {
+		return nil, fmt.Errorf("route tables %w", constant.ErrMissingRequiredParam)
+	}
+	if desired == nil {
+		return nil, fmt.Errorf("desired func %w", constant.ErrMissingRequiredParam)
+	}
+
+	if config.RulePriority <= 0 {
+		config.RulePriority = defaultRulePriority
+	}
+	if config.ResyncPeriod <= 0 {
+		config.ResyncPeriod = time.Minute
+	}
+	if config.RepairQPS <= 0 {
+		config.RepairQPS = 1
+	}
+	if config.RepairBurst <= 0 {
+		config.RepairBurst = 5
+	}
+	if config.UpdateBufferSize <= 0 {
+		config.UpdateBufferSize = 128
+	}
+
+	nsHandle, err := netns.Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current netns: %w", err)
+	}
+	handle, err := netlink.NewHandleAt(nsHandle)
+	if err != nil {
+		nsHandle.Close()
+		return nil, fmt.Errorf("failed to create netlink handle: %w", err)
+	}
+
+	return &routeWatcher{
+		config:    config,
+		desired:   desired,
+		limiter:   flowcontrol.NewTokenBucketRateLimiter(config.RepairQPS, config.RepairBurst),
+		nsHandle:  nsHandle,
+		handle:    handle,
+		hostLinks: map[string]hostRoute{},
+	}, nil
+}
+
+// Start learns the host veths of the current routes to the pods, and then
+// watches the routes and rules in the background until the ctx is done.
+func (w *routeWatcher) Start(ctx context.Context) error {
+	// subscribe before the resync, so that no update is missed
+	sub, err := w.subscribe(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe route updates: %w", err)
+	}
+
+	if err := w.resync(ctx); err != nil {
+		close(sub.done)
+		return fmt.Errorf("failed to resync the routes of tables %v: %w", w.config.Tables, err)
+	}
+
+	go w.run(ctx, sub)
+	return nil
+}
+
+type subscription struct {
+	updates chan netlink.RouteUpdate
+	// deleted is signaled when a route or a rule spiderpool installs is
+	// deleted, the signals are merged into one resync
+	deleted chan struct{}
+	broken  chan struct{}
+	done    chan struct{}
+}
+
+func (w *routeWatcher) subscribe(ctx context.Context) (*subscription, error) {
+	logger := logutils.FromContext(ctx)
+
+	sub := &subscription{
+		updates: make(chan netlink.RouteUpdate, w.config.UpdateBufferSize),
+		deleted: make(chan struct{}, 1),
+		broken:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	setBroken := func(err error) {
+		if ctx.Err() == nil {
+			logger.Warn("route subscription is broken", zap.Error(err))
+		}
+		select {
+		case sub.broken <- struct{}{}:
+		default:
+		}
+	}
+
+	err := netlink.RouteSubscribeWithOptions(sub.updates, sub.done, netlink.RouteSubscribeOptions{
+		Namespace: &w.nsHandle,
+		// the subscription is closed after any receive error, such as
+		// ENOBUFS when the route updates overflow the buffer
+		ErrorCallback: setBroken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.subscribeRules(sub, setBroken); err != nil {
+		close(sub.done)
+		return nil, fmt.Errorf("failed to subscribe rule updates: %w", err)
+	}
+	return sub, nil
+}
+
+// subscribeRules signals sub.deleted when a rule of the managed tables is
+// deleted. The netlink library doesn't parse the rule updates, so the deleted
+// rules are repaired by a resync rather than one by one.
+func (w *routeWatcher) subscribeRules(sub *subscription, cberr func(error)) error {
+	s, err := nl.SubscribeAt(w.nsHandle, netns.None(), unix.NETLINK_ROUTE, unix.RTNLGRP_IPV4_RULE, unix.RTNLGRP_IPV6_RULE)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-sub.done
+		s.Close()
+	}()
+
+	go func() {
+		for {
+			msgs, from, err := s.Receive()
+			if err != nil {
+				cberr(fmt.Errorf("failed to receive rule updates: %w", err))
+				return
+			}
+			if from.Pid != nl.PidKernel {
+				continue
+			}
+			for _, m := range msgs {
+				if m.Header.Type != unix.RTM_DELRULE || !w.isManagedTable(ruleUpdateTable(m.Data)) {
+					continue
+				}
+				sub.signalDeleted()
+			}
+		}
+	}()
+	return nil
+}
+
+func (sub *subscription) signalDeleted() {
+	select {
+	case sub.deleted <- struct{}{}:
+	default:
+	}
+}
+
+// ruleUpdateTable returns the table of the rule update, whose header shares
+// the layout of rtmsg, the table beyond 255 is carried by FRA_TABLE.
+func ruleUpdateTable(data []byte) int {
+	if len(data) < unix.SizeofRtMsg {
+		return -1
+	}
+	table := int(nl.DeserializeRtMsg(data).Table)
+	attrs, err := nl.ParseRouteAttr(data[unix.SizeofRtMsg:])
+	if err != nil {
+		return table
+	}
+	for _, attr := range attrs {
+		if attr.Attr.Type == unix.FRA_TABLE && len(attr.Value) >= 4 {
+			table = int(nl.NativeEndian().Uint32(attr.Value[0:4]))
+		}
+	}
+	return table
+}
+
+func (w *routeWatcher) run(ctx context.Context, sub *subscription) {
+	logger := logutils.FromContext(ctx)
+
+	ticker := time.NewTicker(w.config.ResyncPeriod)
+	defer ticker.Stop()
+	defer w.handle.Delete()
+	defer w.nsHandle.Close()
+
+	for {
+		w.watch(ctx, sub, ticker.C)
+		close(sub.done)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+
+			var err error
+			sub, err = w.subscribe(ctx)
+			if err == nil {
+				break
+			}
+			logger.Error("failed to subscribe route updates", zap.Error(err))
+		}
+
+		// the updates may be lost while the subscription is broken
+		if err := w.resync(ctx); err != nil {
+			logger.Error("failed to resync route tables", zap.Error(err))
+		}
+	}
+}
+
+func (w *routeWatcher) watch(ctx context.Context, sub *subscription, resync <-chan time.Time) {
+	logger := logutils.FromContext(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.broken:
+			return
+		case <-resync:
+			if err := w.resync(ctx); err != nil {
+				logger.Error("failed to resync route tables", zap.Error(err))
+			}
+		case <-sub.deleted:
+			if err := w.resync(ctx); err != nil {
+				logger.Error("failed to resync route tables", zap.Error(err))
+			}
+		case update, ok := <-sub.updates:
+			if !ok {
+				return
+			}
+			w.handleRouteUpdate(sub, &update)
+		}
+	}
+}
+
+func (w *routeWatcher) handleRouteUpdate(sub *subscription, update *netlink.RouteUpdate) {
+	if !w.isPodRoute(&update.Route) {
+		return
+	}
+
+	switch update.Type {
+	case unix.RTM_NEWROUTE:
+		w.learn(&update.Route)
+	case unix.RTM_DELROUTE:
+		sub.signalDeleted()
+	}
+}
+
+// resync learns the host veths of the current routes to the pods, and then
+// repairs the missing routes and rules desired by the SpiderEndpoints.
+func (w *routeWatcher) resync(ctx context.Context) error {
+	// the routes tagged by spiderpool may be out of the managed tables
+	routes, err := w.handle.RouteListFiltered(netlink.FAMILY_ALL, &netlink.Route{Table: unix.RT_TABLE_UNSPEC}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return err
+	}
+	currentRoutes := map[string]struct{}{}
+	for idx := range routes {
+		if !w.isPodRoute(&routes[idx]) {
+			continue
+		}
+		w.learn(&routes[idx])
+		currentRoutes[routeKey(routes[idx].Table, routes[idx].Dst, routes[idx].Priority)] = struct{}{}
+	}
+
+	currentRules := map[string]struct{}{}
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		rules, err := w.handle.RuleList(family)
+		if err != nil {
+			return err
+		}
+		for idx := range rules {
+			if !w.isManagedTable(rules[idx].Table) || !isTableRule(&rules[idx]) {
+				continue
+			}
+			currentRules[ruleKey(family, rules[idx].Table, rules[idx].Priority)] = struct{}{}
+		}
+	}
+
+	ips, err := w.desired(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the desired IPs: %w", err)
+	}
+	desiredRoutes, desiredRules := w.desiredState(ips)
+
+	for key, route := range desiredRoutes {
+		if _, ok := currentRoutes[key]; !ok {
+			w.repairRoute(ctx, route)
+		}
+	}
+	for key, rule := range desiredRules {
+		if _, ok := currentRules[key]; !ok {
+			w.repairRule(ctx, rule)
+		}
+	}
+	return nil
+}
+
+// desiredState builds the routes to the desired IPs via their host veths, and
+// the rules to lookup the managed tables having these routes. The host veths
+// are removed by coordinator on CNI DEL, so are the routes via them.
+func (w *routeWatcher) desiredState(ips []net.IP) (map[string]*netlink.Route, map[string]*netlink.Rule) {
+	desiredIPs := map[string]struct{}{}
+	for _, ip := range ips {
+		desiredIPs[ip.String()] = struct{}{}
+	}
+
+	w.lock.Lock()
+	hostLinks := make(map[string]hostRoute, len(w.hostLinks))
+	for key, r := range w.hostLinks {
+		hostLinks[key] = r
+	}
+	w.lock.Unlock()
+
+	routes := map[string]*netlink.Route{}
+	rules := map[string]*netlink.Rule{}
+	for key, r := range hostLinks {
+		if _, err := w.handle.LinkByIndex(r.linkIndex); err != nil {
+			// the host veth is gone along with the pod
+			w.forget(key, r.linkIndex)
+			continue
+		}
+		if _, ok := desiredIPs[r.dst.IP.String()]; !ok {
+			continue
+		}
+
+		family := netlink.FAMILY_V4
+		if r.dst.IP.To4() == nil {
+			family = netlink.FAMILY_V6
+		}
+		routes[key] = &netlink.Route{
+			LinkIndex: r.linkIndex,
+			Dst:       r.dst,
+			Scope:     netlink.SCOPE_LINK,
+			Table:     r.table,
+			Priority:  r.priority,
+			Protocol:  networking.RouteProtocolSpiderpool,
+		}
+
+		if !w.isManagedTable(r.table) {
+			continue
+		}
+		rule := netlink.NewRule()
+		rule.Family = family
+		rule.Table = r.table
+		rule.Priority = w.config.RulePriority
+		rules[ruleKey(family, rule.Table, rule.Priority)] = rule
+	}
+	return routes, rules
+}
+
+// learn records the host veth of the route to a pod
+func (w *routeWatcher) learn(route *netlink.Route) {
+	key := routeKey(route.Table, route.Dst, route.Priority)
+	w.lock.Lock()
+	w.hostLinks[key] = hostRoute{
+		dst:       route.Dst,
+		table:     route.Table,
+		priority:  route.Priority,
+		linkIndex: route.LinkIndex,
+	}
+	w.lock.Unlock()
+}
+
+// forget drops the route unless it is learned again with another host veth
+func (w *routeWatcher) forget(key string, linkIndex int) {
+	w.lock.Lock()
+	if r, ok := w.hostLinks[key]; ok && r.linkIndex == linkIndex {
+		delete(w.hostLinks, key)
+	}
+	w.lock.Unlock()
+}
+
+// repairRoute re-installs the deleted route
+func (w *routeWatcher) repairRoute(ctx context.Context, route *netlink.Route) {
+	logger := logutils.FromContext(ctx).With(zap.String("route", route.String()))
+
+	if !w.limiter.TryAccept() {
+		// the route is still desired, it will be repaired by the next resync
+		logger.Warn("repairing route is rate limited, another daemon may keep deleting it")
+		metric.RouteRepairRateLimitedCounts.Add(ctx, 1, otelapi.WithAttributes(attribute.String("type", repairTypeRoute)))
+		return
+	}
+
+	if err := w.handle.RouteReplace(route); err != nil {
+		logger.Error("failed to repair route", zap.Error(err))
+		metric.RouteRepairFailureCounts.Add(ctx, 1, otelapi.WithAttributes(attribute.String("type", repairTypeRoute)))
+		return
+	}
+	logger.Info("repaired the route deleted by others")
+	metric.RouteRepairCounts.Add(ctx, 1, otelapi.WithAttributes(attribute.String("type", repairTypeRoute)))
+}
+
+// repairRule re-installs the deleted rule
+func (w *routeWatcher) repairRule(ctx context.Context, rule *netlink.Rule) {
+	logger := logutils.FromContext(ctx).With(zap.String("rule", rule.String()))
+
+	if !w.limiter.TryAccept() {
+		logger.Warn("repairing rule is rate limited, another daemon may keep deleting it")
+		metric.RouteRepairRateLimitedCounts.Add(ctx, 1, otelapi.WithAttributes(attribute.String("type", repairTypeRule)))
+		return
+	}
+
+	if err := w.handle.RuleAdd(rule); err != nil && !os.IsExist(err) {
+		logger.Error("failed to repair rule", zap.Error(err))
+		metric.RouteRepairFailureCounts.Add(ctx, 1, otelapi.WithAttributes(attribute.String("type", repairTypeRule)))
+		return
+	}
+	logger.Info("repaired the rule deleted by others")
+	metric.RouteRepairCounts.Add(ctx, 1, otelapi.WithAttributes(attribute.String("type", repairTypeRule)))
+}
+
+// isPodRoute reports whether the route is to a pod's IP via its host veth, in
+// the managed tables or tagged by spiderpool.
+func (w *routeWatcher) isPodRoute(route *netlink.Route) bool {
+	if !w.isManagedTable(route.Table) && route.Protocol != networking.RouteProtocolSpiderpool {
+		return false
+	}
+	if route.Dst == nil || route.LinkIndex <= 0 || route.Gw != nil || len(route.MultiPath) != 0 {
+		return false
+	}
+	ones, bits := route.Dst.Mask.Size()
+	return ones == bits
+}
+
+func (w *routeWatcher) isManagedTable(table int) bool {
+	for _, t := range w.config.Tables {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+// isTableRule reports whether the rule is `from all lookup <table>`
+func isTableRule(rule *netlink.Rule) bool {
+	return rule.Src == nil && rule.Dst == nil && rule.Mark <= 0 && !rule.Invert &&
+		rule.IifName == "" && rule.OifName == ""
+}
+
+func routeKey(table int, dst *net.IPNet, priority int) string {
+	return fmt.Sprintf("%d/%s/%d", table, dst.String(), priority)
+}
+
+func ruleKey(family, table, priority int) string {
+	return fmt.Sprintf("%d/%d/%d", family, table, priority)
+}
@@ -0,0 +1,234 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package routewatcher_test
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/routewatcher"
+)
+
+const hostRuleTable = 500
+
+var _ = Describe("RouteWatcher", Label("route_watcher"), func() {
+	var testNetNS ns.NetNS
+	var ctx context.Context
+	var link netlink.Link
+	// inCNIDel marks the pod of 10.6.0.10 in CNI DEL
+	var inCNIDel atomic.Bool
+
+	desired := func(_ context.Context) ([]net.IP, error) {
+		if inCNIDel.Load() {
+			return nil, nil
+		}
+		return []net.IP{net.ParseIP("10.6.0.10")}, nil
+	}
+
+	hostRoute := func(ip string) *netlink.Route {
+		return &netlink.Route{
+			LinkIndex: link.Attrs().Index,
+			Dst:       &net.IPNet{IP: net.ParseIP(ip).To4(), Mask: net.CIDRMask(32, 32)},
+			Scope:     netlink.SCOPE_LINK,
+			Table:     hostRuleTable,
+		}
+	}
+
+	hostRule := func() *netlink.Rule {
+		rule := netlink.NewRule()
+		rule.Table = hostRuleTable
+		rule.Priority = 1000
+		rule.Family = netlink.FAMILY_V4
+		return rule
+	}
+
+	ruleExists := func() bool {
+		var rules []netlink.Rule
+		err := testNetNS.Do(func(_ ns.NetNS) (err error) {
+			rules, err = netlink.RuleListFiltered(netlink.FAMILY_V4, hostRule(), netlink.RT_FILTER_TABLE|netlink.RT_FILTER_PRIORITY)
+			return err
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return len(rules) > 0
+	}
+
+	routeExists := func(ip string) func() bool {
+		return func() bool {
+			var routes []netlink.Route
+			err := testNetNS.Do(func(_ ns.NetNS) (err error) {
+				routes, err = netlink.RouteListFiltered(netlink.FAMILY_V4, hostRoute(ip), netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST)
+				return err
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return len(routes) > 0
+		}
+	}
+
+	startWatcher := func(config routewatcher.RouteWatcherConfig) {
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			watcher, err := routewatcher.NewRouteWatcher(config, desired)
+			if err != nil {
+				return err
+			}
+			return watcher.Start(ctx)
+		})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		inCNIDel.Store(false)
+
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(context.Background())
+
+		DeferCleanup(func() {
+			cancel()
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+
+		err = testNetNS.Do(func(_ ns.NetNS) error {
+			err := netlink.LinkAdd(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Name: "veth12345"},
+				PeerName:  "peer12345",
+			})
+			if err != nil {
+				return err
+			}
+			for _, name := range []string{"veth12345", "peer12345"} {
+				l, err := netlink.LinkByName(name)
+				if err != nil {
+					return err
+				}
+				if err = netlink.LinkSetUp(l); err != nil {
+					return err
+				}
+			}
+			link, err = netlink.LinkByName("veth12345")
+			if err != nil {
+				return err
+			}
+
+			if err = netlink.RouteAdd(hostRoute("10.6.0.10")); err != nil {
+				return err
+			}
+			if err = netlink.RouteAdd(hostRoute("10.6.0.11")); err != nil {
+				return err
+			}
+			return netlink.RuleAdd(hostRule())
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("repairs the deleted routes and rules which are desired", func() {
+		startWatcher(routewatcher.RouteWatcherConfig{
+			Tables:       []int{hostRuleTable},
+			ResyncPeriod: 200 * time.Millisecond,
+		})
+
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			if err := netlink.RouteDel(hostRoute("10.6.0.10")); err != nil {
+				return err
+			}
+			if err := netlink.RouteDel(hostRoute("10.6.0.11")); err != nil {
+				return err
+			}
+			return netlink.RuleDel(hostRule())
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(routeExists("10.6.0.10")).WithTimeout(3 * time.Second).Should(BeTrue())
+		Consistently(routeExists("10.6.0.11")).WithTimeout(time.Second).Should(BeFalse())
+
+		Eventually(ruleExists).WithTimeout(3 * time.Second).Should(BeTrue())
+	})
+
+	It("rate limits the repair actions", func() {
+		startWatcher(routewatcher.RouteWatcherConfig{
+			Tables:       []int{hostRuleTable},
+			ResyncPeriod: time.Hour,
+			RepairQPS:    0.001,
+			RepairBurst:  1,
+		})
+
+		delRoute := func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				return netlink.RouteDel(hostRoute("10.6.0.10"))
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		delRoute()
+		Eventually(routeExists("10.6.0.10")).WithTimeout(3 * time.Second).Should(BeTrue())
+
+		delRoute()
+		Consistently(routeExists("10.6.0.10")).WithTimeout(time.Second).Should(BeFalse())
+	})
+
+	It("repairs the deleted rules without waiting for resync", func() {
+		startWatcher(routewatcher.RouteWatcherConfig{
+			Tables:       []int{hostRuleTable},
+			ResyncPeriod: time.Hour,
+		})
+
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			return netlink.RuleDel(hostRule())
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ruleExists).WithTimeout(3 * time.Second).Should(BeTrue())
+	})
+
+	It("doesn't repair the routes and rules deleted by CNI DEL", func() {
+		startWatcher(routewatcher.RouteWatcherConfig{
+			Tables:       []int{hostRuleTable},
+			ResyncPeriod: 200 * time.Millisecond,
+		})
+
+		// coordinator tells spiderpool-agent about CNI DEL before cleaning up,
+		// and the SpiderEndpoint still has the IP until the IPAM DEL
+		inCNIDel.Store(true)
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			if err := netlink.RouteDel(hostRoute("10.6.0.10")); err != nil {
+				return err
+			}
+			return netlink.RuleDel(hostRule())
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Consistently(routeExists("10.6.0.10")).WithTimeout(time.Second).Should(BeFalse())
+		Consistently(ruleExists).WithTimeout(time.Second).Should(BeFalse())
+	})
+
+	It("doesn't repair the routes and rules after the host veth is deleted", func() {
+		startWatcher(routewatcher.RouteWatcherConfig{
+			Tables:       []int{hostRuleTable},
+			ResyncPeriod: 200 * time.Millisecond,
+		})
+
+		// the IP is still desired, but the host veth is gone along with the
+		// routes via it
+		err := testNetNS.Do(func(_ ns.NetNS) error {
+			if err := netlink.LinkDel(link); err != nil {
+				return err
+			}
+			return netlink.RuleDel(hostRule())
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Consistently(ruleExists).WithTimeout(time.Second).Should(BeFalse())
+	})
+})
@@ -0,0 +1,27 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package routewatcher_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/metric"
+)
+
+func TestRouteWatcher(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RouteWatcher Suite", Label("routewatcher", "unitest"))
+}
+
+var _ = BeforeSuite(func() {
+	_, err := metric.InitMetric(context.TODO(), constant.SpiderpoolAgent, false, false)
+	Expect(err).NotTo(HaveOccurred())
+	err = metric.InitSpiderpoolAgentMetrics(context.TODO())
+	Expect(err).NotTo(HaveOccurred())
+})
@@ -4,10 +4,12 @@
 package sysctl
 
 import (
+	"errors"
 	"fmt"
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/containernetworking/plugins/pkg/utils/sysctl"
 	"os"
+	"strconv"
 )
 
 // SysctlRPFilter set rp_filter value for host netns and specify netns
@@ -51,17 +53,82 @@ func setRPFilter(v int32) error {
 	return nil
 }
 
-// EnableIpv6Sysctl enable ipv6 for specify netns
-func EnableIpv6Sysctl(netns ns.NetNS) error {
+// SetProxyARP set proxy_arp of the interface in current netns
+func SetProxyARP(iface string, enable bool) error {
+	return setInterfaceSysctl(fmt.Sprintf("/net/ipv4/conf/%s/proxy_arp", iface), enable)
+}
+
+// SetProxyNDP set proxy_ndp of the interface in current netns, which is required
+// for the proxy neighbor entries of the interface to work
+func SetProxyNDP(iface string, enable bool) error {
+	return setInterfaceSysctl(fmt.Sprintf("/net/ipv6/conf/%s/proxy_ndp", iface), enable)
+}
+
+// GetProxyNDP get proxy_ndp of the interface in current netns
+func GetProxyNDP(iface string) (bool, error) {
+	name := fmt.Sprintf("/net/ipv6/conf/%s/proxy_ndp", iface)
+	value, err := sysctl.Sysctl(name)
+	if err != nil {
+		return false, fmt.Errorf("failed to read sysctl %s: %v", name, err)
+	}
+	return value != "0", nil
+}
+
+func setInterfaceSysctl(name string, enable bool) error {
+	value := "0"
+	if enable {
+		value = "1"
+	}
+	if _, err := sysctl.Sysctl(name, value); err != nil {
+		return fmt.Errorf("failed to set sysctl %s to %s: %v", name, value, err)
+	}
+	return nil
+}
+
+// SetDisableIPv6 set disable_ipv6 of the interface in current netns, 1 removes
+// the IPv6 addresses and routes of the interface, such as the link-local of an
+// IPv4-only NIC
+func SetDisableIPv6(iface string, value int) error {
+	if value != 0 && value != 1 {
+		return fmt.Errorf("invalid disable_ipv6 value %d, available options: [0,1]", value)
+	}
+	name := fmt.Sprintf("/net/ipv6/conf/%s/disable_ipv6", iface)
+	if _, err := sysctl.Sysctl(name, strconv.Itoa(value)); err != nil {
+		return fmt.Errorf("failed to set sysctl %s to %d: %v", name, value, err)
+	}
+	return nil
+}
+
+// GetDisableIPv6 get disable_ipv6 of the interface in current netns
+func GetDisableIPv6(iface string) (int, error) {
+	name := fmt.Sprintf("/net/ipv6/conf/%s/disable_ipv6", iface)
+	value, err := sysctl.Sysctl(name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read sysctl %s: %v", name, err)
+	}
+	disabled, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sysctl %s value %q: %v", name, value, err)
+	}
+	return disabled, nil
+}
+
+// ErrIPv6NotCompiled is returned when the kernel is built without IPv6 support
+var ErrIPv6NotCompiled = errors.New("ipv6 is not supported by the kernel")
+
+// EnableIPv6 set disable_ipv6 to 0 for all, default and the given interfaces in specify netns
+func EnableIPv6(netns ns.NetNS, ifaces []string) error {
 	err := netns.Do(func(_ ns.NetNS) error {
-		dirs, err := os.ReadDir("/proc/sys/net/ipv6/conf")
-		if err != nil {
+		if _, err := os.Stat("/proc/sys/net/ipv6"); err != nil {
+			if os.IsNotExist(err) {
+				return ErrIPv6NotCompiled
+			}
 			return err
 		}
 
-		for _, dir := range dirs {
+		for _, iface := range append([]string{"all", "default"}, ifaces...) {
 			// Read current sysctl value
-			name := fmt.Sprintf("/net/ipv6/conf/%s/disable_ipv6", dir.Name())
+			name := fmt.Sprintf("/net/ipv6/conf/%s/disable_ipv6", iface)
 			value, err := sysctl.Sysctl(name)
 			if err != nil {
 				return fmt.Errorf("failed to read current sysctl %+v value: %v", name, err)
@@ -69,7 +136,7 @@ func EnableIpv6Sysctl(netns ns.NetNS) error {
 			// make sure value=0
 			if value != "0" {
 				if _, err = sysctl.Sysctl(name, "0"); err != nil {
-					return fmt.Errorf("failed to read current sysctl %+v value: %v ", name, err)
+					return fmt.Errorf("failed to set sysctl %+v value: %v ", name, err)
 				}
 			}
 		}
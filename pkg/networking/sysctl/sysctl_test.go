@@ -0,0 +1,72 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package sysctl_test
+
+import (
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+
+	"github.com/spidernet-io/spiderpool/pkg/networking/sysctl"
+)
+
+var _ = Describe("Sysctl", Label("sysctl"), func() {
+	var testNetNS ns.NetNS
+
+	BeforeEach(func() {
+		var err error
+		testNetNS, err = testutils.NewNS()
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(testNetNS.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(testNetNS)).To(Succeed())
+		})
+	})
+
+	Context("SetDisableIPv6", func() {
+		It("toggles disable_ipv6 of the interface", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(netlink.LinkAdd(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{Name: "net1"},
+					PeerName:  "peer12345",
+				})).To(Succeed())
+
+				Expect(sysctl.SetDisableIPv6("net1", 1)).To(Succeed())
+				value, err := sysctl.GetDisableIPv6("net1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value).To(Equal(1))
+
+				// the peer is untouched
+				value, err = sysctl.GetDisableIPv6("peer12345")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value).To(Equal(0))
+
+				Expect(sysctl.SetDisableIPv6("net1", 0)).To(Succeed())
+				value, err = sysctl.GetDisableIPv6("net1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value).To(Equal(0))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("fails with the invalid value or interface", func() {
+			err := testNetNS.Do(func(_ ns.NetNS) error {
+				defer GinkgoRecover()
+
+				Expect(sysctl.SetDisableIPv6("lo", 2)).NotTo(Succeed())
+				Expect(sysctl.SetDisableIPv6("net2", 1)).NotTo(Succeed())
+				_, err := sysctl.GetDisableIPv6("net2")
+				Expect(err).To(HaveOccurred())
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
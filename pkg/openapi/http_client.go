@@ -0,0 +1,35 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	runtime_client "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+
+	agentOpenAPIClient "github.com/spidernet-io/spiderpool/api/v1/agent/client"
+)
+
+// NewAgentOpenAPIHttpClient creates a new instance of the agent OpenAPI http
+// client, the host is the address of the spiderpool-agent http server, such as
+// '172.18.0.2:5710'.
+func NewAgentOpenAPIHttpClient(host string, timeout time.Duration) (*agentOpenAPIClient.SpiderpoolAgentAPI, error) {
+	if host == "" {
+		return nil, fmt.Errorf("host must be specified")
+	}
+
+	httpClient := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+		},
+	}
+	clientTrans := runtime_client.NewWithClient(host, agentOpenAPIClient.DefaultBasePath,
+		agentOpenAPIClient.DefaultSchemes, httpClient)
+	client := agentOpenAPIClient.New(clientTrans, strfmt.Default)
+	return client, nil
+}
@@ -510,14 +510,14 @@ func (sc *SubnetController) syncControlledIPPoolIPs(ctx context.Context, subnet
 	// record the metric of how many IPPools the Subnet has.
 	metric.SubnetPoolCounts.Record(int64(len(ipPools)), attribute.String(constant.KindSpiderSubnet, subnet.Name))
 
-	subnetTotalIPs, err := spiderpoolip.AssembleTotalIPs(*subnet.Spec.IPVersion, subnet.Spec.IPs, subnet.Spec.ExcludeIPs)
+	subnetTotalIPs, err := spiderpoolip.AssembleAllocatableIPs(*subnet.Spec.IPVersion, subnet.Spec.Subnet, subnetGateways(subnet), subnet.Spec.IPs, subnet.Spec.ExcludeIPs)
 	if err != nil {
 		return err
 	}
 
 	for _, ipPool := range ipPools {
 		if !ippoolmanager.IsAutoCreatedIPPool(ipPool) {
-			poolTotalIPs, err := spiderpoolip.AssembleTotalIPs(*subnet.Spec.IPVersion, ipPool.Spec.IPs, ipPool.Spec.ExcludeIPs)
+			poolTotalIPs, err := spiderpoolip.AssembleAllocatableIPs(*subnet.Spec.IPVersion, ipPool.Spec.Subnet, ippoolmanager.PoolGateways(ipPool), ipPool.Spec.IPs, ipPool.Spec.ExcludeIPs)
 			if err != nil {
 				logger.Sugar().Errorf("Invalid total IP ranges of IPPool %s, remove the pre-allocation from Subnet", ipPool.Name)
 				continue
@@ -410,7 +410,7 @@ func (sm *subnetManager) preAllocateIPsFromSubnet(ctx context.Context, subnet *s
 
 func subnetStatusCount(subnet *spiderpoolv2beta1.SpiderSubnet) (totalCount, allocatedCount int64) {
 	// total IP Count
-	subnetTotalIPs, _ := spiderpoolip.AssembleTotalIPs(*subnet.Spec.IPVersion, subnet.Spec.IPs, subnet.Spec.ExcludeIPs)
+	subnetTotalIPs, _ := spiderpoolip.AssembleAllocatableIPs(*subnet.Spec.IPVersion, subnet.Spec.Subnet, subnetGateways(subnet), subnet.Spec.IPs, subnet.Spec.ExcludeIPs)
 
 	if subnet.Status.ControlledIPPools == nil {
 		return 0, 0
@@ -429,3 +429,12 @@ func subnetStatusCount(subnet *spiderpoolv2beta1.SpiderSubnet) (totalCount, allo
 	}
 	return int64(len(subnetTotalIPs)), allocatedIPCount
 }
+
+// subnetGateways returns the gateway of the subnet as the gateways which are
+// implicitly excluded, the subnet has no gateway overrides.
+func subnetGateways(subnet *spiderpoolv2beta1.SpiderSubnet) []string {
+	if subnet.Spec.Gateway == nil {
+		return nil
+	}
+	return []string{*subnet.Spec.Gateway}
+}
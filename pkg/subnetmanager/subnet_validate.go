@@ -343,6 +343,10 @@ func validateSubnetRoutes(version types.IPVersion, subnet string, routes []spide
 		if err := ippoolmanager.ValidateContainsIP(routesField.Index(i).Child("gw"), version, subnet, r.Gw); err != nil {
 			return err
 		}
+
+		if err := ippoolmanager.ValidateRouteTable(routesField.Index(i).Child("table"), r.Table); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -45,8 +45,9 @@ type AnnoIPPoolItem struct {
 type AnnoPodRoutesValue []AnnoRouteItem
 
 type AnnoRouteItem struct {
-	Dst string `json:"dst"`
-	Gw  string `json:"gw"`
+	Dst   string `json:"dst"`
+	Gw    string `json:"gw"`
+	Iface string `json:"iface,omitempty"`
 }
 
 type AnnoNSDefautlV4PoolValue []string
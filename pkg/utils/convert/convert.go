@@ -172,8 +172,9 @@ func ConvertAnnoPodRoutesToOAIRoutes(annoPodRoutes types.AnnoPodRoutesValue) []*
 	for _, r := range annoPodRoutes {
 		dst := r.Dst
 		gw := r.Gw
+		iface := r.Iface
 		routes = append(routes, &models.Route{
-			IfName: new(string),
+			IfName: &iface,
 			Dst:    &dst,
 			Gw:     &gw,
 		})
@@ -191,6 +192,7 @@ func ConvertSpecRoutesToOAIRoutes(nic string, specRoutes []spiderpoolv2beta1.Rou
 			IfName: &nic,
 			Dst:    &dst,
 			Gw:     &gw,
+			Table:  r.Table,
 		})
 	}
 
@@ -201,8 +203,9 @@ func ConvertOAIRoutesToSpecRoutes(oaiRoutes []*models.Route) []spiderpoolv2beta1
 	var routes []spiderpoolv2beta1.Route
 	for _, r := range oaiRoutes {
 		routes = append(routes, spiderpoolv2beta1.Route{
-			Dst: *r.Dst,
-			Gw:  *r.Gw,
+			Dst:   *r.Dst,
+			Gw:    *r.Gw,
+			Table: r.Table,
 		})
 	}
 
@@ -276,6 +279,33 @@ func MarshalIPPoolAllocatedIPs(records spiderpoolv2beta1.PoolIPAllocations) (*st
 	return &data, nil
 }
 
+func UnmarshalIPPoolReleasedIPs(data *string) (spiderpoolv2beta1.PoolIPReleases, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var records spiderpoolv2beta1.PoolIPReleases
+	if err := json.Unmarshal([]byte(*data), &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func MarshalIPPoolReleasedIPs(records spiderpoolv2beta1.PoolIPReleases) (*string, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	v, err := json.Marshal(records)
+	if err != nil {
+		return nil, err
+	}
+	data := string(v)
+
+	return &data, nil
+}
+
 func UnmarshalSubnetAllocatedIPPools(data *string) (spiderpoolv2beta1.PoolIPPreAllocations, error) {
 	if data == nil {
 		return nil, nil
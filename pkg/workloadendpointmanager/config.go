@@ -0,0 +1,24 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package workloadendpointmanager
+
+const (
+	defaultMaxHistoryRecords = 5
+	// MaxHistoryRecordsLimit is the max items of the history of SpiderEndpoint
+	// allowed by the CRD, which keeps the Endpoints from bloating etcd
+	MaxHistoryRecordsLimit = 20
+)
+
+type EndpointManagerConfig struct {
+	MaxHistoryRecords *int
+}
+
+func setDefaultsForEndpointManagerConfig(config EndpointManagerConfig) EndpointManagerConfig {
+	if config.MaxHistoryRecords == nil {
+		maxHistoryRecords := defaultMaxHistoryRecords
+		config.MaxHistoryRecords = &maxHistoryRecords
+	}
+
+	return config
+}
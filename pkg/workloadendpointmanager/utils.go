@@ -4,6 +4,8 @@
 package workloadendpointmanager
 
 import (
+	"strings"
+
 	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
 )
 
@@ -22,3 +24,42 @@ func RetrieveIPAllocation(uid, nic string, endpoint *spiderpoolv2beta1.SpiderEnd
 
 	return nil
 }
+
+// EndpointIP is an IP address of the current IP allocation of the
+// SpiderEndpoint, along with the IPPool it's allocated from.
+type EndpointIP struct {
+	Pool string
+	IP   string
+}
+
+// ListEndpointIPs returns the IP addresses of the current IP allocation of
+// the SpiderEndpoint, without the mask.
+func ListEndpointIPs(endpoint *spiderpoolv2beta1.SpiderEndpoint) []EndpointIP {
+	var ips []EndpointIP
+	for _, detail := range endpoint.Status.Current.IPs {
+		if detail.IPv4 != nil && detail.IPv4Pool != nil {
+			ips = append(ips, EndpointIP{Pool: *detail.IPv4Pool, IP: strings.Split(*detail.IPv4, "/")[0]})
+		}
+		if detail.IPv6 != nil && detail.IPv6Pool != nil {
+			ips = append(ips, EndpointIP{Pool: *detail.IPv6Pool, IP: strings.Split(*detail.IPv6, "/")[0]})
+		}
+	}
+
+	return ips
+}
+
+// HasEndpointIP checks whether the IP address is in the current IP
+// allocation of the SpiderEndpoint, no matter whether the IPPool of the IP
+// address is recorded.
+func HasEndpointIP(endpoint *spiderpoolv2beta1.SpiderEndpoint, ip string) bool {
+	for _, detail := range endpoint.Status.Current.IPs {
+		if detail.IPv4 != nil && strings.Split(*detail.IPv4, "/")[0] == ip {
+			return true
+		}
+		if detail.IPv6 != nil && strings.Split(*detail.IPv6, "/")[0] == ip {
+			return true
+		}
+	}
+
+	return false
+}
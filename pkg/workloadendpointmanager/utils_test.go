@@ -89,4 +89,43 @@ var _ = Describe("WorkloadEndpointManager utils", Label("workloadendpoint_manage
 			Expect(*allocation).To(Equal(allocationT))
 		})
 	})
+
+	Describe("Test ListEndpointIPs", func() {
+		BeforeEach(func() {
+			endpointT.Status.Current = spiderpoolv2beta1.PodIPAllocation{
+				UID: string(uuid.NewUUID()),
+				IPs: []spiderpoolv2beta1.IPAllocationDetail{
+					{
+						NIC:      "eth0",
+						IPv4:     pointer.String("172.18.40.10/24"),
+						IPv4Pool: pointer.String("v4-pool"),
+						IPv6:     pointer.String("abcd:1234::a/120"),
+						IPv6Pool: pointer.String("v6-pool"),
+					},
+					{
+						NIC:  "net1",
+						IPv4: pointer.String("172.18.41.10/24"),
+					},
+				},
+			}
+		})
+
+		It("lists the IP addresses with the IPPools", func() {
+			ips := workloadendpointmanager.ListEndpointIPs(endpointT)
+			Expect(ips).To(Equal([]workloadendpointmanager.EndpointIP{
+				{Pool: "v4-pool", IP: "172.18.40.10"},
+				{Pool: "v6-pool", IP: "abcd:1234::a"},
+			}))
+		})
+
+		It("checks whether the IP address is held by the Endpoint", func() {
+			Expect(workloadendpointmanager.HasEndpointIP(endpointT, "172.18.40.10")).To(BeTrue())
+			Expect(workloadendpointmanager.HasEndpointIP(endpointT, "abcd:1234::a")).To(BeTrue())
+			Expect(workloadendpointmanager.HasEndpointIP(endpointT, "172.18.40.11")).To(BeFalse())
+		})
+
+		It("checks the IP address whose IPPool is not recorded", func() {
+			Expect(workloadendpointmanager.HasEndpointIP(endpointT, "172.18.41.10")).To(BeTrue())
+		})
+	})
 })
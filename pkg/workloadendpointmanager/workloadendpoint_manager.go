@@ -6,10 +6,13 @@ package workloadendpointmanager
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
@@ -26,14 +29,17 @@ type WorkloadEndpointManager interface {
 	RemoveFinalizer(ctx context.Context, endpoint *spiderpoolv2beta1.SpiderEndpoint) error
 	PatchIPAllocationResults(ctx context.Context, results []*types.AllocationResult, endpoint *spiderpoolv2beta1.SpiderEndpoint, pod *corev1.Pod, podController types.PodTopController) error
 	ReallocateCurrentIPAllocation(ctx context.Context, uid, nodeName string, endpoint *spiderpoolv2beta1.SpiderEndpoint) error
+	AnnotateForcedRelease(ctx context.Context, endpoint *spiderpoolv2beta1.SpiderEndpoint, ips []string, releasedTime metav1.Time) error
+	RecordPodMAC(ctx context.Context, endpoint *spiderpoolv2beta1.SpiderEndpoint, nic, mac string) error
 }
 
 type workloadEndpointManager struct {
+	config    EndpointManagerConfig
 	client    client.Client
 	apiReader client.Reader
 }
 
-func NewWorkloadEndpointManager(client client.Client, apiReader client.Reader) (WorkloadEndpointManager, error) {
+func NewWorkloadEndpointManager(config EndpointManagerConfig, client client.Client, apiReader client.Reader) (WorkloadEndpointManager, error) {
 	if client == nil {
 		return nil, fmt.Errorf("k8s client %w", constant.ErrMissingRequiredParam)
 	}
@@ -41,7 +47,13 @@ func NewWorkloadEndpointManager(client client.Client, apiReader client.Reader) (
 		return nil, fmt.Errorf("api reader %w", constant.ErrMissingRequiredParam)
 	}
 
+	config = setDefaultsForEndpointManagerConfig(config)
+	if *config.MaxHistoryRecords < 0 || *config.MaxHistoryRecords > MaxHistoryRecordsLimit {
+		return nil, fmt.Errorf("max history records %d of Endpoint must be in [0, %d]", *config.MaxHistoryRecords, MaxHistoryRecordsLimit)
+	}
+
 	return &workloadEndpointManager{
+		config:    config,
 		client:    client,
 		apiReader: apiReader,
 	}, nil
@@ -108,6 +120,7 @@ func (em *workloadEndpointManager) PatchIPAllocationResults(ctx context.Context,
 	}
 
 	if endpoint == nil {
+		now := metav1.Now()
 		endpoint = &spiderpoolv2beta1.SpiderEndpoint{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      pod.Name,
@@ -115,9 +128,10 @@ func (em *workloadEndpointManager) PatchIPAllocationResults(ctx context.Context,
 			},
 			Status: spiderpoolv2beta1.WorkloadEndpointStatus{
 				Current: spiderpoolv2beta1.PodIPAllocation{
-					UID:  string(pod.UID),
-					Node: pod.Spec.NodeName,
-					IPs:  convert.ConvertResultsToIPDetails(results),
+					UID:           string(pod.UID),
+					Node:          pod.Spec.NodeName,
+					IPs:           convert.ConvertResultsToIPDetails(results),
+					AllocatedTime: &now,
 				},
 				OwnerControllerType: podController.Kind,
 				OwnerControllerName: podController.Name,
@@ -155,8 +169,87 @@ func (em *workloadEndpointManager) ReallocateCurrentIPAllocation(ctx context.Con
 		return nil
 	}
 
+	// the previous pod of StatefulSet is deleted, and its IP allocation is
+	// taken over by the new one
+	now := metav1.Now()
+	em.appendHistoryRecord(endpoint, constant.ReleaseReasonPodDeleted, now)
 	endpoint.Status.Current.UID = uid
 	endpoint.Status.Current.Node = nodeName
+	endpoint.Status.Current.AllocatedTime = &now
 
 	return em.client.Update(ctx, endpoint)
 }
+
+// AnnotateForcedRelease annotates the Endpoint with the time and the IPs of
+// the forced release of its IPs.
+func (em *workloadEndpointManager) AnnotateForcedRelease(ctx context.Context, endpoint *spiderpoolv2beta1.SpiderEndpoint, ips []string, releasedTime metav1.Time) error {
+	if endpoint == nil {
+		return fmt.Errorf("endpoint %w", constant.ErrMissingRequiredParam)
+	}
+
+	if endpoint.Annotations == nil {
+		endpoint.Annotations = map[string]string{}
+	}
+	endpoint.Annotations[constant.AnnoForcedReleaseTime] = releasedTime.UTC().Format(time.RFC3339)
+	endpoint.Annotations[constant.AnnoForcedReleaseIPs] = strings.Join(ips, ",")
+	if err := em.client.Update(ctx, endpoint); err != nil {
+		return fmt.Errorf("failed to annotate the forced release of Endpoint %s/%s: %w", endpoint.Namespace, endpoint.Name, err)
+	}
+
+	return nil
+}
+
+// RecordPodMAC records the MAC address assigned to the interface nic in the
+// current IP allocation of the Endpoint.
+func (em *workloadEndpointManager) RecordPodMAC(ctx context.Context, endpoint *spiderpoolv2beta1.SpiderEndpoint, nic, mac string) error {
+	if endpoint == nil {
+		return fmt.Errorf("endpoint %w", constant.ErrMissingRequiredParam)
+	}
+
+	for i := range endpoint.Status.Current.IPs {
+		detail := &endpoint.Status.Current.IPs[i]
+		if detail.NIC != nic {
+			continue
+		}
+		if detail.MAC != nil && *detail.MAC == mac {
+			return nil
+		}
+
+		detail.MAC = pointer.String(mac)
+		if err := em.client.Update(ctx, endpoint); err != nil {
+			return fmt.Errorf("failed to record the MAC address of Endpoint %s/%s: %w", endpoint.Namespace, endpoint.Name, err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("no IP allocation of interface %s in Endpoint %s/%s", nic, endpoint.Namespace, endpoint.Name)
+}
+
+// appendHistoryRecord inserts the current IP allocation into the history of
+// the Endpoint as the latest record, the oldest ones beyond the max history
+// records are dropped.
+func (em *workloadEndpointManager) appendHistoryRecord(endpoint *spiderpoolv2beta1.SpiderEndpoint, reason string, releasedTime metav1.Time) {
+	maxRecords := *em.config.MaxHistoryRecords
+	if maxRecords == 0 {
+		return
+	}
+
+	current := endpoint.Status.Current.DeepCopy()
+	for i := range current.IPs {
+		current.IPs[i].Routes = nil
+	}
+	record := spiderpoolv2beta1.PodIPAllocationRecord{
+		UID:           current.UID,
+		Node:          current.Node,
+		IPs:           current.IPs,
+		AllocatedTime: current.AllocatedTime,
+		ReleasedTime:  &releasedTime,
+		ReleaseReason: reason,
+	}
+
+	history := append([]spiderpoolv2beta1.PodIPAllocationRecord{record}, endpoint.Status.History...)
+	if len(history) > maxRecords {
+		history = history[:maxRecords]
+	}
+	endpoint.Status.History = history
+}
@@ -58,6 +58,7 @@ var _ = BeforeSuite(func() {
 		Build()
 
 	endpointManager, err = workloadendpointmanager.NewWorkloadEndpointManager(
+		workloadendpointmanager.EndpointManagerConfig{},
 		fakeClient,
 		fakeAPIReader,
 	)
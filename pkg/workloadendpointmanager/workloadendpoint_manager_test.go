@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"sync/atomic"
+	"time"
 
 	"github.com/agiledragon/gomonkey/v2"
 	. "github.com/onsi/ginkgo/v2"
@@ -32,6 +33,7 @@ var _ = Describe("WorkloadEndpointManager", Label("workloadendpoint_manager_test
 	Describe("New WorkloadEndpointManager", func() {
 		It("inputs nil client", func() {
 			manager, err := workloadendpointmanager.NewWorkloadEndpointManager(
+				workloadendpointmanager.EndpointManagerConfig{},
 				nil,
 				fakeAPIReader,
 			)
@@ -41,12 +43,26 @@ var _ = Describe("WorkloadEndpointManager", Label("workloadendpoint_manager_test
 
 		It("inputs nil API reader", func() {
 			manager, err := workloadendpointmanager.NewWorkloadEndpointManager(
+				workloadendpointmanager.EndpointManagerConfig{},
 				fakeClient,
 				nil,
 			)
 			Expect(err).To(MatchError(constant.ErrMissingRequiredParam))
 			Expect(manager).To(BeNil())
 		})
+
+		It("inputs invalid max history records", func() {
+			maxHistoryRecords := workloadendpointmanager.MaxHistoryRecordsLimit + 1
+			manager, err := workloadendpointmanager.NewWorkloadEndpointManager(
+				workloadendpointmanager.EndpointManagerConfig{
+					MaxHistoryRecords: &maxHistoryRecords,
+				},
+				fakeClient,
+				fakeAPIReader,
+			)
+			Expect(err).To(HaveOccurred())
+			Expect(manager).To(BeNil())
+		})
 	})
 
 	Describe("Test WorkloadEndpointManager's method", func() {
@@ -454,6 +470,175 @@ var _ = Describe("WorkloadEndpointManager", Label("workloadendpoint_manager_test
 				Expect(err).NotTo(HaveOccurred())
 				Expect(endpointT.Status.Current.UID).To(Equal(uid))
 				Expect(endpointT.Status.Current.Node).To(Equal(nodeName))
+				Expect(endpointT.Status.Current.AllocatedTime).NotTo(BeNil())
+				Expect(endpointT.Status.History).To(HaveLen(1))
+				Expect(endpointT.Status.History[0].Node).To(Equal("old-node"))
+				Expect(endpointT.Status.History[0].ReleaseReason).To(Equal(constant.ReleaseReasonPodDeleted))
+			})
+		})
+
+		Describe("history of the IP allocations taken over", func() {
+			var ipv4 string
+
+			BeforeEach(func() {
+				ipv4 = "172.18.40.10/24"
+				endpointT.Status.Current = spiderpoolv2beta1.PodIPAllocation{
+					UID:  string(uuid.NewUUID()),
+					Node: "node",
+					IPs: []spiderpoolv2beta1.IPAllocationDetail{
+						{
+							NIC:      "eth0",
+							IPv4:     &ipv4,
+							IPv4Pool: pointer.String("default-ipv4-ippool"),
+							Routes: []spiderpoolv2beta1.Route{
+								{Dst: "10.0.0.0/8", Gw: "172.18.40.1"},
+							},
+						},
+					},
+				}
+			})
+
+			It("records the IP allocation without the routes", func() {
+				err := fakeClient.Create(ctx, endpointT)
+				Expect(err).NotTo(HaveOccurred())
+
+				oldUID := endpointT.Status.Current.UID
+				err = endpointManager.ReallocateCurrentIPAllocation(ctx, string(uuid.NewUUID()), "new-node", endpointT)
+				Expect(err).NotTo(HaveOccurred())
+
+				var endpoint spiderpoolv2beta1.SpiderEndpoint
+				err = fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: endpointName}, &endpoint)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(endpoint.Status.History).To(HaveLen(1))
+
+				record := endpoint.Status.History[0]
+				Expect(record.UID).To(Equal(oldUID))
+				Expect(record.Node).To(Equal("node"))
+				Expect(record.ReleaseReason).To(Equal(constant.ReleaseReasonPodDeleted))
+				Expect(record.ReleasedTime).NotTo(BeNil())
+				Expect(record.IPs).To(HaveLen(1))
+				Expect(*record.IPs[0].IPv4).To(Equal(ipv4))
+				Expect(record.IPs[0].Routes).To(BeEmpty())
+				Expect(endpoint.Status.Current.IPs[0].Routes).To(HaveLen(1))
+			})
+
+			It("drops the oldest records beyond the max history records", func() {
+				maxHistoryRecords := 2
+				manager, err := workloadendpointmanager.NewWorkloadEndpointManager(
+					workloadendpointmanager.EndpointManagerConfig{
+						MaxHistoryRecords: &maxHistoryRecords,
+					},
+					fakeClient,
+					fakeAPIReader,
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = fakeClient.Create(ctx, endpointT)
+				Expect(err).NotTo(HaveOccurred())
+
+				var uids []string
+				for i := 0; i < 3; i++ {
+					uids = append(uids, endpointT.Status.Current.UID)
+					err = manager.ReallocateCurrentIPAllocation(ctx, string(uuid.NewUUID()), "node", endpointT)
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				Expect(endpointT.Status.History).To(HaveLen(2))
+				Expect(endpointT.Status.History[0].UID).To(Equal(uids[2]))
+				Expect(endpointT.Status.History[1].UID).To(Equal(uids[1]))
+			})
+
+			It("records nothing if the history is disabled", func() {
+				maxHistoryRecords := 0
+				manager, err := workloadendpointmanager.NewWorkloadEndpointManager(
+					workloadendpointmanager.EndpointManagerConfig{
+						MaxHistoryRecords: &maxHistoryRecords,
+					},
+					fakeClient,
+					fakeAPIReader,
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = fakeClient.Create(ctx, endpointT)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = manager.ReallocateCurrentIPAllocation(ctx, string(uuid.NewUUID()), "node", endpointT)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(endpointT.Status.History).To(BeEmpty())
+			})
+		})
+
+		Describe("AnnotateForcedRelease", func() {
+			It("inputs nil Endpoint", func() {
+				err := endpointManager.AnnotateForcedRelease(ctx, nil, []string{"172.18.40.10"}, metav1.Now())
+				Expect(err).To(MatchError(constant.ErrMissingRequiredParam))
+			})
+
+			It("failed to update Endpoint due to some unknown errors", func() {
+				patches := gomonkey.ApplyMethodReturn(fakeClient, "Update", constant.ErrUnknown)
+				defer patches.Reset()
+
+				err := endpointManager.AnnotateForcedRelease(ctx, endpointT, []string{"172.18.40.10"}, metav1.Now())
+				Expect(err).To(MatchError(constant.ErrUnknown))
+			})
+
+			It("annotates the forced release", func() {
+				err := fakeClient.Create(ctx, endpointT)
+				Expect(err).NotTo(HaveOccurred())
+
+				releasedTime := metav1.NewTime(metav1.Now().Add(-time.Minute))
+				err = endpointManager.AnnotateForcedRelease(ctx, endpointT, []string{"172.18.40.10", "abcd:1234::10"}, releasedTime)
+				Expect(err).NotTo(HaveOccurred())
+
+				var endpoint spiderpoolv2beta1.SpiderEndpoint
+				err = fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: endpointName}, &endpoint)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(endpoint.Annotations).To(HaveKeyWithValue(constant.AnnoForcedReleaseTime, releasedTime.UTC().Format(time.RFC3339)))
+				Expect(endpoint.Annotations).To(HaveKeyWithValue(constant.AnnoForcedReleaseIPs, "172.18.40.10,abcd:1234::10"))
+			})
+		})
+
+		Describe("RecordPodMAC", func() {
+			It("inputs nil Endpoint", func() {
+				err := endpointManager.RecordPodMAC(ctx, nil, "net1", "0a:1b:01:02:03:04")
+				Expect(err).To(MatchError(constant.ErrMissingRequiredParam))
+			})
+
+			It("records the MAC of an unknown interface", func() {
+				endpointT.Status.Current.IPs = []spiderpoolv2beta1.IPAllocationDetail{{NIC: "eth0"}}
+
+				err := endpointManager.RecordPodMAC(ctx, endpointT, "net1", "0a:1b:01:02:03:04")
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("failed to update Endpoint due to some unknown errors", func() {
+				endpointT.Status.Current.IPs = []spiderpoolv2beta1.IPAllocationDetail{{NIC: "net1"}}
+				patches := gomonkey.ApplyMethodReturn(fakeClient, "Update", constant.ErrUnknown)
+				defer patches.Reset()
+
+				err := endpointManager.RecordPodMAC(ctx, endpointT, "net1", "0a:1b:01:02:03:04")
+				Expect(err).To(MatchError(constant.ErrUnknown))
+			})
+
+			It("records the MAC of the interface", func() {
+				endpointT.Status.Current.IPs = []spiderpoolv2beta1.IPAllocationDetail{{NIC: "eth0"}, {NIC: "net1"}}
+				err := fakeClient.Create(ctx, endpointT)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = endpointManager.RecordPodMAC(ctx, endpointT, "net1", "0a:1b:01:02:03:04")
+				Expect(err).NotTo(HaveOccurred())
+
+				var endpoint spiderpoolv2beta1.SpiderEndpoint
+				err = fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: endpointName}, &endpoint)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(endpoint.Status.Current.IPs[0].MAC).To(BeNil())
+				Expect(endpoint.Status.Current.IPs[1].MAC).To(Equal(pointer.String("0a:1b:01:02:03:04")))
+
+				// no update if the MAC is recorded already
+				patches := gomonkey.ApplyMethodReturn(fakeClient, "Update", constant.ErrUnknown)
+				defer patches.Reset()
+				err = endpointManager.RecordPodMAC(ctx, endpointT, "net1", "0a:1b:01:02:03:04")
+				Expect(err).NotTo(HaveOccurred())
 			})
 		})
 	})
@@ -0,0 +1,175 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	e2e "github.com/spidernet-io/e2eframework/framework"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+// The chaos helper is a privileged DaemonSet sharing the host network and PID
+// namespace, which is used to break the routes and rules of the nodes and Pods
+// from outside, as other daemons on the node may do. The commands are executed
+// with the busybox of the helper.
+const (
+	ChaosHelperDSName = "spiderpool-chaos-helper"
+	ChaosHelperNs     = "kube-system"
+)
+
+func GenerateChaosHelperDaemonSetYaml() *appsv1.DaemonSet {
+	labels := map[string]string{"app": ChaosHelperDSName}
+
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ChaosHelperNs,
+			Name:      ChaosHelperDSName,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					HostNetwork: true,
+					HostPID:     true,
+					Tolerations: []corev1.Toleration{
+						{Operator: corev1.TolerationOpExists},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:            "chaos",
+							Image:           "alpine",
+							ImagePullPolicy: "IfNotPresent",
+							Command:         []string{"/bin/ash", "-c", "sleep infinity"},
+							SecurityContext: &corev1.SecurityContext{
+								Privileged: pointer.Bool(true),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// DeployChaosHelperUntilReady creates the chaos helper DaemonSet if it does
+// not exist, and waits for its Pods running on all nodes.
+func DeployChaosHelperUntilReady(ctx context.Context, frame *e2e.Framework) error {
+	ds, err := frame.GetDaemonSet(ChaosHelperDSName, ChaosHelperNs)
+	if err != nil {
+		if !api_errors.IsNotFound(err) {
+			return err
+		}
+		ds = GenerateChaosHelperDaemonSetYaml()
+		if err := frame.CreateDaemonSet(ds); err != nil {
+			return fmt.Errorf("failed to create the chaos helper: %w", err)
+		}
+		GinkgoWriter.Printf("create the chaos helper %v/%v \n", ChaosHelperNs, ChaosHelperDSName)
+	}
+
+	nodeList, err := frame.GetNodeList()
+	if err != nil {
+		return err
+	}
+	return frame.WaitPodListRunning(ds.Spec.Selector.MatchLabels, len(nodeList.Items), ctx)
+}
+
+func getChaosHelperPod(frame *e2e.Framework, nodeName string) (*corev1.Pod, error) {
+	podList, err := frame.GetPodListByLabel(map[string]string{"app": ChaosHelperDSName})
+	if err != nil {
+		return nil, err
+	}
+	for i := range podList.Items {
+		if podList.Items[i].Spec.NodeName == nodeName && podList.Items[i].Status.Phase == corev1.PodRunning {
+			return &podList.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no running chaos helper on node %s, deploy it with DeployChaosHelperUntilReady first", nodeName)
+}
+
+// ExecInNodeNetns executes the command in the host network namespace of the
+// node, the command must not contain single quotes.
+func ExecInNodeNetns(ctx context.Context, frame *e2e.Framework, nodeName, command string) ([]byte, error) {
+	helper, err := getChaosHelperPod(frame, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	command = fmt.Sprintf("sh -c '%s'", command)
+	GinkgoWriter.Printf("execute on node %v: %v \n", nodeName, command)
+	return frame.ExecCommandInPod(helper.Name, helper.Namespace, command, ctx)
+}
+
+// ExecInPodNetns executes the command in the network namespace of the Pod's
+// first container, the command must not contain any quotes.
+func ExecInPodNetns(ctx context.Context, frame *e2e.Framework, pod *corev1.Pod, command string) ([]byte, error) {
+	if pod == nil || len(pod.Status.ContainerStatuses) == 0 {
+		return nil, e2e.ErrWrongInput
+	}
+	// e.g. containerd://<id>
+	containerID := pod.Status.ContainerStatuses[0].ContainerID
+	if idx := strings.Index(containerID, "://"); idx != -1 {
+		containerID = containerID[idx+3:]
+	}
+	if containerID == "" {
+		return nil, fmt.Errorf("the container of pod %s/%s is not started", pod.Namespace, pod.Name)
+	}
+
+	helper, err := getChaosHelperPod(frame, pod.Spec.NodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	// find the process of the container by its cgroup
+	script := fmt.Sprintf(`pid=$(grep -l %s /proc/[0-9]*/cgroup | head -n 1 | cut -d / -f 3) && [ -n "$pid" ] && nsenter --net=/proc/$pid/ns/net -- sh -c "%s"`,
+		containerID, command)
+	command = fmt.Sprintf("sh -c '%s'", script)
+	GinkgoWriter.Printf("execute in pod %v/%v: %v \n", pod.Namespace, pod.Name, command)
+	return frame.ExecCommandInPod(helper.Name, helper.Namespace, command, ctx)
+}
+
+// WaitNodeNetnsOutputMatch executes the command in the host network namespace
+// of the node repeatedly, until its output matches or the ctx is done.
+func WaitNodeNetnsOutputMatch(ctx context.Context, frame *e2e.Framework, nodeName, command string, match func(output string) bool) error {
+	return waitOutputMatch(ctx, command, match, func() ([]byte, error) {
+		return ExecInNodeNetns(ctx, frame, nodeName, command)
+	})
+}
+
+// WaitPodNetnsOutputMatch executes the command in the network namespace of the
+// Pod repeatedly, until its output matches or the ctx is done.
+func WaitPodNetnsOutputMatch(ctx context.Context, frame *e2e.Framework, pod *corev1.Pod, command string, match func(output string) bool) error {
+	return waitOutputMatch(ctx, command, match, func() ([]byte, error) {
+		return ExecInPodNetns(ctx, frame, pod, command)
+	})
+}
+
+func waitOutputMatch(ctx context.Context, command string, match func(output string) bool, exec func() ([]byte, error)) error {
+	var output []byte
+	var err error
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("time out to wait the output of %q to match, last output: %s, error: %v", command, output, err)
+		default:
+			output, err = exec()
+			if err == nil && match(string(output)) {
+				return nil
+			}
+			time.Sleep(ForcedWaitingTime)
+		}
+	}
+}
@@ -32,6 +32,7 @@ const (
 	BatchCreateTimeout         = time.Minute * 5
 	KdoctorCheckTime           = time.Minute * 10
 	SpiderSyncMultusTime       = time.Minute * 2
+	RouteRepairTimeout         = time.Minute * 2
 )
 
 var ForcedWaitingTime = time.Second
@@ -64,6 +65,10 @@ var (
 	SpiderDoctorAgentNs     = "kube-system"
 	SpiderDoctorAgentDSName = "spiderdoctor-agent"
 
+	// the policy routing table and the rule of coordinator on the node
+	HostRuleTable    = 500
+	HostRulePriority = 1000
+
 	// gateway and check for ip conflicting machines
 	VlanGatewayContainer = "vlan-gateway"
 
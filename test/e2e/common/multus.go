@@ -0,0 +1,140 @@
+// Copyright 2023 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+
+	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	frame "github.com/spidernet-io/e2eframework/framework"
+
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+)
+
+// CNIConflist is the CNI config list of a NetworkAttachmentDefinition
+type CNIConflist struct {
+	CNIVersion string      `json:"cniVersion"`
+	Name       string      `json:"name"`
+	Plugins    []CNIPlugin `json:"plugins"`
+}
+
+// CNIPlugin is a plugin of the CNI config list, the fields specific to the
+// CNI type are kept in Raw
+type CNIPlugin struct {
+	Type string          `json:"type"`
+	IPAM *CNIPluginIPAM  `json:"ipam,omitempty"`
+	Raw  json.RawMessage `json:"-"`
+}
+
+// CNIPluginIPAM is the IPAM of a plugin
+type CNIPluginIPAM struct {
+	Type string `json:"type"`
+}
+
+func (p *CNIPlugin) UnmarshalJSON(data []byte) error {
+	type plugin CNIPlugin
+	var out plugin
+	if err := json.Unmarshal(data, &out); err != nil {
+		return err
+	}
+	*p = CNIPlugin(out)
+	p.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// GetGeneratedNAD returns the NetworkAttachmentDefinition generated for the
+// SpiderMultusConfig, it fails if the NetworkAttachmentDefinition isn't owned
+// by a SpiderMultusConfig.
+func GetGeneratedNAD(f *frame.Framework, namespace, name string) (*netv1.NetworkAttachmentDefinition, error) {
+	nad, err := f.GetMultusInstance(name, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, owner := range nad.OwnerReferences {
+		if owner.Kind == constant.KindSpiderMultusConfig {
+			return nad, nil
+		}
+	}
+	return nil, fmt.Errorf("NetworkAttachmentDefinition %s/%s is not generated by SpiderMultusConfig", namespace, name)
+}
+
+// ParseCNIConflist parses the CNI config list of the NetworkAttachmentDefinition
+func ParseCNIConflist(nad *netv1.NetworkAttachmentDefinition) (*CNIConflist, error) {
+	if nad == nil {
+		return nil, fmt.Errorf("nil NetworkAttachmentDefinition")
+	}
+
+	var conflist CNIConflist
+	if err := json.Unmarshal([]byte(nad.Spec.Config), &conflist); err != nil {
+		return nil, fmt.Errorf("failed to parse the CNI config of NetworkAttachmentDefinition %s/%s: %w", nad.Namespace, nad.Name, err)
+	}
+	return &conflist, nil
+}
+
+// HaveChainedPlugin succeeds if the CNI config list, or the one of the
+// NetworkAttachmentDefinition, has a plugin of the cniType
+func HaveChainedPlugin(cniType string) types.GomegaMatcher {
+	return &conflistMatcher{
+		description: fmt.Sprintf("have the chained plugin %q", cniType),
+		match: func(conflist *CNIConflist) bool {
+			for _, plugin := range conflist.Plugins {
+				if plugin.Type == cniType {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// HaveIPAMType succeeds if a plugin of the CNI config list, or the one of the
+// NetworkAttachmentDefinition, has the IPAM of the ipamType
+func HaveIPAMType(ipamType string) types.GomegaMatcher {
+	return &conflistMatcher{
+		description: fmt.Sprintf("have the IPAM type %q", ipamType),
+		match: func(conflist *CNIConflist) bool {
+			for _, plugin := range conflist.Plugins {
+				if plugin.IPAM != nil && plugin.IPAM.Type == ipamType {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+type conflistMatcher struct {
+	description string
+	match       func(conflist *CNIConflist) bool
+}
+
+func (m *conflistMatcher) Match(actual interface{}) (bool, error) {
+	var conflist *CNIConflist
+	switch v := actual.(type) {
+	case *CNIConflist:
+		conflist = v
+	case *netv1.NetworkAttachmentDefinition:
+		var err error
+		if conflist, err = ParseCNIConflist(v); err != nil {
+			return false, err
+		}
+	default:
+		return false, fmt.Errorf("expects a *CNIConflist or *NetworkAttachmentDefinition, got %T", actual)
+	}
+	if conflist == nil {
+		return false, fmt.Errorf("nil CNIConflist")
+	}
+	return m.match(conflist), nil
+}
+
+func (m *conflistMatcher) FailureMessage(actual interface{}) string {
+	return format.Message(actual, "to "+m.description)
+}
+
+func (m *conflistMatcher) NegatedFailureMessage(actual interface{}) string {
+	return format.Message(actual, "not to "+m.description)
+}
@@ -7,6 +7,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -821,3 +823,53 @@ func WaitWebhookReady(ctx context.Context, f *frame.Framework, webhookPort strin
 		}
 	}
 }
+
+// GetIPPoolMetricValue scrapes the metrics of the spiderpool-controller via its
+// Service and returns the value of the metric of the IPPool. Only the leader
+// reports the IPPool metrics, so the caller should retry on error.
+func GetIPPoolMetricValue(ctx context.Context, f *frame.Framework, metricName, poolName string) (int64, error) {
+	nodeList, err := f.GetNodeList()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get node information")
+	}
+
+	serviceObj, err := f.GetService(constant.SpiderpoolController, SpiderPoolConfigmapNameSpace)
+	if err != nil {
+		return 0, fmt.Errorf("failed to obtain service information, unable to obtain cluster IP")
+	}
+	var metricsPort int32
+	for _, port := range serviceObj.Spec.Ports {
+		if port.Name == "metrics" {
+			metricsPort = port.Port
+		}
+	}
+	if metricsPort == 0 {
+		return 0, fmt.Errorf("no metrics port in the service %s", serviceObj.Name)
+	}
+
+	var scrape string
+	if f.Info.IpV6Enabled && !f.Info.IpV4Enabled {
+		scrape = fmt.Sprintf("curl -s -m 3 -g http://[%s]:%d/metrics", serviceObj.Spec.ClusterIP, metricsPort)
+	} else {
+		scrape = fmt.Sprintf("curl -s -m 3 http://%s:%d/metrics", serviceObj.Spec.ClusterIP, metricsPort)
+	}
+	out, err := f.DockerExecCommand(ctx, nodeList.Items[0].Name, scrape)
+	if err != nil {
+		return 0, fmt.Errorf("failed to scrape the metrics, error: %v, output: %s", err, string(out))
+	}
+
+	// e.g. spiderpool_ippool_allocated_ips{family="IPv4",pool="v4pool",subnet=""} 1
+	poolLabel := fmt.Sprintf(`pool="%s"`, poolName)
+	for _, line := range strings.Split(string(out), "\n") {
+		if !strings.HasPrefix(line, metricName+"{") || !strings.Contains(line, poolLabel) {
+			continue
+		}
+		fields := strings.Fields(line)
+		value, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid metric %q: %v", line, err)
+		}
+		return int64(value), nil
+	}
+	return 0, fmt.Errorf("no metric %s of the IPPool %s", metricName, poolName)
+}
@@ -24,6 +24,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/spidernet-io/e2eframework/tools"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apitypes "k8s.io/apimachinery/pkg/types"
 )
@@ -592,4 +593,344 @@ var _ = Describe("MacvlanOverlayOne", Label("overlay", "one-nic", "coordinator")
 			}, common.PodStartTimeout, common.ForcedWaitingTime).Should(BeTrue())
 		})
 	})
+
+	Context("routeTableMode decides whether the routes of the NIC are moved or copied", func() {
+		var v4PoolName, v6PoolName, namespace, depName, mode, podCidrType string
+
+		BeforeEach(func() {
+			mode = "overlay"
+			podCidrType = "cluster"
+			namespace = "ns-" + common.GenerateString(10, true)
+			depName = "dep-name-" + common.GenerateString(10, true)
+
+			err := frame.CreateNamespaceUntilDefaultServiceAccountReady(namespace, common.ServiceAccountReadyTimeout)
+			Expect(err).NotTo(HaveOccurred())
+
+			var v4PoolObj, v6PoolObj *spiderpoolv2beta1.SpiderIPPool
+			if frame.Info.IpV4Enabled {
+				v4PoolName, v4PoolObj = common.GenerateExampleIpv4poolObject(1)
+				gateway := strings.Split(v4PoolObj.Spec.Subnet, "0/")[0] + "1"
+				v4PoolObj.Spec.Gateway = &gateway
+				err = common.CreateIppool(frame, v4PoolObj)
+				Expect(err).NotTo(HaveOccurred(), "failed to create v4 ippool, error is: %v", err)
+			}
+			if frame.Info.IpV6Enabled {
+				v6PoolName, v6PoolObj = common.GenerateExampleIpv6poolObject(1)
+				gateway := strings.Split(v6PoolObj.Spec.Subnet, "/")[0] + "1"
+				v6PoolObj.Spec.Gateway = &gateway
+				err = common.CreateIppool(frame, v6PoolObj)
+				Expect(err).NotTo(HaveOccurred(), "failed to create v6 ippool, error is: %v", err)
+			}
+
+			DeferCleanup(func() {
+				GinkgoWriter.Printf("delete namespace %v. \n", namespace)
+				Expect(frame.DeleteNamespace(namespace)).NotTo(HaveOccurred())
+
+				if frame.Info.IpV4Enabled {
+					GinkgoWriter.Printf("delete v4 ippool %v. \n", v4PoolName)
+					Expect(common.DeleteIPPoolByName(frame, v4PoolName)).NotTo(HaveOccurred())
+				}
+				if frame.Info.IpV6Enabled {
+					GinkgoWriter.Printf("delete v6 ippool %v. \n", v6PoolName)
+					Expect(common.DeleteIPPoolByName(frame, v6PoolName)).NotTo(HaveOccurred())
+				}
+			})
+		})
+
+		// createPodWithRouteTableMode creates a deployment attached to a SpiderMultusConfig
+		// with the given routeTableMode, and returns the routes in the main table and the
+		// policy routing table of the pod's net1.
+		createPodWithRouteTableMode := func(routeTableMode string) (mainRoutes, tableRoutes []string) {
+			multusNadName := "test-multus-" + common.GenerateString(10, true)
+			nad := &spiderpoolv2beta1.SpiderMultusConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      multusNadName,
+					Namespace: namespace,
+				},
+				Spec: spiderpoolv2beta1.MultusCNIConfigSpec{
+					CniType: "macvlan",
+					MacvlanConfig: &spiderpoolv2beta1.SpiderMacvlanCniConfig{
+						Master: []string{common.NIC1},
+					},
+					CoordinatorConfig: &spiderpoolv2beta1.CoordinatorSpec{
+						Mode:               &mode,
+						PodCIDRType:        &podCidrType,
+						PodDefaultRouteNIC: &common.NIC1,
+						RouteTableMode:     &routeTableMode,
+					},
+				},
+			}
+			Expect(frame.CreateSpiderMultusInstance(nad)).NotTo(HaveOccurred())
+
+			podIppoolsAnno := types.AnnoPodIPPoolsValue{
+				types.AnnoIPPoolItem{
+					NIC: common.NIC2,
+				},
+			}
+			if frame.Info.IpV4Enabled {
+				podIppoolsAnno[0].IPv4Pools = []string{v4PoolName}
+			}
+			if frame.Info.IpV6Enabled {
+				podIppoolsAnno[0].IPv6Pools = []string{v6PoolName}
+			}
+			podAnnoMarshal, err := json.Marshal(podIppoolsAnno)
+			Expect(err).NotTo(HaveOccurred())
+
+			var annotations = make(map[string]string)
+			annotations[common.MultusNetworks] = fmt.Sprintf("%s/%s", namespace, multusNadName)
+			annotations[constant.AnnoPodIPPools] = string(podAnnoMarshal)
+			deployObject := common.GenerateExampleDeploymentYaml(depName, namespace, int32(1))
+			deployObject.Spec.Template.Annotations = annotations
+			Expect(frame.CreateDeployment(deployObject)).NotTo(HaveOccurred())
+
+			ctx, cancel := context.WithTimeout(context.Background(), common.PodStartTimeout)
+			defer cancel()
+			depObject, err := frame.WaitDeploymentReady(depName, namespace, ctx)
+			Expect(err).NotTo(HaveOccurred(), "waiting for deploy ready failed, error is: %v ", err)
+			podList, err := frame.GetPodListByLabel(depObject.Spec.Template.Labels)
+			Expect(err).NotTo(HaveOccurred(), "failed to get podList, error is: %v ", err)
+
+			// the routes of net1 in the main table and in its policy routing table 101
+			mainCommandString := fmt.Sprintf("ip r show table main dev %s ; ip -6 r show table main dev %s | grep -v fe80", common.NIC2, common.NIC2)
+			tableCommandString := fmt.Sprintf("ip r show table 101 dev %s ; ip -6 r show table 101 dev %s | grep -v fe80", common.NIC2, common.NIC2)
+			ctx, cancel = context.WithTimeout(context.Background(), common.ExecCommandTimeout)
+			defer cancel()
+			mainData, err := frame.ExecCommandInPod(podList.Items[0].Name, podList.Items[0].Namespace, mainCommandString, ctx)
+			Expect(err).NotTo(HaveOccurred(), "failed to execute command %v, error is: %v ", mainCommandString, err)
+			tableData, err := frame.ExecCommandInPod(podList.Items[0].Name, podList.Items[0].Namespace, tableCommandString, ctx)
+			Expect(err).NotTo(HaveOccurred(), "failed to execute command %v, error is: %v ", tableCommandString, err)
+			GinkgoWriter.Printf("routeTableMode %v, main table: %v, table 101: %v \n", routeTableMode, string(mainData), string(tableData))
+
+			return routeSummaries(string(mainData)), routeSummaries(string(tableData))
+		}
+
+		It("the routes of the NIC should be moved out of the main table in move mode", Label("C00011"), func() {
+			mainRoutes, tableRoutes := createPodWithRouteTableMode("move")
+			Expect(mainRoutes).To(BeEmpty(), "the routes of %s should be moved out of the main table", common.NIC2)
+			Expect(tableRoutes).NotTo(BeEmpty(), "the routes of %s should be moved to the table 101", common.NIC2)
+		})
+
+		It("the routes of the NIC should be kept in the main table in copy mode", Label("C00012"), func() {
+			mainRoutes, tableRoutes := createPodWithRouteTableMode("copy")
+			Expect(mainRoutes).NotTo(BeEmpty(), "the routes of %s should be kept in the main table", common.NIC2)
+			// the copied routes are tagged with the spiderpool protocol, so only
+			// compare their destinations, gateways and scopes
+			Expect(tableRoutes).To(ConsistOf(mainRoutes), "the routes of %s should be copied to the table 101", common.NIC2)
+		})
+	})
+
+	Context("the routes and rules of hostRuleTable deleted by others should be repaired", Serial, func() {
+		var v4PoolName, v6PoolName, namespace, depName, multusNadName, mode, podCidrType string
+		var pod *corev1.Pod
+		var podIPs []string
+
+		BeforeEach(func() {
+			mode = "overlay"
+			podCidrType = "cluster"
+			namespace = "ns-" + common.GenerateString(10, true)
+			depName = "dep-name-" + common.GenerateString(10, true)
+			multusNadName = "test-multus-" + common.GenerateString(10, true)
+			podIPs = nil
+
+			err := frame.CreateNamespaceUntilDefaultServiceAccountReady(namespace, common.ServiceAccountReadyTimeout)
+			Expect(err).NotTo(HaveOccurred())
+
+			var v4PoolObj, v6PoolObj *spiderpoolv2beta1.SpiderIPPool
+			if frame.Info.IpV4Enabled {
+				v4PoolName, v4PoolObj = common.GenerateExampleIpv4poolObject(1)
+				gateway := strings.Split(v4PoolObj.Spec.Subnet, "0/")[0] + "1"
+				v4PoolObj.Spec.Gateway = &gateway
+				err = common.CreateIppool(frame, v4PoolObj)
+				Expect(err).NotTo(HaveOccurred(), "failed to create v4 ippool, error is: %v", err)
+			}
+			if frame.Info.IpV6Enabled {
+				v6PoolName, v6PoolObj = common.GenerateExampleIpv6poolObject(1)
+				gateway := strings.Split(v6PoolObj.Spec.Subnet, "/")[0] + "1"
+				v6PoolObj.Spec.Gateway = &gateway
+				err = common.CreateIppool(frame, v6PoolObj)
+				Expect(err).NotTo(HaveOccurred(), "failed to create v6 ippool, error is: %v", err)
+			}
+
+			nad := &spiderpoolv2beta1.SpiderMultusConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      multusNadName,
+					Namespace: namespace,
+				},
+				Spec: spiderpoolv2beta1.MultusCNIConfigSpec{
+					CniType: "macvlan",
+					MacvlanConfig: &spiderpoolv2beta1.SpiderMacvlanCniConfig{
+						Master: []string{common.NIC1},
+					},
+					CoordinatorConfig: &spiderpoolv2beta1.CoordinatorSpec{
+						Mode:               &mode,
+						PodCIDRType:        &podCidrType,
+						PodDefaultRouteNIC: &common.NIC1,
+					},
+				},
+			}
+			Expect(frame.CreateSpiderMultusInstance(nad)).NotTo(HaveOccurred())
+
+			DeferCleanup(func() {
+				GinkgoWriter.Printf("delete spiderMultusConfig %v/%v. \n", namespace, multusNadName)
+				Expect(frame.DeleteSpiderMultusInstance(namespace, multusNadName)).NotTo(HaveOccurred())
+
+				GinkgoWriter.Printf("delete namespace %v. \n", namespace)
+				Expect(frame.DeleteNamespace(namespace)).NotTo(HaveOccurred())
+
+				if frame.Info.IpV4Enabled {
+					GinkgoWriter.Printf("delete v4 ippool %v. \n", v4PoolName)
+					Expect(common.DeleteIPPoolByName(frame, v4PoolName)).NotTo(HaveOccurred())
+				}
+				if frame.Info.IpV6Enabled {
+					GinkgoWriter.Printf("delete v6 ippool %v. \n", v6PoolName)
+					Expect(common.DeleteIPPoolByName(frame, v6PoolName)).NotTo(HaveOccurred())
+				}
+			})
+
+			podIppoolsAnno := types.AnnoPodIPPoolsValue{
+				types.AnnoIPPoolItem{
+					NIC: common.NIC2,
+				},
+			}
+			if frame.Info.IpV4Enabled {
+				podIppoolsAnno[0].IPv4Pools = []string{v4PoolName}
+			}
+			if frame.Info.IpV6Enabled {
+				podIppoolsAnno[0].IPv6Pools = []string{v6PoolName}
+			}
+			podAnnoMarshal, err := json.Marshal(podIppoolsAnno)
+			Expect(err).NotTo(HaveOccurred())
+
+			var annotations = make(map[string]string)
+			annotations[common.MultusNetworks] = fmt.Sprintf("%s/%s", namespace, multusNadName)
+			annotations[constant.AnnoPodIPPools] = string(podAnnoMarshal)
+			deployObject := common.GenerateExampleDeploymentYaml(depName, namespace, int32(1))
+			deployObject.Spec.Template.Annotations = annotations
+			Expect(frame.CreateDeployment(deployObject)).NotTo(HaveOccurred())
+
+			ctx, cancel := context.WithTimeout(context.Background(), common.PodStartTimeout)
+			defer cancel()
+			depObject, err := frame.WaitDeploymentReady(depName, namespace, ctx)
+			Expect(err).NotTo(HaveOccurred(), "waiting for deploy ready failed, error is: %v ", err)
+			podList, err := frame.GetPodListByLabel(depObject.Spec.Template.Labels)
+			Expect(err).NotTo(HaveOccurred(), "failed to get podList, error is: %v ", err)
+			Expect(podList.Items).NotTo(BeEmpty())
+			pod = &podList.Items[0]
+
+			// the routes to the IPs of net1 are installed in hostRuleTable on the node
+			endpoint, err := common.GetWorkloadByName(frame, namespace, pod.Name)
+			Expect(err).NotTo(HaveOccurred(), "failed to get the endpoint of pod %v/%v, error is: %v ", namespace, pod.Name, err)
+			for _, detail := range endpoint.Status.Current.IPs {
+				if detail.NIC != common.NIC2 {
+					continue
+				}
+				for _, address := range []*string{detail.IPv4, detail.IPv6} {
+					if address == nil {
+						continue
+					}
+					ip, _, err := net.ParseCIDR(*address)
+					Expect(err).NotTo(HaveOccurred())
+					podIPs = append(podIPs, ip.String())
+				}
+			}
+			Expect(podIPs).NotTo(BeEmpty(), "no ip of %v is found in the endpoint %v/%v", common.NIC2, namespace, pod.Name)
+
+			Expect(common.DeployChaosHelperUntilReady(ctx, frame)).NotTo(HaveOccurred())
+		})
+
+		// ipFamilyFlag returns the flag of the ip command for the IP address
+		ipFamilyFlag := func(ip string) string {
+			if net.ParseIP(ip).To4() != nil {
+				return "-4"
+			}
+			return "-6"
+		}
+
+		// checkNodeToPod waits for the node to reach the IPs of the pod through hostRuleTable
+		checkNodeToPod := func(ctx context.Context) {
+			for _, ip := range podIPs {
+				pingCommand := fmt.Sprintf("ping %s -c 2 -W 2 %s", ipFamilyFlag(ip), ip)
+				err := common.WaitNodeNetnsOutputMatch(ctx, frame, pod.Spec.NodeName, pingCommand, func(string) bool { return true })
+				Expect(err).NotTo(HaveOccurred(), "node %v failed to reach the pod ip %v", pod.Spec.NodeName, ip)
+			}
+		}
+
+		It("the rule of hostRuleTable deleted from the node should be repaired", Label("C00013"), func() {
+			ctx, cancel := context.WithTimeout(context.Background(), common.RouteRepairTimeout)
+			defer cancel()
+			checkNodeToPod(ctx)
+
+			families := map[string]struct{}{}
+			for _, ip := range podIPs {
+				families[ipFamilyFlag(ip)] = struct{}{}
+			}
+			for family := range families {
+				delCommand := fmt.Sprintf("ip %s rule del pref %d table %d", family, common.HostRulePriority, common.HostRuleTable)
+				output, err := common.ExecInNodeNetns(ctx, frame, pod.Spec.NodeName, delCommand)
+				Expect(err).NotTo(HaveOccurred(), "failed to delete the rule, output: %s", output)
+
+				showCommand := fmt.Sprintf("ip %s rule show", family)
+				err = common.WaitNodeNetnsOutputMatch(ctx, frame, pod.Spec.NodeName, showCommand, func(output string) bool {
+					for _, line := range strings.Split(output, "\n") {
+						if strings.HasPrefix(line, fmt.Sprintf("%d:", common.HostRulePriority)) && strings.Contains(line, fmt.Sprintf("lookup %d", common.HostRuleTable)) {
+							return true
+						}
+					}
+					return false
+				})
+				Expect(err).NotTo(HaveOccurred(), "the rule of table %v is not repaired", common.HostRuleTable)
+			}
+
+			checkNodeToPod(ctx)
+		})
+
+		It("the routes to the pod deleted from hostRuleTable on the node should be repaired", Label("C00014"), func() {
+			ctx, cancel := context.WithTimeout(context.Background(), common.RouteRepairTimeout)
+			defer cancel()
+			checkNodeToPod(ctx)
+
+			for _, ip := range podIPs {
+				delCommand := fmt.Sprintf("ip %s route del %s table %d", ipFamilyFlag(ip), ip, common.HostRuleTable)
+				output, err := common.ExecInNodeNetns(ctx, frame, pod.Spec.NodeName, delCommand)
+				Expect(err).NotTo(HaveOccurred(), "failed to delete the route, output: %s", output)
+
+				showCommand := fmt.Sprintf("ip %s route show table %d", ipFamilyFlag(ip), common.HostRuleTable)
+				err = common.WaitNodeNetnsOutputMatch(ctx, frame, pod.Spec.NodeName, showCommand, func(output string) bool {
+					for _, line := range strings.Split(output, "\n") {
+						if strings.HasPrefix(line, ip+" ") {
+							return true
+						}
+					}
+					return false
+				})
+				Expect(err).NotTo(HaveOccurred(), "the route to %v in table %v is not repaired", ip, common.HostRuleTable)
+			}
+
+			checkNodeToPod(ctx)
+		})
+	})
 })
+
+// routeSummaries extracts the destination, the gateway and the scope of each
+// route printed by 'ip route', e.g. "10.6.0.0/16 via=<nil> scope=link".
+func routeSummaries(output string) []string {
+	var summaries []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		via, scope := "<nil>", "global"
+		for i := 1; i < len(fields)-1; i++ {
+			switch fields[i] {
+			case "via":
+				via = fields[i+1]
+			case "scope":
+				scope = fields[i+1]
+			}
+		}
+		summaries = append(summaries, fmt.Sprintf("%s via=%s scope=%s", fields[0], via, scope))
+	}
+	return summaries
+}
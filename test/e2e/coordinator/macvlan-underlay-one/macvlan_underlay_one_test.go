@@ -6,15 +6,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/spidernet-io/e2eframework/tools"
 	spiderdoctorV1 "github.com/spidernet-io/spiderdoctor/pkg/k8s/apis/spiderdoctor.spidernet.io/v1"
+	spiderpoolv2beta1 "github.com/spidernet-io/spiderpool/pkg/k8s/apis/spiderpool.spidernet.io/v2beta1"
 	"github.com/spidernet-io/spiderpool/test/e2e/common"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 )
 
 var _ = Describe("MacvlanUnderlayOne", Serial, Label("underlay", "one-interface", "coordinator"), func() {
@@ -136,3 +141,121 @@ var _ = Describe("MacvlanUnderlayOne", Serial, Label("underlay", "one-interface"
 		Expect(err1).NotTo(HaveOccurred())
 	})
 })
+
+var _ = Describe("MacvlanUnderlayOne with enableProxyNDP", Serial, Label("underlay", "one-interface", "coordinator"), func() {
+	var namespace, depName, multusNadName string
+	var pods []corev1.Pod
+
+	BeforeEach(func() {
+		namespace = "ns-" + common.GenerateString(10, true)
+		depName = "dep-name-" + common.GenerateString(10, true)
+		multusNadName = "test-multus-" + common.GenerateString(10, true)
+
+		err := frame.CreateNamespaceUntilDefaultServiceAccountReady(namespace, common.ServiceAccountReadyTimeout)
+		Expect(err).NotTo(HaveOccurred())
+
+		mode := "underlay"
+		nad := &spiderpoolv2beta1.SpiderMultusConfig{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      multusNadName,
+				Namespace: namespace,
+			},
+			Spec: spiderpoolv2beta1.MultusCNIConfigSpec{
+				CniType: "macvlan",
+				MacvlanConfig: &spiderpoolv2beta1.SpiderMacvlanCniConfig{
+					Master: []string{common.NIC1},
+				},
+				CoordinatorConfig: &spiderpoolv2beta1.CoordinatorSpec{
+					Mode:           &mode,
+					EnableProxyNDP: pointer.Bool(true),
+				},
+			},
+		}
+		Expect(frame.CreateSpiderMultusInstance(nad)).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			GinkgoWriter.Printf("delete spiderMultusConfig %v/%v. \n", namespace, multusNadName)
+			Expect(frame.DeleteSpiderMultusInstance(namespace, multusNadName)).NotTo(HaveOccurred())
+
+			GinkgoWriter.Printf("delete namespace %v. \n", namespace)
+			Expect(frame.DeleteNamespace(namespace)).NotTo(HaveOccurred())
+		})
+
+		nodeList, err := frame.GetNodeList()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodeList.Items).NotTo(BeEmpty())
+
+		// both the pods are on the same node, so that they reach each other via the host veths
+		deployObject := common.GenerateExampleDeploymentYaml(depName, namespace, int32(2))
+		deployObject.Spec.Template.Annotations = map[string]string{
+			common.MultusDefaultNetwork: fmt.Sprintf("%s/%s", namespace, multusNadName),
+		}
+		deployObject.Spec.Template.Spec.NodeName = nodeList.Items[0].Name
+		Expect(frame.CreateDeployment(deployObject)).NotTo(HaveOccurred())
+
+		ctx, cancel := context.WithTimeout(context.Background(), common.PodStartTimeout)
+		defer cancel()
+		depObject, err := frame.WaitDeploymentReady(depName, namespace, ctx)
+		Expect(err).NotTo(HaveOccurred(), "waiting for deploy ready failed, error is: %v ", err)
+		podList, err := frame.GetPodListByLabel(depObject.Spec.Template.Labels)
+		Expect(err).NotTo(HaveOccurred(), "failed to get podList, error is: %v ", err)
+		Expect(podList.Items).To(HaveLen(2))
+		pods = podList.Items
+
+		Expect(common.DeployChaosHelperUntilReady(ctx, frame)).NotTo(HaveOccurred())
+	})
+
+	It("the pod should be reached via the host veth with proxy_arp and the proxy neighbors", Label("C00015"), func() {
+		ctx, cancel := context.WithTimeout(context.Background(), common.ExecCommandTimeout)
+		defer cancel()
+
+		first, second := &pods[0], &pods[1]
+		nodeName := first.Spec.NodeName
+		for _, podIP := range first.Status.PodIPs {
+			ip := podIP.IP
+			if net.ParseIP(ip).To4() != nil {
+				// the host veth of the second pod answers the ARP for the first pod by proxy_arp,
+				// since the route to the first pod on the node is via its own host veth
+				hostVethCommand := fmt.Sprintf("ip -4 route get %s | grep -o \"dev [^ ]*\" | cut -d \" \" -f 2", common.GetPodIPv4Address(second).IP)
+				output, err := common.ExecInNodeNetns(ctx, frame, nodeName, hostVethCommand)
+				Expect(err).NotTo(HaveOccurred(), "failed to get the host veth of pod %v, output: %s", second.Name, output)
+				hostVeth := strings.TrimSpace(string(output))
+				Expect(hostVeth).NotTo(BeEmpty())
+
+				proxyARPCommand := fmt.Sprintf("cat /proc/sys/net/ipv4/conf/%s/proxy_arp", hostVeth)
+				output, err = common.ExecInNodeNetns(ctx, frame, nodeName, proxyARPCommand)
+				Expect(err).NotTo(HaveOccurred(), "failed to get proxy_arp of %v, output: %s", hostVeth, output)
+				Expect(strings.TrimSpace(string(output))).To(Equal("1"), "proxy_arp of %v should be enabled", hostVeth)
+
+				// force the second pod to reach the first one through veth0 and the node
+				pingCommand := fmt.Sprintf("ip route replace %s/32 dev veth0 && ping -c 2 -W 2 %s", ip, ip)
+				output, err = common.ExecInPodNetns(ctx, frame, second, pingCommand)
+				Expect(err).NotTo(HaveOccurred(), "pod %v failed to reach pod %v via the node, output: %s", second.Name, ip, output)
+				continue
+			}
+
+			proxyNeighCommand := fmt.Sprintf("ip -6 neigh show proxy %s", ip)
+			err := common.WaitNodeNetnsOutputMatch(ctx, frame, nodeName, proxyNeighCommand, func(output string) bool {
+				return strings.Contains(output, ip)
+			})
+			Expect(err).NotTo(HaveOccurred(), "the proxy neighbor of %v is not found on node %v", ip, nodeName)
+
+			pingCommand := fmt.Sprintf("ping -6 -c 2 -W 2 %s", ip)
+			output, err := common.ExecInPodNetns(ctx, frame, second, pingCommand)
+			Expect(err).NotTo(HaveOccurred(), "pod %v failed to reach pod %v, output: %s", second.Name, ip, output)
+		}
+
+		// the proxy neighbors are removed with the pod
+		Expect(frame.DeletePod(first.Name, first.Namespace)).NotTo(HaveOccurred())
+		for _, podIP := range first.Status.PodIPs {
+			if net.ParseIP(podIP.IP).To4() != nil {
+				continue
+			}
+			proxyNeighCommand := fmt.Sprintf("ip -6 neigh show proxy %s", podIP.IP)
+			err := common.WaitNodeNetnsOutputMatch(ctx, frame, nodeName, proxyNeighCommand, func(output string) bool {
+				return !strings.Contains(output, podIP.IP)
+			})
+			Expect(err).NotTo(HaveOccurred(), "the proxy neighbor of %v is not removed from node %v", podIP.IP, nodeName)
+		}
+	})
+})
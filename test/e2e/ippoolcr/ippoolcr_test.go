@@ -422,4 +422,57 @@ var _ = Describe("test ippool CR", Label("ippoolCR"), func() {
 			GinkgoWriter.Printf("Time cost to delete %v ipv6 ippools is %v \n", ippoolNumber, endT4)
 		}
 	})
+
+	It("the capacity metrics of the IPPool follow the IP allocation", Label("D00017"), func() {
+		const podName = "capacity-metrics"
+		poolName := v4PoolName
+		if !frame.Info.IpV4Enabled {
+			poolName = v6PoolName
+		}
+		var total int64
+		Eventually(func() bool {
+			pool, err := common.GetIppoolByName(frame, poolName)
+			if err != nil || pool.Status.TotalIPCount == nil {
+				return false
+			}
+			total = *pool.Status.TotalIPCount
+			return true
+		}).WithTimeout(time.Minute).WithPolling(time.Second).Should(BeTrue())
+
+		checkMetric := func(metricName string, expected int64) {
+			Eventually(func() (int64, error) {
+				ctx, cancel := context.WithTimeout(context.Background(), common.ExecCommandTimeout)
+				defer cancel()
+				return common.GetIPPoolMetricValue(ctx, frame, metricName, poolName)
+			}).WithTimeout(time.Minute).WithPolling(time.Second*3).Should(Equal(expected),
+				"unexpected metric %s of the IPPool %s", metricName, poolName)
+		}
+
+		By("the IPPool has no IP allocated")
+		checkMetric("spiderpool_ippool_total_ips", total)
+		checkMetric("spiderpool_ippool_allocated_ips", 0)
+		checkMetric("spiderpool_ippool_free_ips", total)
+
+		By("allocate an IP of the IPPool")
+		podIPPoolAnno := types.AnnoPodIPPoolValue{}
+		if frame.Info.IpV4Enabled {
+			podIPPoolAnno.IPv4Pools = []string{v4PoolName}
+		}
+		if frame.Info.IpV6Enabled {
+			podIPPoolAnno.IPv6Pools = []string{v6PoolName}
+		}
+		b, err := json.Marshal(podIPPoolAnno)
+		Expect(err).NotTo(HaveOccurred())
+		podYaml := common.GenerateExamplePodYaml(podName, nsName)
+		podYaml.Annotations = map[string]string{constant.AnnoPodIPPool: string(b)}
+		common.CreatePodUntilReady(frame, podYaml, podName, nsName, common.PodStartTimeout)
+
+		checkMetric("spiderpool_ippool_allocated_ips", 1)
+		checkMetric("spiderpool_ippool_free_ips", total-1)
+
+		By("release the IP of the IPPool")
+		Expect(frame.DeletePod(podName, nsName)).To(Succeed())
+		checkMetric("spiderpool_ippool_allocated_ips", 0)
+		checkMetric("spiderpool_ippool_free_ips", total)
+	})
 })
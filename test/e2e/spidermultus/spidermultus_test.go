@@ -74,24 +74,26 @@ var _ = Describe("test spidermultus", Label("spiderMultus", "overlay"), func() {
 			Expect(spiderMultusConfig).NotTo(BeNil())
 			GinkgoWriter.Printf("spiderMultusConfig %+v \n", spiderMultusConfig)
 
-			Eventually(func() bool {
-				multusConfig, err := frame.GetMultusInstance(spiderMultusNadName, namespace)
-				GinkgoWriter.Printf("Auto-generated multus configuration %+v \n", multusConfig)
-				if api_errors.IsNotFound(err) {
-					return false
-				}
-				// The automatically generated multus configuration should be associated with spidermultus
-				if multusConfig.ObjectMeta.OwnerReferences[0].Kind != constant.KindSpiderMultusConfig {
-					return false
-				}
-				return true
-			}, common.SpiderSyncMultusTime, common.ForcedWaitingTime).Should(BeTrue())
+			// The automatically generated multus configuration should be associated with spidermultus
+			var multusConfig *v1.NetworkAttachmentDefinition
+			Eventually(func() error {
+				multusConfig, err = common.GetGeneratedNAD(frame, namespace, spiderMultusNadName)
+				return err
+			}, common.SpiderSyncMultusTime, common.ForcedWaitingTime).Should(Succeed())
+			GinkgoWriter.Printf("Auto-generated multus configuration %+v \n", multusConfig)
+
+			conflist, err := common.ParseCNIConflist(multusConfig)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conflist.Name).To(Equal(spiderMultusNadName))
+			Expect(conflist).To(common.HaveChainedPlugin("macvlan"))
+			Expect(conflist).To(common.HaveChainedPlugin("coordinator"))
+			Expect(conflist).To(common.HaveIPAMType(constant.Spiderpool))
 
 			// Delete the multus configuration created automatically,
 			// and it will be restored automatically after a period of time.
 			err = frame.DeleteMultusInstance(spiderMultusNadName, namespace)
 			Expect(err).NotTo(HaveOccurred())
-			multusConfig, err := frame.GetMultusInstance(spiderMultusNadName, namespace)
+			multusConfig, err = frame.GetMultusInstance(spiderMultusNadName, namespace)
 			Expect(api_errors.IsNotFound(err)).To(BeTrue())
 			Expect(multusConfig).To(BeNil())
 
@@ -328,6 +330,31 @@ var _ = Describe("test spidermultus", Label("spiderMultus", "overlay"), func() {
 		Expect(err).To(HaveOccurred())
 	})
 
+	It("hostRuleTable collides with the reserved tables and will not be created", Label("M00025"), func() {
+		// the tables reserved by the kernel, and the ones of the pod's interfaces
+		for _, table := range []int{253, 254, 255, 100, 1000} {
+			smc := &spiderpoolv2beta1.SpiderMultusConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "multus-" + common.GenerateString(10, true),
+					Namespace: namespace,
+				},
+				Spec: spiderpoolv2beta1.MultusCNIConfigSpec{
+					CniType: "macvlan",
+					MacvlanConfig: &spiderpoolv2beta1.SpiderMacvlanCniConfig{
+						Master: []string{common.NIC1},
+					},
+					CoordinatorConfig: &spiderpoolv2beta1.CoordinatorSpec{
+						HostRuleTable: pointer.Int(table),
+					},
+				},
+			}
+			GinkgoWriter.Printf("spidermultus cr: %+v \n", smc)
+			err := frame.CreateSpiderMultusInstance(smc)
+			Expect(err).To(HaveOccurred(), "hostRuleTable %d should be rejected", table)
+			GinkgoWriter.Printf("should fail to create, the error is: %v \n", err.Error())
+		}
+	})
+
 	It("testing creating spiderMultusConfig with cniType: ipvlan and checking the net-attach-conf config if works", Label("M00002"), func() {
 		var smcName string = "ipvlan-" + common.GenerateString(10, true)
 